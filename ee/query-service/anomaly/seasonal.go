@@ -11,6 +11,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/postprocess"
 	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 var (
@@ -18,6 +19,14 @@ var (
 	movingAvgWindowSize = 7
 )
 
+const (
+	// DefaultQueryParallelism is the number of the queries of the anomaly detection
+	// run at the same time, the detection runs six queries
+	DefaultQueryParallelism = 2
+	// queryRetries is how many times the failed queries are run again
+	queryRetries = 1
+)
+
 // BaseProvider is an interface that includes common methods for all provider types
 type BaseProvider interface {
 	GetBaseSeasonalProvider() *BaseSeasonalProvider
@@ -44,6 +53,12 @@ func WithFeatureLookup[T BaseProvider](ff interfaces.FeatureLookup) GenericProvi
 	}
 }
 
+func WithQueryParallelism[T BaseProvider](n int) GenericProviderOption[T] {
+	return func(p T) {
+		p.GetBaseSeasonalProvider().queryParallelism = n
+	}
+}
+
 func WithReader[T BaseProvider](reader interfaces.Reader) GenericProviderOption[T] {
 	return func(p T) {
 		p.GetBaseSeasonalProvider().reader = reader
@@ -57,6 +72,9 @@ type BaseSeasonalProvider struct {
 	cache        cache.Cache
 	keyGenerator cache.KeyGenerator
 	ff           interfaces.FeatureLookup
+	// queryParallelism bounds the queries run at the same time so one detection
+	// doesn't take all the connections of the reader
+	queryParallelism int
 }
 
 func (p *BaseSeasonalProvider) getQueryParams(req *GetAnomaliesRequest) *anomalyQueryParams {
@@ -66,81 +84,80 @@ func (p *BaseSeasonalProvider) getQueryParams(req *GetAnomaliesRequest) *anomaly
 	return prepareAnomalyQueryParams(req.Params, req.Seasonality)
 }
 
-func (p *BaseSeasonalProvider) getResults(ctx context.Context, params *anomalyQueryParams) (*anomalyQueryResults, error) {
-	zap.L().Info("fetching results for current period", zap.Any("currentPeriodQuery", params.CurrentPeriodQuery))
-	currentPeriodResults, _, err := p.querierV2.QueryRange(ctx, params.CurrentPeriodQuery)
-	if err != nil {
-		return nil, err
-	}
-
-	currentPeriodResults, err = postprocess.PostProcessResult(currentPeriodResults, params.CurrentPeriodQuery)
-	if err != nil {
-		return nil, err
-	}
-
-	zap.L().Info("fetching results for past period", zap.Any("pastPeriodQuery", params.PastPeriodQuery))
-	pastPeriodResults, _, err := p.querierV2.QueryRange(ctx, params.PastPeriodQuery)
-	if err != nil {
-		return nil, err
-	}
-
-	pastPeriodResults, err = postprocess.PostProcessResult(pastPeriodResults, params.PastPeriodQuery)
-	if err != nil {
-		return nil, err
-	}
-
-	zap.L().Info("fetching results for current season", zap.Any("currentSeasonQuery", params.CurrentSeasonQuery))
-	currentSeasonResults, _, err := p.querierV2.QueryRange(ctx, params.CurrentSeasonQuery)
-	if err != nil {
-		return nil, err
-	}
-
-	currentSeasonResults, err = postprocess.PostProcessResult(currentSeasonResults, params.CurrentSeasonQuery)
-	if err != nil {
-		return nil, err
-	}
+// anomalyQuery is one of the queries of the anomaly detection, results is set
+// once the query succeeds
+type anomalyQuery struct {
+	name    string
+	params  *v3.QueryRangeParamsV3
+	results *[]*v3.Result
+	err     error
+}
 
-	zap.L().Info("fetching results for past season", zap.Any("pastSeasonQuery", params.PastSeasonQuery))
-	pastSeasonResults, _, err := p.querierV2.QueryRange(ctx, params.PastSeasonQuery)
-	if err != nil {
-		return nil, err
+func (p *BaseSeasonalProvider) getResults(ctx context.Context, params *anomalyQueryParams) (*anomalyQueryResults, error) {
+	results := &anomalyQueryResults{}
+	queries := []*anomalyQuery{
+		{name: "current period", params: params.CurrentPeriodQuery, results: &results.CurrentPeriodResults},
+		{name: "past period", params: params.PastPeriodQuery, results: &results.PastPeriodResults},
+		{name: "current season", params: params.CurrentSeasonQuery, results: &results.CurrentSeasonResults},
+		{name: "past season", params: params.PastSeasonQuery, results: &results.PastSeasonResults},
+		{name: "past 2 season", params: params.Past2SeasonQuery, results: &results.Past2SeasonResults},
+		{name: "past 3 season", params: params.Past3SeasonQuery, results: &results.Past3SeasonResults},
+	}
+
+	failed := p.runQueries(ctx, queries)
+	for retry := 0; len(failed) > 0; retry++ {
+		if retry == queryRetries || ctx.Err() != nil {
+			return nil, failed[0].err
+		}
+		zap.L().Warn("retrying the failed anomaly queries", zap.Int("failed", len(failed)), zap.Error(failed[0].err))
+		failed = p.runQueries(ctx, failed)
 	}
+	return results, nil
+}
 
-	pastSeasonResults, err = postprocess.PostProcessResult(pastSeasonResults, params.PastSeasonQuery)
-	if err != nil {
-		return nil, err
+// runQueries runs the queries with at most queryParallelism of them at the same
+// time and returns the failed ones. The failed query doesn't cancel the others
+// so only the failed queries are run again
+func (p *BaseSeasonalProvider) runQueries(ctx context.Context, queries []*anomalyQuery) []*anomalyQuery {
+	var g errgroup.Group
+	g.SetLimit(p.parallelism())
+	for _, q := range queries {
+		g.Go(func() error {
+			q.err = p.runQuery(ctx, q)
+			return q.err
+		})
 	}
+	_ = g.Wait()
 
-	zap.L().Info("fetching results for past 2 season", zap.Any("past2SeasonQuery", params.Past2SeasonQuery))
-	past2SeasonResults, _, err := p.querierV2.QueryRange(ctx, params.Past2SeasonQuery)
-	if err != nil {
-		return nil, err
+	var failed []*anomalyQuery
+	for _, q := range queries {
+		if q.err != nil {
+			failed = append(failed, q)
+		}
 	}
+	return failed
+}
 
-	past2SeasonResults, err = postprocess.PostProcessResult(past2SeasonResults, params.Past2SeasonQuery)
+func (p *BaseSeasonalProvider) runQuery(ctx context.Context, q *anomalyQuery) error {
+	zap.L().Info("fetching results for "+q.name, zap.Any("query", q.params))
+	results, _, err := p.querierV2.QueryRange(ctx, q.params)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	zap.L().Info("fetching results for past 3 season", zap.Any("past3SeasonQuery", params.Past3SeasonQuery))
-	past3SeasonResults, _, err := p.querierV2.QueryRange(ctx, params.Past3SeasonQuery)
+	results, err = postprocess.PostProcessResult(results, q.params)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	*q.results = results
+	return nil
+}
 
-	past3SeasonResults, err = postprocess.PostProcessResult(past3SeasonResults, params.Past3SeasonQuery)
-	if err != nil {
-		return nil, err
+func (p *BaseSeasonalProvider) parallelism() int {
+	if p.queryParallelism <= 0 {
+		return DefaultQueryParallelism
 	}
-
-	return &anomalyQueryResults{
-		CurrentPeriodResults: currentPeriodResults,
-		PastPeriodResults:    pastPeriodResults,
-		CurrentSeasonResults: currentSeasonResults,
-		PastSeasonResults:    pastSeasonResults,
-		Past2SeasonResults:   past2SeasonResults,
-		Past3SeasonResults:   past3SeasonResults,
-	}, nil
+	return p.queryParallelism
 }
 
 // getMatchingSeries gets the matching series from the query result
@@ -0,0 +1,96 @@
+package anomaly
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// flakyQuerier fails the first calls of the failing queries and tracks the
+// queries running at the same time
+type flakyQuerier struct {
+	mtx        sync.Mutex
+	calls      map[*v3.QueryRangeParamsV3]int
+	failing    map[*v3.QueryRangeParamsV3]int
+	running    int
+	maxRunning int
+}
+
+func (q *flakyQuerier) QueryRange(ctx context.Context, params *v3.QueryRangeParamsV3) ([]*v3.Result, map[string]error, error) {
+	q.mtx.Lock()
+	q.calls[params]++
+	calls := q.calls[params]
+	q.running++
+	q.maxRunning = max(q.maxRunning, q.running)
+	q.mtx.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	q.mtx.Lock()
+	q.running--
+	q.mtx.Unlock()
+	if calls <= q.failing[params] {
+		return nil, nil, errors.New("too many simultaneous queries")
+	}
+	return []*v3.Result{{QueryName: "A"}}, nil, nil
+}
+
+func (q *flakyQuerier) QueriesExecuted() []string { return nil }
+
+func (q *flakyQuerier) TimeRanges() [][]int { return nil }
+
+func newTestQueryParams() *v3.QueryRangeParamsV3 {
+	return &v3.QueryRangeParamsV3{CompositeQuery: &v3.CompositeQuery{PanelType: v3.PanelTypeGraph}}
+}
+
+func TestGetResultsRetriesFailedQueries(t *testing.T) {
+	params := &anomalyQueryParams{
+		CurrentPeriodQuery: newTestQueryParams(),
+		PastPeriodQuery:    newTestQueryParams(),
+		CurrentSeasonQuery: newTestQueryParams(),
+		PastSeasonQuery:    newTestQueryParams(),
+		Past2SeasonQuery:   newTestQueryParams(),
+		Past3SeasonQuery:   newTestQueryParams(),
+	}
+	querier := &flakyQuerier{
+		calls:   map[*v3.QueryRangeParamsV3]int{},
+		failing: map[*v3.QueryRangeParamsV3]int{params.PastSeasonQuery: 1},
+	}
+	p := &BaseSeasonalProvider{querierV2: querier, queryParallelism: 2}
+
+	results, err := p.getResults(context.Background(), params)
+	assert.NoError(t, err)
+	assert.Len(t, results.PastSeasonResults, 1)
+	assert.Len(t, results.Past3SeasonResults, 1)
+	assert.LessOrEqual(t, querier.maxRunning, 2)
+
+	// only the failed query is run again
+	assert.Equal(t, 2, querier.calls[params.PastSeasonQuery])
+	assert.Equal(t, 1, querier.calls[params.CurrentPeriodQuery])
+}
+
+func TestGetResultsFailsAfterRetry(t *testing.T) {
+	params := &anomalyQueryParams{
+		CurrentPeriodQuery: newTestQueryParams(),
+		PastPeriodQuery:    newTestQueryParams(),
+		CurrentSeasonQuery: newTestQueryParams(),
+		PastSeasonQuery:    newTestQueryParams(),
+		Past2SeasonQuery:   newTestQueryParams(),
+		Past3SeasonQuery:   newTestQueryParams(),
+	}
+	querier := &flakyQuerier{
+		calls: map[*v3.QueryRangeParamsV3]int{},
+		// the query fails again when retried
+		failing: map[*v3.QueryRangeParamsV3]int{params.CurrentPeriodQuery: 2},
+	}
+	p := &BaseSeasonalProvider{querierV2: querier}
+
+	_, err := p.getResults(context.Background(), params)
+	assert.Error(t, err)
+	assert.Equal(t, 2, querier.calls[params.CurrentPeriodQuery])
+}
@@ -757,17 +757,46 @@ func makeRulesManager(
 
 	// create manager opts
 	managerOpts := &baserules.ManagerOptions{
-		NotifierOpts: notifierOpts,
-		PqlEngine:    pqle,
-		RepoURL:      ruleRepoURL,
-		DBConn:       db,
-		Context:      context.Background(),
-		Logger:       zap.L(),
-		DisableRules: disableRules,
-		FeatureFlags: fm,
-		Reader:       ch,
-		Cache:        cache,
-		EvalDelay:    baseconst.GetEvalDelay(),
+		NotifierOpts:      notifierOpts,
+		PqlEngine:         pqle,
+		RepoURL:           ruleRepoURL,
+		DBConn:            db,
+		Context:           context.Background(),
+		Logger:            zap.L(),
+		DisableRules:      disableRules,
+		FeatureFlags:      fm,
+		Reader:            ch,
+		Cache:             cache,
+		EvalDelay:         baseconst.GetEvalDelay(),
+		EvalTimeout:       baseconst.GetEvalTimeout(),
+		RuleVariables:     baseconst.GetRuleVariables(),
+		ProvisioningDir:   baseconst.RulesProvisioningDir,
+		RuleEventWebhooks: baseconst.GetRuleEventWebhooks(),
+		RuleEvents:        baseconst.GetRuleEvents(),
+		IncidentChannels:  baseconst.GetIncidentChannels(),
+		ShardRules:        baseconst.ShardRules,
+		LeaderElection:    baseconst.RulesLeaderElection,
+		EvalConcurrency:   baseconst.RulesEvalConcurrency,
+		QueryParallelism:  baseconst.RulesQueryParallelism,
+
+		DisableQueryResultCache: !baseconst.RulesQueryResultCache,
+		TemporalityCacheTTL:     baseconst.GetTemporalityCacheTTL(),
+
+		BreakerThreshold:      baseconst.RulesBreakerThreshold,
+		BreakerCooldown:       baseconst.GetBreakerCooldown(),
+		MetaAlertChannels:     baseconst.GetMetaAlertChannels(),
+		MaxEvalBackoff:        baseconst.GetMaxEvalBackoff(),
+		EvalLagThreshold:      baseconst.GetEvalLagThreshold(),
+		EvalLagAlert:          baseconst.RulesEvalLagAlert,
+		ShutdownTimeout:       baseconst.GetRulesShutdownTimeout(),
+		AlertSnapshotInterval: baseconst.GetAlertSnapshotInterval(),
+		MaxResultSeries:       baseconst.RulesMaxResultSeries,
+		MaxResultPoints:       baseconst.RulesMaxResultPoints,
+
+		StateExportWebhooks:     baseconst.GetStateExportWebhooks(),
+		StateExportKafkaBrokers: baseconst.GetStateExportKafkaBrokers(),
+		StateExportKafkaTopic:   baseconst.RulesStateExportKafkaTopic,
+		HistoryQueueCapacity:    baseconst.RulesHistoryQueueCapacity,
 
 		PrepareTaskFunc:     rules.PrepareTaskFunc,
 		UseLogsNewSchema:    useLogsNewSchema,
@@ -186,8 +186,12 @@ func main() {
 		case status := <-server.HealthCheckStatus():
 			zap.L().Info("Received HealthCheck status: ", zap.Int("status", int(status)))
 		case <-signalsChannel:
-			zap.L().Fatal("Received OS Interrupt Signal ... ")
-			server.Stop()
+			zap.L().Info("Received OS Interrupt Signal ... ")
+			if err := server.Stop(); err != nil {
+				zap.L().Fatal("Failed to stop server", zap.Error(err))
+			}
+			zap.L().Info("Server stopped")
+			return
 		}
 	}
 }
@@ -103,6 +103,7 @@ func NewAnomalyRule(
 			anomaly.WithKeyGenerator[*anomaly.HourlyProvider](queryBuilder.NewKeyGenerator()),
 			anomaly.WithReader[*anomaly.HourlyProvider](reader),
 			anomaly.WithFeatureLookup[*anomaly.HourlyProvider](featureFlags),
+			anomaly.WithQueryParallelism[*anomaly.HourlyProvider](baseRule.QueryParallelism()),
 		)
 	} else if t.seasonality == anomaly.SeasonalityDaily {
 		t.provider = anomaly.NewDailyProvider(
@@ -110,6 +111,7 @@ func NewAnomalyRule(
 			anomaly.WithKeyGenerator[*anomaly.DailyProvider](queryBuilder.NewKeyGenerator()),
 			anomaly.WithReader[*anomaly.DailyProvider](reader),
 			anomaly.WithFeatureLookup[*anomaly.DailyProvider](featureFlags),
+			anomaly.WithQueryParallelism[*anomaly.DailyProvider](baseRule.QueryParallelism()),
 		)
 	} else if t.seasonality == anomaly.SeasonalityWeekly {
 		t.provider = anomaly.NewWeeklyProvider(
@@ -117,6 +119,7 @@ func NewAnomalyRule(
 			anomaly.WithKeyGenerator[*anomaly.WeeklyProvider](queryBuilder.NewKeyGenerator()),
 			anomaly.WithReader[*anomaly.WeeklyProvider](reader),
 			anomaly.WithFeatureLookup[*anomaly.WeeklyProvider](featureFlags),
+			anomaly.WithQueryParallelism[*anomaly.WeeklyProvider](baseRule.QueryParallelism()),
 		)
 	}
 	return &t, nil
@@ -141,10 +144,9 @@ func (r *AnomalyRule) prepareQueryRange(ts time.Time) (*v3.QueryRangeParamsV3, e
 	start = start - (start % (60 * 1000))
 	end = end - (end % (60 * 1000))
 
-	compositeQuery := r.Condition().CompositeQuery
-
-	if compositeQuery.PanelType != v3.PanelTypeGraph {
-		compositeQuery.PanelType = v3.PanelTypeGraph
+	compositeQuery, err := r.PreparedCompositeQuery()
+	if err != nil {
+		return nil, err
 	}
 
 	// default mode
@@ -188,18 +190,30 @@ func (r *AnomalyRule) buildAndRunQuery(ctx context.Context, ts time.Time) (baser
 			break
 		}
 	}
+	if err := r.CheckResultSize(queryResult); err != nil {
+		return nil, err
+	}
 
 	var resultVector baserules.Vector
 
-	scoresJSON, _ := json.Marshal(queryResult.AnomalyScores)
-	zap.L().Info("anomaly scores", zap.String("scores", string(scoresJSON)))
+	trace := baserules.EvalTraceFromContext(ctx)
+	trace.SetParams(params)
+	trace.AddSeries(queryResult.QueryName, queryResult.Series)
+	trace.AddSeries("anomaly_scores", queryResult.AnomalyScores)
 
 	for _, series := range queryResult.AnomalyScores {
 		smpl, shouldAlert := r.ShouldAlert(*series)
+		trace.AddDecision(series, smpl, shouldAlert, r.TargetVal())
 		if shouldAlert {
 			resultVector = append(resultVector, smpl)
 		}
 	}
+
+	if !r.HasEnoughBreachingSeries(len(resultVector)) {
+		zap.L().Info("not enough breaching series to alert", zap.String("ruleid", r.ID()), zap.Int("count", len(resultVector)))
+		trace.AddNote(fmt.Sprintf("%d breaching series are not enough to alert", len(resultVector)))
+		return nil, nil
+	}
 	return resultVector, nil
 }
 
@@ -324,7 +338,7 @@ func (r *AnomalyRule) Eval(ctx context.Context, ts time.Time) (interface{}, erro
 		if _, ok := resultFPs[fp]; !ok {
 			// If the alert was previously firing, keep it around for a given
 			// retention time so it is reported as resolved to the AlertManager.
-			if a.State == model.StatePending || (!a.ResolvedAt.IsZero() && ts.Sub(a.ResolvedAt) > baserules.ResolvedRetention) {
+			if a.State == model.StatePending || a.ResolvedExpired(ts) {
 				delete(r.Active, fp)
 			}
 			if a.State != model.StateInactive {
@@ -28,6 +28,9 @@ func PrepareTaskFunc(opts baserules.PrepareTaskOptions) (baserules.Task, error)
 			opts.UseLogsNewSchema,
 			opts.UseTraceNewSchema,
 			baserules.WithEvalDelay(opts.ManagerOpts.EvalDelay),
+			baserules.WithEvalTimeout(opts.ManagerOpts.EvalTimeout),
+			baserules.WithResultLimits(opts.ManagerOpts.MaxResultSeries, opts.ManagerOpts.MaxResultPoints),
+			baserules.WithVariables(opts.ManagerOpts.RuleVariables),
 		)
 
 		if err != nil {
@@ -39,6 +42,54 @@ func PrepareTaskFunc(opts baserules.PrepareTaskOptions) (baserules.Task, error)
 		// create ch rule task for evalution
 		task = newTask(baserules.TaskTypeCh, opts.TaskName, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
 
+	} else if opts.Rule.RuleType == baserules.RuleTypeSLO {
+		// create a slo rule
+		sr, err := baserules.NewSLORule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			baserules.WithEvalDelay(opts.ManagerOpts.EvalDelay),
+			baserules.WithEvalTimeout(opts.ManagerOpts.EvalTimeout),
+			baserules.WithResultLimits(opts.ManagerOpts.MaxResultSeries, opts.ManagerOpts.MaxResultPoints),
+			baserules.WithVariables(opts.ManagerOpts.RuleVariables),
+		)
+
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, sr)
+
+		// create ch rule task for evalution
+		task = newTask(baserules.TaskTypeCh, opts.TaskName, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
+
+	} else if opts.Rule.RuleType == baserules.RuleTypeHeartbeat {
+		// create a heartbeat rule
+		hr, err := baserules.NewHeartbeatRule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			baserules.WithEvalDelay(opts.ManagerOpts.EvalDelay),
+			baserules.WithEvalTimeout(opts.ManagerOpts.EvalTimeout),
+			baserules.WithResultLimits(opts.ManagerOpts.MaxResultSeries, opts.ManagerOpts.MaxResultPoints),
+			baserules.WithVariables(opts.ManagerOpts.RuleVariables),
+		)
+
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, hr)
+
+		// create ch rule task for evalution
+		task = newTask(baserules.TaskTypeCh, opts.TaskName, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
+
 	} else if opts.Rule.RuleType == baserules.RuleTypeProm {
 
 		// create promql rule
@@ -48,6 +99,8 @@ func PrepareTaskFunc(opts baserules.PrepareTaskOptions) (baserules.Task, error)
 			opts.Logger,
 			opts.Reader,
 			opts.ManagerOpts.PqlEngine,
+			baserules.WithEvalTimeout(opts.ManagerOpts.EvalTimeout),
+			baserules.WithResultLimits(opts.ManagerOpts.MaxResultSeries, opts.ManagerOpts.MaxResultPoints),
 		)
 
 		if err != nil {
@@ -68,6 +121,10 @@ func PrepareTaskFunc(opts baserules.PrepareTaskOptions) (baserules.Task, error)
 			opts.Reader,
 			opts.Cache,
 			baserules.WithEvalDelay(opts.ManagerOpts.EvalDelay),
+			baserules.WithEvalTimeout(opts.ManagerOpts.EvalTimeout),
+			baserules.WithResultLimits(opts.ManagerOpts.MaxResultSeries, opts.ManagerOpts.MaxResultPoints),
+			baserules.WithVariables(opts.ManagerOpts.RuleVariables),
+			baserules.WithQueryParallelism(opts.ManagerOpts.QueryParallelism),
 		)
 		if err != nil {
 			return task, err
@@ -79,7 +136,7 @@ func PrepareTaskFunc(opts baserules.PrepareTaskOptions) (baserules.Task, error)
 		task = newTask(baserules.TaskTypeCh, opts.TaskName, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
 
 	} else {
-		return nil, fmt.Errorf("unsupported rule type %s. Supported types: %s, %s", opts.Rule.RuleType, baserules.RuleTypeProm, baserules.RuleTypeThreshold)
+		return nil, fmt.Errorf("unsupported rule type %s. Supported types: %s, %s, %s, %s, %s", opts.Rule.RuleType, baserules.RuleTypeProm, baserules.RuleTypeThreshold, baserules.RuleTypeSLO, baserules.RuleTypeHeartbeat, RuleTypeAnomaly)
 	}
 
 	return task, nil
@@ -133,6 +190,50 @@ func TestNotification(opts baserules.PrepareTestRuleOptions) (int, *basemodel.Ap
 			return 0, basemodel.BadRequest(err)
 		}
 
+	} else if parsedRule.RuleType == baserules.RuleTypeSLO {
+
+		parsedRule.Labels[labels.RuleSourceLabel] = ""
+		parsedRule.Labels[labels.AlertRuleIdLabel] = ""
+
+		// create a slo rule
+		rule, err = baserules.NewSLORule(
+			alertname,
+			parsedRule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			baserules.WithSendAlways(),
+			baserules.WithSendUnmatched(),
+		)
+
+		if err != nil {
+			zap.L().Error("failed to prepare a new slo rule for test", zap.String("name", alertname), zap.Error(err))
+			return 0, basemodel.BadRequest(err)
+		}
+
+	} else if parsedRule.RuleType == baserules.RuleTypeHeartbeat {
+
+		parsedRule.Labels[labels.RuleSourceLabel] = ""
+		parsedRule.Labels[labels.AlertRuleIdLabel] = ""
+
+		// create a heartbeat rule
+		rule, err = baserules.NewHeartbeatRule(
+			alertname,
+			parsedRule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			baserules.WithSendAlways(),
+			baserules.WithSendUnmatched(),
+		)
+
+		if err != nil {
+			zap.L().Error("failed to prepare a new heartbeat rule for test", zap.String("name", alertname), zap.Error(err))
+			return 0, basemodel.BadRequest(err)
+		}
+
 	} else if parsedRule.RuleType == baserules.RuleTypeProm {
 
 		// create promql rule
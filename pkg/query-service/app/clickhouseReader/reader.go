@@ -54,18 +54,20 @@ import (
 )
 
 const (
-	primaryNamespace          = "clickhouse"
-	archiveNamespace          = "clickhouse-archive"
-	signozTraceDBName         = "signoz_traces"
-	signozHistoryDBName       = "signoz_analytics"
-	ruleStateHistoryTableName = "distributed_rule_state_history_v0"
-	signozDurationMVTable     = "distributed_durationSort"
-	signozUsageExplorerTable  = "distributed_usage_explorer"
-	signozSpansTable          = "distributed_signoz_spans"
-	signozErrorIndexTable     = "distributed_signoz_error_index_v2"
-	signozTraceTableName      = "distributed_signoz_index_v2"
-	signozTraceLocalTableName = "signoz_index_v2"
-	signozMetricDBName        = "signoz_metrics"
+	primaryNamespace               = "clickhouse"
+	archiveNamespace               = "clickhouse-archive"
+	signozTraceDBName              = "signoz_traces"
+	signozHistoryDBName            = "signoz_analytics"
+	ruleStateHistoryTableName      = "distributed_rule_state_history_v0"
+	ruleStateHistoryLocalTableName = "rule_state_history_v0"
+	sloErrorBudgetTableName        = "distributed_slo_error_budget_v0"
+	signozDurationMVTable          = "distributed_durationSort"
+	signozUsageExplorerTable       = "distributed_usage_explorer"
+	signozSpansTable               = "distributed_signoz_spans"
+	signozErrorIndexTable          = "distributed_signoz_error_index_v2"
+	signozTraceTableName           = "distributed_signoz_index_v2"
+	signozTraceLocalTableName      = "signoz_index_v2"
+	signozMetricDBName             = "signoz_metrics"
 
 	signozSampleLocalTableName = "samples_v4"
 	signozSampleTableName      = "distributed_samples_v4"
@@ -1918,6 +1920,37 @@ func (r *ClickHouseReader) SetTTL(ctx context.Context,
 			}
 		}(tableName)
 
+	case constants.AlertHistoryTTL:
+		tableName := signozHistoryDBName + "." + ruleStateHistoryLocalTableName
+		statusItem, err := r.checkTTLStatusItem(ctx, tableName)
+		if err != nil {
+			return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error in processing ttl_status check sql query")}
+		}
+		if statusItem.Status == constants.StatusPending {
+			return nil, &model.ApiError{Typ: model.ErrorConflict, Err: fmt.Errorf("TTL is already running")}
+		}
+		go func(tableName string) {
+			_, dbErr := r.localDB.Exec("INSERT INTO ttl_status (transaction_id, created_at, updated_at, table_name, ttl, status, cold_storage_ttl) VALUES (?, ?, ?, ?, ?, ?, ?)", uuid, time.Now(), time.Now(), tableName, params.DelDuration, constants.StatusPending, coldStorageDuration)
+			if dbErr != nil {
+				zap.L().Error("error in inserting to ttl_status table", zap.Error(dbErr))
+				return
+			}
+			req := fmt.Sprintf(
+				"ALTER TABLE %v ON CLUSTER %s MODIFY TTL toDateTime(intDiv(unix_milli, 1000)) + "+
+					"INTERVAL %v SECOND DELETE SETTINGS materialize_ttl_after_modify=0", tableName, r.cluster, params.DelDuration)
+			zap.L().Info("Executing TTL request: ", zap.String("request", req))
+			statusItem, _ := r.checkTTLStatusItem(ctx, tableName)
+			status := constants.StatusSuccess
+			if err := r.db.Exec(ctx, req); err != nil {
+				zap.L().Error("error while setting ttl", zap.Error(err))
+				status = constants.StatusFailed
+			}
+			_, dbErr = r.localDB.Exec("UPDATE ttl_status SET updated_at = ?, status = ? WHERE id = ?", time.Now(), status, statusItem.Id)
+			if dbErr != nil {
+				zap.L().Error("Error in processing ttl_status update sql query", zap.Error(dbErr))
+			}
+		}(tableName)
+
 	default:
 		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while setting ttl. ttl type should be <metrics|traces>, got %v",
 			params.Type)}
@@ -2112,6 +2145,24 @@ func (r *ClickHouseReader) GetTTL(ctx context.Context, ttlParams *model.GetTTLPa
 		}
 	}
 
+	getAlertHistoryTTL := func() (*model.DBResponseTTL, *model.ApiError) {
+		var dbResp []model.DBResponseTTL
+
+		query := fmt.Sprintf("SELECT engine_full FROM system.tables WHERE name='%v' AND database='%v'", ruleStateHistoryLocalTableName, signozHistoryDBName)
+
+		err := r.db.Select(ctx, &dbResp, query)
+
+		if err != nil {
+			zap.L().Error("error while getting ttl", zap.Error(err))
+			return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while getting ttl. Err=%v", err)}
+		}
+		if len(dbResp) == 0 {
+			return nil, nil
+		} else {
+			return &dbResp[0], nil
+		}
+	}
+
 	switch ttlParams.Type {
 	case constants.TraceTTL:
 		tableNameArray := []string{signozTraceDBName + "." + signozTraceTableName, signozTraceDBName + "." + signozDurationMVTable, signozTraceDBName + "." + signozSpansTable, signozTraceDBName + "." + signozErrorIndexTable, signozTraceDBName + "." + signozUsageExplorerTable, signozTraceDBName + "." + defaultDependencyGraphTable}
@@ -2183,6 +2234,28 @@ func (r *ClickHouseReader) GetTTL(ctx context.Context, ttlParams *model.GetTTLPa
 		delTTL, moveTTL := parseTTL(dbResp.EngineFull)
 		return &model.GetTTLResponseItem{LogsTime: delTTL, LogsMoveTime: moveTTL, ExpectedLogsTime: ttlQuery.TTL, ExpectedLogsMoveTime: ttlQuery.ColdStorageTtl, Status: status}, nil
 
+	case constants.AlertHistoryTTL:
+		tableNameArray := []string{signozHistoryDBName + "." + ruleStateHistoryLocalTableName}
+		status, err := r.setTTLQueryStatus(ctx, tableNameArray)
+		if err != nil {
+			return nil, err
+		}
+		dbResp, err := getAlertHistoryTTL()
+		if err != nil {
+			return nil, err
+		}
+		ttlQuery, err := r.checkTTLStatusItem(ctx, tableNameArray[0])
+		if err != nil {
+			return nil, err
+		}
+		ttlQuery.TTL = ttlQuery.TTL / 3600 // convert to hours
+
+		delTTL := -1
+		if dbResp != nil {
+			delTTL, _ = parseTTL(dbResp.EngineFull)
+		}
+		return &model.GetTTLResponseItem{AlertHistoryTime: delTTL, ExpectedAlertHistoryTime: ttlQuery.TTL, Status: status}, nil
+
 	default:
 		return nil, &model.ApiError{Typ: model.ErrorExec, Err: fmt.Errorf("error while getting ttl. ttl type should be metrics|traces, got %v",
 			ttlParams.Type)}
@@ -3931,6 +4004,11 @@ func logCommentKVs(ctx context.Context) map[string]string {
 
 // GetTimeSeriesResultV3 runs the query and returns list of time series
 func (r *ClickHouseReader) GetTimeSeriesResultV3(ctx context.Context, query string) ([]*v3.Series, error) {
+	return r.getTimeSeriesResult(ctx, query)
+}
+
+// getTimeSeriesResult runs the time series query with its bound args
+func (r *ClickHouseReader) getTimeSeriesResult(ctx context.Context, query string, args ...interface{}) ([]*v3.Series, error) {
 
 	ctxArgs := map[string]interface{}{"query": query}
 	for k, v := range logCommentKVs(ctx) {
@@ -3939,6 +4017,8 @@ func (r *ClickHouseReader) GetTimeSeriesResultV3(ctx context.Context, query stri
 
 	defer utils.Elapsed("GetTimeSeriesResultV3", ctxArgs)()
 
+	var progress []func(p *clickhouse.Progress)
+
 	// Hook up query progress reporting if requested.
 	queryId := ctx.Value("queryId")
 	if queryId != nil {
@@ -3947,23 +4027,39 @@ func (r *ClickHouseReader) GetTimeSeriesResultV3(ctx context.Context, query stri
 			zap.L().Error("GetTimeSeriesResultV3: queryId in ctx not a string as expected", zap.Any("queryId", queryId))
 
 		} else {
-			ctx = clickhouse.Context(ctx, clickhouse.WithProgress(
-				func(p *clickhouse.Progress) {
-					go func() {
-						err := r.queryProgressTracker.ReportQueryProgress(qid, p)
-						if err != nil {
-							zap.L().Error(
-								"Couldn't report query progress",
-								zap.String("queryId", qid), zap.Error(err),
-							)
-						}
-					}()
-				},
-			))
+			progress = append(progress, func(p *clickhouse.Progress) {
+				go func() {
+					err := r.queryProgressTracker.ReportQueryProgress(qid, p)
+					if err != nil {
+						zap.L().Error(
+							"Couldn't report query progress",
+							zap.String("queryId", qid), zap.Error(err),
+						)
+					}
+				}()
+			})
 		}
 	}
 
-	rows, err := r.db.Query(ctx, query)
+	// the rules collect the cost of their queries
+	if stats := model.QueryStatsFromContext(ctx); stats != nil {
+		stats.AddQuery(query)
+		progress = append(progress, func(p *clickhouse.Progress) {
+			stats.AddProgress(p.Rows, p.Bytes)
+		})
+	}
+
+	if len(progress) > 0 {
+		ctx = clickhouse.Context(ctx, clickhouse.WithProgress(
+			func(p *clickhouse.Progress) {
+				for _, report := range progress {
+					report(p)
+				}
+			},
+		))
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
 
 	if err != nil {
 		zap.L().Error("error while reading time series result", zap.Error(err))
@@ -4576,7 +4672,7 @@ func (r *ClickHouseReader) AddRuleStateHistory(ctx context.Context, ruleStateHis
 		}
 	}()
 
-	statement, err = r.db.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (rule_id, rule_name, overall_state, overall_state_changed, state, state_changed, unix_milli, labels, fingerprint, value) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
+	statement, err = r.db.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (rule_id, rule_name, overall_state, overall_state_changed, state, state_changed, unix_milli, labels, fingerprint, value, org_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
 		signozHistoryDBName, ruleStateHistoryTableName))
 
 	if err != nil {
@@ -4584,7 +4680,7 @@ func (r *ClickHouseReader) AddRuleStateHistory(ctx context.Context, ruleStateHis
 	}
 
 	for _, history := range ruleStateHistory {
-		err = statement.Append(history.RuleID, history.RuleName, history.OverallState, history.OverallStateChanged, history.State, history.StateChanged, history.UnixMilli, history.Labels, history.Fingerprint, history.Value)
+		err = statement.Append(history.RuleID, history.RuleName, history.OverallState, history.OverallStateChanged, history.State, history.StateChanged, history.UnixMilli, history.Labels, history.Fingerprint, history.Value, history.OrgID)
 		if err != nil {
 			return err
 		}
@@ -4598,11 +4694,11 @@ func (r *ClickHouseReader) AddRuleStateHistory(ctx context.Context, ruleStateHis
 }
 
 func (r *ClickHouseReader) GetLastSavedRuleStateHistory(ctx context.Context, ruleID string) ([]model.RuleStateHistory, error) {
-	query := fmt.Sprintf("SELECT * FROM %s.%s WHERE rule_id = '%s' AND state_changed = true ORDER BY unix_milli DESC LIMIT 1 BY fingerprint",
-		signozHistoryDBName, ruleStateHistoryTableName, ruleID)
+	query := fmt.Sprintf("SELECT * FROM %s.%s WHERE rule_id = @ruleID AND state_changed = true ORDER BY unix_milli DESC LIMIT 1 BY fingerprint",
+		signozHistoryDBName, ruleStateHistoryTableName)
 
 	history := []model.RuleStateHistory{}
-	err := r.db.Select(ctx, &history, query)
+	err := r.db.Select(ctx, &history, query, clickhouse.Named("ruleID", ruleID))
 	if err != nil {
 		return nil, err
 	}
@@ -4614,7 +4710,7 @@ func (r *ClickHouseReader) ReadRuleStateHistoryByRuleID(
 
 	var conditions []string
 
-	conditions = append(conditions, fmt.Sprintf("rule_id = '%s'", ruleID))
+	conditions = append(conditions, "rule_id = @ruleID")
 
 	conditions = append(conditions, fmt.Sprintf("unix_milli >= %d AND unix_milli < %d", params.Start, params.End))
 
@@ -4622,8 +4718,72 @@ func (r *ClickHouseReader) ReadRuleStateHistoryByRuleID(
 		conditions = append(conditions, fmt.Sprintf("state = '%s'", params.State))
 	}
 
-	if params.Filters != nil && len(params.Filters.Items) != 0 {
-		for _, item := range params.Filters.Items {
+	labelConditions, err := ruleStateHistoryLabelConditions(params.Filters)
+	if err != nil {
+		return nil, err
+	}
+	conditions = append(conditions, labelConditions...)
+	whereClause := strings.Join(conditions, " AND ")
+
+	query := fmt.Sprintf("SELECT * FROM %s.%s WHERE %s ORDER BY unix_milli %s LIMIT %d OFFSET %d",
+		signozHistoryDBName, ruleStateHistoryTableName, whereClause, params.Order, params.Limit, params.Offset)
+
+	history := []model.RuleStateHistory{}
+	zap.L().Debug("rule state history query", zap.String("query", query))
+	err = r.db.Select(ctx, &history, query, clickhouse.Named("ruleID", ruleID))
+	if err != nil {
+		zap.L().Error("Error while reading rule state history", zap.Error(err))
+		return nil, err
+	}
+
+	var total uint64
+	zap.L().Debug("rule state history total query", zap.String("query", fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE %s",
+		signozHistoryDBName, ruleStateHistoryTableName, whereClause)))
+	err = r.db.QueryRow(ctx, fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE %s",
+		signozHistoryDBName, ruleStateHistoryTableName, whereClause), clickhouse.Named("ruleID", ruleID)).Scan(&total)
+	if err != nil {
+		return nil, err
+	}
+
+	labelsQuery := fmt.Sprintf("SELECT DISTINCT labels FROM %s.%s WHERE rule_id = $1",
+		signozHistoryDBName, ruleStateHistoryTableName)
+	rows, err := r.db.Query(ctx, labelsQuery, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labelsMap := make(map[string][]string)
+	for rows.Next() {
+		var rawLabel string
+		err = rows.Scan(&rawLabel)
+		if err != nil {
+			return nil, err
+		}
+		label := map[string]string{}
+		err = json.Unmarshal([]byte(rawLabel), &label)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range label {
+			labelsMap[k] = append(labelsMap[k], v)
+		}
+	}
+
+	timeline := &model.RuleStateTimeline{
+		Items:  history,
+		Total:  total,
+		Labels: labelsMap,
+	}
+
+	return timeline, nil
+}
+
+// ruleStateHistoryLabelConditions returns the conditions of the label filters of the rule state history
+func ruleStateHistoryLabelConditions(filters *v3.FilterSet) ([]string, error) {
+	var conditions []string
+	if filters != nil && len(filters.Items) != 0 {
+		for _, item := range filters.Items {
 			toFormat := item.Value
 			op := v3.FilterOperator(strings.ToLower(strings.TrimSpace(string(item.Operator))))
 			if op == v3.FilterOperatorContains || op == v3.FilterOperatorNotContains {
@@ -4668,60 +4828,263 @@ func (r *ClickHouseReader) ReadRuleStateHistoryByRuleID(
 			}
 		}
 	}
-	whereClause := strings.Join(conditions, " AND ")
+	return conditions, nil
+}
 
-	query := fmt.Sprintf("SELECT * FROM %s.%s WHERE %s ORDER BY unix_milli %s LIMIT %d OFFSET %d",
-		signozHistoryDBName, ruleStateHistoryTableName, whereClause, params.Order, params.Limit, params.Offset)
+// QueryAlertStateHistory returns a page of the state changes of the alerts of the rules
+// alertStateHistoryConditions builds the where clause selecting the state changes of the query
+func alertStateHistoryConditions(params *model.QueryAlertStateHistory) (string, error) {
+	conditions := []string{fmt.Sprintf("unix_milli >= %d AND unix_milli < %d", params.Start, params.End)}
+	if len(params.RuleIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("rule_id IN %s", utils.ClickHouseFormattedValue(params.RuleIDs)))
+	}
+	if len(params.Fingerprints) > 0 {
+		fingerprints := make([]string, 0, len(params.Fingerprints))
+		for _, fingerprint := range params.Fingerprints {
+			fingerprints = append(fingerprints, strconv.FormatUint(fingerprint, 10))
+		}
+		conditions = append(conditions, fmt.Sprintf("fingerprint IN (%s)", strings.Join(fingerprints, ",")))
+	}
+	if len(params.States) > 0 {
+		conditions = append(conditions, fmt.Sprintf("state IN %s", utils.ClickHouseFormattedValue(params.States)))
+	}
+	labelConditions, err := ruleStateHistoryLabelConditions(params.Filters)
+	if err != nil {
+		return "", err
+	}
+	conditions = append(conditions, labelConditions...)
+	return strings.Join(conditions, " AND "), nil
+}
 
-	history := []model.RuleStateHistory{}
-	zap.L().Debug("rule state history query", zap.String("query", query))
-	err := r.db.Select(ctx, &history, query)
+func (r *ClickHouseReader) QueryAlertStateHistory(ctx context.Context, params *model.QueryAlertStateHistory) (*model.AlertStateHistoryPage, error) {
+	whereClause, err := alertStateHistoryConditions(params)
 	if err != nil {
-		zap.L().Error("Error while reading rule state history", zap.Error(err))
 		return nil, err
 	}
 
-	var total uint64
-	zap.L().Debug("rule state history total query", zap.String("query", fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE %s",
-		signozHistoryDBName, ruleStateHistoryTableName, whereClause)))
+	query := fmt.Sprintf("SELECT * FROM %s.%s WHERE %s ORDER BY unix_milli %s, rule_id, fingerprint LIMIT %d OFFSET %d",
+		signozHistoryDBName, ruleStateHistoryTableName, whereClause, params.Order, params.Limit, params.Offset)
+	zap.L().Debug("alert state history query", zap.String("query", query))
+
+	page := &model.AlertStateHistoryPage{Items: []model.RuleStateHistory{}, Offset: params.Offset, Limit: params.Limit}
+	if err := r.db.Select(ctx, &page.Items, query); err != nil {
+		zap.L().Error("Error while reading alert state history", zap.Error(err))
+		return nil, err
+	}
 	err = r.db.QueryRow(ctx, fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE %s",
-		signozHistoryDBName, ruleStateHistoryTableName, whereClause)).Scan(&total)
+		signozHistoryDBName, ruleStateHistoryTableName, whereClause)).Scan(&page.Total)
 	if err != nil {
 		return nil, err
 	}
+	return page, nil
+}
 
-	labelsQuery := fmt.Sprintf("SELECT DISTINCT labels FROM %s.%s WHERE rule_id = $1",
-		signozHistoryDBName, ruleStateHistoryTableName)
-	rows, err := r.db.Query(ctx, labelsQuery, ruleID)
-	if err != nil {
+// GetRuleFiringEvents returns when the series of the rules started firing in the time
+// range, oldest first, the firings of a rule in the same minute are one event
+func (r *ClickHouseReader) GetRuleFiringEvents(ctx context.Context, start, end int64, ruleIDs []string) ([]model.RuleFiringEvent, error) {
+	conditions := []string{
+		"state_changed = true",
+		fmt.Sprintf("state = '%s'", model.StateFiring.String()),
+		fmt.Sprintf("unix_milli >= %d AND unix_milli < %d", start, end),
+	}
+	if len(ruleIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("rule_id IN %s", utils.ClickHouseFormattedValue(ruleIDs)))
+	}
+
+	query := fmt.Sprintf(`SELECT rule_id, any(rule_name) AS rule_name, min(unix_milli) AS unix_milli
+	FROM %s.%s
+	WHERE %s
+	GROUP BY rule_id, intDiv(unix_milli, 60000)
+	ORDER BY unix_milli`,
+		signozHistoryDBName, ruleStateHistoryTableName, strings.Join(conditions, " AND "))
+
+	zap.L().Debug("rule firing events query", zap.String("query", query))
+	events := []model.RuleFiringEvent{}
+	if err := r.db.Select(ctx, &events, query); err != nil {
+		zap.L().Error("Error while reading rule firing events", zap.Error(err))
 		return nil, err
 	}
-	defer rows.Close()
+	return events, nil
+}
 
-	labelsMap := make(map[string][]string)
+// ExportAlertStateHistory streams the state changes of the query ignoring its page to fn,
+// oldest first
+func (r *ClickHouseReader) ExportAlertStateHistory(ctx context.Context, params *model.QueryAlertStateHistory, fn func(*model.RuleStateHistory) error) error {
+	whereClause, err := alertStateHistoryConditions(params)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s.%s WHERE %s ORDER BY unix_milli ASC, rule_id, fingerprint",
+		signozHistoryDBName, ruleStateHistoryTableName, whereClause)
+	zap.L().Debug("alert state history export query", zap.String("query", query))
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		zap.L().Error("Error while exporting alert state history", zap.Error(err))
+		return err
+	}
+	defer rows.Close()
 	for rows.Next() {
-		var rawLabel string
-		err = rows.Scan(&rawLabel)
-		if err != nil {
-			return nil, err
+		var item model.RuleStateHistory
+		if err := rows.ScanStruct(&item); err != nil {
+			return err
 		}
-		label := map[string]string{}
-		err = json.Unmarshal([]byte(rawLabel), &label)
-		if err != nil {
-			return nil, err
+		if err := fn(&item); err != nil {
+			return err
 		}
-		for k, v := range label {
-			labelsMap[k] = append(labelsMap[k], v)
+	}
+	return rows.Err()
+}
+
+// GetRuleFiringStats returns how often the series of the rules fired in the time range
+// and for how long, the firing duration of a series is up to its next state change
+func (r *ClickHouseReader) GetRuleFiringStats(ctx context.Context, start, end int64, ruleIDs []string) ([]model.RuleFiringStats, error) {
+	conditions := []string{"state_changed = true", fmt.Sprintf("unix_milli >= %d AND unix_milli < %d", start, end)}
+	if len(ruleIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("rule_id IN %s", utils.ClickHouseFormattedValue(ruleIDs)))
+	}
+
+	query := fmt.Sprintf(`SELECT
+		rule_id,
+		any(rule_name) AS rule_name,
+		countIf(state = '%[1]s') AS firings,
+		uniqExactIf(fingerprint, state = '%[1]s') AS series,
+		ifNotFinite(avgIf(next_unix_milli - unix_milli, state = '%[1]s' AND next_unix_milli > 0) / 1000, 0) AS avg_firing_duration,
+		countIf(state = '%[1]s' AND next_unix_milli > 0 AND next_unix_milli - unix_milli < %[5]d) AS short_firings
+	FROM (
+		SELECT
+			rule_id,
+			rule_name,
+			fingerprint,
+			state,
+			unix_milli,
+			leadInFrame(unix_milli) OVER (PARTITION BY rule_id, fingerprint ORDER BY unix_milli ROWS BETWEEN CURRENT ROW AND 1 FOLLOWING) AS next_unix_milli
+		FROM %[2]s.%[3]s
+		WHERE %[4]s
+	)
+	GROUP BY rule_id
+	HAVING firings > 0`,
+		model.StateFiring.String(), signozHistoryDBName, ruleStateHistoryTableName, strings.Join(conditions, " AND "), model.ShortFiringMillis)
+
+	zap.L().Debug("rule firing stats query", zap.String("query", query))
+	stats := []model.RuleFiringStats{}
+	if err := r.db.Select(ctx, &stats, query); err != nil {
+		zap.L().Error("Error while reading rule firing stats", zap.Error(err))
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetFiringCountsByLabel counts the times the series of the rules started firing in the
+// time range by the value of the label, the most firing values first
+func (r *ClickHouseReader) GetFiringCountsByLabel(ctx context.Context, start, end int64, label string, ruleIDs []string) ([]model.LabelFiringCount, error) {
+	conditions := []string{
+		"state_changed = true",
+		fmt.Sprintf("state = '%s'", model.StateFiring.String()),
+		fmt.Sprintf("unix_milli >= %d AND unix_milli < %d", start, end),
+	}
+	if len(ruleIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("rule_id IN %s", utils.ClickHouseFormattedValue(ruleIDs)))
+	}
+
+	query := fmt.Sprintf(`SELECT
+		JSONExtractString(labels, %s) AS value,
+		count(*) AS firings,
+		uniqExact(rule_id) AS rules,
+		uniqExact(fingerprint) AS series
+	FROM %s.%s
+	WHERE %s
+	GROUP BY value
+	ORDER BY firings DESC, value`,
+		utils.ClickHouseFormattedValue(label), signozHistoryDBName, ruleStateHistoryTableName, strings.Join(conditions, " AND "))
+
+	zap.L().Debug("firing counts by label query", zap.String("query", query))
+	counts := []model.LabelFiringCount{}
+	if err := r.db.Select(ctx, &counts, query); err != nil {
+		zap.L().Error("Error while reading firing counts by label", zap.Error(err))
+		return nil, err
+	}
+	return counts, nil
+}
+
+// GetRuleStateHistoryRuleIDs returns the ids of the rules with state history
+func (r *ClickHouseReader) GetRuleStateHistoryRuleIDs(ctx context.Context) ([]string, error) {
+	ruleIDs := []string{}
+	query := fmt.Sprintf("SELECT DISTINCT rule_id FROM %s.%s", signozHistoryDBName, ruleStateHistoryTableName)
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		zap.L().Error("Error while reading the rules of the state history", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ruleID string
+		if err := rows.Scan(&ruleID); err != nil {
+			return nil, err
 		}
+		ruleIDs = append(ruleIDs, ruleID)
 	}
+	return ruleIDs, rows.Err()
+}
 
-	timeline := &model.RuleStateTimeline{
-		Items:  history,
-		Total:  total,
-		Labels: labelsMap,
+// DeleteRuleStateHistory deletes the state history of the rules, the rows are removed
+// by a mutation in the background
+func (r *ClickHouseReader) DeleteRuleStateHistory(ctx context.Context, ruleIDs []string) error {
+	if len(ruleIDs) == 0 {
+		return nil
+	}
+	query := fmt.Sprintf("ALTER TABLE %s.%s ON CLUSTER %s DELETE WHERE rule_id IN %s",
+		signozHistoryDBName, ruleStateHistoryLocalTableName, r.cluster, utils.ClickHouseFormattedValue(ruleIDs))
+	zap.L().Info("Deleting the rule state history", zap.String("query", query))
+	if err := r.db.Exec(ctx, query); err != nil {
+		zap.L().Error("Error while deleting the rule state history", zap.Error(err))
+		return err
 	}
+	return nil
+}
 
-	return timeline, nil
+// GetRuleStateChanges returns the state changes of the series of the rules in the time
+// range, preceded by the last state before the range of the series not inactive then,
+// the empty rule ids select all the rules
+func (r *ClickHouseReader) GetRuleStateChanges(ctx context.Context, ruleIDs []string, start, end int64) ([]model.RuleStateHistory, error) {
+	ruleCondition := "1 = 1"
+	if len(ruleIDs) > 0 {
+		ruleCondition = fmt.Sprintf("rule_id IN %s", utils.ClickHouseFormattedValue(ruleIDs))
+	}
+
+	changes := []model.RuleStateHistory{}
+	query := fmt.Sprintf(`SELECT rule_id, fingerprint, last_labels AS labels, last_state AS state, last_unix_milli AS unix_milli
+	FROM (
+		SELECT
+			rule_id,
+			fingerprint,
+			argMax(labels, unix_milli) AS last_labels,
+			argMax(state, unix_milli) AS last_state,
+			max(unix_milli) AS last_unix_milli
+		FROM %s.%s
+		WHERE %s AND unix_milli < %d
+		GROUP BY rule_id, fingerprint
+	)
+	WHERE last_state != '%s'`,
+		signozHistoryDBName, ruleStateHistoryTableName, ruleCondition, start, model.StateInactive.String())
+	zap.L().Debug("rule state before range query", zap.String("query", query))
+	if err := r.db.Select(ctx, &changes, query); err != nil {
+		zap.L().Error("Error while reading rule state changes", zap.Error(err))
+		return nil, err
+	}
+
+	inRange := []model.RuleStateHistory{}
+	query = fmt.Sprintf(`SELECT rule_id, fingerprint, labels, state, unix_milli FROM %s.%s
+	WHERE %s AND state_changed = true AND unix_milli >= %d AND unix_milli < %d
+	ORDER BY rule_id, fingerprint, unix_milli`,
+		signozHistoryDBName, ruleStateHistoryTableName, ruleCondition, start, end)
+	zap.L().Debug("rule state changes query", zap.String("query", query))
+	if err := r.db.Select(ctx, &inRange, query); err != nil {
+		zap.L().Error("Error while reading rule state changes", zap.Error(err))
+		return nil, err
+	}
+	return append(changes, inRange...), nil
 }
 
 func (r *ClickHouseReader) ReadRuleStateHistoryTopContributorsByRuleID(
@@ -4731,15 +5094,15 @@ func (r *ClickHouseReader) ReadRuleStateHistoryTopContributorsByRuleID(
 		any(labels) as labels,
 		count(*) as count
 	FROM %s.%s
-	WHERE rule_id = '%s' AND (state_changed = true) AND (state = '%s') AND unix_milli >= %d AND unix_milli <= %d
+	WHERE rule_id = @ruleID AND (state_changed = true) AND (state = '%s') AND unix_milli >= %d AND unix_milli <= %d
 	GROUP BY fingerprint
 	HAVING labels != '{}'
 	ORDER BY count DESC`,
-		signozHistoryDBName, ruleStateHistoryTableName, ruleID, model.StateFiring.String(), params.Start, params.End)
+		signozHistoryDBName, ruleStateHistoryTableName, model.StateFiring.String(), params.Start, params.End)
 
 	zap.L().Debug("rule state history top contributors query", zap.String("query", query))
 	contributors := []model.RuleStateHistoryContributor{}
-	err := r.db.Select(ctx, &contributors, query)
+	err := r.db.Select(ctx, &contributors, query, clickhouse.Named("ruleID", ruleID))
 	if err != nil {
 		zap.L().Error("Error while reading rule state history", zap.Error(err))
 		return nil, err
@@ -4758,7 +5121,7 @@ func (r *ClickHouseReader) GetOverallStateTransitions(ctx context.Context, ruleI
     FROM %s.%s
     WHERE overall_state = '` + model.StateFiring.String() + `' 
       AND overall_state_changed = true
-      AND rule_id IN ('%s')
+      AND rule_id = @ruleID
 	  AND unix_milli >= %d AND unix_milli <= %d
 ),
 resolution_events AS (
@@ -4769,7 +5132,7 @@ resolution_events AS (
     FROM %s.%s
     WHERE overall_state = '` + model.StateInactive.String() + `' 
       AND overall_state_changed = true
-      AND rule_id IN ('%s')
+      AND rule_id = @ruleID
 	  AND unix_milli >= %d AND unix_milli <= %d
 ),
 matched_events AS (
@@ -4789,13 +5152,13 @@ FROM matched_events
 ORDER BY firing_time ASC;`
 
 	query := fmt.Sprintf(tmpl,
-		signozHistoryDBName, ruleStateHistoryTableName, ruleID, params.Start, params.End,
-		signozHistoryDBName, ruleStateHistoryTableName, ruleID, params.Start, params.End)
+		signozHistoryDBName, ruleStateHistoryTableName, params.Start, params.End,
+		signozHistoryDBName, ruleStateHistoryTableName, params.Start, params.End)
 
 	zap.L().Debug("overall state transitions query", zap.String("query", query))
 
 	transitions := []model.RuleStateTransition{}
-	err := r.db.Select(ctx, &transitions, query)
+	err := r.db.Select(ctx, &transitions, query, clickhouse.Named("ruleID", ruleID))
 	if err != nil {
 		return nil, err
 	}
@@ -4824,9 +5187,9 @@ ORDER BY firing_time ASC;`
 
 	// fetch the most recent overall_state from the table
 	var state model.AlertState
-	stateQuery := fmt.Sprintf("SELECT state FROM %s.%s WHERE rule_id = '%s' AND unix_milli <= %d ORDER BY unix_milli DESC LIMIT 1",
-		signozHistoryDBName, ruleStateHistoryTableName, ruleID, params.End)
-	if err := r.db.QueryRow(ctx, stateQuery).Scan(&state); err != nil {
+	stateQuery := fmt.Sprintf("SELECT state FROM %s.%s WHERE rule_id = @ruleID AND unix_milli <= %d ORDER BY unix_milli DESC LIMIT 1",
+		signozHistoryDBName, ruleStateHistoryTableName, params.End)
+	if err := r.db.QueryRow(ctx, stateQuery, clickhouse.Named("ruleID", ruleID)).Scan(&state); err != nil {
 		if err != sql.ErrNoRows {
 			return nil, err
 		}
@@ -4855,9 +5218,9 @@ ORDER BY firing_time ASC;`
 			SELECT
 				unix_milli
 			FROM %s.%s
-			WHERE rule_id = '%s' AND overall_state_changed = true AND overall_state = '%s' AND unix_milli <= %d
-			ORDER BY unix_milli DESC LIMIT 1`, signozHistoryDBName, ruleStateHistoryTableName, ruleID, model.StateFiring.String(), params.End)
-			if err := r.db.QueryRow(ctx, firingQuery).Scan(&firingTime); err != nil {
+			WHERE rule_id = @ruleID AND overall_state_changed = true AND overall_state = '%s' AND unix_milli <= %d
+			ORDER BY unix_milli DESC LIMIT 1`, signozHistoryDBName, ruleStateHistoryTableName, model.StateFiring.String(), params.End)
+			if err := r.db.QueryRow(ctx, firingQuery, clickhouse.Named("ruleID", ruleID)).Scan(&firingTime); err != nil {
 				return nil, err
 			}
 			stateItems = append(stateItems, model.ReleStateItem{
@@ -4886,7 +5249,7 @@ WITH firing_events AS (
     FROM %s.%s
     WHERE overall_state = '` + model.StateFiring.String() + `' 
       AND overall_state_changed = true
-      AND rule_id IN ('%s')
+      AND rule_id = @ruleID
 	  AND unix_milli >= %d AND unix_milli <= %d
 ),
 resolution_events AS (
@@ -4897,7 +5260,7 @@ resolution_events AS (
     FROM %s.%s
     WHERE overall_state = '` + model.StateInactive.String() + `' 
       AND overall_state_changed = true
-      AND rule_id IN ('%s')
+      AND rule_id = @ruleID
 	  AND unix_milli >= %d AND unix_milli <= %d
 ),
 matched_events AS (
@@ -4917,12 +5280,12 @@ FROM matched_events;
 `
 
 	query := fmt.Sprintf(tmpl,
-		signozHistoryDBName, ruleStateHistoryTableName, ruleID, params.Start, params.End,
-		signozHistoryDBName, ruleStateHistoryTableName, ruleID, params.Start, params.End)
+		signozHistoryDBName, ruleStateHistoryTableName, params.Start, params.End,
+		signozHistoryDBName, ruleStateHistoryTableName, params.Start, params.End)
 
 	zap.L().Debug("avg resolution time query", zap.String("query", query))
 	var avgResolutionTime float64
-	err := r.db.QueryRow(ctx, query).Scan(&avgResolutionTime)
+	err := r.db.QueryRow(ctx, query, clickhouse.Named("ruleID", ruleID)).Scan(&avgResolutionTime)
 	if err != nil {
 		return 0, err
 	}
@@ -4943,7 +5306,7 @@ WITH firing_events AS (
     FROM %s.%s
     WHERE overall_state = '` + model.StateFiring.String() + `' 
       AND overall_state_changed = true
-      AND rule_id IN ('%s')
+      AND rule_id = @ruleID
 	  AND unix_milli >= %d AND unix_milli <= %d
 ),
 resolution_events AS (
@@ -4954,7 +5317,7 @@ resolution_events AS (
     FROM %s.%s
     WHERE overall_state = '` + model.StateInactive.String() + `' 
       AND overall_state_changed = true
-      AND rule_id IN ('%s')
+      AND rule_id = @ruleID
 	  AND unix_milli >= %d AND unix_milli <= %d
 ),
 matched_events AS (
@@ -4975,11 +5338,11 @@ GROUP BY ts
 ORDER BY ts ASC;`
 
 	query := fmt.Sprintf(tmpl,
-		signozHistoryDBName, ruleStateHistoryTableName, ruleID, params.Start, params.End,
-		signozHistoryDBName, ruleStateHistoryTableName, ruleID, params.Start, params.End, step)
+		signozHistoryDBName, ruleStateHistoryTableName, params.Start, params.End,
+		signozHistoryDBName, ruleStateHistoryTableName, params.Start, params.End, step)
 
 	zap.L().Debug("avg resolution time by interval query", zap.String("query", query))
-	result, err := r.GetTimeSeriesResultV3(ctx, query)
+	result, err := r.getTimeSeriesResult(ctx, query, clickhouse.Named("ruleID", ruleID))
 	if err != nil || len(result) == 0 {
 		return nil, err
 	}
@@ -4988,12 +5351,12 @@ ORDER BY ts ASC;`
 }
 
 func (r *ClickHouseReader) GetTotalTriggers(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (uint64, error) {
-	query := fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE rule_id = '%s' AND (state_changed = true) AND (state = '%s') AND unix_milli >= %d AND unix_milli <= %d",
-		signozHistoryDBName, ruleStateHistoryTableName, ruleID, model.StateFiring.String(), params.Start, params.End)
+	query := fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE rule_id = @ruleID AND (state_changed = true) AND (state = '%s') AND unix_milli >= %d AND unix_milli <= %d",
+		signozHistoryDBName, ruleStateHistoryTableName, model.StateFiring.String(), params.Start, params.End)
 
 	var totalTriggers uint64
 
-	err := r.db.QueryRow(ctx, query).Scan(&totalTriggers)
+	err := r.db.QueryRow(ctx, query, clickhouse.Named("ruleID", ruleID)).Scan(&totalTriggers)
 	if err != nil {
 		return 0, err
 	}
@@ -5004,10 +5367,72 @@ func (r *ClickHouseReader) GetTotalTriggers(ctx context.Context, ruleID string,
 func (r *ClickHouseReader) GetTriggersByInterval(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (*v3.Series, error) {
 	step := common.MinAllowedStepInterval(params.Start, params.End)
 
-	query := fmt.Sprintf("SELECT count(*), toStartOfInterval(toDateTime(intDiv(unix_milli, 1000)), INTERVAL %d SECOND) as ts FROM %s.%s WHERE rule_id = '%s' AND (state_changed = true) AND (state = '%s') AND unix_milli >= %d AND unix_milli <= %d GROUP BY ts ORDER BY ts ASC",
-		step, signozHistoryDBName, ruleStateHistoryTableName, ruleID, model.StateFiring.String(), params.Start, params.End)
+	query := fmt.Sprintf("SELECT count(*), toStartOfInterval(toDateTime(intDiv(unix_milli, 1000)), INTERVAL %d SECOND) as ts FROM %s.%s WHERE rule_id = @ruleID AND (state_changed = true) AND (state = '%s') AND unix_milli >= %d AND unix_milli <= %d GROUP BY ts ORDER BY ts ASC",
+		step, signozHistoryDBName, ruleStateHistoryTableName, model.StateFiring.String(), params.Start, params.End)
+
+	result, err := r.getTimeSeriesResult(ctx, query, clickhouse.Named("ruleID", ruleID))
+	if err != nil || len(result) == 0 {
+		return nil, err
+	}
+
+	return result[0], nil
+}
+
+func (r *ClickHouseReader) AddSLOErrorRatios(ctx context.Context, errorRatios []model.SLOErrorRatio) error {
+	var statement driver.Batch
+	var err error
+
+	defer func() {
+		if statement != nil {
+			statement.Abort()
+		}
+	}()
+
+	statement, err = r.db.PrepareBatch(ctx, fmt.Sprintf("INSERT INTO %s.%s (rule_id, rule_name, unix_milli, fingerprint, labels, target, error_ratio) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		signozHistoryDBName, sloErrorBudgetTableName))
+
+	if err != nil {
+		return err
+	}
+
+	for _, errorRatio := range errorRatios {
+		err = statement.Append(errorRatio.RuleID, errorRatio.RuleName, errorRatio.UnixMilli, errorRatio.Fingerprint, errorRatio.Labels, errorRatio.Target, errorRatio.ErrorRatio)
+		if err != nil {
+			return err
+		}
+	}
+
+	return statement.Send()
+}
+
+func (r *ClickHouseReader) GetSLOErrorRatio(ctx context.Context, ruleID string, start, end int64) ([]model.SLOErrorBudget, error) {
+	query := fmt.Sprintf(`SELECT
+		fingerprint,
+		any(labels) as labels,
+		avg(error_ratio) as error_ratio
+	FROM %s.%s
+	WHERE rule_id = @ruleID AND unix_milli >= %d AND unix_milli <= %d
+	GROUP BY fingerprint`,
+		signozHistoryDBName, sloErrorBudgetTableName, start, end)
+
+	zap.L().Debug("slo error ratio query", zap.String("query", query))
+	budgets := []model.SLOErrorBudget{}
+	err := r.db.Select(ctx, &budgets, query, clickhouse.Named("ruleID", ruleID))
+	if err != nil {
+		zap.L().Error("Error while reading slo error ratio", zap.Error(err))
+		return nil, err
+	}
+
+	return budgets, nil
+}
+
+func (r *ClickHouseReader) GetSLOErrorRatioByInterval(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (*v3.Series, error) {
+	step := common.MinAllowedStepInterval(params.Start, params.End)
+
+	query := fmt.Sprintf("SELECT avg(error_ratio), toStartOfInterval(toDateTime(intDiv(unix_milli, 1000)), INTERVAL %d SECOND) as ts FROM %s.%s WHERE rule_id = @ruleID AND unix_milli >= %d AND unix_milli <= %d GROUP BY ts ORDER BY ts ASC",
+		step, signozHistoryDBName, sloErrorBudgetTableName, params.Start, params.End)
 
-	result, err := r.GetTimeSeriesResultV3(ctx, query)
+	result, err := r.getTimeSeriesResult(ctx, query, clickhouse.Named("ruleID", ruleID))
 	if err != nil || len(result) == 0 {
 		return nil, err
 	}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 )
 
 type GetStatusFiltersTest struct {
@@ -27,3 +28,24 @@ func TestGetStatusFilters(t *testing.T) {
 		assert.Equal(getStatusFilters(test.query, test.statusParams, test.excludeMap), test.expected)
 	}
 }
+
+func TestRuleStateHistoryLabelConditions(t *testing.T) {
+	conditions, err := ruleStateHistoryLabelConditions(&v3.FilterSet{Items: []v3.FilterItem{
+		{Key: v3.AttributeKey{Key: "service"}, Operator: v3.FilterOperatorEqual, Value: "frontend"},
+		{Key: v3.AttributeKey{Key: "env"}, Operator: v3.FilterOperatorRegex, Value: "prod.*"},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"JSONExtractString(labels, 'service') = 'frontend'",
+		"match(JSONExtractString(labels, 'env'), 'prod.*')",
+	}, conditions)
+
+	conditions, err = ruleStateHistoryLabelConditions(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, conditions)
+
+	_, err = ruleStateHistoryLabelConditions(&v3.FilterSet{Items: []v3.FilterItem{
+		{Key: v3.AttributeKey{Key: "service"}, Operator: "between", Value: "frontend"},
+	}})
+	assert.Error(t, err)
+}
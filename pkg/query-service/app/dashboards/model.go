@@ -99,6 +99,379 @@ func InitDB(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error in creating planned_maintenance table: %s", err.Error())
 	}
 
+	tableSchema = `CREATE TABLE IF NOT EXISTS rule_versions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id INTEGER NOT NULL,
+		version INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		created_by TEXT,
+		UNIQUE(rule_id, version)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating rule_versions table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		resource_type TEXT NOT NULL,
+		resource_id TEXT NOT NULL,
+		action TEXT NOT NULL,
+		actor TEXT,
+		timestamp datetime NOT NULL,
+		diff TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_logs_resource ON audit_logs (resource_type, resource_id);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating audit_logs table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS maintenance_suppressions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		maintenance_id INTEGER NOT NULL,
+		maintenance_name TEXT NOT NULL,
+		rule_id TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		labels TEXT NOT NULL,
+		started_at datetime NOT NULL,
+		ended_at datetime NOT NULL,
+		org_id TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_maintenance_suppressions_maintenance ON maintenance_suppressions (maintenance_id);
+	CREATE INDEX IF NOT EXISTS idx_maintenance_suppressions_rule ON maintenance_suppressions (rule_id);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating maintenance_suppressions table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS notification_templates (
+		channel_id INTEGER PRIMARY KEY,
+		title TEXT NOT NULL,
+		body TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating notification_templates table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS silences (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		matchers TEXT NOT NULL,
+		starts_at datetime NOT NULL,
+		ends_at datetime NOT NULL,
+		comment TEXT NOT NULL DEFAULT '',
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL,
+		updated_at datetime NOT NULL,
+		updated_by TEXT NOT NULL,
+		org_id TEXT
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating silences table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS alert_acks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		matchers TEXT NOT NULL,
+		acked_at datetime NOT NULL,
+		acked_by TEXT NOT NULL,
+		comment TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_alert_acks_acked_at ON alert_acks (acked_at);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating alert_acks table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS delivery_attempts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel TEXT NOT NULL,
+		channel_type TEXT NOT NULL,
+		fingerprints TEXT NOT NULL,
+		attempt INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		timestamp datetime NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_delivery_attempts_channel ON delivery_attempts (channel, timestamp);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating delivery_attempts table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS incidents (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		status TEXT NOT NULL,
+		severity TEXT NOT NULL DEFAULT '',
+		group_key TEXT NOT NULL DEFAULT '',
+		assignee TEXT NOT NULL DEFAULT '',
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL DEFAULT '',
+		updated_at datetime NOT NULL,
+		resolved_at datetime,
+		org_id TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_incidents_status ON incidents (status, group_key);
+	CREATE TABLE IF NOT EXISTS incident_alerts (
+		incident_id INTEGER NOT NULL,
+		fingerprint TEXT NOT NULL,
+		rule_id TEXT NOT NULL,
+		alert_name TEXT NOT NULL,
+		labels TEXT NOT NULL,
+		state TEXT NOT NULL,
+		fired_at datetime NOT NULL,
+		resolved_at datetime,
+		PRIMARY KEY (incident_id, fingerprint)
+	);
+	CREATE TABLE IF NOT EXISTS incident_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		incident_id INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		fingerprint TEXT NOT NULL DEFAULT '',
+		message TEXT NOT NULL,
+		actor TEXT NOT NULL DEFAULT '',
+		timestamp datetime NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_incident_events_incident_id ON incident_events (incident_id, timestamp);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating incidents table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS twilio_calls (
+		id TEXT PRIMARY KEY,
+		channel TEXT NOT NULL,
+		account_sid TEXT NOT NULL,
+		recipient TEXT NOT NULL,
+		rule_id TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		callback_url TEXT NOT NULL,
+		call_sid TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		answered BOOLEAN NOT NULL DEFAULT FALSE,
+		created_at datetime NOT NULL,
+		updated_at datetime NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_twilio_calls_created_at ON twilio_calls (created_at);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating twilio_calls table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS jira_issues (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		issue_key TEXT NOT NULL,
+		issue_url TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		resolved_at datetime
+	);
+	CREATE INDEX IF NOT EXISTS idx_jira_issues_fingerprint ON jira_issues (channel, fingerprint);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating jira_issues table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS chart_snapshots (
+		id TEXT PRIMARY KEY,
+		rule_id TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		image BLOB NOT NULL,
+		created_at datetime NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_chart_snapshots_created_at ON chart_snapshots (created_at);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating chart_snapshots table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS rule_shard_members (
+		id TEXT PRIMARY KEY,
+		started_at datetime NOT NULL,
+		heartbeat_at datetime NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating rule_shard_members table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS rule_manager_leader (
+		name TEXT PRIMARY KEY,
+		holder TEXT NOT NULL,
+		acquired_at datetime NOT NULL,
+		renewed_at datetime NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating rule_manager_leader table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS notification_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id TEXT NOT NULL,
+		alert_name TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		labels TEXT NOT NULL,
+		state TEXT NOT NULL,
+		channel TEXT NOT NULL,
+		channel_type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		payload TEXT NOT NULL,
+		timestamp datetime NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_notification_log_timestamp ON notification_log (timestamp);
+	CREATE INDEX IF NOT EXISTS idx_notification_log_rule_id ON notification_log (rule_id, timestamp);
+	CREATE INDEX IF NOT EXISTS idx_notification_log_channel ON notification_log (channel, timestamp);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating notification_log table: %s", err.Error())
+	}
+
+	// the body of the request is stored once for all the notifications it sent
+	tableSchema = `CREATE TABLE IF NOT EXISTS notification_payloads (
+		id TEXT PRIMARY KEY,
+		payload TEXT NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating notification_payloads table: %s", err.Error())
+	}
+
+	payloadId := `ALTER TABLE notification_log ADD COLUMN payload_id TEXT;`
+	_, err = db.Exec(payloadId)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column payload_id to notification_log table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS delivery_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		channel TEXT NOT NULL,
+		channel_type TEXT NOT NULL,
+		request TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		next_attempt_at datetime NOT NULL,
+		created_at datetime NOT NULL,
+		updated_at datetime NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_delivery_queue_status ON delivery_queue (status, next_attempt_at);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating delivery_queue table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS rule_permissions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id INTEGER NOT NULL,
+		principal_type TEXT NOT NULL,
+		principal TEXT NOT NULL,
+		permission TEXT NOT NULL,
+		UNIQUE(rule_id, principal_type, principal, permission)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating rule_permissions table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS alert_snapshots (
+		rule_id TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		labels TEXT NOT NULL,
+		result_labels TEXT NOT NULL,
+		state TEXT NOT NULL,
+		active_at datetime NOT NULL,
+		fired_at datetime NOT NULL,
+		value REAL NOT NULL,
+		missing INTEGER NOT NULL DEFAULT 0,
+		snapshot_at datetime NOT NULL,
+		PRIMARY KEY (rule_id, fingerprint)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating alert_snapshots table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS alert_ack_events (
+		rule_id TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		fired_at datetime NOT NULL,
+		acked_at datetime NOT NULL,
+		ack_id INTEGER NOT NULL,
+		ack_seconds REAL NOT NULL,
+		PRIMARY KEY (rule_id, fingerprint, fired_at)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating alert_ack_events table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS rule_state_rollups (
+		rule_id TEXT NOT NULL,
+		resolution TEXT NOT NULL,
+		bucket_start datetime NOT NULL,
+		overall_firing_seconds REAL NOT NULL,
+		firing_seconds REAL NOT NULL,
+		pending_seconds REAL NOT NULL,
+		nodata_seconds REAL NOT NULL,
+		transitions INTEGER NOT NULL,
+		firings INTEGER NOT NULL,
+		PRIMARY KEY (rule_id, resolution, bucket_start)
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating rule_state_rollups table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS rule_state_rollup_progress (
+		resolution TEXT PRIMARY KEY,
+		rolled_up_to datetime NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating rule_state_rollup_progress table: %s", err.Error())
+	}
+
+	tableSchema = `CREATE TABLE IF NOT EXISTS firing_annotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		fired_at INTEGER NOT NULL,
+		postmortem_url TEXT NOT NULL DEFAULT '',
+		root_cause TEXT NOT NULL DEFAULT '',
+		text TEXT NOT NULL DEFAULT '',
+		created_by TEXT NOT NULL,
+		created_at datetime NOT NULL,
+		updated_at datetime NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_firing_annotations_rule_id ON firing_annotations (rule_id, fired_at);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating firing_annotations table: %s", err.Error())
+	}
+
+	// the calendar clients fetch the maintenance calendar of the org by its secret token
+	tableSchema = `CREATE TABLE IF NOT EXISTS maintenance_calendar_tokens (
+		org_id TEXT PRIMARY KEY,
+		token TEXT NOT NULL UNIQUE,
+		created_at datetime NOT NULL,
+		created_by TEXT NOT NULL
+	);`
+	_, err = db.Exec(tableSchema)
+	if err != nil {
+		return nil, fmt.Errorf("error in creating maintenance_calendar_tokens table: %s", err.Error())
+	}
+
 	table_schema = `CREATE TABLE IF NOT EXISTS ttl_status (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		transaction_id TEXT NOT NULL,
@@ -134,6 +507,88 @@ func InitDB(dataSourceName string) (*sqlx.DB, error) {
 		return nil, fmt.Errorf("error in adding column updated_by to rules table: %s", err.Error())
 	}
 
+	deletedAt := `ALTER TABLE rules ADD COLUMN deleted_at datetime;`
+	_, err = db.Exec(deletedAt)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column deleted_at to rules table: %s", err.Error())
+	}
+
+	// metadata of the rule definition used to filter the rules
+	// without parsing the data of every rule
+	for _, column := range []string{"alert_type TEXT", "rule_type TEXT", "disabled INTEGER DEFAULT 0", "severity TEXT", "folder TEXT", "tags TEXT", "external_id TEXT"} {
+		_, err = db.Exec(fmt.Sprintf(`ALTER TABLE rules ADD COLUMN %s;`, column))
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return nil, fmt.Errorf("error in adding column %s to rules table: %s", column, err.Error())
+		}
+	}
+
+	// the provisioned rules are managed in the provisioning directory and are read-only in the api
+	provisioned := `ALTER TABLE rules ADD COLUMN provisioned INTEGER DEFAULT 0;`
+	_, err = db.Exec(provisioned)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column provisioned to rules table: %s", err.Error())
+	}
+
+	matchers := `ALTER TABLE planned_maintenance ADD COLUMN matchers TEXT;`
+	_, err = db.Exec(matchers)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column matchers to planned_maintenance table: %s", err.Error())
+	}
+
+	// the severities and channels muted by the partial maintenance
+	for _, column := range []string{"severities", "channels"} {
+		_, err = db.Exec(fmt.Sprintf(`ALTER TABLE planned_maintenance ADD COLUMN %s TEXT;`, column))
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return nil, fmt.Errorf("error in adding column %s to planned_maintenance table: %s", column, err.Error())
+		}
+	}
+
+	mode := `ALTER TABLE planned_maintenance ADD COLUMN mode TEXT;`
+	_, err = db.Exec(mode)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column mode to planned_maintenance table: %s", err.Error())
+	}
+
+	expiresAt := `ALTER TABLE planned_maintenance ADD COLUMN expires_at TIMESTAMP;`
+	_, err = db.Exec(expiresAt)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column expires_at to planned_maintenance table: %s", err.Error())
+	}
+
+	// org owning the rules and maintenance, the rows without an org are shared by all the orgs
+	for _, table := range []string{"rules", "planned_maintenance"} {
+		_, err = db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN org_id TEXT;`, table))
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return nil, fmt.Errorf("error in adding column org_id to %s table: %s", table, err.Error())
+		}
+
+		// the rows created before the orgs were tracked are owned by the org of their
+		// creator, or by the only org
+		_, err = db.Exec(fmt.Sprintf(`UPDATE %[1]s SET org_id=COALESCE(
+			(SELECT users.org_id FROM users WHERE users.email=%[1]s.created_by),
+			(SELECT id FROM organizations WHERE (SELECT count(*) FROM organizations)=1))
+			WHERE COALESCE(org_id, '')='';`, table))
+		if err != nil && !strings.Contains(err.Error(), "no such table") {
+			return nil, fmt.Errorf("error in backfilling column org_id of %s table: %s", table, err.Error())
+		}
+	}
+
+	// org of the user making the change, the logs are only shown to the users of the org
+	auditOrg := `ALTER TABLE audit_logs ADD COLUMN org_id TEXT;`
+	_, err = db.Exec(auditOrg)
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return nil, fmt.Errorf("error in adding column org_id to audit_logs table: %s", err.Error())
+	}
+
+	// the logs recorded before the orgs were tracked are owned by the org of their actor, or by the only org
+	_, err = db.Exec(`UPDATE audit_logs SET org_id=COALESCE(
+		(SELECT users.org_id FROM users WHERE users.email=audit_logs.actor),
+		(SELECT id FROM organizations WHERE (SELECT count(*) FROM organizations)=1))
+		WHERE COALESCE(org_id, '')='';`)
+	if err != nil && !strings.Contains(err.Error(), "no such table") {
+		return nil, fmt.Errorf("error in backfilling column org_id of audit_logs table: %s", err.Error())
+	}
+
 	createdBy = `ALTER TABLE dashboards ADD COLUMN created_by TEXT;`
 	_, err = db.Exec(createdBy)
 	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
@@ -3,12 +3,13 @@ package app
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"math"
 	"net/http"
+	"net/url"
 	"regexp"
 	"slices"
 	"sort"
@@ -22,6 +23,7 @@ import (
 	"github.com/gorilla/websocket"
 	jsoniter "github.com/json-iterator/go"
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/prometheus/promql"
 
 	"go.signoz.io/signoz/pkg/query-service/agentConf"
@@ -463,6 +465,8 @@ func (aH *APIHandler) Respond(w http.ResponseWriter, data interface{}) {
 // RegisterPrivateRoutes registers routes for this handler on the given router
 func (aH *APIHandler) RegisterPrivateRoutes(router *mux.Router) {
 	router.HandleFunc("/api/v1/channels", aH.listChannels).Methods(http.MethodGet)
+	// the internal metrics of the rule engine are scraped on the private port
+	router.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
 }
 
 // RegisterRoutes registers routes for this handler on the given router
@@ -475,26 +479,129 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router, am *AuthMiddleware) {
 	router.HandleFunc("/api/v1/channels/{id}", am.AdminAccess(aH.deleteChannel)).Methods(http.MethodDelete)
 	router.HandleFunc("/api/v1/channels", am.EditAccess(aH.createChannel)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/testChannel", am.EditAccess(aH.testChannel)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/channels/{id}/test", am.EditAccess(aH.testStoredChannel)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/channels/{id}/template", am.ViewAccess(aH.getChannelTemplate)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/channels/{id}/template", am.AdminAccess(aH.setChannelTemplate)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/channels/{id}/template", am.AdminAccess(aH.deleteChannelTemplate)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/channels/{id}/template/preview", am.EditAccess(aH.previewChannelTemplate)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/channels/{id}/deliveries", am.ViewAccess(aH.getChannelDeliveries)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/deliveries", am.ViewAccess(aH.listDeliveries)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/deliveries/queue", am.ViewAccess(aH.listQueuedDeliveries)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/deliveries/queue/{id}/retry", am.EditAccess(aH.retryQueuedDelivery)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/notification_log", am.ViewAccess(aH.listNotificationLog)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/twilio/calls", am.ViewAccess(aH.listTwilioCalls)).Methods(http.MethodGet)
+	// twilio can't authenticate, the status callbacks are verified by the auth token of the channel
+	router.HandleFunc("/api/v1/twilio/calls/{id}/status", am.OpenAccess(aH.handleTwilioCallStatus)).Methods(http.MethodPost)
+	// the charts are fetched by slack and the email clients, their ids can't be guessed
+	router.HandleFunc("/api/v1/charts/{id}", am.OpenAccess(aH.getChartSnapshot)).Methods(http.MethodGet)
 
 	router.HandleFunc("/api/v1/alerts", am.ViewAccess(aH.getAlerts)).Methods(http.MethodGet)
 
 	router.HandleFunc("/api/v1/rules", am.ViewAccess(aH.listRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/external/{externalId:.+}", am.ViewAccess(aH.getRuleByExternalID)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/external/{externalId:.+}", am.EditAccess(aH.putRuleByExternalID)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/rules/external/{externalId:.+}", am.EditAccess(aH.deleteRuleByExternalID)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/rules/trash", am.ViewAccess(aH.listDeletedRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/export", am.ViewAccess(aH.exportRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/export/prometheus", am.ViewAccess(aH.exportPrometheusRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/import", am.EditAccess(aH.importRules)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/import/prometheus", am.EditAccess(aH.importPrometheusRules)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/import/grafana", am.EditAccess(aH.importGrafanaRules)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/validate", am.ViewAccess(aH.validateRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/templates", am.ViewAccess(aH.listRuleTemplates)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/templates/{templateId}", am.ViewAccess(aH.getRuleTemplate)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/templates/{templateId}/instantiate", am.EditAccess(aH.instantiateRuleTemplate)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/folders", am.ViewAccess(aH.listRuleFolders)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/folders/move", am.EditAccess(aH.moveRules)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/shards", am.AdminAccess(aH.listRuleShards)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/leader", am.AdminAccess(aH.getRuleLeader)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/eval_pool", am.AdminAccess(aH.getEvalPoolStats)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/breaker", am.AdminAccess(aH.getCircuitBreaker)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/slow", am.ViewAccess(aH.listSlowRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/noisy", am.ViewAccess(aH.listNoisyRules)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/reliability", am.ViewAccess(aH.getRulesReliability)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/fatigue", am.ViewAccess(aH.getAlertFatigue)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/correlations", am.ViewAccess(aH.listRuleCorrelations)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/alert_counts", am.ViewAccess(aH.getAlertCountsByLabel)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/rules/{id}", am.ViewAccess(aH.getRule)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/rules", am.EditAccess(aH.createRule)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.editRule)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.deleteRule)).Methods(http.MethodDelete)
 	router.HandleFunc("/api/v1/rules/{id}", am.EditAccess(aH.patchRule)).Methods(http.MethodPatch)
+	router.HandleFunc("/api/v1/rules/{id}/restore", am.EditAccess(aH.restoreRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/clone", am.EditAccess(aH.cloneRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/alerts", am.ViewAccess(aH.listRuleAlerts)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/maintenance", am.ViewAccess(aH.getRuleMaintenance)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/health", am.ViewAccess(aH.getRuleHealth)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/trace", am.ViewAccess(aH.getEvalTraces)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/trace", am.EditAccess(aH.enableEvalTrace)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/trace", am.EditAccess(aH.disableEvalTrace)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/rules/{id}/shadow", am.ViewAccess(aH.getRuleShadow)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/shadow", am.EditAccess(aH.startRuleShadow)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/shadow", am.EditAccess(aH.stopRuleShadow)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/rules/{id}/shadow/promote", am.EditAccess(aH.promoteRuleShadow)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/permissions", am.ViewAccess(aH.getRulePermissions)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/permissions", am.AdminAccess(aH.setRulePermissions)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/testRule", am.EditAccess(aH.testRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/testRule/preview", am.EditAccess(aH.previewTestRule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/history", am.ViewAccess(aH.queryAlertStateHistory)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/history/purge", am.AdminAccess(aH.purgeDeletedRulesHistory)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/history/export", am.ViewAccess(aH.exportAlertStateHistory)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/stats", am.ViewAccess(aH.getRuleStats)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/timeline", am.ViewAccess(aH.getRuleStateHistory)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/top_contributors", am.ViewAccess(aH.getRuleStateHistoryTopContributors)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/rules/{id}/history/overall_status", am.ViewAccess(aH.getOverallStateTransitions)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/history/segments", am.ViewAccess(aH.getRuleStateSegments)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/history/rollups", am.ViewAccess(aH.getRuleStateRollups)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/history/top_offenders", am.ViewAccess(aH.getRuleTopOffenders)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/annotations", am.ViewAccess(aH.listFiringAnnotations)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/annotations", am.EditAccess(aH.createFiringAnnotation)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/annotations/{annotationId}", am.EditAccess(aH.updateFiringAnnotation)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/rules/{id}/annotations/{annotationId}", am.EditAccess(aH.deleteFiringAnnotation)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/rules/{id}/error_budget", am.ViewAccess(aH.getErrorBudget)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/error_budget/history", am.ViewAccess(aH.getErrorBudgetHistory)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/rules/{id}/versions", am.ViewAccess(aH.getRuleVersions)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/versions/diff", am.ViewAccess(aH.diffRuleVersions)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/rules/{id}/versions/{version}/rollback", am.EditAccess(aH.rollbackRule)).Methods(http.MethodPost)
+
+	router.HandleFunc("/api/v1/audit_logs", am.ViewAccess(aH.listAuditLogs)).Methods(http.MethodGet)
 
 	router.HandleFunc("/api/v1/downtime_schedules", am.ViewAccess(aH.listDowntimeSchedules)).Methods(http.MethodGet)
+	// the calendar clients can't send the credentials of the user, the calendar is served by its secret token
+	router.HandleFunc("/api/v1/downtime_schedules/calendar/{token}.ics", am.OpenAccess(aH.getDowntimeScheduleCalendar)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/downtime_schedules/calendar/token", am.ViewAccess(aH.getDowntimeScheduleCalendarToken)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/downtime_schedules/calendar/token", am.EditAccess(aH.rotateDowntimeScheduleCalendarToken)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/downtime_schedules/calendar/token", am.EditAccess(aH.revokeDowntimeScheduleCalendarToken)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/alert_settings", am.ViewAccess(aH.getAlertSettings)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.ViewAccess(aH.getDowntimeSchedule)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/downtime_schedules", am.EditAccess(aH.createDowntimeSchedule)).Methods(http.MethodPost)
 	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.EditAccess(aH.editDowntimeSchedule)).Methods(http.MethodPut)
 	router.HandleFunc("/api/v1/downtime_schedules/{id}", am.EditAccess(aH.deleteDowntimeSchedule)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/downtime_schedules/quick", am.EditAccess(aH.createQuickDowntimeSchedule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/downtime_schedules/deploys", am.EditAccess(aH.startDeployDowntimeSchedule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/downtime_schedules/deploys/{id}/finish", am.EditAccess(aH.finishDeployDowntimeSchedule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/downtime_schedules/preview", am.ViewAccess(aH.previewDowntimeSchedule)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/downtime_schedules/{id}/preview", am.ViewAccess(aH.previewDowntimeScheduleByID)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/downtime_schedules/{id}/history", am.ViewAccess(aH.getDowntimeScheduleHistory)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/maintenance_suppressions", am.ViewAccess(aH.listMaintenanceSuppressions)).Methods(http.MethodGet)
+
+	router.HandleFunc("/api/v1/silences", am.ViewAccess(aH.listSilences)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/silences", am.EditAccess(aH.createSilence)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/silences/{id}", am.ViewAccess(aH.getSilence)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/silences/{id}", am.EditAccess(aH.editSilence)).Methods(http.MethodPut)
+	router.HandleFunc("/api/v1/silences/{id}", am.EditAccess(aH.expireSilence)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/alerts/acks", am.ViewAccess(aH.listAlertAcks)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/incidents", am.ViewAccess(aH.listIncidents)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/incidents", am.EditAccess(aH.createIncident)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/incidents/{id}", am.ViewAccess(aH.getIncident)).Methods(http.MethodGet)
+	router.HandleFunc("/api/v1/incidents/{id}", am.EditAccess(aH.updateIncident)).Methods(http.MethodPatch)
+	router.HandleFunc("/api/v1/incidents/{id}", am.EditAccess(aH.deleteIncident)).Methods(http.MethodDelete)
+	router.HandleFunc("/api/v1/incidents/{id}/alerts", am.EditAccess(aH.addIncidentAlerts)).Methods(http.MethodPost)
+	router.HandleFunc("/api/v1/incidents/{id}/alerts/{fingerprint}", am.EditAccess(aH.removeIncidentAlert)).Methods(http.MethodDelete)
+	// slack can't authenticate, the requests are verified by the signing secret of the app
+	router.HandleFunc("/api/v1/slack/actions", am.OpenAccess(aH.handleSlackAction)).Methods(http.MethodPost)
+	// the external incident tools authenticate with the inbound alert token
+	router.HandleFunc("/api/v1/alerts/inbound", am.OpenAccess(aH.handleInboundAlertAction)).Methods(http.MethodPost)
 
 	router.HandleFunc("/api/v1/dashboards", am.ViewAccess(aH.getDashboards)).Methods(http.MethodGet)
 	router.HandleFunc("/api/v1/dashboards", am.EditAccess(aH.createDashboards)).Methods(http.MethodPost)
@@ -606,7 +713,7 @@ func (aH *APIHandler) getRule(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	ruleResponse, err := aH.ruleManager.GetRule(r.Context(), id)
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
 		return
 	}
 	aH.Respond(w, ruleResponse)
@@ -667,6 +774,53 @@ func (aH *APIHandler) PopulateTemporality(ctx context.Context, qp *v3.QueryRange
 	return nil
 }
 
+func (aH *APIHandler) listAuditLogs(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditLogFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	logs, err := aH.ruleManager.RuleDB().GetAuditLogs(r.Context(), filter)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, logs)
+}
+
+// getDowntimeScheduleHistory returns who changed the maintenance and the alerts it suppressed
+func (aH *APIHandler) getDowntimeScheduleHistory(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseMaintenanceSuppressionFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	history, err := aH.ruleManager.GetMaintenanceHistory(r.Context(), mux.Vars(r)["id"], filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, history)
+}
+
+// listMaintenanceSuppressions returns the alerts suppressed by the maintenance e.g. of a rule during an incident
+func (aH *APIHandler) listMaintenanceSuppressions(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseMaintenanceSuppressionFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	suppressions, err := aH.ruleManager.RuleDB().GetMaintenanceSuppressions(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, suppressions)
+}
+
 func (aH *APIHandler) listDowntimeSchedules(w http.ResponseWriter, r *http.Request) {
 	schedules, err := aH.ruleManager.RuleDB().GetAllPlannedMaintenance(r.Context())
 	if err != nil {
@@ -703,6 +857,59 @@ func (aH *APIHandler) listDowntimeSchedules(w http.ResponseWriter, r *http.Reque
 	aH.Respond(w, schedules)
 }
 
+// getDowntimeScheduleCalendar returns the maintenance windows of the org of the token
+// as an iCal feed for the calendar subscriptions
+func (aH *APIHandler) getDowntimeScheduleCalendar(w http.ResponseWriter, r *http.Request) {
+	calendar, err := aH.ruleManager.MaintenanceCalendar(r.Context(), mux.Vars(r)["token"])
+	if errors.Is(err, rules.ErrInvalidCalendarToken) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: err}, nil)
+		return
+	}
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="maintenance.ics"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(calendar)); err != nil {
+		zap.L().Error("error writing the maintenance calendar", zap.Error(err))
+	}
+}
+
+// getDowntimeScheduleCalendarToken returns the token of the calendar url of the org
+func (aH *APIHandler) getDowntimeScheduleCalendarToken(w http.ResponseWriter, r *http.Request) {
+	token, err := aH.ruleManager.RuleDB().GetMaintenanceCalendarToken(r.Context())
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: errors.New("the maintenance calendar has no token")}, nil)
+		return
+	}
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, token)
+}
+
+// rotateDowntimeScheduleCalendarToken creates a new token of the calendar url, the previous url stops working
+func (aH *APIHandler) rotateDowntimeScheduleCalendarToken(w http.ResponseWriter, r *http.Request) {
+	token, err := aH.ruleManager.RotateMaintenanceCalendarToken(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, token)
+}
+
+// revokeDowntimeScheduleCalendarToken stops serving the calendar by its url
+func (aH *APIHandler) revokeDowntimeScheduleCalendarToken(w http.ResponseWriter, r *http.Request) {
+	if err := aH.ruleManager.RevokeMaintenanceCalendarToken(r.Context()); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, nil)
+}
+
 func (aH *APIHandler) getDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
 	schedule, err := aH.ruleManager.RuleDB().GetPlannedMaintenanceByID(r.Context(), id)
@@ -725,12 +932,13 @@ func (aH *APIHandler) createDowntimeSchedule(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	_, err = aH.ruleManager.RuleDB().CreatePlannedMaintenance(r.Context(), schedule)
+	warnings := aH.downtimeScheduleWarnings(r.Context(), &schedule, "")
+	id, err := aH.ruleManager.RuleDB().CreatePlannedMaintenance(r.Context(), schedule)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
-	aH.Respond(w, nil)
+	aH.Respond(w, rules.PlannedMaintenanceWarnings{Id: id, Warnings: warnings})
 }
 
 func (aH *APIHandler) editDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
@@ -745,12 +953,24 @@ func (aH *APIHandler) editDowntimeSchedule(w http.ResponseWriter, r *http.Reques
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
+	warnings := aH.downtimeScheduleWarnings(r.Context(), &schedule, id)
 	_, err = aH.ruleManager.RuleDB().EditPlannedMaintenance(r.Context(), schedule, id)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
-	aH.Respond(w, nil)
+	aH.Respond(w, rules.PlannedMaintenanceWarnings{Warnings: warnings})
+}
+
+// downtimeScheduleWarnings returns the existing maintenance overlapping the written one,
+// the write goes ahead when the overlaps can't be found
+func (aH *APIHandler) downtimeScheduleWarnings(ctx context.Context, schedule *rules.PlannedMaintenance, id string) []rules.MaintenanceOverlap {
+	overlaps, err := aH.ruleManager.PlannedMaintenanceOverlaps(ctx, schedule, id)
+	if err != nil {
+		zap.L().Error("failed to find the overlapping maintenance", zap.Error(err))
+		return []rules.MaintenanceOverlap{}
+	}
+	return overlaps
 }
 
 func (aH *APIHandler) deleteDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
@@ -763,235 +983,1543 @@ func (aH *APIHandler) deleteDowntimeSchedule(w http.ResponseWriter, r *http.Requ
 	aH.Respond(w, nil)
 }
 
-func (aH *APIHandler) getRuleStats(w http.ResponseWriter, r *http.Request) {
-	ruleID := mux.Vars(r)["id"]
-	params := model.QueryRuleStateHistory{}
-	err := json.NewDecoder(r.Body).Decode(&params)
+// createQuickDowntimeSchedule creates the maintenance muting the rules from now until its ttl has passed
+func (aH *APIHandler) createQuickDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
+	var quick rules.QuickMaintenance
+	err := json.NewDecoder(r.Body).Decode(&quick)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
-
-	totalCurrentTriggers, err := aH.reader.GetTotalTriggers(r.Context(), ruleID, &params)
+	if err := quick.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	schedule, err := aH.ruleManager.CreateQuickMaintenance(r.Context(), quick)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
-	currentTriggersSeries, err := aH.reader.GetTriggersByInterval(r.Context(), ruleID, &params)
+	aH.Respond(w, schedule)
+}
+
+// startDeployDowntimeSchedule is called by the CI to mute the alerts of the deploy, the
+// maintenance is closed by finishDeployDowntimeSchedule or after the duration of the deploy
+func (aH *APIHandler) startDeployDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
+	var deploy rules.DeployMaintenance
+	err := json.NewDecoder(r.Body).Decode(&deploy)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := deploy.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	schedule, err := aH.ruleManager.StartDeployMaintenance(r.Context(), deploy)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
+	aH.Respond(w, schedule)
+}
 
-	currentAvgResolutionTime, err := aH.reader.GetAvgResolutionTime(r.Context(), ruleID, &params)
+func (aH *APIHandler) finishDeployDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	err := aH.ruleManager.FinishDeployMaintenance(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("downtime schedule %s not found", id)}, nil)
+		return
+	}
+	if errors.Is(err, rules.ErrNotQuickMaintenance) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
-	currentAvgResolutionTimeSeries, err := aH.reader.GetAvgResolutionTimeByInterval(r.Context(), ruleID, &params)
+	aH.Respond(w, nil)
+}
+
+// previewDowntimeSchedule returns the rules and active alerts the unsaved maintenance would mute
+func (aH *APIHandler) previewDowntimeSchedule(w http.ResponseWriter, r *http.Request) {
+	var schedule rules.PlannedMaintenance
+	err := json.NewDecoder(r.Body).Decode(&schedule)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := schedule.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	preview, err := aH.ruleManager.PreviewPlannedMaintenance(r.Context(), &schedule)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
+	aH.Respond(w, preview)
+}
 
-	if params.End-params.Start >= 86400000 {
-		days := int64(math.Ceil(float64(params.End-params.Start) / 86400000))
-		params.Start -= days * 86400000
-		params.End -= days * 86400000
-	} else {
-		params.Start -= 86400000
-		params.End -= 86400000
+// previewDowntimeScheduleByID returns the rules and active alerts the saved maintenance would mute
+func (aH *APIHandler) previewDowntimeScheduleByID(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	schedule, err := aH.ruleManager.RuleDB().GetPlannedMaintenanceByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("downtime schedule %s not found", id)}, nil)
+		return
 	}
-
-	totalPastTriggers, err := aH.reader.GetTotalTriggers(r.Context(), ruleID, &params)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
-	pastTriggersSeries, err := aH.reader.GetTriggersByInterval(r.Context(), ruleID, &params)
+	preview, err := aH.ruleManager.PreviewPlannedMaintenance(r.Context(), schedule)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
+	aH.Respond(w, preview)
+}
 
-	pastAvgResolutionTime, err := aH.reader.GetAvgResolutionTime(r.Context(), ruleID, &params)
+// listAlertAcks returns the acknowledgements still applied to the firing alerts
+func (aH *APIHandler) listAlertAcks(w http.ResponseWriter, r *http.Request) {
+	acks, err := aH.ruleManager.RuleDB().GetAlertAcks(r.Context(), time.Now().Add(-rules.AlertAckRetention))
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
-	pastAvgResolutionTimeSeries, err := aH.reader.GetAvgResolutionTimeByInterval(r.Context(), ruleID, &params)
+	aH.Respond(w, acks)
+}
+
+// handleSlackAction handles the clicks on the buttons of the slack alerts, slack
+// posts the interaction as the payload form field and shows the message in the response
+func (aH *APIHandler) handleSlackAction(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	err = rules.VerifySlackRequest(constants.SlackSigningSecret, r.Header.Get("X-Slack-Request-Timestamp"), r.Header.Get("X-Slack-Signature"), body, time.Now())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorForbidden, Err: err}, nil)
+		return
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
-	if math.IsNaN(currentAvgResolutionTime) || math.IsInf(currentAvgResolutionTime, 0) {
-		currentAvgResolutionTime = 0
+	var interaction rules.SlackInteraction
+	if err := json.Unmarshal([]byte(form.Get("payload")), &interaction); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	response, err := aH.ruleManager.HandleSlackAction(r.Context(), &interaction)
+	if err != nil {
+		zap.L().Error("failed to handle the slack action", zap.Error(err))
+		// slack shows the response to the user who clicked the button
+		response = &rules.SlackActionResponse{ResponseType: "ephemeral", Text: "Failed to handle the action: " + err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		zap.L().Error("failed to write the slack response", zap.Error(err))
 	}
-	if math.IsNaN(pastAvgResolutionTime) || math.IsInf(pastAvgResolutionTime, 0) {
-		pastAvgResolutionTime = 0
+}
+
+// handleInboundAlertAction acks or resolves the alert from an external incident tool
+func (aH *APIHandler) handleInboundAlertAction(w http.ResponseWriter, r *http.Request) {
+	if err := rules.VerifyInboundToken(constants.InboundAlertToken, r.Header.Get("Authorization")); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorUnauthorized, Err: err}, nil)
+		return
 	}
 
-	stats := model.Stats{
-		TotalCurrentTriggers:           totalCurrentTriggers,
-		TotalPastTriggers:              totalPastTriggers,
-		CurrentTriggersSeries:          currentTriggersSeries,
-		PastTriggersSeries:             pastTriggersSeries,
-		CurrentAvgResolutionTime:       strconv.FormatFloat(currentAvgResolutionTime, 'f', -1, 64),
-		PastAvgResolutionTime:          strconv.FormatFloat(pastAvgResolutionTime, 'f', -1, 64),
-		CurrentAvgResolutionTimeSeries: currentAvgResolutionTimeSeries,
-		PastAvgResolutionTimeSeries:    pastAvgResolutionTimeSeries,
+	var action rules.InboundAlertAction
+	if err := json.NewDecoder(r.Body).Decode(&action); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
 	}
 
-	aH.Respond(w, stats)
+	result, err := aH.ruleManager.HandleInboundAction(r.Context(), &action)
+	if err != nil {
+		switch {
+		case errors.Is(err, rules.ErrAlertNotActive):
+			RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: err}, nil)
+		case errors.Is(err, rules.ErrInvalidInboundAction), errors.Is(err, rules.ErrMissingInboundAlert):
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		default:
+			RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		}
+		return
+	}
+	aH.Respond(w, result)
 }
 
-func (aH *APIHandler) getOverallStateTransitions(w http.ResponseWriter, r *http.Request) {
-	ruleID := mux.Vars(r)["id"]
-	params := model.QueryRuleStateHistory{}
-	err := json.NewDecoder(r.Body).Decode(&params)
+// incidentApiError maps the error of the incident to the api error
+func incidentApiError(err error, id string) *model.ApiError {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("incident %s not found", id)}
+	case errors.Is(err, rules.ErrMissingIncidentTitle), errors.Is(err, rules.ErrInvalidIncidentStatus), errors.Is(err, rules.ErrAlertNotActive):
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+}
+
+// listIncidents returns the incidents without their alerts and timeline, latest first
+func (aH *APIHandler) listIncidents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseIncidentFilter(r)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
 		return
 	}
 
-	stateItems, err := aH.reader.GetOverallStateTransitions(r.Context(), ruleID, &params)
+	incidents, err := aH.ruleManager.RuleDB().GetIncidents(r.Context(), filter)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
 	}
+	aH.Respond(w, incidents)
+}
 
-	aH.Respond(w, stateItems)
+func (aH *APIHandler) getIncident(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	incident, err := aH.ruleManager.RuleDB().GetIncident(r.Context(), id)
+	if err != nil {
+		RespondError(w, incidentApiError(err, id), nil)
+		return
+	}
+	aH.Respond(w, incident)
 }
 
-func (aH *APIHandler) metaForLinks(ctx context.Context, rule *rules.GettableRule) ([]v3.FilterItem, []v3.AttributeKey, map[string]v3.AttributeKey) {
-	filterItems := []v3.FilterItem{}
-	groupBy := []v3.AttributeKey{}
-	keys := make(map[string]v3.AttributeKey)
+// createIncident groups the active alerts with the fingerprints into an incident
+func (aH *APIHandler) createIncident(w http.ResponseWriter, r *http.Request) {
+	var postable rules.PostableIncident
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
 
-	if rule.AlertType == rules.AlertTypeLogs {
-		logFields, err := aH.reader.GetLogFields(ctx)
-		if err == nil {
-			params := &v3.QueryRangeParamsV3{
-				CompositeQuery: rule.RuleCondition.CompositeQuery,
-			}
-			keys = model.GetLogFieldsV3(ctx, params, logFields)
-		} else {
-			zap.L().Error("failed to get log fields using empty keys; the link might not work as expected", zap.Error(err))
-		}
-	} else if rule.AlertType == rules.AlertTypeTraces {
-		traceFields, err := aH.reader.GetSpanAttributeKeys(ctx)
-		if err == nil {
-			keys = traceFields
-		} else {
-			zap.L().Error("failed to get span attributes using empty keys; the link might not work as expected", zap.Error(err))
-		}
+	incident, err := aH.ruleManager.CreateIncident(r.Context(), postable)
+	if err != nil {
+		RespondError(w, incidentApiError(err, ""), nil)
+		return
 	}
+	aH.Respond(w, incident)
+}
 
-	if rule.AlertType == rules.AlertTypeLogs || rule.AlertType == rules.AlertTypeTraces {
-		if rule.RuleCondition.CompositeQuery != nil {
-			if rule.RuleCondition.QueryType() == v3.QueryTypeBuilder {
-				selectedQuery := rule.RuleCondition.GetSelectedQueryName()
-				if rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery] != nil &&
-					rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].Filters != nil {
-					filterItems = rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].Filters.Items
-				}
-				if rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery] != nil &&
-					rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].GroupBy != nil {
-					groupBy = rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].GroupBy
-				}
-			}
-		}
+// updateIncident changes the title, severity, status or assignee of the incident
+func (aH *APIHandler) updateIncident(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var update rules.IncidentUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
 	}
-	return filterItems, groupBy, keys
+
+	incident, err := aH.ruleManager.UpdateIncident(r.Context(), id, update)
+	if err != nil {
+		RespondError(w, incidentApiError(err, id), nil)
+		return
+	}
+	aH.Respond(w, incident)
 }
 
-func (aH *APIHandler) getRuleStateHistory(w http.ResponseWriter, r *http.Request) {
-	ruleID := mux.Vars(r)["id"]
-	params := model.QueryRuleStateHistory{}
-	err := json.NewDecoder(r.Body).Decode(&params)
+func (aH *APIHandler) deleteIncident(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := aH.ruleManager.DeleteIncident(r.Context(), id); err != nil {
+		RespondError(w, incidentApiError(err, id), nil)
+		return
+	}
+	aH.Respond(w, "incident successfully deleted")
+}
+
+// addIncidentAlerts adds the active alerts with the fingerprints to the incident
+func (aH *APIHandler) addIncidentAlerts(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var req struct {
+		Fingerprints []string `json:"fingerprints"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	incident, err := aH.ruleManager.AddIncidentAlerts(r.Context(), id, req.Fingerprints)
+	if err != nil {
+		RespondError(w, incidentApiError(err, id), nil)
+		return
+	}
+	aH.Respond(w, incident)
+}
+
+func (aH *APIHandler) removeIncidentAlert(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	incident, err := aH.ruleManager.RemoveIncidentAlert(r.Context(), vars["id"], vars["fingerprint"])
+	if err != nil {
+		RespondError(w, incidentApiError(err, vars["id"]), nil)
+		return
+	}
+	aH.Respond(w, incident)
+}
+
+func silenceApiError(err error, id string) *model.ApiError {
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("silence %s not found", id)}
+	}
+	return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+}
+
+// listSilences returns the silences, the status query param selects the
+// pending, active or expired silences
+func (aH *APIHandler) listSilences(w http.ResponseWriter, r *http.Request) {
+	filter := &rules.SilenceFilter{Status: rules.SilenceStatus(r.URL.Query().Get("status"))}
+	switch filter.Status {
+	case "", rules.SilenceStatusPending, rules.SilenceStatusActive, rules.SilenceStatusExpired:
+	default:
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid status %s, must be pending, active or expired", filter.Status)}, nil)
+		return
+	}
+
+	silences, err := aH.ruleManager.RuleDB().GetSilences(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, silences)
+}
+
+func (aH *APIHandler) getSilence(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	silence, err := aH.ruleManager.RuleDB().GetSilenceByID(r.Context(), id)
+	if err != nil {
+		RespondError(w, silenceApiError(err, id), nil)
+		return
+	}
+	aH.Respond(w, silence)
+}
+
+func (aH *APIHandler) createSilence(w http.ResponseWriter, r *http.Request) {
+	var silence rules.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = time.Now()
+	}
+	if err := silence.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	created, err := aH.ruleManager.CreateSilence(r.Context(), silence)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, created)
+}
+
+func (aH *APIHandler) editSilence(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	var silence rules.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := silence.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	edited, err := aH.ruleManager.EditSilence(r.Context(), silence, id)
+	if err != nil {
+		RespondError(w, silenceApiError(err, id), nil)
+		return
+	}
+	aH.Respond(w, edited)
+}
+
+// expireSilence ends the silence now, the expired silence is kept
+func (aH *APIHandler) expireSilence(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := aH.ruleManager.RuleDB().ExpireSilence(r.Context(), id, time.Now()); err != nil {
+		RespondError(w, silenceApiError(err, id), nil)
+		return
+	}
+	aH.Respond(w, "silence successfully expired")
+}
+
+func (aH *APIHandler) getRuleStats(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+	params := model.QueryRuleStateHistory{}
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	stats, err := aH.ruleManager.RuleStats(r.Context(), ruleID, &params)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, stats)
+}
+
+func (aH *APIHandler) getOverallStateTransitions(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+	params := model.QueryRuleStateHistory{}
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	stateItems, err := aH.ruleManager.OverallStateTransitions(r.Context(), ruleID, &params)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+
+	aH.Respond(w, stateItems)
+}
+
+func (aH *APIHandler) getRuleStateSegments(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+	params := model.QueryRuleStateHistory{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if params.Order == "" {
+		params.Order = "asc"
+	}
+	if params.Limit == 0 {
+		params.Limit = 100
+	}
+	if err := params.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	segments, err := aH.ruleManager.StateSegments(r.Context(), ruleID, &params)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, segments)
+}
+
+func (aH *APIHandler) getRuleStateRollups(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+	params := rules.QueryStateRollups{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if params.Resolution == "" {
+		params.Resolution = rules.RollupDaily
+	}
+	if err := params.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	rollups, err := aH.ruleManager.StateRollups(r.Context(), ruleID, &params)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, rollups)
+}
+
+// getRuleTopOffenders returns the series of the rule firing the most often or the
+// longest in the time range
+func (aH *APIHandler) getRuleTopOffenders(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+	params := rules.QueryTopOffenders{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if params.Limit == 0 {
+		params.Limit = 10
+	}
+	if err := params.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	offenders, err := aH.ruleManager.TopOffendingSeries(r.Context(), ruleID, &params)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, offenders)
+}
+
+// firingAnnotationApiError maps the errors of the annotations to the api errors
+func firingAnnotationApiError(err error, id string) *model.ApiError {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("annotation %s not found", id)}
+	case errors.Is(err, rules.ErrEmptyAnnotation), errors.Is(err, rules.ErrInvalidPostmortemURL), errors.Is(err, rules.ErrFiringPeriodNotFound):
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	return ruleApiError(err, model.ErrorInternal)
+}
+
+// listFiringAnnotations returns the annotations of the firing periods of the rule
+// starting in the time range in milliseconds
+func (aH *APIHandler) listFiringAnnotations(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+	start, err := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid start %s", r.URL.Query().Get("start"))}, nil)
+		return
+	}
+	end, err := strconv.ParseInt(r.URL.Query().Get("end"), 10, 64)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid end %s", r.URL.Query().Get("end"))}, nil)
+		return
+	}
+
+	annotations, err := aH.ruleManager.FiringAnnotations(r.Context(), ruleID, start, end)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, annotations)
+}
+
+// createFiringAnnotation attaches the postmortem link, root cause or text to the firing
+// period of the rule
+func (aH *APIHandler) createFiringAnnotation(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+	var postable rules.PostableFiringAnnotation
+	if err := json.NewDecoder(r.Body).Decode(&postable); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := postable.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	annotation, err := aH.ruleManager.AnnotateFiringPeriod(r.Context(), ruleID, postable)
+	if err != nil {
+		RespondError(w, firingAnnotationApiError(err, ""), nil)
+		return
+	}
+	aH.Respond(w, annotation)
+}
+
+func (aH *APIHandler) updateFiringAnnotation(w http.ResponseWriter, r *http.Request) {
+	ruleID, id := mux.Vars(r)["id"], mux.Vars(r)["annotationId"]
+	var note rules.AnnotationNote
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	annotation, err := aH.ruleManager.UpdateFiringAnnotation(r.Context(), ruleID, id, note)
+	if err != nil {
+		RespondError(w, firingAnnotationApiError(err, id), nil)
+		return
+	}
+	aH.Respond(w, annotation)
+}
+
+func (aH *APIHandler) deleteFiringAnnotation(w http.ResponseWriter, r *http.Request) {
+	ruleID, id := mux.Vars(r)["id"], mux.Vars(r)["annotationId"]
+	if err := aH.ruleManager.DeleteFiringAnnotation(r.Context(), ruleID, id); err != nil {
+		RespondError(w, firingAnnotationApiError(err, id), nil)
+		return
+	}
+	aH.Respond(w, "annotation successfully deleted")
+}
+
+func (aH *APIHandler) metaForLinks(ctx context.Context, rule *rules.GettableRule) ([]v3.FilterItem, []v3.AttributeKey, map[string]v3.AttributeKey) {
+	filterItems := []v3.FilterItem{}
+	groupBy := []v3.AttributeKey{}
+	keys := make(map[string]v3.AttributeKey)
+
+	if rule.AlertType == rules.AlertTypeLogs {
+		logFields, err := aH.reader.GetLogFields(ctx)
+		if err == nil {
+			params := &v3.QueryRangeParamsV3{
+				CompositeQuery: rule.RuleCondition.CompositeQuery,
+			}
+			keys = model.GetLogFieldsV3(ctx, params, logFields)
+		} else {
+			zap.L().Error("failed to get log fields using empty keys; the link might not work as expected", zap.Error(err))
+		}
+	} else if rule.AlertType == rules.AlertTypeTraces {
+		traceFields, err := aH.reader.GetSpanAttributeKeys(ctx)
+		if err == nil {
+			keys = traceFields
+		} else {
+			zap.L().Error("failed to get span attributes using empty keys; the link might not work as expected", zap.Error(err))
+		}
+	}
+
+	if rule.AlertType == rules.AlertTypeLogs || rule.AlertType == rules.AlertTypeTraces {
+		if rule.RuleCondition.CompositeQuery != nil {
+			if rule.RuleCondition.QueryType() == v3.QueryTypeBuilder {
+				selectedQuery := rule.RuleCondition.GetSelectedQueryName()
+				if rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery] != nil &&
+					rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].Filters != nil {
+					filterItems = rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].Filters.Items
+				}
+				if rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery] != nil &&
+					rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].GroupBy != nil {
+					groupBy = rule.RuleCondition.CompositeQuery.BuilderQueries[selectedQuery].GroupBy
+				}
+			}
+		}
+	}
+	return filterItems, groupBy, keys
+}
+
+func (aH *APIHandler) getRuleStateHistory(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+	params := model.QueryRuleStateHistory{}
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if err := params.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	res, err := aH.ruleManager.RuleStateHistory(r.Context(), ruleID, &params)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+
+	rule, err := aH.ruleManager.GetRule(r.Context(), ruleID)
+	if err == nil {
+		for idx := range res.Items {
+			lbls := make(map[string]string)
+			err := json.Unmarshal([]byte(res.Items[idx].Labels), &lbls)
+			if err != nil {
+				continue
+			}
+			filterItems, groupBy, keys := aH.metaForLinks(r.Context(), rule)
+			newFilters := contextlinks.PrepareFilters(lbls, filterItems, groupBy, keys)
+			end := time.Unix(res.Items[idx].UnixMilli/1000, 0)
+			// why are we subtracting 3 minutes?
+			// the query range is calculated based on the rule's evalWindow and evalDelay
+			// alerts have 2 minutes delay built in, so we need to subtract that from the start time
+			// to get the correct query range
+			start := end.Add(-time.Duration(rule.EvalWindow)).Add(-3 * time.Minute)
+			if rule.AlertType == rules.AlertTypeLogs {
+				res.Items[idx].RelatedLogsLink = contextlinks.PrepareLinksToLogs(start, end, newFilters)
+			} else if rule.AlertType == rules.AlertTypeTraces {
+				res.Items[idx].RelatedTracesLink = contextlinks.PrepareLinksToTraces(start, end, newFilters)
+			}
+		}
+	}
+
+	aH.Respond(w, res)
+}
+
+// queryAlertStateHistory returns a page of the state changes of the alerts across the rules
+func (aH *APIHandler) queryAlertStateHistory(w http.ResponseWriter, r *http.Request) {
+	params := model.QueryAlertStateHistory{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if params.Order == "" {
+		params.Order = "desc"
+	}
+	if params.Limit == 0 {
+		params.Limit = 100
+	}
+	if err := params.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	page, err := aH.ruleManager.QueryStateHistory(r.Context(), &params)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, page)
+}
+
+// exportResponseWriter sets the headers of the exported file on its first write so the
+// export failing before can still respond with the error
+type exportResponseWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (e *exportResponseWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", e.contentType)
+		e.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, e.filename))
+		e.w.WriteHeader(http.StatusOK)
+	}
+	return e.w.Write(p)
+}
+
+// exportAlertStateHistory streams the state changes of the alerts matching the query as csv or parquet
+func (aH *APIHandler) exportAlertStateHistory(w http.ResponseWriter, r *http.Request) {
+	format := rules.HistoryExportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = rules.HistoryExportCSV
+	}
+	if err := format.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	params := model.QueryAlertStateHistory{}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	params.Order = "asc"
+	if err := params.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	ew := &exportResponseWriter{w: w, contentType: format.ContentType(), filename: "alert_history." + string(format)}
+	if err := aH.ruleManager.ExportStateHistory(r.Context(), &params, format, ew); err != nil {
+		if !ew.started {
+			RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+			return
+		}
+		zap.L().Error("error exporting the alert state history", zap.Error(err))
+	}
+}
+
+// purgeDeletedRulesHistory deletes the state history of the rules no longer stored
+func (aH *APIHandler) purgeDeletedRulesHistory(w http.ResponseWriter, r *http.Request) {
+	purged, err := aH.ruleManager.PurgeDeletedRulesHistory(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, map[string][]string{"ruleIds": purged})
+}
+
+func (aH *APIHandler) getRuleStateHistoryTopContributors(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+	params := model.QueryRuleStateHistory{}
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	res, err := aH.ruleManager.RuleStateHistoryTopContributors(r.Context(), ruleID, &params)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+
+	rule, err := aH.ruleManager.GetRule(r.Context(), ruleID)
+	if err == nil {
+		for idx := range res {
+			lbls := make(map[string]string)
+			err := json.Unmarshal([]byte(res[idx].Labels), &lbls)
+			if err != nil {
+				continue
+			}
+			filterItems, groupBy, keys := aH.metaForLinks(r.Context(), rule)
+			newFilters := contextlinks.PrepareFilters(lbls, filterItems, groupBy, keys)
+			end := time.Unix(params.End/1000, 0)
+			start := time.Unix(params.Start/1000, 0)
+			if rule.AlertType == rules.AlertTypeLogs {
+				res[idx].RelatedLogsLink = contextlinks.PrepareLinksToLogs(start, end, newFilters)
+			} else if rule.AlertType == rules.AlertTypeTraces {
+				res[idx].RelatedTracesLink = contextlinks.PrepareLinksToTraces(start, end, newFilters)
+			}
+		}
+	}
+
+	aH.Respond(w, res)
+}
+
+func (aH *APIHandler) getErrorBudget(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+
+	res, err := aH.ruleManager.GetErrorBudget(r.Context(), ruleID)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, res)
+}
+
+func (aH *APIHandler) getErrorBudgetHistory(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+	params := model.QueryRuleStateHistory{}
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	res, err := aH.ruleManager.GetErrorBudgetHistory(r.Context(), ruleID, &params)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, res)
+}
+
+func (aH *APIHandler) exportRules(w http.ResponseWriter, r *http.Request) {
+
+	filter, err := parseRuleFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	bundle, err := aH.ruleManager.ExportRules(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Header().Set("Content-Disposition", `attachment; filename="rules.yaml"`)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(bundle); err != nil {
+		zap.L().Error("error writing the rule bundle", zap.Error(err))
+	}
+}
+
+func (aH *APIHandler) exportPrometheusRules(w http.ResponseWriter, r *http.Request) {
+
+	filter, err := parseRuleFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	export, err := aH.ruleManager.ExportPrometheusRules(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, export)
+}
+
+func (aH *APIHandler) importRules(w http.ResponseWriter, r *http.Request) {
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	results, err := aH.ruleManager.ImportRules(r.Context(), body)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, results)
+}
+
+func (aH *APIHandler) importPrometheusRules(w http.ResponseWriter, r *http.Request) {
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+
+	conversions, err := aH.ruleManager.ImportPrometheusRules(r.Context(), body, dryRun)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, conversions)
+}
+
+func (aH *APIHandler) importGrafanaRules(w http.ResponseWriter, r *http.Request) {
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+
+	conversions, err := aH.ruleManager.ImportGrafanaRules(r.Context(), body, dryRun)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, conversions)
+}
+
+// externalRuleError returns the api error of the rule api by external id
+func externalRuleError(err error) *model.ApiError {
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("rule not found")}
+	}
+	return ruleApiError(err, model.ErrorBadData)
+}
+
+func (aH *APIHandler) getRuleByExternalID(w http.ResponseWriter, r *http.Request) {
+	externalID := mux.Vars(r)["externalId"]
+
+	rule, err := aH.ruleManager.GetRuleByExternalID(r.Context(), externalID)
+	if err != nil {
+		RespondError(w, externalRuleError(err), nil)
+		return
+	}
+
+	aH.Respond(w, rule)
+}
+
+func (aH *APIHandler) putRuleByExternalID(w http.ResponseWriter, r *http.Request) {
+	externalID := mux.Vars(r)["externalId"]
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	rule, _, err := aH.ruleManager.PutRuleByExternalID(r.Context(), externalID, string(body))
+	if err != nil {
+		RespondError(w, externalRuleError(err), ruleErrorDetails(err))
+		return
+	}
+
+	aH.Respond(w, rule)
+}
+
+func (aH *APIHandler) deleteRuleByExternalID(w http.ResponseWriter, r *http.Request) {
+	externalID := mux.Vars(r)["externalId"]
+
+	if err := aH.ruleManager.DeleteRuleByExternalID(r.Context(), externalID); err != nil {
+		RespondError(w, externalRuleError(err), nil)
+		return
+	}
+
+	aH.Respond(w, "rule successfully deleted")
+}
+
+func (aH *APIHandler) listRuleFolders(w http.ResponseWriter, r *http.Request) {
+
+	folders, err := aH.ruleManager.ListRuleFolders(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, folders)
+}
+
+// listRuleShards returns the query service replicas sharing the evaluation of the rules
+func (aH *APIHandler) listRuleShards(w http.ResponseWriter, r *http.Request) {
+
+	members, err := aH.ruleManager.ShardMembers(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, members)
+}
+
+// getRuleLeader returns the replica evaluating the rules and the replicas standing by
+func (aH *APIHandler) getRuleLeader(w http.ResponseWriter, r *http.Request) {
+
+	status, err := aH.ruleManager.RuleLeaderStatus(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, status)
+}
+
+// getEvalPoolStats returns the queueing metrics of the rule evaluations
+func (aH *APIHandler) getEvalPoolStats(w http.ResponseWriter, r *http.Request) {
+	aH.Respond(w, aH.ruleManager.EvalPoolStats())
+}
+
+// getCircuitBreaker returns whether the rule evaluations are paused because the datastore is failing
+func (aH *APIHandler) getCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	aH.Respond(w, aH.ruleManager.CircuitBreakerStatus())
+}
+
+// listSlowRules returns the rules with the most expensive evaluations and their queries
+func (aH *APIHandler) listSlowRules(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid limit %s", l)}, nil)
+			return
+		}
+	}
+
+	slowRules, err := aH.ruleManager.SlowRules(rules.RuleCostSort(r.URL.Query().Get("sortBy")), limit)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, slowRules)
+}
+
+// listNoisyRules returns the rules firing the most over the window for the alert fatigue cleanup
+func (aH *APIHandler) listNoisyRules(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid limit %s", l)}, nil)
+			return
+		}
+	}
+	var window time.Duration
+	if wd := r.URL.Query().Get("window"); wd != "" {
+		var err error
+		window, err = time.ParseDuration(wd)
+		if err != nil || window <= 0 {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid window %s", wd)}, nil)
+			return
+		}
+	}
+
+	sortBy := rules.NoisyRuleSort(r.URL.Query().Get("sortBy"))
+	if err := sortBy.Validate(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	report, err := aH.ruleManager.NoisyRules(r.Context(), window, sortBy, limit)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, report)
+}
+
+// getAlertCountsByLabel returns the firings of the rules over the window broken down
+// by the value of the label
+func (aH *APIHandler) getAlertCountsByLabel(w http.ResponseWriter, r *http.Request) {
+	label := r.URL.Query().Get("label")
+	if err := rules.ValidateAlertLabel(label); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	limit := 20
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid limit %s", l)}, nil)
+			return
+		}
+	}
+	var window time.Duration
+	if wd := r.URL.Query().Get("window"); wd != "" {
+		var err error
+		window, err = time.ParseDuration(wd)
+		if err != nil || window <= 0 {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid window %s", wd)}, nil)
+			return
+		}
+	}
+
+	report, err := aH.ruleManager.AlertCountsByLabel(r.Context(), label, window, limit)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, report)
+}
+
+func (aH *APIHandler) getRulesReliability(w http.ResponseWriter, r *http.Request) {
+	var window time.Duration
+	if wd := r.URL.Query().Get("window"); wd != "" {
+		var err error
+		window, err = time.ParseDuration(wd)
+		if err != nil || window <= 0 {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid window %s", wd)}, nil)
+			return
+		}
+	}
+
+	report, err := aH.ruleManager.RuleReliability(r.Context(), window)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, report)
+}
+
+// getAlertFatigue returns the rules flapping, never firing or never acknowledged with the recommendations
+func (aH *APIHandler) getAlertFatigue(w http.ResponseWriter, r *http.Request) {
+	var window time.Duration
+	if wd := r.URL.Query().Get("window"); wd != "" {
+		var err error
+		window, err = time.ParseDuration(wd)
+		if err != nil || window <= 0 {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid window %s", wd)}, nil)
+			return
+		}
+	}
+
+	report, err := aH.ruleManager.AlertFatigue(r.Context(), window)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, report)
+}
+
+// listRuleCorrelations returns the rules whose alerts consistently fire together
+func (aH *APIHandler) listRuleCorrelations(w http.ResponseWriter, r *http.Request) {
+	durations := map[string]time.Duration{}
+	for _, param := range []string{"window", "within"} {
+		if v := r.URL.Query().Get(param); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid %s %s", param, v)}, nil)
+				return
+			}
+			durations[param] = d
+		}
+	}
+
+	report, err := aH.ruleManager.CorrelatedRules(r.Context(), durations["window"], durations["within"])
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, report)
+}
+
+type moveRulesRequest struct {
+	RuleIDs []string `json:"ruleIds"`
+	Folder  string   `json:"folder"`
+}
+
+func (aH *APIHandler) moveRules(w http.ResponseWriter, r *http.Request) {
+
+	req := moveRulesRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if len(req.RuleIDs) == 0 {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("ruleIds are required")}, nil)
+		return
+	}
+
+	rules, err := aH.ruleManager.MoveRules(r.Context(), req.RuleIDs, req.Folder)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorBadData), nil)
+		return
+	}
+
+	aH.Respond(w, rules)
+}
+
+func (aH *APIHandler) listRuleTemplates(w http.ResponseWriter, r *http.Request) {
+	aH.Respond(w, rules.GetRuleTemplates())
+}
+
+func (aH *APIHandler) getRuleTemplate(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := rules.GetRuleTemplate(mux.Vars(r)["templateId"])
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, tmpl)
+}
+
+type instantiateRuleTemplateRequest struct {
+	Variables map[string]interface{} `json:"variables"`
+}
+
+func (aH *APIHandler) instantiateRuleTemplate(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["templateId"]
+	if _, err := rules.GetRuleTemplate(id); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: err}, nil)
+		return
+	}
+
+	req := instantiateRuleTemplateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	rule, err := aH.ruleManager.InstantiateRuleTemplate(r.Context(), id, req.Variables)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, ruleErrorDetails(err))
+		return
+	}
+
+	aH.Respond(w, rule)
+}
+
+func (aH *APIHandler) listDeletedRules(w http.ResponseWriter, r *http.Request) {
+
+	rules, err := aH.ruleManager.ListDeletedRules(r.Context())
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, rules)
+}
+
+func (aH *APIHandler) restoreRule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	rule, err := aH.ruleManager.RestoreRule(r.Context(), id)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorBadData), nil)
+		return
+	}
+
+	aH.Respond(w, rule)
+}
+
+func (aH *APIHandler) cloneRule(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	rule, err := aH.ruleManager.CloneRule(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("rule %s not found", id)}, nil)
+		return
+	}
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorBadData), ruleErrorDetails(err))
+		return
+	}
+
+	aH.Respond(w, rule)
+}
+
+func (aH *APIHandler) getRulePermissions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err := aH.ruleManager.RuleDB().CheckRulePermission(r.Context(), id, rules.RulePermissionView); err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+
+	permissions, err := aH.ruleManager.RuleDB().GetRulePermissions(r.Context(), id)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, permissions)
+}
+
+func (aH *APIHandler) setRulePermissions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	permissions := []rules.RulePermission{}
+	if err := json.NewDecoder(r.Body).Decode(&permissions); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	if _, err := aH.ruleManager.RuleDB().GetStoredRule(r.Context(), id); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("rule %s not found", id)}, nil)
+		return
+	}
+
+	if err := aH.ruleManager.RuleDB().SetRulePermissions(r.Context(), id, permissions); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, permissions)
+}
+
+// getRuleMaintenance returns the maintenance currently or soon muting the rule
+func (aH *APIHandler) getRuleMaintenance(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+
+	maintenance, err := aH.ruleManager.GetRuleMaintenance(r.Context(), ruleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("rule %s not found", ruleID)}, nil)
+		return
+	}
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, maintenance)
+}
+
+// getRuleHealth returns the health of the rule and the backoff of its failing evaluations
+func (aH *APIHandler) getRuleHealth(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+
+	health, err := aH.ruleManager.RuleHealth(r.Context(), ruleID)
+	if errors.Is(err, rules.ErrRuleNotLoaded) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("rule %s: %w", ruleID, err)}, nil)
+		return
+	}
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, health)
+}
+
+type enableEvalTraceRequest struct {
+	// Duration is how long the evaluations are traced, 15 minutes when not set
+	Duration rules.Duration `json:"duration"`
+}
+
+// enableEvalTrace captures the queries, the results and the decisions of the evaluations of the rule
+func (aH *APIHandler) enableEvalTrace(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+
+	var req enableEvalTraceRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+			return
+		}
+	}
+	if req.Duration < 0 {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("trace duration cannot be negative")}, nil)
+		return
+	}
+
+	status, err := aH.ruleManager.EnableEvalTrace(r.Context(), ruleID, time.Duration(req.Duration))
+	if errors.Is(err, rules.ErrRuleNotLoaded) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("rule %s: %w", ruleID, err)}, nil)
+		return
+	}
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, status)
+}
+
+// disableEvalTrace stops capturing the evaluations of the rule, the captured ones are kept
+func (aH *APIHandler) disableEvalTrace(w http.ResponseWriter, r *http.Request) {
+	status, err := aH.ruleManager.DisableEvalTrace(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, status)
+}
+
+// getEvalTraces returns the last captured evaluations of the rule
+func (aH *APIHandler) getEvalTraces(w http.ResponseWriter, r *http.Request) {
+	status, err := aH.ruleManager.EvalTraces(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, status)
+}
+
+type startRuleShadowRequest struct {
+	// Rule is the edited definition of the rule evaluated in shadow
+	Rule json.RawMessage `json:"rule"`
+	// Duration is how long the shadow definition is evaluated, 24 hours when not set
+	Duration rules.Duration `json:"duration"`
+}
+
+// startRuleShadow evaluates the edited definition of the rule alongside the live one
+// without sending its alerts
+func (aH *APIHandler) startRuleShadow(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+
+	var req startRuleShadowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	if len(req.Rule) == 0 {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("rule definition is required")}, nil)
+		return
+	}
+	if req.Duration < 0 {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("shadow duration cannot be negative")}, nil)
+		return
+	}
+
+	status, err := aH.ruleManager.StartShadow(r.Context(), ruleID, string(req.Rule), time.Duration(req.Duration))
+	if errors.Is(err, rules.ErrRuleNotLoaded) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("rule %s: %w", ruleID, err)}, nil)
+		return
+	}
+	if err != nil {
+		if details := ruleErrorDetails(err); details != nil {
+			RespondError(w, ruleApiError(err, model.ErrorBadData), details)
+			return
+		}
+		RespondError(w, ruleApiError(err, model.ErrorBadData), nil)
+		return
+	}
+	aH.Respond(w, status)
+}
+
+// getRuleShadow compares what the shadow definition of the rule would have done with the live one
+func (aH *APIHandler) getRuleShadow(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+
+	status, err := aH.ruleManager.ShadowStatus(r.Context(), ruleID)
+	if errors.Is(err, rules.ErrNoShadow) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("rule %s: %w", ruleID, err)}, nil)
+		return
+	}
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, status)
+}
+
+// stopRuleShadow stops evaluating the shadow definition of the rule
+func (aH *APIHandler) stopRuleShadow(w http.ResponseWriter, r *http.Request) {
+	if err := aH.ruleManager.StopShadow(r.Context(), mux.Vars(r)["id"]); err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, "shadow evaluation stopped")
+}
+
+// promoteRuleShadow applies the shadow definition of the rule as its live definition
+func (aH *APIHandler) promoteRuleShadow(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+
+	err := aH.ruleManager.PromoteShadow(r.Context(), ruleID)
+	if errors.Is(err, rules.ErrNoShadow) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("rule %s: %w", ruleID, err)}, nil)
+		return
+	}
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+	aH.Respond(w, "rule successfully edited")
+}
+
+func (aH *APIHandler) listRuleAlerts(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+
+	filter, err := parseRuleAlertsFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	alerts, err := aH.ruleManager.ListRuleAlerts(r.Context(), ruleID, filter)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+
+	aH.Respond(w, alerts)
+}
+
+func (aH *APIHandler) getRuleVersions(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+
+	versions, err := aH.ruleManager.GetRuleVersions(r.Context(), ruleID)
+	if err != nil {
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
+		return
+	}
+
+	aH.Respond(w, versions)
+}
+
+func (aH *APIHandler) diffRuleVersions(w http.ResponseWriter, r *http.Request) {
+	ruleID := mux.Vars(r)["id"]
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid from version")}, nil)
 		return
 	}
-	if err := params.Validate(); err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid to version")}, nil)
 		return
 	}
 
-	res, err := aH.reader.ReadRuleStateHistoryByRuleID(r.Context(), ruleID, &params)
+	diff, err := aH.ruleManager.DiffRuleVersions(r.Context(), ruleID, from, to)
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		RespondError(w, ruleApiError(err, model.ErrorBadData), nil)
 		return
 	}
 
-	rule, err := aH.ruleManager.GetRule(r.Context(), ruleID)
-	if err == nil {
-		for idx := range res.Items {
-			lbls := make(map[string]string)
-			err := json.Unmarshal([]byte(res.Items[idx].Labels), &lbls)
-			if err != nil {
-				continue
-			}
-			filterItems, groupBy, keys := aH.metaForLinks(r.Context(), rule)
-			newFilters := contextlinks.PrepareFilters(lbls, filterItems, groupBy, keys)
-			end := time.Unix(res.Items[idx].UnixMilli/1000, 0)
-			// why are we subtracting 3 minutes?
-			// the query range is calculated based on the rule's evalWindow and evalDelay
-			// alerts have 2 minutes delay built in, so we need to subtract that from the start time
-			// to get the correct query range
-			start := end.Add(-time.Duration(rule.EvalWindow)).Add(-3 * time.Minute)
-			if rule.AlertType == rules.AlertTypeLogs {
-				res.Items[idx].RelatedLogsLink = contextlinks.PrepareLinksToLogs(start, end, newFilters)
-			} else if rule.AlertType == rules.AlertTypeTraces {
-				res.Items[idx].RelatedTracesLink = contextlinks.PrepareLinksToTraces(start, end, newFilters)
-			}
-		}
-	}
-
-	aH.Respond(w, res)
+	aH.Respond(w, diff)
 }
 
-func (aH *APIHandler) getRuleStateHistoryTopContributors(w http.ResponseWriter, r *http.Request) {
+func (aH *APIHandler) rollbackRule(w http.ResponseWriter, r *http.Request) {
 	ruleID := mux.Vars(r)["id"]
-	params := model.QueryRuleStateHistory{}
-	err := json.NewDecoder(r.Body).Decode(&params)
+
+	version, err := strconv.Atoi(mux.Vars(r)["version"])
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid version")}, nil)
 		return
 	}
 
-	res, err := aH.reader.ReadRuleStateHistoryTopContributorsByRuleID(r.Context(), ruleID, &params)
+	rule, err := aH.ruleManager.RollbackRule(r.Context(), ruleID, version)
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		RespondError(w, ruleApiError(err, model.ErrorBadData), nil)
 		return
 	}
 
-	rule, err := aH.ruleManager.GetRule(r.Context(), ruleID)
-	if err == nil {
-		for idx := range res {
-			lbls := make(map[string]string)
-			err := json.Unmarshal([]byte(res[idx].Labels), &lbls)
-			if err != nil {
-				continue
-			}
-			filterItems, groupBy, keys := aH.metaForLinks(r.Context(), rule)
-			newFilters := contextlinks.PrepareFilters(lbls, filterItems, groupBy, keys)
-			end := time.Unix(params.End/1000, 0)
-			start := time.Unix(params.Start/1000, 0)
-			if rule.AlertType == rules.AlertTypeLogs {
-				res[idx].RelatedLogsLink = contextlinks.PrepareLinksToLogs(start, end, newFilters)
-			} else if rule.AlertType == rules.AlertTypeTraces {
-				res[idx].RelatedTracesLink = contextlinks.PrepareLinksToTraces(start, end, newFilters)
-			}
-		}
-	}
-
-	aH.Respond(w, res)
+	aH.Respond(w, rule)
 }
 
 func (aH *APIHandler) listRules(w http.ResponseWriter, r *http.Request) {
 
-	rules, err := aH.ruleManager.ListRuleStates(r.Context())
+	filter, err := parseRuleFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	rules, err := aH.ruleManager.ListRuleStates(r.Context(), filter)
 	if err != nil {
 		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
 		return
@@ -1002,6 +2530,24 @@ func (aH *APIHandler) listRules(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, rules)
 }
 
+// getAlertSettings returns the rules, channels and maintenance of the alert settings page
+func (aH *APIHandler) getAlertSettings(w http.ResponseWriter, r *http.Request) {
+
+	filter, err := parseRuleFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	settings, err := aH.ruleManager.GetAlertSettings(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, settings)
+}
+
 func (aH *APIHandler) getDashboards(w http.ResponseWriter, r *http.Request) {
 
 	allDashboards, err := dashboards.GetDashboards(r.Context())
@@ -1238,6 +2784,33 @@ func (aH *APIHandler) testRule(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, response)
 }
 
+// previewTestRule evaluates the rule once and returns the rendered alerts without sending them
+func (aH *APIHandler) previewTestRule(w http.ResponseWriter, r *http.Request) {
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		zap.L().Error("Error in getting req body in preview test rule API", zap.Error(err))
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	alerts, apiErr := aH.ruleManager.PreviewNotification(ctx, string(body))
+	if apiErr != nil {
+		RespondError(w, apiErr, nil)
+		return
+	}
+
+	response := map[string]interface{}{
+		"alertCount": len(alerts),
+		"alerts":     alerts,
+	}
+	aH.Respond(w, response)
+}
+
 func (aH *APIHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"]
@@ -1245,7 +2818,7 @@ func (aH *APIHandler) deleteRule(w http.ResponseWriter, r *http.Request) {
 	err := aH.ruleManager.DeleteRule(r.Context(), id)
 
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
 		return
 	}
 
@@ -1268,7 +2841,7 @@ func (aH *APIHandler) patchRule(w http.ResponseWriter, r *http.Request) {
 	gettableRule, err := aH.ruleManager.PatchRule(r.Context(), string(body), id)
 
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
 		return
 	}
 
@@ -1289,7 +2862,11 @@ func (aH *APIHandler) editRule(w http.ResponseWriter, r *http.Request) {
 	err = aH.ruleManager.EditRule(r.Context(), string(body), id)
 
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		if details := ruleErrorDetails(err); details != nil {
+			RespondError(w, ruleApiError(err, model.ErrorBadData), details)
+			return
+		}
+		RespondError(w, ruleApiError(err, model.ErrorInternal), nil)
 		return
 	}
 
@@ -1307,9 +2884,167 @@ func (aH *APIHandler) getChannel(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, channel)
 }
 
+// getChannelDeliveries returns the requests the query service sent to the channel, latest first
+func (aH *APIHandler) getChannelDeliveries(w http.ResponseWriter, r *http.Request) {
+	channel, apiErrorObj := aH.ruleManager.RuleDB().GetChannel(mux.Vars(r)["id"])
+	if apiErrorObj != nil {
+		RespondError(w, apiErrorObj, nil)
+		return
+	}
+
+	filter, err := parseDeliveryAttemptFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	filter.Channel = channel.Name
+
+	attempts, err := aH.ruleManager.RuleDB().GetDeliveryAttempts(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, attempts)
+}
+
+// listDeliveries returns the requests sent to all the channels and the alertmanager, latest first
+func (aH *APIHandler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseDeliveryAttemptFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+	filter.Channel = r.URL.Query().Get("channel")
+
+	attempts, err := aH.ruleManager.RuleDB().GetDeliveryAttempts(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, attempts)
+}
+
+// listNotificationLog returns the notifications of the alerts sent to the channels, latest first
+func (aH *APIHandler) listNotificationLog(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseNotificationLogFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	entries, err := aH.ruleManager.RuleDB().GetNotificationLog(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, entries)
+}
+
+// listTwilioCalls returns the voice calls made for the alerts and whether they were answered
+func (aH *APIHandler) listTwilioCalls(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTwilioCallFilter(r)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	calls, err := aH.ruleManager.RuleDB().GetTwilioCalls(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, calls)
+}
+
+// handleTwilioCallStatus records the status of the voice call twilio reports when the call ends
+func (aH *APIHandler) handleTwilioCallStatus(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := r.ParseForm(); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	call, err := aH.ruleManager.HandleTwilioCallStatus(r.Context(), id, r.Header.Get(rules.TwilioSignatureHeader), r.PostForm)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("twilio call %s not found", id)}, nil)
+		case errors.Is(err, rules.ErrInvalidTwilioSignature):
+			RespondError(w, &model.ApiError{Typ: model.ErrorForbidden, Err: err}, nil)
+		default:
+			RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		}
+		return
+	}
+	aH.Respond(w, call)
+}
+
+// getChartSnapshot returns the png chart of the alert attached to the notification
+func (aH *APIHandler) getChartSnapshot(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	snapshot, err := aH.ruleManager.RuleDB().GetChartSnapshot(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("chart %s not found", id)}, nil)
+			return
+		}
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=604800, immutable")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(snapshot.Image); err != nil {
+		zap.L().Error("error writing the chart", zap.Error(err))
+	}
+}
+
+// listQueuedDeliveries returns the failed deliveries waiting in the retry queue and the ones given up
+func (aH *APIHandler) listQueuedDeliveries(w http.ResponseWriter, r *http.Request) {
+	filter := &rules.QueuedDeliveryFilter{
+		Channel: r.URL.Query().Get("channel"),
+		Status:  rules.QueuedDeliveryStatus(r.URL.Query().Get("status")),
+	}
+	switch filter.Status {
+	case "", rules.QueuedDeliveryPending, rules.QueuedDeliveryDead:
+	default:
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid status %s, must be pending or dead", filter.Status)}, nil)
+		return
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("invalid limit %s", limit)}, nil)
+			return
+		}
+	}
+
+	deliveries, err := aH.ruleManager.RuleDB().GetQueuedDeliveries(r.Context(), filter)
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, deliveries)
+}
+
+// retryQueuedDelivery retries the queued delivery on the next run of the queue
+func (aH *APIHandler) retryQueuedDelivery(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	delivery, err := aH.ruleManager.RetryQueuedDelivery(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("queued delivery %s not found", id)}, nil)
+		return
+	}
+	if err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, delivery)
+}
+
 func (aH *APIHandler) deleteChannel(w http.ResponseWriter, r *http.Request) {
 	id := mux.Vars(r)["id"]
-	apiErrorObj := aH.ruleManager.RuleDB().DeleteChannel(id)
+	apiErrorObj := aH.ruleManager.RuleDB().DeleteChannel(r.Context(), id)
 	if apiErrorObj != nil {
 		RespondError(w, apiErrorObj, nil)
 		return
@@ -1352,6 +3087,21 @@ func (aH *APIHandler) testChannel(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, "test alert sent")
 }
 
+// testStoredChannel sends a test alert to the stored channel and returns the responses of its providers
+func (aH *APIHandler) testStoredChannel(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	result, err := aH.ruleManager.TestChannel(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			RespondError(w, &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("channel %s not found", id)}, nil)
+			return
+		}
+		RespondError(w, &model.ApiError{Typ: model.ErrorInternal, Err: err}, nil)
+		return
+	}
+	aH.Respond(w, result)
+}
+
 func (aH *APIHandler) editChannel(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"]
@@ -1371,7 +3121,7 @@ func (aH *APIHandler) editChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, apiErrorObj := aH.ruleManager.RuleDB().EditChannel(receiver, id)
+	_, apiErrorObj := aH.ruleManager.RuleDB().EditChannel(r.Context(), receiver, id)
 
 	if apiErrorObj != nil {
 		RespondError(w, apiErrorObj, nil)
@@ -1399,7 +3149,7 @@ func (aH *APIHandler) createChannel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, apiErrorObj := aH.ruleManager.RuleDB().CreateChannel(receiver)
+	_, apiErrorObj := aH.ruleManager.RuleDB().CreateChannel(r.Context(), receiver)
 
 	if apiErrorObj != nil {
 		RespondError(w, apiErrorObj, nil)
@@ -1410,6 +3160,65 @@ func (aH *APIHandler) createChannel(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// channelTemplateApiError maps the error of the channel template to the api error
+func channelTemplateApiError(err error) *model.ApiError {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: fmt.Errorf("channel template not found")}
+	case errors.Is(err, rules.ErrChannelTemplateNotSupported), errors.Is(err, rules.ErrInvalidChannelTemplate):
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+}
+
+func (aH *APIHandler) getChannelTemplate(w http.ResponseWriter, r *http.Request) {
+	template, err := aH.ruleManager.GetChannelTemplate(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		RespondError(w, channelTemplateApiError(err), nil)
+		return
+	}
+	aH.Respond(w, template)
+}
+
+func (aH *APIHandler) setChannelTemplate(w http.ResponseWriter, r *http.Request) {
+	var template rules.ChannelTemplate
+	if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	stored, err := aH.ruleManager.SetChannelTemplate(r.Context(), mux.Vars(r)["id"], template)
+	if err != nil {
+		RespondError(w, channelTemplateApiError(err), nil)
+		return
+	}
+	aH.Respond(w, stored)
+}
+
+func (aH *APIHandler) deleteChannelTemplate(w http.ResponseWriter, r *http.Request) {
+	if err := aH.ruleManager.DeleteChannelTemplate(r.Context(), mux.Vars(r)["id"]); err != nil {
+		RespondError(w, channelTemplateApiError(err), nil)
+		return
+	}
+	aH.Respond(w, "channel template successfully deleted")
+}
+
+// previewChannelTemplate renders the template against the sample alerts without storing it
+func (aH *APIHandler) previewChannelTemplate(w http.ResponseWriter, r *http.Request) {
+	var preview rules.ChannelTemplatePreview
+	if err := json.NewDecoder(r.Body).Decode(&preview); err != nil && !errors.Is(err, io.EOF) {
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	rendered, err := aH.ruleManager.PreviewChannelTemplate(r.Context(), mux.Vars(r)["id"], preview)
+	if err != nil {
+		RespondError(w, channelTemplateApiError(err), nil)
+		return
+	}
+	aH.Respond(w, rendered)
+}
+
 func (aH *APIHandler) getAlerts(w http.ResponseWriter, r *http.Request) {
 	params := r.URL.Query()
 	amEndpoint := constants.GetAlertManagerApiPrefix()
@@ -1429,6 +3238,47 @@ func (aH *APIHandler) getAlerts(w http.ResponseWriter, r *http.Request) {
 	aH.Respond(w, string(body))
 }
 
+// ruleApiError returns the api error of the failed rule operation, the
+// operations not permitted on the rule are reported as forbidden
+func ruleApiError(err error, typ model.ErrorType) *model.ApiError {
+	var permissionErr *rules.RulePermissionError
+	if errors.As(err, &permissionErr) {
+		return &model.ApiError{Typ: model.ErrorForbidden, Err: err}
+	}
+	var provisionedErr *rules.RuleProvisionedError
+	if errors.As(err, &provisionedErr) {
+		return &model.ApiError{Typ: model.ErrorForbidden, Err: err}
+	}
+	// the rules of the other orgs are not found
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.ApiError{Typ: model.ErrorNotFound, Err: err}
+	}
+	return &model.ApiError{Typ: typ, Err: err}
+}
+
+// ruleErrorDetails returns the structured details of the rule validation error, if any
+func ruleErrorDetails(err error) interface{} {
+	var unitErr *rules.UnitMismatchError
+	if errors.As(err, &unitErr) {
+		return unitErr
+	}
+	return nil
+}
+
+// validateRule reports all the errors and warnings of the rule without saving it
+func (aH *APIHandler) validateRule(w http.ResponseWriter, r *http.Request) {
+
+	defer r.Body.Close()
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		zap.L().Error("Error in getting req body for validate rule API", zap.Error(err))
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		return
+	}
+
+	aH.Respond(w, rules.ValidateRule(body))
+}
+
 func (aH *APIHandler) createRule(w http.ResponseWriter, r *http.Request) {
 
 	defer r.Body.Close()
@@ -1441,7 +3291,7 @@ func (aH *APIHandler) createRule(w http.ResponseWriter, r *http.Request) {
 
 	rule, err := aH.ruleManager.CreateRule(r.Context(), string(body))
 	if err != nil {
-		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, nil)
+		RespondError(w, &model.ApiError{Typ: model.ErrorBadData, Err: err}, ruleErrorDetails(err))
 		return
 	}
 
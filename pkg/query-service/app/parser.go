@@ -27,6 +27,7 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/postprocess"
+	"go.signoz.io/signoz/pkg/query-service/rules"
 	"go.signoz.io/signoz/pkg/query-service/utils"
 	querytemplate "go.signoz.io/signoz/pkg/query-service/utils/queryTemplate"
 )
@@ -70,6 +71,251 @@ func parseRegisterEventRequest(r *http.Request) (*model.RegisterEventParams, err
 	return postData, nil
 }
 
+// parseRuleFilter reads the filter of the rules list from the query params
+func parseRuleFilter(r *http.Request) (*rules.StoredRuleFilter, error) {
+	query := r.URL.Query()
+	filter := &rules.StoredRuleFilter{
+		AlertType: rules.AlertType(query.Get("alertType")),
+		RuleType:  rules.RuleType(query.Get("ruleType")),
+		Severity:  query.Get("severity"),
+		Tag:       query.Get("tag"),
+	}
+
+	if query.Has("folder") {
+		folder := query.Get("folder")
+		filter.Folder = &folder
+	}
+
+	switch query.Get("state") {
+	case "":
+	case "enabled":
+		disabled := false
+		filter.Disabled = &disabled
+	case "disabled":
+		disabled := true
+		filter.Disabled = &disabled
+	default:
+		return nil, fmt.Errorf("invalid state %s, must be enabled or disabled", query.Get("state"))
+	}
+
+	return filter, nil
+}
+
+func parseAuditLogFilter(r *http.Request) (*rules.AuditLogFilter, error) {
+	query := r.URL.Query()
+	filter := &rules.AuditLogFilter{
+		ResourceType: rules.AuditResourceType(query.Get("resourceType")),
+		ResourceId:   query.Get("resourceId"),
+		Actor:        query.Get("actor"),
+	}
+
+	switch filter.ResourceType {
+	case "", rules.AuditResourceRule, rules.AuditResourceChannel, rules.AuditResourceMaintenance:
+	default:
+		return nil, fmt.Errorf("invalid resourceType %s, must be rule, channel or maintenance", filter.ResourceType)
+	}
+
+	var err error
+	if start := query.Get("start"); start != "" {
+		if filter.Start, err = parseMetricsTime(start); err != nil {
+			return nil, err
+		}
+	}
+	if end := query.Get("end"); end != "" {
+		if filter.End, err = parseMetricsTime(end); err != nil {
+			return nil, err
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 0 {
+			return nil, fmt.Errorf("invalid limit %s", limit)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseMaintenanceSuppressionFilter reads the filter of the alerts suppressed by the maintenance from the query params
+func parseMaintenanceSuppressionFilter(r *http.Request) (*rules.MaintenanceSuppressionFilter, error) {
+	query := r.URL.Query()
+	filter := &rules.MaintenanceSuppressionFilter{
+		MaintenanceId: query.Get("maintenanceId"),
+		RuleId:        query.Get("ruleId"),
+	}
+
+	var err error
+	if start := query.Get("start"); start != "" {
+		if filter.Start, err = parseMetricsTime(start); err != nil {
+			return nil, err
+		}
+	}
+	if end := query.Get("end"); end != "" {
+		if filter.End, err = parseMetricsTime(end); err != nil {
+			return nil, err
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 0 {
+			return nil, fmt.Errorf("invalid limit %s", limit)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseDeliveryAttemptFilter reads the filter of the delivery attempts from the query params
+func parseDeliveryAttemptFilter(r *http.Request) (*rules.DeliveryAttemptFilter, error) {
+	query := r.URL.Query()
+	filter := &rules.DeliveryAttemptFilter{Status: rules.DeliveryStatus(query.Get("status"))}
+
+	switch filter.Status {
+	case "", rules.DeliveryStatusSuccess, rules.DeliveryStatusFailed:
+	default:
+		return nil, fmt.Errorf("invalid status %s, must be success or failed", filter.Status)
+	}
+
+	var err error
+	if start := query.Get("start"); start != "" {
+		if filter.Start, err = parseMetricsTime(start); err != nil {
+			return nil, err
+		}
+	}
+	if end := query.Get("end"); end != "" {
+		if filter.End, err = parseMetricsTime(end); err != nil {
+			return nil, err
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %s", limit)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseNotificationLogFilter reads the filter of the notification log from the query params
+func parseNotificationLogFilter(r *http.Request) (*rules.NotificationLogFilter, error) {
+	query := r.URL.Query()
+	filter := &rules.NotificationLogFilter{
+		RuleId:      query.Get("ruleId"),
+		Channel:     query.Get("channel"),
+		Fingerprint: query.Get("fingerprint"),
+	}
+
+	var err error
+	if start := query.Get("start"); start != "" {
+		if filter.Start, err = parseMetricsTime(start); err != nil {
+			return nil, err
+		}
+	}
+	if end := query.Get("end"); end != "" {
+		if filter.End, err = parseMetricsTime(end); err != nil {
+			return nil, err
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %s", limit)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseTwilioCallFilter reads the filter of the twilio calls from the query params
+func parseTwilioCallFilter(r *http.Request) (*rules.TwilioCallFilter, error) {
+	query := r.URL.Query()
+	filter := &rules.TwilioCallFilter{
+		Channel:     query.Get("channel"),
+		Fingerprint: query.Get("fingerprint"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %s", limit)
+		}
+	}
+	return filter, nil
+}
+
+// parseIncidentFilter reads the filter of the incidents from the query params
+func parseIncidentFilter(r *http.Request) (*rules.IncidentFilter, error) {
+	query := r.URL.Query()
+	filter := &rules.IncidentFilter{
+		Status:   rules.IncidentStatus(query.Get("status")),
+		GroupKey: query.Get("groupKey"),
+	}
+
+	switch filter.Status {
+	case "", rules.IncidentStatusOpen, rules.IncidentStatusAcknowledged, rules.IncidentStatusResolved:
+	default:
+		return nil, rules.ErrInvalidIncidentStatus
+	}
+
+	var err error
+	if open := query.Get("open"); open != "" {
+		if filter.Open, err = strconv.ParseBool(open); err != nil {
+			return nil, fmt.Errorf("invalid open %s", open)
+		}
+	}
+	if start := query.Get("start"); start != "" {
+		if filter.Start, err = parseMetricsTime(start); err != nil {
+			return nil, err
+		}
+	}
+	if end := query.Get("end"); end != "" {
+		if filter.End, err = parseMetricsTime(end); err != nil {
+			return nil, err
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %s", limit)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseRuleAlertsFilter reads the filter of the active alerts of a rule from the
+// query params, the labels are given as label=name:value and all must match
+func parseRuleAlertsFilter(r *http.Request) (*rules.RuleAlertsFilter, error) {
+	query := r.URL.Query()
+	filter := &rules.RuleAlertsFilter{
+		State:  query.Get("state"),
+		Labels: map[string]string{},
+	}
+
+	switch filter.State {
+	case "", "pending", "firing", "nodata":
+	default:
+		return nil, fmt.Errorf("invalid state %s, must be pending, firing or nodata", filter.State)
+	}
+
+	for _, label := range query["label"] {
+		name, value, found := strings.Cut(label, ":")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid label %s, must be name:value", label)
+		}
+		filter.Labels[name] = value
+	}
+
+	var err error
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 0 {
+			return nil, fmt.Errorf("invalid limit %s", limit)
+		}
+	}
+	if offset := query.Get("offset"); offset != "" {
+		if filter.Offset, err = strconv.Atoi(offset); err != nil || filter.Offset < 0 {
+			return nil, fmt.Errorf("invalid offset %s", offset)
+		}
+	}
+
+	return filter, nil
+}
+
 func parseMetricsTime(s string) (time.Time, error) {
 	if t, err := strconv.ParseFloat(s, 64); err == nil {
 		s, ns := math.Modf(t)
@@ -415,8 +661,8 @@ func parseTTLParams(r *http.Request) (*model.TTLParams, error) {
 	}
 
 	// Validate the type parameter
-	if typeTTL != baseconstants.TraceTTL && typeTTL != baseconstants.MetricsTTL && typeTTL != baseconstants.LogsTTL {
-		return nil, fmt.Errorf("type param should be metrics|traces|logs, got %v", typeTTL)
+	if typeTTL != baseconstants.TraceTTL && typeTTL != baseconstants.MetricsTTL && typeTTL != baseconstants.LogsTTL && typeTTL != baseconstants.AlertHistoryTTL {
+		return nil, fmt.Errorf("type param should be metrics|traces|logs|alert_history, got %v", typeTTL)
 	}
 
 	// Validate the TTL duration.
@@ -428,6 +674,9 @@ func parseTTLParams(r *http.Request) (*model.TTLParams, error) {
 	var toColdParsed time.Duration
 
 	// If some cold storage is provided, validate the cold storage move TTL.
+	if len(coldStorage) > 0 && typeTTL == baseconstants.AlertHistoryTTL {
+		return nil, fmt.Errorf("cold storage is not supported for the alert history")
+	}
 	if len(coldStorage) > 0 {
 		toColdParsed, err = time.ParseDuration(toColdDuration)
 		if err != nil || toColdParsed.Seconds() <= 0 {
@@ -454,8 +703,8 @@ func parseGetTTL(r *http.Request) (*model.GetTTLParams, error) {
 		return nil, fmt.Errorf("type param cannot be empty from the query")
 	} else {
 		// Validate the type parameter
-		if typeTTL != baseconstants.TraceTTL && typeTTL != baseconstants.MetricsTTL && typeTTL != baseconstants.LogsTTL {
-			return nil, fmt.Errorf("type param should be metrics|traces|logs, got %v", typeTTL)
+		if typeTTL != baseconstants.TraceTTL && typeTTL != baseconstants.MetricsTTL && typeTTL != baseconstants.LogsTTL && typeTTL != baseconstants.AlertHistoryTTL {
+			return nil, fmt.Errorf("type param should be metrics|traces|logs|alert_history, got %v", typeTTL)
 		}
 	}
 
@@ -812,6 +1061,25 @@ func validateExpressions(expressions []string, funcs map[string]govaluate.Expres
 	return errs
 }
 
+// validateJoinOn checks the queries of the formula group by the labels it's joined on
+func validateJoinOn(joinOn []string, groupKeys map[string][]string) error {
+	for queryName, keys := range groupKeys {
+		for _, label := range joinOn {
+			found := false
+			for _, key := range keys {
+				if key == label {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("query %s must group by the join label %s", queryName, label)
+			}
+		}
+	}
+	return nil
+}
+
 func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiError) {
 
 	var queryRangeParams *v3.QueryRangeParamsV3
@@ -866,18 +1134,25 @@ func ParseQueryRangeParams(r *http.Request) (*v3.QueryRangeParamsV3, *model.ApiE
 					}
 				}
 
-				params := make(map[string]interface{})
-				for k, v := range groupKeys {
-					params[k] = v
-				}
+				if len(query.JoinOn) > 0 {
+					// the queries joined on the labels only need to group by them
+					if err := validateJoinOn(query.JoinOn, groupKeys); err != nil {
+						return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+					}
+				} else {
+					params := make(map[string]interface{})
+					for k, v := range groupKeys {
+						params[k] = v
+					}
 
-				can, _, err := expression.CanJoin(params)
-				if err != nil {
-					return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
-				}
+					can, _, err := expression.CanJoin(params)
+					if err != nil {
+						return nil, &model.ApiError{Typ: model.ErrorBadData, Err: err}
+					}
 
-				if !can {
-					return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("cannot join the given group keys")}
+					if !can {
+						return nil, &model.ApiError{Typ: model.ErrorBadData, Err: fmt.Errorf("cannot join the given group keys")}
+					}
 				}
 			}
 
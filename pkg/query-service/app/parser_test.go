@@ -1403,6 +1403,69 @@ func TestQueryRangeFormula(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			desc: "join on the shared group by key",
+			compositeQuery: v3.CompositeQuery{
+				PanelType: v3.PanelTypeGraph,
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:          "A",
+						DataSource:         v3.DataSourceMetrics,
+						AggregateOperator:  v3.AggregateOperatorSum,
+						AggregateAttribute: v3.AttributeKey{Key: "signoz_calls_total"},
+						GroupBy:            []v3.AttributeKey{{Key: "service_name"}, {Key: "status_code"}},
+						Expression:         "A",
+					},
+					"B": {
+						QueryName:          "B",
+						DataSource:         v3.DataSourceMetrics,
+						AggregateOperator:  v3.AggregateOperatorSum,
+						AggregateAttribute: v3.AttributeKey{Key: "signoz_calls_total"},
+						GroupBy:            []v3.AttributeKey{{Key: "service_name"}, {Key: "operation_name"}},
+						Expression:         "B",
+					},
+					"F1": {
+						QueryName:  "F1",
+						Expression: "A/B",
+						JoinOn:     []string{"service_name"},
+					},
+				},
+			},
+			expectErr: false,
+		},
+		{
+			desc: "join on a key not grouped by",
+			compositeQuery: v3.CompositeQuery{
+				PanelType: v3.PanelTypeGraph,
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:          "A",
+						DataSource:         v3.DataSourceMetrics,
+						AggregateOperator:  v3.AggregateOperatorSum,
+						AggregateAttribute: v3.AttributeKey{Key: "signoz_calls_total"},
+						GroupBy:            []v3.AttributeKey{{Key: "service_name"}},
+						Expression:         "A",
+					},
+					"B": {
+						QueryName:          "B",
+						DataSource:         v3.DataSourceMetrics,
+						AggregateOperator:  v3.AggregateOperatorSum,
+						AggregateAttribute: v3.AttributeKey{Key: "signoz_calls_total"},
+						GroupBy:            []v3.AttributeKey{{Key: "operation_name"}},
+						Expression:         "B",
+					},
+					"F1": {
+						QueryName:  "F1",
+						Expression: "A/B",
+						JoinOn:     []string{"service_name"},
+					},
+				},
+			},
+			expectErr: true,
+			errMsg:    "query B must group by the join label service_name",
+		},
 		{
 			desc: "Unknow variable in expression",
 			compositeQuery: v3.CompositeQuery{
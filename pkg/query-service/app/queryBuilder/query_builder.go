@@ -107,6 +107,14 @@ func expressionToQuery(
 	// err should be nil here since the expression is already validated
 	formula, _ := govaluate.NewEvaluableExpressionFromTokens(modified)
 
+	// the queries of the formula joined on the labels are only matched on them, and
+	// every query keeps its other labels
+	joinOn := qp.CompositeQuery.BuilderQueries[queryName].JoinOn
+	if len(joinOn) > 0 && qp.CompositeQuery.PanelType != v3.PanelTypeTable {
+		joinOn = append(append([]string{}, joinOn...), "ts")
+	}
+	selectedTags := make(map[string]struct{})
+
 	var formulaSubQuery string
 	var joinUsing string
 	var prevVar string
@@ -119,7 +127,20 @@ func expressionToQuery(
 		if qp.CompositeQuery.PanelType != v3.PanelTypeTable {
 			groupTags = append(groupTags, "ts")
 		}
-		if joinUsing == "" {
+		onTags := groupTags
+		if len(joinOn) > 0 {
+			onTags = joinOn
+			for _, tag := range groupTags {
+				if _, ok := selectedTags[tag]; ok {
+					continue
+				}
+				selectedTags[tag] = struct{}{}
+				if joinUsing != "" {
+					joinUsing += ", "
+				}
+				joinUsing += fmt.Sprintf("%s.`%s` as `%s`", variable, tag, tag)
+			}
+		} else if joinUsing == "" {
 			for _, tag := range groupTags {
 				joinUsing += fmt.Sprintf("%s.`%s` as `%s`, ", variable, tag, tag)
 			}
@@ -128,7 +149,7 @@ func expressionToQuery(
 		formulaSubQuery += fmt.Sprintf("(%s) as %s ", query, variable)
 		if idx > 0 {
 			formulaSubQuery += " ON "
-			for _, tag := range groupTags {
+			for _, tag := range onTags {
 				formulaSubQuery += fmt.Sprintf("%s.`%s` = %s.`%s` AND ", prevVar, tag, variable, tag)
 			}
 			formulaSubQuery = strings.TrimSuffix(formulaSubQuery, " AND ")
@@ -448,6 +469,9 @@ func (c *cacheKeyGenerator) GenerateKeys(params *v3.QueryRangeParamsV3) map[stri
 			}
 
 			expressionCacheKey := expressionToKey(expression, keys)
+			if len(query.JoinOn) > 0 {
+				expressionCacheKey += "&joinOn=" + strings.Join(query.JoinOn, ",")
+			}
 			keys[query.QueryName] = expressionCacheKey
 		}
 	}
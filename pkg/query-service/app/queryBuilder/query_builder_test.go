@@ -64,6 +64,57 @@ func TestBuildQueryWithMultipleQueriesAndFormula(t *testing.T) {
 	})
 }
 
+func TestBuildQueryWithJoinOn(t *testing.T) {
+	q := &v3.QueryRangeParamsV3{
+		Start: 1650991982000,
+		End:   1651078382000,
+		CompositeQuery: &v3.CompositeQuery{
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {
+					QueryName:          "A",
+					StepInterval:       60,
+					DataSource:         v3.DataSourceMetrics,
+					AggregateAttribute: v3.AttributeKey{Key: "errors"},
+					AggregateOperator:  v3.AggregateOperatorSumRate,
+					Temporality:        v3.Cumulative,
+					GroupBy:            []v3.AttributeKey{{Key: "service_name"}, {Key: "status_code"}},
+					Expression:         "A",
+				},
+				"B": {
+					QueryName:          "B",
+					StepInterval:       60,
+					DataSource:         v3.DataSourceMetrics,
+					AggregateAttribute: v3.AttributeKey{Key: "requests"},
+					AggregateOperator:  v3.AggregateOperatorSumRate,
+					Temporality:        v3.Cumulative,
+					GroupBy:            []v3.AttributeKey{{Key: "service_name"}, {Key: "operation"}},
+					Expression:         "B",
+				},
+				"C": {
+					QueryName:  "C",
+					Expression: "A/B",
+					JoinOn:     []string{"service_name"},
+				},
+			},
+		},
+	}
+	qbOptions := QueryBuilderOptions{
+		BuildMetricQuery: metricsv3.PrepareMetricQuery,
+	}
+	fm := featureManager.StartManager()
+	qb := NewQueryBuilder(qbOptions, fm)
+
+	queries, err := qb.PrepareQueries(q)
+	require.NoError(t, err)
+
+	// the series keep the labels of both queries and are only matched on the join label
+	require.Contains(t, queries["C"], "SELECT A.`service_name` as `service_name`, A.`status_code` as `status_code`, A.`ts` as `ts`, B.`operation` as `operation`, A.value / B.value")
+	require.Contains(t, queries["C"], "ON A.`service_name` = B.`service_name` AND A.`ts` = B.`ts`")
+
+	keys := NewKeyGenerator().GenerateKeys(q)
+	require.Contains(t, keys["C"], "&joinOn=service_name")
+}
+
 func TestBuildQueryWithIncorrectQueryRef(t *testing.T) {
 	t.Run("TestBuildQueryWithFilters", func(t *testing.T) {
 		q := &v3.QueryRangeParamsV3{
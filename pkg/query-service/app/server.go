@@ -756,6 +756,36 @@ func makeRulesManager(
 		Reader:            ch,
 		Cache:             cache,
 		EvalDelay:         constants.GetEvalDelay(),
+		EvalTimeout:       constants.GetEvalTimeout(),
+		RuleVariables:     constants.GetRuleVariables(),
+		ProvisioningDir:   constants.RulesProvisioningDir,
+		RuleEventWebhooks: constants.GetRuleEventWebhooks(),
+		RuleEvents:        constants.GetRuleEvents(),
+		IncidentChannels:  constants.GetIncidentChannels(),
+		ShardRules:        constants.ShardRules,
+		LeaderElection:    constants.RulesLeaderElection,
+		EvalConcurrency:   constants.RulesEvalConcurrency,
+		QueryParallelism:  constants.RulesQueryParallelism,
+
+		DisableQueryResultCache: !constants.RulesQueryResultCache,
+		TemporalityCacheTTL:     constants.GetTemporalityCacheTTL(),
+
+		BreakerThreshold:      constants.RulesBreakerThreshold,
+		BreakerCooldown:       constants.GetBreakerCooldown(),
+		MetaAlertChannels:     constants.GetMetaAlertChannels(),
+		MaxEvalBackoff:        constants.GetMaxEvalBackoff(),
+		EvalLagThreshold:      constants.GetEvalLagThreshold(),
+		EvalLagAlert:          constants.RulesEvalLagAlert,
+		ShutdownTimeout:       constants.GetRulesShutdownTimeout(),
+		AlertSnapshotInterval: constants.GetAlertSnapshotInterval(),
+		MaxResultSeries:       constants.RulesMaxResultSeries,
+		MaxResultPoints:       constants.RulesMaxResultPoints,
+
+		StateExportWebhooks:     constants.GetStateExportWebhooks(),
+		StateExportKafkaBrokers: constants.GetStateExportKafkaBrokers(),
+		StateExportKafkaTopic:   constants.RulesStateExportKafkaTopic,
+		HistoryQueueCapacity:    constants.RulesHistoryQueueCapacity,
+
 		UseLogsNewSchema:  useLogsNewSchema,
 		UseTraceNewSchema: useTraceNewSchema,
 	}
@@ -4,6 +4,7 @@ import (
 	"maps"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -49,6 +50,7 @@ func IsTelemetryEnabled() bool {
 const TraceTTL = "traces"
 const MetricsTTL = "metrics"
 const LogsTTL = "logs"
+const AlertHistoryTTL = "alert_history"
 
 const DurationSort = "DurationSort"
 const TimestampSort = "TimestampSort"
@@ -67,6 +69,14 @@ var TELEMETRY_ACTIVE_USER_DURATION_MINUTES = GetOrDefaultEnvInt("TELEMETRY_ACTIV
 
 var InviteEmailTemplate = GetOrDefaultEnv("INVITE_EMAIL_TEMPLATE", "/root/templates/invitation_email_template.html")
 
+// SlackSigningSecret verifies the clicks on the buttons of the slack alerts, the
+// buttons are rejected when it's not set
+var SlackSigningSecret = GetOrDefaultEnv("SLACK_SIGNING_SECRET", "")
+
+// InboundAlertToken authenticates the external systems acking or resolving the
+// alerts, the inbound actions are rejected when it's not set
+var InboundAlertToken = GetOrDefaultEnv("INBOUND_ALERT_TOKEN", "")
+
 // Alert manager channel subpath
 var AmChannelApiPath = GetOrDefaultEnv("ALERTMANAGER_API_CHANNEL_PATH", "v1/routes")
 
@@ -178,6 +188,161 @@ func GetEvalDelay() time.Duration {
 	return evalDelayDuration
 }
 
+// GetRuleVariables returns the org level variables that can be referenced
+// in the rule filters, configured as a comma separated list of key=value pairs
+func GetRuleVariables() map[string]string {
+	vars := make(map[string]string)
+	for _, pair := range strings.Split(GetOrDefaultEnv("RULES_VARIABLES", ""), ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || strings.TrimSpace(key) == "" {
+			continue
+		}
+		vars[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return vars
+}
+
+// RulesProvisioningDir holds the rule files provisioned at startup and on SIGHUP
+var RulesProvisioningDir = GetOrDefaultEnv("RULES_PROVISIONING_DIR", "")
+
+// ShardRules shares the rules among the query service replicas using the rules db
+var ShardRules = GetOrDefaultEnv("RULES_SHARDING_ENABLED", "false") == "true"
+
+// GetEvalTimeout returns the timeout of the rule evaluations, zero
+// evaluates the rules for up to their frequency
+func GetEvalTimeout() time.Duration {
+	evalTimeout, err := time.ParseDuration(GetOrDefaultEnv("RULES_EVAL_TIMEOUT", "0s"))
+	if err != nil {
+		return 0
+	}
+	return evalTimeout
+}
+
+// GetTemporalityCacheTTL returns how long the rules reuse the temporality of the metrics
+func GetTemporalityCacheTTL() time.Duration {
+	ttl, err := time.ParseDuration(GetOrDefaultEnv("RULES_TEMPORALITY_CACHE_TTL", "30m"))
+	if err != nil {
+		return 0
+	}
+	return ttl
+}
+
+// GetBreakerCooldown returns how long the rule evaluations are paused once the datastore is failing
+func GetBreakerCooldown() time.Duration {
+	cooldown, err := time.ParseDuration(GetOrDefaultEnv("RULES_BREAKER_COOLDOWN", "1m"))
+	if err != nil {
+		return 0
+	}
+	return cooldown
+}
+
+// GetMaxEvalBackoff returns the longest interval between the evaluations of the failing rules
+func GetMaxEvalBackoff() time.Duration {
+	backoff, err := time.ParseDuration(GetOrDefaultEnv("RULES_MAX_EVAL_BACKOFF", "30m"))
+	if err != nil {
+		return 0
+	}
+	return backoff
+}
+
+// GetEvalLagThreshold returns how late the rule evaluation starts before the rule is lagging
+func GetEvalLagThreshold() time.Duration {
+	threshold, err := time.ParseDuration(GetOrDefaultEnv("RULES_EVAL_LAG_THRESHOLD", "30s"))
+	if err != nil {
+		return 0
+	}
+	return threshold
+}
+
+// RulesEvalLagAlert notifies the meta alert channels while the rule evaluations are lagging
+var RulesEvalLagAlert = GetOrDefaultEnv("RULES_EVAL_LAG_ALERT_ENABLED", "false") == "true"
+
+// GetRulesShutdownTimeout returns how long the rule manager drains the evaluations and the notifications on shutdown
+func GetRulesShutdownTimeout() time.Duration {
+	timeout, err := time.ParseDuration(GetOrDefaultEnv("RULES_SHUTDOWN_TIMEOUT", "30s"))
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// GetAlertSnapshotInterval returns how often the active alerts of the rules are saved to be restored at startup
+func GetAlertSnapshotInterval() time.Duration {
+	interval, err := time.ParseDuration(GetOrDefaultEnv("RULES_ALERT_SNAPSHOT_INTERVAL", "1m"))
+	if err != nil {
+		return 0
+	}
+	return interval
+}
+
+// RulesBreakerThreshold is the number of consecutive datastore failures pausing the
+// rule evaluations, negative disables the pause
+var RulesBreakerThreshold = GetOrDefaultEnvInt("RULES_BREAKER_THRESHOLD", 10)
+
+// RulesEvalConcurrency is the number of rules evaluated at the same time
+var RulesEvalConcurrency = GetOrDefaultEnvInt("RULES_EVAL_CONCURRENCY", 16)
+
+// RulesQueryParallelism is the number of the queries of one rule evaluation run at the same time
+var RulesQueryParallelism = GetOrDefaultEnvInt("RULES_QUERY_PARALLELISM", 2)
+
+// RulesMaxResultSeries and RulesMaxResultPoints abort the rule evaluations querying
+// larger results, zero doesn't limit the results
+var RulesMaxResultSeries = GetOrDefaultEnvInt("RULES_MAX_RESULT_SERIES", 10000)
+var RulesMaxResultPoints = GetOrDefaultEnvInt("RULES_MAX_RESULT_POINTS", 1000000)
+
+// RulesQueryResultCache shares the results of the identical queries of the rules
+var RulesQueryResultCache = GetOrDefaultEnv("RULES_QUERY_RESULT_CACHE_ENABLED", "true") == "true"
+
+// RulesLeaderElection evaluates the rules on the replica elected leader through the rules db
+var RulesLeaderElection = GetOrDefaultEnv("RULES_LEADER_ELECTION_ENABLED", "false") == "true"
+
+// GetRuleEventWebhooks returns the webhooks sent the rule changes
+func GetRuleEventWebhooks() []string {
+	return splitEnvList("RULES_EVENT_WEBHOOKS")
+}
+
+// GetStateExportWebhooks returns the http sinks the alert state changes and notifications are posted to
+func GetStateExportWebhooks() []string {
+	return splitEnvList("RULES_STATE_EXPORT_WEBHOOKS")
+}
+
+// GetStateExportKafkaBrokers returns the kafka brokers the alert state changes and notifications are produced to
+func GetStateExportKafkaBrokers() []string {
+	return splitEnvList("RULES_STATE_EXPORT_KAFKA_BROKERS")
+}
+
+// RulesStateExportKafkaTopic is the kafka topic the alert state changes and notifications are produced to
+var RulesStateExportKafkaTopic = GetOrDefaultEnv("RULES_STATE_EXPORT_KAFKA_TOPIC", "signoz_alert_state")
+
+// RulesHistoryQueueCapacity bounds the rule state history written in the background,
+// negative writes the state history during the rule evaluations
+var RulesHistoryQueueCapacity = GetOrDefaultEnvInt("RULES_HISTORY_QUEUE_CAPACITY", 10000)
+
+// GetIncidentChannels returns the channels notified of the incidents
+func GetIncidentChannels() []string {
+	return splitEnvList("INCIDENT_CHANNELS")
+}
+
+// GetMetaAlertChannels returns the channels notified while the rule evaluations are paused
+func GetMetaAlertChannels() []string {
+	return splitEnvList("RULES_META_ALERT_CHANNELS")
+}
+
+// GetRuleEvents returns the rule changes sent to the webhooks e.g. created,deleted, all when empty
+func GetRuleEvents() []string {
+	return splitEnvList("RULES_EVENTS")
+}
+
+func splitEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(GetOrDefaultEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 var ContextTimeoutMaxAllowed = GetContextTimeoutMaxAllowed()
 
 const (
@@ -1,5 +1,7 @@
 package converter
 
+import "slices"
+
 // Unit represents a unit of measurement
 type Unit string
 
@@ -40,26 +42,60 @@ var (
 	NoneConverter       = &noneConverter{}
 )
 
+// Units supported by the converters
+var (
+	durationUnits    = []Unit{"ns", "us", "µs", "ms", "s", "m", "h", "d"}
+	dataUnits        = []Unit{"bytes", "decbytes", "bits", "decbits", "kbytes", "decKbytes", "deckbytes", "mbytes", "decMbytes", "decmbytes", "gbytes", "decGbytes", "decgbytes", "tbytes", "decTbytes", "dectbytes", "pbytes", "decPbytes", "decpbytes"}
+	dataRateUnits    = []Unit{"binBps", "Bps", "binbps", "bps", "KiBs", "Kibits", "KBs", "Kbits", "MiBs", "Mibits", "MBs", "Mbits", "GiBs", "Gibits", "GBs", "Gbits", "TiBs", "Tibits", "TBs", "Tbits", "PiBs", "Pibits", "PBs", "Pbits"}
+	percentUnits     = []Unit{"percent", "percentunit"}
+	boolUnits        = []Unit{"bool", "bool_yes_no", "bool_true_false", "bool_1_0"}
+	perSecondUnits   = []Unit{"cps", "ops", "reqps", "rps", "wps", "iops"}
+	perMinuteUnits   = []Unit{"cpm", "opm", "rpm", "wpm"}
+	throughputUnits  = append(append([]Unit{}, perSecondUnits...), perMinuteUnits...)
+	compatibleGroups = [][]Unit{durationUnits, dataUnits, dataRateUnits, percentUnits, boolUnits, perSecondUnits, perMinuteUnits}
+)
+
 // FromUnit returns a converter for the given unit
 func FromUnit(u Unit) Converter {
-	switch u {
-	case "ns", "us", "µs", "ms", "s", "m", "h", "d":
+	switch {
+	case slices.Contains(durationUnits, u):
 		return DurationConverter
-	case "bytes", "decbytes", "bits", "decbits", "kbytes", "decKbytes", "deckbytes", "mbytes", "decMbytes", "decmbytes", "gbytes", "decGbytes", "decgbytes", "tbytes", "decTbytes", "dectbytes", "pbytes", "decPbytes", "decpbytes":
+	case slices.Contains(dataUnits, u):
 		return DataConverter
-	case "binBps", "Bps", "binbps", "bps", "KiBs", "Kibits", "KBs", "Kbits", "MiBs", "Mibits", "MBs", "Mbits", "GiBs", "Gibits", "GBs", "Gbits", "TiBs", "Tibits", "TBs", "Tbits", "PiBs", "Pibits", "PBs", "Pbits":
+	case slices.Contains(dataRateUnits, u):
 		return DataRateConverter
-	case "percent", "percentunit":
+	case slices.Contains(percentUnits, u):
 		return PercentConverter
-	case "bool", "bool_yes_no", "bool_true_false", "bool_1_0":
+	case slices.Contains(boolUnits, u):
 		return BoolConverter
-	case "cps", "ops", "reqps", "rps", "wps", "iops", "cpm", "opm", "rpm", "wpm":
+	case slices.Contains(throughputUnits, u):
 		return ThroughputConverter
 	default:
 		return NoneConverter
 	}
 }
 
+// CompatibleUnits returns the units the given unit can be converted to.
+// The throughput converter doesn't scale the values so the per second
+// and the per minute units are not compatible with each other.
+func CompatibleUnits(u Unit) []Unit {
+	for _, group := range compatibleGroups {
+		if slices.Contains(group, u) {
+			return group
+		}
+	}
+	return []Unit{u}
+}
+
+// IsCompatible returns true if the value in the from unit can be converted
+// to the to unit, a missing unit on either side needs no conversion
+func IsCompatible(from, to Unit) bool {
+	if from == "" || to == "" || from == to {
+		return true
+	}
+	return slices.Contains(CompatibleUnits(from), to)
+}
+
 func UnitToName(u string) string {
 	switch u {
 	case "ns":
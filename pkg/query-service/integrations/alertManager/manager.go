@@ -95,7 +95,15 @@ func (m *manager) URLPath(path string) *neturl.URL {
 	return m.parsedURL.ResolveReference(upath)
 }
 
+// AddRoute routes the receiver and its copy that doesn't send the resolved alerts
 func (m *manager) AddRoute(receiver *Receiver) *model.ApiError {
+	if apiErr := m.addRoute(receiver.routable()); apiErr != nil {
+		return apiErr
+	}
+	return m.addRoute(receiver.withoutResolved())
+}
+
+func (m *manager) addRoute(receiver *Receiver) *model.ApiError {
 
 	receiverString, _ := json.Marshal(receiver)
 
@@ -114,7 +122,19 @@ func (m *manager) AddRoute(receiver *Receiver) *model.ApiError {
 	return nil
 }
 
+// EditRoute updates the routes of the receiver and its copy that doesn't send the
+// resolved alerts, the copy is added for the receivers routed before it existed
 func (m *manager) EditRoute(receiver *Receiver) *model.ApiError {
+	if apiErr := m.editRoute(receiver.routable()); apiErr != nil {
+		return apiErr
+	}
+	if apiErr := m.editRoute(receiver.withoutResolved()); apiErr != nil {
+		return m.addRoute(receiver.withoutResolved())
+	}
+	return nil
+}
+
+func (m *manager) editRoute(receiver *Receiver) *model.ApiError {
 	receiverString, _ := json.Marshal(receiver)
 
 	amURL := m.prepareAmChannelApiURL()
@@ -142,7 +162,18 @@ func (m *manager) EditRoute(receiver *Receiver) *model.ApiError {
 	return nil
 }
 
+// DeleteRoute removes the routes of the receiver and its copy that doesn't send the resolved alerts
 func (m *manager) DeleteRoute(name string) *model.ApiError {
+	if apiErr := m.deleteRoute(name); apiErr != nil {
+		return apiErr
+	}
+	if apiErr := m.deleteRoute(WithoutResolved(name)); apiErr != nil {
+		zap.L().Warn("failed to delete the route of the receiver without the resolved alerts", zap.String("receiver", name), zap.Error(apiErr.Err))
+	}
+	return nil
+}
+
+func (m *manager) deleteRoute(name string) *model.ApiError {
 	values := map[string]string{"name": name}
 	requestData, _ := json.Marshal(values)
 
@@ -174,7 +205,7 @@ func (m *manager) DeleteRoute(name string) *model.ApiError {
 
 func (m *manager) TestReceiver(receiver *Receiver) *model.ApiError {
 
-	receiverBytes, _ := json.Marshal(receiver)
+	receiverBytes, _ := json.Marshal(receiver.routable())
 
 	amTestURL := m.prepareTestApiURL()
 	response, err := http.Post(amTestURL, contentType, bytes.NewBuffer(receiverBytes))
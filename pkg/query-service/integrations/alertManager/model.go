@@ -22,6 +22,179 @@ type Receiver struct {
 	VictorOpsConfigs interface{} `yaml:"victorops_configs,omitempty" json:"victorops_configs,omitempty"`
 	SNSConfigs       interface{} `yaml:"sns_configs,omitempty" json:"sns_configs,omitempty"`
 	MSTeamsConfigs   interface{} `yaml:"msteams_configs,omitempty" json:"msteams_configs,omitempty"`
+
+	// the configs below are delivered by the query service, they are
+	// not sent to the alertmanager
+	PagerdutyV2Configs interface{} `yaml:"-" json:"pagerduty_v2_configs,omitempty"`
+	SmtpConfigs        interface{} `yaml:"-" json:"smtp_configs,omitempty"`
+	TwilioConfigs      interface{} `yaml:"-" json:"twilio_configs,omitempty"`
+	JiraConfigs        interface{} `yaml:"-" json:"jira_configs,omitempty"`
+
+	// Timezone and TimeFormat are how the times of the alerts are shown in the messages
+	// rendered by the query service, UTC and 2006-01-02 15:04:05 MST by default
+	Timezone   string `yaml:"-" json:"timezone,omitempty"`
+	TimeFormat string `yaml:"-" json:"time_format,omitempty"`
+}
+
+// routable returns the receiver without the configs delivered by the
+// query service, the alertmanager only knows the other configs
+func (r *Receiver) routable() *Receiver {
+	routable := *r
+	routable.PagerdutyV2Configs = nil
+	routable.SmtpConfigs = nil
+	routable.TwilioConfigs = nil
+	routable.JiraConfigs = nil
+	routable.Timezone, routable.TimeFormat = "", ""
+	routable.WebhookConfigs = routableWebhooks(r.WebhookConfigs)
+	routable.SlackConfigs = routableSlack(r.SlackConfigs)
+	return &routable
+}
+
+// Routed reports whether the receiver has configs sent by the alertmanager
+func (r *Receiver) Routed() bool {
+	routable := r.routable()
+	for _, configs := range []interface{}{
+		routable.EmailConfigs, routable.PagerdutyConfigs, routable.SlackConfigs, routable.WebhookConfigs,
+		routable.OpsGenieConfigs, routable.WechatConfigs, routable.PushoverConfigs, routable.VictorOpsConfigs,
+		routable.SNSConfigs, routable.MSTeamsConfigs,
+	} {
+		if configs != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutResolvedSuffix names the copy of the receiver that doesn't send the resolved alerts
+const withoutResolvedSuffix = "/without-resolved"
+
+// WithoutResolved returns the name of the copy of the receiver that doesn't send the
+// resolved alerts, the alerts of the rules not notifying the channel of the resolved
+// alerts are routed to it so the alertmanager doesn't notify them once they expire
+func WithoutResolved(name string) string {
+	return name + withoutResolvedSuffix
+}
+
+// withoutResolved returns the routable copy of the receiver with send_resolved off in all its configs
+func (r *Receiver) withoutResolved() *Receiver {
+	routable := r.routable()
+	routable.Name = WithoutResolved(r.Name)
+	for _, configs := range []*interface{}{
+		&routable.EmailConfigs, &routable.PagerdutyConfigs, &routable.SlackConfigs, &routable.WebhookConfigs,
+		&routable.OpsGenieConfigs, &routable.WechatConfigs, &routable.PushoverConfigs, &routable.VictorOpsConfigs,
+		&routable.SNSConfigs, &routable.MSTeamsConfigs,
+	} {
+		*configs = withoutSendResolved(*configs)
+	}
+	return routable
+}
+
+func withoutSendResolved(configs interface{}) interface{} {
+	list, ok := configs.([]interface{})
+	if !ok {
+		return configs
+	}
+	copied := make([]interface{}, 0, len(list))
+	for _, config := range list {
+		c, ok := config.(map[string]interface{})
+		if !ok {
+			copied = append(copied, config)
+			continue
+		}
+		without := make(map[string]interface{}, len(c)+1)
+		for key, value := range c {
+			without[key] = value
+		}
+		without["send_resolved"] = false
+		copied = append(copied, without)
+	}
+	return copied
+}
+
+// SlackActionCallbackID is the callback id of the slack messages with the SigNoz actions,
+// the clicks on the buttons are sent by slack to the interactivity url of the app
+const SlackActionCallbackID = "signoz_alert"
+
+// The names of the slack buttons, the snooze buttons are named snooze:<duration>
+const (
+	SlackActionAck    = "ack"
+	SlackActionSnooze = "snooze"
+)
+
+// slackActionValue identifies the alerts of the message by their common labels
+const slackActionValue = `{{ range .CommonLabels.SortedPairs }}{{ urlquery .Name }}={{ urlquery .Value }}&{{ end }}`
+
+// slackActions are the buttons added to the messages of the interactive slack configs
+var slackActions = []interface{}{
+	map[string]interface{}{"type": "button", "text": "Acknowledge", "name": SlackActionAck, "value": slackActionValue, "style": "primary"},
+	map[string]interface{}{"type": "button", "text": "Snooze 1h", "name": SlackActionSnooze + ":1h", "value": slackActionValue},
+	map[string]interface{}{"type": "button", "text": "Snooze 24h", "name": SlackActionSnooze + ":24h", "value": slackActionValue},
+}
+
+// slackChartURL shows the chart of the first firing alert of the message
+const slackChartURL = `{{ with .Alerts.Firing }}{{ (index . 0).Annotations.chart_url }}{{ end }}`
+
+// routableSlack replaces the interactive flag of the slack configs, which the alertmanager
+// doesn't know, with the callback id and the buttons of the SigNoz actions. The messages
+// show the chart of the alert unless the config has its own image.
+func routableSlack(configs interface{}) interface{} {
+	list, ok := configs.([]interface{})
+	if !ok {
+		return configs
+	}
+	routable := make([]interface{}, 0, len(list))
+	for _, config := range list {
+		c, ok := config.(map[string]interface{})
+		if !ok {
+			routable = append(routable, config)
+			continue
+		}
+		interactive, _ := c["interactive"].(bool)
+		copied := make(map[string]interface{}, len(c)+2)
+		for key, value := range c {
+			copied[key] = value
+		}
+		delete(copied, "interactive")
+		if _, ok := copied["image_url"]; !ok {
+			copied["image_url"] = slackChartURL
+		}
+		if interactive {
+			actions, _ := c["actions"].([]interface{})
+			copied["callback_id"] = SlackActionCallbackID
+			copied["actions"] = append(append([]interface{}{}, actions...), slackActions...)
+		}
+		routable = append(routable, copied)
+	}
+	return routable
+}
+
+// DeliveredWebhook reports whether the webhook config is delivered by the query
+// service, i.e it signs the payload or sets headers the alertmanager doesn't support
+func DeliveredWebhook(config interface{}) bool {
+	c, ok := config.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	secret, _ := c["secret"].(string)
+	headers, _ := c["headers"].(map[string]interface{})
+	return secret != "" || len(headers) > 0
+}
+
+func routableWebhooks(configs interface{}) interface{} {
+	list, ok := configs.([]interface{})
+	if !ok {
+		return configs
+	}
+	routable := []interface{}{}
+	for _, config := range list {
+		if !DeliveredWebhook(config) {
+			routable = append(routable, config)
+		}
+	}
+	if len(routable) == 0 {
+		return nil
+	}
+	return routable
 }
 
 type ReceiverResponse struct {
@@ -44,6 +217,9 @@ type Alert struct {
 	GeneratorURL string    `json:"generatorURL,omitempty"`
 
 	Receivers []string `json:"receivers,omitempty"`
+
+	// KeepAlive marks the alert re-sent only so that it doesn't expire, it's not a notification
+	KeepAlive bool `json:"-"`
 }
 
 // Name returns the name of the alert. It is equivalent to the "alertname" label.
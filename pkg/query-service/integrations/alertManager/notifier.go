@@ -50,6 +50,8 @@ type NotifierOptions struct {
 	AlertManagerURLs []string
 	// timeout limit on requests
 	Timeout time.Duration
+	// OnSend is called with the outcome of every request sending the alerts to an alertmanager
+	OnSend func(url string, alerts []*Alert, latency time.Duration, err error)
 }
 
 func (opts *NotifierOptions) String() string {
@@ -228,7 +230,12 @@ func (n *Notifier) sendAll(alerts ...*Alert) bool {
 
 		go func(ams *alertmanagerSet, am Manager) {
 			u := am.URLPath(alertPushEndpoint).String()
-			if err := n.sendOne(ctx, ams.client, u, b); err != nil {
+			begin := time.Now()
+			err := n.sendOne(ctx, ams.client, u, b)
+			if n.opts.OnSend != nil {
+				n.opts.OnSend(u, alerts, time.Since(begin), err)
+			}
+			if err != nil {
 				zap.L().Error("Error calling alert API", zap.String("alertmanager", u), zap.Int("count", len(alerts)), zap.Error(err))
 			} else {
 				atomic.AddUint64(&numSuccess, 1)
@@ -265,6 +272,21 @@ func (n *Notifier) sendOne(ctx context.Context, c *http.Client, url string, b []
 	return err
 }
 
+// Drain sends the queued notifications until the queue is empty or the context is
+// done, then shuts down the notification handler.
+func (n *Notifier) Drain(ctx context.Context) {
+	for n.queueLen() > 0 && ctx.Err() == nil {
+		alerts := n.nextBatch()
+		if !n.sendAll(alerts...) {
+			zap.L().Warn("msg: dropped alerts while draining", zap.Int("count", len(alerts)))
+		}
+	}
+	if count := n.queueLen(); count > 0 {
+		zap.L().Warn("msg: dropped the queued alerts on shutdown", zap.Int("count", count))
+	}
+	n.Stop()
+}
+
 // Stop shuts down the notification handler.
 func (n *Notifier) Stop() {
 	level.Info(n.logger).Log("msg", "Stopping notification manager...")
@@ -95,6 +95,14 @@ type Reader interface {
 	AddRuleStateHistory(ctx context.Context, ruleStateHistory []model.RuleStateHistory) error
 	GetOverallStateTransitions(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) ([]model.ReleStateItem, error)
 	ReadRuleStateHistoryByRuleID(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (*model.RuleStateTimeline, error)
+	QueryAlertStateHistory(ctx context.Context, params *model.QueryAlertStateHistory) (*model.AlertStateHistoryPage, error)
+	ExportAlertStateHistory(ctx context.Context, params *model.QueryAlertStateHistory, fn func(*model.RuleStateHistory) error) error
+	GetRuleFiringStats(ctx context.Context, start, end int64, ruleIDs []string) ([]model.RuleFiringStats, error)
+	GetRuleFiringEvents(ctx context.Context, start, end int64, ruleIDs []string) ([]model.RuleFiringEvent, error)
+	GetFiringCountsByLabel(ctx context.Context, start, end int64, label string, ruleIDs []string) ([]model.LabelFiringCount, error)
+	GetRuleStateChanges(ctx context.Context, ruleIDs []string, start, end int64) ([]model.RuleStateHistory, error)
+	GetRuleStateHistoryRuleIDs(ctx context.Context) ([]string, error)
+	DeleteRuleStateHistory(ctx context.Context, ruleIDs []string) error
 	GetTotalTriggers(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (uint64, error)
 	GetTriggersByInterval(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (*v3.Series, error)
 	GetAvgResolutionTime(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (float64, error)
@@ -102,6 +110,10 @@ type Reader interface {
 	ReadRuleStateHistoryTopContributorsByRuleID(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) ([]model.RuleStateHistoryContributor, error)
 	GetLastSavedRuleStateHistory(ctx context.Context, ruleID string) ([]model.RuleStateHistory, error)
 
+	AddSLOErrorRatios(ctx context.Context, errorRatios []model.SLOErrorRatio) error
+	GetSLOErrorRatio(ctx context.Context, ruleID string, start, end int64) ([]model.SLOErrorBudget, error)
+	GetSLOErrorRatioByInterval(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (*v3.Series, error)
+
 	GetMinAndMaxTimestampForTraceID(ctx context.Context, traceID []string) (int64, int64, error)
 
 	// Query Progress tracking helpers.
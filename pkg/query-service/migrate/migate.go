@@ -152,5 +152,77 @@ ENGINE = Distributed(%s, signoz_analytics, rule_state_history_v0, cityHash64(rul
 		}
 	}
 
+	// org of the rule, empty for the rules without an org
+	for _, table := range []string{"rule_state_history_v0", "distributed_rule_state_history_v0"} {
+		addOrgID := fmt.Sprintf("ALTER TABLE signoz_analytics.%s ON CLUSTER %s ADD COLUMN IF NOT EXISTS org_id LowCardinality(String) DEFAULT ''", table, cluster)
+		if err := conn.Exec(context.Background(), addOrgID); err != nil {
+			return err
+		}
+	}
+
+	return clickHouseMigrateSLOErrorBudget(conn, cluster)
+}
+
+// clickHouseMigrateSLOErrorBudget creates the tables that track
+// the error ratio observed by the SLO rules in every evaluation
+func clickHouseMigrateSLOErrorBudget(conn driver.Conn, cluster string) error {
+
+	localTable := `CREATE TABLE IF NOT EXISTS signoz_analytics.slo_error_budget_v0 ON CLUSTER %s
+(
+	_retention_days UInt32 DEFAULT 180,
+    rule_id LowCardinality(String),
+    rule_name LowCardinality(String),
+    unix_milli Int64 CODEC(Delta(8), ZSTD(1)),
+    fingerprint UInt64 CODEC(ZSTD(1)),
+    labels String CODEC(ZSTD(5)),
+    target Float64 CODEC(ZSTD(1)),
+    error_ratio Float64 CODEC(Gorilla, ZSTD(1)),
+)
+ENGINE = MergeTree
+PARTITION BY toDate(unix_milli / 1000)
+ORDER BY (rule_id, unix_milli)
+TTL toDateTime(unix_milli / 1000) + toIntervalDay(_retention_days)
+SETTINGS ttl_only_drop_parts = 1, index_granularity = 8192`
+
+	distributedTable := `CREATE TABLE IF NOT EXISTS signoz_analytics.distributed_slo_error_budget_v0 ON CLUSTER %s
+(
+    rule_id LowCardinality(String),
+    rule_name LowCardinality(String),
+    unix_milli Int64 CODEC(Delta(8), ZSTD(1)),
+    fingerprint UInt64 CODEC(ZSTD(1)),
+    labels String CODEC(ZSTD(5)),
+    target Float64 CODEC(ZSTD(1)),
+    error_ratio Float64 CODEC(Gorilla, ZSTD(1)),
+)
+ENGINE = Distributed(%s, signoz_analytics, slo_error_budget_v0, cityHash64(rule_id, rule_name, fingerprint))`
+
+	tableExists := `SELECT count(*) FROM system.tables WHERE name = 'slo_error_budget_v0' AND database = 'signoz_analytics'`
+	var tableCount uint64
+	err := conn.QueryRow(context.Background(), tableExists).Scan(&tableCount)
+	if err != nil {
+		return err
+	}
+
+	if tableCount == 0 {
+		err = conn.Exec(context.Background(), fmt.Sprintf(localTable, cluster))
+		if err != nil {
+			return err
+		}
+	}
+
+	distributedTableExists := `SELECT count(*) FROM system.tables WHERE name = 'distributed_slo_error_budget_v0' AND database = 'signoz_analytics'`
+	var distributedTableCount uint64
+	err = conn.QueryRow(context.Background(), distributedTableExists).Scan(&distributedTableCount)
+	if err != nil {
+		return err
+	}
+
+	if distributedTableCount == 0 {
+		err = conn.Exec(context.Background(), fmt.Sprintf(distributedTable, cluster, cluster))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
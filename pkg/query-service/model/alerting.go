@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
@@ -117,6 +118,23 @@ type RuleStateTimeline struct {
 	Items  []RuleStateHistory  `json:"items"`
 	Total  uint64              `json:"total"`
 	Labels map[string][]string `json:"labels"`
+	// Annotations is the notes of the firing periods starting in the time range
+	Annotations []FiringAnnotation `json:"annotations"`
+}
+
+// FiringAnnotation is the note attached to the firing period of the rule starting at
+// FiredAt, the zero fingerprint annotates the firing period of the rule as a whole
+type FiringAnnotation struct {
+	Id            int64     `json:"id"`
+	RuleID        string    `json:"ruleId"`
+	Fingerprint   uint64    `json:"fingerprint,omitempty"`
+	FiredAt       int64     `json:"firedAt"`
+	PostmortemURL string    `json:"postmortemUrl,omitempty"`
+	RootCause     string    `json:"rootCause,omitempty"`
+	Text          string    `json:"text,omitempty"`
+	CreatedBy     string    `json:"createdBy"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
 }
 
 type RuleStateHistory struct {
@@ -132,6 +150,7 @@ type RuleStateHistory struct {
 	Labels       LabelsString `json:"labels" ch:"labels"`
 	Fingerprint  uint64       `json:"fingerprint" ch:"fingerprint"`
 	Value        float64      `json:"value" ch:"value"`
+	OrgID        string       `json:"orgId" ch:"org_id"`
 
 	RelatedTracesLink string `json:"relatedTracesLink"`
 	RelatedLogsLink   string `json:"relatedLogsLink"`
@@ -160,6 +179,117 @@ func (r *QueryRuleStateHistory) Validate() error {
 	return nil
 }
 
+// MaxStateHistoryLimit bounds the state changes returned in one page
+const MaxStateHistoryLimit = 1000
+
+// QueryAlertStateHistory selects the state changes of the alerts across the rules,
+// the empty rule ids, fingerprints and states select all of them
+type QueryAlertStateHistory struct {
+	Start        int64         `json:"start"`
+	End          int64         `json:"end"`
+	RuleIDs      []string      `json:"ruleIds"`
+	Fingerprints []uint64      `json:"fingerprints"`
+	States       []string      `json:"states"`
+	Filters      *v3.FilterSet `json:"filters"`
+	Offset       int64         `json:"offset"`
+	Limit        int64         `json:"limit"`
+	Order        string        `json:"order"`
+}
+
+func (r *QueryAlertStateHistory) Validate() error {
+	if r.Start == 0 || r.End == 0 {
+		return fmt.Errorf("start and end are required")
+	}
+	if r.Start >= r.End {
+		return fmt.Errorf("start must be before end")
+	}
+	if r.Offset < 0 || r.Limit < 0 {
+		return fmt.Errorf("offset and limit must be greater than 0")
+	}
+	if r.Limit > MaxStateHistoryLimit {
+		return fmt.Errorf("limit cannot be greater than %d", MaxStateHistoryLimit)
+	}
+	if r.Order != "asc" && r.Order != "desc" {
+		return fmt.Errorf("order must be asc or desc")
+	}
+	for _, state := range r.States {
+		switch state {
+		case StateInactive.String(), StatePending.String(), StateFiring.String(), StateNoData.String(), StateDisabled.String():
+		default:
+			return fmt.Errorf("unknown state %s", state)
+		}
+	}
+	return nil
+}
+
+// AlertStateHistoryPage is a page of the state changes of the alerts, Total counts
+// the state changes of all the pages
+type AlertStateHistoryPage struct {
+	Items  []RuleStateHistory `json:"items"`
+	Total  uint64             `json:"total"`
+	Offset int64              `json:"offset"`
+	Limit  int64              `json:"limit"`
+}
+
+// RuleFiringStats is how often the series of the rule fired and for how long
+type RuleFiringStats struct {
+	RuleID   string `json:"ruleId" ch:"rule_id"`
+	RuleName string `json:"ruleName" ch:"rule_name"`
+	// Firings is the number of the times the series of the rule started firing
+	Firings uint64 `json:"firings" ch:"firings"`
+	// Series is the number of the distinct series that fired
+	Series uint64 `json:"series" ch:"series"`
+	// AvgFiringDuration is how long the series fired before resolving in seconds
+	AvgFiringDuration float64 `json:"avgFiringDuration" ch:"avg_firing_duration"`
+	// ShortFirings is the number of the firings resolved within ShortFiringMillis
+	ShortFirings uint64 `json:"shortFirings" ch:"short_firings"`
+}
+
+// RuleFiringEvent is when the series of the rule started firing, the firings of the
+// rule in the same minute are one event
+type RuleFiringEvent struct {
+	RuleID    string `json:"ruleId" ch:"rule_id"`
+	RuleName  string `json:"ruleName" ch:"rule_name"`
+	UnixMilli int64  `json:"unixMilli" ch:"unix_milli"`
+}
+
+// ShortFiringMillis is the firing duration under which the firing counts as short
+const ShortFiringMillis = 5 * 60 * 1000
+
+// LabelFiringCount is how often the series of the rules with the value of the label
+// started firing, the empty value counts the series without the label
+type LabelFiringCount struct {
+	Value string `json:"value" ch:"value"`
+	// Firings is the number of the times the series started firing
+	Firings uint64 `json:"firings" ch:"firings"`
+	// Rules and Series are the number of the distinct rules and series that fired
+	Rules  uint64 `json:"rules" ch:"rules"`
+	Series uint64 `json:"series" ch:"series"`
+}
+
+// StateSegment is the span of time the series stayed in the state
+type StateSegment struct {
+	State AlertState `json:"state"`
+	Start int64      `json:"start"`
+	End   int64      `json:"end"`
+}
+
+// SeriesStateTimeline is the consecutive state segments of a series of the rule
+type SeriesStateTimeline struct {
+	Fingerprint uint64         `json:"fingerprint"`
+	Labels      LabelsString   `json:"labels"`
+	Segments    []StateSegment `json:"segments"`
+}
+
+// RuleStateSegments is the state timelines of the series of the rule over the time
+// range, Total counts the series before the limit
+type RuleStateSegments struct {
+	Start  int64                 `json:"start"`
+	End    int64                 `json:"end"`
+	Total  int                   `json:"total"`
+	Series []SeriesStateTimeline `json:"series"`
+}
+
 type RuleStateHistoryContributor struct {
 	Fingerprint       uint64       `json:"fingerprint" ch:"fingerprint"`
 	Labels            LabelsString `json:"labels" ch:"labels"`
@@ -168,6 +298,27 @@ type RuleStateHistoryContributor struct {
 	RelatedLogsLink   string       `json:"relatedLogsLink"`
 }
 
+// SLOErrorRatio is the error ratio of an SLO series observed in an evaluation
+type SLOErrorRatio struct {
+	RuleID      string       `json:"ruleID" ch:"rule_id"`
+	RuleName    string       `json:"ruleName" ch:"rule_name"`
+	UnixMilli   int64        `json:"unixMilli" ch:"unix_milli"`
+	Fingerprint uint64       `json:"fingerprint" ch:"fingerprint"`
+	Labels      LabelsString `json:"labels" ch:"labels"`
+	Target      float64      `json:"target" ch:"target"`
+	ErrorRatio  float64      `json:"errorRatio" ch:"error_ratio"`
+}
+
+// SLOErrorBudget is the error budget of an SLO series over the compliance window
+type SLOErrorBudget struct {
+	Fingerprint uint64       `json:"fingerprint" ch:"fingerprint"`
+	Labels      LabelsString `json:"labels" ch:"labels"`
+	ErrorRatio  float64      `json:"errorRatio" ch:"error_ratio"`
+	// Consumed and Remaining are the fractions of the error budget
+	Consumed  float64 `json:"consumed"`
+	Remaining float64 `json:"remaining"`
+}
+
 type RuleStateTransition struct {
 	RuleID         string     `json:"ruleID" ch:"rule_id"`
 	State          AlertState `json:"state" ch:"state"`
@@ -179,6 +330,8 @@ type ReleStateItem struct {
 	State AlertState `json:"state"`
 	Start int64      `json:"start"`
 	End   int64      `json:"end"`
+	// Annotations is the notes of the firing period
+	Annotations []FiringAnnotation `json:"annotations,omitempty"`
 }
 
 type Stats struct {
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"context"
+	"sync"
+)
+
+// maxQueryStatsQueries bounds the queries kept by the stats
+const maxQueryStatsQueries = 10
+
+type queryStatsKey struct{}
+
+// QueryStats collects the queries run with the context and the rows and bytes they read
+type QueryStats struct {
+	mtx       sync.Mutex
+	readRows  uint64
+	readBytes uint64
+	queries   []string
+}
+
+// NewQueryStatsContext returns the context collecting the stats of its queries
+func NewQueryStatsContext(ctx context.Context, stats *QueryStats) context.Context {
+	return context.WithValue(ctx, queryStatsKey{}, stats)
+}
+
+// QueryStatsFromContext returns the stats collected for the context, nil when not collected
+func QueryStatsFromContext(ctx context.Context) *QueryStats {
+	stats, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
+	return stats
+}
+
+// AddQuery records the query run
+func (s *QueryStats) AddQuery(query string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.queries) < maxQueryStatsQueries {
+		s.queries = append(s.queries, query)
+	}
+}
+
+// AddProgress adds the rows and bytes read by a query
+func (s *QueryStats) AddProgress(rows, bytes uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.readRows += rows
+	s.readBytes += bytes
+}
+
+// Read returns the rows and bytes read by the queries
+func (s *QueryStats) Read() (rows, bytes uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.readRows, s.readBytes
+}
+
+// Queries returns the queries run
+func (s *QueryStats) Queries() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]string(nil), s.queries...)
+}
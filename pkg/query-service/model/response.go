@@ -379,19 +379,21 @@ type DBResponseTTL struct {
 }
 
 type GetTTLResponseItem struct {
-	MetricsTime             int    `json:"metrics_ttl_duration_hrs,omitempty"`
-	MetricsMoveTime         int    `json:"metrics_move_ttl_duration_hrs,omitempty"`
-	TracesTime              int    `json:"traces_ttl_duration_hrs,omitempty"`
-	TracesMoveTime          int    `json:"traces_move_ttl_duration_hrs,omitempty"`
-	LogsTime                int    `json:"logs_ttl_duration_hrs,omitempty"`
-	LogsMoveTime            int    `json:"logs_move_ttl_duration_hrs,omitempty"`
-	ExpectedMetricsTime     int    `json:"expected_metrics_ttl_duration_hrs,omitempty"`
-	ExpectedMetricsMoveTime int    `json:"expected_metrics_move_ttl_duration_hrs,omitempty"`
-	ExpectedTracesTime      int    `json:"expected_traces_ttl_duration_hrs,omitempty"`
-	ExpectedTracesMoveTime  int    `json:"expected_traces_move_ttl_duration_hrs,omitempty"`
-	ExpectedLogsTime        int    `json:"expected_logs_ttl_duration_hrs,omitempty"`
-	ExpectedLogsMoveTime    int    `json:"expected_logs_move_ttl_duration_hrs,omitempty"`
-	Status                  string `json:"status"`
+	MetricsTime              int    `json:"metrics_ttl_duration_hrs,omitempty"`
+	MetricsMoveTime          int    `json:"metrics_move_ttl_duration_hrs,omitempty"`
+	TracesTime               int    `json:"traces_ttl_duration_hrs,omitempty"`
+	TracesMoveTime           int    `json:"traces_move_ttl_duration_hrs,omitempty"`
+	LogsTime                 int    `json:"logs_ttl_duration_hrs,omitempty"`
+	LogsMoveTime             int    `json:"logs_move_ttl_duration_hrs,omitempty"`
+	ExpectedMetricsTime      int    `json:"expected_metrics_ttl_duration_hrs,omitempty"`
+	ExpectedMetricsMoveTime  int    `json:"expected_metrics_move_ttl_duration_hrs,omitempty"`
+	ExpectedTracesTime       int    `json:"expected_traces_ttl_duration_hrs,omitempty"`
+	ExpectedTracesMoveTime   int    `json:"expected_traces_move_ttl_duration_hrs,omitempty"`
+	ExpectedLogsTime         int    `json:"expected_logs_ttl_duration_hrs,omitempty"`
+	ExpectedLogsMoveTime     int    `json:"expected_logs_move_ttl_duration_hrs,omitempty"`
+	AlertHistoryTime         int    `json:"alert_history_ttl_duration_hrs,omitempty"`
+	ExpectedAlertHistoryTime int    `json:"expected_alert_history_ttl_duration_hrs,omitempty"`
+	Status                   string `json:"status"`
 }
 
 type DBResponseServiceName struct {
@@ -792,6 +792,7 @@ type BuilderQuery struct {
 	TimeAggregation      TimeAggregation   `json:"timeAggregation,omitempty"`
 	SpaceAggregation     SpaceAggregation  `json:"spaceAggregation,omitempty"`
 	Functions            []Function        `json:"functions,omitempty"`
+	JoinOn               []string          `json:"joinOn,omitempty"`
 	ShiftBy              int64
 	IsAnomaly            bool
 	QueriesUsedInFormula []string
@@ -856,6 +857,7 @@ func (b *BuilderQuery) Clone() *BuilderQuery {
 		TimeAggregation:      b.TimeAggregation,
 		SpaceAggregation:     b.SpaceAggregation,
 		Functions:            b.Functions,
+		JoinOn:               b.JoinOn,
 		ShiftBy:              b.ShiftBy,
 		IsAnomaly:            b.IsAnomaly,
 		QueriesUsedInFormula: b.QueriesUsedInFormula,
@@ -974,6 +976,22 @@ func (b *BuilderQuery) Validate(panelType PanelType) error {
 		return fmt.Errorf("expression is required")
 	}
 
+	if len(b.JoinOn) > 0 {
+		if b.QueryName == b.Expression {
+			return fmt.Errorf("join on is only supported for formulas")
+		}
+		joinOn := make(map[string]struct{})
+		for _, label := range b.JoinOn {
+			if label == "" {
+				return fmt.Errorf("join on label is required")
+			}
+			if _, ok := joinOn[label]; ok {
+				return fmt.Errorf("duplicate join on label %s", label)
+			}
+			joinOn[label] = struct{}{}
+		}
+	}
+
 	if len(b.Functions) > 0 {
 		for _, function := range b.Functions {
 			if err := function.Name.Validate(); err != nil {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/SigNoz/govaluate"
@@ -70,34 +71,46 @@ func joinAndCalculate(
 	canDefaultZero map[string]bool,
 ) (*v3.Series, error) {
 
-	uniqueTimestamps := make(map[int64]struct{})
-	// map[queryName]map[timestamp]value
-	seriesMap := make(map[string]map[int64]float64)
+	// map[queryName]series
+	matchingSeries := make(map[string]*v3.Series)
 	for _, result := range results {
-		var matchingSeries *v3.Series
 		// We try to find a series that matches the label set from the current query result
 		for _, series := range result.Series {
 			if isSubset(uniqueLabelSet, series.Labels) {
-				matchingSeries = series
+				matchingSeries[result.QueryName] = series
 				break
 			}
 		}
+	}
+	return calculate(matchingSeries, uniqueLabelSet, expression, canDefaultZero)
+}
 
+// calculate joins the matching series of the queries on timestamp and evaluates the
+// expression at every timestamp
+func calculate(
+	matchingSeries map[string]*v3.Series,
+	labels map[string]string,
+	expression *govaluate.EvaluableExpression,
+	canDefaultZero map[string]bool,
+) (*v3.Series, error) {
+
+	uniqueTimestamps := make(map[int64]struct{})
+	// map[queryName]map[timestamp]value
+	seriesMap := make(map[string]map[int64]float64)
+	for queryName, series := range matchingSeries {
 		// Prepare the seriesMap for quick lookup during evaluation
 		// seriesMap[queryName][timestamp]value contains the value of the series with the given queryName at the given timestamp
-		if matchingSeries != nil {
-			for _, point := range matchingSeries.Points {
-				if _, ok := seriesMap[result.QueryName]; !ok {
-					seriesMap[result.QueryName] = make(map[int64]float64)
-				}
-				seriesMap[result.QueryName][point.Timestamp] = point.Value
-				uniqueTimestamps[point.Timestamp] = struct{}{}
+		for _, point := range series.Points {
+			if _, ok := seriesMap[queryName]; !ok {
+				seriesMap[queryName] = make(map[int64]float64)
 			}
+			seriesMap[queryName][point.Timestamp] = point.Value
+			uniqueTimestamps[point.Timestamp] = struct{}{}
 		}
 	}
 
 	resultSeries := &v3.Series{
-		Labels: uniqueLabelSet,
+		Labels: labels,
 		Points: make([]v3.Point, 0),
 	}
 	timestamps := make([]int64, 0)
@@ -158,6 +171,116 @@ func joinAndCalculate(
 	return resultSeries, nil
 }
 
+// joinKey is the values of the join labels of the series, false when the series lacks
+// one of the labels
+func joinKey(labels map[string]string, joinOn []string) (string, bool) {
+	values := make([]string, 0, len(joinOn))
+	for _, label := range joinOn {
+		value, ok := labels[label]
+		if !ok {
+			return "", false
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, "\xff"), true
+}
+
+// processJoinedResults evaluates the expression on the series of the queries matched on
+// the join labels alone, e.g the errors by service and status over the requests by
+// service. Every combination of the series matched is evaluated and the series joined
+// keep the labels of all the matched series
+func processJoinedResults(
+	results []*v3.Result,
+	expression *govaluate.EvaluableExpression,
+	canDefaultZero map[string]bool,
+	joinOn []string,
+) (*v3.Result, error) {
+
+	queriesInExpression := make(map[string]struct{})
+	for _, v := range expression.Vars() {
+		queriesInExpression[v] = struct{}{}
+	}
+
+	// map[joinKey]map[queryName][]series
+	seriesByKey := make(map[string]map[string][]*v3.Series)
+	keys := make([]string, 0)
+	queryNames := make([]string, 0)
+	for _, result := range results {
+		if _, ok := queriesInExpression[result.QueryName]; !ok {
+			continue
+		}
+		queryNames = append(queryNames, result.QueryName)
+		for _, series := range result.Series {
+			key, ok := joinKey(series.Labels, joinOn)
+			if !ok {
+				continue
+			}
+			if _, ok := seriesByKey[key]; !ok {
+				seriesByKey[key] = make(map[string][]*v3.Series)
+				keys = append(keys, key)
+			}
+			seriesByKey[key][result.QueryName] = append(seriesByKey[key][result.QueryName], series)
+		}
+	}
+
+	newSeries := make([]*v3.Series, 0)
+	for _, key := range keys {
+		// the combinations of the series of the queries sharing the join labels
+		combinations := []map[string]*v3.Series{{}}
+		for _, queryName := range queryNames {
+			matched := seriesByKey[key][queryName]
+			if len(matched) == 0 {
+				continue
+			}
+			next := make([]map[string]*v3.Series, 0, len(combinations)*len(matched))
+			for _, combination := range combinations {
+				for _, series := range matched {
+					joined := make(map[string]*v3.Series, len(combination)+1)
+					for name, s := range combination {
+						joined[name] = s
+					}
+					joined[queryName] = series
+					next = append(next, joined)
+				}
+			}
+			combinations = next
+		}
+
+		for _, combination := range combinations {
+			labels := make(map[string]string)
+			for _, queryName := range queryNames {
+				if series, ok := combination[queryName]; ok {
+					for k, v := range series.Labels {
+						if _, ok := labels[k]; !ok {
+							labels[k] = v
+						}
+					}
+				}
+			}
+			series, err := calculate(combination, labels, expression, canDefaultZero)
+			if err != nil {
+				return nil, err
+			}
+			if len(series.Points) != 0 {
+				series.LabelsArray = labelsArray(series.Labels)
+				newSeries = append(newSeries, series)
+			}
+		}
+	}
+
+	return &v3.Result{
+		Series: newSeries,
+	}, nil
+}
+
+func labelsArray(labels map[string]string) []map[string]string {
+	labelsArray := make([]map[string]string, 0)
+	for k, v := range labels {
+		labelsArray = append(labelsArray, map[string]string{k: v})
+	}
+	return labelsArray
+}
+
 // Main function to process the Results
 // A series can be "join"ed with other series if they have the same label set or one is a subset of the other.
 // 1. Find all unique label sets
@@ -183,11 +306,7 @@ func processResults(
 			return nil, err
 		}
 		if series != nil && len(series.Points) != 0 {
-			labelsArray := make([]map[string]string, 0)
-			for k, v := range series.Labels {
-				labelsArray = append(labelsArray, map[string]string{k: v})
-			}
-			series.LabelsArray = labelsArray
+			series.LabelsArray = labelsArray(series.Labels)
 			newSeries = append(newSeries, series)
 		}
 	}
@@ -1785,3 +1785,73 @@ func TestProcessResultsMixedQueries(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessJoinedResults(t *testing.T) {
+	// the errors by service and status over the requests by service and operation
+	results := []*v3.Result{
+		{
+			QueryName: "A",
+			Series: []*v3.Series{
+				{
+					Labels: map[string]string{"service_name": "frontend", "status_code": "500"},
+					Points: []v3.Point{{Timestamp: 1, Value: 10}, {Timestamp: 2, Value: 20}},
+				},
+				{
+					Labels: map[string]string{"service_name": "frontend", "status_code": "503"},
+					Points: []v3.Point{{Timestamp: 1, Value: 5}},
+				},
+				{
+					Labels: map[string]string{"service_name": "redis", "status_code": "500"},
+					Points: []v3.Point{{Timestamp: 1, Value: 1}},
+				},
+			},
+		},
+		{
+			QueryName: "B",
+			Series: []*v3.Series{
+				{
+					Labels: map[string]string{"service_name": "frontend", "operation": "GET /api"},
+					Points: []v3.Point{{Timestamp: 1, Value: 100}, {Timestamp: 2, Value: 200}},
+				},
+				{
+					Labels: map[string]string{"operation": "GET /health"},
+					Points: []v3.Point{{Timestamp: 1, Value: 100}},
+				},
+			},
+		},
+	}
+	want := []*v3.Series{
+		{
+			Labels: map[string]string{"service_name": "frontend", "status_code": "500", "operation": "GET /api"},
+			Points: []v3.Point{{Timestamp: 1, Value: 0.1}, {Timestamp: 2, Value: 0.1}},
+		},
+		{
+			Labels: map[string]string{"service_name": "frontend", "status_code": "503", "operation": "GET /api"},
+			Points: []v3.Point{{Timestamp: 1, Value: 0.05}},
+		},
+	}
+
+	expression, err := govaluate.NewEvaluableExpression("A/B")
+	if err != nil {
+		t.Fatalf("Error parsing expression: %v", err)
+	}
+	canDefaultZero := map[string]bool{
+		"A": false,
+		"B": false,
+	}
+	got, err := processJoinedResults(results, expression, canDefaultZero, []string{"service_name"})
+	if err != nil {
+		t.Fatalf("Error processing results: %v", err)
+	}
+	if len(got.Series) != len(want) {
+		t.Fatalf("processJoinedResults(): number of series - got = %v, want %v", len(got.Series), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got.Series[i].Labels, want[i].Labels) {
+			t.Errorf("processJoinedResults(): labels - got = %v, want %v", got.Series[i].Labels, want[i].Labels)
+		}
+		if !reflect.DeepEqual(got.Series[i].Points, want[i].Points) {
+			t.Errorf("processJoinedResults(): points - got = %v, want %v", got.Series[i].Points, want[i].Points)
+		}
+	}
+}
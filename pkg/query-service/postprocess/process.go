@@ -58,7 +58,12 @@ func PostProcessResult(result []*v3.Result, queryRangeParams *v3.QueryRangeParam
 				zap.L().Error("error in expression", zap.Error(err))
 				return nil, err
 			}
-			formulaResult, err := processResults(result, expression, canDefaultZero)
+			var formulaResult *v3.Result
+			if len(query.JoinOn) > 0 {
+				formulaResult, err = processJoinedResults(result, expression, canDefaultZero, query.JoinOn)
+			} else {
+				formulaResult, err = processResults(result, expression, canDefaultZero)
+			}
 			if err != nil {
 				zap.L().Error("error in expression", zap.Error(err))
 				return nil, err
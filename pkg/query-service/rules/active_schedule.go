@@ -0,0 +1,150 @@
+package rules
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// ActiveScheduleMode decides what the rule does outside the active windows
+type ActiveScheduleMode string
+
+const (
+	// ActiveScheduleModeEvaluate evaluates the rule only
+	// during the active windows
+	ActiveScheduleModeEvaluate ActiveScheduleMode = "evaluate"
+	// ActiveScheduleModeNotify keeps evaluating the rule but sends
+	// the notifications only during the active windows
+	ActiveScheduleModeNotify ActiveScheduleMode = "notify"
+)
+
+const activeWindowTimeLayout = "15:04"
+
+// ActiveSchedule captures the time windows (e.g business hours) during which
+// the rule is active, outside these windows the rule is silent
+type ActiveSchedule struct {
+	Timezone string             `yaml:"timezone" json:"timezone"`
+	Mode     ActiveScheduleMode `yaml:"mode,omitempty" json:"mode,omitempty"`
+	Windows  []ActiveWindow     `yaml:"windows" json:"windows"`
+}
+
+// ActiveWindow is a daily time range in the schedule timezone, the end time
+// may be before the start time for the windows spanning midnight
+type ActiveWindow struct {
+	// Days the window starts on, all days when empty
+	Days      []RepeatOn `yaml:"days,omitempty" json:"days,omitempty"`
+	StartTime string     `yaml:"startTime" json:"startTime"`
+	EndTime   string     `yaml:"endTime" json:"endTime"`
+}
+
+func (s *ActiveSchedule) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return errors.Wrapf(err, "invalid active schedule timezone %q", s.Timezone)
+	}
+	switch s.Mode {
+	case "", ActiveScheduleModeEvaluate, ActiveScheduleModeNotify:
+	default:
+		return errors.Errorf("unsupported active schedule mode: %s", s.Mode)
+	}
+	if len(s.Windows) == 0 {
+		return errors.Errorf("active schedule requires at least one window")
+	}
+	for _, w := range s.Windows {
+		start, err := time.Parse(activeWindowTimeLayout, w.StartTime)
+		if err != nil {
+			return errors.Errorf("invalid active window start time %q, expected HH:MM", w.StartTime)
+		}
+		end, err := time.Parse(activeWindowTimeLayout, w.EndTime)
+		if err != nil {
+			return errors.Errorf("invalid active window end time %q, expected HH:MM", w.EndTime)
+		}
+		if start.Equal(end) {
+			return errors.Errorf("active window start and end time cannot be the same")
+		}
+		for _, day := range w.Days {
+			if !slices.Contains(weekdays, day) {
+				return errors.Errorf("invalid active window day: %s", day)
+			}
+		}
+	}
+	return nil
+}
+
+var weekdays = []RepeatOn{
+	RepeatOnSunday,
+	RepeatOnMonday,
+	RepeatOnTuesday,
+	RepeatOnWednesday,
+	RepeatOnThursday,
+	RepeatOnFriday,
+	RepeatOnSaturday,
+}
+
+// isActive returns true if the given time falls in any of the windows
+func (s *ActiveSchedule) isActive(now time.Time) bool {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		zap.L().Error("Error loading location", zap.String("timezone", s.Timezone), zap.Error(err))
+		// don't silence the rule on a bad schedule
+		return true
+	}
+	currentTime := now.In(loc)
+
+	for _, w := range s.Windows {
+		start, err := time.Parse(activeWindowTimeLayout, w.StartTime)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse(activeWindowTimeLayout, w.EndTime)
+		if err != nil {
+			continue
+		}
+
+		today := time.Date(currentTime.Year(), currentTime.Month(), currentTime.Day(), 0, 0, 0, 0, loc)
+		// check the window started today and, for the windows spanning
+		// midnight, the window started yesterday
+		for _, day := range []time.Time{today, today.AddDate(0, 0, -1)} {
+			if !w.startsOn(day.Weekday()) {
+				continue
+			}
+			windowStart := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+			windowEnd := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+			if !windowEnd.After(windowStart) {
+				windowEnd = windowEnd.AddDate(0, 0, 1)
+			}
+			if !currentTime.Before(windowStart) && currentTime.Before(windowEnd) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (w *ActiveWindow) startsOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	return slices.Contains(w.Days, RepeatOn(strings.ToLower(day.String())))
+}
+
+// shouldEvaluate returns true if the rule should be evaluated at the given time
+func (s *ActiveSchedule) shouldEvaluate(now time.Time) bool {
+	if s == nil || s.Mode == ActiveScheduleModeNotify {
+		return true
+	}
+	return s.isActive(now)
+}
+
+// shouldNotify returns true if the notifications should be sent at the given time
+func (s *ActiveSchedule) shouldNotify(now time.Time) bool {
+	if s == nil {
+		return true
+	}
+	return s.isActive(now)
+}
@@ -0,0 +1,192 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestActiveSchedule(t *testing.T) {
+	businessHours := &ActiveSchedule{
+		Timezone: "Asia/Kolkata",
+		Windows: []ActiveWindow{
+			{
+				Days:      []RepeatOn{RepeatOnMonday, RepeatOnTuesday, RepeatOnWednesday, RepeatOnThursday, RepeatOnFriday},
+				StartTime: "09:00",
+				EndTime:   "18:00",
+			},
+		},
+	}
+	overnight := &ActiveSchedule{
+		Timezone: "UTC",
+		Mode:     ActiveScheduleModeNotify,
+		Windows: []ActiveWindow{
+			{
+				Days:      []RepeatOn{RepeatOnFriday},
+				StartTime: "22:00",
+				EndTime:   "06:00",
+			},
+		},
+	}
+
+	cases := []struct {
+		name             string
+		schedule         *ActiveSchedule
+		ts               time.Time
+		expectedEvaluate bool
+		expectedNotify   bool
+	}{
+		{
+			name:             "no schedule",
+			schedule:         nil,
+			ts:               time.Date(2024, 5, 6, 3, 0, 0, 0, time.UTC),
+			expectedEvaluate: true,
+			expectedNotify:   true,
+		},
+		{
+			name:     "monday 10:30 IST is in business hours",
+			schedule: businessHours,
+			// 10:30 IST
+			ts:               time.Date(2024, 5, 6, 5, 0, 0, 0, time.UTC),
+			expectedEvaluate: true,
+			expectedNotify:   true,
+		},
+		{
+			name:     "monday 19:30 IST is outside business hours",
+			schedule: businessHours,
+			// 19:30 IST
+			ts:               time.Date(2024, 5, 6, 14, 0, 0, 0, time.UTC),
+			expectedEvaluate: false,
+			expectedNotify:   false,
+		},
+		{
+			name:     "sunday 10:30 IST is outside business hours",
+			schedule: businessHours,
+			// 10:30 IST
+			ts:               time.Date(2024, 5, 5, 5, 0, 0, 0, time.UTC),
+			expectedEvaluate: false,
+			expectedNotify:   false,
+		},
+		{
+			name:             "saturday 02:00 is in the window started on friday",
+			schedule:         overnight,
+			ts:               time.Date(2024, 5, 4, 2, 0, 0, 0, time.UTC),
+			expectedEvaluate: true,
+			expectedNotify:   true,
+		},
+		{
+			name:             "friday 02:00 is not in the window started on thursday",
+			schedule:         overnight,
+			ts:               time.Date(2024, 5, 3, 2, 0, 0, 0, time.UTC),
+			expectedEvaluate: true,
+			expectedNotify:   false,
+		},
+	}
+
+	for _, c := range cases {
+		if result := c.schedule.shouldEvaluate(c.ts); result != c.expectedEvaluate {
+			t.Errorf("%s: expected evaluate %v, got %v", c.name, c.expectedEvaluate, result)
+		}
+		if result := c.schedule.shouldNotify(c.ts); result != c.expectedNotify {
+			t.Errorf("%s: expected notify %v, got %v", c.name, c.expectedNotify, result)
+		}
+	}
+}
+
+func TestActiveScheduleValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		schedule *ActiveSchedule
+		valid    bool
+	}{
+		{
+			name:     "valid",
+			schedule: &ActiveSchedule{Timezone: "UTC", Windows: []ActiveWindow{{StartTime: "09:00", EndTime: "17:00"}}},
+			valid:    true,
+		},
+		{
+			name:     "invalid timezone",
+			schedule: &ActiveSchedule{Timezone: "Mars/Olympus", Windows: []ActiveWindow{{StartTime: "09:00", EndTime: "17:00"}}},
+		},
+		{
+			name:     "no windows",
+			schedule: &ActiveSchedule{Timezone: "UTC"},
+		},
+		{
+			name:     "invalid time",
+			schedule: &ActiveSchedule{Timezone: "UTC", Windows: []ActiveWindow{{StartTime: "9am", EndTime: "17:00"}}},
+		},
+		{
+			name:     "invalid day",
+			schedule: &ActiveSchedule{Timezone: "UTC", Windows: []ActiveWindow{{Days: []RepeatOn{"someday"}, StartTime: "09:00", EndTime: "17:00"}}},
+		},
+	}
+
+	for _, c := range cases {
+		err := c.schedule.Validate()
+		if c.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if !c.valid && err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
+
+type resolvedHistoryReader struct {
+	stateHistoryReader
+	written []model.RuleStateHistory
+}
+
+func (r *resolvedHistoryReader) AddRuleStateHistory(ctx context.Context, ruleStateHistory []model.RuleStateHistory) error {
+	r.written = append(r.written, ruleStateHistory...)
+	return nil
+}
+
+func TestActiveScheduleResolvesAlerts(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	reader := &resolvedHistoryReader{}
+	rule := &BaseRule{
+		id:     "1",
+		reader: reader,
+		Active: map[uint64]*Alert{
+			1: {State: model.StateFiring, QueryResultLables: labels.FromMap(map[string]string{"service": "frontend"}), FiredAt: ts.Add(-time.Hour), LastSentAt: ts.Add(-time.Minute)},
+			2: {State: model.StatePending, QueryResultLables: labels.FromMap(map[string]string{"service": "backend"}), ActiveAt: ts.Add(-time.Minute)},
+		},
+	}
+
+	// the alerts firing when the evaluate schedule closes are resolved
+	rule.ResolveActiveAlerts(context.Background(), ts)
+	assert.Len(t, rule.Active, 1)
+	assert.Equal(t, model.StateInactive, rule.Active[1].State)
+	assert.Equal(t, ts, rule.Active[1].ResolvedAt)
+	assert.Len(t, reader.written, 2)
+
+	resolved := 0
+	rule.SendAlerts(context.Background(), ts, time.Minute, time.Minute, func(ctx context.Context, expr string, alerts ...*Alert) {
+		resolved += len(alerts)
+	})
+	assert.Equal(t, 1, resolved)
+
+	// the resolved alerts are dropped after the retention
+	rule.ResolveActiveAlerts(context.Background(), ts.Add(ResolvedRetention+time.Minute))
+	assert.Empty(t, rule.Active)
+	assert.Len(t, reader.written, 2)
+}
+
+func TestResolvedExpired(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC)
+	resolvedAt := ts.Add(-ResolvedRetention - time.Minute)
+
+	assert.False(t, (&Alert{State: model.StateFiring}).ResolvedExpired(ts))
+	assert.False(t, (&Alert{ResolvedAt: ts.Add(-time.Minute)}).ResolvedExpired(ts))
+	assert.True(t, (&Alert{ResolvedAt: resolvedAt, LastSentAt: resolvedAt}).ResolvedExpired(ts))
+	// the resolves held by the notify schedule are kept until they are sent
+	assert.False(t, (&Alert{ResolvedAt: resolvedAt, LastSentAt: resolvedAt.Add(-time.Hour)}).ResolvedExpired(ts))
+	// the alerts never notified as firing have no resolve to send
+	assert.True(t, (&Alert{ResolvedAt: resolvedAt}).ResolvedExpired(ts))
+}
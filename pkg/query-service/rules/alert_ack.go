@@ -0,0 +1,106 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// AlertAckRetention is how long the acknowledgements are applied, the alert
+// firing for longer is notified again
+const AlertAckRetention = 7 * 24 * time.Hour
+
+// AlertAck acknowledges the firing alerts matching all the matchers, the
+// acknowledged alerts are not notified again until they resolve. The alert
+// firing again after it resolved is notified like a new alert.
+type AlertAck struct {
+	Id       int64               `json:"id" db:"id"`
+	Matchers MaintenanceMatchers `json:"matchers" db:"matchers"`
+	AckedAt  time.Time           `json:"ackedAt" db:"acked_at"`
+	AckedBy  string              `json:"ackedBy" db:"acked_by"`
+	Comment  string              `json:"comment" db:"comment"`
+}
+
+func (r *ruleDB) CreateAlertAck(ctx context.Context, ack AlertAck) (int64, error) {
+	if ack.AckedAt.IsZero() {
+		ack.AckedAt = time.Now()
+	}
+	ack.AckedAt = ack.AckedAt.UTC()
+
+	result, err := r.NamedExec(`INSERT INTO alert_acks (matchers, acked_at, acked_by, comment)
+		VALUES (:matchers, :acked_at, :acked_by, :comment)`, ack)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to alert_acks", zap.Error(err))
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	ack.Id = id
+
+	after, err := json.Marshal(ack)
+	if err != nil {
+		return id, err
+	}
+	return id, addAuditLog(ctx, r, AuditResourceAlertAck, strconv.FormatInt(id, 10), AuditActionCreate, "", string(after))
+}
+
+func (r *ruleDB) GetAlertAcks(ctx context.Context, since time.Time) ([]AlertAck, error) {
+	query, args := newSelectQuery("SELECT id, matchers, acked_at, acked_by, comment FROM alert_acks").
+		where("acked_at>=?", since.UTC()).
+		order("acked_at DESC").
+		build()
+
+	acks := []AlertAck{}
+	if err := r.Select(&acks, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return acks, nil
+}
+
+// acknowledges reports whether the alert fired before the acknowledgement and matches all its matchers
+func (a *AlertAck) acknowledges(alert *Alert) bool {
+	if !alert.ResolvedAt.IsZero() || alert.FiredAt.After(a.AckedAt) || len(a.Matchers) == 0 {
+		return false
+	}
+	for _, matcher := range a.Matchers {
+		parsed, err := parseMaintenanceMatcher(matcher)
+		if err != nil || !parsed.matches(alert.Labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// ackMatchingAlerts drops the notifications of the acknowledged firing alerts,
+// their resolved notifications are still sent
+func ackMatchingAlerts(notify NotifyFunc, acks []AlertAck) NotifyFunc {
+	if len(acks) == 0 {
+		return notify
+	}
+	return func(ctx context.Context, expr string, alerts ...*Alert) {
+		notified := make([]*Alert, 0, len(alerts))
+		for _, alert := range alerts {
+			acked := false
+			for i := range acks {
+				if acks[i].acknowledges(alert) {
+					zap.L().Debug("alert acknowledged", zap.Int64("ack", acks[i].Id), zap.String("labels", alert.Labels.String()))
+					acked = true
+					break
+				}
+			}
+			if !acked {
+				notified = append(notified, alert)
+			}
+		}
+		if len(notified) > 0 {
+			notify(ctx, expr, notified...)
+		}
+	}
+}
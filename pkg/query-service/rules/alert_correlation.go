@@ -0,0 +1,206 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	promModel "github.com/prometheus/common/model"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	// DefaultCorrelationWindow is the time range the correlations are mined over when
+	// the window is not given
+	DefaultCorrelationWindow = 7 * 24 * time.Hour
+	// maxCorrelationWindow bounds the history scanned for the correlations
+	maxCorrelationWindow = 30 * 24 * time.Hour
+	// DefaultCorrelationWithin is how close the firings of two rules are to fire together
+	DefaultCorrelationWithin = 5 * time.Minute
+	// maxCorrelationWithin bounds how far apart the firings firing together can be
+	maxCorrelationWithin = time.Hour
+
+	// minCorrelatedFirings is the number of the times two rules need to fire together
+	minCorrelatedFirings = 3
+	// minCorrelationScore is the share of the firings of two rules firing together
+	// for them to be correlated
+	minCorrelationScore = 0.5
+)
+
+// RuleCorrelation is the two rules whose alerts consistently fire together, the score is
+// the share of the firings of both rules firing together
+type RuleCorrelation struct {
+	RuleID        string `json:"ruleId"`
+	RuleName      string `json:"ruleName"`
+	OtherRuleID   string `json:"otherRuleId"`
+	OtherRuleName string `json:"otherRuleName"`
+	// Firings and OtherFirings are the number of the times each rule fired
+	Firings      int `json:"firings"`
+	OtherFirings int `json:"otherFirings"`
+	// CoFirings is the number of the times the rules fired together
+	CoFirings int     `json:"coFirings"`
+	Score     float64 `json:"score"`
+	// LeaderRuleID is the rule firing first most of the times the rules fired together,
+	// empty when neither does
+	LeaderRuleID string `json:"leaderRuleId,omitempty"`
+	Suggestion   string `json:"suggestion"`
+}
+
+// CorrelationReport is the correlated rules the user can view over the window, the
+// highest scores first
+type CorrelationReport struct {
+	Start        time.Time         `json:"start"`
+	End          time.Time         `json:"end"`
+	Within       Duration          `json:"within"`
+	Correlations []RuleCorrelation `json:"correlations"`
+}
+
+// ruleEpisodes is the times the rule started firing, the firings of the rule within
+// the correlation window of the first one are the same episode
+type ruleEpisodes struct {
+	name   string
+	starts []int64
+}
+
+// CorrelatedRules mines the state history of the rules the user can view for the rules
+// whose alerts fire within the given duration of each other over the window
+func (m *Manager) CorrelatedRules(ctx context.Context, window, within time.Duration) (*CorrelationReport, error) {
+	if window <= 0 {
+		window = DefaultCorrelationWindow
+	}
+	if within <= 0 {
+		within = DefaultCorrelationWithin
+	}
+	within = min(within, maxCorrelationWithin)
+	end := time.Now()
+	start := end.Add(-min(window, maxCorrelationWindow))
+	report := &CorrelationReport{Start: start, End: end, Within: Duration(within), Correlations: []RuleCorrelation{}}
+
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(storedRules) < 2 {
+		return report, nil
+	}
+	ruleIds := make([]string, 0, len(storedRules))
+	for _, storedRule := range storedRules {
+		ruleIds = append(ruleIds, strconv.Itoa(storedRule.Id))
+	}
+
+	events, err := m.reader.GetRuleFiringEvents(ctx, start.UnixMilli(), end.UnixMilli(), ruleIds)
+	if err != nil {
+		return nil, err
+	}
+	report.Correlations = correlateFirings(events, within)
+	return report, nil
+}
+
+// correlateFirings pairs the rules whose firing episodes start within the duration of
+// each other often enough
+func correlateFirings(events []model.RuleFiringEvent, within time.Duration) []RuleCorrelation {
+	withinMillis := within.Milliseconds()
+
+	episodes := map[string]*ruleEpisodes{}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].UnixMilli < events[j].UnixMilli
+	})
+	for _, event := range events {
+		e, ok := episodes[event.RuleID]
+		if !ok {
+			e = &ruleEpisodes{name: event.RuleName}
+			episodes[event.RuleID] = e
+		}
+		if n := len(e.starts); n > 0 && event.UnixMilli-e.starts[n-1] <= withinMillis {
+			continue
+		}
+		e.starts = append(e.starts, event.UnixMilli)
+	}
+
+	ids := make([]string, 0, len(episodes))
+	for id, e := range episodes {
+		if len(e.starts) >= minCorrelatedFirings {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	correlations := []RuleCorrelation{}
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			ea, eb := episodes[a], episodes[b]
+			matchedA, ledA := coFirings(ea.starts, eb.starts, withinMillis)
+			matchedB, ledB := coFirings(eb.starts, ea.starts, withinMillis)
+			co := min(matchedA, matchedB)
+			if co < minCorrelatedFirings {
+				continue
+			}
+			score := float64(co) / float64(len(ea.starts)+len(eb.starts)-co)
+			if score < minCorrelationScore {
+				continue
+			}
+
+			correlation := RuleCorrelation{
+				RuleID:        a,
+				RuleName:      ea.name,
+				OtherRuleID:   b,
+				OtherRuleName: eb.name,
+				Firings:       len(ea.starts),
+				OtherFirings:  len(eb.starts),
+				CoFirings:     co,
+				Score:         score,
+			}
+			// the rule firing first in two thirds of the co-firings is likely the cause
+			switch {
+			case ledA*3 >= matchedA*2:
+				correlation.LeaderRuleID = a
+				correlation.Suggestion = fmt.Sprintf("%s usually fires before %s, make %s depend on %s or group their alerts into one incident",
+					ea.name, eb.name, eb.name, ea.name)
+			case ledB*3 >= matchedB*2:
+				correlation.LeaderRuleID = b
+				correlation.Suggestion = fmt.Sprintf("%s usually fires before %s, make %s depend on %s or group their alerts into one incident",
+					eb.name, ea.name, ea.name, eb.name)
+			default:
+				correlation.Suggestion = fmt.Sprintf("%s and %s fire within %s of each other, group their alerts into one incident",
+					ea.name, eb.name, promModel.Duration(within))
+			}
+			correlations = append(correlations, correlation)
+		}
+	}
+
+	sort.SliceStable(correlations, func(i, j int) bool {
+		if correlations[i].Score != correlations[j].Score {
+			return correlations[i].Score > correlations[j].Score
+		}
+		return correlations[i].CoFirings > correlations[j].CoFirings
+	})
+	return correlations
+}
+
+// coFirings counts the episodes of a with an episode of b starting within the duration
+// of them, and how many of those started strictly before the episode of b
+func coFirings(a, b []int64, withinMillis int64) (matched, led int) {
+	j := 0
+	for _, start := range a {
+		for j < len(b) && b[j] < start-withinMillis {
+			j++
+		}
+		// the closest episode of b from the first one not too early
+		closest := -1
+		for k := j; k < len(b) && b[k] <= start+withinMillis; k++ {
+			if closest == -1 || abs(int(b[k]-start)) < abs(int(b[closest]-start)) {
+				closest = k
+			}
+		}
+		if closest == -1 {
+			continue
+		}
+		matched++
+		if start < b[closest] {
+			led++
+		}
+	}
+	return matched, led
+}
@@ -0,0 +1,43 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestCorrelateFirings(t *testing.T) {
+	minute := time.Minute.Milliseconds()
+	events := []model.RuleFiringEvent{}
+	fire := func(ruleId, name string, at int64) {
+		events = append(events, model.RuleFiringEvent{RuleID: ruleId, RuleName: name, UnixMilli: at})
+	}
+	for i := int64(0); i < 4; i++ {
+		base := i * 120 * minute
+		// the database fires first, the api follows
+		fire("1", "DB latency", base)
+		fire("1", "DB latency", base+minute)
+		fire("2", "API errors", base+2*minute)
+		// unrelated
+		fire("3", "Disk usage", base+60*minute)
+	}
+	// fires once with the others
+	fire("4", "Queue lag", 0)
+
+	correlations := correlateFirings(events, 5*time.Minute)
+	if assert.Len(t, correlations, 1) {
+		c := correlations[0]
+		assert.Equal(t, "1", c.RuleID)
+		assert.Equal(t, "2", c.OtherRuleID)
+		assert.Equal(t, 4, c.Firings)
+		assert.Equal(t, 4, c.CoFirings)
+		assert.Equal(t, 1.0, c.Score)
+		assert.Equal(t, "1", c.LeaderRuleID)
+		assert.Equal(t, "DB latency usually fires before API errors, make API errors depend on DB latency or group their alerts into one incident", c.Suggestion)
+	}
+
+	// too far apart
+	assert.Empty(t, correlateFirings(events, time.Minute))
+}
@@ -0,0 +1,121 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// digestLabel marks the digest alert, it's not a series of the rule
+const digestLabel = "digest"
+
+// alertDigest batches the alerts of the rule under the daily digest policy: the alerts
+// are not notified on their own, the alerts firing during the day are notified together
+// in one digest alert once the day is over
+type alertDigest struct {
+	// start is when the first alert of the day was added, zero while there are none
+	start time.Time
+	// notifiedAt is when the last digest was notified
+	notifiedAt time.Time
+	alerts     map[uint64]*Alert
+}
+
+func newAlertDigest(settings *NotificationSettings) *alertDigest {
+	if settings.policy() != NotifyPolicyDailyDigest {
+		return nil
+	}
+	return &alertDigest{alerts: map[uint64]*Alert{}}
+}
+
+// add records the latest state of the alert in the digest of the day, the alerts
+// resolved before the last digest were notified by it
+func (d *alertDigest) add(ts time.Time, alert *Alert) {
+	if !alert.ResolvedAt.IsZero() && !alert.ResolvedAt.After(d.notifiedAt) {
+		return
+	}
+	if len(d.alerts) == 0 {
+		d.start = ts
+	}
+	copied := *alert
+	d.alerts[alert.Labels.Hash()] = &copied
+}
+
+// flush returns the digest of the day once it's over, nil until then. The alerts
+// still firing open the digest of the next day.
+func (d *alertDigest) flush(ts time.Time) []*Alert {
+	if len(d.alerts) == 0 || ts.Sub(d.start) < dailyInterval {
+		return nil
+	}
+
+	alerts := make([]*Alert, 0, len(d.alerts))
+	for hash, alert := range d.alerts {
+		alerts = append(alerts, alert)
+		if !alert.ResolvedAt.IsZero() {
+			delete(d.alerts, hash)
+		}
+	}
+	d.start, d.notifiedAt = ts, ts
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].FiredAt.Equal(alerts[j].FiredAt) {
+			return alerts[i].FiredAt.Before(alerts[j].FiredAt)
+		}
+		return alerts[i].Labels.String() < alerts[j].Labels.String()
+	})
+	return alerts
+}
+
+// digestAlert returns the alert notifying the alerts of the day, it has the labels common
+// to the alerts and lists the alerts in its description. The digest is never notified as
+// resolved, it's routed to the copies of the channels not sending the resolved alerts.
+func digestAlert(ts, validUntil time.Time, alerts []*Alert) *Alert {
+	common := labelsMap(alerts[0].Labels)
+	for _, alert := range alerts[1:] {
+		for name, value := range common {
+			if alert.Labels.Get(name) != value {
+				delete(common, name)
+			}
+		}
+	}
+	common[digestLabel] = string(NotifyPolicyDailyDigest)
+
+	firing := 0
+	lines := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		series := map[string]string{}
+		for name, value := range labelsMap(alert.Labels) {
+			if _, ok := common[name]; !ok {
+				series[name] = value
+			}
+		}
+		line := fmt.Sprintf("- %s fired at %s", labels.FromMap(series).String(), alert.FiredAt.UTC().Format(time.RFC3339))
+		if alert.ResolvedAt.IsZero() {
+			firing++
+			line += ", still firing"
+		} else {
+			line += ", resolved at " + alert.ResolvedAt.UTC().Format(time.RFC3339)
+		}
+		lines = append(lines, line)
+	}
+
+	annotations := map[string]string{
+		labels.AlertSummaryLabel:     fmt.Sprintf("%d alerts fired in the last day, %d still firing", len(alerts), firing),
+		labels.AlertDescriptionLabel: strings.Join(lines, "\n"),
+	}
+
+	return &Alert{
+		State:        model.StateFiring,
+		Labels:       labels.FromMap(common),
+		Annotations:  labels.FromMap(annotations),
+		GeneratorURL: alerts[0].GeneratorURL,
+		Receivers:    alerts[0].Receivers,
+		ActiveAt:     ts,
+		FiredAt:      ts,
+		LastSentAt:   ts,
+		ValidUntil:   validUntil,
+		resolved:     &ResolvedNotifications{Disabled: true},
+	}
+}
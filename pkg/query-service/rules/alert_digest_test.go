@@ -0,0 +1,71 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestAlertDigest(t *testing.T) {
+	settings := &NotificationSettings{Policy: NotifyPolicyDailyDigest}
+	assert.NoError(t, settings.Validate())
+	assert.Nil(t, newAlertDigest(&NotificationSettings{Policy: NotifyPolicyDaily}))
+
+	var notified [][]*Alert
+	notify := func(ctx context.Context, expr string, alerts ...*Alert) {
+		notified = append(notified, alerts)
+	}
+	alert := func(pod string, firedAt time.Time) *Alert {
+		return &Alert{
+			State:     model.StateFiring,
+			Labels:    labels.FromMap(map[string]string{labels.AlertNameLabel: "cpu", "severity": "warning", "pod": pod}),
+			Receivers: []string{"slack"},
+			FiredAt:   firedAt,
+		}
+	}
+
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	rule := &BaseRule{notificationSettings: settings, digest: newAlertDigest(settings), Active: map[uint64]*Alert{}}
+	rule.Active[1] = alert("cart-1", start)
+	rule.SendAlerts(context.Background(), start, time.Minute, time.Minute, notify)
+	rule.Active[2] = alert("cart-2", start.Add(time.Hour))
+	rule.Active[3] = &Alert{State: model.StatePending, Labels: labels.FromMap(map[string]string{"pod": "cart-3"})}
+	rule.SendAlerts(context.Background(), start.Add(time.Hour), time.Minute, time.Minute, notify)
+	rule.Active[1].State = model.StateInactive
+	rule.Active[1].ResolvedAt = start.Add(2 * time.Hour)
+	rule.SendAlerts(context.Background(), start.Add(2*time.Hour), time.Minute, time.Minute, notify)
+
+	// the alerts are held until the day is over
+	assert.Empty(t, notified)
+
+	// then the alerts of the day are notified in one digest
+	rule.SendAlerts(context.Background(), start.Add(24*time.Hour), time.Minute, time.Minute, notify)
+	assert.Len(t, notified, 1)
+	assert.Len(t, notified[0], 1)
+	digest := notified[0][0]
+	assert.Equal(t, map[string]string{labels.AlertNameLabel: "cpu", "severity": "warning", digestLabel: "daily_digest"}, digest.Labels.Map())
+	assert.Equal(t, "2 alerts fired in the last day, 1 still firing", digest.Annotations.Get(labels.AlertSummaryLabel))
+	assert.Equal(t, `- {pod="cart-1"} fired at 2024-05-01T09:00:00Z, resolved at 2024-05-01T11:00:00Z
+- {pod="cart-2"} fired at 2024-05-01T10:00:00Z, still firing`, digest.Annotations.Get(labels.AlertDescriptionLabel))
+	assert.Equal(t, []string{"slack"}, digest.Receivers)
+	assert.True(t, digest.resolved.disables("slack"))
+	assert.True(t, digest.ValidUntil.After(start.Add(24*time.Hour)))
+
+	// the alert still firing opens the digest of the next day, the resolved alert is not notified again
+	rule.SendAlerts(context.Background(), start.Add(36*time.Hour), time.Minute, time.Minute, notify)
+	assert.Len(t, notified, 1)
+	rule.SendAlerts(context.Background(), start.Add(48*time.Hour), time.Minute, time.Minute, notify)
+	assert.Len(t, notified, 2)
+	assert.Equal(t, "1 alerts fired in the last day, 1 still firing", notified[1][0].Annotations.Get(labels.AlertSummaryLabel))
+
+	// the test notifications are sent right away
+	rule.sendAlways = true
+	rule.SendAlerts(context.Background(), start.Add(49*time.Hour), time.Minute, time.Minute, notify)
+	assert.Len(t, notified, 3)
+	assert.Len(t, notified[2], 2)
+	assert.False(t, notified[2][0].Labels.Has(digestLabel))
+}
@@ -0,0 +1,186 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	promModel "github.com/prometheus/common/model"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultFatigueWindow is the time range the rules are analyzed over when the
+	// window is not given
+	DefaultFatigueWindow = 14 * 24 * time.Hour
+	// maxFatigueWindow bounds the history scanned for the analysis
+	maxFatigueWindow = 90 * 24 * time.Hour
+
+	// flappingMinFirings is the number of the short firings over the window the rule
+	// needs to be flagged as flapping, along with most of its firings being short
+	flappingMinFirings = 5
+	// unackedMinFirings is the number of the firings over the window the rule needs to
+	// be flagged as never acknowledged
+	unackedMinFirings = 3
+)
+
+// FatigueIssue is the pattern of the rule causing alert fatigue
+type FatigueIssue string
+
+const (
+	// FatigueIssueFlapping is the rule firing and resolving within minutes repeatedly
+	FatigueIssueFlapping FatigueIssue = "flapping"
+	// FatigueIssueNeverFires is the enabled rule that did not fire over the window
+	FatigueIssueNeverFires FatigueIssue = "never_fires"
+	// FatigueIssueNeverAcknowledged is the rule firing without its alerts being acknowledged
+	FatigueIssueNeverAcknowledged FatigueIssue = "never_acknowledged"
+)
+
+// FatigueAction is the change of the rule recommended to address the issue
+type FatigueAction string
+
+const (
+	FatigueActionRaiseThreshold FatigueAction = "raise_threshold"
+	FatigueActionAddFor         FatigueAction = "add_for_duration"
+	FatigueActionDelete         FatigueAction = "delete"
+)
+
+// FatigueRecommendation is the action recommended for the issue of the rule
+type FatigueRecommendation struct {
+	Issue   FatigueIssue  `json:"issue"`
+	Action  FatigueAction `json:"action"`
+	Message string        `json:"message"`
+}
+
+// RuleFatigue is the fatigue score of the rule with the recommendations addressing it,
+// the score is from 0 to 100, the higher the noisier
+type RuleFatigue struct {
+	RuleID          string                  `json:"ruleId"`
+	RuleName        string                  `json:"ruleName"`
+	Score           float64                 `json:"score"`
+	Firings         uint64                  `json:"firings"`
+	ShortFirings    uint64                  `json:"shortFirings"`
+	Acknowledged    int                     `json:"acknowledged"`
+	Recommendations []FatigueRecommendation `json:"recommendations"`
+}
+
+// FatigueReport is the rules the user can view causing alert fatigue over the window,
+// the highest scores first
+type FatigueReport struct {
+	Start time.Time     `json:"start"`
+	End   time.Time     `json:"end"`
+	Rules []RuleFatigue `json:"rules"`
+}
+
+// AlertFatigue analyzes the state history and the acknowledgements of the rules the
+// user can view over the window and returns the rules flapping, never firing or never
+// acknowledged with the recommendations addressing them
+func (m *Manager) AlertFatigue(ctx context.Context, window time.Duration) (*FatigueReport, error) {
+	if window <= 0 {
+		window = DefaultFatigueWindow
+	}
+	end := time.Now()
+	start := end.Add(-min(window, maxFatigueWindow))
+	report := &FatigueReport{Start: start, End: end, Rules: []RuleFatigue{}}
+
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(storedRules) == 0 {
+		return report, nil
+	}
+	ruleIds := make([]string, 0, len(storedRules))
+	for _, storedRule := range storedRules {
+		ruleIds = append(ruleIds, strconv.Itoa(storedRule.Id))
+	}
+
+	firingStats, err := m.reader.GetRuleFiringStats(ctx, start.UnixMilli(), end.UnixMilli(), ruleIds)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]model.RuleFiringStats, len(firingStats))
+	for _, s := range firingStats {
+		stats[s.RuleID] = s
+	}
+	ackStats, err := m.ruleDB.GetAlertAckStatsByRule(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, storedRule := range storedRules {
+		rule, err := ParsePostableRule([]byte(storedRule.Data))
+		if err != nil {
+			zap.L().Error("failed to parse rule from db", zap.Int("id", storedRule.Id), zap.Error(err))
+			continue
+		}
+		id := strconv.Itoa(storedRule.Id)
+		s := stats[id]
+		fatigue := RuleFatigue{
+			RuleID:       id,
+			RuleName:     rule.AlertName,
+			Firings:      s.Firings,
+			ShortFirings: s.ShortFirings,
+			Acknowledged: ackStats[id].Acknowledged,
+		}
+		// the rules created during the window could not have fired before
+		existed := storedRule.CreatedAt == nil || storedRule.CreatedAt.Before(start)
+		scoreFatigue(&fatigue, time.Duration(rule.For), !storedRule.Disabled && existed, end.Sub(start))
+		if len(fatigue.Recommendations) > 0 {
+			report.Rules = append(report.Rules, fatigue)
+		}
+	}
+
+	sort.SliceStable(report.Rules, func(i, j int) bool {
+		if report.Rules[i].Score != report.Rules[j].Score {
+			return report.Rules[i].Score > report.Rules[j].Score
+		}
+		return report.Rules[i].RuleID < report.Rules[j].RuleID
+	})
+	return report, nil
+}
+
+// scoreFatigue flags the issues of the rule, the flapping rules score up to 60 by their
+// share of the short firings, the never acknowledged rules 30 and the silent rules 10
+func scoreFatigue(fatigue *RuleFatigue, holdDuration time.Duration, checkSilent bool, window time.Duration) {
+	shortFiring := time.Duration(model.ShortFiringMillis) * time.Millisecond
+	shortFiringText := promModel.Duration(shortFiring).String()
+
+	if fatigue.Firings > 0 && fatigue.ShortFirings >= flappingMinFirings && fatigue.ShortFirings*2 >= fatigue.Firings {
+		fatigue.Score += 60 * float64(fatigue.ShortFirings) / float64(fatigue.Firings)
+		if holdDuration < shortFiring {
+			fatigue.Recommendations = append(fatigue.Recommendations, FatigueRecommendation{
+				Issue:  FatigueIssueFlapping,
+				Action: FatigueActionAddFor,
+				Message: fmt.Sprintf("%d of the %d firings resolved within %s, add a For duration of at least %s so the alerts resolving quickly do not fire",
+					fatigue.ShortFirings, fatigue.Firings, shortFiringText, shortFiringText),
+			})
+		}
+		fatigue.Recommendations = append(fatigue.Recommendations, FatigueRecommendation{
+			Issue:   FatigueIssueFlapping,
+			Action:  FatigueActionRaiseThreshold,
+			Message: fmt.Sprintf("%d of the %d firings resolved within %s, raise the threshold above the usual fluctuations", fatigue.ShortFirings, fatigue.Firings, shortFiringText),
+		})
+	}
+
+	if fatigue.Firings >= unackedMinFirings && fatigue.Acknowledged == 0 {
+		fatigue.Score += 30
+		fatigue.Recommendations = append(fatigue.Recommendations, FatigueRecommendation{
+			Issue:   FatigueIssueNeverAcknowledged,
+			Action:  FatigueActionRaiseThreshold,
+			Message: fmt.Sprintf("the rule fired %d times and none of its alerts were acknowledged, raise the threshold so it fires only when action is needed", fatigue.Firings),
+		})
+	}
+
+	if checkSilent && fatigue.Firings == 0 {
+		fatigue.Score += 10
+		fatigue.Recommendations = append(fatigue.Recommendations, FatigueRecommendation{
+			Issue:   FatigueIssueNeverFires,
+			Action:  FatigueActionDelete,
+			Message: fmt.Sprintf("the rule did not fire in the last %s, delete it if the condition no longer applies or check its query", promModel.Duration(window)),
+		})
+	}
+}
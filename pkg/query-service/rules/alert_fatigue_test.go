@@ -0,0 +1,71 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestScoreFatigue(t *testing.T) {
+	// flapping without a For duration
+	fatigue := RuleFatigue{Firings: 10, ShortFirings: 8, Acknowledged: 2}
+	scoreFatigue(&fatigue, 0, true, 14*24*time.Hour)
+	assert.InDelta(t, 48, fatigue.Score, 0.01)
+	if assert.Len(t, fatigue.Recommendations, 2) {
+		assert.Equal(t, FatigueActionAddFor, fatigue.Recommendations[0].Action)
+		assert.Equal(t, "8 of the 10 firings resolved within 5m, add a For duration of at least 5m so the alerts resolving quickly do not fire", fatigue.Recommendations[0].Message)
+		assert.Equal(t, FatigueActionRaiseThreshold, fatigue.Recommendations[1].Action)
+	}
+
+	// flapping with a For duration, never acknowledged
+	fatigue = RuleFatigue{Firings: 10, ShortFirings: 5}
+	scoreFatigue(&fatigue, 10*time.Minute, true, 14*24*time.Hour)
+	assert.InDelta(t, 60, fatigue.Score, 0.01)
+	if assert.Len(t, fatigue.Recommendations, 2) {
+		assert.Equal(t, FatigueIssueFlapping, fatigue.Recommendations[0].Issue)
+		assert.Equal(t, FatigueIssueNeverAcknowledged, fatigue.Recommendations[1].Issue)
+	}
+
+	// never fires
+	fatigue = RuleFatigue{}
+	scoreFatigue(&fatigue, 0, true, 14*24*time.Hour)
+	if assert.Len(t, fatigue.Recommendations, 1) {
+		assert.Equal(t, FatigueActionDelete, fatigue.Recommendations[0].Action)
+		assert.Equal(t, "the rule did not fire in the last 2w, delete it if the condition no longer applies or check its query", fatigue.Recommendations[0].Message)
+	}
+	// the disabled or new rules are not expected to fire
+	fatigue = RuleFatigue{}
+	scoreFatigue(&fatigue, 0, false, 14*24*time.Hour)
+	assert.Empty(t, fatigue.Recommendations)
+
+	// healthy
+	fatigue = RuleFatigue{Firings: 10, ShortFirings: 2, Acknowledged: 8}
+	scoreFatigue(&fatigue, 0, true, 14*24*time.Hour)
+	assert.Empty(t, fatigue.Recommendations)
+	assert.Zero(t, fatigue.Score)
+}
+
+func TestAlertFatigue(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	m.reader = &firingStatsReader{stats: []model.RuleFiringStats{
+		{RuleID: "1", RuleName: "Error rate", Firings: 12, ShortFirings: 12},
+	}}
+	for _, alert := range []string{"Error rate", "Latency"} {
+		_, err := m.CreateRule(ctx, `{"alert":"`+alert+`","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+		assert.NoError(t, err)
+	}
+
+	// the rules created during the window are not flagged as never firing
+	report, err := m.AlertFatigue(ctx, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Rules, 1) {
+		assert.Equal(t, "1", report.Rules[0].RuleID)
+		assert.Equal(t, "Error rate", report.Rules[0].RuleName)
+		assert.InDelta(t, 90, report.Rules[0].Score, 0.01)
+		assert.Len(t, report.Rules[0].Recommendations, 3)
+	}
+}
@@ -0,0 +1,133 @@
+package rules
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// groupBySeries groups the alerts by all their labels, i.e every series is notified on its own
+const groupBySeries = "..."
+
+// grouping reports whether the notifications of the rule are grouped
+func (ns *NotificationSettings) grouping() bool {
+	return ns != nil && (len(ns.GroupBy) > 0 || ns.GroupWait > 0 || ns.GroupInterval > 0)
+}
+
+// groupDelay is the longest time the notification of the alert is held by the grouping
+func (ns *NotificationSettings) groupDelay() time.Duration {
+	if !ns.grouping() {
+		return 0
+	}
+	return time.Duration(ns.GroupWait + ns.GroupInterval)
+}
+
+// groupKey returns the key of the group of the alert
+func (ns *NotificationSettings) groupKey(alert *Alert) string {
+	if slices.Contains(ns.GroupBy, groupBySeries) {
+		return alert.Labels.String()
+	}
+	values := make([]string, 0, len(ns.GroupBy))
+	for _, name := range ns.GroupBy {
+		values = append(values, name+"="+alert.Labels.Get(name))
+	}
+	return strings.Join(values, ",")
+}
+
+// alertGroup is the alerts of the group waiting to be notified
+type alertGroup struct {
+	pending map[uint64]*Alert
+	// notifiedAt is the last notification of the group, zero until the group wait is over
+	notifiedAt time.Time
+	timer      *time.Timer
+	notify     NotifyFunc
+}
+
+// alertGrouper holds the notifications of the rule like the grouping of the alertmanager:
+// the first alert of a group waits the group wait for the other alerts of the group,
+// then the new or changed alerts of the group are notified once every group interval
+type alertGrouper struct {
+	mtx      sync.Mutex
+	settings *NotificationSettings
+	groups   map[string]*alertGroup
+}
+
+func newAlertGrouper(settings *NotificationSettings) *alertGrouper {
+	if !settings.grouping() {
+		return nil
+	}
+	return &alertGrouper{settings: settings, groups: map[string]*alertGroup{}}
+}
+
+// add queues the alerts in their groups, the groups due are notified right away
+// and the others when their wait or interval is over
+func (g *alertGrouper) add(ctx context.Context, now time.Time, alerts []*Alert, notify NotifyFunc) {
+	ctx = context.WithoutCancel(ctx)
+
+	g.mtx.Lock()
+	var due []string
+	for _, alert := range alerts {
+		key := g.settings.groupKey(alert)
+		group, ok := g.groups[key]
+		if !ok {
+			group = &alertGroup{pending: map[uint64]*Alert{}}
+			g.groups[key] = group
+		}
+		group.pending[alert.Labels.Hash()] = alert
+		group.notify = notify
+		if group.timer != nil || slices.Contains(due, key) {
+			continue
+		}
+
+		delay := time.Duration(g.settings.GroupWait)
+		if !group.notifiedAt.IsZero() {
+			delay = group.notifiedAt.Add(time.Duration(g.settings.GroupInterval)).Sub(now)
+		}
+		if delay <= 0 {
+			due = append(due, key)
+			continue
+		}
+		group.timer = time.AfterFunc(delay, func() {
+			g.flush(ctx, key, time.Now())
+		})
+	}
+	g.mtx.Unlock()
+
+	for _, key := range due {
+		g.flush(ctx, key, now)
+	}
+}
+
+// flush notifies the pending alerts of the group, the group is dropped
+// once all its alerts are resolved
+func (g *alertGrouper) flush(ctx context.Context, key string, now time.Time) {
+	g.mtx.Lock()
+	group, ok := g.groups[key]
+	if !ok || len(group.pending) == 0 {
+		g.mtx.Unlock()
+		return
+	}
+	alerts := make([]*Alert, 0, len(group.pending))
+	resolved := true
+	for _, alert := range group.pending {
+		alerts = append(alerts, alert)
+		if alert.ResolvedAt.IsZero() {
+			resolved = false
+		}
+	}
+	group.pending = map[uint64]*Alert{}
+	group.notifiedAt = now
+	group.timer = nil
+	notify := group.notify
+	if resolved {
+		delete(g.groups, key)
+	}
+	g.mtx.Unlock()
+
+	zap.L().Debug("notifying the alert group", zap.String("group", key), zap.Int("alerts", len(alerts)))
+	notify(ctx, "", alerts...)
+}
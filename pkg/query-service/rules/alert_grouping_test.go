@@ -0,0 +1,80 @@
+package rules
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestAlertGrouper(t *testing.T) {
+	notifications := make(chan []string, 10)
+	notify := func(ctx context.Context, expr string, alerts ...*Alert) {
+		var pods []string
+		for _, a := range alerts {
+			pods = append(pods, a.Labels.Get("pod"))
+		}
+		sort.Strings(pods)
+		notifications <- pods
+	}
+	alert := func(service, pod string) *Alert {
+		return &Alert{Labels: labels.FromMap(map[string]string{"service": service, "pod": pod})}
+	}
+	received := func() []string {
+		select {
+		case pods := <-notifications:
+			return pods
+		case <-time.After(time.Second):
+			return nil
+		}
+	}
+
+	// every series is notified right away
+	g := newAlertGrouper(&NotificationSettings{GroupBy: []string{groupBySeries}})
+	g.add(context.Background(), time.Now(), []*Alert{alert("cart", "cart-1"), alert("cart", "cart-2")}, notify)
+	assert.Len(t, notifications, 2)
+	<-notifications
+	<-notifications
+
+	// the alerts of the service wait for each other
+	g = newAlertGrouper(&NotificationSettings{GroupBy: []string{"service"}, GroupWait: Duration(50 * time.Millisecond), GroupInterval: Duration(200 * time.Millisecond)})
+	g.add(context.Background(), time.Now(), []*Alert{alert("cart", "cart-1")}, notify)
+	g.add(context.Background(), time.Now(), []*Alert{alert("cart", "cart-2"), alert("checkout", "checkout-1")}, notify)
+	assert.Empty(t, notifications)
+	first, second := received(), received()
+	if len(first) == 1 {
+		first, second = second, first
+	}
+	assert.Equal(t, []string{"cart-1", "cart-2"}, first)
+	assert.Equal(t, []string{"checkout-1"}, second)
+
+	// the next alerts of the group wait for the group interval
+	start := time.Now()
+	g.add(context.Background(), start, []*Alert{alert("cart", "cart-3")}, notify)
+	assert.Equal(t, []string{"cart-3"}, received())
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+
+	// the resolved group is dropped
+	resolved := alert("checkout", "checkout-1")
+	resolved.ResolvedAt = time.Now()
+	g.add(context.Background(), time.Now().Add(time.Second), []*Alert{resolved}, notify)
+	assert.Equal(t, []string{"checkout-1"}, received())
+	g.mtx.Lock()
+	assert.NotContains(t, g.groups, "service=checkout")
+	g.mtx.Unlock()
+
+	assert.Nil(t, newAlertGrouper(&NotificationSettings{Policy: NotifyPolicyStateChange}))
+}
+
+func TestNotificationSettingsGrouping(t *testing.T) {
+	assert.NoError(t, (&NotificationSettings{GroupBy: []string{"service", "env"}, GroupWait: Duration(time.Minute)}).Validate())
+	assert.Error(t, (&NotificationSettings{GroupBy: []string{groupBySeries, "env"}}).Validate())
+	assert.Error(t, (&NotificationSettings{GroupWait: Duration(-time.Minute)}).Validate())
+
+	settings := &NotificationSettings{GroupBy: []string{"service"}, GroupWait: Duration(time.Minute), GroupInterval: Duration(5 * time.Minute)}
+	assert.Equal(t, 6*time.Minute, settings.groupDelay())
+	assert.Equal(t, "service=cart", settings.groupKey(&Alert{Labels: labels.FromMap(map[string]string{"service": "cart", "pod": "cart-1"})}))
+}
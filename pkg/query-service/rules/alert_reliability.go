@@ -0,0 +1,204 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultReliabilityWindow is the time range the mean times to acknowledge and to
+	// resolve are computed over when the window is not given
+	DefaultReliabilityWindow = 30 * 24 * time.Hour
+	// maxReliabilityWindow bounds the history scanned for the reliability report
+	maxReliabilityWindow = 90 * 24 * time.Hour
+)
+
+// AlertAckEvent is the acknowledgement of the firing alert of the rule, the time to
+// acknowledge is from when the alert fired to the first acknowledgement
+type AlertAckEvent struct {
+	RuleId      string    `json:"ruleId" db:"rule_id"`
+	Fingerprint string    `json:"fingerprint" db:"fingerprint"`
+	FiredAt     time.Time `json:"firedAt" db:"fired_at"`
+	AckedAt     time.Time `json:"ackedAt" db:"acked_at"`
+	AckId       int64     `json:"ackId" db:"ack_id"`
+	AckSeconds  float64   `json:"ackSeconds" db:"ack_seconds"`
+}
+
+// AlertAckStats is the number of the alerts of the rule acknowledged and the mean
+// time it took in seconds
+type AlertAckStats struct {
+	Acknowledged int     `db:"acknowledged"`
+	MeanSeconds  float64 `db:"mean_seconds"`
+}
+
+func (r *ruleDB) RecordAlertAckEvents(ctx context.Context, events []AlertAckEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx, err := r.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, event := range events {
+		// the alert acknowledged again keeps its first acknowledgement
+		_, err := tx.Exec(`INSERT OR IGNORE INTO alert_ack_events (rule_id, fingerprint, fired_at, acked_at, ack_id, ack_seconds)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			event.RuleId, event.Fingerprint, event.FiredAt.UTC(), event.AckedAt.UTC(), event.AckId, event.AckSeconds)
+		if err != nil {
+			zap.L().Error("Error in Executing INSERT to alert_ack_events", zap.Error(err))
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *ruleDB) GetAlertAckStatsByRule(ctx context.Context, start, end time.Time) (map[string]AlertAckStats, error) {
+	rows := []struct {
+		RuleId string `db:"rule_id"`
+		AlertAckStats
+	}{}
+	err := r.Select(&rows, `SELECT rule_id, COUNT(*) AS acknowledged, AVG(ack_seconds) AS mean_seconds FROM alert_ack_events
+		WHERE fired_at>=$1 AND fired_at<$2 GROUP BY rule_id`, start.UTC(), end.UTC())
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	stats := make(map[string]AlertAckStats, len(rows))
+	for _, row := range rows {
+		stats[row.RuleId] = row.AlertAckStats
+	}
+	return stats, nil
+}
+
+// createAlertAck stores the acknowledgement and records when the firing alerts of the
+// rules evaluated by this replica it acknowledges were acknowledged
+func (m *Manager) createAlertAck(ctx context.Context, ack AlertAck) (int64, error) {
+	if ack.AckedAt.IsZero() {
+		ack.AckedAt = time.Now()
+	}
+	id, err := m.ruleDB.CreateAlertAck(ctx, ack)
+	if err != nil {
+		return id, err
+	}
+	ack.Id = id
+
+	m.mtx.RLock()
+	rules := make([]Rule, 0, len(m.rules))
+	for _, rule := range m.rules {
+		rules = append(rules, rule)
+	}
+	m.mtx.RUnlock()
+
+	var events []AlertAckEvent
+	for _, rule := range rules {
+		for _, alert := range rule.ActiveAlerts() {
+			if alert.State != model.StateFiring || !ack.acknowledges(alert) {
+				continue
+			}
+			ackSeconds := ack.AckedAt.Sub(alert.FiredAt).Seconds()
+			events = append(events, AlertAckEvent{
+				RuleId:      rule.ID(),
+				Fingerprint: fmt.Sprintf("%016x", alert.Labels.Hash()),
+				FiredAt:     alert.FiredAt,
+				AckedAt:     ack.AckedAt,
+				AckId:       id,
+				AckSeconds:  ackSeconds,
+			})
+			observeAck(rule.ID(), ackSeconds)
+		}
+	}
+	if err := m.ruleDB.RecordAlertAckEvents(ctx, events); err != nil {
+		zap.L().Warn("failed to record the acknowledged alerts", zap.Int64("ack", id), zap.Error(err))
+	}
+	return id, nil
+}
+
+// RuleReliability is how long the alerts of the rule took to be acknowledged and to
+// resolve on average over the window
+type RuleReliability struct {
+	RuleID   string `json:"ruleId"`
+	RuleName string `json:"ruleName"`
+	// Firings is the number of the times the series of the rule started firing
+	Firings uint64 `json:"firings"`
+	// Acknowledged is the number of the firing alerts acknowledged
+	Acknowledged int `json:"acknowledged"`
+	// MTTA is the mean time to acknowledge of the acknowledged alerts in seconds
+	MTTA float64 `json:"mtta"`
+	// MTTR is the mean time to resolve of the firing alerts in seconds
+	MTTR float64 `json:"mttr"`
+}
+
+// ReliabilityReport is the mean times to acknowledge and to resolve of the rules the
+// user can view over the window
+type ReliabilityReport struct {
+	Start time.Time         `json:"start"`
+	End   time.Time         `json:"end"`
+	Rules []RuleReliability `json:"rules"`
+}
+
+// RuleReliability returns the mean times to acknowledge and to resolve of the rules the
+// user can view, the times to resolve come from the state history of the rules
+func (m *Manager) RuleReliability(ctx context.Context, window time.Duration) (*ReliabilityReport, error) {
+	if window <= 0 {
+		window = DefaultReliabilityWindow
+	}
+	end := time.Now()
+	start := end.Add(-min(window, maxReliabilityWindow))
+	report := &ReliabilityReport{Start: start, End: end, Rules: []RuleReliability{}}
+
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(storedRules) == 0 {
+		return report, nil
+	}
+	ruleIds := make([]string, 0, len(storedRules))
+	viewable := make(map[string]struct{}, len(storedRules))
+	for _, storedRule := range storedRules {
+		ruleIds = append(ruleIds, strconv.Itoa(storedRule.Id))
+		viewable[strconv.Itoa(storedRule.Id)] = struct{}{}
+	}
+
+	firingStats, err := m.reader.GetRuleFiringStats(ctx, start.UnixMilli(), end.UnixMilli(), ruleIds)
+	if err != nil {
+		return nil, err
+	}
+	ackStats, err := m.ruleDB.GetAlertAckStatsByRule(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range firingStats {
+		ack := ackStats[s.RuleID]
+		delete(ackStats, s.RuleID)
+		report.Rules = append(report.Rules, RuleReliability{
+			RuleID:       s.RuleID,
+			RuleName:     s.RuleName,
+			Firings:      s.Firings,
+			Acknowledged: ack.Acknowledged,
+			MTTA:         ack.MeanSeconds,
+			MTTR:         s.AvgFiringDuration,
+		})
+	}
+	// the alerts acknowledged without a state change of their rule in the window
+	for ruleId, ack := range ackStats {
+		if _, ok := viewable[ruleId]; !ok {
+			continue
+		}
+		report.Rules = append(report.Rules, RuleReliability{RuleID: ruleId, Acknowledged: ack.Acknowledged, MTTA: ack.MeanSeconds})
+	}
+
+	sort.Slice(report.Rules, func(i, j int) bool {
+		return report.Rules[i].RuleID < report.Rules[j].RuleID
+	})
+	return report, nil
+}
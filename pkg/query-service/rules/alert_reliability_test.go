@@ -0,0 +1,54 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestRuleReliability(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	m.reader = &firingStatsReader{stats: []model.RuleFiringStats{
+		{RuleID: "1", RuleName: "Error rate", Firings: 4, Series: 2, AvgFiringDuration: 1800},
+	}}
+	for _, alert := range []string{"Error rate", "Latency"} {
+		_, err := m.CreateRule(ctx, `{"alert":"`+alert+`","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+		assert.NoError(t, err)
+	}
+
+	now := time.Now()
+	alert := func(service string, firedAt time.Time) *Alert {
+		return &Alert{
+			State:   model.StateFiring,
+			Labels:  labels.FromMap(map[string]string{labels.AlertNameLabel: "Error rate", labels.AlertRuleIdLabel: "1", "service.name": service}),
+			FiredAt: firedAt,
+		}
+	}
+	cart, frontend := alert("cart", now.Add(-10*time.Minute)), alert("frontend", now.Add(-30*time.Minute))
+	m.rules["1"] = &ThresholdRule{BaseRule: &BaseRule{id: "1", Active: map[uint64]*Alert{1: cart, 2: frontend}}}
+
+	_, err := m.createAlertAck(ctx, AlertAck{Matchers: alertAckMatchers(cart), AckedBy: "jane", AckedAt: now})
+	assert.NoError(t, err)
+	_, err = m.createAlertAck(ctx, AlertAck{Matchers: alertAckMatchers(frontend), AckedBy: "jane", AckedAt: now})
+	assert.NoError(t, err)
+	// the alert acknowledged again keeps its first time to acknowledge
+	_, err = m.createAlertAck(ctx, AlertAck{Matchers: alertAckMatchers(cart), AckedBy: "john", AckedAt: now.Add(time.Hour)})
+	assert.NoError(t, err)
+	// the acks of the rules without state changes in the window are reported too
+	assert.NoError(t, m.ruleDB.RecordAlertAckEvents(ctx, []AlertAckEvent{
+		{RuleId: "2", Fingerprint: "a", FiredAt: now.Add(-time.Hour), AckedAt: now, AckSeconds: 3600},
+		{RuleId: "3", Fingerprint: "b", FiredAt: now.Add(-time.Hour), AckedAt: now, AckSeconds: 60},
+	}))
+
+	report, err := m.RuleReliability(ctx, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Rules, 2) {
+		assert.Equal(t, RuleReliability{RuleID: "1", RuleName: "Error rate", Firings: 4, Acknowledged: 2, MTTA: 1200, MTTR: 1800}, report.Rules[0])
+		assert.Equal(t, RuleReliability{RuleID: "2", Acknowledged: 1, MTTA: 3600}, report.Rules[1])
+	}
+}
@@ -0,0 +1,174 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// RuleSummary is the part of the rule shown in the alert settings, joined with
+// the channels it notifies and the maintenance covering it
+type RuleSummary struct {
+	Id          string           `json:"id"`
+	Name        string           `json:"name"`
+	AlertType   AlertType        `json:"alertType"`
+	RuleType    RuleType         `json:"ruleType"`
+	State       model.AlertState `json:"state"`
+	Disabled    bool             `json:"disabled"`
+	Severity    string           `json:"severity,omitempty"`
+	Folder      string           `json:"folder,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`
+	Provisioned bool             `json:"provisioned,omitempty"`
+	UpdatedAt   *time.Time       `json:"updateAt"`
+	UpdatedBy   *string          `json:"updateBy"`
+	Channels    []string         `json:"channels"`
+	// MaintenanceIds are the maintenance covering the rule, active or not
+	MaintenanceIds []int64 `json:"maintenanceIds"`
+	// InMaintenance is set when a maintenance covering the rule is active
+	InMaintenance bool `json:"inMaintenance"`
+}
+
+// ChannelSummary is the channel without its configuration, the rule ids are the rules notifying it
+type ChannelSummary struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	RuleIds   []string  `json:"ruleIds"`
+}
+
+// MaintenanceSummary is the maintenance with the rules it covers
+type MaintenanceSummary struct {
+	PlannedMaintenance
+	RuleIds []string `json:"ruleIds"`
+}
+
+// MarshalJSON adds the rule ids to the maintenance, the marshaler of the
+// maintenance is promoted and would otherwise drop them
+func (s MaintenanceSummary) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(s.PlannedMaintenance)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	fields["ruleIds"] = s.RuleIds
+	return json.Marshal(fields)
+}
+
+// AlertSettings are the rules, channels and maintenance of the alert settings page
+type AlertSettings struct {
+	Rules       []RuleSummary        `json:"rules"`
+	Channels    []ChannelSummary     `json:"channels"`
+	Maintenance []MaintenanceSummary `json:"maintenance"`
+}
+
+// GetAlertSettings returns the rules matching the filter along with all the
+// channels and maintenance, the references between them are resolved so
+// that the page is rendered from a single response
+func (m *Manager) GetAlertSettings(ctx context.Context, filter *StoredRuleFilter) (*AlertSettings, error) {
+	storedRules, err := m.ruleDB.FilterStoredRules(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	channels, apiErr := m.ruleDB.GetChannels()
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	maintenances, err := m.ruleDB.GetAllPlannedMaintenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &AlertSettings{
+		Rules:       make([]RuleSummary, 0, len(storedRules)),
+		Channels:    make([]ChannelSummary, 0, len(*channels)),
+		Maintenance: make([]MaintenanceSummary, 0, len(maintenances)),
+	}
+
+	channelIndex := map[string]int{}
+	for _, channel := range *channels {
+		channelIndex[channel.Name] = len(settings.Channels)
+		settings.Channels = append(settings.Channels, ChannelSummary{
+			Id:        channel.Id,
+			Name:      channel.Name,
+			Type:      channel.Type,
+			CreatedAt: channel.CreatedAt,
+			UpdatedAt: channel.UpdatedAt,
+			RuleIds:   []string{},
+		})
+	}
+	for _, maintenance := range maintenances {
+		settings.Maintenance = append(settings.Maintenance, MaintenanceSummary{PlannedMaintenance: maintenance, RuleIds: []string{}})
+	}
+
+	now := time.Now()
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	for _, s := range storedRules {
+		rule := PostableRule{}
+		if err := json.Unmarshal([]byte(s.Data), &rule); err != nil {
+			zap.L().Error("failed to unmarshal rule from db", zap.Int("id", s.Id), zap.Error(err))
+			continue
+		}
+
+		summary := RuleSummary{
+			Id:             fmt.Sprintf("%d", s.Id),
+			Name:           rule.AlertName,
+			AlertType:      rule.AlertType,
+			RuleType:       rule.RuleType,
+			State:          model.StateDisabled,
+			Disabled:       true,
+			Severity:       rule.Labels["severity"],
+			Folder:         rule.Folder,
+			Tags:           rule.Tags,
+			Provisioned:    s.Provisioned,
+			UpdatedAt:      s.UpdatedAt,
+			UpdatedBy:      s.UpdatedBy,
+			Channels:       []string{},
+			MaintenanceIds: []int64{},
+		}
+		if r, ok := m.rules[summary.Id]; ok {
+			summary.State = r.State()
+			summary.Disabled = false
+		}
+
+		for _, name := range rule.PreferredChannels {
+			summary.Channels = append(summary.Channels, name)
+			if i, ok := channelIndex[name]; ok {
+				settings.Channels[i].RuleIds = append(settings.Channels[i].RuleIds, summary.Id)
+			}
+		}
+
+		for i := range settings.Maintenance {
+			maintenance := &settings.Maintenance[i]
+			if !maintenance.covers(summary.Id) {
+				continue
+			}
+			maintenance.RuleIds = append(maintenance.RuleIds, summary.Id)
+			summary.MaintenanceIds = append(summary.MaintenanceIds, maintenance.Id)
+			// the partial maintenance only mutes some of the alerts of the rule
+			if maintenance.Schedule != nil && !maintenance.isPartial() && maintenance.shouldSkip(summary.Id, now) {
+				summary.InMaintenance = true
+			}
+		}
+
+		settings.Rules = append(settings.Rules, summary)
+	}
+
+	return settings, nil
+}
+
+// covers reports whether the maintenance applies to the rule, the maintenance
+// without rules covers all the rules
+func (m *PlannedMaintenance) covers(ruleID string) bool {
+	return m.AlertIds == nil || len(*m.AlertIds) == 0 || slices.Contains(*m.AlertIds, ruleID)
+}
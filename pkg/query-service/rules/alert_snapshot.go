@@ -0,0 +1,190 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultAlertSnapshotInterval is how often the active alerts of the rules are saved
+	DefaultAlertSnapshotInterval = time.Minute
+
+	// alertSnapshotMaxAge is how old the snapshot restored at startup can be, the
+	// snapshots of the rules disabled or evaluated elsewhere since are not restored
+	alertSnapshotMaxAge = 15 * time.Minute
+)
+
+// AlertSnapshot is the active alert of the rule saved to the rules db, it's restored
+// at startup so the pending alerts keep their hold duration across restarts
+type AlertSnapshot struct {
+	RuleId      string           `json:"ruleId" db:"rule_id"`
+	Fingerprint string           `json:"fingerprint" db:"fingerprint"`
+	Labels      SuppressedLabels `json:"labels" db:"labels"`
+	// ResultLabels are the labels of the series of the query result
+	ResultLabels SuppressedLabels `json:"resultLabels" db:"result_labels"`
+	State        model.AlertState `json:"state" db:"state"`
+	ActiveAt     time.Time        `json:"activeAt" db:"active_at"`
+	FiredAt      time.Time        `json:"firedAt" db:"fired_at"`
+	Value        float64          `json:"value" db:"value"`
+	Missing      bool             `json:"missing" db:"missing"`
+	SnapshotAt   time.Time        `json:"snapshotAt" db:"snapshot_at"`
+}
+
+func newAlertSnapshots(ruleId string, alerts []*Alert, ts time.Time) []AlertSnapshot {
+	snapshots := make([]AlertSnapshot, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.State != model.StatePending && alert.State != model.StateFiring {
+			continue
+		}
+		snapshots = append(snapshots, AlertSnapshot{
+			RuleId:       ruleId,
+			Fingerprint:  fmt.Sprintf("%016x", alert.Labels.Hash()),
+			Labels:       labelsMap(alert.Labels),
+			ResultLabels: labelsMap(alert.QueryResultLables),
+			State:        alert.State,
+			ActiveAt:     alert.ActiveAt,
+			FiredAt:      alert.FiredAt,
+			Value:        alert.Value,
+			Missing:      alert.Missing,
+			SnapshotAt:   ts,
+		})
+	}
+	return snapshots
+}
+
+func (r *ruleDB) SaveAlertSnapshots(ctx context.Context, ruleIds []string, snapshots []AlertSnapshot) error {
+	tx, err := r.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, ruleId := range ruleIds {
+		if _, err := tx.Exec("DELETE FROM alert_snapshots WHERE rule_id=$1", ruleId); err != nil {
+			zap.L().Error("Error in Executing DELETE from alert_snapshots", zap.Error(err))
+			return err
+		}
+	}
+	for _, snapshot := range snapshots {
+		_, err := tx.Exec(`INSERT OR REPLACE INTO alert_snapshots (rule_id, fingerprint, labels, result_labels, state, active_at, fired_at, value, missing, snapshot_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			snapshot.RuleId, snapshot.Fingerprint, snapshot.Labels, snapshot.ResultLabels, snapshot.State.String(),
+			snapshot.ActiveAt.UTC(), snapshot.FiredAt.UTC(), snapshot.Value, snapshot.Missing, snapshot.SnapshotAt.UTC())
+		if err != nil {
+			zap.L().Error("Error in Executing INSERT to alert_snapshots", zap.Error(err))
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *ruleDB) GetAlertSnapshots(ctx context.Context, ruleId string) ([]AlertSnapshot, error) {
+	snapshots := []AlertSnapshot{}
+	err := r.Select(&snapshots, `SELECT rule_id, fingerprint, labels, result_labels, state, active_at, fired_at, value, missing, snapshot_at
+		FROM alert_snapshots WHERE rule_id=$1 ORDER BY fingerprint`, ruleId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+func (r *ruleDB) DeleteAlertSnapshots(ctx context.Context, ruleId string) error {
+	_, err := r.Exec("DELETE FROM alert_snapshots WHERE rule_id=$1", ruleId)
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE from alert_snapshots", zap.Error(err))
+	}
+	return err
+}
+
+// loadAlertSnapshots fetches the snapshot of the rule restored at startup, the
+// snapshot too old to reflect the last evaluations is dropped
+func loadAlertSnapshots(ctx context.Context, db RuleDB, ruleId string, now time.Time) []AlertSnapshot {
+	if db == nil {
+		return nil
+	}
+	snapshots, err := db.GetAlertSnapshots(ctx, ruleId)
+	if err != nil {
+		zap.L().Warn("failed to load the snapshot of the active alerts", zap.String("ruleid", ruleId), zap.Error(err))
+		return nil
+	}
+	fresh := snapshots[:0]
+	for _, snapshot := range snapshots {
+		if now.Sub(snapshot.SnapshotAt) <= alertSnapshotMaxAge {
+			fresh = append(fresh, snapshot)
+		}
+	}
+	return fresh
+}
+
+// restoreSnapshot adds the pending alerts of the snapshot to the active alerts and
+// moves the firing alerts restored from the history back to when they became active.
+// The history is the latest record of the firing alerts, the firing alerts of the
+// snapshot missing from it resolved after the snapshot, it's called with the lock held
+func (r *BaseRule) restoreSnapshot(snapshots []AlertSnapshot) {
+	for _, snapshot := range snapshots {
+		lbs := labels.FromMap(snapshot.Labels)
+		h := lbs.Hash()
+		if alert, ok := r.Active[h]; ok {
+			if !snapshot.ActiveAt.IsZero() && snapshot.ActiveAt.Before(alert.ActiveAt) {
+				alert.ActiveAt = snapshot.ActiveAt
+			}
+			continue
+		}
+		if snapshot.State != model.StatePending {
+			continue
+		}
+		r.Active[h] = &Alert{
+			Labels:            lbs,
+			QueryResultLables: labels.FromMap(snapshot.ResultLabels),
+			ActiveAt:          snapshot.ActiveAt,
+			State:             model.StatePending,
+			Value:             snapshot.Value,
+			GeneratorURL:      r.GeneratorURL(),
+			Receivers:         r.preferredChannels,
+			Missing:           snapshot.Missing,
+		}
+	}
+}
+
+// snapshotAlerts saves the active alerts of the rules evaluated by this replica
+func (m *Manager) snapshotAlerts(ctx context.Context) {
+	m.mtx.RLock()
+	rules := make([]Rule, 0, len(m.rules))
+	for _, rule := range m.rules {
+		rules = append(rules, rule)
+	}
+	m.mtx.RUnlock()
+	if len(rules) == 0 {
+		return
+	}
+
+	now := time.Now()
+	ruleIds := make([]string, 0, len(rules))
+	var snapshots []AlertSnapshot
+	for _, rule := range rules {
+		ruleIds = append(ruleIds, rule.ID())
+		snapshots = append(snapshots, newAlertSnapshots(rule.ID(), rule.ActiveAlerts(), now)...)
+	}
+	if err := m.ruleDB.SaveAlertSnapshots(ctx, ruleIds, snapshots); err != nil {
+		zap.L().Error("failed to save the snapshot of the active alerts", zap.Error(err))
+	}
+}
+
+func (m *Manager) snapshotAlertsLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(m.opts.AlertSnapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			m.snapshotAlerts(context.Background())
+		}
+	}
+}
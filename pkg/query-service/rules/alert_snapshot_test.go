@@ -0,0 +1,127 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestAlertSnapshotsRoundTrip(t *testing.T) {
+	db := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	alerts := []*Alert{
+		{
+			Labels:            labels.FromMap(map[string]string{"alertname": "high latency", "service": "frontend"}),
+			QueryResultLables: labels.FromMap(map[string]string{"service": "frontend"}),
+			State:             model.StatePending,
+			ActiveAt:          now.Add(-3 * time.Minute),
+			Value:             12,
+		},
+		{
+			Labels: labels.FromMap(map[string]string{"alertname": "high latency", "service": "backend"}),
+			State:  model.StateInactive,
+		},
+	}
+	assert.NoError(t, db.SaveAlertSnapshots(ctx, []string{"1"}, newAlertSnapshots("1", alerts, now)))
+
+	snapshots, err := db.GetAlertSnapshots(ctx, "1")
+	assert.NoError(t, err)
+	if assert.Len(t, snapshots, 1) {
+		assert.Equal(t, model.StatePending, snapshots[0].State)
+		assert.Equal(t, "frontend", snapshots[0].Labels["service"])
+		assert.Equal(t, "frontend", snapshots[0].ResultLabels["service"])
+		assert.True(t, snapshots[0].ActiveAt.Equal(now.Add(-3*time.Minute)))
+		assert.Equal(t, 12.0, snapshots[0].Value)
+	}
+
+	// the next snapshot replaces the alerts of the rule
+	assert.NoError(t, db.SaveAlertSnapshots(ctx, []string{"1"}, nil))
+	snapshots, err = db.GetAlertSnapshots(ctx, "1")
+	assert.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func TestBaseRule_RestoreStateFromSnapshot(t *testing.T) {
+	threshold := 1.0
+	now := time.Now().Truncate(time.Millisecond)
+	firedAt := now.Add(-10 * time.Minute)
+
+	reader := &stateHistoryReader{
+		lastSavedState: []model.RuleStateHistory{
+			{
+				RuleID:      "1",
+				State:       model.StateFiring,
+				UnixMilli:   firedAt.UnixMilli(),
+				Labels:      model.LabelsString(`{"service":"frontend"}`),
+				Fingerprint: 1,
+				Value:       10,
+			},
+		},
+	}
+
+	rule, err := NewBaseRule("1", &PostableRule{
+		AlertName: "restore test",
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypeBuilder},
+			CompareOp:      ValueIsAbove,
+			MatchType:      AtleastOnce,
+			Target:         &threshold,
+		},
+	}, reader)
+	assert.NoError(t, err)
+
+	alertLabels := func(service string) map[string]string {
+		return map[string]string{
+			labels.AlertNameLabel:   "restore test",
+			labels.AlertRuleIdLabel: "1",
+			labels.RuleSourceLabel:  rule.GeneratorURL(),
+			"service":               service,
+		}
+	}
+	snapshot := []AlertSnapshot{
+		{RuleId: "1", Labels: alertLabels("frontend"), State: model.StateFiring, ActiveAt: firedAt.Add(-5 * time.Minute), SnapshotAt: now},
+		{RuleId: "1", Labels: alertLabels("backend"), ResultLabels: SuppressedLabels{"service": "backend"}, State: model.StatePending, ActiveAt: now.Add(-4 * time.Minute), Value: 5, SnapshotAt: now},
+		// resolved since the snapshot as the history doesn't have it firing
+		{RuleId: "1", Labels: alertLabels("db"), State: model.StateFiring, ActiveAt: now.Add(-time.Hour), SnapshotAt: now},
+	}
+	assert.NoError(t, rule.RestoreState(context.Background(), snapshot))
+
+	assert.Len(t, rule.Active, 2)
+	for _, a := range rule.Active {
+		switch a.Labels.Get("service") {
+		case "frontend":
+			assert.Equal(t, model.StateFiring, a.State)
+			assert.True(t, a.FiredAt.Equal(firedAt))
+			assert.True(t, a.ActiveAt.Equal(firedAt.Add(-5*time.Minute)))
+		case "backend":
+			assert.Equal(t, model.StatePending, a.State)
+			assert.True(t, a.ActiveAt.Equal(now.Add(-4*time.Minute)))
+			assert.Equal(t, "backend", a.QueryResultLables.Get("service"))
+		default:
+			t.Errorf("unexpected alert %v", a.Labels)
+		}
+	}
+}
+
+func TestLoadAlertSnapshotsDropsStale(t *testing.T) {
+	db := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.NoError(t, db.SaveAlertSnapshots(ctx, []string{"1", "2"}, []AlertSnapshot{
+		{RuleId: "1", Fingerprint: "a", State: model.StatePending, ActiveAt: now, SnapshotAt: now.Add(-time.Minute)},
+		{RuleId: "2", Fingerprint: "b", State: model.StatePending, ActiveAt: now, SnapshotAt: now.Add(-time.Hour)},
+	}))
+
+	assert.Len(t, loadAlertSnapshots(ctx, db, "1", now), 1)
+	assert.Empty(t, loadAlertSnapshots(ctx, db, "2", now))
+	assert.Nil(t, loadAlertSnapshots(ctx, nil, "1", now))
+}
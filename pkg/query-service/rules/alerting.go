@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/converter"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.signoz.io/signoz/pkg/query-service/utils/labels"
@@ -30,6 +32,8 @@ const (
 	RuleTypeThreshold = "threshold_rule"
 	RuleTypeProm      = "promql_rule"
 	RuleTypeAnomaly   = "anomaly_rule"
+	RuleTypeSLO       = "slo_rule"
+	RuleTypeHeartbeat = "heartbeat_rule"
 )
 
 type RuleHealth string
@@ -61,9 +65,21 @@ type Alert struct {
 	ValidUntil time.Time
 
 	Missing bool
+
+	// keepAlive marks the alert re-sent to the alertmanager only to keep it valid,
+	// it's not delivered to the channels nor logged as a notification
+	keepAlive bool
+	// resolved are the resolved notifications of the rule, the alertmanager routes the
+	// alert to the copies of the channels with the resolved notifications disabled
+	resolved *ResolvedNotifications
+
+	// Series is the series the alert was last evaluated on, nil for the rules without one
+	Series *AlertSeries
+	// chart is the chart attached to the notification of the alert
+	chart *alertChart
 }
 
-func (a *Alert) needsSending(ts time.Time, resendDelay time.Duration) bool {
+func (a *Alert) needsSending(ts time.Time, resendDelay time.Duration, policy NotifyPolicy) bool {
 	if a.State == model.StatePending {
 		return false
 	}
@@ -73,14 +89,131 @@ func (a *Alert) needsSending(ts time.Time, resendDelay time.Duration) bool {
 		return true
 	}
 
+	if policy == NotifyPolicyStateChange {
+		// only send when the alert started firing since the last send
+		return a.LastSentAt.IsZero() || a.FiredAt.After(a.LastSentAt)
+	}
+
 	return a.LastSentAt.Add(resendDelay).Before(ts)
 }
 
+// ResolvedExpired reports whether the resolved alert is no longer kept, the resolve
+// of the alert notified as firing is kept until it's sent e.g. once the active
+// schedule of the rule opens
+func (a *Alert) ResolvedExpired(ts time.Time) bool {
+	if a.ResolvedAt.IsZero() || ts.Sub(a.ResolvedAt) <= ResolvedRetention {
+		return false
+	}
+	return a.LastSentAt.IsZero() || !a.ResolvedAt.After(a.LastSentAt)
+}
+
+// needsKeepAlive reports whether the firing alert not re-sent under the state change
+// policy is sent to the alertmanager again so that it doesn't expire there
+func (a *Alert) needsKeepAlive(ts time.Time, resendDelay time.Duration, policy NotifyPolicy) bool {
+	return policy == NotifyPolicyStateChange && a.State == model.StateFiring &&
+		a.ResolvedAt.IsZero() && a.LastSentAt.Add(resendDelay).Before(ts)
+}
+
 type NamedAlert struct {
 	Name string
 	*Alert
 }
 
+// NotifyPolicy decides how often the notifications are sent
+// for an alert while it keeps firing
+type NotifyPolicy string
+
+const (
+	// NotifyPolicyDefault re-sends the firing alerts after the
+	// resend delay configured for the rule manager
+	NotifyPolicyDefault NotifyPolicy = ""
+	// NotifyPolicyInterval re-sends the firing alerts every interval
+	NotifyPolicyInterval NotifyPolicy = "interval"
+	// NotifyPolicyStateChange sends the alert only when it starts firing and when
+	// it is resolved, the firing alerts are still re-sent to the alertmanager after
+	// the resend delay so they don't expire but the re-sends are not notified
+	NotifyPolicyStateChange NotifyPolicy = "state_change"
+	// NotifyPolicyDaily re-sends the firing alerts once a day
+	NotifyPolicyDaily NotifyPolicy = "daily"
+	// NotifyPolicyDailyDigest notifies the alerts fired during the day
+	// together in one digest alert once a day
+	NotifyPolicyDailyDigest NotifyPolicy = "daily_digest"
+)
+
+const dailyInterval = 24 * time.Hour
+
+// NotificationSettings captures the per-rule notification preferences
+type NotificationSettings struct {
+	Policy NotifyPolicy `yaml:"policy,omitempty" json:"policy,omitempty"`
+	// Interval is used with the interval policy
+	Interval Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+
+	// GroupBy are the labels the alerts are grouped by, "..." notifies every series on its
+	// own and no labels put all the alerts of the rule in one group. GroupWait is how long
+	// the first alert of a group waits for the others and GroupInterval is how long the
+	// group waits before notifying its new or changed alerts.
+	GroupBy       []string `yaml:"groupBy,omitempty" json:"groupBy,omitempty"`
+	GroupWait     Duration `yaml:"groupWait,omitempty" json:"groupWait,omitempty"`
+	GroupInterval Duration `yaml:"groupInterval,omitempty" json:"groupInterval,omitempty"`
+
+	// EmailTemplate renders the emails of the rule sent by the smtp channels, it
+	// takes precedence over the template of the channel
+	EmailTemplate *EmailTemplate `yaml:"emailTemplate,omitempty" json:"emailTemplate,omitempty"`
+
+	// Resolved controls the notifications of the resolved alerts, they are sent like the
+	// firing alerts by default
+	Resolved *ResolvedNotifications `yaml:"resolved,omitempty" json:"resolved,omitempty"`
+}
+
+func (ns *NotificationSettings) Validate() error {
+	if ns == nil {
+		return nil
+	}
+	switch ns.Policy {
+	case NotifyPolicyDefault, NotifyPolicyStateChange, NotifyPolicyDaily, NotifyPolicyDailyDigest:
+	case NotifyPolicyInterval:
+		if ns.Interval <= 0 {
+			return errors.Errorf("notification interval is required for the %s policy", ns.Policy)
+		}
+	default:
+		return errors.Errorf("unsupported notification policy: %s", ns.Policy)
+	}
+	if ns.GroupWait < 0 || ns.GroupInterval < 0 {
+		return errors.Errorf("group wait and group interval must not be negative")
+	}
+	if slices.Contains(ns.GroupBy, groupBySeries) && len(ns.GroupBy) > 1 {
+		return errors.Errorf("group by %s can't be combined with other labels", groupBySeries)
+	}
+	if ns.EmailTemplate != nil {
+		if _, err := ns.EmailTemplate.channelTemplate().render(newNotificationData("", sampleAlerts(nil, time.Now()))); err != nil {
+			return errors.Wrap(err, "email template")
+		}
+	}
+	return ns.Resolved.Validate()
+}
+
+// resendDelay returns the delay after which a firing alert should
+// be re-sent, falling back to the given default
+func (ns *NotificationSettings) resendDelay(defaultDelay time.Duration) time.Duration {
+	if ns == nil {
+		return defaultDelay
+	}
+	switch ns.policy() {
+	case NotifyPolicyInterval:
+		return time.Duration(ns.Interval)
+	case NotifyPolicyDaily:
+		return dailyInterval
+	}
+	return defaultDelay
+}
+
+func (ns *NotificationSettings) policy() NotifyPolicy {
+	if ns == nil {
+		return NotifyPolicyDefault
+	}
+	return ns.Policy
+}
+
 type CompareOp string
 
 const (
@@ -103,6 +236,9 @@ const (
 	OnAverage     MatchType = "3"
 	InTotal       MatchType = "4"
 	Last          MatchType = "5"
+	// PercentOfPoints fires when at least MatchPercent % of
+	// the points in the eval window match the condition
+	PercentOfPoints MatchType = "6"
 )
 
 type RuleCondition struct {
@@ -118,6 +254,21 @@ type RuleCondition struct {
 	SelectedQuery     string             `json:"selectedQueryName,omitempty"`
 	RequireMinPoints  bool               `yaml:"requireMinPoints,omitempty" json:"requireMinPoints,omitempty"`
 	RequiredNumPoints int                `yaml:"requiredNumPoints,omitempty" json:"requiredNumPoints,omitempty"`
+	// MatchPercent is the percentage of points that need to match
+	// the condition when the match type is PercentOfPoints
+	MatchPercent float64 `yaml:"matchPercent,omitempty" json:"matchPercent,omitempty"`
+	// BreachingSeriesThreshold fires the rule only when more than
+	// this many series match the condition e.g alert when more than
+	// 3 pods are above 90% CPU. Zero disables the check.
+	BreachingSeriesThreshold int `yaml:"breachingSeriesThreshold,omitempty" json:"breachingSeriesThreshold,omitempty"`
+	// SLO is used by the SLO rules, the selected query is the SLI
+	SLO *SLOCondition `yaml:"slo,omitempty" json:"slo,omitempty"`
+	// Heartbeat is used by the heartbeat rules, the selected query is the expected signal
+	Heartbeat *HeartbeatCondition `yaml:"heartbeat,omitempty" json:"heartbeat,omitempty"`
+	// DeltaEval queries only the data since the previous evaluation and keeps the
+	// points of the eval window in memory, it's supported by the threshold rules
+	// counting the logs and traces events
+	DeltaEval bool `yaml:"deltaEval,omitempty" json:"deltaEval,omitempty"`
 }
 
 func (rc *RuleCondition) GetSelectedQueryName() string {
@@ -167,6 +318,14 @@ func (rc *RuleCondition) IsValid() bool {
 		return false
 	}
 
+	if rc.SLO != nil {
+		return rc.SLO.Target > 0 && rc.SLO.Target < 100
+	}
+
+	if rc.Heartbeat != nil {
+		return rc.Heartbeat.AbsentFor > 0
+	}
+
 	if rc.QueryType() == v3.QueryTypeBuilder {
 		if rc.Target == nil {
 			return false
@@ -184,6 +343,42 @@ func (rc *RuleCondition) IsValid() bool {
 	return true
 }
 
+// UnitMismatchError is returned when the threshold can't be converted
+// to the unit of the query result
+type UnitMismatchError struct {
+	TargetUnit      string   `json:"targetUnit"`
+	Unit            string   `json:"unit"`
+	CompatibleUnits []string `json:"compatibleUnits"`
+}
+
+func (e *UnitMismatchError) Error() string {
+	return fmt.Sprintf("threshold unit %s can't be converted to the y-axis unit %s, compatible units are: %s",
+		e.TargetUnit, e.Unit, strings.Join(e.CompatibleUnits, ", "))
+}
+
+// Validate checks that the threshold can be compared with the query result
+func (rc *RuleCondition) Validate() error {
+	if rc == nil || rc.CompositeQuery == nil || rc.Target == nil {
+		return nil
+	}
+
+	targetUnit := converter.Unit(rc.TargetUnit)
+	unit := converter.Unit(rc.CompositeQuery.Unit)
+	if converter.IsCompatible(targetUnit, unit) {
+		return nil
+	}
+
+	compatibleUnits := []string{}
+	for _, u := range converter.CompatibleUnits(unit) {
+		compatibleUnits = append(compatibleUnits, string(u))
+	}
+	return &UnitMismatchError{
+		TargetUnit:      rc.TargetUnit,
+		Unit:            rc.CompositeQuery.Unit,
+		CompatibleUnits: compatibleUnits,
+	}
+}
+
 // QueryType is a short hand method to get query type
 func (rc *RuleCondition) QueryType() v3.QueryType {
 	if rc.CompositeQuery != nil {
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 	"time"
 	"unicode/utf8"
 
@@ -50,6 +52,20 @@ type PostableRule struct {
 	RuleType    RuleType  `yaml:"ruleType,omitempty" json:"ruleType,omitempty"`
 	EvalWindow  Duration  `yaml:"evalWindow,omitempty" json:"evalWindow,omitempty"`
 	Frequency   Duration  `yaml:"frequency,omitempty" json:"frequency,omitempty"`
+	// For keeps the alerts pending for this long before they fire
+	For Duration `yaml:"for,omitempty" json:"for,omitempty"`
+	// EvalDelay lags the evaluation of this rule, overriding the
+	// delay configured for the rule manager
+	EvalDelay Duration `yaml:"evalDelay,omitempty" json:"evalDelay,omitempty"`
+	// EvalTimeout cancels the queries of this rule running for longer, overriding
+	// the timeout configured for the rule manager
+	EvalTimeout Duration `yaml:"evalTimeout,omitempty" json:"evalTimeout,omitempty"`
+	// MaxResultSeries and MaxResultPoints abort the evaluations of this rule querying
+	// larger results, overriding the limits configured for the rule manager
+	MaxResultSeries int `yaml:"maxResultSeries,omitempty" json:"maxResultSeries,omitempty"`
+	MaxResultPoints int `yaml:"maxResultPoints,omitempty" json:"maxResultPoints,omitempty"`
+	// Priority orders the evaluation of this rule when the rule engine is overloaded
+	Priority RulePriority `yaml:"priority,omitempty" json:"priority,omitempty"`
 
 	RuleCondition *RuleCondition    `yaml:"condition,omitempty" json:"condition,omitempty"`
 	Labels        map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
@@ -62,8 +78,25 @@ type PostableRule struct {
 
 	PreferredChannels []string `json:"preferredChannels,omitempty"`
 
+	NotificationSettings *NotificationSettings `yaml:"notificationSettings,omitempty" json:"notificationSettings,omitempty"`
+
+	// ActiveSchedule restricts the rule to the given time windows
+	ActiveSchedule *ActiveSchedule `yaml:"activeSchedule,omitempty" json:"activeSchedule,omitempty"`
+
+	// ExternalID is the stable identifier of the rule assigned
+	// by the tools that manage the rules outside of SigNoz
+	ExternalID string `yaml:"externalId,omitempty" json:"externalId,omitempty"`
+
+	// Folder and Tags organize the rules e.g per team or service
+	Folder string   `yaml:"folder,omitempty" json:"folder,omitempty"`
+	Tags   []string `yaml:"tags,omitempty" json:"tags,omitempty"`
+
 	Version string `json:"version,omitempty"`
 
+	// OrgID is the org owning the rule, it is taken from the stored
+	// rule and not from the rule definition
+	OrgID string `yaml:"-" json:"-"`
+
 	// legacy
 	Expr    string `yaml:"expr,omitempty" json:"expr,omitempty"`
 	OldYaml string `json:"yaml,omitempty"`
@@ -80,6 +113,21 @@ func parsePostableRule(content []byte, kind RuleDataKind) (*PostableRule, error)
 // parseIntoRule loads the content (data) into PostableRule and also
 // validates the end result
 func parseIntoRule(initRule PostableRule, content []byte, kind RuleDataKind) (*PostableRule, error) {
+	rule, err := loadIntoRule(initRule, content, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// loadIntoRule loads the content (data) into PostableRule and fills
+// the defaults without validating the result
+func loadIntoRule(initRule PostableRule, content []byte, kind RuleDataKind) (*PostableRule, error) {
 
 	rule := &initRule
 
@@ -121,7 +169,7 @@ func parseIntoRule(initRule PostableRule, content []byte, kind RuleDataKind) (*P
 		rule.Frequency = Duration(1 * time.Minute)
 	}
 
-	if rule.RuleCondition != nil {
+	if rule.RuleCondition != nil && rule.RuleCondition.CompositeQuery != nil {
 		if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypeBuilder {
 			if rule.RuleType == "" {
 				rule.RuleType = RuleTypeThreshold
@@ -137,10 +185,6 @@ func parseIntoRule(initRule PostableRule, content []byte, kind RuleDataKind) (*P
 		}
 	}
 
-	if err := rule.Validate(); err != nil {
-		return nil, err
-	}
-
 	return rule, nil
 }
 
@@ -199,6 +243,12 @@ func isAllQueriesDisabled(compositeQuery *v3.CompositeQuery) bool {
 	return true
 }
 
+// validateChange runs the checks enforced only when the rule is created or edited,
+// the stored rules failing them are still loaded
+func (r *PostableRule) validateChange() error {
+	return r.RuleCondition.Validate()
+}
+
 func (r *PostableRule) Validate() error {
 
 	var errs []error
@@ -226,6 +276,92 @@ func (r *PostableRule) Validate() error {
 		if r.RuleCondition.MatchType == "" {
 			errs = append(errs, errors.Errorf("rule condition missing the match option"))
 		}
+		if r.RuleCondition.BreachingSeriesThreshold < 0 {
+			errs = append(errs, errors.Errorf("rule condition breaching series threshold cannot be negative"))
+		}
+		if r.RuleCondition.MatchType == PercentOfPoints && (r.RuleCondition.MatchPercent <= 0 || r.RuleCondition.MatchPercent > 100) {
+			errs = append(errs, errors.Errorf("rule condition match percent must be between 0 and 100"))
+		}
+	}
+
+	if r.RuleCondition.DeltaEval {
+		if r.RuleType != RuleTypeThreshold {
+			errs = append(errs, errors.Errorf("delta evaluation is supported only by the threshold rules"))
+		} else if r.RuleCondition.CompositeQuery != nil {
+			if err := r.RuleCondition.validateDeltaEval(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if r.RuleType == RuleTypeSLO {
+		if r.RuleCondition.SLO == nil {
+			errs = append(errs, errors.Errorf("rule condition missing the slo"))
+		} else if r.RuleCondition.SLO.Target <= 0 || r.RuleCondition.SLO.Target >= 100 {
+			errs = append(errs, errors.Errorf("slo target must be between 0 and 100"))
+		} else if r.RuleCondition.SLO.Window < 0 {
+			errs = append(errs, errors.Errorf("slo window cannot be negative"))
+		}
+		if r.RuleCondition.QueryType() == v3.QueryTypePromQL {
+			errs = append(errs, errors.Errorf("slo rules support only the builder and clickhouse queries"))
+		}
+	}
+
+	if r.RuleType == RuleTypeHeartbeat {
+		if r.RuleCondition.Heartbeat == nil {
+			errs = append(errs, errors.Errorf("rule condition missing the heartbeat"))
+		} else {
+			if r.RuleCondition.Heartbeat.AbsentFor <= 0 {
+				errs = append(errs, errors.Errorf("heartbeat absent for duration must be greater than 0"))
+			}
+			if r.RuleCondition.Heartbeat.WebhookURL != "" {
+				if u, err := url.Parse(r.RuleCondition.Heartbeat.WebhookURL); err != nil || u.Host == "" {
+					errs = append(errs, errors.Errorf("invalid heartbeat webhook url: %s", r.RuleCondition.Heartbeat.WebhookURL))
+				}
+			}
+		}
+		if r.RuleCondition.QueryType() == v3.QueryTypePromQL {
+			errs = append(errs, errors.Errorf("heartbeat rules support only the builder and clickhouse queries"))
+		}
+	}
+
+	if r.EvalDelay < 0 {
+		errs = append(errs, errors.Errorf("eval delay cannot be negative"))
+	}
+
+	if r.EvalTimeout < 0 {
+		errs = append(errs, errors.Errorf("eval timeout cannot be negative"))
+	}
+
+	if r.For < 0 {
+		errs = append(errs, errors.Errorf("for duration cannot be negative"))
+	}
+
+	if r.MaxResultSeries < 0 || r.MaxResultPoints < 0 {
+		errs = append(errs, errors.Errorf("result limits cannot be negative"))
+	}
+
+	if err := r.Priority.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := r.NotificationSettings.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := r.ActiveSchedule.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if r.Folder != strings.TrimSpace(r.Folder) {
+		errs = append(errs, errors.Errorf("folder cannot start or end with spaces"))
+	}
+
+	for _, tag := range r.Tags {
+		if strings.TrimSpace(tag) == "" {
+			errs = append(errs, errors.Errorf("tags cannot be empty"))
+			break
+		}
 	}
 
 	for k, v := range r.Labels {
@@ -297,9 +433,20 @@ type GettableRules struct {
 type GettableRule struct {
 	Id    string           `json:"id"`
 	State model.AlertState `json:"state"`
+	// OverallState is the state of the rule recorded in the state history, firing
+	// while any of its series is firing or has no data
+	OverallState model.AlertState `json:"overallState"`
+	// SeriesStates counts the active series of the rule by state
+	SeriesStates map[string]int `json:"seriesStates,omitempty"`
+	// Series is the state of the active series of the rule, latest first, the rest
+	// of the series are listed by the alerts of the rule
+	Series []RuleAlert `json:"series,omitempty"`
 	PostableRule
 	CreatedAt *time.Time `json:"createAt"`
 	CreatedBy *string    `json:"createBy"`
 	UpdatedAt *time.Time `json:"updateAt"`
 	UpdatedBy *string    `json:"updateBy"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// Provisioned rules are read-only, they are managed in the provisioning directory
+	Provisioned bool `json:"provisioned,omitempty"`
 }
@@ -3,6 +3,7 @@ package rules
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 )
 
@@ -84,3 +85,39 @@ func TestIsAllQueriesDisabled(t *testing.T) {
 		}
 	}
 }
+
+func TestRuleConditionUnitValidation(t *testing.T) {
+	target := 1.0
+	cases := []struct {
+		name       string
+		targetUnit string
+		unit       string
+		valid      bool
+	}{
+		{name: "no target unit", targetUnit: "", unit: "ms", valid: true},
+		{name: "no y-axis unit", targetUnit: "s", unit: "", valid: true},
+		{name: "same unit", targetUnit: "bytes", unit: "bytes", valid: true},
+		{name: "convertible units", targetUnit: "s", unit: "ms", valid: true},
+		{name: "bytes vs seconds", targetUnit: "bytes", unit: "s", valid: false},
+		{name: "per second vs per minute", targetUnit: "reqps", unit: "rpm", valid: false},
+	}
+
+	for _, c := range cases {
+		rc := &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypeBuilder, Unit: c.unit},
+			Target:         &target,
+			TargetUnit:     c.targetUnit,
+		}
+		err := rc.Validate()
+		if c.valid {
+			assert.NoError(t, err, c.name)
+			continue
+		}
+		var unitErr *UnitMismatchError
+		if assert.ErrorAs(t, err, &unitErr, c.name) {
+			assert.Equal(t, c.targetUnit, unitErr.TargetUnit)
+			assert.Equal(t, c.unit, unitErr.Unit)
+			assert.Contains(t, unitErr.CompatibleUnits, c.unit)
+		}
+	}
+}
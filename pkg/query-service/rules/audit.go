@@ -0,0 +1,166 @@
+package rules
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+// AuditResourceType is the kind of the resource recorded in the audit log
+type AuditResourceType string
+
+const (
+	AuditResourceRule        AuditResourceType = "rule"
+	AuditResourceChannel     AuditResourceType = "channel"
+	AuditResourceMaintenance AuditResourceType = "maintenance"
+	AuditResourceSilence     AuditResourceType = "silence"
+	AuditResourceAlertAck    AuditResourceType = "alert_ack"
+	AuditResourceAnnotation  AuditResourceType = "firing_annotation"
+)
+
+// AuditAction is the change made to the resource
+type AuditAction string
+
+const (
+	AuditActionCreate  AuditAction = "create"
+	AuditActionEdit    AuditAction = "edit"
+	AuditActionDelete  AuditAction = "delete"
+	AuditActionRestore AuditAction = "restore"
+)
+
+// AuditChanges are the fields of the resource changed by the action
+type AuditChanges []RuleVersionChange
+
+func (c *AuditChanges) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, c)
+	case string:
+		return json.Unmarshal([]byte(data), c)
+	}
+	return nil
+}
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		c = AuditChanges{}
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// AuditLog is a change made to a rule, channel or maintenance
+type AuditLog struct {
+	Id           int64             `json:"id" db:"id"`
+	ResourceType AuditResourceType `json:"resourceType" db:"resource_type"`
+	ResourceId   string            `json:"resourceId" db:"resource_id"`
+	Action       AuditAction       `json:"action" db:"action"`
+	Actor        *string           `json:"actor" db:"actor"`
+	Timestamp    time.Time         `json:"timestamp" db:"timestamp"`
+	Changes      AuditChanges      `json:"changes" db:"diff"`
+}
+
+// AuditLogFilter selects the audit logs, the empty fields match all the logs
+type AuditLogFilter struct {
+	ResourceType AuditResourceType
+	ResourceId   string
+	Actor        string
+	Start        time.Time
+	End          time.Time
+	// Limit is the max number of logs returned, latest first
+	Limit int
+}
+
+const defaultAuditLogLimit = 100
+
+// auditActor is the email of the user making the change
+func auditActor(ctx context.Context) string {
+	if user := common.GetUserFromContext(ctx); user != nil {
+		return user.Email
+	}
+	email, _ := auth.GetEmailFromJwt(ctx)
+	return email
+}
+
+// auditData parses the stored definition of the resource for the diff,
+// the definitions that are not json are compared as a whole
+func auditData(data string) interface{} {
+	if data == "" {
+		return nil
+	}
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+		return data
+	}
+	return parsed
+}
+
+// addAuditLog records the change of the resource from the before to the after definition
+func addAuditLog(ctx context.Context, db sqlx.Execer, resourceType AuditResourceType, resourceId string, action AuditAction, before, after string) error {
+	changes := AuditChanges(diffRuleData(auditData(before), auditData(after)))
+
+	query := `INSERT INTO audit_logs (resource_type, resource_id, action, actor, timestamp, diff, org_id) VALUES($1,$2,$3,$4,$5,$6,$7);`
+	if _, err := db.Exec(query, resourceType, resourceId, action, auditActor(ctx), time.Now().UTC(), changes, contextOrgID(ctx)); err != nil {
+		zap.L().Error("Error in recording the audit log", zap.String("resource", string(resourceType)), zap.String("id", resourceId), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ruleDB) GetAuditLogs(ctx context.Context, filter *AuditLogFilter) ([]AuditLog, error) {
+	if filter == nil {
+		filter = &AuditLogFilter{}
+	}
+	// the changes of a rule are only shown to the users who can view the rule
+	if filter.ResourceType == AuditResourceRule && filter.ResourceId != "" {
+		if err := r.CheckRulePermission(ctx, filter.ResourceId, RulePermissionView); err != nil {
+			return nil, err
+		}
+	}
+
+	q := newSelectQuery("SELECT id, resource_type, resource_id, action, actor, timestamp, diff FROM audit_logs")
+	// the changes hold the definitions of the resources, e.g the secrets of the channels
+	whereOrg(ctx, q, "audit_logs")
+	if condition, args := viewableRule(ctx, "CAST(audit_logs.resource_id AS INTEGER)"); condition != "" {
+		q.where(fmt.Sprintf("(audit_logs.resource_type!='%s' OR %s)", AuditResourceRule, condition), args...)
+	}
+	if filter.ResourceType != "" {
+		q.where("resource_type=?", filter.ResourceType)
+	}
+	if filter.ResourceId != "" {
+		q.where("resource_id=?", filter.ResourceId)
+	}
+	if filter.Actor != "" {
+		q.where("actor=?", filter.Actor)
+	}
+	if !filter.Start.IsZero() {
+		q.where("timestamp>=?", filter.Start.UTC())
+	}
+	if !filter.End.IsZero() {
+		q.where("timestamp<=?", filter.End.UTC())
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLogLimit
+	}
+	query, args := q.order("timestamp DESC, id DESC").page(limit, 0).build()
+
+	logs := []AuditLog{}
+	if err := r.Select(&logs, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return logs, nil
+}
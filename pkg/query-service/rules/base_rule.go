@@ -1,18 +1,25 @@
 package rules
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"net/url"
+	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"go.signoz.io/signoz/pkg/query-service/converter"
+	"go.signoz.io/signoz/pkg/query-service/formatter"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	qslabels "go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.signoz.io/signoz/pkg/query-service/utils/times"
+	"go.signoz.io/signoz/pkg/query-service/utils/timestamp"
 	"go.uber.org/zap"
 )
 
@@ -21,6 +28,7 @@ type BaseRule struct {
 	id             string
 	name           string
 	source         string
+	orgID          string
 	handledRestart bool
 
 	// Type of the rule
@@ -37,6 +45,18 @@ type BaseRule struct {
 	// evalDelay is the delay in evaluation of the rule
 	// this is useful in cases where the data is not available immediately
 	evalDelay time.Duration
+	// evalTimeout cancels the evaluation running for longer, the
+	// evaluation frequency is the timeout when not set
+	evalTimeout time.Duration
+	// queryParallelism is the number of the queries of the evaluation run at the same time
+	queryParallelism int
+	// maxResultSeries and maxResultPoints abort the evaluation querying a larger result
+	maxResultSeries int
+	maxResultPoints int
+	// priority orders the evaluation of the rule when the engine is overloaded
+	priority RulePriority
+	// query is prepared from the rule condition when the rule is created
+	query *queryTemplate
 
 	// holds the static set of labels and annotations for the rule
 	// these are the same for all alerts created for this rule
@@ -45,7 +65,18 @@ type BaseRule struct {
 	// preferredChannels is the list of channels to send the alert to
 	// if the rule is triggered
 	preferredChannels []string
-	mtx               sync.Mutex
+	// notificationSettings controls how often the notifications
+	// are sent for the firing alerts
+	notificationSettings *NotificationSettings
+	// grouper holds the notifications of the grouped alerts, nil when not grouped
+	grouper *alertGrouper
+	// digest holds the alerts of the day under the daily digest policy, nil under the others
+	digest *alertDigest
+
+	// activeSchedule restricts the evaluation or the
+	// notifications to the configured time windows
+	activeSchedule *ActiveSchedule
+	mtx            sync.Mutex
 	// the time it took to evaluate the rule (most recent evaluation)
 	evaluationDuration time.Duration
 	// the timestamp of the last evaluation
@@ -62,6 +93,10 @@ type BaseRule struct {
 
 	reader interfaces.Reader
 
+	// shadow is set on the edited definition evaluated alongside the live rule,
+	// it doesn't record the state history nor restore the state of the live rule
+	shadow bool
+
 	logger *zap.Logger
 
 	// sendUnmatched sends observed metric values
@@ -73,6 +108,10 @@ type BaseRule struct {
 	// or other params
 	sendAlways bool
 
+	// variables are the org level variables that can be used
+	// in the filter values along with the rule labels
+	variables map[string]string
+
 	// TemporalityMap is a map of metric name to temporality
 	// to avoid fetching temporality for the same metric multiple times
 	// querying the v4 table on low cardinal temporality column
@@ -82,6 +121,15 @@ type BaseRule struct {
 
 type RuleOption func(*BaseRule)
 
+// WithVariables sets the (org level) variables that can be referenced
+// in the filter values of the rule queries. The rule labels take
+// precedence over these variables.
+func WithVariables(vars map[string]string) RuleOption {
+	return func(r *BaseRule) {
+		r.variables = vars
+	}
+}
+
 func WithSendAlways() RuleOption {
 	return func(r *BaseRule) {
 		r.sendAlways = true
@@ -100,6 +148,25 @@ func WithEvalDelay(dur time.Duration) RuleOption {
 	}
 }
 
+func WithEvalTimeout(dur time.Duration) RuleOption {
+	return func(r *BaseRule) {
+		r.evalTimeout = dur
+	}
+}
+
+func WithQueryParallelism(n int) RuleOption {
+	return func(r *BaseRule) {
+		r.queryParallelism = n
+	}
+}
+
+func WithResultLimits(maxSeries, maxPoints int) RuleOption {
+	return func(r *BaseRule) {
+		r.maxResultSeries = maxSeries
+		r.maxResultPoints = maxPoints
+	}
+}
+
 func WithLogger(logger *zap.Logger) RuleOption {
 	return func(r *BaseRule) {
 		r.logger = logger
@@ -112,19 +179,26 @@ func NewBaseRule(id string, p *PostableRule, reader interfaces.Reader, opts ...R
 	}
 
 	baseRule := &BaseRule{
-		id:                id,
-		name:              p.AlertName,
-		source:            p.Source,
-		typ:               p.AlertType,
-		ruleCondition:     p.RuleCondition,
-		evalWindow:        time.Duration(p.EvalWindow),
-		labels:            qslabels.FromMap(p.Labels),
-		annotations:       qslabels.FromMap(p.Annotations),
-		preferredChannels: p.PreferredChannels,
-		health:            HealthUnknown,
-		Active:            map[uint64]*Alert{},
-		reader:            reader,
-		TemporalityMap:    make(map[string]map[v3.Temporality]bool),
+		id:                   id,
+		name:                 p.AlertName,
+		source:               p.Source,
+		orgID:                p.OrgID,
+		typ:                  p.AlertType,
+		ruleCondition:        p.RuleCondition,
+		evalWindow:           time.Duration(p.EvalWindow),
+		holdDuration:         time.Duration(p.For),
+		labels:               qslabels.FromMap(p.Labels),
+		annotations:          qslabels.FromMap(p.Annotations),
+		preferredChannels:    p.PreferredChannels,
+		notificationSettings: p.NotificationSettings,
+		grouper:              newAlertGrouper(p.NotificationSettings),
+		digest:               newAlertDigest(p.NotificationSettings),
+		activeSchedule:       p.ActiveSchedule,
+		priority:             p.Priority,
+		health:               HealthUnknown,
+		Active:               map[uint64]*Alert{},
+		reader:               reader,
+		TemporalityMap:       make(map[string]map[v3.Temporality]bool),
 	}
 
 	if baseRule.evalWindow == 0 {
@@ -135,6 +209,23 @@ func NewBaseRule(id string, p *PostableRule, reader interfaces.Reader, opts ...R
 		opt(baseRule)
 	}
 
+	// the delay set on the rule takes precedence over
+	// the one configured for the rule manager
+	if p.EvalDelay > 0 {
+		baseRule.evalDelay = time.Duration(p.EvalDelay)
+	}
+	if p.EvalTimeout > 0 {
+		baseRule.evalTimeout = time.Duration(p.EvalTimeout)
+	}
+	if p.MaxResultSeries > 0 {
+		baseRule.maxResultSeries = p.MaxResultSeries
+	}
+	if p.MaxResultPoints > 0 {
+		baseRule.maxResultPoints = p.MaxResultPoints
+	}
+	// the variables of the filters are set by the options
+	baseRule.query = newQueryTemplate(baseRule)
+
 	return baseRule, nil
 }
 
@@ -168,6 +259,37 @@ func (r *BaseRule) compareOp() CompareOp {
 	return r.ruleCondition.CompareOp
 }
 
+// matchesCondition reports whether the value satisfies
+// the compare op against the target
+func (r *BaseRule) matchesCondition(value float64) bool {
+	switch r.compareOp() {
+	case ValueIsAbove:
+		return value > r.targetVal()
+	case ValueIsBelow:
+		return value < r.targetVal()
+	case ValueIsEq:
+		return value == r.targetVal()
+	case ValueIsNotEq:
+		return value != r.targetVal()
+	case ValueAboveOrEq:
+		return value >= r.targetVal()
+	case ValueBelowOrEq:
+		return value <= r.targetVal()
+	case ValueOutsideBounds:
+		return math.Abs(value) >= r.targetVal()
+	}
+	return false
+}
+
+// HasEnoughBreachingSeries reports whether the number of series matching
+// the condition is above the breaching series threshold of the rule
+func (r *BaseRule) HasEnoughBreachingSeries(count int) bool {
+	if r.ruleCondition == nil || r.ruleCondition.BreachingSeriesThreshold <= 0 {
+		return true
+	}
+	return count > r.ruleCondition.BreachingSeriesThreshold
+}
+
 func (r *BaseRule) currentAlerts() []*Alert {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
@@ -180,10 +302,26 @@ func (r *BaseRule) currentAlerts() []*Alert {
 	return alerts
 }
 
+func (r *BaseRule) ActiveSchedule() *ActiveSchedule {
+	return r.activeSchedule
+}
+
 func (r *BaseRule) EvalDelay() time.Duration {
 	return r.evalDelay
 }
 
+func (r *BaseRule) EvalTimeout() time.Duration {
+	return r.evalTimeout
+}
+
+func (r *BaseRule) QueryParallelism() int {
+	return r.queryParallelism
+}
+
+func (r *BaseRule) Priority() RulePriority {
+	return r.priority
+}
+
 func (r *BaseRule) EvalWindow() time.Duration {
 	return r.evalWindow
 }
@@ -209,6 +347,7 @@ func (r *ThresholdRule) hostFromSource() string {
 
 func (r *BaseRule) ID() string                       { return r.id }
 func (r *BaseRule) Name() string                     { return r.name }
+func (r *BaseRule) OrgID() string                    { return r.orgID }
 func (r *BaseRule) Condition() *RuleCondition        { return r.ruleCondition }
 func (r *BaseRule) Labels() qslabels.BaseLabels      { return r.labels }
 func (r *BaseRule) Annotations() qslabels.BaseLabels { return r.annotations }
@@ -240,6 +379,107 @@ func (r *BaseRule) Timestamps(ts time.Time) (time.Time, time.Time) {
 	return time.UnixMilli(start), time.UnixMilli(end)
 }
 
+// TemplateVariables returns the variables available to the templated
+// filter values i.e the org level variables and the rule labels
+func (r *BaseRule) TemplateVariables() map[string]string {
+	vars := make(map[string]string, len(r.variables))
+	for k, v := range r.variables {
+		vars[k] = v
+	}
+	if r.labels != nil {
+		for k, v := range r.labels.Map() {
+			vars[k] = v
+		}
+	}
+	return vars
+}
+
+// ResolveFilterVariables expands the variables referenced in the filter
+// values of the builder queries e.g `deployment.environment = {{.env}}`.
+// The composite query is returned as is when there is nothing to expand,
+// otherwise a copy with the expanded values is returned so that the rule
+// condition keeps the templates for the next evaluation.
+func (r *BaseRule) ResolveFilterVariables(cq *v3.CompositeQuery) (*v3.CompositeQuery, error) {
+	if cq == nil || !hasTemplatedFilters(cq) {
+		return cq, nil
+	}
+
+	vars := r.TemplateVariables()
+	expand := func(value string) (string, error) {
+		if !strings.Contains(value, "{{") {
+			return value, nil
+		}
+		tmpl, err := template.New("filter-value").Option("missingkey=error").Parse(value)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, vars); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+
+	resolved := cq.Clone()
+	for name, q := range resolved.BuilderQueries {
+		if q.Filters == nil {
+			continue
+		}
+		items := make([]v3.FilterItem, len(q.Filters.Items))
+		for idx, item := range q.Filters.Items {
+			switch value := item.Value.(type) {
+			case string:
+				expanded, err := expand(value)
+				if err != nil {
+					return nil, fmt.Errorf("failed to expand filter value of query %s: %w", name, err)
+				}
+				item.Value = expanded
+			case []interface{}:
+				values := make([]interface{}, len(value))
+				for i, v := range value {
+					values[i] = v
+					if str, ok := v.(string); ok {
+						expanded, err := expand(str)
+						if err != nil {
+							return nil, fmt.Errorf("failed to expand filter value of query %s: %w", name, err)
+						}
+						values[i] = expanded
+					}
+				}
+				item.Value = values
+			}
+			items[idx] = item
+		}
+		q.Filters.Items = items
+	}
+	return resolved, nil
+}
+
+func hasTemplatedFilters(cq *v3.CompositeQuery) bool {
+	isTemplate := func(v interface{}) bool {
+		str, ok := v.(string)
+		return ok && strings.Contains(str, "{{")
+	}
+	for _, q := range cq.BuilderQueries {
+		if q.Filters == nil {
+			continue
+		}
+		for _, item := range q.Filters.Items {
+			if isTemplate(item.Value) {
+				return true
+			}
+			if values, ok := item.Value.([]interface{}); ok {
+				for _, v := range values {
+					if isTemplate(v) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
 func (r *BaseRule) SetLastError(err error) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
@@ -309,22 +549,104 @@ func (r *BaseRule) ActiveAlerts() []*Alert {
 }
 
 func (r *BaseRule) SendAlerts(ctx context.Context, ts time.Time, resendDelay time.Duration, interval time.Duration, notifyFunc NotifyFunc) {
-	alerts := []*Alert{}
+	resendDelay = r.notificationSettings.resendDelay(resendDelay)
+	policy := r.notificationSettings.policy()
+
+	delta := resendDelay
+	if interval > resendDelay {
+		delta = interval
+	}
+	if r.digest != nil && !r.sendAlways {
+		r.sendDigest(ctx, ts, 4*delta, notifyFunc)
+		return
+	}
+
+	alerts, keepAlive := []*Alert{}, []*Alert{}
 	r.ForEachActiveAlert(func(alert *Alert) {
-		if alert.needsSending(ts, resendDelay) {
+		if alert.needsSending(ts, resendDelay, policy) {
 			alert.LastSentAt = ts
-			delta := resendDelay
-			if interval > resendDelay {
-				delta = interval
-			}
-			alert.ValidUntil = ts.Add(4 * delta)
+			// the grouped alert stays valid while it is held by the group
+			alert.ValidUntil = ts.Add(4*delta + r.notificationSettings.groupDelay())
 			anew := *alert
 			alerts = append(alerts, &anew)
+		} else if alert.needsKeepAlive(ts, resendDelay, policy) {
+			alert.LastSentAt = ts
+			alert.ValidUntil = ts.Add(4 * delta)
+			anew := *alert
+			anew.keepAlive = true
+			keepAlive = append(keepAlive, &anew)
 		}
 	})
+	if len(keepAlive) > 0 {
+		// the keep-alives bypass the group, they only renew the alerts in the alertmanager
+		notifyFunc(ctx, "", r.resolvedNotifications(ctx, keepAlive)...)
+	}
+	alerts = r.resolvedNotifications(ctx, alerts)
+	if r.grouper != nil {
+		r.grouper.add(ctx, ts, alerts, notifyFunc)
+		return
+	}
 	notifyFunc(ctx, "", alerts...)
 }
 
+// sendDigest adds the alerts of the rule to the digest of the day and notifies
+// the digest once the day is over, the alerts are not notified on their own
+func (r *BaseRule) sendDigest(ctx context.Context, ts time.Time, validFor time.Duration, notifyFunc NotifyFunc) {
+	r.ForEachActiveAlert(func(alert *Alert) {
+		if alert.State != model.StatePending {
+			r.digest.add(ts, alert)
+		}
+	})
+	if alerts := r.digest.flush(ts); len(alerts) > 0 {
+		notifyFunc(ctx, "", digestAlert(ts, ts.Add(validFor), alerts))
+	}
+}
+
+// ResolveActiveAlerts resolves the alerts of the rule at ts like the evaluation without
+// results does, e.g. once the active schedule of the rule closes, so they don't stay firing
+func (r *BaseRule) ResolveActiveAlerts(ctx context.Context, ts time.Time) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	prevState := r.State()
+	itemsToAdd := []model.RuleStateHistory{}
+	for fp, a := range r.Active {
+		if a.State == model.StatePending || a.ResolvedExpired(ts) {
+			delete(r.Active, fp)
+		}
+		if a.State == model.StateInactive {
+			continue
+		}
+		labelsJSON, err := json.Marshal(a.QueryResultLables)
+		if err != nil {
+			zap.L().Error("error marshaling labels", zap.Error(err), zap.Any("labels", a.Labels))
+		}
+		a.State = model.StateInactive
+		a.ResolvedAt = ts
+		itemsToAdd = append(itemsToAdd, model.RuleStateHistory{
+			RuleID:       r.ID(),
+			RuleName:     r.Name(),
+			State:        model.StateInactive,
+			StateChanged: true,
+			UnixMilli:    ts.UnixMilli(),
+			Labels:       model.LabelsString(labelsJSON),
+			Fingerprint:  a.QueryResultLables.Hash(),
+			Value:        a.Value,
+		})
+	}
+	if len(itemsToAdd) == 0 {
+		return
+	}
+
+	currentState := r.State()
+	for idx, item := range itemsToAdd {
+		item.OverallStateChanged = currentState != prevState
+		item.OverallState = currentState
+		itemsToAdd[idx] = item
+	}
+	r.RecordRuleStateHistory(ctx, prevState, currentState, itemsToAdd)
+}
+
 func (r *BaseRule) ForEachActiveAlert(f func(*Alert)) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
@@ -400,6 +722,14 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 					break
 				}
 			}
+		} else if r.compareOp() == ValueAboveOrEq || r.compareOp() == ValueBelowOrEq {
+			for _, smpl := range series.Points {
+				if r.matchesCondition(smpl.Value) {
+					alertSmpl = Sample{Point: Point{V: smpl.Value}, Metric: lbls}
+					shouldAlert = true
+					break
+				}
+			}
 		}
 	case AllTheTimes:
 		// If all samples match the condition, the rule is firing.
@@ -469,6 +799,23 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 					break
 				}
 			}
+		} else if r.compareOp() == ValueAboveOrEq || r.compareOp() == ValueBelowOrEq {
+			for _, smpl := range series.Points {
+				if !r.matchesCondition(smpl.Value) {
+					shouldAlert = false
+					break
+				}
+			}
+			// use the value closest to the target from the series
+			if shouldAlert {
+				closest := series.Points[0].Value
+				for _, smpl := range series.Points[1:] {
+					if math.Abs(smpl.Value-r.targetVal()) < math.Abs(closest-r.targetVal()) {
+						closest = smpl.Value
+					}
+				}
+				alertSmpl = Sample{Point: Point{V: closest}, Metric: lbls}
+			}
 		}
 	case OnAverage:
 		// If the average of all samples matches the condition, the rule is firing.
@@ -502,6 +849,8 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 			if math.Abs(avg) >= r.targetVal() {
 				shouldAlert = true
 			}
+		} else if r.compareOp() == ValueAboveOrEq || r.compareOp() == ValueBelowOrEq {
+			shouldAlert = r.matchesCondition(avg)
 		}
 	case InTotal:
 		// If the sum of all samples matches the condition, the rule is firing.
@@ -534,33 +883,70 @@ func (r *BaseRule) ShouldAlert(series v3.Series) (Sample, bool) {
 			if math.Abs(sum) >= r.targetVal() {
 				shouldAlert = true
 			}
+		} else if r.compareOp() == ValueAboveOrEq || r.compareOp() == ValueBelowOrEq {
+			shouldAlert = r.matchesCondition(sum)
 		}
 	case Last:
-		// If the last sample matches the condition, the rule is firing.
+		// If the most recent sample matches the condition, the rule is firing.
+		// The points are not guaranteed to be ordered, so look up the
+		// sample with the latest timestamp instead of the last element.
+		last := series.Points[0]
+		for _, smpl := range series.Points[1:] {
+			if smpl.Timestamp >= last.Timestamp {
+				last = smpl
+			}
+		}
 		shouldAlert = false
-		alertSmpl = Sample{Point: Point{V: series.Points[len(series.Points)-1].Value}, Metric: lbls}
+		alertSmpl = Sample{Point: Point{V: last.Value}, Metric: lbls}
 		if r.compareOp() == ValueIsAbove {
-			if series.Points[len(series.Points)-1].Value > r.targetVal() {
+			if last.Value > r.targetVal() {
 				shouldAlert = true
 			}
 		} else if r.compareOp() == ValueIsBelow {
-			if series.Points[len(series.Points)-1].Value < r.targetVal() {
+			if last.Value < r.targetVal() {
 				shouldAlert = true
 			}
 		} else if r.compareOp() == ValueIsEq {
-			if series.Points[len(series.Points)-1].Value == r.targetVal() {
+			if last.Value == r.targetVal() {
 				shouldAlert = true
 			}
 		} else if r.compareOp() == ValueIsNotEq {
-			if series.Points[len(series.Points)-1].Value != r.targetVal() {
+			if last.Value != r.targetVal() {
+				shouldAlert = true
+			}
+		} else if r.compareOp() == ValueOutsideBounds {
+			if math.Abs(last.Value) >= r.targetVal() {
 				shouldAlert = true
 			}
+		} else if r.compareOp() == ValueAboveOrEq || r.compareOp() == ValueBelowOrEq {
+			shouldAlert = r.matchesCondition(last.Value)
+		}
+	case PercentOfPoints:
+		// If at least the configured percentage of samples match the condition, the rule is firing.
+		// The most recent matching sample is used as the alert value.
+		var matched int
+		var latest v3.Point
+		for _, smpl := range series.Points {
+			if r.matchesCondition(smpl.Value) {
+				if matched == 0 || smpl.Timestamp >= latest.Timestamp {
+					latest = smpl
+				}
+				matched++
+			}
+		}
+		percent := float64(matched) / float64(len(series.Points)) * 100
+		if matched > 0 && percent >= r.ruleCondition.MatchPercent {
+			shouldAlert = true
+			alertSmpl = Sample{Point: Point{V: latest.Value}, Metric: lbls}
 		}
 	}
 	return alertSmpl, shouldAlert
 }
 
 func (r *BaseRule) RecordRuleStateHistory(ctx context.Context, prevState, currentState model.AlertState, itemsToAdd []model.RuleStateHistory) error {
+	if r.shadow {
+		return nil
+	}
 	zap.L().Debug("recording rule state history", zap.String("ruleid", r.ID()), zap.Any("prevState", prevState), zap.Any("currentState", currentState), zap.Any("itemsToAdd", itemsToAdd))
 	revisedItemsToAdd := map[uint64]model.RuleStateHistory{}
 
@@ -643,6 +1029,7 @@ func (r *BaseRule) RecordRuleStateHistory(ctx context.Context, prevState, curren
 
 		entries := make([]model.RuleStateHistory, 0, len(revisedItemsToAdd))
 		for _, item := range revisedItemsToAdd {
+			item.OrgID = r.orgID
 			entries = append(entries, item)
 		}
 		err := r.reader.AddRuleStateHistory(ctx, entries)
@@ -655,19 +1042,133 @@ func (r *BaseRule) RecordRuleStateHistory(ctx context.Context, prevState, curren
 	return nil
 }
 
+// RestoreState loads the last saved state of each series from the rule state
+// history and re-creates the firing alerts in the active map, so that alerts
+// which were firing before a restart keep their original ActiveAt/FiredAt
+// instead of starting over as pending. The pending alerts are restored from
+// the snapshot of the active alerts so their hold duration isn't reset.
+func (r *BaseRule) RestoreState(ctx context.Context, snapshot []AlertSnapshot) error {
+	r.mtx.Lock()
+	handledRestart := r.handledRestart
+	r.mtx.Unlock()
+
+	// the state has already been carried over from a previous
+	// instance of the rule (i.e the rule was edited), the shadow
+	// rule starts from the empty state
+	if handledRestart || r.shadow {
+		return nil
+	}
+
+	var lastSavedState []model.RuleStateHistory
+	if r.reader != nil {
+		var err error
+		lastSavedState, err = r.reader.GetLastSavedRuleStateHistory(ctx, r.ID())
+		if err != nil {
+			return err
+		}
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	valueFormatter := formatter.FromUnit(r.Unit())
+
+	for _, item := range lastSavedState {
+		if item.State != model.StateFiring && item.State != model.StateNoData {
+			continue
+		}
+
+		var resultLabels qslabels.Labels
+		if err := json.Unmarshal([]byte(item.Labels), &resultLabels); err != nil {
+			zap.L().Warn("failed to parse labels of saved state, skipping", zap.String("ruleid", r.ID()), zap.Uint64("fingerprint", item.Fingerprint), zap.Error(err))
+			continue
+		}
+		resultLabels = qslabels.NewBuilder(resultLabels).Del(qslabels.MetricNameLabel).Del(qslabels.TemporalityLabel).Labels()
+
+		firedAt := time.UnixMilli(item.UnixMilli)
+
+		tmplData := AlertTemplateData(resultLabels.Map(), valueFormatter.Format(item.Value, r.Unit()), valueFormatter.Format(r.targetVal(), r.Unit()))
+		defs := "{{$labels := .Labels}}{{$value := .Value}}{{$threshold := .Threshold}}"
+		expand := func(text string) string {
+			tmpl := NewTemplateExpander(
+				ctx,
+				defs+text,
+				"__alert_"+r.Name(),
+				tmplData,
+				times.Time(timestamp.FromTime(firedAt)),
+				nil,
+			)
+			result, err := tmpl.Expand()
+			if err != nil {
+				result = fmt.Sprintf("<error expanding template: %s>", err)
+			}
+			return result
+		}
+
+		lb := qslabels.NewBuilder(resultLabels)
+		for name, value := range r.labels.Map() {
+			lb.Set(name, expand(value))
+		}
+		lb.Set(qslabels.AlertNameLabel, r.Name())
+		lb.Set(qslabels.AlertRuleIdLabel, r.ID())
+		lb.Set(qslabels.RuleSourceLabel, r.GeneratorURL())
+		if item.State == model.StateNoData {
+			lb.Set(qslabels.AlertNameLabel, "[No data] "+r.Name())
+		}
+
+		annotations := make(qslabels.Labels, 0, len(r.annotations.Map()))
+		for name, value := range r.annotations.Map() {
+			annotations = append(annotations, qslabels.Label{Name: name, Value: expand(value)})
+		}
+
+		lbs := lb.Labels()
+		r.Active[lbs.Hash()] = &Alert{
+			Labels:            lbs,
+			QueryResultLables: resultLabels,
+			Annotations:       annotations,
+			ActiveAt:          firedAt,
+			FiredAt:           firedAt,
+			State:             model.StateFiring,
+			Value:             item.Value,
+			GeneratorURL:      r.GeneratorURL(),
+			Receivers:         r.preferredChannels,
+			Missing:           item.State == model.StateNoData,
+		}
+	}
+
+	r.restoreSnapshot(snapshot)
+
+	zap.L().Info("restored rule state from history", zap.String("ruleid", r.ID()), zap.Int("alerts", len(r.Active)))
+
+	// the active alerts now reflect the saved state, there is
+	// no need to reconcile the state history on the first eval
+	r.handledRestart = true
+
+	return nil
+}
+
+// PopulateTemporality sets the temporality of the metric queries without one, the
+// temporality is shared by the rules through the cache of the manager, the rules
+// evaluated without the cache keep their own
 func (r *BaseRule) PopulateTemporality(ctx context.Context, qp *v3.QueryRangeParamsV3) error {
 
+	cache := temporalityCacheFromContext(ctx)
+	known := r.TemporalityMap
+	if cache != nil {
+		known = cache.get(metricNames(qp), time.Now())
+	}
+
 	missingTemporality := make([]string, 0)
 	metricNameToTemporality := make(map[string]map[v3.Temporality]bool)
 	if qp.CompositeQuery != nil && len(qp.CompositeQuery.BuilderQueries) > 0 {
 		for _, query := range qp.CompositeQuery.BuilderQueries {
 			// if there is no temporality specified in the query but we have it in the map
 			// then use the value from the map
-			if query.Temporality == "" && r.TemporalityMap[query.AggregateAttribute.Key] != nil {
+			if query.Temporality == "" && known[query.AggregateAttribute.Key] != nil {
 				// We prefer delta if it is available
-				if r.TemporalityMap[query.AggregateAttribute.Key][v3.Delta] {
+				if known[query.AggregateAttribute.Key][v3.Delta] {
 					query.Temporality = v3.Delta
-				} else if r.TemporalityMap[query.AggregateAttribute.Key][v3.Cumulative] {
+				} else if known[query.AggregateAttribute.Key][v3.Cumulative] {
 					query.Temporality = v3.Cumulative
 				} else {
 					query.Temporality = v3.Unspecified
@@ -687,10 +1188,16 @@ func (r *BaseRule) PopulateTemporality(ctx context.Context, qp *v3.QueryRangePar
 	var err error
 
 	if len(missingTemporality) > 0 {
-		nameToTemporality, err = r.reader.FetchTemporality(ctx, missingTemporality)
+		// all the missing metrics are fetched at once
+		nameToTemporality, err = queryWithContext(ctx, func() (map[string]map[v3.Temporality]bool, error) {
+			return r.reader.FetchTemporality(ctx, missingTemporality)
+		})
 		if err != nil {
 			return err
 		}
+		if cache != nil {
+			cache.set(missingTemporality, nameToTemporality, time.Now())
+		}
 	}
 
 	if qp.CompositeQuery != nil && len(qp.CompositeQuery.BuilderQueries) > 0 {
@@ -704,7 +1211,9 @@ func (r *BaseRule) PopulateTemporality(ctx context.Context, qp *v3.QueryRangePar
 				} else {
 					query.Temporality = v3.Unspecified
 				}
-				r.TemporalityMap[query.AggregateAttribute.Key] = nameToTemporality[query.AggregateAttribute.Key]
+				if cache == nil {
+					r.TemporalityMap[query.AggregateAttribute.Key] = nameToTemporality[query.AggregateAttribute.Key]
+				}
 			}
 		}
 	}
@@ -1,9 +1,14 @@
 package rules
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 )
 
 func TestBaseRule_RequireMinPoints(t *testing.T) {
@@ -62,3 +67,313 @@ func TestBaseRule_RequireMinPoints(t *testing.T) {
 		})
 	}
 }
+
+type stateHistoryReader struct {
+	interfaces.Reader
+	lastSavedState []model.RuleStateHistory
+}
+
+func (r *stateHistoryReader) GetLastSavedRuleStateHistory(ctx context.Context, ruleID string) ([]model.RuleStateHistory, error) {
+	return r.lastSavedState, nil
+}
+
+func TestBaseRule_RestoreState(t *testing.T) {
+	threshold := 1.0
+	firedAt := time.Now().Add(-10 * time.Minute).Truncate(time.Millisecond)
+
+	reader := &stateHistoryReader{
+		lastSavedState: []model.RuleStateHistory{
+			{
+				RuleID:      "1",
+				State:       model.StateFiring,
+				UnixMilli:   firedAt.UnixMilli(),
+				Labels:      model.LabelsString(`{"service":"frontend"}`),
+				Fingerprint: 1,
+				Value:       10,
+			},
+			{
+				RuleID:      "1",
+				State:       model.StateInactive,
+				UnixMilli:   firedAt.UnixMilli(),
+				Labels:      model.LabelsString(`{"service":"backend"}`),
+				Fingerprint: 2,
+				Value:       0,
+			},
+		},
+	}
+
+	rule, err := NewBaseRule("1", &PostableRule{
+		AlertName: "restore test",
+		Labels:    map[string]string{"severity": "warning"},
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypeBuilder},
+			CompareOp:      ValueIsAbove,
+			MatchType:      AtleastOnce,
+			Target:         &threshold,
+		},
+	}, reader)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err := rule.RestoreState(context.Background(), nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(rule.Active) != 1 {
+		t.Fatalf("expected 1 restored alert, got %d", len(rule.Active))
+	}
+	for _, a := range rule.Active {
+		if a.State != model.StateFiring {
+			t.Errorf("expected restored alert to be firing, got %s", a.State)
+		}
+		if !a.FiredAt.Equal(firedAt) || !a.ActiveAt.Equal(firedAt) {
+			t.Errorf("expected restored alert to keep fired at %v, got %v", firedAt, a.FiredAt)
+		}
+		if a.Labels.Get("service") != "frontend" || a.Labels.Get("severity") != "warning" {
+			t.Errorf("unexpected labels %v", a.Labels)
+		}
+		if a.Labels.Get(labels.AlertRuleIdLabel) != "1" {
+			t.Errorf("expected rule id label to be set, got %v", a.Labels)
+		}
+	}
+	if !rule.handledRestart {
+		t.Errorf("expected restart to be handled after restoring state")
+	}
+}
+
+func TestBaseRule_SendAlertsNotificationPolicy(t *testing.T) {
+	now := time.Now()
+	firedAt := now.Add(-2 * time.Hour)
+
+	cases := []struct {
+		name            string
+		settings        *NotificationSettings
+		lastSentAt      time.Time
+		expectSend      bool
+		expectKeepAlive bool
+	}{
+		{
+			name:       "default policy resends after resend delay",
+			lastSentAt: now.Add(-2 * time.Minute),
+			expectSend: true,
+		},
+		{
+			name:       "interval policy waits for the interval",
+			settings:   &NotificationSettings{Policy: NotifyPolicyInterval, Interval: Duration(30 * time.Minute)},
+			lastSentAt: now.Add(-10 * time.Minute),
+			expectSend: false,
+		},
+		{
+			name:       "interval policy resends after the interval",
+			settings:   &NotificationSettings{Policy: NotifyPolicyInterval, Interval: Duration(30 * time.Minute)},
+			lastSentAt: now.Add(-31 * time.Minute),
+			expectSend: true,
+		},
+		{
+			name:       "state change policy sends when never sent",
+			settings:   &NotificationSettings{Policy: NotifyPolicyStateChange},
+			expectSend: true,
+		},
+		{
+			name:            "state change policy keeps the alert alive without resending it",
+			settings:        &NotificationSettings{Policy: NotifyPolicyStateChange},
+			lastSentAt:      now.Add(-90 * time.Minute),
+			expectSend:      false,
+			expectKeepAlive: true,
+		},
+		{
+			name:       "state change policy waits for the resend delay to keep the alert alive",
+			settings:   &NotificationSettings{Policy: NotifyPolicyStateChange},
+			lastSentAt: now.Add(-30 * time.Second),
+			expectSend: false,
+		},
+		{
+			name:       "daily policy does not resend within a day",
+			settings:   &NotificationSettings{Policy: NotifyPolicyDaily},
+			lastSentAt: now.Add(-90 * time.Minute),
+			expectSend: false,
+		},
+		{
+			name:       "daily policy resends after a day",
+			settings:   &NotificationSettings{Policy: NotifyPolicyDaily},
+			lastSentAt: now.Add(-25 * time.Hour),
+			expectSend: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule := &BaseRule{
+				notificationSettings: c.settings,
+				Active: map[uint64]*Alert{
+					1: {State: model.StateFiring, FiredAt: firedAt, LastSentAt: c.lastSentAt},
+				},
+			}
+			sent, keptAlive := 0, 0
+			rule.SendAlerts(context.Background(), now, time.Minute, time.Minute, func(ctx context.Context, expr string, alerts ...*Alert) {
+				for _, alert := range alerts {
+					if alert.keepAlive {
+						keptAlive++
+					} else {
+						sent++
+					}
+				}
+			})
+			if (sent > 0) != c.expectSend {
+				t.Errorf("expected send to be %v, got %d alerts sent", c.expectSend, sent)
+			}
+			if (keptAlive > 0) != c.expectKeepAlive {
+				t.Errorf("expected keep-alive to be %v, got %d alerts kept alive", c.expectKeepAlive, keptAlive)
+			}
+			if c.expectKeepAlive && !rule.Active[1].ValidUntil.After(now) {
+				t.Errorf("expected the kept alive alert to stay valid")
+			}
+		})
+	}
+}
+
+func TestBaseRule_LastValueMatchType(t *testing.T) {
+	threshold := 10.0
+	tests := []struct {
+		name          string
+		compareOp     CompareOp
+		series        *v3.Series
+		shouldAlert   bool
+		expectedValue float64
+	}{
+		{
+			name:      "stale breach early in the window does not alert",
+			compareOp: ValueIsAbove,
+			series: &v3.Series{
+				Points: []v3.Point{
+					{Timestamp: 1, Value: 20},
+					{Timestamp: 2, Value: 5},
+					{Timestamp: 3, Value: 6},
+				},
+			},
+			shouldAlert:   false,
+			expectedValue: 6,
+		},
+		{
+			name:      "latest point is used even if points are not ordered",
+			compareOp: ValueIsAbove,
+			series: &v3.Series{
+				Points: []v3.Point{
+					{Timestamp: 3, Value: 15},
+					{Timestamp: 1, Value: 5},
+					{Timestamp: 2, Value: 6},
+				},
+			},
+			shouldAlert:   true,
+			expectedValue: 15,
+		},
+		{
+			name:      "outside bounds uses the absolute latest value",
+			compareOp: ValueOutsideBounds,
+			series: &v3.Series{
+				Points: []v3.Point{
+					{Timestamp: 1, Value: 1},
+					{Timestamp: 2, Value: -12},
+				},
+			},
+			shouldAlert:   true,
+			expectedValue: -12,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule := &BaseRule{
+				ruleCondition: &RuleCondition{
+					CompareOp: test.compareOp,
+					MatchType: Last,
+					Target:    &threshold,
+				},
+			}
+			smpl, shouldAlert := rule.ShouldAlert(*test.series)
+			if shouldAlert != test.shouldAlert {
+				t.Errorf("expected shouldAlert to be %v, got %v", test.shouldAlert, shouldAlert)
+			}
+			if smpl.V != test.expectedValue {
+				t.Errorf("expected sample value %v, got %v", test.expectedValue, smpl.V)
+			}
+		})
+	}
+}
+
+func TestBaseRule_PercentOfPointsMatchType(t *testing.T) {
+	threshold := 10.0
+	series := v3.Series{
+		Points: []v3.Point{
+			{Timestamp: 1, Value: 20},
+			{Timestamp: 2, Value: 5},
+			{Timestamp: 3, Value: 30},
+			{Timestamp: 4, Value: 6},
+		},
+	}
+	tests := []struct {
+		name          string
+		percent       float64
+		shouldAlert   bool
+		expectedValue float64
+	}{
+		{
+			name:          "alerts when the percentage of matching points is reached",
+			percent:       50,
+			shouldAlert:   true,
+			expectedValue: 30,
+		},
+		{
+			name:        "does not alert below the percentage",
+			percent:     75,
+			shouldAlert: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule := &BaseRule{
+				ruleCondition: &RuleCondition{
+					CompareOp:    ValueIsAbove,
+					MatchType:    PercentOfPoints,
+					MatchPercent: test.percent,
+					Target:       &threshold,
+				},
+			}
+			smpl, shouldAlert := rule.ShouldAlert(series)
+			if shouldAlert != test.shouldAlert {
+				t.Errorf("expected shouldAlert to be %v, got %v", test.shouldAlert, shouldAlert)
+			}
+			if shouldAlert && smpl.V != test.expectedValue {
+				t.Errorf("expected sample value %v, got %v", test.expectedValue, smpl.V)
+			}
+		})
+	}
+}
+
+func TestBaseRule_HasEnoughBreachingSeries(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		count     int
+		expected  bool
+	}{
+		{name: "check disabled", threshold: 0, count: 1, expected: true},
+		{name: "count equal to threshold", threshold: 3, count: 3, expected: false},
+		{name: "count above threshold", threshold: 3, count: 4, expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rule := &BaseRule{
+				ruleCondition: &RuleCondition{
+					BreachingSeriesThreshold: test.threshold,
+				},
+			}
+			if got := rule.HasEnoughBreachingSeries(test.count); got != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
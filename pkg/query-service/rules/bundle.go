@@ -0,0 +1,234 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// RuleBundle is the yaml document with a set of rules, the rules
+// use the same fields as the rules api
+type RuleBundle struct {
+	Rules []map[string]interface{} `yaml:"rules"`
+}
+
+type RuleImportStatus string
+
+const (
+	RuleImportCreated RuleImportStatus = "created"
+	RuleImportUpdated RuleImportStatus = "updated"
+	RuleImportFailed  RuleImportStatus = "failed"
+)
+
+// RuleImportResult is the outcome of importing a rule of the bundle
+type RuleImportResult struct {
+	ExternalID string           `json:"externalId"`
+	ID         string           `json:"id,omitempty"`
+	Status     RuleImportStatus `json:"status"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// RuleConversion is the outcome of converting a rule of another alerting system,
+// Error is set when the rule can't be converted and Warnings list the
+// constructs that are dropped or behave differently
+type RuleConversion struct {
+	Group    string        `json:"group"`
+	Name     string        `json:"name"`
+	Rule     *PostableRule `json:"rule,omitempty"`
+	Warnings []string      `json:"warnings,omitempty"`
+	Error    string        `json:"error,omitempty"`
+
+	// Import is the outcome of creating or updating the converted rule
+	Import *RuleImportResult `json:"import,omitempty"`
+}
+
+// importConversions creates or updates the converted rules by their external id
+func (m *Manager) importConversions(ctx context.Context, conversions []RuleConversion) {
+	for idx, conversion := range conversions {
+		if conversion.Rule == nil {
+			continue
+		}
+		result := RuleImportResult{ExternalID: conversion.Rule.ExternalID, Status: RuleImportFailed}
+		ruleJSON, err := json.Marshal(conversion.Rule)
+		if err != nil {
+			result.Error = err.Error()
+		} else if id, status, err := m.upsertRule(ctx, conversion.Rule.ExternalID, string(ruleJSON)); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.ID = id
+			result.Status = status
+		}
+		conversions[idx].Import = &result
+	}
+}
+
+// ExportRules returns the rules matching the filter as a yaml bundle, the rules
+// without an external id are stamped with a unique one first so that importing
+// the bundle elsewhere never takes over an unrelated rule
+func (m *Manager) ExportRules(ctx context.Context, filter *StoredRuleFilter) ([]byte, error) {
+	storedRules, err := m.ruleDB.FilterStoredRules(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := RuleBundle{Rules: make([]map[string]interface{}, 0, len(storedRules))}
+	for _, s := range storedRules {
+		rule := PostableRule{}
+		if err := json.Unmarshal([]byte(s.Data), &rule); err != nil {
+			zap.L().Error("failed to unmarshal rule from db", zap.Int("id", s.Id), zap.Error(err))
+			continue
+		}
+		if rule.ExternalID == "" {
+			externalID := newExternalID()
+			if err := m.ruleDB.SetRuleExternalID(ctx, s.Id, externalID); err != nil {
+				zap.L().Error("failed to stamp the external id of the rule", zap.Int("id", s.Id), zap.Error(err))
+				return nil, err
+			}
+			rule.ExternalID = externalID
+		}
+
+		// go through json so that the bundle uses the field names of the api
+		ruleJSON, err := json.Marshal(rule)
+		if err != nil {
+			return nil, err
+		}
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal(ruleJSON, &fields); err != nil {
+			return nil, err
+		}
+		bundle.Rules = append(bundle.Rules, fields)
+	}
+
+	return yamlv3.Marshal(bundle)
+}
+
+// newExternalID returns the external id stamped on the exported rules created without one
+func newExternalID() string {
+	return "rule-" + uuid.New().String()
+}
+
+// ImportRules creates or updates the rules of the yaml bundle by their external id,
+// a rule that fails to import doesn't stop the import of the other rules
+func (m *Manager) ImportRules(ctx context.Context, content []byte) ([]RuleImportResult, error) {
+	bundle := RuleBundle{}
+	if err := yamlv3.Unmarshal(content, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse the rule bundle: %w", err)
+	}
+
+	results := make([]RuleImportResult, 0, len(bundle.Rules))
+	for idx, fields := range bundle.Rules {
+		result := m.importRule(ctx, fields)
+		if result.Status == RuleImportFailed {
+			zap.L().Error("failed to import rule", zap.Int("index", idx), zap.String("externalId", result.ExternalID), zap.String("error", result.Error))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (m *Manager) importRule(ctx context.Context, fields map[string]interface{}) RuleImportResult {
+	result := RuleImportResult{Status: RuleImportFailed}
+
+	ruleJSON, err := json.Marshal(fields)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	rule, err := ParsePostableRule(ruleJSON)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.ExternalID = rule.ExternalID
+	if rule.ExternalID == "" {
+		result.Error = "externalId is required"
+		return result
+	}
+
+	id, status, err := m.upsertRule(ctx, rule.ExternalID, string(ruleJSON))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.ID = id
+	result.Status = status
+	return result
+}
+
+// upsertRule creates the rule or updates the rule with the external id
+func (m *Manager) upsertRule(ctx context.Context, externalID string, ruleStr string) (string, RuleImportStatus, error) {
+	stored, err := m.ruleDB.GetStoredRuleByExternalID(ctx, externalID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", RuleImportFailed, err
+	}
+
+	if stored != nil {
+		id := strconv.Itoa(stored.Id)
+		if err := m.EditRule(ctx, ruleStr, id); err != nil {
+			return "", RuleImportFailed, err
+		}
+		return id, RuleImportUpdated, nil
+	}
+
+	created, err := m.CreateRule(ctx, ruleStr)
+	if err != nil {
+		return "", RuleImportFailed, err
+	}
+	return created.Id, RuleImportCreated, nil
+}
+
+// PutRuleByExternalID creates or updates the rule with the external id, the external id
+// of the path is used when the rule doesn't have one and must match it otherwise
+func (m *Manager) PutRuleByExternalID(ctx context.Context, externalID string, ruleStr string) (*GettableRule, RuleImportStatus, error) {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(ruleStr), &fields); err != nil {
+		return nil, RuleImportFailed, ErrFailedToParseJSON
+	}
+	if id, ok := fields["externalId"]; ok && id != externalID {
+		return nil, RuleImportFailed, fmt.Errorf("externalId %v of the rule doesn't match %s", id, externalID)
+	}
+	fields["externalId"] = externalID
+
+	ruleJSON, err := json.Marshal(fields)
+	if err != nil {
+		return nil, RuleImportFailed, err
+	}
+
+	id, status, err := m.upsertRule(ctx, externalID, string(ruleJSON))
+	if err != nil {
+		return nil, RuleImportFailed, err
+	}
+
+	rule, err := m.GetRule(ctx, id)
+	if err != nil {
+		return nil, RuleImportFailed, err
+	}
+	return rule, status, nil
+}
+
+// GetRuleByExternalID returns the rule with the external id
+func (m *Manager) GetRuleByExternalID(ctx context.Context, externalID string) (*GettableRule, error) {
+	stored, err := m.ruleDB.GetStoredRuleByExternalID(ctx, externalID)
+	if err != nil {
+		return nil, err
+	}
+	return m.GetRule(ctx, strconv.Itoa(stored.Id))
+}
+
+// DeleteRuleByExternalID deletes the rule with the external id
+func (m *Manager) DeleteRuleByExternalID(ctx context.Context, externalID string) error {
+	stored, err := m.ruleDB.GetStoredRuleByExternalID(ctx, externalID)
+	if err != nil {
+		return err
+	}
+	return m.DeleteRule(ctx, strconv.Itoa(stored.Id))
+}
@@ -0,0 +1,167 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	return &Manager{
+		tasks:  map[string]Task{},
+		rules:  map[string]Rule{},
+		ruleDB: NewRuleDB(utils.NewQueryServiceDBForTests(t), nil),
+		opts:   &ManagerOptions{DisableRules: true},
+	}
+}
+
+const testBundle = `
+rules:
+  - externalId: checkout-latency
+    alert: Checkout latency
+    alertType: METRIC_BASED_ALERT
+    ruleType: threshold_rule
+    folder: payments
+    condition:
+      compositeQuery:
+        queryType: builder
+        panelType: graph
+        builderQueries:
+          A:
+            queryName: A
+            dataSource: metrics
+            aggregateOperator: p99
+            aggregateAttribute:
+              key: signoz_latency
+            expression: A
+      op: "1"
+      matchType: "1"
+      target: 500
+  - alert: Without external id
+    condition:
+      compositeQuery:
+        queryType: promql
+        promQueries:
+          A:
+            query: up
+      op: "1"
+      matchType: "1"
+      target: 1
+`
+
+func TestImportExportRules(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	results, err := m.ImportRules(ctx, []byte(testBundle))
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, RuleImportResult{ExternalID: "checkout-latency", ID: "1", Status: RuleImportCreated}, results[0])
+	assert.Equal(t, RuleImportFailed, results[1].Status)
+	assert.Equal(t, "externalId is required", results[1].Error)
+
+	// a rule created from the ui is stamped with a unique external id on export
+	_, err = m.CreateRule(ctx, `{"alert":"Error rate","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+	assert.NoError(t, err)
+
+	bundle, err := m.ExportRules(ctx, nil)
+	assert.NoError(t, err)
+	exported := RuleBundle{}
+	assert.NoError(t, yamlv3.Unmarshal(bundle, &exported))
+	assert.Len(t, exported.Rules, 2)
+	assert.Equal(t, "checkout-latency", exported.Rules[0]["externalId"])
+	assert.Equal(t, "payments", exported.Rules[0]["folder"])
+	stampedID, _ := exported.Rules[1]["externalId"].(string)
+	assert.True(t, strings.HasPrefix(stampedID, "rule-"))
+	assert.NotEqual(t, "rule-2", stampedID)
+	stamped, err := m.GetRuleByExternalID(ctx, stampedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "2", stamped.Id)
+
+	// importing the export updates the rules instead of creating them again
+	exported.Rules[0]["alert"] = "Checkout p99 latency"
+	bundle, err = yamlv3.Marshal(exported)
+	assert.NoError(t, err)
+	results, err = m.ImportRules(ctx, bundle)
+	assert.NoError(t, err)
+	assert.Equal(t, []RuleImportResult{
+		{ExternalID: "checkout-latency", ID: "1", Status: RuleImportUpdated},
+		{ExternalID: stampedID, ID: "2", Status: RuleImportUpdated},
+	}, results)
+
+	rule, err := m.GetRule(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Checkout p99 latency", rule.AlertName)
+
+	rules, err := m.ruleDB.GetStoredRules(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+
+	// the external ids are unique
+	_, err = m.CreateRule(ctx, `{"alert":"Duplicate","externalId":"checkout-latency","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"up"}}},"op":"1","matchType":"1","target":1}}`)
+	assert.Error(t, err)
+
+	// the default names of the rules don't match the rules created without an external id
+	_, err = m.CreateRule(ctx, `{"alert":"Latency","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"latency"}}},"op":"1","matchType":"1","target":1}}`)
+	assert.NoError(t, err)
+	results, err = m.ImportRules(ctx, []byte(`rules:
+  - alert: Other instance
+    externalId: rule-3
+    condition:
+      compositeQuery:
+        queryType: promql
+        promQueries:
+          A:
+            query: other
+      op: "1"
+      matchType: "1"
+      target: 1
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []RuleImportResult{{ExternalID: "rule-3", ID: "4", Status: RuleImportCreated}}, results)
+	rule, err = m.GetRule(ctx, "3")
+	assert.NoError(t, err)
+	assert.Equal(t, "Latency", rule.AlertName)
+}
+
+func TestPutRuleByExternalID(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	ruleStr := `{"alert":"Error rate","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`
+
+	rule, status, err := m.PutRuleByExternalID(ctx, "terraform/error-rate", ruleStr)
+	assert.NoError(t, err)
+	assert.Equal(t, RuleImportCreated, status)
+	assert.Equal(t, "1", rule.Id)
+	assert.Equal(t, "terraform/error-rate", rule.ExternalID)
+
+	// putting the same rule again keeps the id
+	rule, status, err = m.PutRuleByExternalID(ctx, "terraform/error-rate", ruleStr)
+	assert.NoError(t, err)
+	assert.Equal(t, RuleImportUpdated, status)
+	assert.Equal(t, "1", rule.Id)
+
+	_, _, err = m.PutRuleByExternalID(ctx, "terraform/other", `{"externalId":"terraform/error-rate","alert":"Mismatch"}`)
+	assert.Error(t, err)
+
+	rule, err = m.GetRuleByExternalID(ctx, "terraform/error-rate")
+	assert.NoError(t, err)
+	assert.Equal(t, "Error rate", rule.AlertName)
+
+	assert.NoError(t, m.DeleteRuleByExternalID(ctx, "terraform/error-rate"))
+	_, err = m.GetRuleByExternalID(ctx, "terraform/error-rate")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the deleted rule's external id can be used again
+	rule, status, err = m.PutRuleByExternalID(ctx, "terraform/error-rate", ruleStr)
+	assert.NoError(t, err)
+	assert.Equal(t, RuleImportCreated, status)
+	assert.Equal(t, "2", rule.Id)
+}
@@ -0,0 +1,191 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// deliveryTimeout bounds the request sent to the provider of the channel
+const deliveryTimeout = 10 * time.Second
+
+// deliveredChannel is the channel whose notifications are sent by the query service
+// instead of the alertmanager, e.g the providers the alertmanager can't send to the
+// way the users want
+type deliveredChannel struct {
+	name     string
+	receiver *am.Receiver
+	// template is the message template of the channel, nil when it has none
+	template *ChannelTemplate
+}
+
+// channelSender sends the alerts to the configs of its kind in the channel
+type channelSender interface {
+	// configured reports whether the channel has configs of the sender
+	configured(receiver *am.Receiver) bool
+	// validate checks the configs of the sender in the receiver
+	validate(receiver *am.Receiver) error
+	send(ctx context.Context, d *channelDelivery, channel *deliveredChannel, alerts []*Alert) error
+}
+
+// channelSenders are the senders of the configs delivered by the query service
+var channelSenders = []channelSender{
+	pagerdutyV2Sender{},
+	webhookSender{},
+	smtpSender{},
+	twilioSender{},
+	jiraSender{},
+}
+
+// decodeConfigs decodes the configs of the receiver into the typed configs
+func decodeConfigs(configs interface{}, typed interface{}) error {
+	data, err := json.Marshal(configs)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, typed)
+}
+
+// validateDeliveredConfigs checks the configs of the receiver delivered by the query service
+func validateDeliveredConfigs(receiver *am.Receiver) *model.ApiError {
+	if err := validateTimeSettings(receiver); err != nil {
+		return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+	}
+	for _, sender := range channelSenders {
+		if !sender.configured(receiver) {
+			continue
+		}
+		if err := sender.validate(receiver); err != nil {
+			return &model.ApiError{Typ: model.ErrorBadData, Err: err}
+		}
+	}
+	return nil
+}
+
+// channelDelivery sends the alerts to the channels delivered by the query service
+type channelDelivery struct {
+	ruleDB RuleDB
+	client *http.Client
+	// backoff is the wait before the first retry of a failed request
+	backoff time.Duration
+
+	mtx sync.Mutex
+	// digests are the alerts waiting for the digest emails by channel and recipients
+	digests map[string]*emailDigest
+
+	// test collects the responses of the providers to the test notifications, the
+	// requests of the test are sent once and not recorded
+	test *channelTest
+	// exporter exports the logged notifications, nil when no sink is configured
+	exporter *stateExporter
+}
+
+func newChannelDelivery(ruleDB RuleDB) *channelDelivery {
+	return &channelDelivery{
+		ruleDB:  ruleDB,
+		client:  &http.Client{Timeout: deliveryTimeout},
+		backoff: defaultDeliveryBackoff,
+		digests: map[string]*emailDigest{},
+	}
+}
+
+// channels returns the delivered channels the alerts are sent to, the alert without
+// receivers is sent to all the channels like in the alertmanager
+func (d *channelDelivery) channels(ctx context.Context, alerts []*Alert) (map[string]*deliveredChannel, map[string][]*Alert, error) {
+	stored, apiErr := d.ruleDB.GetChannels()
+	if apiErr != nil {
+		return nil, nil, apiErr.Err
+	}
+
+	channels := map[string]*deliveredChannel{}
+	for _, c := range *stored {
+		receiver := &am.Receiver{}
+		if err := json.Unmarshal([]byte(c.Data), receiver); err != nil {
+			zap.L().Error("failed to parse the channel", zap.String("channel", c.Name), zap.Error(err))
+			continue
+		}
+		delivered := false
+		for _, sender := range channelSenders {
+			if sender.configured(receiver) {
+				delivered = true
+			}
+		}
+		if !delivered {
+			continue
+		}
+		template, err := d.ruleDB.GetChannelTemplate(ctx, int64(c.Id))
+		if err != nil {
+			template = nil
+		}
+		channels[c.Name] = &deliveredChannel{name: c.Name, receiver: receiver, template: template}
+	}
+
+	routed := map[string][]*Alert{}
+	for _, alert := range alerts {
+		receivers := alert.Receivers
+		if len(receivers) == 0 {
+			receivers = make([]string, 0, len(channels))
+			for name := range channels {
+				receivers = append(receivers, name)
+			}
+		}
+		for _, name := range receivers {
+			if _, ok := channels[name]; ok {
+				routed[name] = append(routed[name], alert)
+			}
+		}
+	}
+	return channels, routed, nil
+}
+
+// deliver sends the alerts to the delivered channels in the background so
+// that the slow providers don't hold the evaluation of the rules
+func (d *channelDelivery) deliver(ctx context.Context, alerts []*Alert) {
+	if d == nil || len(alerts) == 0 {
+		return
+	}
+	channels, routed, err := d.channels(ctx, alerts)
+	if err != nil {
+		zap.L().Error("failed to fetch the channels", zap.Error(err))
+		return
+	}
+	if len(routed) == 0 {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		for name, channelAlerts := range routed {
+			channel := channels[name]
+			for _, sender := range channelSenders {
+				if !sender.configured(channel.receiver) {
+					continue
+				}
+				if err := sender.send(ctx, d, channel, channelAlerts); err != nil {
+					zap.L().Error("failed to deliver the alerts", zap.String("channel", name), zap.Int("alerts", len(channelAlerts)), zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// renderAlertTemplate renders the template of the channel for the single alert
+func (c *deliveredChannel) renderAlertTemplate(alert *Alert, format ChannelTemplateFormat) (*RenderedChannelTemplate, error) {
+	if c.template == nil {
+		return nil, nil
+	}
+	t := *c.template
+	t.Format = format
+	rendered, err := t.render(newNotificationData(c.name, []*Alert{alert}).localize(c.receiver))
+	if err != nil {
+		return nil, fmt.Errorf("channel %s: %w", c.name, err)
+	}
+	return rendered, nil
+}
@@ -0,0 +1,659 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	html_template "html/template"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	text_template "text/template"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// ChannelTemplateFormat is how the body of the channel template is rendered
+type ChannelTemplateFormat string
+
+const (
+	ChannelTemplateText ChannelTemplateFormat = "text"
+	ChannelTemplateHTML ChannelTemplateFormat = "html"
+	ChannelTemplateJSON ChannelTemplateFormat = "json"
+)
+
+// defaultTimeFormat is the format of the times shown by the channels without a time format
+const defaultTimeFormat = "2006-01-02 15:04:05 MST"
+
+var (
+	ErrChannelTemplateNotSupported = errors.New("the channel type does not support templates")
+	ErrInvalidChannelTemplate      = errors.New("invalid channel template")
+)
+
+// channelTemplateFormats are the formats of the channel types supporting templates
+var channelTemplateFormats = map[string]ChannelTemplateFormat{
+	"slack":    ChannelTemplateText,
+	"msteams":  ChannelTemplateText,
+	"opsgenie": ChannelTemplateText,
+	"email":    ChannelTemplateHTML,
+	"webhook":  ChannelTemplateJSON,
+	// the delivered channels render the template themselves
+	"pagerduty_v2": ChannelTemplateText,
+	"smtp":         ChannelTemplateHTML,
+	"twilio":       ChannelTemplateText,
+	"jira":         ChannelTemplateText,
+}
+
+// ChannelTemplate is the message sent to the channel in place of the default one. The
+// title and body are go templates executed with the same data as the alertmanager
+// templates: .Status, .Alerts, .GroupLabels, .CommonLabels, .CommonAnnotations
+type ChannelTemplate struct {
+	ChannelId int64                 `json:"channelId" db:"channel_id"`
+	Format    ChannelTemplateFormat `json:"format" db:"-"`
+	Title     string                `json:"title" db:"title"`
+	Body      string                `json:"body" db:"body"`
+	UpdatedAt time.Time             `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string                `json:"updatedBy" db:"updated_by"`
+}
+
+// RenderedChannelTemplate is the message the channel template renders for the alerts
+type RenderedChannelTemplate struct {
+	Format ChannelTemplateFormat `json:"format"`
+	Title  string                `json:"title"`
+	Body   string                `json:"body"`
+}
+
+// SampleAlert is an alert the channel template is previewed with
+type SampleAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	Resolved    bool              `json:"resolved"`
+}
+
+// ChannelTemplatePreview is the template rendered against the sample alerts, the
+// stored template is rendered when the title and body are empty
+type ChannelTemplatePreview struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Alerts are a firing critical alert when empty
+	Alerts []SampleAlert `json:"alerts"`
+}
+
+// notificationKV are the labels or annotations of the notification data
+type notificationKV map[string]string
+
+type notificationPair struct {
+	Name  string
+	Value string
+}
+
+// SortedPairs returns the pairs sorted by name
+func (kv notificationKV) SortedPairs() []notificationPair {
+	pairs := make([]notificationPair, 0, len(kv))
+	for _, name := range kv.Names() {
+		pairs = append(pairs, notificationPair{Name: name, Value: kv[name]})
+	}
+	return pairs
+}
+
+// Names returns the sorted names
+func (kv notificationKV) Names() []string {
+	names := make([]string, 0, len(kv))
+	for name := range kv {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Values returns the values sorted by name
+func (kv notificationKV) Values() []string {
+	values := make([]string, 0, len(kv))
+	for _, name := range kv.Names() {
+		values = append(values, kv[name])
+	}
+	return values
+}
+
+// Remove returns a copy without the names
+func (kv notificationKV) Remove(names []string) notificationKV {
+	copied := notificationKV{}
+	for name, value := range kv {
+		copied[name] = value
+	}
+	for _, name := range names {
+		delete(copied, name)
+	}
+	return copied
+}
+
+type notificationAlert struct {
+	Status       string
+	Labels       notificationKV
+	Annotations  notificationKV
+	Value        float64
+	StartsAt     time.Time
+	EndsAt       time.Time
+	GeneratorURL string
+	Fingerprint  string
+	// ChartURL is the url of the chart of the firing alert, the inline image of the emails
+	ChartURL html_template.URL
+}
+
+type notificationAlerts []notificationAlert
+
+// Firing returns the firing alerts
+func (as notificationAlerts) Firing() []notificationAlert {
+	return as.withStatus("firing")
+}
+
+// Resolved returns the resolved alerts
+func (as notificationAlerts) Resolved() []notificationAlert {
+	return as.withStatus("resolved")
+}
+
+func (as notificationAlerts) withStatus(status string) []notificationAlert {
+	alerts := []notificationAlert{}
+	for _, a := range as {
+		if a.Status == status {
+			alerts = append(alerts, a)
+		}
+	}
+	return alerts
+}
+
+// notificationData is the data the channel templates are executed with, it
+// mirrors the data of the alertmanager templates
+type notificationData struct {
+	Receiver          string
+	Status            string
+	Alerts            notificationAlerts
+	GroupLabels       notificationKV
+	CommonLabels      notificationKV
+	CommonAnnotations notificationKV
+	ExternalURL       string
+	// Timezone and TimeFormat are how the channel shows the times, the times of the
+	// alerts are in the timezone
+	Timezone   string
+	TimeFormat string
+
+	location *time.Location
+}
+
+func newNotificationData(receiver string, alerts []*Alert) *notificationData {
+	data := &notificationData{
+		Receiver:          receiver,
+		Status:            "resolved",
+		GroupLabels:       notificationKV{},
+		CommonLabels:      notificationKV{},
+		CommonAnnotations: notificationKV{},
+		Timezone:          "UTC",
+		TimeFormat:        defaultTimeFormat,
+		location:          time.UTC,
+	}
+	for i, alert := range alerts {
+		a := notificationAlert{
+			Status:       "firing",
+			Labels:       notificationKV(labelsMap(alert.Labels)),
+			Annotations:  notificationKV(labelsMap(alert.Annotations)),
+			Value:        alert.Value,
+			StartsAt:     alert.FiredAt,
+			GeneratorURL: alert.GeneratorURL,
+			Fingerprint:  fmt.Sprintf("%016x", alert.Labels.Hash()),
+		}
+		if !alert.ResolvedAt.IsZero() {
+			a.Status, a.EndsAt = "resolved", alert.ResolvedAt
+		} else {
+			data.Status = "firing"
+			if alert.chart != nil {
+				a.ChartURL = html_template.URL(alert.chart.url)
+			}
+		}
+		data.Alerts = append(data.Alerts, a)
+
+		if i == 0 {
+			for name, value := range a.Labels {
+				data.CommonLabels[name] = value
+			}
+			for name, value := range a.Annotations {
+				data.CommonAnnotations[name] = value
+			}
+			continue
+		}
+		for name, value := range data.CommonLabels {
+			if a.Labels[name] != value {
+				delete(data.CommonLabels, name)
+			}
+		}
+		for name, value := range data.CommonAnnotations {
+			if a.Annotations[name] != value {
+				delete(data.CommonAnnotations, name)
+			}
+		}
+	}
+	if name, ok := data.CommonLabels[labels.AlertNameLabel]; ok {
+		data.GroupLabels[labels.AlertNameLabel] = name
+	}
+	return data
+}
+
+// localize shows the times of the data in the timezone and format of the channel,
+// the settings are validated when the channel is saved and ignored when invalid
+func (d *notificationData) localize(receiver *am.Receiver) *notificationData {
+	if receiver == nil {
+		return d
+	}
+	if receiver.Timezone != "" {
+		if location, err := time.LoadLocation(receiver.Timezone); err == nil {
+			d.Timezone, d.location = receiver.Timezone, location
+		}
+	}
+	if receiver.TimeFormat != "" {
+		d.TimeFormat = receiver.TimeFormat
+	}
+	for i := range d.Alerts {
+		d.Alerts[i].StartsAt = d.Alerts[i].StartsAt.In(d.location)
+		if !d.Alerts[i].EndsAt.IsZero() {
+			d.Alerts[i].EndsAt = d.Alerts[i].EndsAt.In(d.location)
+		}
+	}
+	return d
+}
+
+// FormatTime returns the time in the timezone and format of the channel
+func (d *notificationData) FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(d.location).Format(d.TimeFormat)
+}
+
+// FormatUnix returns the unix timestamp in the timezone and format of the channel,
+// the values above 1e11 are taken as milliseconds
+func (d *notificationData) FormatUnix(v interface{}) (string, error) {
+	var ts float64
+	switch value := v.(type) {
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid unix timestamp %q", value)
+		}
+		ts = parsed
+	case float64:
+		ts = value
+	case int:
+		ts = float64(value)
+	case int64:
+		ts = float64(value)
+	default:
+		return "", fmt.Errorf("invalid unix timestamp %v", v)
+	}
+	if math.Abs(ts) > 1e11 {
+		return d.FormatTime(time.UnixMilli(int64(ts))), nil
+	}
+	return d.FormatTime(time.Unix(0, int64(ts*1e9))), nil
+}
+
+// validateTimeSettings checks the timezone and time format of the channel
+func validateTimeSettings(receiver *am.Receiver) error {
+	if receiver.Timezone != "" {
+		if _, err := time.LoadLocation(receiver.Timezone); err != nil {
+			return fmt.Errorf("invalid channel timezone %q: %w", receiver.Timezone, err)
+		}
+	}
+	// the layout without any time elements prints itself
+	if receiver.TimeFormat != "" && time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(receiver.TimeFormat) == receiver.TimeFormat {
+		return fmt.Errorf("invalid channel time format %q, must be a go time layout e.g. 2006-01-02 15:04:05 MST", receiver.TimeFormat)
+	}
+	return nil
+}
+
+// labelsMap returns the labels as a map, the alerts of the tests may have no annotations
+func labelsMap(lbls labels.BaseLabels) map[string]string {
+	if lbls == nil {
+		return map[string]string{}
+	}
+	return lbls.Map()
+}
+
+// sampleAlerts converts the sample alerts of the preview, a firing critical
+// alert is used when there are none
+func sampleAlerts(samples []SampleAlert, now time.Time) []*Alert {
+	if len(samples) == 0 {
+		samples = []SampleAlert{{
+			Labels: map[string]string{
+				labels.AlertNameLabel: "High error rate",
+				"severity":            "critical",
+				"service.name":        "frontend",
+			},
+			Annotations: map[string]string{
+				labels.AlertSummaryLabel: "The error rate of frontend is above 5%",
+				"description":            "The error rate of frontend is 7.5% which is above 5%",
+			},
+		}}
+	}
+
+	alerts := make([]*Alert, 0, len(samples))
+	for _, sample := range samples {
+		alert := &Alert{
+			Labels:      labels.FromMap(sample.Labels),
+			Annotations: labels.FromMap(sample.Annotations),
+			FiredAt:     now.Add(-5 * time.Minute),
+		}
+		if sample.Resolved {
+			alert.ResolvedAt = now
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// channelTemplateFuncs are the functions of the alertmanager templates
+var channelTemplateFuncs = map[string]interface{}{
+	"toUpper":   strings.ToUpper,
+	"toLower":   strings.ToLower,
+	"trimSpace": strings.TrimSpace,
+	"title":     cases.Title(language.Und).String,
+	"join": func(sep string, s []string) string {
+		return strings.Join(s, sep)
+	},
+	"match": regexp.MatchString,
+	"reReplaceAll": func(pattern, repl, text string) string {
+		re := regexp.MustCompile(pattern)
+		return re.ReplaceAllString(text, repl)
+	},
+	"safeHtml": func(text string) html_template.HTML {
+		return html_template.HTML(text)
+	},
+	"stringSlice": func(s ...string) []string {
+		return s
+	},
+}
+
+// render executes the template with the data, the html body is escaped and
+// the json body must be valid json
+func (t *ChannelTemplate) render(data *notificationData) (*RenderedChannelTemplate, error) {
+	rendered := &RenderedChannelTemplate{Format: t.Format}
+
+	title, err := text_template.New("title").Funcs(channelTemplateFuncs).Parse(t.Title)
+	if err != nil {
+		return nil, fmt.Errorf("%w: title: %v", ErrInvalidChannelTemplate, err)
+	}
+	var b bytes.Buffer
+	if err := title.Execute(&b, data); err != nil {
+		return nil, fmt.Errorf("%w: title: %v", ErrInvalidChannelTemplate, err)
+	}
+	rendered.Title = b.String()
+
+	b.Reset()
+	if t.Format == ChannelTemplateHTML {
+		body, err := html_template.New("body").Funcs(channelTemplateFuncs).Parse(t.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: body: %v", ErrInvalidChannelTemplate, err)
+		}
+		err = body.Execute(&b, data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: body: %v", ErrInvalidChannelTemplate, err)
+		}
+	} else {
+		body, err := text_template.New("body").Funcs(channelTemplateFuncs).Parse(t.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%w: body: %v", ErrInvalidChannelTemplate, err)
+		}
+		err = body.Execute(&b, data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: body: %v", ErrInvalidChannelTemplate, err)
+		}
+	}
+	rendered.Body = b.String()
+
+	if t.Format == ChannelTemplateJSON && !json.Valid(b.Bytes()) {
+		return nil, fmt.Errorf("%w: the body does not render valid json", ErrInvalidChannelTemplate)
+	}
+	return rendered, nil
+}
+
+// applyTo sets the template on the configs of the receiver, the alertmanager renders it
+// when the notification is sent. The webhook payload is not templated by the alertmanager
+// so the json template is only kept for the deliveries rendering it.
+func (t *ChannelTemplate) applyTo(receiver *am.Receiver) {
+	set := func(configs interface{}, fields func(config map[string]interface{})) {
+		list, _ := configs.([]interface{})
+		for _, config := range list {
+			if c, ok := config.(map[string]interface{}); ok {
+				fields(c)
+			}
+		}
+	}
+
+	set(receiver.SlackConfigs, func(c map[string]interface{}) {
+		c["title"], c["text"] = t.Title, t.Body
+	})
+	set(receiver.MSTeamsConfigs, func(c map[string]interface{}) {
+		c["title"], c["text"] = t.Title, t.Body
+	})
+	set(receiver.OpsGenieConfigs, func(c map[string]interface{}) {
+		c["message"], c["description"] = t.Title, t.Body
+	})
+	set(receiver.EmailConfigs, func(c map[string]interface{}) {
+		headers, _ := c["headers"].(map[string]interface{})
+		if headers == nil {
+			headers = map[string]interface{}{}
+		}
+		headers["Subject"] = t.Title
+		c["headers"], c["html"] = headers, t.Body
+	})
+}
+
+// channelReceiver returns the receiver of the channel with the template applied
+func channelReceiver(channel *model.ChannelItem, t *ChannelTemplate) (*am.Receiver, error) {
+	receiver := &am.Receiver{}
+	if err := json.Unmarshal([]byte(channel.Data), receiver); err != nil {
+		return nil, err
+	}
+	if t != nil {
+		t.applyTo(receiver)
+	}
+	return receiver, nil
+}
+
+func (r *ruleDB) GetChannelTemplate(ctx context.Context, channelId int64) (*ChannelTemplate, error) {
+	t := &ChannelTemplate{}
+	err := r.Get(t, `SELECT channel_id, title, body, updated_at, COALESCE(updated_by, '') AS updated_by FROM notification_templates WHERE channel_id=$1;`, channelId)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// SetChannelTemplate stores the template of the channel and updates the route of the channel
+func (r *ruleDB) SetChannelTemplate(ctx context.Context, channel *model.ChannelItem, t ChannelTemplate) error {
+	receiver, err := channelReceiver(channel, &t)
+	if err != nil {
+		return err
+	}
+
+	before := ""
+	if existing, err := r.GetChannelTemplate(ctx, int64(channel.Id)); err == nil {
+		data, _ := json.Marshal(existing)
+		before = string(data)
+	}
+
+	t.ChannelId = int64(channel.Id)
+	t.UpdatedAt = time.Now().UTC()
+	t.UpdatedBy = auditActor(ctx)
+
+	tx, err := r.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.NamedExec(`INSERT INTO notification_templates (channel_id, title, body, updated_at, updated_by)
+		VALUES (:channel_id, :title, :body, :updated_at, :updated_by)
+		ON CONFLICT(channel_id) DO UPDATE SET title=excluded.title, body=excluded.body, updated_at=excluded.updated_at, updated_by=excluded.updated_by`, t); err != nil {
+		zap.L().Error("Error in Executing INSERT to notification_templates", zap.Error(err))
+		return err
+	}
+
+	after, _ := json.Marshal(t)
+	if err := addAuditLog(ctx, tx, AuditResourceChannel, fmt.Sprintf("%d", channel.Id), AuditActionEdit, before, string(after)); err != nil {
+		return err
+	}
+
+	if err := r.editRoute(receiver); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteChannelTemplate removes the template of the channel so that the channel
+// sends the default message again
+func (r *ruleDB) DeleteChannelTemplate(ctx context.Context, channel *model.ChannelItem) error {
+	existing, err := r.GetChannelTemplate(ctx, int64(channel.Id))
+	if err != nil {
+		return err
+	}
+	receiver, err := channelReceiver(channel, nil)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM notification_templates WHERE channel_id=$1;`, channel.Id); err != nil {
+		zap.L().Error("Error in Executing DELETE to notification_templates", zap.Error(err))
+		return err
+	}
+
+	before, _ := json.Marshal(existing)
+	if err := addAuditLog(ctx, tx, AuditResourceChannel, fmt.Sprintf("%d", channel.Id), AuditActionEdit, string(before), ""); err != nil {
+		return err
+	}
+
+	if err := r.editRoute(receiver); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// editRoute updates the route of the receiver in the alertmanager
+func (r *ruleDB) editRoute(receiver *am.Receiver) error {
+	if r.alertManager == nil {
+		return nil
+	}
+	if apiErr := r.alertManager.EditRoute(receiver); apiErr != nil {
+		return apiErr.Err
+	}
+	return nil
+}
+
+// channelTemplate returns the template of the channel, nil when the channel has none
+func (r *ruleDB) channelTemplate(ctx context.Context, channelId int) (*ChannelTemplate, error) {
+	t, err := r.GetChannelTemplate(ctx, int64(channelId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return t, err
+}
+
+// channelTemplateFormat returns the format of the templates of the channel type
+func channelTemplateFormat(channelType string) (ChannelTemplateFormat, error) {
+	format, ok := channelTemplateFormats[channelType]
+	if !ok {
+		return "", ErrChannelTemplateNotSupported
+	}
+	return format, nil
+}
+
+// GetChannelTemplate returns the template of the channel
+func (m *Manager) GetChannelTemplate(ctx context.Context, id string) (*ChannelTemplate, error) {
+	channel, apiErr := m.ruleDB.GetChannel(id)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	t, err := m.ruleDB.GetChannelTemplate(ctx, int64(channel.Id))
+	if err != nil {
+		return nil, err
+	}
+	t.Format, _ = channelTemplateFormat(channel.Type)
+	return t, nil
+}
+
+// SetChannelTemplate validates the template by rendering it against the sample
+// alert and stores it, the template is used for the next notifications
+func (m *Manager) SetChannelTemplate(ctx context.Context, id string, t ChannelTemplate) (*ChannelTemplate, error) {
+	channel, apiErr := m.ruleDB.GetChannel(id)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	format, err := channelTemplateFormat(channel.Type)
+	if err != nil {
+		return nil, err
+	}
+	t.Format = format
+	receiver, err := channelReceiver(channel, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := t.render(newNotificationData(channel.Name, sampleAlerts(nil, time.Now())).localize(receiver)); err != nil {
+		return nil, err
+	}
+
+	if err := m.ruleDB.SetChannelTemplate(ctx, channel, t); err != nil {
+		return nil, err
+	}
+	return m.GetChannelTemplate(ctx, id)
+}
+
+// DeleteChannelTemplate restores the default message of the channel
+func (m *Manager) DeleteChannelTemplate(ctx context.Context, id string) error {
+	channel, apiErr := m.ruleDB.GetChannel(id)
+	if apiErr != nil {
+		return apiErr.Err
+	}
+	return m.ruleDB.DeleteChannelTemplate(ctx, channel)
+}
+
+// PreviewChannelTemplate renders the template of the preview, or the stored
+// template of the channel, against the sample alerts
+func (m *Manager) PreviewChannelTemplate(ctx context.Context, id string, preview ChannelTemplatePreview) (*RenderedChannelTemplate, error) {
+	channel, apiErr := m.ruleDB.GetChannel(id)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	format, err := channelTemplateFormat(channel.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &ChannelTemplate{Title: preview.Title, Body: preview.Body}
+	if preview.Title == "" && preview.Body == "" {
+		t, err = m.ruleDB.GetChannelTemplate(ctx, int64(channel.Id))
+		if err != nil {
+			return nil, err
+		}
+	}
+	t.Format = format
+	receiver, err := channelReceiver(channel, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.render(newNotificationData(channel.Name, sampleAlerts(preview.Alerts, time.Now())).localize(receiver))
+}
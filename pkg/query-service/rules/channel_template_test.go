@@ -0,0 +1,150 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// routeRecorder is the alertmanager keeping the last route edited
+type routeRecorder struct {
+	am.Manager
+	edited *am.Receiver
+}
+
+func (r *routeRecorder) EditRoute(receiver *am.Receiver) *model.ApiError {
+	r.edited = receiver
+	return nil
+}
+
+func TestRenderChannelTemplate(t *testing.T) {
+	data := newNotificationData("slack", sampleAlerts([]SampleAlert{
+		{Labels: map[string]string{"alertname": "Latency", "service.name": "cart"}, Annotations: map[string]string{"summary": "<b>slow</b>"}},
+		{Labels: map[string]string{"alertname": "Latency", "service.name": "checkout"}, Resolved: true},
+	}, time.Now()))
+
+	cases := []struct {
+		name     string
+		template ChannelTemplate
+		title    string
+		body     string
+		err      bool
+	}{
+		{
+			name:     "slack",
+			template: ChannelTemplate{Format: ChannelTemplateText, Title: `[{{ .Status | toUpper }}] {{ .CommonLabels.alertname }}`, Body: `{{ range .Alerts.Firing }}{{ index .Labels "service.name" }} {{ .Annotations.summary }}{{ end }}`},
+			title:    "[FIRING] Latency",
+			body:     "cart <b>slow</b>",
+		},
+		{
+			name:     "email",
+			template: ChannelTemplate{Format: ChannelTemplateHTML, Title: `{{ len .Alerts.Resolved }} resolved`, Body: `<p>{{ range .Alerts }}{{ .Annotations.summary }}{{ end }}</p>`},
+			title:    "1 resolved",
+			body:     "<p>&lt;b&gt;slow&lt;/b&gt;</p>",
+		},
+		{
+			name:     "webhook",
+			template: ChannelTemplate{Format: ChannelTemplateJSON, Body: `{"alerts": [{{ range $i, $a := .Alerts }}{{ if $i }},{{ end }}"{{ $a.Fingerprint }}"{{ end }}], "labels": "{{ join "," .CommonLabels.Names }}"}`},
+			body:     `{"alerts": ["` + data.Alerts[0].Fingerprint + `","` + data.Alerts[1].Fingerprint + `"], "labels": "alertname"}`,
+		},
+		{
+			name:     "invalid json",
+			template: ChannelTemplate{Format: ChannelTemplateJSON, Body: `{"status": {{ .Status }}}`},
+			err:      true,
+		},
+		{
+			name:     "invalid template",
+			template: ChannelTemplate{Format: ChannelTemplateText, Title: `{{ .Status`},
+			err:      true,
+		},
+	}
+	for _, c := range cases {
+		rendered, err := c.template.render(data)
+		if c.err {
+			assert.ErrorIs(t, err, ErrInvalidChannelTemplate, c.name)
+			continue
+		}
+		assert.NoError(t, err, c.name)
+		assert.Equal(t, c.title, rendered.Title, c.name)
+		assert.Equal(t, c.body, rendered.Body, c.name)
+	}
+}
+
+func TestChannelTemplate(t *testing.T) {
+	routes := &routeRecorder{}
+	db := newRuleDB(utils.NewQueryServiceDBForTests(t), routes, nil)
+	m := &Manager{ruleDB: db}
+	ctx := context.Background()
+
+	_, err := db.Exec(`INSERT INTO notification_channels (created_at, updated_at, name, type, data) VALUES($1,$1,'slack','slack',$2),($1,$1,'pagerduty','pagerduty','{}');`,
+		time.Now(), `{"name":"slack","slack_configs":[{"channel":"#alerts","title":"default"}]}`)
+	assert.NoError(t, err)
+
+	_, err = m.GetChannelTemplate(ctx, "1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	_, err = m.SetChannelTemplate(ctx, "2", ChannelTemplate{Title: "{{ .Status }}"})
+	assert.ErrorIs(t, err, ErrChannelTemplateNotSupported)
+	_, err = m.SetChannelTemplate(ctx, "1", ChannelTemplate{Title: "{{ .Missing }}"})
+	assert.ErrorIs(t, err, ErrInvalidChannelTemplate)
+
+	stored, err := m.SetChannelTemplate(ctx, "1", ChannelTemplate{Title: "{{ .CommonLabels.alertname }}", Body: "{{ .CommonAnnotations.summary }}"})
+	assert.NoError(t, err)
+	assert.Equal(t, ChannelTemplateText, stored.Format)
+	assert.Equal(t, []interface{}{map[string]interface{}{"channel": "#alerts", "title": "{{ .CommonLabels.alertname }}", "text": "{{ .CommonAnnotations.summary }}"}}, routes.edited.SlackConfigs)
+
+	// the stored template is previewed when the preview has none
+	rendered, err := m.PreviewChannelTemplate(ctx, "1", ChannelTemplatePreview{})
+	assert.NoError(t, err)
+	assert.Equal(t, "High error rate", rendered.Title)
+	rendered, err = m.PreviewChannelTemplate(ctx, "1", ChannelTemplatePreview{Title: "{{ .Receiver }}", Alerts: []SampleAlert{{Resolved: true}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "slack", rendered.Title)
+
+	// the edited channel keeps the template
+	_, apiErr := db.EditChannel(ctx, &am.Receiver{Name: "slack", SlackConfigs: []interface{}{map[string]interface{}{"channel": "#oncall"}}}, "1")
+	assert.Nil(t, apiErr)
+	assert.Equal(t, []interface{}{map[string]interface{}{"channel": "#oncall", "title": "{{ .CommonLabels.alertname }}", "text": "{{ .CommonAnnotations.summary }}"}}, routes.edited.SlackConfigs)
+
+	assert.NoError(t, m.DeleteChannelTemplate(ctx, "1"))
+	assert.Equal(t, []interface{}{map[string]interface{}{"channel": "#oncall"}}, routes.edited.SlackConfigs)
+	_, err = m.GetChannelTemplate(ctx, "1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
+
+func TestChannelTimeSettings(t *testing.T) {
+	firedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	alerts := []*Alert{{Labels: labels.FromMap(map[string]string{"alertname": "Errors"}), FiredAt: firedAt}}
+
+	data := newNotificationData("email", alerts).localize(nil)
+	assert.Equal(t, "2024-05-01 10:00:00 UTC", data.FormatTime(firedAt))
+
+	receiver := &am.Receiver{Name: "email", Timezone: "Asia/Kolkata", TimeFormat: "02 Jan 2006 15:04 MST"}
+	data = newNotificationData("email", alerts).localize(receiver)
+	assert.Equal(t, "Asia/Kolkata", data.Alerts[0].StartsAt.Location().String())
+	assert.Equal(t, "01 May 2024 15:30 IST", data.FormatTime(firedAt))
+	for _, v := range []interface{}{"1714557600", 1714557600000.0, int64(1714557600)} {
+		formatted, err := data.FormatUnix(v)
+		assert.NoError(t, err)
+		assert.Equal(t, "01 May 2024 15:30 IST", formatted)
+	}
+	_, err := data.FormatUnix("yesterday")
+	assert.Error(t, err)
+
+	rendered, err := (&ChannelTemplate{Title: "{{ (index .Alerts 0).StartsAt.Format .TimeFormat }}", Body: defaultEmailBody, Format: ChannelTemplateHTML}).render(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "01 May 2024 15:30 IST", rendered.Title)
+	assert.Contains(t, rendered.Body, "<b>Since:</b> 01 May 2024 15:30 IST")
+
+	assert.NoError(t, validateTimeSettings(receiver))
+	assert.Error(t, validateTimeSettings(&am.Receiver{Timezone: "Mars/Olympus"}))
+	assert.Error(t, validateTimeSettings(&am.Receiver{TimeFormat: "YYYY-MM-DD"}))
+	assert.NotNil(t, validateDeliveredConfigs(&am.Receiver{Name: "email", Timezone: "Mars/Olympus"}))
+}
@@ -0,0 +1,256 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	// ChartURLAnnotation is the annotation of the firing alerts holding the url of their chart
+	ChartURLAnnotation = "chart_url"
+
+	chartWidth   = 480
+	chartHeight  = 160
+	chartPadding = 8
+	// chartRetention is how long the charts of the notifications can be fetched
+	chartRetention = 7 * 24 * time.Hour
+)
+
+var (
+	chartBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	chartLine       = color.RGBA{R: 0x4e, G: 0x74, B: 0xf8, A: 0xff}
+	chartThreshold  = color.RGBA{R: 0xe5, G: 0x48, B: 0x4d, A: 0xff}
+)
+
+// AlertSeries is the series of the alert from the query the rule ran when the
+// alert was last evaluated, and the threshold it was compared to
+type AlertSeries struct {
+	Points    []Point
+	Threshold float64
+}
+
+// ChartSnapshot is the chart of the alert rendered for a notification
+type ChartSnapshot struct {
+	Id          string    `json:"id" db:"id"`
+	RuleId      string    `json:"ruleId" db:"rule_id"`
+	Fingerprint string    `json:"fingerprint" db:"fingerprint"`
+	Image       []byte    `json:"-" db:"image"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+}
+
+// alertChart is the chart attached to the notification of the alert
+type alertChart struct {
+	// url is the url the chart is served at, empty when the alert has no generator url
+	url   string
+	image []byte
+}
+
+func (r *ruleDB) CreateChartSnapshot(ctx context.Context, snapshot ChartSnapshot) error {
+	snapshot.CreatedAt = snapshot.CreatedAt.UTC()
+	_, err := r.NamedExec(`INSERT INTO chart_snapshots (id, rule_id, fingerprint, image, created_at)
+		VALUES (:id, :rule_id, :fingerprint, :image, :created_at)`, snapshot)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to chart_snapshots", zap.Error(err))
+	}
+	return err
+}
+
+func (r *ruleDB) GetChartSnapshot(ctx context.Context, id string) (*ChartSnapshot, error) {
+	snapshot := &ChartSnapshot{}
+	if err := r.Get(snapshot, "SELECT id, rule_id, fingerprint, image, created_at FROM chart_snapshots WHERE id=$1", id); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func (r *ruleDB) PurgeChartSnapshots(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.Exec("DELETE FROM chart_snapshots WHERE created_at < $1", before.UTC())
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE from chart_snapshots", zap.Error(err))
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// newAlertSeries returns the series of the sample compared to the threshold, nil
+// when the sample has too few points to draw
+func newAlertSeries(points []Point, threshold float64) *AlertSeries {
+	if len(points) < 2 {
+		return nil
+	}
+	return &AlertSeries{Points: points, Threshold: threshold}
+}
+
+// chartURL returns the url of the chart on the host of the generator url of the alert,
+// the host serves the api of SigNoz
+func chartURL(generatorURL, id string) string {
+	u, err := url.Parse(generatorURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s://%s/api/v1/charts/%s", u.Scheme, u.Host, id)
+}
+
+// renderChart draws the series as a sparkline with the threshold as a dashed line
+func renderChart(series *AlertSeries) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	for x := 0; x < chartWidth; x++ {
+		for y := 0; y < chartHeight; y++ {
+			img.Set(x, y, chartBackground)
+		}
+	}
+
+	var points []Point
+	for _, p := range series.Points {
+		if !math.IsNaN(p.V) && !math.IsInf(p.V, 0) {
+			points = append(points, p)
+		}
+	}
+	if len(points) < 2 {
+		return nil, fmt.Errorf("the series has less than 2 points to draw")
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].T < points[j].T })
+
+	minT, maxT := points[0].T, points[0].T
+	minV, maxV := series.Threshold, series.Threshold
+	for _, p := range points {
+		minT, maxT = min(minT, p.T), max(maxT, p.T)
+		minV, maxV = math.Min(minV, p.V), math.Max(maxV, p.V)
+	}
+	if maxT == minT {
+		maxT = minT + 1
+	}
+	// the margin keeps the flat series and the threshold off the edges
+	margin := (maxV - minV) * 0.1
+	if margin == 0 {
+		margin = math.Max(math.Abs(maxV)*0.1, 1)
+	}
+	minV, maxV = minV-margin, maxV+margin
+
+	toX := func(t int64) int {
+		return chartPadding + int(float64(t-minT)/float64(maxT-minT)*float64(chartWidth-2*chartPadding-1))
+	}
+	toY := func(v float64) int {
+		return chartHeight - 1 - chartPadding - int((v-minV)/(maxV-minV)*float64(chartHeight-2*chartPadding-1))
+	}
+
+	thresholdY := toY(series.Threshold)
+	for x := chartPadding; x < chartWidth-chartPadding; x++ {
+		if (x/6)%2 == 0 {
+			img.Set(x, thresholdY, chartThreshold)
+		}
+	}
+	for i := 1; i < len(points); i++ {
+		drawLine(img, toX(points[i-1].T), toY(points[i-1].V), toX(points[i].T), toY(points[i].V), chartLine)
+	}
+	// the last point is the value the rule alerted on
+	last := points[len(points)-1]
+	x, y := toX(last.T), toY(last.V)
+	for dx := -3; dx <= 3; dx++ {
+		for dy := -3; dy <= 3; dy++ {
+			if dx*dx+dy*dy <= 9 {
+				img.Set(x+dx, y+dy, chartLine)
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// drawLine draws the 2px wide line between the points with the Bresenham algorithm
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		img.Set(x0+1, y0, c)
+		img.Set(x0, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// attachCharts renders the charts of the firing alerts about to be notified, the
+// charts are stored to be served at the url set in the chart url annotation and
+// embedded in the emails delivered by the query service
+func (m *Manager) attachCharts(ctx context.Context, alerts []*Alert) {
+	now := time.Now()
+	stored := false
+	for _, alert := range alerts {
+		if alert.Series == nil || !alert.ResolvedAt.IsZero() {
+			continue
+		}
+		rendered, err := renderChart(alert.Series)
+		if err != nil {
+			zap.L().Debug("failed to render the chart of the alert", zap.Error(err))
+			continue
+		}
+		alert.chart = &alertChart{image: rendered}
+
+		snapshot := ChartSnapshot{
+			Id:          uuid.New().String(),
+			RuleId:      alert.Labels.Get(labels.AlertRuleIdLabel),
+			Fingerprint: fmt.Sprintf("%016x", alert.Labels.Hash()),
+			Image:       rendered,
+			CreatedAt:   now,
+		}
+		link := chartURL(alert.GeneratorURL, snapshot.Id)
+		if link == "" {
+			continue
+		}
+		if err := m.ruleDB.CreateChartSnapshot(ctx, snapshot); err != nil {
+			continue
+		}
+		stored = true
+		alert.chart.url = link
+		annotations := labelsMap(alert.Annotations)
+		if _, ok := annotations[ChartURLAnnotation]; !ok {
+			annotations[ChartURLAnnotation] = link
+			alert.Annotations = labels.FromMap(annotations)
+		}
+	}
+
+	if stored {
+		if _, err := m.ruleDB.PurgeChartSnapshots(ctx, now.Add(-chartRetention)); err != nil {
+			zap.L().Error("failed to purge the expired charts", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"io"
+	"math"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestRenderChart(t *testing.T) {
+	series := &AlertSeries{Threshold: 5, Points: []Point{{T: 3000, V: 7}, {T: 1000, V: 2}, {T: 2000, V: math.NaN()}, {T: 4000, V: 8}}}
+	data, err := renderChart(series)
+	assert.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, chartWidth, img.Bounds().Dx())
+	assert.Equal(t, chartHeight, img.Bounds().Dy())
+
+	_, err = renderChart(&AlertSeries{Points: []Point{{T: 1000, V: 2}, {T: 2000, V: math.Inf(1)}}})
+	assert.Error(t, err)
+	assert.Nil(t, newAlertSeries([]Point{{T: 1000, V: 2}}, 5))
+}
+
+func TestChartURL(t *testing.T) {
+	assert.Equal(t, "https://signoz.example.com/api/v1/charts/abc", chartURL("https://signoz.example.com/alerts/edit?ruleId=1", "abc"))
+	assert.Equal(t, "", chartURL("", "abc"))
+	assert.Equal(t, "", chartURL("/alerts/edit?ruleId=1", "abc"))
+}
+
+func TestAttachCharts(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+	series := &AlertSeries{Threshold: 5, Points: []Point{{T: 1000, V: 2}, {T: 2000, V: 7}}}
+	firing := &Alert{
+		Labels:       labels.FromMap(map[string]string{"alertname": "Errors", "ruleId": "1"}),
+		Annotations:  labels.FromMap(map[string]string{"summary": "The error rate is 7%"}),
+		GeneratorURL: "https://signoz.example.com/alerts/edit?ruleId=1",
+		Series:       series,
+	}
+	// the alerts without a generator url only get the inline chart of the emails
+	unlinked := &Alert{Labels: labels.FromMap(map[string]string{"alertname": "Disk"}), Series: series}
+	resolved := &Alert{Labels: labels.FromMap(map[string]string{"alertname": "Latency"}), Series: series, ResolvedAt: time.Now()}
+
+	m.attachCharts(ctx, []*Alert{firing, unlinked, resolved})
+
+	link := firing.Annotations.Map()[ChartURLAnnotation]
+	assert.True(t, strings.HasPrefix(link, "https://signoz.example.com/api/v1/charts/"))
+	assert.Equal(t, "The error rate is 7%", firing.Annotations.Map()["summary"])
+	snapshot, err := m.ruleDB.GetChartSnapshot(ctx, strings.TrimPrefix(link, "https://signoz.example.com/api/v1/charts/"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1", snapshot.RuleId)
+	assert.Equal(t, firing.chart.image, snapshot.Image)
+
+	if assert.NotNil(t, unlinked.chart) {
+		assert.Empty(t, unlinked.chart.url)
+	}
+	assert.Nil(t, unlinked.Annotations)
+	assert.Nil(t, resolved.chart)
+
+	count, err := m.ruleDB.PurgeChartSnapshots(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestEmailInlineChart(t *testing.T) {
+	alert := &Alert{
+		Labels:  labels.FromMap(map[string]string{"alertname": "Errors"}),
+		FiredAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		chart:   &alertChart{url: "https://signoz.example.com/api/v1/charts/abc", image: []byte("png")},
+	}
+	inlined, images := inlineCharts([]*Alert{alert})
+	rendered, err := (&ChannelTemplate{Title: defaultEmailSubject, Body: defaultEmailBody, Format: ChannelTemplateHTML}).render(newNotificationData("email", inlined))
+	assert.NoError(t, err)
+	config := &SmtpConfig{To: "oncall@example.com", From: "alerts@example.com"}
+	data, err := config.message(rendered.Title, rendered.Body, time.Now(), images...)
+	assert.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	assert.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/related", mediaType)
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	part, err := reader.NextPart()
+	assert.NoError(t, err)
+	// the reader decodes the quoted-printable html
+	body, err := io.ReadAll(part)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `<img src="cid:`+images[0].contentId+`"`)
+
+	part, err = reader.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "<"+images[0].contentId+">", part.Header.Get("Content-Id"))
+	assert.Equal(t, "image/png", part.Header.Get("Content-Type"))
+	// the chart of the alert isn't changed by the email
+	assert.Equal(t, "https://signoz.example.com/api/v1/charts/abc", alert.chart.url)
+}
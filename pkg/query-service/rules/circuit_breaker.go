@@ -0,0 +1,244 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultBreakerThreshold is the number of consecutive datastore failures tripping the breaker
+	DefaultBreakerThreshold = 10
+	// DefaultBreakerCooldown is how long the evaluations are paused once the breaker tripped
+	DefaultBreakerCooldown = time.Minute
+
+	// breakerAlertName is the name of the meta alert sent while the breaker is open
+	breakerAlertName = "Rule evaluations paused: datastore failing"
+	// breakerAlertValidity is how long the meta alert stays firing without being sent again
+	breakerAlertValidity = 24 * time.Hour
+)
+
+// errQueryFailed is the failure of the datastore answering the queries of the rules
+var errQueryFailed = errors.New("internal error while querying")
+
+// CircuitState is the state of the breaker shared by the rule evaluations
+type CircuitState string
+
+const (
+	// CircuitClosed evaluates the rules
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen pauses the evaluations until the cooldown is over
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen evaluates a single rule probing whether the datastore recovered
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// ErrCircuitOpen is the error of the rules not evaluated while the breaker is open
+type ErrCircuitOpen struct {
+	Failures int
+	RetryAt  time.Time
+	Cause    string
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("evaluation paused after %d consecutive datastore failures, retrying at %s: %s", e.Failures, e.RetryAt.Format(time.RFC3339), e.Cause)
+}
+
+// CircuitBreakerStatus is the state of the breaker returned by the api
+type CircuitBreakerStatus struct {
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	Threshold           int          `json:"threshold"`
+	OpenedAt            *time.Time   `json:"openedAt,omitempty"`
+	RetryAt             *time.Time   `json:"retryAt,omitempty"`
+	LastError           string       `json:"lastError,omitempty"`
+}
+
+// circuitBreaker pauses the evaluation of all the rules once the datastore failed
+// the queries of consecutive evaluations, so the failing datastore isn't queried by
+// every rule. It's probed by one evaluation at a time once the cooldown is over
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	// onChange is called when the breaker trips and when it closes again
+	onChange func(status CircuitBreakerStatus)
+
+	mtx      sync.Mutex
+	state    CircuitState
+	failures int
+	lastErr  error
+	openedAt time.Time
+	retryAt  time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration, onChange func(status CircuitBreakerStatus)) *circuitBreaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, onChange: onChange, state: CircuitClosed}
+}
+
+// isDatastoreFailure reports whether the evaluation failed because of the datastore
+func isDatastoreFailure(err error) bool {
+	return errors.Is(err, errQueryFailed) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// allow reports whether the rule can be evaluated, all the evaluations are
+// allowed without a breaker. Once the cooldown is over the rules of the higher
+// priorities probe the datastore first, the others follow a fraction of the
+// cooldown later so they still probe when there are no such rules
+func (b *circuitBreaker) allow(now time.Time, priority RulePriority) error {
+	if b == nil {
+		return nil
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		probeDelay := b.cooldown * time.Duration(priority.rank()) / time.Duration(len(rulePriorities))
+		if now.Before(b.retryAt.Add(probeDelay)) {
+			return b.openError()
+		}
+		b.state, b.probing = CircuitHalfOpen, true
+		zap.L().Info("probing the datastore after the rule evaluations were paused")
+		return nil
+	case CircuitHalfOpen:
+		if b.probing {
+			return b.openError()
+		}
+		b.probing = true
+		return nil
+	}
+	return nil
+}
+
+// openError is called with the lock held
+func (b *circuitBreaker) openError() error {
+	cause := ""
+	if b.lastErr != nil {
+		cause = b.lastErr.Error()
+	}
+	return &ErrCircuitOpen{Failures: b.failures, RetryAt: b.retryAt, Cause: cause}
+}
+
+// record updates the breaker with the result of the allowed evaluation
+func (b *circuitBreaker) record(err error, now time.Time) {
+	if b == nil {
+		return
+	}
+	failed := isDatastoreFailure(err)
+
+	b.mtx.Lock()
+	changed := false
+	switch b.state {
+	case CircuitClosed:
+		if failed {
+			b.failures++
+			b.lastErr = err
+			if b.failures >= b.threshold {
+				b.state, b.openedAt, b.retryAt = CircuitOpen, now, now.Add(b.cooldown)
+				changed = true
+				zap.L().Error("pausing the rule evaluations, the datastore is failing", zap.Int("failures", b.failures), zap.Error(err))
+			}
+		} else if err == nil {
+			b.failures = 0
+		}
+	case CircuitHalfOpen:
+		b.probing = false
+		if failed {
+			// the meta alert is still firing, the breaker opens again without a new one
+			b.failures++
+			b.lastErr = err
+			b.state, b.retryAt = CircuitOpen, now.Add(b.cooldown)
+		} else if err == nil {
+			b.state, b.failures, b.lastErr = CircuitClosed, 0, nil
+			changed = true
+			zap.L().Info("resuming the rule evaluations, the datastore recovered")
+		}
+	}
+	status := b.statusLocked()
+	if changed {
+		// the resolved meta alert keeps the time the breaker tripped
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	b.mtx.Unlock()
+
+	if changed && b.onChange != nil {
+		b.onChange(status)
+	}
+}
+
+// status returns the state of the breaker
+func (b *circuitBreaker) status() CircuitBreakerStatus {
+	if b == nil {
+		return CircuitBreakerStatus{State: CircuitClosed}
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.statusLocked()
+}
+
+func (b *circuitBreaker) statusLocked() CircuitBreakerStatus {
+	status := CircuitBreakerStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Threshold:           b.threshold,
+	}
+	if b.state != CircuitClosed {
+		openedAt, retryAt := b.openedAt, b.retryAt
+		status.OpenedAt, status.RetryAt = &openedAt, &retryAt
+	}
+	if b.lastErr != nil {
+		status.LastError = b.lastErr.Error()
+	}
+	return status
+}
+
+// breakerAlert is the meta alert firing while the evaluations are paused
+func breakerAlert(status CircuitBreakerStatus, channels []string, now time.Time) *Alert {
+	alert := &Alert{
+		State: model.StateFiring,
+		Labels: labels.FromMap(map[string]string{
+			labels.AlertNameLabel: breakerAlertName,
+			"severity":            "critical",
+		}),
+		Annotations: labels.FromMap(map[string]string{
+			labels.AlertSummaryLabel:     fmt.Sprintf("The rule evaluations are paused after %d consecutive datastore failures", status.ConsecutiveFailures),
+			labels.AlertDescriptionLabel: status.LastError,
+		}),
+		Receivers:  channels,
+		ActiveAt:   now,
+		FiredAt:    now,
+		LastSentAt: now,
+		ValidUntil: now.Add(breakerAlertValidity),
+	}
+	if status.OpenedAt != nil {
+		alert.ActiveAt, alert.FiredAt = *status.OpenedAt, *status.OpenedAt
+	}
+	if status.State == CircuitClosed {
+		alert.State, alert.ResolvedAt = model.StateInactive, now
+	}
+	return alert
+}
+
+// notifyBreaker sends the meta alert when the breaker trips and resolves it when it closes
+func (m *Manager) notifyBreaker(status CircuitBreakerStatus) {
+	m.send(context.Background(), []*Alert{breakerAlert(status, m.opts.MetaAlertChannels, time.Now())})
+}
+
+// CircuitBreakerStatus returns the state of the breaker pausing the rule evaluations
+func (m *Manager) CircuitBreakerStatus() CircuitBreakerStatus {
+	return m.opts.breaker.status()
+}
@@ -0,0 +1,77 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var changes []CircuitBreakerStatus
+	breaker := newCircuitBreaker(3, time.Minute, func(status CircuitBreakerStatus) {
+		changes = append(changes, status)
+	})
+	now := time.Now()
+	failure := fmt.Errorf("%w: connection refused", errQueryFailed)
+
+	// the failures of the rules themselves don't trip the breaker
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, breaker.allow(now, RulePriorityCritical))
+		breaker.record(errors.New("invalid threshold"), now)
+	}
+	assert.Equal(t, CircuitClosed, breaker.status().State)
+
+	// a success resets the consecutive failures
+	breaker.record(failure, now)
+	breaker.record(failure, now)
+	breaker.record(nil, now)
+	assert.Equal(t, 0, breaker.status().ConsecutiveFailures)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, breaker.allow(now, RulePriorityCritical))
+		breaker.record(failure, now)
+	}
+	assert.Equal(t, CircuitOpen, breaker.status().State)
+	assert.Len(t, changes, 1)
+
+	var openErr *ErrCircuitOpen
+	assert.ErrorAs(t, breaker.allow(now.Add(30*time.Second), RulePriorityCritical), &openErr)
+	assert.Equal(t, 3, openErr.Failures)
+
+	// a single evaluation probes the datastore after the cooldown
+	later := now.Add(time.Minute)
+	assert.NoError(t, breaker.allow(later, RulePriorityCritical))
+	assert.Error(t, breaker.allow(later, RulePriorityCritical))
+	assert.Equal(t, CircuitHalfOpen, breaker.status().State)
+
+	// the failed probe pauses the evaluations again without another meta alert
+	breaker.record(failure, later)
+	assert.Equal(t, CircuitOpen, breaker.status().State)
+	assert.Len(t, changes, 1)
+
+	// the rules of the lower priorities probe later
+	later = later.Add(time.Minute)
+	assert.Error(t, breaker.allow(later, RulePriorityLow))
+	assert.NoError(t, breaker.allow(later, RulePriorityCritical))
+	breaker.record(nil, later)
+	assert.Equal(t, CircuitClosed, breaker.status().State)
+	assert.NoError(t, breaker.allow(later, RulePriorityCritical))
+	assert.Len(t, changes, 2)
+	assert.Equal(t, now, *changes[1].OpenedAt)
+
+	alert := breakerAlert(changes[1], []string{"oncall"}, later)
+	assert.Equal(t, model.StateInactive, alert.State)
+	assert.Equal(t, now, alert.FiredAt)
+	assert.Equal(t, []string{"oncall"}, alert.Receivers)
+}
+
+func TestCircuitBreakerNil(t *testing.T) {
+	var breaker *circuitBreaker
+	assert.NoError(t, breaker.allow(time.Now(), RulePriorityNormal))
+	breaker.record(errQueryFailed, time.Now())
+	assert.Equal(t, CircuitClosed, breaker.status().State)
+}
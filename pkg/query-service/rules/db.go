@@ -2,11 +2,14 @@ package rules
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -16,15 +19,25 @@ import (
 	"go.signoz.io/signoz/pkg/query-service/model"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	"go.uber.org/zap"
+	yaml "gopkg.in/yaml.v2"
 )
 
 // Data store to capture user alert rule settings
 type RuleDB interface {
 	GetChannel(id string) (*model.ChannelItem, *model.ApiError)
 	GetChannels() (*[]model.ChannelItem, *model.ApiError)
-	DeleteChannel(id string) *model.ApiError
-	CreateChannel(receiver *am.Receiver) (*am.Receiver, *model.ApiError)
-	EditChannel(receiver *am.Receiver, id string) (*am.Receiver, *model.ApiError)
+	DeleteChannel(ctx context.Context, id string) *model.ApiError
+	CreateChannel(ctx context.Context, receiver *am.Receiver) (*am.Receiver, *model.ApiError)
+	EditChannel(ctx context.Context, receiver *am.Receiver, id string) (*am.Receiver, *model.ApiError)
+
+	// GetChannelTemplate fetches the message template of the channel
+	GetChannelTemplate(ctx context.Context, channelId int64) (*ChannelTemplate, error)
+
+	// SetChannelTemplate stores the message template of the channel and applies it to the route
+	SetChannelTemplate(ctx context.Context, channel *model.ChannelItem, t ChannelTemplate) error
+
+	// DeleteChannelTemplate removes the message template of the channel from the db and the route
+	DeleteChannelTemplate(ctx context.Context, channel *model.ChannelItem) error
 
 	// CreateRuleTx stores rule in the db and returns tx and group name (on success)
 	CreateRuleTx(ctx context.Context, rule string) (int64, Tx, error)
@@ -32,15 +45,45 @@ type RuleDB interface {
 	// EditRuleTx updates the given rule in the db and returns tx and group name (on success)
 	EditRuleTx(ctx context.Context, rule string, id string) (string, Tx, error)
 
-	// DeleteRuleTx deletes the given rule in the db and returns tx and group name (on success)
+	// DeleteRuleTx moves the given rule to the trash and returns tx and group name (on success)
 	DeleteRuleTx(ctx context.Context, id string) (string, Tx, error)
 
+	// RestoreRuleTx restores the given rule from the trash and returns tx and group name (on success)
+	RestoreRuleTx(ctx context.Context, id string) (string, Tx, error)
+
+	// GetDeletedRules fetches the rules in the trash
+	GetDeletedRules(ctx context.Context) ([]StoredRule, error)
+
+	// PurgeDeletedRules permanently removes the rules deleted before the given time
+	PurgeDeletedRules(ctx context.Context, before time.Time) (int64, error)
+
 	// GetStoredRules fetches the rule definitions from db
 	GetStoredRules(ctx context.Context) ([]StoredRule, error)
 
+	// GetAllRuleIds fetches the ids of the rules of all the orgs, the rules in the trash included
+	GetAllRuleIds(ctx context.Context) ([]string, error)
+
+	// FilterStoredRules fetches the rule definitions matching the filter from db
+	FilterStoredRules(ctx context.Context, filter *StoredRuleFilter) ([]StoredRule, error)
+
+	// GetRuleFolders fetches the folders of the rules with the count of rules in them
+	GetRuleFolders(ctx context.Context) ([]RuleFolder, error)
+
 	// GetStoredRule for a given ID from DB
 	GetStoredRule(ctx context.Context, id string) (*StoredRule, error)
 
+	// GetStoredRuleByExternalID fetches the rule with the given external id from DB
+	GetStoredRuleByExternalID(ctx context.Context, externalID string) (*StoredRule, error)
+
+	// SetRuleExternalID stamps the external id on the rule created without one
+	SetRuleExternalID(ctx context.Context, id int, externalID string) error
+
+	// GetRuleVersions fetches all the versions of the rule, latest first
+	GetRuleVersions(ctx context.Context, id string) ([]RuleVersion, error)
+
+	// GetRuleVersion fetches the given version of the rule
+	GetRuleVersion(ctx context.Context, id string, version int) (*RuleVersion, error)
+
 	// CreatePlannedMaintenance stores a given maintenance in db
 	CreatePlannedMaintenance(ctx context.Context, maintenance PlannedMaintenance) (int64, error)
 
@@ -50,12 +93,228 @@ type RuleDB interface {
 	// GetPlannedMaintenanceByID fetches the maintenance definition from db by id
 	GetPlannedMaintenanceByID(ctx context.Context, id string) (*PlannedMaintenance, error)
 
+	// RecordMaintenanceSuppressions stores the alerts suppressed by the maintenance at the time
+	RecordMaintenanceSuppressions(ctx context.Context, suppressions []MaintenanceSuppression, ts time.Time, gap time.Duration) error
+
+	// GetMaintenanceSuppressions fetches the alerts suppressed by the maintenance matching the filter
+	GetMaintenanceSuppressions(ctx context.Context, filter *MaintenanceSuppressionFilter) ([]MaintenanceSuppression, error)
+
+	// PurgeExpiredPlannedMaintenance deletes the quick maintenance expired before the given time
+	PurgeExpiredPlannedMaintenance(ctx context.Context, before time.Time) (int64, error)
+
 	// EditPlannedMaintenance updates the given maintenance in the db
 	EditPlannedMaintenance(ctx context.Context, maintenance PlannedMaintenance, id string) (string, error)
 
 	// GetAllPlannedMaintenance fetches the maintenance definitions from db
 	GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMaintenance, error)
 
+	// SetMaintenanceCalendarToken replaces the calendar token of the org of the user
+	SetMaintenanceCalendarToken(ctx context.Context, token MaintenanceCalendarToken) error
+
+	// GetMaintenanceCalendarToken fetches the calendar token of the org of the user
+	GetMaintenanceCalendarToken(ctx context.Context) (*MaintenanceCalendarToken, error)
+
+	// GetMaintenanceCalendarTokenOrg fetches the org of the calendar token
+	GetMaintenanceCalendarTokenOrg(ctx context.Context, token string) (string, error)
+
+	// DeleteMaintenanceCalendarToken revokes the calendar token of the org of the user
+	DeleteMaintenanceCalendarToken(ctx context.Context) error
+
+	// CreateSilence stores the silence in the db
+	CreateSilence(ctx context.Context, silence Silence) (int64, error)
+
+	// GetSilenceByID fetches the silence from the db by id
+	GetSilenceByID(ctx context.Context, id string) (*Silence, error)
+
+	// GetSilences fetches the silences matching the filter, latest ending first
+	GetSilences(ctx context.Context, filter *SilenceFilter) ([]Silence, error)
+
+	// EditSilence updates the given silence in the db
+	EditSilence(ctx context.Context, silence Silence, id string) error
+
+	// ExpireSilence ends the silence at the given time
+	ExpireSilence(ctx context.Context, id string, ts time.Time) error
+
+	// CreateAlertAck stores the acknowledgement of the alerts
+	CreateAlertAck(ctx context.Context, ack AlertAck) (int64, error)
+
+	// GetAlertAcks fetches the acknowledgements made since the given time, latest first
+	GetAlertAcks(ctx context.Context, since time.Time) ([]AlertAck, error)
+
+	// QueueDelivery stores the failed delivery to be retried from the queue
+	QueueDelivery(ctx context.Context, delivery QueuedDelivery) (int64, error)
+
+	// GetQueuedDeliveries fetches the queued deliveries matching the filter, earliest due first
+	GetQueuedDeliveries(ctx context.Context, filter *QueuedDeliveryFilter) ([]QueuedDelivery, error)
+
+	// GetQueuedDelivery fetches the queued delivery by id
+	GetQueuedDelivery(ctx context.Context, id string) (*QueuedDelivery, error)
+
+	// UpdateQueuedDelivery stores the outcome of the retry of the queued delivery
+	UpdateQueuedDelivery(ctx context.Context, delivery QueuedDelivery) error
+
+	// DeleteQueuedDelivery drops the delivery from the queue
+	DeleteQueuedDelivery(ctx context.Context, id int64) error
+
+	// RecordDeliveryAttempt stores the request sent to the provider of the channel
+	RecordDeliveryAttempt(ctx context.Context, attempt DeliveryAttempt) error
+
+	// PurgeDeliveryAttempts deletes the delivery attempts made before the given time
+	PurgeDeliveryAttempts(ctx context.Context, before time.Time) (int64, error)
+
+	// GetDeliveryAttempts fetches the delivery attempts matching the filter, latest first
+	GetDeliveryAttempts(ctx context.Context, filter *DeliveryAttemptFilter) ([]DeliveryAttempt, error)
+
+	// RecordNotifications stores the notifications of the alerts sent to the channels
+	RecordNotifications(ctx context.Context, entries []NotificationLogEntry) error
+
+	// PurgeNotifications deletes the notifications logged before the given time
+	PurgeNotifications(ctx context.Context, before time.Time) (int64, error)
+
+	// CreateIncident stores the incident without its alerts
+	CreateIncident(ctx context.Context, incident Incident) (int64, error)
+
+	// GetIncident fetches the incident with its alerts and timeline
+	GetIncident(ctx context.Context, id string) (*Incident, error)
+
+	// GetIncidents fetches the incidents matching the filter, latest first
+	GetIncidents(ctx context.Context, filter *IncidentFilter) ([]Incident, error)
+
+	// UpdateIncident stores the changed incident
+	UpdateIncident(ctx context.Context, incident Incident) error
+
+	// DeleteIncident deletes the incident with its alerts and timeline
+	DeleteIncident(ctx context.Context, id int64) error
+
+	// SetIncidentAlert adds the alert to the incident or updates its state
+	SetIncidentAlert(ctx context.Context, alert IncidentAlert) error
+
+	// DeleteIncidentAlert removes the alert from the incident
+	DeleteIncidentAlert(ctx context.Context, incidentId int64, fingerprint string) error
+
+	// GetOpenIncidentAlerts fetches the alerts of the incidents that are not resolved
+	GetOpenIncidentAlerts(ctx context.Context) ([]IncidentAlert, error)
+
+	// AddIncidentEvents adds the events to the timelines of the incidents
+	AddIncidentEvents(ctx context.Context, events []IncidentEvent) error
+
+	// GetNotificationLog fetches the notifications matching the filter, latest first
+	GetNotificationLog(ctx context.Context, filter *NotificationLogFilter) ([]NotificationLogEntry, error)
+
+	// CountNotificationsByRule counts the notifications of the alerts of every rule sent in the time range
+	CountNotificationsByRule(ctx context.Context, start, end time.Time) (map[string]int, error)
+
+	// RecordAlertAckEvents stores when the firing alerts were first acknowledged
+	RecordAlertAckEvents(ctx context.Context, events []AlertAckEvent) error
+
+	// GetAlertAckStatsByRule returns the acknowledged alerts of every rule fired in the time range and the mean time to acknowledge them
+	GetAlertAckStatsByRule(ctx context.Context, start, end time.Time) (map[string]AlertAckStats, error)
+
+	// SaveStateRollups stores the rollups of the state history and how far the history is rolled up
+	SaveStateRollups(ctx context.Context, resolution RollupResolution, rolledUpTo time.Time, rollups []StateRollup) error
+
+	// GetStateRollupProgress returns how far the state history is rolled up, zero when it never was
+	GetStateRollupProgress(ctx context.Context, resolution RollupResolution) (time.Time, error)
+
+	// GetStateRollups fetches the rollups of the rule starting in the time range, the empty rule id fetches those of all the rules
+	GetStateRollups(ctx context.Context, ruleId string, resolution RollupResolution, start, end time.Time) ([]StateRollup, error)
+
+	// PurgeStateRollups deletes the rollups starting before the given time
+	PurgeStateRollups(ctx context.Context, resolution RollupResolution, before time.Time) error
+
+	// DeleteStateRollups deletes the rollups of the rules
+	DeleteStateRollups(ctx context.Context, ruleIds []string) error
+
+	// CreateFiringAnnotation stores the annotation of the firing period of the rule
+	CreateFiringAnnotation(ctx context.Context, annotation model.FiringAnnotation) (int64, error)
+
+	// GetFiringAnnotation fetches the annotation by id
+	GetFiringAnnotation(ctx context.Context, id string) (*model.FiringAnnotation, error)
+
+	// GetFiringAnnotations fetches the annotations of the firing periods of the rule starting in the time range in milliseconds
+	GetFiringAnnotations(ctx context.Context, ruleId string, start, end int64) ([]model.FiringAnnotation, error)
+
+	// UpdateFiringAnnotation changes the note of the annotation
+	UpdateFiringAnnotation(ctx context.Context, annotation model.FiringAnnotation) error
+
+	// DeleteFiringAnnotation deletes the annotation by id
+	DeleteFiringAnnotation(ctx context.Context, id string) error
+
+	// DeleteFiringAnnotationsOfRules deletes the annotations of the rules
+	DeleteFiringAnnotationsOfRules(ctx context.Context, ruleIds []string) error
+
+	// CreateTwilioCall stores the voice call made to the recipient
+	CreateTwilioCall(ctx context.Context, call TwilioCall) error
+
+	// GetTwilioCall fetches the voice call by id
+	GetTwilioCall(ctx context.Context, id string) (*TwilioCall, error)
+
+	// GetTwilioCalls fetches the voice calls matching the filter, latest first
+	GetTwilioCalls(ctx context.Context, filter *TwilioCallFilter) ([]TwilioCall, error)
+
+	// UpdateTwilioCallStatus stores the status of the voice call reported by twilio
+	UpdateTwilioCallStatus(ctx context.Context, call TwilioCall) error
+
+	// CreateJiraIssue stores the issue opened for the alert
+	CreateJiraIssue(ctx context.Context, issue JiraIssue) error
+
+	// GetOpenJiraIssue fetches the issue of the alert in the channel that is not resolved yet
+	GetOpenJiraIssue(ctx context.Context, channel, fingerprint string) (*JiraIssue, error)
+
+	// ResolveJiraIssue marks the issue resolved at the given time
+	ResolveJiraIssue(ctx context.Context, id int64, ts time.Time) error
+
+	// CreateChartSnapshot stores the chart rendered for the notification of the alert
+	CreateChartSnapshot(ctx context.Context, snapshot ChartSnapshot) error
+
+	// GetChartSnapshot fetches the chart by id
+	GetChartSnapshot(ctx context.Context, id string) (*ChartSnapshot, error)
+
+	// PurgeChartSnapshots deletes the charts created before the given time
+	PurgeChartSnapshots(ctx context.Context, before time.Time) (int64, error)
+
+	// SaveAlertSnapshots replaces the saved active alerts of the rules with the given ones
+	SaveAlertSnapshots(ctx context.Context, ruleIds []string, snapshots []AlertSnapshot) error
+
+	// GetAlertSnapshots fetches the saved active alerts of the rule
+	GetAlertSnapshots(ctx context.Context, ruleId string) ([]AlertSnapshot, error)
+
+	// DeleteAlertSnapshots removes the saved active alerts of the rule
+	DeleteAlertSnapshots(ctx context.Context, ruleId string) error
+
+	// HeartbeatShardMember renews the membership of the replica in the rule shard
+	HeartbeatShardMember(ctx context.Context, id string, ts time.Time) error
+
+	// GetShardMembers fetches the replicas with a heartbeat since the given time
+	GetShardMembers(ctx context.Context, since time.Time) ([]ShardMember, error)
+
+	// DeleteShardMember removes the replica from the rule shard
+	DeleteShardMember(ctx context.Context, id string) error
+
+	// AcquireRuleLeader takes or renews the leader lease for the replica, the lease of
+	// another replica is taken once not renewed for the ttl. It returns the leader
+	AcquireRuleLeader(ctx context.Context, id string, ts time.Time, ttl time.Duration) (*RuleLeader, error)
+
+	// GetRuleLeader fetches the leader lease, nil when no replica was ever elected
+	GetRuleLeader(ctx context.Context) (*RuleLeader, error)
+
+	// ReleaseRuleLeader gives up the lease held by the replica
+	ReleaseRuleLeader(ctx context.Context, id string) error
+
+	// GetRulePermissions fetches the permissions granted on the rule
+	GetRulePermissions(ctx context.Context, id string) ([]RulePermission, error)
+
+	// SetRulePermissions replaces the permissions granted on the rule
+	SetRulePermissions(ctx context.Context, id string, permissions []RulePermission) error
+
+	// CheckRulePermission returns a RulePermissionError if the user of the context
+	// is not allowed the operation on the rule, and a RuleProvisionedError if the
+	// provisioned rule is changed outside of the provisioning
+	CheckRulePermission(ctx context.Context, id string, permission RulePermissionType) error
+
+	// GetAuditLogs fetches the changes made to the rules, channels and maintenance, latest first
+	GetAuditLogs(ctx context.Context, filter *AuditLogFilter) ([]AuditLog, error)
+
 	// used for internal telemetry
 	GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error)
 }
@@ -67,6 +326,128 @@ type StoredRule struct {
 	UpdatedAt *time.Time `json:"updated_at" db:"updated_at"`
 	UpdatedBy *string    `json:"updated_by" db:"updated_by"`
 	Data      string     `json:"data" db:"data"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+
+	AlertType *string `json:"alert_type,omitempty" db:"alert_type"`
+	RuleType  *string `json:"rule_type,omitempty" db:"rule_type"`
+	Disabled  bool    `json:"disabled" db:"disabled"`
+	Severity  *string `json:"severity,omitempty" db:"severity"`
+	Folder    *string `json:"folder,omitempty" db:"folder"`
+	// Tags is the json array of the tags of the rule
+	Tags       *string `json:"tags,omitempty" db:"tags"`
+	ExternalID *string `json:"external_id,omitempty" db:"external_id"`
+	OrgID      *string `json:"org_id,omitempty" db:"org_id"`
+	// Provisioned rules are managed in the provisioning directory
+	Provisioned bool `json:"provisioned" db:"provisioned"`
+}
+
+// orgID is the org owning the rule, empty for the rules shared by all the orgs
+func (s *StoredRule) orgID() string {
+	if s.OrgID == nil {
+		return ""
+	}
+	return *s.OrgID
+}
+
+// storedRuleColumns are the columns of the rules table read into the StoredRule
+const storedRuleColumns = "id, created_at, created_by, updated_at, updated_by, data, alert_type, rule_type, disabled, severity, folder, tags, external_id, org_id, provisioned"
+
+// plannedMaintenanceColumns are the columns of the planned_maintenance table read into the PlannedMaintenance
+const plannedMaintenanceColumns = "id, name, description, schedule, alert_ids, matchers, severities, channels, COALESCE(mode, '') AS mode, expires_at, created_at, created_by, updated_at, updated_by, COALESCE(org_id, '') AS org_id"
+
+// RuleFolder is a folder of the rules
+type RuleFolder struct {
+	Name  string `json:"name" db:"folder"`
+	Count int    `json:"count" db:"count"`
+}
+
+// likeEscaper escapes the wildcards of the LIKE patterns
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// StoredRuleFilter selects the rules by their metadata, the empty fields match all the rules
+type StoredRuleFilter struct {
+	AlertType AlertType
+	RuleType  RuleType
+	Disabled  *bool
+	Severity  string
+	// Folder selects the rules in the folder, the empty folder selects the rules not in any folder
+	Folder *string
+	Tag    string
+}
+
+// ruleMetadata is the part of the rule definition stored
+// in the columns of the rules table
+type ruleMetadata struct {
+	AlertType  string `db:"alert_type"`
+	RuleType   string `db:"rule_type"`
+	Disabled   bool   `db:"disabled"`
+	Severity   string `db:"severity"`
+	Folder     string `db:"folder"`
+	Tags       string `db:"tags"`
+	ExternalID string `db:"external_id"`
+}
+
+// ruleRow is the row of the rules table written by the create and edit
+type ruleRow struct {
+	ruleMetadata
+	Id          int64     `db:"id"`
+	CreatedAt   time.Time `db:"created_at"`
+	CreatedBy   string    `db:"created_by"`
+	UpdatedAt   time.Time `db:"updated_at"`
+	UpdatedBy   string    `db:"updated_by"`
+	Data        string    `db:"data"`
+	OrgID       string    `db:"org_id"`
+	Provisioned bool      `db:"provisioned"`
+}
+
+// newRuleMetadata reads the metadata from the rule definition, the rules
+// that can't be parsed are stored without the metadata
+func newRuleMetadata(data string) ruleMetadata {
+	rule := PostableRule{}
+	if err := json.Unmarshal([]byte(data), &rule); err != nil {
+		if err := yaml.Unmarshal([]byte(data), &rule); err != nil {
+			return ruleMetadata{}
+		}
+	}
+
+	ruleType := rule.RuleType
+	if ruleType == "" {
+		if rule.Expr != "" {
+			ruleType = RuleTypeProm
+		} else if rule.RuleCondition != nil && rule.RuleCondition.CompositeQuery != nil {
+			switch rule.RuleCondition.CompositeQuery.QueryType {
+			case v3.QueryTypeBuilder:
+				ruleType = RuleTypeThreshold
+			case v3.QueryTypePromQL:
+				ruleType = RuleTypeProm
+			}
+		}
+	}
+
+	tags := []string{}
+	if rule.Tags != nil {
+		tags = rule.Tags
+	}
+	tagsJSON, _ := json.Marshal(tags)
+
+	return ruleMetadata{
+		AlertType:  string(rule.AlertType),
+		RuleType:   string(ruleType),
+		Disabled:   rule.Disabled,
+		Severity:   rule.Labels["severity"],
+		Folder:     rule.Folder,
+		Tags:       string(tagsJSON),
+		ExternalID: rule.ExternalID,
+	}
+}
+
+// RuleVersion is the rule definition as saved by an edit
+type RuleVersion struct {
+	RuleId    int        `json:"ruleId" db:"rule_id"`
+	Version   int        `json:"version" db:"version"`
+	Data      string     `json:"data" db:"data"`
+	CreatedAt *time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy *string    `json:"createdBy" db:"created_by"`
 }
 
 type Tx interface {
@@ -77,15 +458,64 @@ type Tx interface {
 type ruleDB struct {
 	*sqlx.DB
 	alertManager am.Manager
+
+	// alertsInfo caches the telemetry of the rules and channels, the
+	// generation is bumped by every change to the rules or channels
+	alertsInfoMtx        sync.Mutex
+	alertsInfo           *model.AlertsInfo
+	alertsInfoGeneration uint64
+
+	// events posts the rule changes to the configured webhooks
+	events *ruleEvents
+}
+
+// commitHookTx runs the hook once the transaction is committed
+type commitHookTx struct {
+	*sqlx.Tx
+	onCommit func()
+}
+
+func (t *commitHookTx) Commit() error {
+	if err := t.Tx.Commit(); err != nil {
+		return err
+	}
+	t.onCommit()
+	return nil
 }
 
 // todo: move init methods for creating tables
 
 func NewRuleDB(db *sqlx.DB, alertManager am.Manager) RuleDB {
-	return &ruleDB{
-		db,
-		alertManager,
+	return newRuleDB(db, alertManager, nil)
+}
+
+func newRuleDB(db *sqlx.DB, alertManager am.Manager, events *ruleEvents) *ruleDB {
+	r := &ruleDB{
+		DB:           db,
+		alertManager: alertManager,
+		events:       events,
 	}
+	if err := r.backfillRuleMetadata(); err != nil {
+		zap.L().Error("failed to backfill the rule metadata", zap.Error(err))
+	}
+	return r
+}
+
+// backfillRuleMetadata fills the metadata columns of the rules created before them
+func (r *ruleDB) backfillRuleMetadata() error {
+	rules := []StoredRule{}
+	if err := r.Select(&rules, "SELECT id, data FROM rules WHERE alert_type IS NULL OR folder IS NULL OR external_id IS NULL"); err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		row := ruleRow{ruleMetadata: newRuleMetadata(rule.Data), Id: int64(rule.Id)}
+		_, err := r.NamedExec(`UPDATE rules SET alert_type=:alert_type, rule_type=:rule_type, disabled=:disabled, severity=:severity, folder=:folder, tags=:tags, external_id=:external_id WHERE id=:id;`, row)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // CreateRuleTx stores a given rule in db and returns task name,
@@ -97,23 +527,28 @@ func (r *ruleDB) CreateRuleTx(ctx context.Context, rule string) (int64, Tx, erro
 	if user := common.GetUserFromContext(ctx); user != nil {
 		userEmail = user.Email
 	}
-	createdAt := time.Now()
-	updatedAt := time.Now()
-	tx, err := r.Begin()
-	if err != nil {
+	row := ruleRow{
+		ruleMetadata: newRuleMetadata(rule),
+		CreatedAt:    time.Now(),
+		CreatedBy:    userEmail,
+		UpdatedAt:    time.Now(),
+		UpdatedBy:    userEmail,
+		Data:         rule,
+		OrgID:        contextOrgID(ctx),
+		Provisioned:  isProvisioning(ctx),
+	}
+
+	if err := r.checkExternalID(ctx, row.ExternalID, 0); err != nil {
 		return lastInsertId, nil, err
 	}
 
-	stmt, err := tx.Prepare(`INSERT into rules (created_at, created_by, updated_at, updated_by, data) VALUES($1,$2,$3,$4,$5);`)
+	tx, err := r.Beginx()
 	if err != nil {
-		zap.L().Error("Error in preparing statement for INSERT to rules", zap.Error(err))
-		tx.Rollback()
 		return lastInsertId, nil, err
 	}
 
-	defer stmt.Close()
-
-	result, err := stmt.Exec(createdAt, userEmail, updatedAt, userEmail, rule)
+	result, err := tx.NamedExec(`INSERT into rules (created_at, created_by, updated_at, updated_by, data, alert_type, rule_type, disabled, severity, folder, tags, external_id, org_id, provisioned)
+		VALUES(:created_at, :created_by, :updated_at, :updated_by, :data, :alert_type, :rule_type, :disabled, :severity, :folder, :tags, :external_id, :org_id, :provisioned);`, row)
 	if err != nil {
 		zap.L().Error("Error in Executing prepared statement for INSERT to rules", zap.Error(err))
 		tx.Rollback() // return an error too, we may want to wrap them
@@ -127,7 +562,22 @@ func (r *ruleDB) CreateRuleTx(ctx context.Context, rule string) (int64, Tx, erro
 		return lastInsertId, nil, err
 	}
 
-	return lastInsertId, tx, nil
+	if _, err := tx.Exec(`INSERT INTO rule_versions (rule_id, version, data, created_at, created_by) VALUES($1,1,$2,$3,$4);`, lastInsertId, rule, row.CreatedAt, userEmail); err != nil {
+		zap.L().Error("Error in Executing INSERT to rule_versions", zap.Error(err))
+		tx.Rollback()
+		return lastInsertId, nil, err
+	}
+
+	if err := addAuditLog(ctx, tx, AuditResourceRule, strconv.FormatInt(lastInsertId, 10), AuditActionCreate, "", rule); err != nil {
+		tx.Rollback()
+		return lastInsertId, nil, err
+	}
+
+	onCommit := func() {
+		r.invalidateAlertsInfo()
+		r.events.send(ctx, RuleEventCreated, strconv.FormatInt(lastInsertId, 10), rule)
+	}
+	return lastInsertId, &commitHookTx{Tx: tx, onCommit: onCommit}, nil
 }
 
 // EditRuleTx stores a given rule string in database and returns
@@ -140,49 +590,149 @@ func (r *ruleDB) EditRuleTx(ctx context.Context, rule string, id string) (string
 		return groupName, nil, fmt.Errorf("failed to read alert id from parameters")
 	}
 
+	if err := r.CheckRulePermission(ctx, id, RulePermissionEdit); err != nil {
+		return groupName, nil, err
+	}
+
 	var userEmail string
 	if user := common.GetUserFromContext(ctx); user != nil {
 		userEmail = user.Email
 	}
-	updatedAt := time.Now()
 	groupName = prepareTaskName(int64(idInt))
 
+	row := ruleRow{
+		ruleMetadata: newRuleMetadata(rule),
+		Id:           int64(idInt),
+		UpdatedAt:    time.Now(),
+		UpdatedBy:    userEmail,
+		Data:         rule,
+		Provisioned:  isProvisioning(ctx),
+	}
+	if err := r.checkExternalID(ctx, row.ExternalID, idInt); err != nil {
+		return groupName, nil, err
+	}
+
 	// todo(amol): resolve this error - database locked when using
 	// edit transaction with sqlx
 	// tx, err := r.Begin()
 	//if err != nil {
 	//	return groupName, tx, err
 	//}
-	stmt, err := r.Prepare(`UPDATE rules SET updated_by=$1, updated_at=$2, data=$3 WHERE id=$4;`)
-	if err != nil {
-		zap.L().Error("Error in preparing statement for UPDATE to rules", zap.Error(err))
-		// tx.Rollback()
+	var before string
+	if err := r.Get(&before, `SELECT data FROM rules WHERE id=$1 AND deleted_at IS NULL;`, idInt); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		zap.L().Error("Error in fetching the stored rule", zap.Error(err))
 		return groupName, nil, err
 	}
-	defer stmt.Close()
 
-	if _, err := stmt.Exec(userEmail, updatedAt, rule, idInt); err != nil {
+	// rules created before versioning have no versions yet, record the
+	// stored definition first so that the edit can be rolled back
+	backfill := `INSERT INTO rule_versions (rule_id, version, data, created_at, created_by)
+		SELECT id, 1, data, updated_at, updated_by FROM rules
+		WHERE id=$1 AND deleted_at IS NULL AND NOT EXISTS (SELECT 1 FROM rule_versions WHERE rule_id=$1);`
+	if _, err := r.Exec(backfill, idInt); err != nil {
+		zap.L().Error("Error in recording the stored rule version", zap.Error(err))
+		return groupName, nil, err
+	}
+
+	result, err := r.NamedExec(`UPDATE rules SET updated_by=:updated_by, updated_at=:updated_at, data=:data, alert_type=:alert_type, rule_type=:rule_type, disabled=:disabled,
+		severity=:severity, folder=:folder, tags=:tags, external_id=:external_id, provisioned=:provisioned WHERE id=:id AND deleted_at IS NULL;`, row)
+	if err != nil {
 		zap.L().Error("Error in Executing prepared statement for UPDATE to rules", zap.Error(err))
 		// tx.Rollback() // return an error too, we may want to wrap them
 		return groupName, nil, err
 	}
+	r.invalidateAlertsInfo()
+	if count, err := result.RowsAffected(); err == nil && count == 0 {
+		return groupName, nil, fmt.Errorf("rule %d not found", idInt)
+	}
+
+	if err := r.addRuleVersion(idInt, rule, row.UpdatedAt, userEmail); err != nil {
+		zap.L().Error("Error in adding the rule version", zap.Error(err))
+		return groupName, nil, err
+	}
+
+	if err := addAuditLog(ctx, r, AuditResourceRule, id, AuditActionEdit, before, rule); err != nil {
+		return groupName, nil, err
+	}
+	r.events.send(ctx, editEvent(before, rule), id, rule)
 	return groupName, nil, nil
 }
 
-// DeleteRuleTx deletes a given rule with id and returns
+// addRuleVersion records the rule definition as the next version of the rule
+func (r *ruleDB) addRuleVersion(ruleId int, rule string, createdAt time.Time, createdBy string) error {
+	query := `INSERT INTO rule_versions (rule_id, version, data, created_at, created_by)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4 FROM rule_versions WHERE rule_id=$1;`
+	_, err := r.Exec(query, ruleId, rule, createdAt, createdBy)
+	return err
+}
+
+func (r *ruleDB) GetRuleVersions(ctx context.Context, id string) ([]RuleVersion, error) {
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id parameter")
+	}
+
+	if err := r.CheckRulePermission(ctx, id, RulePermissionView); err != nil {
+		return nil, err
+	}
+
+	versions := []RuleVersion{}
+
+	query := "SELECT rule_id, version, data, created_at, created_by FROM rule_versions WHERE rule_id=$1 ORDER BY version DESC"
+	err = r.Select(&versions, query, intId)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+func (r *ruleDB) GetRuleVersion(ctx context.Context, id string, version int) (*RuleVersion, error) {
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id parameter")
+	}
+
+	if err := r.CheckRulePermission(ctx, id, RulePermissionView); err != nil {
+		return nil, err
+	}
+
+	ruleVersion := &RuleVersion{}
+
+	query := "SELECT rule_id, version, data, created_at, created_by FROM rule_versions WHERE rule_id=$1 AND version=$2"
+	err = r.Get(ruleVersion, query, intId, version)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return ruleVersion, nil
+}
+
+// DeleteRuleTx moves a given rule with id to the trash and returns
 // taskname, sql tx and error (if any)
 func (r *ruleDB) DeleteRuleTx(ctx context.Context, id string) (string, Tx, error) {
 
 	idInt, _ := strconv.Atoi(id)
 	groupName := prepareTaskName(int64(idInt))
 
+	if err := r.CheckRulePermission(ctx, id, RulePermissionDelete); err != nil {
+		return groupName, nil, err
+	}
+
+	var userEmail string
+	if user := common.GetUserFromContext(ctx); user != nil {
+		userEmail = user.Email
+	}
+
 	// commented as this causes db locked error
 	// tx, err := r.Begin()
 	// if err != nil {
 	// 	return groupName, tx, err
 	// }
 
-	stmt, err := r.Prepare(`DELETE FROM rules WHERE id=$1;`)
+	stmt, err := r.Prepare(`UPDATE rules SET deleted_at=$1, updated_by=$2 WHERE id=$3 AND deleted_at IS NULL;`)
 
 	if err != nil {
 		return groupName, nil, err
@@ -190,22 +740,149 @@ func (r *ruleDB) DeleteRuleTx(ctx context.Context, id string) (string, Tx, error
 
 	defer stmt.Close()
 
-	if _, err := stmt.Exec(idInt); err != nil {
-		zap.L().Error("Error in Executing prepared statement for DELETE to rules", zap.Error(err))
+	var before string
+	if err := r.Get(&before, `SELECT data FROM rules WHERE id=$1 AND deleted_at IS NULL;`, idInt); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		zap.L().Error("Error in fetching the stored rule", zap.Error(err))
+		return groupName, nil, err
+	}
+
+	result, err := stmt.Exec(time.Now(), userEmail, idInt)
+	if err != nil {
+		zap.L().Error("Error in Executing prepared statement for soft DELETE to rules", zap.Error(err))
 		// tx.Rollback()
 		return groupName, nil, err
 	}
+	r.invalidateAlertsInfo()
+
+	if count, err := result.RowsAffected(); err == nil && count > 0 {
+		if err := addAuditLog(ctx, r, AuditResourceRule, id, AuditActionDelete, before, ""); err != nil {
+			return groupName, nil, err
+		}
+		r.events.send(ctx, RuleEventDeleted, id, before)
+	}
 
 	return groupName, nil, nil
 }
 
+// RestoreRuleTx restores a given rule with id from the trash and returns
+// taskname, sql tx and error (if any)
+func (r *ruleDB) RestoreRuleTx(ctx context.Context, id string) (string, Tx, error) {
+
+	idInt, _ := strconv.Atoi(id)
+	groupName := prepareTaskName(int64(idInt))
+
+	if err := r.CheckRulePermission(ctx, id, RulePermissionDelete); err != nil {
+		return groupName, nil, err
+	}
+
+	var userEmail string
+	if user := common.GetUserFromContext(ctx); user != nil {
+		userEmail = user.Email
+	}
+
+	result, err := r.Exec(`UPDATE rules SET deleted_at=NULL, updated_at=$1, updated_by=$2 WHERE id=$3 AND deleted_at IS NOT NULL;`, time.Now(), userEmail, idInt)
+	if err != nil {
+		zap.L().Error("Error in Executing UPDATE to restore rule", zap.Error(err))
+		return groupName, nil, err
+	}
+	r.invalidateAlertsInfo()
+	if count, err := result.RowsAffected(); err == nil && count == 0 {
+		return groupName, nil, fmt.Errorf("rule %d not found in the trash", idInt)
+	}
+
+	var after string
+	if err := r.Get(&after, `SELECT data FROM rules WHERE id=$1;`, idInt); err != nil {
+		zap.L().Error("Error in fetching the restored rule", zap.Error(err))
+		return groupName, nil, err
+	}
+	if err := addAuditLog(ctx, r, AuditResourceRule, id, AuditActionRestore, "", after); err != nil {
+		return groupName, nil, err
+	}
+	// the restored rule is back in the inventory
+	r.events.send(ctx, RuleEventCreated, id, after)
+
+	return groupName, nil, nil
+}
+
+func (r *ruleDB) GetDeletedRules(ctx context.Context) ([]StoredRule, error) {
+
+	rules := []StoredRule{}
+
+	q := newSelectQuery("SELECT " + storedRuleColumns + ", deleted_at FROM rules").where("deleted_at IS NOT NULL")
+	whereOrg(ctx, q, "rules")
+
+	query, args := q.order("deleted_at DESC").build()
+	err := r.Select(&rules, query, args...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// PurgeDeletedRules permanently removes the rules deleted before
+// the given time along with their versions
+func (r *ruleDB) PurgeDeletedRules(ctx context.Context, before time.Time) (int64, error) {
+
+	if _, err := r.Exec(`DELETE FROM rule_versions WHERE rule_id IN (SELECT id FROM rules WHERE deleted_at IS NOT NULL AND deleted_at < $1);`, before); err != nil {
+		zap.L().Error("Error in Executing DELETE to rule_versions", zap.Error(err))
+		return 0, err
+	}
+
+	if _, err := r.Exec(`DELETE FROM rule_permissions WHERE rule_id IN (SELECT id FROM rules WHERE deleted_at IS NOT NULL AND deleted_at < $1);`, before); err != nil {
+		zap.L().Error("Error in Executing DELETE to rule_permissions", zap.Error(err))
+		return 0, err
+	}
+
+	result, err := r.Exec(`DELETE FROM rules WHERE deleted_at IS NOT NULL AND deleted_at < $1;`, before)
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE to rules", zap.Error(err))
+		return 0, err
+	}
+	r.invalidateAlertsInfo()
+
+	return result.RowsAffected()
+}
+
 func (r *ruleDB) GetStoredRules(ctx context.Context) ([]StoredRule, error) {
+	return r.FilterStoredRules(ctx, nil)
+}
+
+func (r *ruleDB) FilterStoredRules(ctx context.Context, filter *StoredRuleFilter) ([]StoredRule, error) {
 
 	rules := []StoredRule{}
 
-	query := "SELECT id, created_at, created_by, updated_at, updated_by, data FROM rules"
+	q := newSelectQuery("SELECT " + storedRuleColumns + " FROM rules").where("deleted_at IS NULL")
+
+	if filter != nil {
+		if filter.AlertType != "" {
+			q.where("alert_type=?", filter.AlertType)
+		}
+		if filter.RuleType != "" {
+			q.where("rule_type=?", filter.RuleType)
+		}
+		if filter.Disabled != nil {
+			q.where("disabled=?", *filter.Disabled)
+		}
+		if filter.Severity != "" {
+			q.where("severity=?", filter.Severity)
+		}
+		if filter.Folder != nil {
+			q.where("COALESCE(folder, '')=?", *filter.Folder)
+		}
+		if filter.Tag != "" {
+			// tags are stored as a json array, match the quoted tag
+			tagJSON, _ := json.Marshal(filter.Tag)
+			q.where("tags LIKE ? ESCAPE '\\'", "%"+likeEscaper.Replace(string(tagJSON))+"%")
+		}
+	}
+	whereViewable(ctx, q)
+	whereOrg(ctx, q, "rules")
 
-	err := r.Select(&rules, query)
+	query, args := q.build()
+	err := r.Select(&rules, query, args...)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
@@ -215,6 +892,95 @@ func (r *ruleDB) GetStoredRules(ctx context.Context) ([]StoredRule, error) {
 	return rules, nil
 }
 
+// GetStoredRuleByExternalID fetches the rule by its external id, the rules
+// created without an external id are not found
+func (r *ruleDB) GetStoredRuleByExternalID(ctx context.Context, externalID string) (*StoredRule, error) {
+
+	rule := &StoredRule{}
+
+	q := newSelectQuery("SELECT "+storedRuleColumns+" FROM rules").
+		where("deleted_at IS NULL").
+		where("external_id=?", externalID)
+	whereOrg(ctx, q, "rules")
+
+	query, args := q.build()
+	err := r.Get(rule, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// SetRuleExternalID stamps the external id on the rule without changing its version,
+// the rules that already have an external id are left as they are
+func (r *ruleDB) SetRuleExternalID(ctx context.Context, id int, externalID string) error {
+
+	var data string
+	if err := r.Get(&data, `SELECT data FROM rules WHERE id=$1 AND COALESCE(external_id, '')='';`, id); err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(data), &fields); err != nil {
+		return err
+	}
+	fields["externalId"] = externalID
+	stamped, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.Exec(`UPDATE rules SET data=$1, external_id=$2 WHERE id=$3 AND COALESCE(external_id, '')='';`, string(stamped), externalID, id)
+	if err != nil {
+		zap.L().Error("Error in Executing UPDATE to rules", zap.Error(err))
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// checkExternalID returns an error if the external id is used by a rule other than the given rule
+func (r *ruleDB) checkExternalID(ctx context.Context, externalID string, id int) error {
+	if externalID == "" {
+		return nil
+	}
+	rule, err := r.GetStoredRuleByExternalID(ctx, externalID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if rule.Id != id {
+		return fmt.Errorf("external id %s is already used by rule %d", externalID, rule.Id)
+	}
+	return nil
+}
+
+func (r *ruleDB) GetRuleFolders(ctx context.Context) ([]RuleFolder, error) {
+
+	folders := []RuleFolder{}
+
+	q := newSelectQuery("SELECT folder, count(*) AS count FROM rules").
+		where("deleted_at IS NULL").
+		where("folder IS NOT NULL AND folder != ''")
+	whereOrg(ctx, q, "rules")
+
+	query, args := q.group("folder").order("folder").build()
+	err := r.Select(&folders, query, args...)
+
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return folders, nil
+}
+
 func (r *ruleDB) GetStoredRule(ctx context.Context, id string) (*StoredRule, error) {
 	intId, err := strconv.Atoi(id)
 	if err != nil {
@@ -223,25 +989,33 @@ func (r *ruleDB) GetStoredRule(ctx context.Context, id string) (*StoredRule, err
 
 	rule := &StoredRule{}
 
-	query := fmt.Sprintf("SELECT id, created_at, created_by, updated_at, updated_by, data FROM rules WHERE id=%d", intId)
-	err = r.Get(rule, query)
-
-	// zap.L().Info(query)
-
+	query, args := newSelectQuery("SELECT "+storedRuleColumns+" FROM rules").
+		where("id=?", intId).
+		where("deleted_at IS NULL").
+		build()
+	err = r.Get(rule, query, args...)
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
 		return nil, err
 	}
 
+	if err := r.CheckRulePermission(ctx, id, RulePermissionView); err != nil {
+		return nil, err
+	}
+
 	return rule, nil
 }
 
 func (r *ruleDB) GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMaintenance, error) {
 	maintenances := []PlannedMaintenance{}
 
-	query := "SELECT id, name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by FROM planned_maintenance"
+	// the expired quick maintenance is hidden until it is purged
+	q := newSelectQuery("SELECT "+plannedMaintenanceColumns+" FROM planned_maintenance").
+		where("(expires_at IS NULL OR expires_at > ?)", time.Now().UTC())
+	whereOrg(ctx, q, "planned_maintenance")
 
-	err := r.Select(&maintenances, query)
+	query, args := q.build()
+	err := r.Select(&maintenances, query, args...)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
@@ -254,8 +1028,11 @@ func (r *ruleDB) GetAllPlannedMaintenance(ctx context.Context) ([]PlannedMainten
 func (r *ruleDB) GetPlannedMaintenanceByID(ctx context.Context, id string) (*PlannedMaintenance, error) {
 	maintenance := &PlannedMaintenance{}
 
-	query := "SELECT id, name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by FROM planned_maintenance WHERE id=$1"
-	err := r.Get(maintenance, query, id)
+	q := newSelectQuery("SELECT "+plannedMaintenanceColumns+" FROM planned_maintenance").where("id=?", id)
+	whereOrg(ctx, q, "planned_maintenance")
+
+	query, args := q.build()
+	err := r.Get(maintenance, query, args...)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
@@ -272,55 +1049,126 @@ func (r *ruleDB) CreatePlannedMaintenance(ctx context.Context, maintenance Plann
 	maintenance.CreatedAt = time.Now()
 	maintenance.UpdatedBy = email
 	maintenance.UpdatedAt = time.Now()
+	maintenance.OrgID = contextOrgID(ctx)
 
-	query := "INSERT INTO planned_maintenance (name, description, schedule, alert_ids, created_at, created_by, updated_at, updated_by) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)"
+	query := `INSERT INTO planned_maintenance (name, description, schedule, alert_ids, matchers, severities, channels, mode, expires_at, created_at, created_by, updated_at, updated_by, org_id)
+		VALUES (:name, :description, :schedule, :alert_ids, :matchers, :severities, :channels, :mode, :expires_at, :created_at, :created_by, :updated_at, :updated_by, :org_id)`
 
-	result, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.CreatedAt, maintenance.CreatedBy, maintenance.UpdatedAt, maintenance.UpdatedBy)
+	result, err := r.NamedExec(query, maintenance)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
 		return 0, err
 	}
 
-	return result.LastInsertId()
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	maintenance.Id = id
+
+	after, err := json.Marshal(maintenance)
+	if err != nil {
+		return id, err
+	}
+	return id, addAuditLog(ctx, r, AuditResourceMaintenance, strconv.FormatInt(id, 10), AuditActionCreate, "", string(after))
 }
 
 func (r *ruleDB) DeletePlannedMaintenance(ctx context.Context, id string) (string, error) {
+	before, err := r.GetPlannedMaintenanceByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
 	query := "DELETE FROM planned_maintenance WHERE id=$1"
-	_, err := r.Exec(query, id)
+	_, err = r.Exec(query, id)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
 		return "", err
 	}
 
-	return "", nil
+	beforeData, err := json.Marshal(before)
+	if err != nil {
+		return "", err
+	}
+	return "", addAuditLog(ctx, r, AuditResourceMaintenance, id, AuditActionDelete, string(beforeData), "")
+}
+
+// PurgeExpiredPlannedMaintenance deletes the quick maintenance expired
+// before the given time, the maintenance without an expiry is kept
+func (r *ruleDB) PurgeExpiredPlannedMaintenance(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.Exec(`DELETE FROM planned_maintenance WHERE expires_at IS NOT NULL AND expires_at < $1;`, before.UTC())
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE to planned_maintenance", zap.Error(err))
+		return 0, err
+	}
+
+	return result.RowsAffected()
 }
 
 func (r *ruleDB) EditPlannedMaintenance(ctx context.Context, maintenance PlannedMaintenance, id string) (string, error) {
+	before, err := r.GetPlannedMaintenanceByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
 	email, _ := auth.GetEmailFromJwt(ctx)
+	maintenance.Id = before.Id
+	// the quick maintenance stays quick when edited without its expiry
+	if maintenance.ExpiresAt == nil && before.ExpiresAt != nil {
+		expiresAt := before.ExpiresAt.UTC()
+		if maintenance.Schedule != nil && maintenance.Schedule.EndTime.After(expiresAt) {
+			expiresAt = maintenance.Schedule.EndTime.UTC()
+		}
+		maintenance.ExpiresAt = &expiresAt
+	}
 	maintenance.UpdatedBy = email
 	maintenance.UpdatedAt = time.Now()
 
-	query := "UPDATE planned_maintenance SET name=$1, description=$2, schedule=$3, alert_ids=$4, updated_at=$5, updated_by=$6 WHERE id=$7"
-	_, err := r.Exec(query, maintenance.Name, maintenance.Description, maintenance.Schedule, maintenance.AlertIds, maintenance.UpdatedAt, maintenance.UpdatedBy, id)
+	query := "UPDATE planned_maintenance SET name=:name, description=:description, schedule=:schedule, alert_ids=:alert_ids, matchers=:matchers, severities=:severities, channels=:channels, mode=:mode, expires_at=:expires_at, updated_at=:updated_at, updated_by=:updated_by WHERE id=:id"
+	_, err = r.NamedExec(query, maintenance)
 
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
 		return "", err
 	}
 
-	return "", nil
+	// the audit diff skips the bookkeeping fields that change on every edit
+	maintenance.CreatedAt, maintenance.CreatedBy = before.CreatedAt, before.CreatedBy
+	maintenance.OrgID = before.OrgID
+	before.UpdatedAt, before.UpdatedBy = maintenance.UpdatedAt, maintenance.UpdatedBy
+	beforeData, err := json.Marshal(before)
+	if err != nil {
+		return "", err
+	}
+	afterData, err := json.Marshal(maintenance)
+	if err != nil {
+		return "", err
+	}
+	return "", addAuditLog(ctx, r, AuditResourceMaintenance, id, AuditActionEdit, string(beforeData), string(afterData))
 }
 
 func getChannelType(receiver *am.Receiver) string {
 
+	if receiver.SmtpConfigs != nil {
+		return "smtp"
+	}
+	if receiver.TwilioConfigs != nil {
+		return "twilio"
+	}
+	if receiver.JiraConfigs != nil {
+		return "jira"
+	}
 	if receiver.EmailConfigs != nil {
 		return "email"
 	}
 	if receiver.OpsGenieConfigs != nil {
 		return "opsgenie"
 	}
+	if receiver.PagerdutyV2Configs != nil {
+		return "pagerduty_v2"
+	}
 	if receiver.PagerdutyConfigs != nil {
 		return "pagerduty"
 	}
@@ -353,7 +1201,7 @@ func (r *ruleDB) GetChannel(id string) (*model.ChannelItem, *model.ApiError) {
 	idInt, _ := strconv.Atoi(id)
 	channel := model.ChannelItem{}
 
-	query := "SELECT id, created_at, updated_at, name, type, data data FROM notification_channels WHERE id=?;"
+	query := "SELECT id, created_at, updated_at, name, type, data data FROM notification_channels WHERE id=$1;"
 
 	stmt, err := r.Preparex(query)
 
@@ -372,7 +1220,7 @@ func (r *ruleDB) GetChannel(id string) (*model.ChannelItem, *model.ApiError) {
 	return &channel, nil
 }
 
-func (r *ruleDB) DeleteChannel(id string) *model.ApiError {
+func (r *ruleDB) DeleteChannel(ctx context.Context, id string) *model.ApiError {
 
 	idInt, _ := strconv.Atoi(id)
 
@@ -403,6 +1251,17 @@ func (r *ruleDB) DeleteChannel(id string) *model.ApiError {
 		}
 	}
 
+	if _, err := tx.Exec(`DELETE FROM notification_templates WHERE channel_id=$1;`, idInt); err != nil {
+		zap.L().Error("Error in Executing DELETE to notification_templates", zap.Error(err))
+		tx.Rollback()
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	if err := addAuditLog(ctx, tx, AuditResourceChannel, id, AuditActionDelete, channelToDelete.Data, ""); err != nil {
+		tx.Rollback()
+		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
 	apiError := r.alertManager.DeleteRoute(channelToDelete.Name)
 	if apiError != nil {
 		tx.Rollback()
@@ -414,6 +1273,7 @@ func (r *ruleDB) DeleteChannel(id string) *model.ApiError {
 		zap.L().Error("Error in committing transaction for DELETE command to notification_channels", zap.Error(err))
 		return &model.ApiError{Typ: model.ErrorInternal, Err: err}
 	}
+	r.invalidateAlertsInfo()
 
 	return nil
 
@@ -438,7 +1298,11 @@ func (r *ruleDB) GetChannels() (*[]model.ChannelItem, *model.ApiError) {
 
 }
 
-func (r *ruleDB) EditChannel(receiver *am.Receiver, id string) (*am.Receiver, *model.ApiError) {
+func (r *ruleDB) EditChannel(ctx context.Context, receiver *am.Receiver, id string) (*am.Receiver, *model.ApiError) {
+
+	if apiErr := validateDeliveredConfigs(receiver); apiErr != nil {
+		return nil, apiErr
+	}
 
 	idInt, _ := strconv.Atoi(id)
 
@@ -477,7 +1341,24 @@ func (r *ruleDB) EditChannel(receiver *am.Receiver, id string) (*am.Receiver, *m
 		}
 	}
 
-	apiError := r.alertManager.EditRoute(receiver)
+	if err := addAuditLog(ctx, tx, AuditResourceChannel, id, AuditActionEdit, channel.Data, string(receiverString)); err != nil {
+		tx.Rollback()
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	// the route keeps the message template of the channel
+	template, err := r.channelTemplate(ctx, channel.Id)
+	if err != nil {
+		tx.Rollback()
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+	route, err := channelReceiver(&model.ChannelItem{Data: string(receiverString)}, template)
+	if err != nil {
+		tx.Rollback()
+		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+	}
+
+	apiError := r.alertManager.EditRoute(route)
 	if apiError != nil {
 		tx.Rollback()
 		return nil, apiError
@@ -488,12 +1369,17 @@ func (r *ruleDB) EditChannel(receiver *am.Receiver, id string) (*am.Receiver, *m
 		zap.L().Error("Error in committing transaction for INSERT to notification_channels", zap.Error(err))
 		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 	}
+	r.invalidateAlertsInfo()
 
 	return receiver, nil
 
 }
 
-func (r *ruleDB) CreateChannel(receiver *am.Receiver) (*am.Receiver, *model.ApiError) {
+func (r *ruleDB) CreateChannel(ctx context.Context, receiver *am.Receiver) (*am.Receiver, *model.ApiError) {
+
+	if apiErr := validateDeliveredConfigs(receiver); apiErr != nil {
+		return nil, apiErr
+	}
 
 	channel_type := getChannelType(receiver)
 
@@ -513,11 +1399,21 @@ func (r *ruleDB) CreateChannel(receiver *am.Receiver) (*am.Receiver, *model.ApiE
 		}
 		defer stmt.Close()
 
-		if _, err := stmt.Exec(time.Now(), time.Now(), receiver.Name, channel_type, string(receiverString)); err != nil {
+		result, err := stmt.Exec(time.Now(), time.Now(), receiver.Name, channel_type, string(receiverString))
+		if err != nil {
 			zap.L().Error("Error in Executing prepared statement for INSERT to notification_channels", zap.Error(err))
 			tx.Rollback() // return an error too, we may want to wrap them
 			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 		}
+
+		id, err := result.LastInsertId()
+		if err == nil {
+			err = addAuditLog(ctx, tx, AuditResourceChannel, strconv.FormatInt(id, 10), AuditActionCreate, "", string(receiverString))
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
+		}
 	}
 
 	apiError := r.alertManager.AddRoute(receiver)
@@ -531,18 +1427,84 @@ func (r *ruleDB) CreateChannel(receiver *am.Receiver) (*am.Receiver, *model.ApiE
 		zap.L().Error("Error in committing transaction for INSERT to notification_channels", zap.Error(err))
 		return nil, &model.ApiError{Typ: model.ErrorInternal, Err: err}
 	}
+	r.invalidateAlertsInfo()
 
 	return receiver, nil
 
 }
 
+// invalidateAlertsInfo drops the cached alerts info, it is called once
+// the change to the rules or channels is committed
+func (r *ruleDB) invalidateAlertsInfo() {
+	r.alertsInfoMtx.Lock()
+	defer r.alertsInfoMtx.Unlock()
+	r.alertsInfo = nil
+	r.alertsInfoGeneration++
+}
+
+// GetAlertsInfo returns the cached alerts info, it is collected again
+// only after the rules or channels change
 func (r *ruleDB) GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error) {
+	r.alertsInfoMtx.Lock()
+	if r.alertsInfo != nil {
+		alertsInfo := *r.alertsInfo
+		r.alertsInfoMtx.Unlock()
+		return &alertsInfo, nil
+	}
+	generation := r.alertsInfoGeneration
+	r.alertsInfoMtx.Unlock()
+
+	alertsInfo, err := r.collectAlertsInfo(ctx)
+	if err != nil {
+		return alertsInfo, err
+	}
+
+	// a change made while collecting is not in the collected info, keep
+	// it out of the cache to collect again on the next call
+	r.alertsInfoMtx.Lock()
+	if r.alertsInfoGeneration == generation {
+		cached := *alertsInfo
+		r.alertsInfo = &cached
+	}
+	r.alertsInfoMtx.Unlock()
+
+	return alertsInfo, nil
+}
+
+func (r *ruleDB) collectAlertsInfo(ctx context.Context) (*model.AlertsInfo, error) {
 	alertsInfo := model.AlertsInfo{}
-	// fetch alerts from rules db
-	query := "SELECT data FROM rules"
+
+	// count the alerts by type from the metadata columns
+	counts := []struct {
+		AlertType string `db:"alert_type"`
+		RuleType  string `db:"rule_type"`
+		Count     int    `db:"count"`
+	}{}
+	err := r.Select(&counts, "SELECT COALESCE(alert_type, '') AS alert_type, COALESCE(rule_type, '') AS rule_type, count(*) AS count FROM rules WHERE deleted_at IS NULL GROUP BY alert_type, rule_type")
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return &alertsInfo, err
+	}
+	for _, c := range counts {
+		switch AlertType(c.AlertType) {
+		case AlertTypeLogs:
+			alertsInfo.LogsBasedAlerts += c.Count
+		case AlertTypeMetric:
+			alertsInfo.MetricBasedAlerts += c.Count
+			if RuleType(c.RuleType) == RuleTypeAnomaly {
+				alertsInfo.AnomalyBasedAlerts += c.Count
+			}
+		case AlertTypeTraces:
+			alertsInfo.TracesBasedAlerts += c.Count
+		}
+		alertsInfo.TotalAlerts += c.Count
+	}
+
+	// the query details are only available in the rule definition
+	query := "SELECT data FROM rules WHERE deleted_at IS NULL"
 	var alertsData []string
 	var alertNames []string
-	err := r.Select(&alertsData, query)
+	err = r.Select(&alertsData, query)
 	if err != nil {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
 		return &alertsInfo, err
@@ -559,8 +1521,6 @@ func (r *ruleDB) GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error) {
 		}
 		alertNames = append(alertNames, rule.AlertName)
 		if rule.AlertType == AlertTypeLogs {
-			alertsInfo.LogsBasedAlerts = alertsInfo.LogsBasedAlerts + 1
-
 			if rule.RuleCondition != nil && rule.RuleCondition.CompositeQuery != nil {
 				if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypeClickHouseSQL {
 					if strings.Contains(alert, "signoz_logs.distributed_logs") ||
@@ -584,7 +1544,6 @@ func (r *ruleDB) GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error) {
 				}
 			}
 		} else if rule.AlertType == AlertTypeMetric {
-			alertsInfo.MetricBasedAlerts = alertsInfo.MetricBasedAlerts + 1
 			if rule.RuleCondition != nil && rule.RuleCondition.CompositeQuery != nil {
 				if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypeBuilder {
 					alertsInfo.MetricsBuilderQueries = alertsInfo.MetricsBuilderQueries + 1
@@ -599,12 +1558,7 @@ func (r *ruleDB) GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error) {
 					}
 				}
 			}
-			if rule.RuleType == RuleTypeAnomaly {
-				alertsInfo.AnomalyBasedAlerts = alertsInfo.AnomalyBasedAlerts + 1
-			}
 		} else if rule.AlertType == AlertTypeTraces {
-			alertsInfo.TracesBasedAlerts = alertsInfo.TracesBasedAlerts + 1
-
 			if rule.RuleCondition != nil && rule.RuleCondition.CompositeQuery != nil {
 				if rule.RuleCondition.CompositeQuery.QueryType == v3.QueryTypeClickHouseSQL {
 					if strings.Contains(alert, "signoz_traces.distributed_signoz_index_v2") ||
@@ -615,7 +1569,6 @@ func (r *ruleDB) GetAlertsInfo(ctx context.Context) (*model.AlertsInfo, error) {
 				}
 			}
 		}
-		alertsInfo.TotalAlerts = alertsInfo.TotalAlerts + 1
 	}
 	alertsInfo.AlertNames = alertNames
 
@@ -0,0 +1,452 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+func TestRuleDBTrash(t *testing.T) {
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+	ctx := context.Background()
+
+	for _, data := range []string{`{"alert":"first"}`, `{"alert":"second"}`} {
+		_, tx, err := ruleDB.CreateRuleTx(ctx, data)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+	}
+
+	_, _, err := ruleDB.DeleteRuleTx(ctx, "1")
+	assert.NoError(t, err)
+
+	// the deleted rule is hidden from the rules and can't be edited
+	rules, err := ruleDB.GetStoredRules(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, 2, rules[0].Id)
+	_, err = ruleDB.GetStoredRule(ctx, "1")
+	assert.Error(t, err)
+	_, _, err = ruleDB.EditRuleTx(ctx, `{"alert":"edited"}`, "1")
+	assert.Error(t, err)
+
+	deleted, err := ruleDB.GetDeletedRules(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, deleted, 1)
+	assert.Equal(t, 1, deleted[0].Id)
+	assert.NotNil(t, deleted[0].DeletedAt)
+
+	// the rule deleted within the retention is kept
+	count, err := ruleDB.PurgeDeletedRules(ctx, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	_, _, err = ruleDB.RestoreRuleTx(ctx, "1")
+	assert.NoError(t, err)
+	rule, err := ruleDB.GetStoredRule(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"alert":"first"}`, rule.Data)
+
+	// only the rules in the trash can be restored
+	_, _, err = ruleDB.RestoreRuleTx(ctx, "1")
+	assert.Error(t, err)
+
+	_, _, err = ruleDB.DeleteRuleTx(ctx, "2")
+	assert.NoError(t, err)
+	count, err = ruleDB.PurgeDeletedRules(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	deleted, err = ruleDB.GetDeletedRules(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, deleted, 0)
+}
+
+func TestRuleDBMetadata(t *testing.T) {
+	db := utils.NewQueryServiceDBForTests(t)
+	ctx := context.Background()
+
+	// a rule created before the metadata columns
+	_, err := db.Exec(`INSERT INTO rules (created_at, updated_at, data) VALUES ($1, $1, $2)`, time.Now(),
+		`{"alert":"legacy","alertType":"METRIC_BASED_ALERT","condition":{"compositeQuery":{"queryType":"promql"}},"labels":{"severity":"critical"}}`)
+	assert.NoError(t, err)
+
+	ruleDB := NewRuleDB(db, nil)
+
+	for _, data := range []string{
+		`{"alert":"logs","alertType":"LOGS_BASED_ALERT","ruleType":"threshold_rule","condition":{"compositeQuery":{"queryType":"builder"}},"labels":{"severity":"warning"}}`,
+		`{"alert":"anomaly","alertType":"METRIC_BASED_ALERT","ruleType":"anomaly_rule","disabled":true,"labels":{"severity":"critical"}}`,
+	} {
+		_, tx, err := ruleDB.CreateRuleTx(ctx, data)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+	}
+
+	legacy, err := ruleDB.GetStoredRule(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, string(AlertTypeMetric), *legacy.AlertType)
+	assert.Equal(t, string(RuleTypeProm), *legacy.RuleType)
+	assert.Equal(t, "critical", *legacy.Severity)
+
+	disabled := true
+	cases := []struct {
+		filter   *StoredRuleFilter
+		expected []int
+	}{
+		{filter: nil, expected: []int{1, 2, 3}},
+		{filter: &StoredRuleFilter{AlertType: AlertTypeMetric}, expected: []int{1, 3}},
+		{filter: &StoredRuleFilter{RuleType: RuleTypeThreshold}, expected: []int{2}},
+		{filter: &StoredRuleFilter{Severity: "critical", Disabled: &disabled}, expected: []int{3}},
+	}
+	for _, c := range cases {
+		rules, err := ruleDB.FilterStoredRules(ctx, c.filter)
+		assert.NoError(t, err)
+		var ids []int
+		for _, rule := range rules {
+			ids = append(ids, rule.Id)
+		}
+		assert.Equal(t, c.expected, ids)
+	}
+
+	// the metadata follows the edits
+	_, _, err = ruleDB.EditRuleTx(ctx, `{"alert":"logs","alertType":"LOGS_BASED_ALERT","ruleType":"threshold_rule","condition":{"compositeQuery":{"queryType":"builder"}},"labels":{"severity":"critical"}}`, "2")
+	assert.NoError(t, err)
+	rules, err := ruleDB.FilterStoredRules(ctx, &StoredRuleFilter{Severity: "critical"})
+	assert.NoError(t, err)
+	assert.Len(t, rules, 3)
+
+	info, err := ruleDB.GetAlertsInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, info.TotalAlerts)
+	assert.Equal(t, 2, info.MetricBasedAlerts)
+	assert.Equal(t, 1, info.AnomalyBasedAlerts)
+	assert.Equal(t, 1, info.LogsBasedAlerts)
+}
+
+func TestRuleDBFoldersAndTags(t *testing.T) {
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+	ctx := context.Background()
+
+	for _, data := range []string{
+		`{"alert":"checkout latency","folder":"payments","tags":["checkout","latency"]}`,
+		`{"alert":"checkout errors","folder":"payments","tags":["checkout"]}`,
+		`{"alert":"disk usage","tags":["infra"]}`,
+		`{"alert":"queue lag","tags":["queue_lag"]}`,
+	} {
+		_, tx, err := ruleDB.CreateRuleTx(ctx, data)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+	}
+
+	folders, err := ruleDB.GetRuleFolders(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []RuleFolder{{Name: "payments", Count: 2}}, folders)
+
+	payments, root := "payments", ""
+	cases := []struct {
+		filter   *StoredRuleFilter
+		expected []int
+	}{
+		{filter: &StoredRuleFilter{Folder: &payments}, expected: []int{1, 2}},
+		{filter: &StoredRuleFilter{Folder: &root}, expected: []int{3, 4}},
+		{filter: &StoredRuleFilter{Tag: "checkout"}, expected: []int{1, 2}},
+		{filter: &StoredRuleFilter{Tag: "latency", Folder: &payments}, expected: []int{1}},
+		{filter: &StoredRuleFilter{Tag: "check"}, expected: nil},
+		{filter: &StoredRuleFilter{Tag: "queue%"}, expected: nil},
+		{filter: &StoredRuleFilter{Tag: "queue_lag"}, expected: []int{4}},
+	}
+	for _, c := range cases {
+		rules, err := ruleDB.FilterStoredRules(ctx, c.filter)
+		assert.NoError(t, err)
+		var ids []int
+		for _, rule := range rules {
+			ids = append(ids, rule.Id)
+		}
+		assert.Equal(t, c.expected, ids)
+	}
+
+	_, _, err = ruleDB.EditRuleTx(ctx, `{"alert":"disk usage","folder":"infra","tags":["infra"]}`, "3")
+	assert.NoError(t, err)
+	folders, err = ruleDB.GetRuleFolders(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []RuleFolder{{Name: "infra", Count: 1}, {Name: "payments", Count: 2}}, folders)
+}
+
+func TestRuleDBAuditLog(t *testing.T) {
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+	user := &model.UserPayload{User: model.User{Email: "admin@signoz.io"}}
+	ctx := context.WithValue(context.Background(), constants.ContextUserKey, user)
+
+	_, tx, err := ruleDB.CreateRuleTx(ctx, `{"alert":"cpu","condition":{"target":90}}`)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	_, _, err = ruleDB.EditRuleTx(ctx, `{"alert":"cpu","condition":{"target":80}}`, "1")
+	assert.NoError(t, err)
+	_, _, err = ruleDB.DeleteRuleTx(ctx, "1")
+	assert.NoError(t, err)
+
+	logs, err := ruleDB.GetAuditLogs(ctx, &AuditLogFilter{ResourceType: AuditResourceRule, ResourceId: "1"})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 3)
+	assert.Equal(t, AuditActionDelete, logs[0].Action)
+	assert.Equal(t, AuditActionEdit, logs[1].Action)
+	assert.Equal(t, AuditActionCreate, logs[2].Action)
+	assert.Equal(t, "admin@signoz.io", *logs[1].Actor)
+	assert.Equal(t, AuditChanges{{Path: "condition.target", From: float64(90), To: float64(80)}}, logs[1].Changes)
+
+	// deleting the rule in the trash again is not recorded
+	_, _, err = ruleDB.DeleteRuleTx(ctx, "1")
+	assert.NoError(t, err)
+
+	maintenance := PlannedMaintenance{
+		Name:     "upgrade",
+		Schedule: &Schedule{Timezone: "UTC", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)},
+		AlertIds: &AlertIds{"1"},
+	}
+	id, err := ruleDB.CreatePlannedMaintenance(ctx, maintenance)
+	assert.NoError(t, err)
+	maintenance.Name = "db upgrade"
+	_, err = ruleDB.EditPlannedMaintenance(ctx, maintenance, strconv.FormatInt(id, 10))
+	assert.NoError(t, err)
+
+	logs, err = ruleDB.GetAuditLogs(ctx, &AuditLogFilter{ResourceType: AuditResourceMaintenance, Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+	assert.Equal(t, AuditActionEdit, logs[0].Action)
+	assert.Equal(t, AuditChanges{{Path: "name", From: "upgrade", To: "db upgrade"}}, logs[0].Changes)
+
+	logs, err = ruleDB.GetAuditLogs(ctx, &AuditLogFilter{Actor: "admin@signoz.io", Start: time.Now().Add(-time.Minute)})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 5)
+	logs, err = ruleDB.GetAuditLogs(ctx, &AuditLogFilter{End: time.Now().Add(-time.Minute)})
+	assert.NoError(t, err)
+	assert.Empty(t, logs)
+}
+
+func TestRuleDBPermissions(t *testing.T) {
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+
+	adminGroupId := auth.AuthCacheObj.AdminGroupId
+	auth.AuthCacheObj.AdminGroupId = "admin-group"
+	defer func() { auth.AuthCacheObj.AdminGroupId = adminGroupId }()
+
+	userCtx := func(email, groupId string) context.Context {
+		user := &model.UserPayload{User: model.User{Email: email, GroupId: groupId}}
+		return context.WithValue(context.Background(), constants.ContextUserKey, user)
+	}
+	admin := userCtx("admin@signoz.io", "admin-group")
+	oncall := userCtx("oncall@signoz.io", "editor-group")
+	editor := userCtx("editor@signoz.io", "editor-group")
+	viewer := userCtx("viewer@signoz.io", "viewer-group")
+
+	for _, data := range []string{`{"alert":"paging"}`, `{"alert":"self service"}`} {
+		_, tx, err := ruleDB.CreateRuleTx(admin, data)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+	}
+
+	err := ruleDB.SetRulePermissions(admin, "1", []RulePermission{
+		{PrincipalType: RulePrincipalUser, Principal: "oncall@signoz.io", Permission: RulePermissionEdit},
+		{PrincipalType: RulePrincipalGroup, Principal: "viewer-group", Permission: RulePermissionView},
+	})
+	assert.NoError(t, err)
+	err = ruleDB.SetRulePermissions(admin, "1", []RulePermission{{PrincipalType: "team", Principal: "sre", Permission: RulePermissionView}})
+	assert.Error(t, err)
+
+	// the rules without permissions are open to everyone
+	rules, err := ruleDB.GetStoredRules(editor)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, 2, rules[0].Id)
+	_, _, err = ruleDB.EditRuleTx(editor, `{"alert":"self service edited"}`, "2")
+	assert.NoError(t, err)
+
+	// the locked rule is hidden from the users without a permission
+	_, err = ruleDB.GetStoredRule(editor, "1")
+	assert.Equal(t, &RulePermissionError{RuleId: "1", Permission: RulePermissionView}, err)
+	_, _, err = ruleDB.EditRuleTx(editor, `{"alert":"paging edited"}`, "1")
+	assert.Equal(t, &RulePermissionError{RuleId: "1", Permission: RulePermissionEdit}, err)
+
+	// and so are its changes
+	_, err = ruleDB.GetAuditLogs(editor, &AuditLogFilter{ResourceType: AuditResourceRule, ResourceId: "1"})
+	assert.Equal(t, &RulePermissionError{RuleId: "1", Permission: RulePermissionView}, err)
+	logs, err := ruleDB.GetAuditLogs(editor, &AuditLogFilter{ResourceType: AuditResourceRule})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 2)
+	for _, log := range logs {
+		assert.Equal(t, "2", log.ResourceId)
+	}
+	logs, err = ruleDB.GetAuditLogs(viewer, &AuditLogFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 4)
+
+	// the group permission grants the view only
+	rules, err = ruleDB.GetStoredRules(viewer)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	_, err = ruleDB.GetStoredRule(viewer, "1")
+	assert.NoError(t, err)
+	_, _, err = ruleDB.EditRuleTx(viewer, `{"alert":"paging edited"}`, "1")
+	assert.Error(t, err)
+
+	// the edit permission includes the view but not the delete
+	_, err = ruleDB.GetStoredRule(oncall, "1")
+	assert.NoError(t, err)
+	_, _, err = ruleDB.EditRuleTx(oncall, `{"alert":"paging edited"}`, "1")
+	assert.NoError(t, err)
+	_, _, err = ruleDB.DeleteRuleTx(oncall, "1")
+	assert.Equal(t, &RulePermissionError{RuleId: "1", Permission: RulePermissionDelete}, err)
+
+	// admins and the query service itself can access all the rules
+	rules, err = ruleDB.GetStoredRules(admin)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	rules, err = ruleDB.GetStoredRules(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	_, _, err = ruleDB.DeleteRuleTx(admin, "1")
+	assert.NoError(t, err)
+
+	permissions, err := ruleDB.GetRulePermissions(admin, "1")
+	assert.NoError(t, err)
+	assert.Len(t, permissions, 2)
+}
+
+func TestRuleDBOrgScoping(t *testing.T) {
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+
+	orgCtx := func(orgId string) context.Context {
+		user := &model.UserPayload{User: model.User{Email: "admin@" + orgId, OrgId: orgId}}
+		return context.WithValue(context.Background(), constants.ContextUserKey, user)
+	}
+	acme := orgCtx("acme")
+	globex := orgCtx("globex")
+
+	for _, create := range []struct {
+		ctx  context.Context
+		data string
+	}{
+		{acme, `{"alert":"acme"}`},
+		{globex, `{"alert":"globex","externalId":"globex-rule"}`},
+		{context.Background(), `{"alert":"shared"}`},
+	} {
+		_, tx, err := ruleDB.CreateRuleTx(create.ctx, create.data)
+		assert.NoError(t, err)
+		assert.NoError(t, tx.Commit())
+	}
+
+	// the orgs see their own rules and the shared rules
+	rules, err := ruleDB.GetStoredRules(acme)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+	assert.Equal(t, 1, rules[0].Id)
+	assert.Equal(t, "acme", rules[0].orgID())
+	assert.Equal(t, 3, rules[1].Id)
+	assert.Equal(t, "", rules[1].orgID())
+
+	// the query service itself sees all the rules
+	rules, err = ruleDB.GetStoredRules(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, rules, 3)
+
+	// the rules of the other orgs are not found
+	_, err = ruleDB.GetStoredRule(acme, "2")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, _, err = ruleDB.EditRuleTx(acme, `{"alert":"globex edited"}`, "2")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, _, err = ruleDB.DeleteRuleTx(acme, "2")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = ruleDB.GetStoredRuleByExternalID(acme, "globex-rule")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, _, err = ruleDB.EditRuleTx(acme, `{"alert":"shared edited"}`, "3")
+	assert.NoError(t, err)
+
+	maintenance := PlannedMaintenance{
+		Name:     "upgrade",
+		Schedule: &Schedule{Timezone: "UTC", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)},
+	}
+	id, err := ruleDB.CreatePlannedMaintenance(acme, maintenance)
+	assert.NoError(t, err)
+
+	maintenances, err := ruleDB.GetAllPlannedMaintenance(globex)
+	assert.NoError(t, err)
+	assert.Empty(t, maintenances)
+	_, err = ruleDB.DeletePlannedMaintenance(globex, strconv.FormatInt(id, 10))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the changes made by an org are not shown to the other orgs
+	logs, err := ruleDB.GetAuditLogs(globex, &AuditLogFilter{ResourceType: AuditResourceMaintenance})
+	assert.NoError(t, err)
+	assert.Empty(t, logs)
+	logs, err = ruleDB.GetAuditLogs(acme, &AuditLogFilter{ResourceType: AuditResourceMaintenance})
+	assert.NoError(t, err)
+	assert.Len(t, logs, 1)
+
+	// the maintenance of an org does not apply to the rules of the other orgs
+	maintenances, err = ruleDB.GetAllPlannedMaintenance(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, maintenances, 1)
+	assert.True(t, maintenances[0].matchesOrg("acme"))
+	assert.False(t, maintenances[0].matchesOrg("globex"))
+}
+
+func TestRuleDBAlertsInfoCache(t *testing.T) {
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil).(*ruleDB)
+	ctx := context.Background()
+
+	create := func(data string, commit bool) {
+		_, tx, err := ruleDB.CreateRuleTx(ctx, data)
+		assert.NoError(t, err)
+		if commit {
+			assert.NoError(t, tx.Commit())
+		} else {
+			assert.NoError(t, tx.Rollback())
+		}
+	}
+	create(`{"alert":"cpu","alertType":"METRIC_BASED_ALERT"}`, true)
+
+	info, err := ruleDB.GetAlertsInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, info.TotalAlerts)
+	assert.Equal(t, []string{"cpu"}, info.AlertNames)
+
+	// the cached info is returned until the rules change
+	_, err = ruleDB.Exec(`UPDATE rules SET deleted_at=$1`, time.Now())
+	assert.NoError(t, err)
+	info, err = ruleDB.GetAlertsInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, info.TotalAlerts)
+	_, err = ruleDB.Exec(`UPDATE rules SET deleted_at=NULL`)
+	assert.NoError(t, err)
+
+	// the rolled back rule leaves the cache as is
+	create(`{"alert":"errors","alertType":"LOGS_BASED_ALERT","condition":{"compositeQuery":{"queryType":"builder"}}}`, false)
+	assert.NotNil(t, ruleDB.alertsInfo)
+
+	create(`{"alert":"errors","alertType":"LOGS_BASED_ALERT","condition":{"compositeQuery":{"queryType":"builder"}}}`, true)
+	info, err = ruleDB.GetAlertsInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, info.TotalAlerts)
+	assert.Equal(t, 1, info.LogsBasedAlerts)
+
+	_, _, err = ruleDB.EditRuleTx(ctx, `{"alert":"errors","alertType":"TRACES_BASED_ALERT"}`, "2")
+	assert.NoError(t, err)
+	info, err = ruleDB.GetAlertsInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, info.LogsBasedAlerts)
+	assert.Equal(t, 1, info.TracesBasedAlerts)
+
+	_, _, err = ruleDB.DeleteRuleTx(ctx, "1")
+	assert.NoError(t, err)
+	info, err = ruleDB.GetAlertsInfo(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, info.TotalAlerts)
+	assert.Equal(t, []string{"errors"}, info.AlertNames)
+}
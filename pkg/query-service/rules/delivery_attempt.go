@@ -0,0 +1,291 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultDeliveryRetries is how many times the failed request is retried
+	defaultDeliveryRetries = 3
+	maxDeliveryRetries     = 10
+	// defaultDeliveryBackoff is the wait before the first retry, doubled after every retry
+	defaultDeliveryBackoff = time.Second
+	maxDeliveryBackoff     = 30 * time.Second
+	// maxDeliveryResponseSize bounds the response body kept for the senders reading it
+	maxDeliveryResponseSize = 1 << 20
+
+	defaultDeliveryAttemptLimit = 100
+)
+
+// DeliveryStatus is the outcome of the delivery attempt
+type DeliveryStatus string
+
+const (
+	DeliveryStatusSuccess DeliveryStatus = "success"
+	DeliveryStatusFailed  DeliveryStatus = "failed"
+)
+
+// DeliveryFingerprints are the fingerprints of the alerts in the request
+type DeliveryFingerprints []string
+
+func (f *DeliveryFingerprints) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, f)
+	case string:
+		return json.Unmarshal([]byte(data), f)
+	}
+	return nil
+}
+
+func (f DeliveryFingerprints) Value() (driver.Value, error) {
+	if f == nil {
+		f = DeliveryFingerprints{}
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// DeliveryAttempt is a request sent to the provider of the channel by the query service
+type DeliveryAttempt struct {
+	Id           int64                `json:"id" db:"id"`
+	Channel      string               `json:"channel" db:"channel"`
+	ChannelType  string               `json:"channelType" db:"channel_type"`
+	Fingerprints DeliveryFingerprints `json:"fingerprints" db:"fingerprints"`
+	// Attempt is 1 for the first request and is incremented by the retries
+	Attempt    int            `json:"attempt" db:"attempt"`
+	Status     DeliveryStatus `json:"status" db:"status"`
+	StatusCode int            `json:"statusCode" db:"status_code"`
+	Error      string         `json:"error,omitempty" db:"error"`
+	LatencyMs  int64          `json:"latencyMs" db:"latency_ms"`
+	Timestamp  time.Time      `json:"timestamp" db:"timestamp"`
+}
+
+// DeliveryAttemptFilter selects the delivery attempts, the empty fields match all the attempts
+type DeliveryAttemptFilter struct {
+	Channel string
+	Status  DeliveryStatus
+	Start   time.Time
+	End     time.Time
+	// Limit is the max number of attempts returned, latest first
+	Limit int
+}
+
+func (r *ruleDB) RecordDeliveryAttempt(ctx context.Context, attempt DeliveryAttempt) error {
+	attempt.Timestamp = attempt.Timestamp.UTC()
+	_, err := r.NamedExec(`INSERT INTO delivery_attempts (channel, channel_type, fingerprints, attempt, status, status_code, error, latency_ms, timestamp)
+		VALUES (:channel, :channel_type, :fingerprints, :attempt, :status, :status_code, :error, :latency_ms, :timestamp)`, attempt)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to delivery_attempts", zap.Error(err))
+	}
+	return err
+}
+
+func (r *ruleDB) PurgeDeliveryAttempts(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.Exec("DELETE FROM delivery_attempts WHERE timestamp < $1", before.UTC())
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE from delivery_attempts", zap.Error(err))
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *ruleDB) GetDeliveryAttempts(ctx context.Context, filter *DeliveryAttemptFilter) ([]DeliveryAttempt, error) {
+	if filter == nil {
+		filter = &DeliveryAttemptFilter{}
+	}
+
+	q := newSelectQuery("SELECT id, channel, channel_type, fingerprints, attempt, status, status_code, error, latency_ms, timestamp FROM delivery_attempts")
+	if filter.Channel != "" {
+		q.where("channel=?", filter.Channel)
+	}
+	if filter.Status != "" {
+		q.where("status=?", filter.Status)
+	}
+	if !filter.Start.IsZero() {
+		q.where("timestamp>=?", filter.Start.UTC())
+	}
+	if !filter.End.IsZero() {
+		q.where("timestamp<=?", filter.End.UTC())
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultDeliveryAttemptLimit
+	}
+	query, args := q.order("timestamp DESC, id DESC").page(limit, 0).build()
+
+	attempts := []DeliveryAttempt{}
+	if err := r.Select(&attempts, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// deliveryRequest is the notification sent to the provider of the channel, it is
+// stored in the delivery queue when it keeps failing
+type deliveryRequest struct {
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channelType"`
+	// URL is the endpoint the body is posted to
+	URL     string            `json:"url,omitempty"`
+	Body    []byte            `json:"body"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Smtp sends the body as the email message through the smtp server instead
+	Smtp         *SmtpConfig `json:"smtp,omitempty"`
+	Fingerprints []string    `json:"fingerprints"`
+	// Alerts are the alerts notified by the request, they are logged with the outcome
+	Alerts []notifiedAlert `json:"alerts,omitempty"`
+	// Retries is how many times the request is retried on the transient failures
+	// before it is queued
+	Retries int `json:"-"`
+
+	// response is the body of the successful response, it is not kept when the
+	// request is queued
+	response []byte
+}
+
+// retryable reports whether the request failed with a transient error worth retrying,
+// the request without a response failed on the network
+func retryable(statusCode int, err error) bool {
+	if err == nil {
+		return false
+	}
+	return statusCode == 0 || statusCode >= 500 || statusCode == http.StatusTooManyRequests
+}
+
+// try sends the request once and records the attempt, transient reports whether
+// the failure is worth retrying
+func (d *channelDelivery) try(ctx context.Context, r *deliveryRequest, attempt int) (bool, error) {
+	start := time.Now()
+	var (
+		statusCode int
+		transient  bool
+		err        error
+	)
+	if r.Smtp != nil {
+		statusCode, transient, err = r.Smtp.sendMail(ctx, r.Body)
+	} else {
+		statusCode, err = d.postOnce(ctx, r)
+		transient = retryable(statusCode, err)
+	}
+
+	record := DeliveryAttempt{
+		Channel:      r.Channel,
+		ChannelType:  r.ChannelType,
+		Fingerprints: r.Fingerprints,
+		Attempt:      attempt,
+		Status:       DeliveryStatusSuccess,
+		StatusCode:   statusCode,
+		LatencyMs:    time.Since(start).Milliseconds(),
+		Timestamp:    time.Now(),
+	}
+	if err != nil {
+		record.Status, record.Error = DeliveryStatusFailed, err.Error()
+	}
+	observeNotification(r.ChannelType, err)
+	if recordErr := d.ruleDB.RecordDeliveryAttempt(ctx, record); recordErr != nil {
+		zap.L().Error("failed to record the delivery attempt", zap.String("channel", r.Channel), zap.Error(recordErr))
+	}
+	return transient, err
+}
+
+// send sends the request, the transient failures are retried with an exponential
+// backoff and the request still failing is queued to be retried later
+func (d *channelDelivery) send(ctx context.Context, r *deliveryRequest) (err error) {
+	if d.test != nil {
+		return d.test.send(ctx, d, r)
+	}
+	defer func() { d.logNotification(ctx, r, err) }()
+
+	backoff := d.backoff
+	for attempt := 1; ; attempt++ {
+		transient, err := d.try(ctx, r, attempt)
+		if err == nil || !transient {
+			return err
+		}
+		if attempt > r.Retries {
+			d.enqueue(ctx, r, attempt, err)
+			return err
+		}
+
+		zap.L().Warn("retrying the delivery", zap.String("channel", r.Channel), zap.Int("attempt", attempt), zap.Duration("backoff", backoff), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxDeliveryBackoff {
+			backoff = maxDeliveryBackoff
+		}
+	}
+}
+
+func (d *channelDelivery) postOnce(ctx context.Context, r *deliveryRequest) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range r.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("%s responded %s: %s", r.ChannelType, resp.Status, strings.TrimSpace(string(message)))
+	}
+	r.response, _ = io.ReadAll(io.LimitReader(resp.Body, maxDeliveryResponseSize))
+	return resp.StatusCode, nil
+}
+
+// recordAlertmanagerSend records the request sending the alerts to the alertmanager,
+// the alertmanager delivers them to the channels and retries on its own
+func (d *channelDelivery) recordAlertmanagerSend(url string, alerts []*am.Alert, latency time.Duration, err error) {
+	fingerprints := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		fingerprints = append(fingerprints, fmt.Sprintf("%016x", alert.Labels.Hash()))
+	}
+	record := DeliveryAttempt{
+		Channel:      "alertmanager",
+		ChannelType:  "alertmanager",
+		Fingerprints: fingerprints,
+		Attempt:      1,
+		Status:       DeliveryStatusSuccess,
+		LatencyMs:    latency.Milliseconds(),
+		Timestamp:    time.Now(),
+	}
+	if err != nil {
+		record.Status, record.Error = DeliveryStatusFailed, fmt.Sprintf("%s: %s", url, err.Error())
+	}
+	observeNotification(record.ChannelType, err)
+	if recordErr := d.ruleDB.RecordDeliveryAttempt(context.Background(), record); recordErr != nil {
+		zap.L().Error("failed to record the alertmanager delivery", zap.Error(recordErr))
+	}
+	notifications := alertmanagerNotifications(alerts, record.Timestamp, err)
+	if logErr := d.ruleDB.RecordNotifications(context.Background(), notifications); logErr != nil {
+		zap.L().Error("failed to log the alertmanager notifications", zap.Error(logErr))
+	}
+	d.exporter.exportNotifications(notifications)
+}
@@ -0,0 +1,239 @@
+package rules
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// deliveryQueueInterval is how often the due deliveries of the queue are retried
+	deliveryQueueInterval = 30 * time.Second
+	// deliveryQueueBatch is the max number of deliveries retried at once
+	deliveryQueueBatch = 50
+	// queuedDeliveryBackoff is the wait before the first retry from the queue
+	queuedDeliveryBackoff    = time.Minute
+	maxQueuedDeliveryBackoff = time.Hour
+	// maxQueuedDeliveryAttempts is how many attempts are made before the delivery is given up
+	maxQueuedDeliveryAttempts = 12
+)
+
+// QueuedDeliveryStatus is the state of the delivery in the queue
+type QueuedDeliveryStatus string
+
+const (
+	// QueuedDeliveryPending is retried once it is due
+	QueuedDeliveryPending QueuedDeliveryStatus = "pending"
+	// QueuedDeliveryDead failed with a permanent error or ran out of attempts
+	QueuedDeliveryDead QueuedDeliveryStatus = "dead"
+)
+
+func (r *deliveryRequest) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, r)
+	case string:
+		return json.Unmarshal([]byte(data), r)
+	}
+	return nil
+}
+
+func (r deliveryRequest) Value() (driver.Value, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// QueuedDelivery is the notification that kept failing with transient errors, it is
+// retried from the db so that it survives the restarts of the query service
+type QueuedDelivery struct {
+	Id          int64                `json:"id" db:"id"`
+	Channel     string               `json:"channel" db:"channel"`
+	ChannelType string               `json:"channelType" db:"channel_type"`
+	Request     deliveryRequest      `json:"-" db:"request"`
+	Status      QueuedDeliveryStatus `json:"status" db:"status"`
+	// Attempts is the number of attempts made, including the ones before it was queued
+	Attempts      int       `json:"attempts" db:"attempts"`
+	LastError     string    `json:"lastError" db:"last_error"`
+	NextAttemptAt time.Time `json:"nextAttemptAt" db:"next_attempt_at"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// QueuedDeliveryFilter selects the queued deliveries, the empty fields match all the deliveries
+type QueuedDeliveryFilter struct {
+	Channel string
+	Status  QueuedDeliveryStatus
+	// DueBy selects the deliveries whose next attempt is due by the time
+	DueBy time.Time
+	Limit int
+}
+
+const queuedDeliveryColumns = "id, channel, channel_type, request, status, attempts, last_error, next_attempt_at, created_at, updated_at"
+
+func (r *ruleDB) QueueDelivery(ctx context.Context, delivery QueuedDelivery) (int64, error) {
+	now := time.Now().UTC()
+	delivery.CreatedAt, delivery.UpdatedAt = now, now
+	delivery.NextAttemptAt = delivery.NextAttemptAt.UTC()
+
+	result, err := r.NamedExec(`INSERT INTO delivery_queue (channel, channel_type, request, status, attempts, last_error, next_attempt_at, created_at, updated_at)
+		VALUES (:channel, :channel_type, :request, :status, :attempts, :last_error, :next_attempt_at, :created_at, :updated_at)`, delivery)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to delivery_queue", zap.Error(err))
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func (r *ruleDB) GetQueuedDeliveries(ctx context.Context, filter *QueuedDeliveryFilter) ([]QueuedDelivery, error) {
+	if filter == nil {
+		filter = &QueuedDeliveryFilter{}
+	}
+
+	q := newSelectQuery("SELECT " + queuedDeliveryColumns + " FROM delivery_queue")
+	if filter.Channel != "" {
+		q.where("channel=?", filter.Channel)
+	}
+	if filter.Status != "" {
+		q.where("status=?", filter.Status)
+	}
+	if !filter.DueBy.IsZero() {
+		q.where("next_attempt_at<=?", filter.DueBy.UTC())
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultDeliveryAttemptLimit
+	}
+	query, args := q.order("next_attempt_at, id").page(limit, 0).build()
+
+	deliveries := []QueuedDelivery{}
+	if err := r.Select(&deliveries, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *ruleDB) GetQueuedDelivery(ctx context.Context, id string) (*QueuedDelivery, error) {
+	delivery := &QueuedDelivery{}
+	if err := r.Get(delivery, "SELECT "+queuedDeliveryColumns+" FROM delivery_queue WHERE id=$1", id); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// UpdateQueuedDelivery stores the outcome of the retry of the delivery
+func (r *ruleDB) UpdateQueuedDelivery(ctx context.Context, delivery QueuedDelivery) error {
+	delivery.UpdatedAt = time.Now().UTC()
+	delivery.NextAttemptAt = delivery.NextAttemptAt.UTC()
+	_, err := r.NamedExec(`UPDATE delivery_queue SET status=:status, attempts=:attempts, last_error=:last_error,
+		next_attempt_at=:next_attempt_at, updated_at=:updated_at WHERE id=:id`, delivery)
+	if err != nil {
+		zap.L().Error("Error in Executing UPDATE to delivery_queue", zap.Error(err))
+	}
+	return err
+}
+
+func (r *ruleDB) DeleteQueuedDelivery(ctx context.Context, id int64) error {
+	_, err := r.Exec("DELETE FROM delivery_queue WHERE id=$1", id)
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE from delivery_queue", zap.Error(err))
+	}
+	return err
+}
+
+// queuedDeliveryBackoffFor returns the wait before the next retry of the delivery, it is
+// as long as the delivery has been queued so that the wait doubles after every retry
+func queuedDeliveryBackoffFor(delivery *QueuedDelivery, now time.Time) time.Duration {
+	backoff := now.Sub(delivery.CreatedAt)
+	if backoff < queuedDeliveryBackoff {
+		return queuedDeliveryBackoff
+	}
+	if backoff > maxQueuedDeliveryBackoff {
+		return maxQueuedDeliveryBackoff
+	}
+	return backoff
+}
+
+// enqueue stores the request failing with a transient error to be retried from the queue
+func (d *channelDelivery) enqueue(ctx context.Context, r *deliveryRequest, attempts int, err error) {
+	_, queueErr := d.ruleDB.QueueDelivery(ctx, QueuedDelivery{
+		Channel:       r.Channel,
+		ChannelType:   r.ChannelType,
+		Request:       *r,
+		Status:        QueuedDeliveryPending,
+		Attempts:      attempts,
+		LastError:     err.Error(),
+		NextAttemptAt: time.Now().Add(queuedDeliveryBackoff),
+	})
+	if queueErr != nil {
+		zap.L().Error("failed to queue the delivery", zap.String("channel", r.Channel), zap.Error(queueErr))
+		return
+	}
+	zap.L().Warn("queued the failed delivery", zap.String("channel", r.Channel), zap.Int("attempts", attempts), zap.Error(err))
+}
+
+// retry sends the queued delivery once, the delivered request is dropped from the queue
+// and the failed one is scheduled again until it runs out of attempts
+func (d *channelDelivery) retry(ctx context.Context, delivery *QueuedDelivery, now time.Time) error {
+	transient, err := d.try(ctx, &delivery.Request, delivery.Attempts+1)
+	if err == nil {
+		d.logNotification(ctx, &delivery.Request, nil)
+		return d.ruleDB.DeleteQueuedDelivery(ctx, delivery.Id)
+	}
+
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+	delivery.NextAttemptAt = now.Add(queuedDeliveryBackoffFor(delivery, now))
+	if !transient || delivery.Attempts >= maxQueuedDeliveryAttempts {
+		delivery.Status = QueuedDeliveryDead
+		zap.L().Error("gave up the delivery", zap.String("channel", delivery.Channel), zap.Int("attempts", delivery.Attempts), zap.Error(err))
+	}
+	return d.ruleDB.UpdateQueuedDelivery(ctx, *delivery)
+}
+
+// retryQueue retries the due deliveries of the queue
+func (d *channelDelivery) retryQueue(ctx context.Context, now time.Time) {
+	deliveries, err := d.ruleDB.GetQueuedDeliveries(ctx, &QueuedDeliveryFilter{Status: QueuedDeliveryPending, DueBy: now, Limit: deliveryQueueBatch})
+	if err != nil {
+		zap.L().Error("failed to fetch the queued deliveries", zap.Error(err))
+		return
+	}
+	for i := range deliveries {
+		if err := d.retry(ctx, &deliveries[i], now); err != nil {
+			zap.L().Error("failed to update the queued delivery", zap.Int64("id", deliveries[i].Id), zap.Error(err))
+		}
+	}
+}
+
+func (d *channelDelivery) retryQueueLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(deliveryQueueInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			d.retryQueue(context.Background(), time.Now())
+		}
+	}
+}
+
+// RetryQueuedDelivery schedules the queued delivery, e.g the dead one, to be retried on the next run of the queue
+func (m *Manager) RetryQueuedDelivery(ctx context.Context, id string) (*QueuedDelivery, error) {
+	delivery, err := m.ruleDB.GetQueuedDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Status = QueuedDeliveryPending
+	delivery.NextAttemptAt = time.Now()
+	if err := m.ruleDB.UpdateQueuedDelivery(ctx, *delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
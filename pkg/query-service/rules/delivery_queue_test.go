@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+func TestDeliveryQueue(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer server.Close()
+
+	db := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil).(*ruleDB)
+	delivery := newChannelDelivery(db)
+	delivery.backoff = time.Millisecond
+	ctx := context.Background()
+
+	request := func(channel string) *deliveryRequest {
+		return &deliveryRequest{Channel: channel, ChannelType: "webhook", URL: server.URL, Body: []byte(`{}`), Fingerprints: []string{"fp"}, Retries: 2}
+	}
+
+	// the request failing after all the retries is queued
+	assert.Error(t, delivery.send(ctx, request("flaky")))
+	queued, err := db.GetQueuedDeliveries(ctx, nil)
+	assert.NoError(t, err)
+	if !assert.Len(t, queued, 1) {
+		return
+	}
+	assert.Equal(t, QueuedDeliveryPending, queued[0].Status)
+	assert.Equal(t, 3, queued[0].Attempts)
+	assert.Equal(t, server.URL, queued[0].Request.URL)
+
+	// the queued request isn't retried before it is due
+	delivery.retryQueue(ctx, time.Now())
+	attempts, err := db.GetDeliveryAttempts(ctx, &DeliveryAttemptFilter{Channel: "flaky"})
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 3)
+
+	// the delivered request is dropped from the queue
+	status.Store(http.StatusOK)
+	delivery.retryQueue(ctx, time.Now().Add(2*queuedDeliveryBackoff))
+	queued, err = db.GetQueuedDeliveries(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, queued)
+	attempts, err = db.GetDeliveryAttempts(ctx, &DeliveryAttemptFilter{Channel: "flaky"})
+	assert.NoError(t, err)
+	if assert.Len(t, attempts, 4) {
+		assert.Equal(t, DeliveryStatusSuccess, attempts[0].Status)
+		assert.Equal(t, 4, attempts[0].Attempt)
+	}
+
+	// the request rejected on retry is given up
+	status.Store(http.StatusServiceUnavailable)
+	assert.Error(t, delivery.send(ctx, request("rejected")))
+	status.Store(http.StatusBadRequest)
+	delivery.retryQueue(ctx, time.Now().Add(2*queuedDeliveryBackoff))
+	queued, err = db.GetQueuedDeliveries(ctx, &QueuedDeliveryFilter{Status: QueuedDeliveryDead})
+	assert.NoError(t, err)
+	if !assert.Len(t, queued, 1) {
+		return
+	}
+	assert.Equal(t, 4, queued[0].Attempts)
+	assert.Contains(t, queued[0].LastError, "400")
+
+	// the dead delivery is retried again on request
+	m := &Manager{ruleDB: db}
+	retried, err := m.RetryQueuedDelivery(ctx, strconv.FormatInt(queued[0].Id, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, QueuedDeliveryPending, retried.Status)
+	status.Store(http.StatusOK)
+	delivery.retryQueue(ctx, time.Now().Add(time.Second))
+	queued, err = db.GetQueuedDeliveries(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, queued)
+}
+
+func TestQueuedDeliveryBackoff(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	delivery := &QueuedDelivery{CreatedAt: created}
+	assert.Equal(t, queuedDeliveryBackoff, queuedDeliveryBackoffFor(delivery, created.Add(10*time.Second)))
+	assert.Equal(t, 20*time.Minute, queuedDeliveryBackoffFor(delivery, created.Add(20*time.Minute)))
+	assert.Equal(t, maxQueuedDeliveryBackoff, queuedDeliveryBackoffFor(delivery, created.Add(5*time.Hour)))
+}
@@ -0,0 +1,156 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// deltaLookbackSteps is the number of the last steps of the window queried again by
+// the delta evaluation, the events of the latest steps may not all be ingested yet
+const deltaLookbackSteps = 2
+
+// deltaEvalOperators are the aggregations computed for every step on its own, the
+// points of the steps already queried don't change with the new data
+var deltaEvalOperators = map[v3.AggregateOperator]struct{}{
+	v3.AggregateOperatorCount:         {},
+	v3.AggregateOperatorCountDistinct: {},
+	v3.AggregateOperatorSum:           {},
+}
+
+// validateDeltaEval checks the queries of the rule can be evaluated incrementally,
+// the logs and traces queries counting the events of every step without the
+// functions and the limit that depend on the whole window
+func (rc *RuleCondition) validateDeltaEval() error {
+	if rc.QueryType() != v3.QueryTypeBuilder {
+		return errors.Errorf("delta evaluation supports only the builder queries")
+	}
+	for name, q := range rc.CompositeQuery.BuilderQueries {
+		if q.Disabled || q.Expression != name {
+			// the formulas are computed for every point of their queries
+			continue
+		}
+		if q.DataSource != v3.DataSourceLogs && q.DataSource != v3.DataSourceTraces {
+			return errors.Errorf("delta evaluation supports only the logs and traces queries, query %s is on %s", name, q.DataSource)
+		}
+		if _, ok := deltaEvalOperators[q.AggregateOperator]; !ok {
+			return errors.Errorf("delta evaluation supports only the count, count distinct and sum aggregations, query %s uses %s", name, q.AggregateOperator)
+		}
+		if len(q.Functions) > 0 || q.Limit > 0 {
+			return errors.Errorf("delta evaluation doesn't support the functions and the limit, query %s uses them", name)
+		}
+	}
+	return nil
+}
+
+// deltaWindow keeps the points of the eval window of the rule in the delta evaluation
+// mode, the evaluations query only the steps since the previous evaluation and
+// merge them into the window instead of querying the whole window every time
+type deltaWindow struct {
+	// step is the step of the points in milliseconds
+	step int64
+	// end is where the data queried so far ends
+	end    int64
+	series map[uint64]*v3.Series
+}
+
+func newDeltaWindow() *deltaWindow {
+	return &deltaWindow{series: map[uint64]*v3.Series{}}
+}
+
+// queryStart returns where the query of the window [start, end] starts, the whole
+// window is queried when the points kept don't cover its beginning
+func (w *deltaWindow) queryStart(start, end, step int64) int64 {
+	if w.step != step || w.end == 0 || w.end < start || w.end > end {
+		w.step, w.end, w.series = step, 0, map[uint64]*v3.Series{}
+		return start
+	}
+	from := w.end - w.end%step - (deltaLookbackSteps-1)*step
+	return max(from, start)
+}
+
+// merge replaces the points of the window since from with the result of the query,
+// drops the points before the window and returns the result for the whole window
+func (w *deltaWindow) merge(result *v3.Result, start, from, end int64) *v3.Result {
+	windowStart := start - start%w.step
+	for h, series := range w.series {
+		points := series.Points[:0]
+		for _, p := range series.Points {
+			if p.Timestamp >= windowStart && p.Timestamp < from {
+				points = append(points, p)
+			}
+		}
+		series.Points = points
+		if len(points) == 0 {
+			delete(w.series, h)
+		}
+	}
+
+	merged := &v3.Result{}
+	if result != nil {
+		merged.QueryName = result.QueryName
+		for _, series := range result.Series {
+			h := labels.FromMap(series.Labels).Hash()
+			kept, ok := w.series[h]
+			if !ok {
+				kept = &v3.Series{Labels: series.Labels, LabelsArray: series.LabelsArray}
+				w.series[h] = kept
+			}
+			for _, p := range series.Points {
+				if p.Timestamp >= windowStart {
+					kept.Points = append(kept.Points, p)
+				}
+			}
+			sort.Slice(kept.Points, func(i, j int) bool {
+				return kept.Points[i].Timestamp < kept.Points[j].Timestamp
+			})
+		}
+	}
+	w.end = end
+
+	hashes := make([]uint64, 0, len(w.series))
+	for h, series := range w.series {
+		if len(series.Points) == 0 {
+			delete(w.series, h)
+			continue
+		}
+		hashes = append(hashes, h)
+	}
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	for _, h := range hashes {
+		series := *w.series[h]
+		series.Points = append([]v3.Point(nil), series.Points...)
+		merged.Series = append(merged.Series, &series)
+	}
+	return merged
+}
+
+// runDeltaQuery queries the steps of the eval window since the previous evaluation
+// and returns the result of the selected query for the whole window
+func (r *ThresholdRule) runDeltaQuery(ctx context.Context, params *v3.QueryRangeParamsV3) (*v3.Result, error) {
+	var step int64
+	if q, ok := params.CompositeQuery.BuilderQueries[r.GetSelectedQuery()]; ok {
+		step = q.StepInterval * 1000
+	}
+	if step <= 0 {
+		return r.runQuery(ctx, params)
+	}
+
+	start, end := params.Start, params.End
+	from := r.delta.queryStart(start, end, step)
+	if from > start {
+		EvalTraceFromContext(ctx).AddNote(fmt.Sprintf("delta evaluation queried the data since %s", time.UnixMilli(from).UTC().Format(time.RFC3339)))
+	}
+	params.Start = from
+
+	result, err := r.runQuery(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return r.delta.merge(result, start, from, end), nil
+}
@@ -0,0 +1,70 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func deltaSeries(service string, points ...v3.Point) *v3.Series {
+	return &v3.Series{Labels: map[string]string{"service": service}, Points: points}
+}
+
+func TestDeltaWindow(t *testing.T) {
+	const minute = int64(60_000)
+	w := newDeltaWindow()
+
+	// the first evaluation queries the whole window
+	from := w.queryStart(0, 5*minute, minute)
+	assert.Equal(t, int64(0), from)
+	res := w.merge(&v3.Result{QueryName: "A", Series: []*v3.Series{
+		deltaSeries("frontend", v3.Point{Timestamp: 0, Value: 1}, v3.Point{Timestamp: 2 * minute, Value: 2}, v3.Point{Timestamp: 4 * minute, Value: 3}),
+		deltaSeries("backend", v3.Point{Timestamp: 0, Value: 5}),
+	}}, 0, from, 5*minute)
+	assert.Len(t, res.Series, 2)
+
+	// the next evaluation queries the last steps again and the new ones
+	from = w.queryStart(minute, 6*minute, minute)
+	assert.Equal(t, 4*minute, from)
+	res = w.merge(&v3.Result{QueryName: "A", Series: []*v3.Series{
+		deltaSeries("frontend", v3.Point{Timestamp: 4 * minute, Value: 4}, v3.Point{Timestamp: 5 * minute, Value: 6}),
+	}}, minute, from, 6*minute)
+
+	assert.Equal(t, "A", res.QueryName)
+	// the backend series only had points before the window
+	if assert.Len(t, res.Series, 1) {
+		assert.Equal(t, []v3.Point{
+			{Timestamp: 2 * minute, Value: 2},
+			{Timestamp: 4 * minute, Value: 4},
+			{Timestamp: 5 * minute, Value: 6},
+		}, res.Series[0].Points)
+	}
+
+	// the window is queried again when the evaluations stopped for longer than the window
+	assert.Equal(t, 20*minute, w.queryStart(20*minute, 25*minute, minute))
+	assert.Empty(t, w.series)
+}
+
+func TestValidateDeltaEval(t *testing.T) {
+	condition := func(q *v3.BuilderQuery) *RuleCondition {
+		return &RuleCondition{CompositeQuery: &v3.CompositeQuery{
+			QueryType:      v3.QueryTypeBuilder,
+			BuilderQueries: map[string]*v3.BuilderQuery{"A": q},
+		}}
+	}
+
+	assert.NoError(t, condition(&v3.BuilderQuery{
+		QueryName: "A", Expression: "A", DataSource: v3.DataSourceLogs, AggregateOperator: v3.AggregateOperatorCount,
+	}).validateDeltaEval())
+	assert.Error(t, condition(&v3.BuilderQuery{
+		QueryName: "A", Expression: "A", DataSource: v3.DataSourceMetrics, AggregateOperator: v3.AggregateOperatorSum,
+	}).validateDeltaEval())
+	assert.Error(t, condition(&v3.BuilderQuery{
+		QueryName: "A", Expression: "A", DataSource: v3.DataSourceTraces, AggregateOperator: v3.AggregateOperatorP99,
+	}).validateDeltaEval())
+	assert.Error(t, condition(&v3.BuilderQuery{
+		QueryName: "A", Expression: "A", DataSource: v3.DataSourceLogs, AggregateOperator: v3.AggregateOperatorCount, Limit: 10,
+	}).validateDeltaEval())
+	assert.Error(t, (&RuleCondition{CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypeClickHouseSQL}}).validateDeltaEval())
+}
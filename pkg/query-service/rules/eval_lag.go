@@ -0,0 +1,146 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultEvalLagThreshold is how late the evaluation starts before the rule is lagging
+	DefaultEvalLagThreshold = 30 * time.Second
+
+	// evalLagAlertName is the name of the meta alert sent while rules are lagging
+	evalLagAlertName = "Rule evaluations lagging"
+)
+
+// evalLagTracker keeps how late the last evaluation of every rule started after its
+// scheduled time, the engine is overloaded when the rules lag past the threshold
+type evalLagTracker struct {
+	threshold time.Duration
+	// onChange is called when the first rule lags and when no rule lags anymore
+	onChange func(lagging []string, lag time.Duration)
+
+	mtx     sync.Mutex
+	lags    map[string]time.Duration
+	lagging map[string]struct{}
+}
+
+func newEvalLagTracker(threshold time.Duration, onChange func(lagging []string, lag time.Duration)) *evalLagTracker {
+	if threshold <= 0 {
+		threshold = DefaultEvalLagThreshold
+	}
+	return &evalLagTracker{
+		threshold: threshold,
+		onChange:  onChange,
+		lags:      map[string]time.Duration{},
+		lagging:   map[string]struct{}{},
+	}
+}
+
+// record keeps the lag of the evaluation of the rule scheduled at ts and started at now
+func (t *evalLagTracker) record(ruleId string, ts, now time.Time) {
+	if t == nil {
+		return
+	}
+	lag := max(now.Sub(ts), 0)
+	evalLag.WithLabelValues(ruleId).Set(lag.Seconds())
+
+	t.mtx.Lock()
+	t.lags[ruleId] = lag
+	before := len(t.lagging)
+	if lag > t.threshold {
+		if _, ok := t.lagging[ruleId]; !ok {
+			zap.L().Warn("rule evaluation is lagging", zap.String("ruleid", ruleId), zap.Duration("lag", lag))
+		}
+		t.lagging[ruleId] = struct{}{}
+	} else {
+		delete(t.lagging, ruleId)
+	}
+	changed := (before == 0) != (len(t.lagging) == 0)
+	lagging, maxLag := t.laggingLocked()
+	t.mtx.Unlock()
+
+	if changed && t.onChange != nil {
+		t.onChange(lagging, maxLag)
+	}
+}
+
+// laggingLocked returns the lagging rules and the longest lag, it's called with the lock held
+func (t *evalLagTracker) laggingLocked() ([]string, time.Duration) {
+	var maxLag time.Duration
+	lagging := make([]string, 0, len(t.lagging))
+	for ruleId := range t.lagging {
+		lagging = append(lagging, ruleId)
+		maxLag = max(maxLag, t.lags[ruleId])
+	}
+	sort.Strings(lagging)
+	return lagging, maxLag
+}
+
+// get returns the lag of the last evaluation of the rule and whether it's lagging
+func (t *evalLagTracker) get(ruleId string) (time.Duration, bool) {
+	if t == nil {
+		return 0, false
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	_, lagging := t.lagging[ruleId]
+	return t.lags[ruleId], lagging
+}
+
+// forget drops the lag of the rule no longer evaluated
+func (t *evalLagTracker) forget(ruleId string) {
+	if t == nil {
+		return
+	}
+	t.mtx.Lock()
+	delete(t.lags, ruleId)
+	_, wasLagging := t.lagging[ruleId]
+	delete(t.lagging, ruleId)
+	changed := wasLagging && len(t.lagging) == 0
+	lagging, maxLag := t.laggingLocked()
+	t.mtx.Unlock()
+
+	if changed && t.onChange != nil {
+		t.onChange(lagging, maxLag)
+	}
+}
+
+// evalLagAlert is the meta alert firing while the rules are lagging
+func evalLagAlert(lagging []string, lag, threshold time.Duration, channels []string, now time.Time) *Alert {
+	alert := &Alert{
+		State: model.StateFiring,
+		Labels: labels.FromMap(map[string]string{
+			labels.AlertNameLabel: evalLagAlertName,
+			"severity":            "warning",
+		}),
+		Annotations: labels.FromMap(map[string]string{
+			labels.AlertSummaryLabel:     fmt.Sprintf("%d rules are evaluated more than %s late, the alerts of the rules are delayed", len(lagging), threshold),
+			labels.AlertDescriptionLabel: fmt.Sprintf("The longest evaluation lag is %s", lag.Round(time.Second)),
+		}),
+		Receivers:  channels,
+		ActiveAt:   now,
+		FiredAt:    now,
+		LastSentAt: now,
+		ValidUntil: now.Add(breakerAlertValidity),
+	}
+	if len(lagging) == 0 {
+		alert.State, alert.ResolvedAt = model.StateInactive, now
+		alert.Annotations = labels.FromMap(map[string]string{
+			labels.AlertSummaryLabel: "The rules are evaluated on time again",
+		})
+	}
+	return alert
+}
+
+// notifyEvalLag sends the meta alert when the first rule lags and resolves it when no rule lags
+func (m *Manager) notifyEvalLag(lagging []string, lag time.Duration) {
+	m.send(context.Background(), []*Alert{evalLagAlert(lagging, lag, m.opts.evalLag.threshold, m.opts.MetaAlertChannels, time.Now())})
+}
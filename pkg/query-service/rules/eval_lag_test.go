@@ -0,0 +1,49 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestEvalLagTracker(t *testing.T) {
+	type change struct {
+		lagging []string
+		lag     time.Duration
+	}
+	var changes []change
+	tracker := newEvalLagTracker(30*time.Second, func(lagging []string, lag time.Duration) {
+		changes = append(changes, change{lagging, lag})
+	})
+	ts := time.Now()
+
+	tracker.record("1", ts, ts.Add(time.Second))
+	lag, lagging := tracker.get("1")
+	assert.Equal(t, time.Second, lag)
+	assert.False(t, lagging)
+	assert.Empty(t, changes)
+
+	// the meta alert fires with the first lagging rule only
+	tracker.record("1", ts, ts.Add(time.Minute))
+	tracker.record("2", ts, ts.Add(2*time.Minute))
+	_, lagging = tracker.get("1")
+	assert.True(t, lagging)
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, []string{"1"}, changes[0].lagging)
+	}
+
+	// and resolves once no rule lags
+	tracker.record("1", ts, ts)
+	tracker.forget("2")
+	if assert.Len(t, changes, 2) {
+		assert.Empty(t, changes[1].lagging)
+	}
+
+	alert := evalLagAlert([]string{"1", "2"}, 2*time.Minute, 30*time.Second, []string{"oncall"}, ts)
+	assert.Equal(t, model.StateFiring, alert.State)
+	assert.Equal(t, []string{"oncall"}, alert.Receivers)
+	alert = evalLagAlert(nil, 0, 30*time.Second, nil, ts)
+	assert.Equal(t, model.StateInactive, alert.State)
+}
@@ -0,0 +1,194 @@
+package rules
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultEvalConcurrency is the number of rules evaluated at the same time
+// when the concurrency is not configured
+const DefaultEvalConcurrency = 16
+
+// EvalPoolStats are the queueing metrics of the rule evaluations
+type EvalPoolStats struct {
+	// Workers is the number of rules evaluated at the same time
+	Workers int `json:"workers"`
+	// Running and Queued are the evaluations in progress and waiting for a worker
+	Running int `json:"running"`
+	Queued  int `json:"queued"`
+	// QueuedByPriority are the evaluations waiting for a worker by the priority of their rule
+	QueuedByPriority map[RulePriority]int `json:"queuedByPriority"`
+	// Completed is the number of evaluations run, Canceled the ones dropped while
+	// waiting because their rule was stopped
+	Completed int64 `json:"completed"`
+	Canceled  int64 `json:"canceled"`
+	// AvgWaitMs and MaxWaitMs are how long the evaluations waited for a worker
+	AvgWaitMs float64 `json:"avgWaitMs"`
+	MaxWaitMs float64 `json:"maxWaitMs"`
+}
+
+// evalStarvationAge is how long the evaluation waits before it's run ahead of
+// the evaluations of a higher priority
+const evalStarvationAge = 10 * time.Second
+
+// evalPool limits the rules evaluated at the same time, and so the concurrent
+// queries of the rules, the evaluations past the limit wait for a worker. The
+// free worker runs the waiting evaluation of the highest priority, unless an
+// evaluation waited for longer than evalStarvationAge
+type evalPool struct {
+	workers int
+
+	mtx       sync.Mutex
+	waiting   [][]*evalWaiter
+	running   int
+	queued    int
+	completed int64
+	canceled  int64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// evalWaiter is the evaluation waiting for a worker, ready is closed once it has one
+type evalWaiter struct {
+	ready    chan struct{}
+	queuedAt time.Time
+}
+
+func newEvalPool(workers int) *evalPool {
+	if workers <= 0 {
+		workers = DefaultEvalConcurrency
+	}
+	return &evalPool{workers: workers, waiting: make([][]*evalWaiter, len(rulePriorities))}
+}
+
+// run evaluates once a worker is free, the evaluation is dropped when done is
+// closed first. The evaluations run right away without a pool
+func (p *evalPool) run(done <-chan struct{}, priority RulePriority, eval func()) bool {
+	if p == nil {
+		eval()
+		return true
+	}
+	if !p.acquire(done, priority) {
+		return false
+	}
+	defer p.release()
+	eval()
+	return true
+}
+
+// acquire waits for a worker, it returns false when done is closed first
+func (p *evalPool) acquire(done <-chan struct{}, priority RulePriority) bool {
+	p.mtx.Lock()
+	if p.running < p.workers && p.queued == 0 {
+		p.running++
+		p.mtx.Unlock()
+		evalRunning.Inc()
+		return true
+	}
+	waiter := &evalWaiter{ready: make(chan struct{}), queuedAt: time.Now()}
+	rank := priority.rank()
+	p.waiting[rank] = append(p.waiting[rank], waiter)
+	p.queued++
+	p.mtx.Unlock()
+	evalQueueLength.Inc()
+
+	select {
+	case <-waiter.ready:
+		return true
+	case <-done:
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	for i, w := range p.waiting[rank] {
+		if w == waiter {
+			p.waiting[rank] = append(p.waiting[rank][:i], p.waiting[rank][i+1:]...)
+			p.queued--
+			p.canceled++
+			evalQueueLength.Dec()
+			return false
+		}
+	}
+	// the worker was given to the evaluation while it was stopped
+	p.releaseLocked()
+	return false
+}
+
+// release frees the worker of the completed evaluation
+func (p *evalPool) release() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.completed++
+	p.releaseLocked()
+}
+
+// releaseLocked gives the worker to the next waiting evaluation, it's called with the lock held
+func (p *evalPool) releaseLocked() {
+	p.running--
+	evalRunning.Dec()
+	waiter := p.next(time.Now())
+	if waiter == nil {
+		return
+	}
+
+	wait := time.Since(waiter.queuedAt)
+	p.queued--
+	p.running++
+	p.totalWait += wait
+	p.maxWait = max(p.maxWait, wait)
+	evalQueueLength.Dec()
+	evalRunning.Inc()
+	close(waiter.ready)
+}
+
+// next dequeues the evaluation given the free worker, it's called with the lock held
+func (p *evalPool) next(now time.Time) *evalWaiter {
+	oldest, first := -1, -1
+	for rank, waiting := range p.waiting {
+		if len(waiting) == 0 {
+			continue
+		}
+		if first < 0 {
+			first = rank
+		}
+		if oldest < 0 || waiting[0].queuedAt.Before(p.waiting[oldest][0].queuedAt) {
+			oldest = rank
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+	// the starving evaluation runs ahead of the higher priorities
+	rank := first
+	if now.Sub(p.waiting[oldest][0].queuedAt) >= evalStarvationAge {
+		rank = oldest
+	}
+	waiter := p.waiting[rank][0]
+	p.waiting[rank] = p.waiting[rank][1:]
+	return waiter
+}
+
+// stats returns the queueing metrics of the pool
+func (p *evalPool) stats() EvalPoolStats {
+	if p == nil {
+		return EvalPoolStats{}
+	}
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	stats := EvalPoolStats{
+		Workers:          p.workers,
+		Running:          p.running,
+		Queued:           p.queued,
+		QueuedByPriority: map[RulePriority]int{},
+		Completed:        p.completed,
+		Canceled:         p.canceled,
+		MaxWaitMs:        float64(p.maxWait) / float64(time.Millisecond),
+	}
+	for rank, waiting := range p.waiting {
+		stats.QueuedByPriority[rulePriorities[rank]] = len(waiting)
+	}
+	if started := p.completed + int64(p.running); started > 0 {
+		stats.AvgWaitMs = float64(p.totalWait) / float64(started) / float64(time.Millisecond)
+	}
+	return stats
+}
@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvalPool(t *testing.T) {
+	pool := newEvalPool(2)
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pool.run(nil, RulePriorityNormal, func() {
+				started <- struct{}{}
+				<-release
+			})
+		}()
+	}
+
+	// only two evaluations run at the same time
+	<-started
+	<-started
+	assert.Eventually(t, func() bool { return pool.stats().Queued == 1 }, time.Second, time.Millisecond)
+	stats := pool.stats()
+	assert.Equal(t, 2, stats.Workers)
+	assert.Equal(t, 2, stats.Running)
+
+	close(release)
+	wg.Wait()
+	stats = pool.stats()
+	assert.Equal(t, 0, stats.Running)
+	assert.Equal(t, 0, stats.Queued)
+	assert.Equal(t, int64(3), stats.Completed)
+	assert.Greater(t, stats.MaxWaitMs, 0.0)
+}
+
+func TestEvalPoolCanceled(t *testing.T) {
+	pool := newEvalPool(1)
+	release := make(chan struct{})
+	go pool.run(nil, RulePriorityNormal, func() { <-release })
+	assert.Eventually(t, func() bool { return pool.stats().Running == 1 }, time.Second, time.Millisecond)
+
+	// the evaluation of a stopped rule is dropped while waiting
+	done := make(chan struct{})
+	close(done)
+	assert.False(t, pool.run(done, RulePriorityNormal, func() { t.Fatal("the canceled evaluation ran") }))
+	assert.Equal(t, int64(1), pool.stats().Canceled)
+	close(release)
+
+	var unbounded *evalPool
+	ran := false
+	assert.True(t, unbounded.run(nil, RulePriorityNormal, func() { ran = true }))
+	assert.True(t, ran)
+}
+
+func TestEvalPoolPriority(t *testing.T) {
+	pool := newEvalPool(1)
+	release := make(chan struct{})
+	go pool.run(nil, RulePriorityNormal, func() { <-release })
+	assert.Eventually(t, func() bool { return pool.stats().Running == 1 }, time.Second, time.Millisecond)
+
+	var mtx sync.Mutex
+	var order []RulePriority
+	var wg sync.WaitGroup
+	for _, priority := range []RulePriority{RulePriorityLow, "", RulePriorityCritical} {
+		wg.Add(1)
+		go func(priority RulePriority) {
+			defer wg.Done()
+			pool.run(nil, priority, func() {
+				mtx.Lock()
+				order = append(order, priority)
+				mtx.Unlock()
+			})
+		}(priority)
+		assert.Eventually(t, func() bool { return pool.stats().QueuedByPriority[RulePriority(priority.String())] == 1 }, time.Second, time.Millisecond)
+	}
+
+	// the waiting evaluations run by priority
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []RulePriority{RulePriorityCritical, "", RulePriorityLow}, order)
+}
+
+func TestEvalPoolStarvation(t *testing.T) {
+	pool := newEvalPool(1)
+	now := time.Now()
+	starving := &evalWaiter{ready: make(chan struct{}), queuedAt: now.Add(-evalStarvationAge)}
+	critical := &evalWaiter{ready: make(chan struct{}), queuedAt: now}
+	pool.waiting[RulePriorityLow.rank()] = []*evalWaiter{starving}
+	pool.waiting[RulePriorityCritical.rank()] = []*evalWaiter{critical}
+
+	// the evaluation waiting for too long runs ahead of the higher priorities
+	assert.Equal(t, starving, pool.next(now))
+	assert.Equal(t, critical, pool.next(now))
+	assert.Nil(t, pool.next(now))
+}
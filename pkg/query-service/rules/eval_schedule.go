@@ -0,0 +1,32 @@
+package rules
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// nextEvalTimestamp returns the slot evaluated when the task wakes up at now after
+// evaluating the slot at last, and the number of the slots skipped. The slots are
+// frequency apart on the wall clock, the task evaluates the latest slot that has
+// started rather than every slot it slept through, so the windows of the
+// evaluations stay aligned to the slots however long the evaluations ran
+func nextEvalTimestamp(last, now time.Time, frequency time.Duration) (time.Time, int64) {
+	slots := int64(now.Sub(last) / frequency)
+	if slots < 1 {
+		// the timer fired early as the wall clock was set back
+		return last.Add(frequency), 0
+	}
+	return last.Add(time.Duration(slots) * frequency), slots - 1
+}
+
+// recordMissedEvals counts the slots skipped by the rules of the task
+func recordMissedEvals(rules []Rule, missed int64) {
+	if missed <= 0 {
+		return
+	}
+	for _, rule := range rules {
+		ruleEvalsMissed.WithLabelValues(rule.ID()).Add(float64(missed))
+		zap.L().Warn("rule evaluations skipped as the previous evaluation ran past them", zap.String("ruleid", rule.ID()), zap.Int64("missed", missed))
+	}
+}
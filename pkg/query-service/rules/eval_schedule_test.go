@@ -0,0 +1,49 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextEvalTimestamp(t *testing.T) {
+	last := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+		missed   int64
+	}{
+		{
+			name:     "on time",
+			now:      last.Add(time.Minute + 10*time.Millisecond),
+			expected: last.Add(time.Minute),
+		},
+		{
+			name:     "evaluation ran past the next slot",
+			now:      last.Add(time.Minute + 30*time.Second),
+			expected: last.Add(time.Minute),
+		},
+		{
+			name:     "evaluation ran past several slots",
+			now:      last.Add(3*time.Minute + 30*time.Second),
+			expected: last.Add(3 * time.Minute),
+			missed:   2,
+		},
+		{
+			name:     "wall clock set back",
+			now:      last.Add(20 * time.Second),
+			expected: last.Add(time.Minute),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ts, missed := nextEvalTimestamp(last, c.now, time.Minute)
+			assert.True(t, ts.Equal(c.expected), "expected %s, got %s", c.expected, ts)
+			assert.Equal(t, c.missed, missed)
+		})
+	}
+}
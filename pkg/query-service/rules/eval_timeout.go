@@ -0,0 +1,57 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// evalTimeout returns how long the rule is evaluated before its queries are
+// canceled, the evaluation frequency when the rule has no timeout
+func evalTimeout(rule Rule, frequency time.Duration) time.Duration {
+	if timeout := rule.EvalTimeout(); timeout > 0 {
+		return timeout
+	}
+	return frequency
+}
+
+// evalWithTimeout evaluates the rule, the queries still running after the
+// timeout are canceled so a stuck query doesn't block the next evaluations
+func evalWithTimeout(ctx context.Context, rule Rule, ts time.Time, frequency time.Duration) error {
+	timeout := evalTimeout(rule, frequency)
+	evalCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := rule.Eval(evalCtx, ts)
+	if err != nil && errors.Is(evalCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("evaluation timed out after %s: %w", timeout, err)
+	}
+	return err
+}
+
+// queryWithContext runs the query until the context is done, the queries not
+// honoring the cancellation are left to finish in the background
+func queryWithContext[T any](ctx context.Context, query func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := query()
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestQueryWithContext(t *testing.T) {
+	value, err := queryWithContext(context.Background(), func() (int, error) { return 1, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, value)
+
+	// the stuck query is given up once the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	stuck := make(chan struct{})
+	defer close(stuck)
+	_, err = queryWithContext(ctx, func() (int, error) {
+		<-stuck
+		return 1, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the query is not run once the context is done
+	_, err = queryWithContext(ctx, func() (int, error) {
+		t.Fatal("the query ran after the context was done")
+		return 0, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestEvalTimeout(t *testing.T) {
+	postableRule := PostableRule{
+		AlertName: "Test Eval Timeout",
+		AlertType: AlertTypeMetric,
+		RuleType:  RuleTypeThreshold,
+		Frequency: Duration(5 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeClickHouseSQL,
+				ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+					"A": {Query: "SELECT 1"},
+				},
+			},
+		},
+	}
+	fm := featureManager.StartManager()
+
+	// the frequency is the timeout when none is configured
+	rule, err := NewThresholdRule("70", &postableRule, fm, nil, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 5*time.Minute, evalTimeout(rule, 5*time.Minute))
+
+	rule, err = NewThresholdRule("70", &postableRule, fm, nil, true, true, WithEvalTimeout(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, evalTimeout(rule, 5*time.Minute))
+
+	// the rule level timeout overrides the manager level timeout
+	postableRule.EvalTimeout = Duration(2 * time.Minute)
+	rule, err = NewThresholdRule("70", &postableRule, fm, nil, true, true, WithEvalTimeout(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, evalTimeout(rule, 5*time.Minute))
+}
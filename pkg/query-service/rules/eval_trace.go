@@ -0,0 +1,326 @@
+package rules
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+const (
+	// DefaultEvalTraceDuration is how long the evaluations of the rule are traced
+	// when the duration is not given
+	DefaultEvalTraceDuration = 15 * time.Minute
+	// maxEvalTraceDuration bounds the tracing forgotten on a rule
+	maxEvalTraceDuration = 24 * time.Hour
+	// evalTracesPerRule is the number of the last traces kept for the rule
+	evalTracesPerRule = 10
+	// evalTraceMaxSeries bounds the series and the decisions of the trace
+	evalTraceMaxSeries = 100
+)
+
+type evalTraceKey struct{}
+
+// EvalTrace is what the evaluation of the rule queried and decided, it's captured
+// for the rules in the debug mode to find out why they did or didn't fire
+type EvalTrace struct {
+	RuleID      string    `json:"ruleId"`
+	EvaluatedAt time.Time `json:"evaluatedAt"`
+	DurationMs  float64   `json:"durationMs"`
+	Error       string    `json:"error,omitempty"`
+
+	// Params are the query params of the evaluation once enriched
+	Params *v3.QueryRangeParamsV3 `json:"params,omitempty"`
+	// Queries are the queries generated for the datastore
+	Queries []string `json:"queries,omitempty"`
+	// Results are the raw series of the selected query by result name
+	Results map[string][]*v3.Series `json:"results,omitempty"`
+	// Decisions are whether every series matched the rule condition
+	Decisions []EvalDecision `json:"decisions,omitempty"`
+	// Notes explain the decisions taken for the whole result
+	Notes []string `json:"notes,omitempty"`
+	// Truncated is set when the series past evalTraceMaxSeries were dropped
+	Truncated bool `json:"truncated,omitempty"`
+	// ActiveAlerts are the alerts of the rule after the evaluation by state
+	ActiveAlerts map[string]int `json:"activeAlerts,omitempty"`
+
+	mtx sync.Mutex
+}
+
+// EvalDecision is whether the series matched the rule condition
+type EvalDecision struct {
+	Labels      map[string]string `json:"labels"`
+	Points      int               `json:"points"`
+	ShouldAlert bool              `json:"shouldAlert"`
+	// Value is the value compared to the target for the matching series
+	Value  *float64 `json:"value,omitempty"`
+	Target float64  `json:"target"`
+}
+
+// EvalTraceFromContext returns the trace of the evaluation, nil when the rule isn't traced
+func EvalTraceFromContext(ctx context.Context) *EvalTrace {
+	trace, _ := ctx.Value(evalTraceKey{}).(*EvalTrace)
+	return trace
+}
+
+func withEvalTrace(ctx context.Context, trace *EvalTrace) context.Context {
+	if trace == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, evalTraceKey{}, trace)
+}
+
+// SetParams records the query params of the evaluation
+func (t *EvalTrace) SetParams(params *v3.QueryRangeParamsV3) {
+	if t == nil || params == nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	clone := *params
+	clone.CompositeQuery = params.CompositeQuery.Clone()
+	t.Params = &clone
+}
+
+// AddQuery records the query sent to the datastore
+func (t *EvalTrace) AddQuery(query string) {
+	if t == nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.Queries = append(t.Queries, query)
+}
+
+// AddSeries records the raw series of the result
+func (t *EvalTrace) AddSeries(name string, series []*v3.Series) {
+	if t == nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if t.Results == nil {
+		t.Results = map[string][]*v3.Series{}
+	}
+	if len(series) > evalTraceMaxSeries {
+		series, t.Truncated = series[:evalTraceMaxSeries], true
+	}
+	t.Results[name] = cloneResult(&v3.Result{Series: series}).Series
+}
+
+// AddDecision records whether the series matched the rule condition
+func (t *EvalTrace) AddDecision(series *v3.Series, sample Sample, shouldAlert bool, target float64) {
+	if t == nil || series == nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if len(t.Decisions) >= evalTraceMaxSeries {
+		t.Truncated = true
+		return
+	}
+	decision := EvalDecision{
+		Labels:      make(map[string]string, len(series.Labels)),
+		Points:      len(series.Points),
+		ShouldAlert: shouldAlert,
+		Target:      target,
+	}
+	for k, v := range series.Labels {
+		decision.Labels[k] = v
+	}
+	if shouldAlert {
+		value := sample.V
+		decision.Value = &value
+	}
+	t.Decisions = append(t.Decisions, decision)
+}
+
+// AddNote records the decision taken for the whole result
+func (t *EvalTrace) AddNote(note string) {
+	if t == nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.Notes = append(t.Notes, note)
+}
+
+// finish records the outcome of the evaluation
+func (t *EvalTrace) finish(rule Rule, duration time.Duration, err error, stats *model.QueryStats) {
+	if t == nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.DurationMs = float64(duration) / float64(time.Millisecond)
+	if err != nil {
+		t.Error = err.Error()
+	}
+	if stats != nil {
+		t.Queries = append(t.Queries, stats.Queries()...)
+	}
+	t.ActiveAlerts = map[string]int{}
+	for _, alert := range rule.ActiveAlerts() {
+		t.ActiveAlerts[alert.State.String()]++
+	}
+}
+
+// snapshot copies the trace so it's read while the evaluation goes on
+func (t *EvalTrace) snapshot() *EvalTrace {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	snapshot := &EvalTrace{
+		RuleID:       t.RuleID,
+		EvaluatedAt:  t.EvaluatedAt,
+		DurationMs:   t.DurationMs,
+		Error:        t.Error,
+		Params:       t.Params,
+		Queries:      t.Queries,
+		Decisions:    t.Decisions,
+		Notes:        t.Notes,
+		Truncated:    t.Truncated,
+		ActiveAlerts: t.ActiveAlerts,
+	}
+	if t.Results != nil {
+		snapshot.Results = make(map[string][]*v3.Series, len(t.Results))
+		for name, series := range t.Results {
+			snapshot.Results[name] = series
+		}
+	}
+	return snapshot
+}
+
+// EvalTraceStatus is whether the evaluations of the rule are traced and the last traces
+type EvalTraceStatus struct {
+	RuleID  string       `json:"ruleId"`
+	Enabled bool         `json:"enabled"`
+	Until   *time.Time   `json:"until,omitempty"`
+	Traces  []*EvalTrace `json:"traces"`
+}
+
+type ruleTraces struct {
+	until  time.Time
+	traces []*EvalTrace
+}
+
+// evalTracer keeps the traces of the evaluations of the rules in the debug mode
+type evalTracer struct {
+	mtx   sync.Mutex
+	rules map[string]*ruleTraces
+}
+
+func newEvalTracer() *evalTracer {
+	return &evalTracer{rules: map[string]*ruleTraces{}}
+}
+
+// enable traces the evaluations of the rule until the given time
+func (t *evalTracer) enable(ruleId string, until time.Time) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	traces, ok := t.rules[ruleId]
+	if !ok {
+		traces = &ruleTraces{}
+		t.rules[ruleId] = traces
+	}
+	traces.until = until
+}
+
+// disable stops tracing the evaluations of the rule, its traces are kept
+func (t *evalTracer) disable(ruleId string) {
+	if t == nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if traces, ok := t.rules[ruleId]; ok {
+		traces.until = time.Time{}
+	}
+}
+
+// start returns the trace of the evaluation of the rule at ts, nil when the rule isn't traced
+func (t *evalTracer) start(rule Rule, ts time.Time) *EvalTrace {
+	if t == nil {
+		return nil
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	traces, ok := t.rules[rule.ID()]
+	if !ok || !time.Now().Before(traces.until) {
+		return nil
+	}
+	trace := &EvalTrace{RuleID: rule.ID(), EvaluatedAt: ts}
+	traces.traces = append(traces.traces, trace)
+	if len(traces.traces) > evalTracesPerRule {
+		traces.traces = traces.traces[len(traces.traces)-evalTracesPerRule:]
+	}
+	return trace
+}
+
+// status returns whether the rule is traced and its last traces, the newest first
+func (t *evalTracer) status(ruleId string) *EvalTraceStatus {
+	status := &EvalTraceStatus{RuleID: ruleId, Traces: []*EvalTrace{}}
+	if t == nil {
+		return status
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	traces, ok := t.rules[ruleId]
+	if !ok {
+		return status
+	}
+	if time.Now().Before(traces.until) {
+		until := traces.until
+		status.Enabled, status.Until = true, &until
+	}
+	for i := len(traces.traces) - 1; i >= 0; i-- {
+		status.Traces = append(status.Traces, traces.traces[i].snapshot())
+	}
+	return status
+}
+
+// forget drops the traces of the rule no longer evaluated
+func (t *evalTracer) forget(ruleId string) {
+	if t == nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	delete(t.rules, ruleId)
+}
+
+// EnableEvalTrace captures the evaluations of the rule for the given duration
+func (m *Manager) EnableEvalTrace(ctx context.Context, id string, duration time.Duration) (*EvalTraceStatus, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionEdit); err != nil {
+		return nil, err
+	}
+	m.mtx.RLock()
+	_, ok := m.rules[id]
+	m.mtx.RUnlock()
+	if !ok {
+		return nil, ErrRuleNotLoaded
+	}
+	if duration <= 0 {
+		duration = DefaultEvalTraceDuration
+	}
+	m.opts.tracer.enable(id, time.Now().Add(min(duration, maxEvalTraceDuration)))
+	return m.opts.tracer.status(id), nil
+}
+
+// DisableEvalTrace stops capturing the evaluations of the rule
+func (m *Manager) DisableEvalTrace(ctx context.Context, id string) (*EvalTraceStatus, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionEdit); err != nil {
+		return nil, err
+	}
+	m.opts.tracer.disable(id)
+	return m.opts.tracer.status(id), nil
+}
+
+// EvalTraces returns the last captured evaluations of the rule
+func (m *Manager) EvalTraces(ctx context.Context, id string) (*EvalTraceStatus, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionView); err != nil {
+		return nil, err
+	}
+	return m.opts.tracer.status(id), nil
+}
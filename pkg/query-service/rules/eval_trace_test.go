@@ -0,0 +1,124 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestEvalTracer(t *testing.T) {
+	tracer := newEvalTracer()
+	rule := &ThresholdRule{BaseRule: &BaseRule{id: "1", Active: map[uint64]*Alert{}}}
+	ts := time.Now()
+
+	// the rules are not traced by default
+	assert.Nil(t, tracer.start(rule, ts))
+	assert.Nil(t, EvalTraceFromContext(withEvalTrace(context.Background(), nil)))
+
+	tracer.enable("1", time.Now().Add(time.Minute))
+	trace := tracer.start(rule, ts)
+	ctx := withEvalTrace(context.Background(), trace)
+	assert.Equal(t, trace, EvalTraceFromContext(ctx))
+
+	series := &v3.Series{Labels: map[string]string{"service": "api"}, Points: []v3.Point{{Timestamp: 1, Value: 5}}}
+	EvalTraceFromContext(ctx).AddSeries("A", []*v3.Series{series})
+	EvalTraceFromContext(ctx).AddDecision(series, Sample{Point: Point{V: 5}}, true, 3)
+	EvalTraceFromContext(ctx).AddNote("1 breaching series are not enough to alert")
+	trace.finish(rule, time.Second, errors.New("query failed"), nil)
+
+	// the series of the trace are not changed by the rule
+	series.Labels["service"] = "web"
+
+	status := tracer.status("1")
+	assert.True(t, status.Enabled)
+	if assert.Len(t, status.Traces, 1) {
+		traced := status.Traces[0]
+		assert.Equal(t, "query failed", traced.Error)
+		assert.Equal(t, 1000.0, traced.DurationMs)
+		assert.Equal(t, "api", traced.Results["A"][0].Labels["service"])
+		if assert.Len(t, traced.Decisions, 1) {
+			assert.True(t, traced.Decisions[0].ShouldAlert)
+			assert.Equal(t, 5.0, *traced.Decisions[0].Value)
+		}
+		assert.Len(t, traced.Notes, 1)
+	}
+
+	// the last traces are kept, the newest first
+	for i := 1; i <= evalTracesPerRule; i++ {
+		tracer.start(rule, ts.Add(time.Duration(i)*time.Minute))
+	}
+	status = tracer.status("1")
+	assert.Len(t, status.Traces, evalTracesPerRule)
+	assert.Equal(t, ts.Add(evalTracesPerRule*time.Minute), status.Traces[0].EvaluatedAt)
+
+	tracer.disable("1")
+	assert.Nil(t, tracer.start(rule, ts))
+	status = tracer.status("1")
+	assert.False(t, status.Enabled)
+	assert.Len(t, status.Traces, evalTracesPerRule)
+
+	tracer.forget("1")
+	assert.Empty(t, tracer.status("1").Traces)
+}
+
+func TestEvalTraceNil(t *testing.T) {
+	var trace *EvalTrace
+	trace.SetParams(&v3.QueryRangeParamsV3{})
+	trace.AddQuery("SELECT 1")
+	trace.AddSeries("A", nil)
+	trace.AddDecision(&v3.Series{}, Sample{}, false, 0)
+	trace.AddNote("note")
+	trace.finish(nil, time.Second, nil, nil)
+}
+
+func TestEvalTraceAccess(t *testing.T) {
+	m := newTestManager(t)
+
+	adminGroupId := auth.AuthCacheObj.AdminGroupId
+	auth.AuthCacheObj.AdminGroupId = "admin-group"
+	defer func() { auth.AuthCacheObj.AdminGroupId = adminGroupId }()
+
+	userCtx := func(email, groupId, orgId string) context.Context {
+		user := &model.UserPayload{User: model.User{Email: email, GroupId: groupId, OrgId: orgId}}
+		return context.WithValue(context.Background(), constants.ContextUserKey, user)
+	}
+	admin := userCtx("admin@acme.io", "admin-group", "acme")
+	viewer := userCtx("viewer@acme.io", "viewer-group", "acme")
+	other := userCtx("admin@globex.io", "admin-group", "globex")
+
+	_, err := m.CreateRule(admin, `{"alert":"Error rate","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+	require.NoError(t, err)
+	require.NoError(t, m.ruleDB.SetRulePermissions(admin, "1", []RulePermission{
+		{PrincipalType: RulePrincipalUser, Principal: "viewer@acme.io", Permission: RulePermissionView},
+	}))
+
+	// the viewers read the traces and the health but don't change the tracing
+	_, err = m.EvalTraces(viewer, "1")
+	assert.NoError(t, err)
+	_, err = m.RuleHealth(viewer, "1")
+	assert.NotErrorIs(t, err, sql.ErrNoRows)
+	denied := &RulePermissionError{RuleId: "1", Permission: RulePermissionEdit}
+	_, err = m.EnableEvalTrace(viewer, "1", time.Minute)
+	assert.Equal(t, denied, err)
+	_, err = m.DisableEvalTrace(viewer, "1")
+	assert.Equal(t, denied, err)
+	_, err = m.DisableEvalTrace(admin, "1")
+	assert.NoError(t, err)
+
+	// the rules of the other orgs are not found
+	_, err = m.EvalTraces(other, "1")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = m.EnableEvalTrace(other, "1", time.Minute)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = m.RuleHealth(other, "1")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
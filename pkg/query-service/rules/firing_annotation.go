@@ -0,0 +1,319 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrEmptyAnnotation      = errors.New("annotation must have a postmortem link, a root cause or a text")
+	ErrInvalidPostmortemURL = errors.New("postmortem link must be an http or https url")
+	ErrFiringPeriodNotFound = errors.New("the rule did not start firing at the given time")
+)
+
+// AnnotationNote is the free-form content of the annotation
+type AnnotationNote struct {
+	PostmortemURL string `json:"postmortemUrl"`
+	RootCause     string `json:"rootCause"`
+	Text          string `json:"text"`
+}
+
+func (n *AnnotationNote) Validate() error {
+	if n.PostmortemURL == "" && n.RootCause == "" && n.Text == "" {
+		return ErrEmptyAnnotation
+	}
+	if n.PostmortemURL != "" {
+		u, err := url.ParseRequestURI(n.PostmortemURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidPostmortemURL
+		}
+	}
+	return nil
+}
+
+// PostableFiringAnnotation is the annotation of the firing period of the rule starting
+// at FiredAt in milliseconds, the zero fingerprint annotates the rule as a whole
+type PostableFiringAnnotation struct {
+	Fingerprint uint64 `json:"fingerprint"`
+	FiredAt     int64  `json:"firedAt"`
+	AnnotationNote
+}
+
+func (p *PostableFiringAnnotation) Validate() error {
+	if p.FiredAt <= 0 {
+		return fmt.Errorf("firedAt is required")
+	}
+	return p.AnnotationNote.Validate()
+}
+
+// firingAnnotationRow is the stored annotation, the fingerprint does not fit the
+// integers of sqlite
+type firingAnnotationRow struct {
+	Id            int64     `db:"id"`
+	RuleId        string    `db:"rule_id"`
+	Fingerprint   string    `db:"fingerprint"`
+	FiredAt       int64     `db:"fired_at"`
+	PostmortemURL string    `db:"postmortem_url"`
+	RootCause     string    `db:"root_cause"`
+	Text          string    `db:"text"`
+	CreatedBy     string    `db:"created_by"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+const firingAnnotationColumns = "id, rule_id, fingerprint, fired_at, postmortem_url, root_cause, text, created_by, created_at, updated_at"
+
+func newFiringAnnotationRow(a model.FiringAnnotation) firingAnnotationRow {
+	return firingAnnotationRow{
+		Id:            a.Id,
+		RuleId:        a.RuleID,
+		Fingerprint:   strconv.FormatUint(a.Fingerprint, 10),
+		FiredAt:       a.FiredAt,
+		PostmortemURL: a.PostmortemURL,
+		RootCause:     a.RootCause,
+		Text:          a.Text,
+		CreatedBy:     a.CreatedBy,
+		CreatedAt:     a.CreatedAt.UTC(),
+		UpdatedAt:     a.UpdatedAt.UTC(),
+	}
+}
+
+func (row *firingAnnotationRow) annotation() model.FiringAnnotation {
+	fingerprint, _ := strconv.ParseUint(row.Fingerprint, 10, 64)
+	return model.FiringAnnotation{
+		Id:            row.Id,
+		RuleID:        row.RuleId,
+		Fingerprint:   fingerprint,
+		FiredAt:       row.FiredAt,
+		PostmortemURL: row.PostmortemURL,
+		RootCause:     row.RootCause,
+		Text:          row.Text,
+		CreatedBy:     row.CreatedBy,
+		CreatedAt:     row.CreatedAt,
+		UpdatedAt:     row.UpdatedAt,
+	}
+}
+
+func (r *ruleDB) CreateFiringAnnotation(ctx context.Context, annotation model.FiringAnnotation) (int64, error) {
+	result, err := r.NamedExec(`INSERT INTO firing_annotations (rule_id, fingerprint, fired_at, postmortem_url, root_cause, text, created_by, created_at, updated_at)
+		VALUES (:rule_id, :fingerprint, :fired_at, :postmortem_url, :root_cause, :text, :created_by, :created_at, :updated_at)`,
+		newFiringAnnotationRow(annotation))
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to firing_annotations", zap.Error(err))
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	annotation.Id = id
+
+	after, err := json.Marshal(annotation)
+	if err != nil {
+		return id, err
+	}
+	return id, addAuditLog(ctx, r, AuditResourceAnnotation, strconv.FormatInt(id, 10), AuditActionCreate, "", string(after))
+}
+
+func (r *ruleDB) GetFiringAnnotation(ctx context.Context, id string) (*model.FiringAnnotation, error) {
+	row := firingAnnotationRow{}
+	if err := r.Get(&row, "SELECT "+firingAnnotationColumns+" FROM firing_annotations WHERE id=$1", id); err != nil {
+		return nil, err
+	}
+	annotation := row.annotation()
+	return &annotation, nil
+}
+
+func (r *ruleDB) GetFiringAnnotations(ctx context.Context, ruleId string, start, end int64) ([]model.FiringAnnotation, error) {
+	query, args := newSelectQuery("SELECT "+firingAnnotationColumns+" FROM firing_annotations").
+		where("rule_id=?", ruleId).
+		where("fired_at>=?", start).
+		where("fired_at<=?", end).
+		order("fired_at, id").
+		build()
+
+	rows := []firingAnnotationRow{}
+	if err := r.Select(&rows, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	annotations := make([]model.FiringAnnotation, 0, len(rows))
+	for i := range rows {
+		annotations = append(annotations, rows[i].annotation())
+	}
+	return annotations, nil
+}
+
+func (r *ruleDB) UpdateFiringAnnotation(ctx context.Context, annotation model.FiringAnnotation) error {
+	before, err := r.GetFiringAnnotation(ctx, strconv.FormatInt(annotation.Id, 10))
+	if err != nil {
+		return err
+	}
+	_, err = r.NamedExec(`UPDATE firing_annotations SET postmortem_url=:postmortem_url, root_cause=:root_cause, text=:text,
+		updated_at=:updated_at WHERE id=:id`, newFiringAnnotationRow(annotation))
+	if err != nil {
+		zap.L().Error("Error in Executing UPDATE to firing_annotations", zap.Error(err))
+		return err
+	}
+
+	beforeData, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterData, err := json.Marshal(annotation)
+	if err != nil {
+		return err
+	}
+	return addAuditLog(ctx, r, AuditResourceAnnotation, strconv.FormatInt(annotation.Id, 10), AuditActionEdit, string(beforeData), string(afterData))
+}
+
+func (r *ruleDB) DeleteFiringAnnotation(ctx context.Context, id string) error {
+	before, err := r.GetFiringAnnotation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := r.Exec("DELETE FROM firing_annotations WHERE id=$1", id); err != nil {
+		zap.L().Error("Error in Executing DELETE from firing_annotations", zap.Error(err))
+		return err
+	}
+
+	beforeData, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	return addAuditLog(ctx, r, AuditResourceAnnotation, id, AuditActionDelete, string(beforeData), "")
+}
+
+func (r *ruleDB) DeleteFiringAnnotationsOfRules(ctx context.Context, ruleIds []string) error {
+	for _, ruleId := range ruleIds {
+		if _, err := r.Exec("DELETE FROM firing_annotations WHERE rule_id=$1", ruleId); err != nil {
+			zap.L().Error("Error in Executing DELETE from firing_annotations", zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// AnnotateFiringPeriod attaches the annotation to the firing period of the rule, the
+// state history of the rule must have the series starting to fire at the given time
+func (m *Manager) AnnotateFiringPeriod(ctx context.Context, ruleID string, postable PostableFiringAnnotation) (*model.FiringAnnotation, error) {
+	if err := postable.Validate(); err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleID, RulePermissionEdit); err != nil {
+		return nil, err
+	}
+
+	changes, err := m.reader.GetRuleStateChanges(ctx, []string{ruleID}, postable.FiredAt, postable.FiredAt+1)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, change := range changes {
+		if change.UnixMilli != postable.FiredAt || (change.State != model.StateFiring && change.State != model.StateNoData) {
+			continue
+		}
+		if postable.Fingerprint == 0 || change.Fingerprint == postable.Fingerprint {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, ErrFiringPeriodNotFound
+	}
+
+	now := time.Now()
+	annotation := model.FiringAnnotation{
+		RuleID:        ruleID,
+		Fingerprint:   postable.Fingerprint,
+		FiredAt:       postable.FiredAt,
+		PostmortemURL: postable.PostmortemURL,
+		RootCause:     postable.RootCause,
+		Text:          postable.Text,
+		CreatedBy:     auditActor(ctx),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	id, err := m.ruleDB.CreateFiringAnnotation(ctx, annotation)
+	if err != nil {
+		return nil, err
+	}
+	return m.ruleDB.GetFiringAnnotation(ctx, strconv.FormatInt(id, 10))
+}
+
+// UpdateFiringAnnotation replaces the note of the annotation of the rule
+func (m *Manager) UpdateFiringAnnotation(ctx context.Context, ruleID, id string, note AnnotationNote) (*model.FiringAnnotation, error) {
+	if err := note.Validate(); err != nil {
+		return nil, err
+	}
+	annotation, err := m.ruleAnnotation(ctx, ruleID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	annotation.PostmortemURL = note.PostmortemURL
+	annotation.RootCause = note.RootCause
+	annotation.Text = note.Text
+	annotation.UpdatedAt = time.Now()
+	if err := m.ruleDB.UpdateFiringAnnotation(ctx, *annotation); err != nil {
+		return nil, err
+	}
+	return m.ruleDB.GetFiringAnnotation(ctx, id)
+}
+
+// DeleteFiringAnnotation deletes the annotation of the rule
+func (m *Manager) DeleteFiringAnnotation(ctx context.Context, ruleID, id string) error {
+	if _, err := m.ruleAnnotation(ctx, ruleID, id); err != nil {
+		return err
+	}
+	return m.ruleDB.DeleteFiringAnnotation(ctx, id)
+}
+
+// ruleAnnotation fetches the annotation the user can edit, the annotations of the
+// other rules are not found
+func (m *Manager) ruleAnnotation(ctx context.Context, ruleID, id string) (*model.FiringAnnotation, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleID, RulePermissionEdit); err != nil {
+		return nil, err
+	}
+	annotation, err := m.ruleDB.GetFiringAnnotation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if annotation.RuleID != ruleID {
+		return nil, sql.ErrNoRows
+	}
+	return annotation, nil
+}
+
+// FiringAnnotations returns the annotations of the firing periods of the rule starting
+// in the time range
+func (m *Manager) FiringAnnotations(ctx context.Context, ruleID string, start, end int64) ([]model.FiringAnnotation, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleID, RulePermissionView); err != nil {
+		return nil, err
+	}
+	return m.ruleDB.GetFiringAnnotations(ctx, ruleID, start, end)
+}
+
+// AnnotateStateItems attaches the annotations to the firing periods they start in
+func AnnotateStateItems(items []model.ReleStateItem, annotations []model.FiringAnnotation) {
+	for i := range items {
+		if items[i].State != model.StateFiring {
+			continue
+		}
+		for _, annotation := range annotations {
+			if annotation.FiredAt >= items[i].Start && annotation.FiredAt < items[i].End {
+				items[i].Annotations = append(items[i].Annotations, annotation)
+			}
+		}
+	}
+}
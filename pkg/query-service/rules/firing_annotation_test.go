@@ -0,0 +1,66 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestAnnotateFiringPeriod(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	for _, alert := range []string{"Error rate", "Latency"} {
+		_, err := m.CreateRule(ctx, `{"alert":"`+alert+`","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+		require.NoError(t, err)
+	}
+	m.reader = &stateChangesReader{changes: []model.RuleStateHistory{
+		{RuleID: "1", Fingerprint: 7, State: model.StateFiring, UnixMilli: 5000},
+	}}
+
+	note := AnnotationNote{PostmortemURL: "https://wiki.example.com/pm/42", RootCause: "bad deploy"}
+	_, err := m.AnnotateFiringPeriod(ctx, "1", PostableFiringAnnotation{FiredAt: 4000, AnnotationNote: note})
+	assert.ErrorIs(t, err, ErrFiringPeriodNotFound)
+	_, err = m.AnnotateFiringPeriod(ctx, "1", PostableFiringAnnotation{FiredAt: 5000, Fingerprint: 8, AnnotationNote: note})
+	assert.ErrorIs(t, err, ErrFiringPeriodNotFound)
+	_, err = m.AnnotateFiringPeriod(ctx, "1", PostableFiringAnnotation{FiredAt: 5000, AnnotationNote: AnnotationNote{PostmortemURL: "wiki/pm/42"}})
+	assert.ErrorIs(t, err, ErrInvalidPostmortemURL)
+
+	annotation, err := m.AnnotateFiringPeriod(ctx, "1", PostableFiringAnnotation{FiredAt: 5000, Fingerprint: 7, AnnotationNote: note})
+	require.NoError(t, err)
+	assert.Equal(t, "1", annotation.RuleID)
+	assert.Equal(t, uint64(7), annotation.Fingerprint)
+	assert.Equal(t, "bad deploy", annotation.RootCause)
+	id := strconv.FormatInt(annotation.Id, 10)
+
+	annotation, err = m.UpdateFiringAnnotation(ctx, "1", id, AnnotationNote{RootCause: "config change", Text: "rolled back"})
+	require.NoError(t, err)
+	assert.Empty(t, annotation.PostmortemURL)
+	assert.Equal(t, "config change", annotation.RootCause)
+
+	annotations, err := m.FiringAnnotations(ctx, "1", 0, 10000)
+	require.NoError(t, err)
+	assert.Len(t, annotations, 1)
+	annotations, err = m.FiringAnnotations(ctx, "1", 6000, 10000)
+	require.NoError(t, err)
+	assert.Empty(t, annotations)
+
+	items := []model.ReleStateItem{
+		{State: model.StateInactive, Start: 0, End: 5000},
+		{State: model.StateFiring, Start: 5000, End: 9000},
+	}
+	AnnotateStateItems(items, []model.FiringAnnotation{*annotation})
+	assert.Empty(t, items[0].Annotations)
+	assert.Len(t, items[1].Annotations, 1)
+
+	// the annotation is not found through the other rule
+	assert.ErrorIs(t, m.DeleteFiringAnnotation(ctx, "2", id), sql.ErrNoRows)
+	require.NoError(t, m.DeleteFiringAnnotation(ctx, "1", id))
+	annotations, err = m.FiringAnnotations(ctx, "1", 0, 10000)
+	require.NoError(t, err)
+	assert.Empty(t, annotations)
+}
@@ -0,0 +1,323 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	promModel "github.com/prometheus/common/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// grafanaExpressionDatasource is the datasource uid of the server side expressions
+const grafanaExpressionDatasource = "__expr__"
+
+// grafanaAlertingExport is the export format of the Grafana unified alerting rules
+type grafanaAlertingExport struct {
+	Groups []grafanaRuleGroup `json:"groups"`
+}
+
+type grafanaRuleGroup struct {
+	Name     string        `json:"name"`
+	Folder   string        `json:"folder"`
+	Interval string        `json:"interval"`
+	Rules    []grafanaRule `json:"rules"`
+}
+
+type grafanaRule struct {
+	UID          string            `json:"uid"`
+	Title        string            `json:"title"`
+	Condition    string            `json:"condition"`
+	Data         []grafanaQuery    `json:"data"`
+	NoDataState  string            `json:"noDataState"`
+	ExecErrState string            `json:"execErrState"`
+	For          string            `json:"for"`
+	Annotations  map[string]string `json:"annotations"`
+	Labels       map[string]string `json:"labels"`
+	IsPaused     bool              `json:"isPaused"`
+}
+
+type grafanaQuery struct {
+	RefID             string `json:"refId"`
+	DatasourceUID     string `json:"datasourceUid"`
+	RelativeTimeRange struct {
+		From int64 `json:"from"`
+		To   int64 `json:"to"`
+	} `json:"relativeTimeRange"`
+	Model grafanaQueryModel `json:"model"`
+}
+
+type grafanaQueryModel struct {
+	// Expr is the PromQL expression of the Prometheus queries
+	Expr string `json:"expr"`
+
+	// Type, Expression, Reducer and Conditions are set for the expressions
+	Type       string             `json:"type"`
+	Expression string             `json:"expression"`
+	Reducer    string             `json:"reducer"`
+	Conditions []grafanaCondition `json:"conditions"`
+}
+
+type grafanaCondition struct {
+	Evaluator struct {
+		Params []float64 `json:"params"`
+		Type   string    `json:"type"`
+	} `json:"evaluator"`
+	Query struct {
+		Params []string `json:"params"`
+	} `json:"query"`
+	Reducer struct {
+		Type string `json:"type"`
+	} `json:"reducer"`
+}
+
+var grafanaCompareOps = map[string]CompareOp{
+	"gt":  ValueIsAbove,
+	"lt":  ValueIsBelow,
+	"gte": ValueAboveOrEq,
+	"lte": ValueBelowOrEq,
+	"eq":  ValueIsEq,
+	"ne":  ValueIsNotEq,
+}
+
+// grafanaMatchType maps the reducer of the series to the match type, the max is
+// above the threshold if any point is above it and the min if all the points are
+func grafanaMatchType(reducer string, compareOp CompareOp) (MatchType, bool) {
+	switch reducer {
+	case "last":
+		return Last, true
+	case "mean", "avg":
+		return OnAverage, true
+	case "sum":
+		return InTotal, true
+	case "max":
+		switch compareOp {
+		case ValueIsAbove, ValueAboveOrEq:
+			return AtleastOnce, true
+		case ValueIsBelow, ValueBelowOrEq:
+			return AllTheTimes, true
+		}
+	case "min":
+		switch compareOp {
+		case ValueIsBelow, ValueBelowOrEq:
+			return AtleastOnce, true
+		case ValueIsAbove, ValueAboveOrEq:
+			return AllTheTimes, true
+		}
+	}
+	return MatchTypeNone, false
+}
+
+// ConvertGrafanaRules converts the rules of the Grafana unified alerting export, only
+// the rules with a single Prometheus query and a threshold condition are supported
+func ConvertGrafanaRules(content []byte) ([]RuleConversion, error) {
+	export := grafanaAlertingExport{}
+	if err := json.Unmarshal(content, &export); err != nil {
+		return nil, fmt.Errorf("failed to parse the grafana alerting export: %w", err)
+	}
+
+	conversions := []RuleConversion{}
+	for _, group := range export.Groups {
+		for _, rule := range group.Rules {
+			conversions = append(conversions, convertGrafanaRule(group, rule))
+		}
+	}
+	return conversions, nil
+}
+
+func convertGrafanaRule(group grafanaRuleGroup, rule grafanaRule) RuleConversion {
+	conversion := RuleConversion{Group: group.Name, Name: rule.Title}
+
+	query, compareOp, target, matchType, err := grafanaRuleCondition(rule)
+	if err != nil {
+		conversion.Error = err.Error()
+		return conversion
+	}
+
+	frequency := time.Minute
+	if group.Interval != "" {
+		interval, err := promModel.ParseDuration(group.Interval)
+		if err != nil {
+			conversion.Error = fmt.Sprintf("invalid group interval: %s", group.Interval)
+			return conversion
+		}
+		frequency = time.Duration(interval)
+	}
+
+	evalWindow := time.Duration(query.RelativeTimeRange.From-query.RelativeTimeRange.To) * time.Second
+	if evalWindow <= 0 {
+		evalWindow = frequency
+	}
+	var evalDelay time.Duration
+	if query.RelativeTimeRange.To > 0 {
+		evalDelay = time.Duration(query.RelativeTimeRange.To) * time.Second
+	}
+
+	// the pending period is the window the condition must hold all the time like the
+	// for of the Prometheus rules
+	if rule.For != "" {
+		pending, err := promModel.ParseDuration(rule.For)
+		if err != nil {
+			conversion.Error = fmt.Sprintf("invalid pending period: %s", rule.For)
+			return conversion
+		}
+		if pending > 0 {
+			if matchType != Last {
+				conversion.Warnings = append(conversion.Warnings, fmt.Sprintf("the reducer is replaced by the pending period %s", rule.For))
+			}
+			evalWindow = time.Duration(pending)
+			matchType = AllTheTimes
+		}
+	}
+
+	var alertOnAbsent bool
+	switch rule.NoDataState {
+	case "", "NoData", "Alerting":
+		alertOnAbsent = true
+	case "OK":
+	default:
+		conversion.Warnings = append(conversion.Warnings, fmt.Sprintf("the no data state %s is not supported", rule.NoDataState))
+	}
+	if rule.ExecErrState != "" && rule.ExecErrState != "Error" {
+		conversion.Warnings = append(conversion.Warnings, fmt.Sprintf("the error state %s is not supported", rule.ExecErrState))
+	}
+
+	if err := checkImportedTemplates(rule.Labels, rule.Annotations); err != nil {
+		conversion.Error = err.Error()
+		return conversion
+	}
+
+	ruleCondition := &RuleCondition{
+		CompositeQuery: &v3.CompositeQuery{
+			QueryType: v3.QueryTypePromQL,
+			PanelType: v3.PanelTypeGraph,
+			PromQueries: map[string]*v3.PromQuery{
+				"A": {Query: query.Model.Expr},
+			},
+		},
+		CompareOp: compareOp,
+		Target:    &target,
+		MatchType: matchType,
+	}
+	if alertOnAbsent {
+		ruleCondition.AlertOnAbsent = true
+		ruleCondition.AbsentFor = uint64(evalWindow / time.Minute)
+		if ruleCondition.AbsentFor == 0 {
+			ruleCondition.AbsentFor = 1
+		}
+	}
+
+	conversion.Rule = &PostableRule{
+		AlertName:     rule.Title,
+		AlertType:     AlertTypeMetric,
+		RuleType:      RuleTypeProm,
+		EvalWindow:    Duration(evalWindow),
+		Frequency:     Duration(frequency),
+		EvalDelay:     Duration(evalDelay),
+		RuleCondition: ruleCondition,
+		Labels:        rule.Labels,
+		Annotations:   rule.Annotations,
+		Disabled:      rule.IsPaused,
+		Folder:        group.Folder,
+	}
+	if rule.UID != "" {
+		conversion.Rule.ExternalID = "grafana/" + rule.UID
+	} else {
+		conversion.Rule.ExternalID = fmt.Sprintf("grafana/%s/%s", group.Name, rule.Title)
+	}
+
+	if err := conversion.Rule.Validate(); err != nil {
+		conversion.Error = err.Error()
+		conversion.Rule = nil
+	}
+
+	return conversion
+}
+
+// grafanaRuleCondition follows the condition of the rule to the Prometheus query, the
+// condition is either a classic condition or a threshold on the reduced query
+func grafanaRuleCondition(rule grafanaRule) (*grafanaQuery, CompareOp, float64, MatchType, error) {
+	queries := make(map[string]*grafanaQuery, len(rule.Data))
+	var datasourceQueries int
+	for idx := range rule.Data {
+		q := &rule.Data[idx]
+		queries[q.RefID] = q
+		if q.DatasourceUID != grafanaExpressionDatasource {
+			datasourceQueries++
+		}
+	}
+	if datasourceQueries != 1 {
+		return nil, CompareOpNone, 0, MatchTypeNone, fmt.Errorf("only the rules with a single query are supported, found %d", datasourceQueries)
+	}
+
+	condition, ok := queries[rule.Condition]
+	if !ok {
+		return nil, CompareOpNone, 0, MatchTypeNone, fmt.Errorf("condition %s not found", rule.Condition)
+	}
+
+	var (
+		reducer   = "last"
+		evaluator grafanaCondition
+		source    string
+	)
+	switch condition.Model.Type {
+	case "classic_conditions":
+		if len(condition.Model.Conditions) != 1 {
+			return nil, CompareOpNone, 0, MatchTypeNone, fmt.Errorf("only the classic conditions with a single condition are supported")
+		}
+		evaluator = condition.Model.Conditions[0]
+		if len(evaluator.Query.Params) > 0 {
+			source = evaluator.Query.Params[0]
+		}
+		reducer = evaluator.Reducer.Type
+	case "threshold":
+		if len(condition.Model.Conditions) != 1 {
+			return nil, CompareOpNone, 0, MatchTypeNone, fmt.Errorf("only the thresholds with a single condition are supported")
+		}
+		evaluator = condition.Model.Conditions[0]
+		source = condition.Model.Expression
+		// the threshold is usually applied to the reduced query
+		if reduce, ok := queries[source]; ok && reduce.Model.Type == "reduce" {
+			reducer = reduce.Model.Reducer
+			source = reduce.Model.Expression
+		}
+	default:
+		return nil, CompareOpNone, 0, MatchTypeNone, fmt.Errorf("the %s condition is not supported", condition.Model.Type)
+	}
+
+	query, ok := queries[strings.TrimPrefix(source, "$")]
+	if !ok || query.DatasourceUID == grafanaExpressionDatasource {
+		return nil, CompareOpNone, 0, MatchTypeNone, fmt.Errorf("the condition must be applied to the query, found %s", source)
+	}
+	if query.Model.Expr == "" {
+		return nil, CompareOpNone, 0, MatchTypeNone, fmt.Errorf("only the Prometheus queries are supported")
+	}
+
+	compareOp, ok := grafanaCompareOps[evaluator.Evaluator.Type]
+	if !ok || len(evaluator.Evaluator.Params) == 0 {
+		return nil, CompareOpNone, 0, MatchTypeNone, fmt.Errorf("the %s evaluator is not supported", evaluator.Evaluator.Type)
+	}
+	matchType, ok := grafanaMatchType(reducer, compareOp)
+	if !ok {
+		return nil, CompareOpNone, 0, MatchTypeNone, fmt.Errorf("the %s reducer is not supported with the %s evaluator", reducer, evaluator.Evaluator.Type)
+	}
+
+	return query, compareOp, evaluator.Evaluator.Params[0], matchType, nil
+}
+
+// ImportGrafanaRules converts the Grafana alerting export and creates or updates
+// the converted rules by their external id, the rules are only converted on dry run
+func (m *Manager) ImportGrafanaRules(ctx context.Context, content []byte, dryRun bool) ([]RuleConversion, error) {
+	conversions, err := ConvertGrafanaRules(content)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return conversions, nil
+	}
+
+	m.importConversions(ctx, conversions)
+	return conversions, nil
+}
@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pql "github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testGrafanaExport = `{
+  "apiVersion": 1,
+  "groups": [
+    {
+      "orgId": 1,
+      "name": "api",
+      "folder": "payments",
+      "interval": "30s",
+      "rules": [
+        {
+          "uid": "cpu-high",
+          "title": "High CPU",
+          "condition": "C",
+          "data": [
+            {"refId": "A", "relativeTimeRange": {"from": 600, "to": 0}, "datasourceUid": "prometheus", "model": {"expr": "avg by (instance) (rate(cpu_seconds_total[5m]))", "refId": "A"}},
+            {"refId": "B", "datasourceUid": "__expr__", "model": {"type": "reduce", "expression": "A", "reducer": "max", "refId": "B"}},
+            {"refId": "C", "datasourceUid": "__expr__", "model": {"type": "threshold", "expression": "B", "conditions": [{"evaluator": {"params": [0.8], "type": "gt"}}], "refId": "C"}}
+          ],
+          "noDataState": "OK",
+          "execErrState": "Error",
+          "for": "5m",
+          "annotations": {"summary": "{{ $labels.instance }} CPU is high"},
+          "labels": {"severity": "warning"},
+          "isPaused": true
+        },
+        {
+          "uid": "latency-classic",
+          "title": "Latency",
+          "condition": "B",
+          "data": [
+            {"refId": "A", "relativeTimeRange": {"from": 300, "to": 60}, "datasourceUid": "prometheus", "model": {"expr": "histogram_quantile(0.99, rate(latency_bucket[5m]))"}},
+            {"refId": "B", "datasourceUid": "__expr__", "model": {"type": "classic_conditions", "conditions": [{"evaluator": {"params": [2], "type": "lt"}, "query": {"params": ["A"]}, "reducer": {"type": "avg"}}]}}
+          ],
+          "noDataState": "NoData"
+        },
+        {
+          "uid": "loki-errors",
+          "title": "Log errors",
+          "condition": "C",
+          "data": [
+            {"refId": "A", "datasourceUid": "loki", "model": {"queryType": "range"}},
+            {"refId": "C", "datasourceUid": "__expr__", "model": {"type": "threshold", "expression": "A", "conditions": [{"evaluator": {"params": [1], "type": "gt"}}]}}
+          ]
+        },
+        {
+          "uid": "range",
+          "title": "Within range",
+          "condition": "C",
+          "data": [
+            {"refId": "A", "datasourceUid": "prometheus", "model": {"expr": "up"}},
+            {"refId": "C", "datasourceUid": "__expr__", "model": {"type": "threshold", "expression": "A", "conditions": [{"evaluator": {"params": [1, 2], "type": "within_range"}}]}}
+          ]
+        },
+        {
+          "uid": "math",
+          "title": "Math",
+          "condition": "C",
+          "data": [
+            {"refId": "A", "datasourceUid": "prometheus", "model": {"expr": "up"}},
+            {"refId": "C", "datasourceUid": "__expr__", "model": {"type": "math", "expression": "$A > 1"}}
+          ]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestConvertGrafanaRules(t *testing.T) {
+	conversions, err := ConvertGrafanaRules([]byte(testGrafanaExport))
+	assert.NoError(t, err)
+	assert.Len(t, conversions, 5)
+
+	cpu := conversions[0]
+	assert.Empty(t, cpu.Error)
+	assert.Equal(t, []string{"the reducer is replaced by the pending period 5m"}, cpu.Warnings)
+	rule := cpu.Rule
+	assert.Equal(t, "High CPU", rule.AlertName)
+	assert.Equal(t, "avg by (instance) (rate(cpu_seconds_total[5m]))", rule.RuleCondition.CompositeQuery.PromQueries["A"].Query)
+	assert.Equal(t, ValueIsAbove, rule.RuleCondition.CompareOp)
+	assert.Equal(t, 0.8, *rule.RuleCondition.Target)
+	// the pending period is the window the condition holds all the time
+	assert.Equal(t, AllTheTimes, rule.RuleCondition.MatchType)
+	assert.False(t, rule.RuleCondition.AlertOnAbsent)
+	assert.Equal(t, Duration(5*time.Minute), rule.EvalWindow)
+	assert.Equal(t, Duration(30*time.Second), rule.Frequency)
+	assert.True(t, rule.Disabled)
+	assert.Equal(t, "payments", rule.Folder)
+	assert.Equal(t, "grafana/cpu-high", rule.ExternalID)
+	assert.Equal(t, "warning", rule.Labels["severity"])
+
+	latency := conversions[1]
+	assert.Empty(t, latency.Error)
+	rule = latency.Rule
+	assert.Equal(t, ValueIsBelow, rule.RuleCondition.CompareOp)
+	assert.Equal(t, 2.0, *rule.RuleCondition.Target)
+	assert.Equal(t, OnAverage, rule.RuleCondition.MatchType)
+	assert.Equal(t, Duration(4*time.Minute), rule.EvalWindow)
+	assert.Equal(t, Duration(time.Minute), rule.EvalDelay)
+	assert.True(t, rule.RuleCondition.AlertOnAbsent)
+	assert.Equal(t, uint64(4), rule.RuleCondition.AbsentFor)
+
+	assert.Equal(t, "only the Prometheus queries are supported", conversions[2].Error)
+	assert.Equal(t, "the within_range evaluator is not supported", conversions[3].Error)
+	assert.Equal(t, "the math condition is not supported", conversions[4].Error)
+
+	_, err = ConvertGrafanaRules([]byte("groups: []"))
+	assert.Error(t, err)
+}
+
+func TestImportGrafanaRules(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	conversions, err := m.ImportGrafanaRules(ctx, []byte(testGrafanaExport), false)
+	assert.NoError(t, err)
+	assert.Equal(t, RuleImportCreated, conversions[0].Import.Status)
+	assert.Equal(t, RuleImportCreated, conversions[1].Import.Status)
+	assert.Nil(t, conversions[2].Import)
+
+	rule, err := m.ruleDB.GetStoredRuleByExternalID(ctx, "grafana/latency-classic")
+	assert.NoError(t, err)
+	assert.Equal(t, conversions[1].Import.ID, "2")
+	assert.Equal(t, 2, rule.Id)
+}
+
+func TestImportedGrafanaInclusiveRuleFires(t *testing.T) {
+	conversions, err := ConvertGrafanaRules([]byte(`{"groups": [{"name": "api", "rules": [{
+		"uid": "errors",
+		"title": "Errors",
+		"condition": "C",
+		"data": [
+			{"refId": "A", "relativeTimeRange": {"from": 300, "to": 0}, "datasourceUid": "prometheus", "model": {"expr": "error_ratio"}},
+			{"refId": "B", "datasourceUid": "__expr__", "model": {"type": "reduce", "expression": "A", "reducer": "last"}},
+			{"refId": "C", "datasourceUid": "__expr__", "model": {"type": "threshold", "expression": "B", "conditions": [{"evaluator": {"params": [0.5], "type": "gte"}}]}}
+		],
+		"noDataState": "OK",
+		"for": "2m"
+	}]}]}`))
+	require.NoError(t, err)
+	require.Len(t, conversions, 1)
+	require.Empty(t, conversions[0].Error)
+	assert.Empty(t, conversions[0].Warnings)
+
+	imported := conversions[0].Rule
+	assert.Equal(t, ValueAboveOrEq, imported.RuleCondition.CompareOp)
+	assert.Equal(t, AllTheTimes, imported.RuleCondition.MatchType)
+	assert.Equal(t, Duration(2*time.Minute), imported.EvalWindow)
+
+	rule, err := NewPromRule("1", imported, zap.NewNop(), nil, nil)
+	require.NoError(t, err)
+	_, shouldAlert := rule.ShouldAlert(toCommonSeries(pql.Series{Floats: []pql.FPoint{{T: 1, F: 0.5}, {T: 2, F: 0.6}}}))
+	assert.True(t, shouldAlert)
+	_, shouldAlert = rule.ShouldAlert(toCommonSeries(pql.Series{Floats: []pql.FPoint{{T: 1, F: 0.4}, {T: 2, F: 0.6}}}))
+	assert.False(t, shouldAlert)
+}
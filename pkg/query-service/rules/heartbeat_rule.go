@@ -0,0 +1,239 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+const heartbeatWebhookTimeout = 10 * time.Second
+
+// HeartbeatCondition captures the signal expected by a heartbeat rule
+type HeartbeatCondition struct {
+	// AbsentFor is how long the signal can be missing before the rule fires
+	AbsentFor Duration `yaml:"absentFor" json:"absentFor"`
+	// WebhookURL is called directly when the signal goes missing and when it
+	// comes back, so that the alert reaches an external dead man's switch
+	// service even if the alert manager is down
+	WebhookURL string `yaml:"webhookUrl,omitempty" json:"webhookUrl,omitempty"`
+}
+
+// HeartbeatWebhookPayload is the body posted to the heartbeat webhook
+type HeartbeatWebhookPayload struct {
+	RuleID   string            `json:"ruleId"`
+	RuleName string            `json:"ruleName"`
+	Status   string            `json:"status"`
+	Labels   map[string]string `json:"labels"`
+	LastSeen time.Time         `json:"lastSeen,omitempty"`
+}
+
+type heartbeat struct {
+	labels   labels.Labels
+	lastSeen time.Time
+	missing  bool
+}
+
+// HeartbeatRule fires when every expected signal i.e the series returned by the
+// selected query stops arriving for the configured duration
+type HeartbeatRule struct {
+	*ThresholdRule
+
+	heartbeat *HeartbeatCondition
+	// startedAt is used as the last seen time of the signals
+	// that haven't been seen since the rule started
+	startedAt  time.Time
+	heartbeats map[uint64]*heartbeat
+	client     *http.Client
+}
+
+func NewHeartbeatRule(
+	id string,
+	p *PostableRule,
+	featureFlags interfaces.FeatureLookup,
+	reader interfaces.Reader,
+	useLogsNewSchema bool,
+	useTraceNewSchema bool,
+	opts ...RuleOption,
+) (*HeartbeatRule, error) {
+
+	zap.L().Info("creating new HeartbeatRule", zap.String("id", id))
+
+	if p.RuleCondition == nil || p.RuleCondition.Heartbeat == nil {
+		return nil, fmt.Errorf("heartbeat condition is required for the heartbeat rule")
+	}
+
+	t, err := NewThresholdRule(id, p, featureFlags, reader, useLogsNewSchema, useTraceNewSchema, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the query should cover the absent duration to find when the signal was last seen
+	if absentFor := time.Duration(p.RuleCondition.Heartbeat.AbsentFor); t.evalWindow < absentFor {
+		t.evalWindow = absentFor
+	}
+
+	return &HeartbeatRule{
+		ThresholdRule: t,
+		heartbeat:     p.RuleCondition.Heartbeat,
+		startedAt:     time.Now(),
+		heartbeats:    make(map[uint64]*heartbeat),
+		client:        &http.Client{Timeout: heartbeatWebhookTimeout},
+	}, nil
+}
+
+func (r *HeartbeatRule) Type() RuleType {
+	return RuleTypeHeartbeat
+}
+
+func (r *HeartbeatRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vector, error) {
+
+	params, err := r.prepareQueryRange(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	queryResult, err := r.runQuery(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if queryResult != nil {
+		for _, series := range queryResult.Series {
+			points := removeGroupinSetPoints(*series)
+			if len(points) == 0 {
+				continue
+			}
+			var lastSeen int64
+			for _, p := range points {
+				if p.Timestamp > lastSeen {
+					lastSeen = p.Timestamp
+				}
+			}
+			r.seen(seriesLabels(series), time.UnixMilli(lastSeen))
+		}
+	}
+
+	r.removePlaceholder(ctx)
+
+	return r.missingSignals(ctx, ts), nil
+}
+
+// removePlaceholder removes the placeholder for the signal that was never
+// seen once the signals with the labels start arriving
+func (r *HeartbeatRule) removePlaceholder(ctx context.Context) {
+	h, ok := r.heartbeats[labels.Labels{}.Hash()]
+	if !ok || len(r.heartbeats) == 1 {
+		return
+	}
+	if h.missing {
+		h.missing = false
+		r.notifyWebhook(ctx, h)
+	}
+	delete(r.heartbeats, labels.Labels{}.Hash())
+}
+
+// seen records the time the signal with the given labels was last seen
+func (r *HeartbeatRule) seen(lbls labels.Labels, lastSeen time.Time) {
+	h, ok := r.heartbeats[lbls.Hash()]
+	if !ok {
+		h = &heartbeat{labels: lbls}
+		r.heartbeats[lbls.Hash()] = h
+	}
+	if lastSeen.After(h.lastSeen) {
+		h.lastSeen = lastSeen
+	}
+}
+
+// missingSignals returns a sample for every signal missing for the absent duration,
+// the rule fires even if the signal was never seen since the rule started
+func (r *HeartbeatRule) missingSignals(ctx context.Context, ts time.Time) Vector {
+	if len(r.heartbeats) == 0 {
+		r.heartbeats[labels.Labels{}.Hash()] = &heartbeat{labels: labels.Labels{}, lastSeen: r.startedAt}
+	}
+
+	var resultVector Vector
+	for _, h := range r.heartbeats {
+		missing := ts.Sub(h.lastSeen) >= time.Duration(r.heartbeat.AbsentFor)
+		if missing != h.missing {
+			h.missing = missing
+			r.notifyWebhook(ctx, h)
+		}
+		if !missing {
+			continue
+		}
+
+		lbls := labels.NewBuilder(h.labels)
+		if !h.lastSeen.Equal(r.startedAt) {
+			lbls.Set("lastSeen", h.lastSeen.Format(constants.AlertTimeFormat))
+		}
+		resultVector = append(resultVector, Sample{
+			Point:     Point{T: ts.UnixMilli()},
+			Metric:    lbls.Labels(),
+			IsMissing: true,
+		})
+	}
+	return resultVector
+}
+
+// notifyWebhook posts the state of the signal to the heartbeat webhook
+func (r *HeartbeatRule) notifyWebhook(ctx context.Context, h *heartbeat) {
+	if r.heartbeat.WebhookURL == "" {
+		return
+	}
+
+	status := "resolved"
+	if h.missing {
+		status = "firing"
+	}
+	payload := HeartbeatWebhookPayload{
+		RuleID:   r.ID(),
+		RuleName: r.Name(),
+		Status:   status,
+		Labels:   h.labels.Map(),
+		LastSeen: h.lastSeen,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		zap.L().Error("failed to marshal heartbeat webhook payload", zap.String("ruleid", r.ID()), zap.Error(err))
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.heartbeat.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		zap.L().Error("failed to create heartbeat webhook request", zap.String("ruleid", r.ID()), zap.Error(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		zap.L().Error("failed to call heartbeat webhook", zap.String("ruleid", r.ID()), zap.Error(err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		zap.L().Error("heartbeat webhook returned an error", zap.String("ruleid", r.ID()), zap.Int("status", resp.StatusCode))
+	}
+}
+
+func (r *HeartbeatRule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
+
+	res, err := r.buildAndRunQuery(ctx, ts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r.evalVector(ctx, ts, res, 0)
+}
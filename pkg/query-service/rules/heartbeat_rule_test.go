@@ -0,0 +1,92 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestHeartbeatRuleMissingSignals(t *testing.T) {
+	var payloads []HeartbeatWebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload HeartbeatWebhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads = append(payloads, payload)
+	}))
+	defer server.Close()
+
+	postableRule := PostableRule{
+		AlertName:  "Collector heartbeat",
+		AlertType:  AlertTypeLogs,
+		RuleType:   RuleTypeHeartbeat,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:         "A",
+						StepInterval:      60,
+						AggregateOperator: v3.AggregateOperatorCount,
+						DataSource:        v3.DataSourceLogs,
+						Expression:        "A",
+					},
+				},
+			},
+			Heartbeat: &HeartbeatCondition{
+				AbsentFor:  Duration(10 * time.Minute),
+				WebhookURL: server.URL,
+			},
+		},
+	}
+	assert.NoError(t, postableRule.Validate())
+
+	fm := featureManager.StartManager()
+	rule, err := NewHeartbeatRule("69", &postableRule, fm, nil, true, true)
+	assert.NoError(t, err)
+	// the query covers the absent duration
+	assert.Equal(t, 10*time.Minute, rule.EvalWindow())
+
+	ctx := context.Background()
+	start := rule.startedAt
+
+	// nothing seen since the rule started
+	assert.Len(t, rule.missingSignals(ctx, start.Add(5*time.Minute)), 0)
+	res := rule.missingSignals(ctx, start.Add(10*time.Minute))
+	assert.Len(t, res, 1)
+	assert.True(t, res[0].IsMissing)
+	assert.Len(t, payloads, 1)
+	assert.Equal(t, "firing", payloads[0].Status)
+
+	// the signals start arriving
+	collectorA := labels.FromMap(map[string]string{"host.name": "collector-a"})
+	collectorB := labels.FromMap(map[string]string{"host.name": "collector-b"})
+	rule.seen(collectorA, start.Add(11*time.Minute))
+	rule.seen(collectorB, start.Add(11*time.Minute))
+	rule.removePlaceholder(ctx)
+	assert.Equal(t, "resolved", payloads[len(payloads)-1].Status)
+	assert.Len(t, rule.missingSignals(ctx, start.Add(12*time.Minute)), 0)
+
+	// collector-b stops sending
+	rule.seen(collectorA, start.Add(20*time.Minute))
+	res = rule.missingSignals(ctx, start.Add(22*time.Minute))
+	assert.Len(t, res, 1)
+	assert.Equal(t, "collector-b", res[0].Metric.Get("host.name"))
+	assert.NotEmpty(t, res[0].Metric.Get("lastSeen"))
+	assert.Equal(t, "firing", payloads[len(payloads)-1].Status)
+	assert.Equal(t, "collector-b", payloads[len(payloads)-1].Labels["host.name"])
+
+	// collector-b is back
+	rule.seen(collectorB, start.Add(23*time.Minute))
+	assert.Len(t, rule.missingSignals(ctx, start.Add(24*time.Minute)), 0)
+	assert.Equal(t, "resolved", payloads[len(payloads)-1].Status)
+}
@@ -0,0 +1,156 @@
+package rules
+
+import (
+	"context"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// DefaultHistoryQueueCapacity bounds the state history entries waiting to be written
+const DefaultHistoryQueueCapacity = 10000
+
+const (
+	// historyBatchSize and historyFlushInterval are how many entries are written at once
+	// and how long the entries wait for a batch to fill
+	historyBatchSize     = 1000
+	historyFlushInterval = time.Second
+	historyWriteTimeout  = 30 * time.Second
+	// historyWriteAttempts is how many times a batch is written before it's dropped,
+	// historyWriteBackoff is the wait before the first retry and doubles after every retry
+	historyWriteAttempts = 3
+	historyWriteBackoff  = time.Second
+)
+
+// historyWriter writes the state history of the rules in the background so the
+// evaluations never wait for the datastore, the entries are dropped when the queue
+// is full or the batch keeps failing
+type historyWriter struct {
+	reader  interfaces.Reader
+	queue   chan model.RuleStateHistory
+	backoff time.Duration
+	// done stops the writes, stopped is closed once the queued entries are written
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newHistoryWriter(reader interfaces.Reader, capacity int) *historyWriter {
+	return &historyWriter{
+		reader:  reader,
+		queue:   make(chan model.RuleStateHistory, capacity),
+		backoff: historyWriteBackoff,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (w *historyWriter) start() {
+	if w == nil {
+		return
+	}
+	go w.run()
+}
+
+// stop writes the entries left in the queue, it gives up on them once the context
+// is done
+func (w *historyWriter) stop(ctx context.Context) {
+	if w == nil {
+		return
+	}
+	close(w.done)
+	select {
+	case <-w.stopped:
+	case <-ctx.Done():
+		zap.L().Warn("queued rule state history was not written before the shutdown timeout")
+	}
+}
+
+func (w *historyWriter) enqueue(entries []model.RuleStateHistory) {
+	for _, entry := range entries {
+		select {
+		case w.queue <- entry:
+		default:
+			historyDropped.WithLabelValues("queue_full").Inc()
+		}
+	}
+}
+
+// run writes the queued entries in batches until the writer is stopped, then writes
+// the entries left in the queue
+func (w *historyWriter) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(historyFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]model.RuleStateHistory, 0, historyBatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			w.write(batch)
+			batch = make([]model.RuleStateHistory, 0, historyBatchSize)
+		}
+	}
+	for {
+		select {
+		case <-w.done:
+			for {
+				select {
+				case entry := <-w.queue:
+					batch = append(batch, entry)
+					if len(batch) == historyBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		case entry := <-w.queue:
+			batch = append(batch, entry)
+			if len(batch) == historyBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// write retries the failed batch with a backoff, the retries don't wait once the
+// writer is stopped
+func (w *historyWriter) write(batch []model.RuleStateHistory) {
+	backoff := w.backoff
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+		err := w.reader.AddRuleStateHistory(ctx, batch)
+		cancel()
+		if err == nil {
+			return
+		}
+		historyWriteFailures.Inc()
+		if attempt == historyWriteAttempts {
+			historyDropped.WithLabelValues("write_failed").Add(float64(len(batch)))
+			zap.L().Error("dropped the rule state history", zap.Int("entries", len(batch)), zap.Int("attempts", attempt), zap.Error(err))
+			return
+		}
+		zap.L().Warn("failed to write the rule state history", zap.Int("entries", len(batch)), zap.Int("attempt", attempt), zap.Error(err))
+		select {
+		case <-time.After(backoff):
+		case <-w.done:
+		}
+		backoff *= 2
+	}
+}
+
+// asyncHistoryReader queues the state history written by the evaluations for the
+// history writer
+type asyncHistoryReader struct {
+	interfaces.Reader
+	writer *historyWriter
+}
+
+func (r *asyncHistoryReader) AddRuleStateHistory(ctx context.Context, ruleStateHistory []model.RuleStateHistory) error {
+	r.writer.enqueue(ruleStateHistory)
+	return nil
+}
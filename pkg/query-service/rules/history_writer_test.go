@@ -0,0 +1,80 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// flakyHistoryReader fails the first writes of the state history
+type flakyHistoryReader struct {
+	interfaces.Reader
+	mtx      sync.Mutex
+	failures int
+	attempts int
+	written  []model.RuleStateHistory
+}
+
+func (r *flakyHistoryReader) AddRuleStateHistory(ctx context.Context, ruleStateHistory []model.RuleStateHistory) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.attempts++
+	if r.attempts <= r.failures {
+		return errors.New("clickhouse unavailable")
+	}
+	r.written = append(r.written, ruleStateHistory...)
+	return nil
+}
+
+func TestHistoryWriter(t *testing.T) {
+	reader := &flakyHistoryReader{failures: 1}
+	writer := newHistoryWriter(reader, 10)
+	writer.backoff = time.Millisecond
+	async := &asyncHistoryReader{Reader: reader, writer: writer}
+
+	require.NoError(t, async.AddRuleStateHistory(context.Background(), []model.RuleStateHistory{
+		{RuleID: "1", Fingerprint: 1, State: model.StateFiring},
+		{RuleID: "1", Fingerprint: 2, State: model.StateFiring},
+	}))
+	// the entries are only written in the background
+	assert.Empty(t, reader.written)
+
+	// the failed batch is retried when the writer stops
+	writer.start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	writer.stop(ctx)
+
+	reader.mtx.Lock()
+	defer reader.mtx.Unlock()
+	assert.Equal(t, 2, reader.attempts)
+	assert.Len(t, reader.written, 2)
+}
+
+func TestHistoryWriterDrops(t *testing.T) {
+	reader := &flakyHistoryReader{failures: historyWriteAttempts}
+	writer := newHistoryWriter(reader, 1)
+	writer.backoff = time.Millisecond
+
+	// the entries past the capacity are dropped
+	writer.enqueue([]model.RuleStateHistory{{RuleID: "1"}, {RuleID: "1"}})
+	assert.Len(t, writer.queue, 1)
+
+	// the batch failing every attempt is dropped
+	writer.start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	writer.stop(ctx)
+
+	reader.mtx.Lock()
+	defer reader.mtx.Unlock()
+	assert.Equal(t, historyWriteAttempts, reader.attempts)
+	assert.Empty(t, reader.written)
+}
@@ -0,0 +1,147 @@
+package rules
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	InboundActionAck     = "ack"
+	InboundActionResolve = "resolve"
+)
+
+var (
+	ErrInvalidInboundToken  = errors.New("invalid inbound alert token")
+	ErrInvalidInboundAction = errors.New("inbound action must be ack or resolve")
+	ErrMissingInboundAlert  = errors.New("inbound action must have the fingerprint or the dedup key of the alert")
+)
+
+// VerifyInboundToken checks the bearer token of the request made by the external
+// system against the configured token, the requests are rejected when it's not set
+func VerifyInboundToken(token, authorization string) error {
+	bearer, ok := strings.CutPrefix(authorization, "Bearer ")
+	if token == "" || !ok || subtle.ConstantTimeCompare([]byte(token), []byte(bearer)) != 1 {
+		return ErrInvalidInboundToken
+	}
+	return nil
+}
+
+// InboundAlertAction is the ack or resolve of an alert made in an external incident
+// tool, the alert is identified by its fingerprint or the dedup key it was sent with
+type InboundAlertAction struct {
+	Action      string `json:"action"`
+	Fingerprint string `json:"fingerprint"`
+	DedupKey    string `json:"dedupKey"`
+	// Source is the external system and User who made the action there, both are
+	// recorded as the actor of the acknowledgement
+	Source  string `json:"source"`
+	User    string `json:"user"`
+	Comment string `json:"comment"`
+}
+
+func (a *InboundAlertAction) Validate() error {
+	if a.Action != InboundActionAck && a.Action != InboundActionResolve {
+		return ErrInvalidInboundAction
+	}
+	if a.Fingerprint == "" && a.DedupKey == "" {
+		return ErrMissingInboundAlert
+	}
+	if a.Fingerprint != "" && a.DedupKey != "" && a.Fingerprint != a.DedupKey {
+		return fmt.Errorf("%w: the fingerprint and the dedup key are different alerts", ErrMissingInboundAlert)
+	}
+	return nil
+}
+
+// fingerprint returns the fingerprint of the alert, the alerts are deduplicated
+// in the external systems by their fingerprint
+func (a *InboundAlertAction) fingerprint() string {
+	if a.Fingerprint != "" {
+		return a.Fingerprint
+	}
+	return a.DedupKey
+}
+
+func (a *InboundAlertAction) actor() string {
+	source := a.Source
+	if source == "" {
+		source = "external"
+	}
+	if a.User == "" {
+		return source
+	}
+	return source + ":" + a.User
+}
+
+// InboundActionResult is the alert the inbound action was applied to
+type InboundActionResult struct {
+	Action      string `json:"action"`
+	Fingerprint string `json:"fingerprint"`
+	AlertName   string `json:"alertName"`
+	AckId       int64  `json:"ackId"`
+}
+
+// alertAckMatchers returns the matchers acknowledging only the alert
+func alertAckMatchers(alert *Alert) MaintenanceMatchers {
+	lbls := labelsMap(alert.Labels)
+	matchers := make(MaintenanceMatchers, 0, len(lbls))
+	for name, value := range lbls {
+		matchers = append(matchers, name+"="+value)
+	}
+	sort.Strings(matchers)
+	return matchers
+}
+
+// HandleInboundAction acks or resolves the firing alert from an external system.
+// Both stop the re-notification of the alert until it resolves in the rule. The
+// resolve also notifies the alert as resolved to the channels, the rule still
+// reports the alert firing until its condition clears and notifies it again when
+// it fires after that.
+func (m *Manager) HandleInboundAction(ctx context.Context, action *InboundAlertAction) (*InboundActionResult, error) {
+	if err := action.Validate(); err != nil {
+		return nil, err
+	}
+	alerts, err := m.activeAlertsByFingerprint([]string{action.fingerprint()})
+	if err != nil {
+		return nil, err
+	}
+	alert := alerts[0]
+
+	comment := action.Comment
+	if comment == "" {
+		comment = "Acknowledged from " + action.actor()
+		if action.Action == InboundActionResolve {
+			comment = "Resolved from " + action.actor()
+		}
+	}
+	ackId, err := m.createAlertAck(ctx, AlertAck{Matchers: alertAckMatchers(alert), AckedBy: action.actor(), Comment: comment})
+	if err != nil {
+		return nil, err
+	}
+
+	if action.Action == InboundActionResolve {
+		now := time.Now()
+		alert.State = model.StateInactive
+		alert.ResolvedAt = now
+		alert.LastSentAt = now
+		alert.ValidUntil = now
+		m.send(ctx, []*Alert{alert})
+		m.correlateIncidents(ctx, []*Alert{alert}, now)
+	}
+	zap.L().Info("inbound alert action", zap.String("action", action.Action), zap.String("fingerprint", action.fingerprint()), zap.String("actor", action.actor()))
+
+	return &InboundActionResult{
+		Action:      action.Action,
+		Fingerprint: action.fingerprint(),
+		AlertName:   alert.Labels.Get(labels.AlertNameLabel),
+		AckId:       ackId,
+	}, nil
+}
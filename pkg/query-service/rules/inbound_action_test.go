@@ -0,0 +1,73 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestVerifyInboundToken(t *testing.T) {
+	assert.NoError(t, VerifyInboundToken("secret", "Bearer secret"))
+	assert.ErrorIs(t, VerifyInboundToken("secret", "Bearer other"), ErrInvalidInboundToken)
+	assert.ErrorIs(t, VerifyInboundToken("secret", "secret"), ErrInvalidInboundToken)
+	assert.ErrorIs(t, VerifyInboundToken("", "Bearer "), ErrInvalidInboundToken)
+}
+
+func TestHandleInboundAction(t *testing.T) {
+	m := newTestManager(t)
+	m.notifier, _ = am.NewNotifier(&am.NotifierOptions{QueueCapacity: 10}, nil)
+	m.delivery = newChannelDelivery(m.ruleDB.(*ruleDB))
+	ctx := context.Background()
+
+	firedAt := time.Now().Add(-time.Hour)
+	alert := func(service string) *Alert {
+		return &Alert{
+			State:   model.StateFiring,
+			Labels:  labels.FromMap(map[string]string{labels.AlertNameLabel: "Errors", labels.AlertRuleIdLabel: "1", "service.name": service}),
+			FiredAt: firedAt,
+		}
+	}
+	cart, frontend := alert("cart"), alert("frontend")
+	m.rules["1"] = &ThresholdRule{BaseRule: &BaseRule{Active: map[uint64]*Alert{1: cart, 2: frontend}}}
+	fingerprint := fmt.Sprintf("%016x", cart.Labels.Hash())
+
+	_, err := m.HandleInboundAction(ctx, &InboundAlertAction{Action: "close", Fingerprint: fingerprint})
+	assert.ErrorIs(t, err, ErrInvalidInboundAction)
+	_, err = m.HandleInboundAction(ctx, &InboundAlertAction{Action: InboundActionAck})
+	assert.ErrorIs(t, err, ErrMissingInboundAlert)
+	_, err = m.HandleInboundAction(ctx, &InboundAlertAction{Action: InboundActionAck, DedupKey: "0000000000000000"})
+	assert.ErrorIs(t, err, ErrAlertNotActive)
+
+	// the ack only stops the notifications of the alert
+	result, err := m.HandleInboundAction(ctx, &InboundAlertAction{Action: InboundActionAck, DedupKey: fingerprint, Source: "pagerduty", User: "jane"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Errors", result.AlertName)
+	acks, err := m.ruleDB.GetAlertAcks(ctx, firedAt)
+	assert.NoError(t, err)
+	if assert.Len(t, acks, 1) {
+		assert.Equal(t, "pagerduty:jane", acks[0].AckedBy)
+		assert.Equal(t, "Acknowledged from pagerduty:jane", acks[0].Comment)
+		assert.True(t, acks[0].acknowledges(cart))
+		assert.False(t, acks[0].acknowledges(frontend))
+	}
+
+	// the resolve closes the incident of the alert
+	m.correlateIncidents(ctx, []*Alert{cart}, firedAt)
+	_, err = m.HandleInboundAction(ctx, &InboundAlertAction{Action: InboundActionResolve, Fingerprint: fingerprint, Source: "opsgenie"})
+	assert.NoError(t, err)
+	incidents, err := m.ruleDB.GetIncidents(ctx, nil)
+	assert.NoError(t, err)
+	if assert.Len(t, incidents, 1) {
+		incident, err := m.ruleDB.GetIncident(ctx, strconv.FormatInt(incidents[0].Id, 10))
+		assert.NoError(t, err)
+		assert.Equal(t, IncidentStatusResolved, incident.Status)
+	}
+	assert.Equal(t, model.StateFiring, cart.State)
+}
@@ -0,0 +1,778 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+// IncidentStatus is the state of the incident
+type IncidentStatus string
+
+const (
+	IncidentStatusOpen         IncidentStatus = "open"
+	IncidentStatusAcknowledged IncidentStatus = "acknowledged"
+	IncidentStatusResolved     IncidentStatus = "resolved"
+)
+
+// IncidentEventType is the kind of the change in the timeline of the incident
+type IncidentEventType string
+
+const (
+	IncidentEventOpened        IncidentEventType = "opened"
+	IncidentEventAlertAdded    IncidentEventType = "alert_added"
+	IncidentEventAlertFiring   IncidentEventType = "alert_firing"
+	IncidentEventAlertResolved IncidentEventType = "alert_resolved"
+	IncidentEventAlertRemoved  IncidentEventType = "alert_removed"
+	IncidentEventStatusChanged IncidentEventType = "status_changed"
+	IncidentEventAssigned      IncidentEventType = "assigned"
+	IncidentEventEdited        IncidentEventType = "edited"
+)
+
+const (
+	// DefaultIncidentWindow is how long after its last change the open incident
+	// takes the new firing alerts of its group
+	DefaultIncidentWindow = 30 * time.Minute
+	// incidentNotificationValidity is how long the notification of the open incident is valid
+	// in the alertmanager, the incident open for longer is notified as resolved by it
+	incidentNotificationValidity = 24 * time.Hour
+	// IncidentIdLabel is the label of the notifications of the incidents
+	IncidentIdLabel = "incidentId"
+
+	defaultIncidentLimit = 100
+	// maxOpenIncidents is the max number of open incidents the alerts are correlated with
+	maxOpenIncidents = 1000
+)
+
+var (
+	ErrMissingIncidentTitle  = errors.New("incident must have a title")
+	ErrInvalidIncidentStatus = errors.New("incident status must be open, acknowledged or resolved")
+	ErrAlertNotActive        = errors.New("the alert is not active")
+)
+
+// incidentSeverities ranks the severities, the incident has the highest severity of its alerts
+var incidentSeverities = map[string]int{
+	"info":     1,
+	"warning":  2,
+	"error":    3,
+	"critical": 4,
+}
+
+// Incident is the firing alerts correlated into one problem. The alerts of the same
+// service, or of the same rule when they have no service, firing within the window
+// of each other join the same incident, the alerts can also be grouped by hand. The
+// incident resolves on its own once all its alerts resolved.
+type Incident struct {
+	Id       int64          `json:"id" db:"id"`
+	Title    string         `json:"title" db:"title"`
+	Status   IncidentStatus `json:"status" db:"status"`
+	Severity string         `json:"severity" db:"severity"`
+	// GroupKey is what the alerts of the incident are correlated by, it is empty
+	// for the incident grouped by hand
+	GroupKey   string     `json:"groupKey" db:"group_key"`
+	Assignee   string     `json:"assignee" db:"assignee"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	CreatedBy  string     `json:"createdBy" db:"created_by"`
+	UpdatedAt  time.Time  `json:"updatedAt" db:"updated_at"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+	OrgID      string     `json:"-" db:"org_id"`
+
+	Alerts   []IncidentAlert `json:"alerts,omitempty" db:"-"`
+	Timeline []IncidentEvent `json:"timeline,omitempty" db:"-"`
+}
+
+// IncidentAlert is the alert of the incident with its latest state
+type IncidentAlert struct {
+	IncidentId  int64            `json:"incidentId" db:"incident_id"`
+	Fingerprint string           `json:"fingerprint" db:"fingerprint"`
+	RuleId      string           `json:"ruleId" db:"rule_id"`
+	AlertName   string           `json:"alertName" db:"alert_name"`
+	Labels      SuppressedLabels `json:"labels" db:"labels"`
+	// State is firing or resolved
+	State      string     `json:"state" db:"state"`
+	FiredAt    time.Time  `json:"firedAt" db:"fired_at"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+}
+
+// IncidentEvent is a change in the timeline of the incident, the actor is empty for
+// the changes made by the alerts
+type IncidentEvent struct {
+	Id          int64             `json:"id" db:"id"`
+	IncidentId  int64             `json:"incidentId" db:"incident_id"`
+	Type        IncidentEventType `json:"type" db:"type"`
+	Fingerprint string            `json:"fingerprint,omitempty" db:"fingerprint"`
+	Message     string            `json:"message" db:"message"`
+	Actor       string            `json:"actor,omitempty" db:"actor"`
+	Timestamp   time.Time         `json:"timestamp" db:"timestamp"`
+}
+
+// IncidentFilter selects the incidents, the empty fields match all the incidents
+type IncidentFilter struct {
+	Status IncidentStatus
+	// Open selects the incidents that are not resolved
+	Open     bool
+	GroupKey string
+	// Start and End select the incidents created in the range
+	Start time.Time
+	End   time.Time
+	// Limit is the max number of incidents returned, latest first
+	Limit int
+}
+
+// PostableIncident groups the active alerts by hand
+type PostableIncident struct {
+	Title        string   `json:"title"`
+	Severity     string   `json:"severity"`
+	Assignee     string   `json:"assignee"`
+	Fingerprints []string `json:"fingerprints"`
+}
+
+// IncidentUpdate is the change of the incident, the nil fields are left as they are
+type IncidentUpdate struct {
+	Title    *string         `json:"title"`
+	Severity *string         `json:"severity"`
+	Status   *IncidentStatus `json:"status"`
+	Assignee *string         `json:"assignee"`
+}
+
+func (u *IncidentUpdate) Validate() error {
+	if u.Title != nil && strings.TrimSpace(*u.Title) == "" {
+		return ErrMissingIncidentTitle
+	}
+	if u.Status != nil {
+		switch *u.Status {
+		case IncidentStatusOpen, IncidentStatusAcknowledged, IncidentStatusResolved:
+		default:
+			return ErrInvalidIncidentStatus
+		}
+	}
+	return nil
+}
+
+const incidentColumns = "id, title, status, severity, group_key, assignee, created_at, created_by, updated_at, resolved_at, COALESCE(org_id, '') AS org_id"
+
+func (r *ruleDB) CreateIncident(ctx context.Context, incident Incident) (int64, error) {
+	incident.CreatedAt, incident.UpdatedAt = incident.CreatedAt.UTC(), incident.UpdatedAt.UTC()
+	result, err := r.NamedExec(`INSERT INTO incidents (title, status, severity, group_key, assignee, created_at, created_by, updated_at, resolved_at, org_id)
+		VALUES (:title, :status, :severity, :group_key, :assignee, :created_at, :created_by, :updated_at, :resolved_at, :org_id)`, incident)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to incidents", zap.Error(err))
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetIncident fetches the incident with its alerts and timeline
+func (r *ruleDB) GetIncident(ctx context.Context, id string) (*Incident, error) {
+	q := newSelectQuery("SELECT "+incidentColumns+" FROM incidents").where("id=?", id)
+	whereOrg(ctx, q, "incidents")
+	query, args := q.build()
+
+	incident := &Incident{}
+	if err := r.Get(incident, query, args...); err != nil {
+		return nil, err
+	}
+
+	incident.Alerts = []IncidentAlert{}
+	if err := r.Select(&incident.Alerts, `SELECT incident_id, fingerprint, rule_id, alert_name, labels, state, fired_at, resolved_at
+		FROM incident_alerts WHERE incident_id=$1 ORDER BY fired_at, fingerprint`, incident.Id); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	incident.Timeline = []IncidentEvent{}
+	if err := r.Select(&incident.Timeline, `SELECT id, incident_id, type, fingerprint, message, actor, timestamp
+		FROM incident_events WHERE incident_id=$1 ORDER BY timestamp, id`, incident.Id); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return incident, nil
+}
+
+// GetIncidents fetches the incidents matching the filter without their alerts and timeline
+func (r *ruleDB) GetIncidents(ctx context.Context, filter *IncidentFilter) ([]Incident, error) {
+	if filter == nil {
+		filter = &IncidentFilter{}
+	}
+
+	q := newSelectQuery("SELECT " + incidentColumns + " FROM incidents")
+	if filter.Status != "" {
+		q.where("status=?", filter.Status)
+	}
+	if filter.Open {
+		q.where("status<>?", IncidentStatusResolved)
+	}
+	if filter.GroupKey != "" {
+		q.where("group_key=?", filter.GroupKey)
+	}
+	if !filter.Start.IsZero() {
+		q.where("created_at>=?", filter.Start.UTC())
+	}
+	if !filter.End.IsZero() {
+		q.where("created_at<=?", filter.End.UTC())
+	}
+	whereOrg(ctx, q, "incidents")
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultIncidentLimit
+	}
+	query, args := q.order("created_at DESC, id DESC").page(limit, 0).build()
+
+	incidents := []Incident{}
+	if err := r.Select(&incidents, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return incidents, nil
+}
+
+func (r *ruleDB) UpdateIncident(ctx context.Context, incident Incident) error {
+	incident.UpdatedAt = incident.UpdatedAt.UTC()
+	_, err := r.NamedExec(`UPDATE incidents SET title=:title, status=:status, severity=:severity, assignee=:assignee,
+		updated_at=:updated_at, resolved_at=:resolved_at WHERE id=:id`, incident)
+	if err != nil {
+		zap.L().Error("Error in Executing UPDATE to incidents", zap.Error(err))
+	}
+	return err
+}
+
+// DeleteIncident deletes the incident with its alerts and timeline
+func (r *ruleDB) DeleteIncident(ctx context.Context, id int64) error {
+	tx, err := r.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"incident_events", "incident_alerts"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE incident_id=$1", id); err != nil {
+			zap.L().Error("Error in Executing DELETE from "+table, zap.Error(err))
+			return err
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM incidents WHERE id=$1", id); err != nil {
+		zap.L().Error("Error in Executing DELETE from incidents", zap.Error(err))
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetIncidentAlert adds the alert to the incident or updates its state
+func (r *ruleDB) SetIncidentAlert(ctx context.Context, alert IncidentAlert) error {
+	alert.FiredAt = alert.FiredAt.UTC()
+	_, err := r.NamedExec(`INSERT INTO incident_alerts (incident_id, fingerprint, rule_id, alert_name, labels, state, fired_at, resolved_at)
+		VALUES (:incident_id, :fingerprint, :rule_id, :alert_name, :labels, :state, :fired_at, :resolved_at)
+		ON CONFLICT(incident_id, fingerprint) DO UPDATE SET state=excluded.state, fired_at=excluded.fired_at, resolved_at=excluded.resolved_at`, alert)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to incident_alerts", zap.Error(err))
+	}
+	return err
+}
+
+func (r *ruleDB) DeleteIncidentAlert(ctx context.Context, incidentId int64, fingerprint string) error {
+	result, err := r.Exec("DELETE FROM incident_alerts WHERE incident_id=$1 AND fingerprint=$2", incidentId, fingerprint)
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE from incident_alerts", zap.Error(err))
+		return err
+	}
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetOpenIncidentAlerts fetches the alerts of the incidents that are not resolved
+func (r *ruleDB) GetOpenIncidentAlerts(ctx context.Context) ([]IncidentAlert, error) {
+	alerts := []IncidentAlert{}
+	err := r.Select(&alerts, `SELECT a.incident_id, a.fingerprint, a.rule_id, a.alert_name, a.labels, a.state, a.fired_at, a.resolved_at
+		FROM incident_alerts a JOIN incidents i ON i.id=a.incident_id WHERE i.status<>$1`, IncidentStatusResolved)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *ruleDB) AddIncidentEvents(ctx context.Context, events []IncidentEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := r.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, event := range events {
+		event.Timestamp = event.Timestamp.UTC()
+		if _, err := tx.NamedExec(`INSERT INTO incident_events (incident_id, type, fingerprint, message, actor, timestamp)
+			VALUES (:incident_id, :type, :fingerprint, :message, :actor, :timestamp)`, event); err != nil {
+			zap.L().Error("Error in Executing INSERT to incident_events", zap.Error(err))
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// incidentGroupKey is the key the firing alerts are correlated by, the service of the
+// alert or its rule when it has no service
+func incidentGroupKey(alert *Alert) string {
+	for _, name := range []string{"service.name", "service_name", "service"} {
+		if service := alert.Labels.Get(name); service != "" {
+			return "service:" + service
+		}
+	}
+	return "rule:" + alert.Labels.Get(labels.AlertRuleIdLabel)
+}
+
+// newIncidentAlert returns the alert of the incident with the state of the alert
+func newIncidentAlert(incidentId int64, alert *Alert) IncidentAlert {
+	incidentAlert := IncidentAlert{
+		IncidentId:  incidentId,
+		Fingerprint: fmt.Sprintf("%016x", alert.Labels.Hash()),
+		RuleId:      alert.Labels.Get(labels.AlertRuleIdLabel),
+		AlertName:   alert.Labels.Get(labels.AlertNameLabel),
+		Labels:      labelsMap(alert.Labels),
+		State:       "firing",
+		FiredAt:     alert.FiredAt,
+	}
+	if !alert.ResolvedAt.IsZero() {
+		resolvedAt := alert.ResolvedAt.UTC()
+		incidentAlert.State, incidentAlert.ResolvedAt = "resolved", &resolvedAt
+	}
+	return incidentAlert
+}
+
+// higherSeverity returns the higher of the severities
+func higherSeverity(a, b string) string {
+	if incidentSeverities[strings.ToLower(b)] > incidentSeverities[strings.ToLower(a)] {
+		return b
+	}
+	return a
+}
+
+// incidentState is the open incident with its alerts while the alerts are correlated
+type incidentState struct {
+	incident Incident
+	alerts   map[string]*IncidentAlert
+	changed  bool
+	// notify is set when the incident opened or resolved
+	notify bool
+}
+
+// correlateIncidents adds the notified alerts to the incidents: the firing alert joins the
+// open incident of its group changed within the window, or opens a new incident, and the
+// incident resolves once all its alerts resolved
+func (m *Manager) correlateIncidents(ctx context.Context, alerts []*Alert, now time.Time) {
+	if len(alerts) == 0 {
+		return
+	}
+	m.incidentMtx.Lock()
+	defer m.incidentMtx.Unlock()
+
+	open, err := m.ruleDB.GetIncidents(ctx, &IncidentFilter{Open: true, Limit: maxOpenIncidents})
+	if err != nil {
+		zap.L().Error("failed to fetch the open incidents", zap.Error(err))
+		return
+	}
+	openAlerts, err := m.ruleDB.GetOpenIncidentAlerts(ctx)
+	if err != nil {
+		zap.L().Error("failed to fetch the alerts of the open incidents", zap.Error(err))
+		return
+	}
+
+	states := map[int64]*incidentState{}
+	var ordered []*incidentState
+	for _, incident := range open {
+		state := &incidentState{incident: incident, alerts: map[string]*IncidentAlert{}}
+		states[incident.Id] = state
+		ordered = append(ordered, state)
+	}
+	byFingerprint := map[string][]*incidentState{}
+	for i := range openAlerts {
+		alert := &openAlerts[i]
+		if state, ok := states[alert.IncidentId]; ok {
+			state.alerts[alert.Fingerprint] = alert
+			byFingerprint[alert.Fingerprint] = append(byFingerprint[alert.Fingerprint], state)
+		}
+	}
+
+	var events []IncidentEvent
+	ruleOrgs := map[string]string{}
+	for _, alert := range alerts {
+		fingerprint := fmt.Sprintf("%016x", alert.Labels.Hash())
+		if joined := byFingerprint[fingerprint]; len(joined) > 0 {
+			for _, state := range joined {
+				updated := newIncidentAlert(state.incident.Id, alert)
+				existing := state.alerts[fingerprint]
+				if existing.State == updated.State && existing.FiredAt.Equal(updated.FiredAt.UTC()) {
+					continue
+				}
+				eventType := IncidentEventAlertFiring
+				if updated.State == "resolved" {
+					eventType = IncidentEventAlertResolved
+				}
+				events = append(events, IncidentEvent{IncidentId: state.incident.Id, Type: eventType, Fingerprint: fingerprint,
+					Message: fmt.Sprintf("%s is %s", updated.AlertName, updated.State), Timestamp: now})
+				if err := m.ruleDB.SetIncidentAlert(ctx, updated); err != nil {
+					continue
+				}
+				*existing = updated
+				state.changed = true
+			}
+			continue
+		}
+		if !alert.ResolvedAt.IsZero() {
+			continue
+		}
+
+		ruleId := alert.Labels.Get(labels.AlertRuleIdLabel)
+		orgID, ok := ruleOrgs[ruleId]
+		if !ok {
+			orgID = m.ruleOrgID(ctx, ruleId)
+			ruleOrgs[ruleId] = orgID
+		}
+		key := incidentGroupKey(alert)
+		var state *incidentState
+		for _, candidate := range ordered {
+			if candidate.incident.GroupKey == key && candidate.incident.OrgID == orgID && now.Sub(candidate.incident.UpdatedAt) <= m.opts.IncidentWindow {
+				state = candidate
+				break
+			}
+		}
+		if state == nil {
+			title := alert.Labels.Get(labels.AlertNameLabel)
+			if strings.HasPrefix(key, "service:") {
+				title = fmt.Sprintf("%s on %s", title, strings.TrimPrefix(key, "service:"))
+			}
+			incident := Incident{
+				Title:     title,
+				Status:    IncidentStatusOpen,
+				Severity:  alert.Labels.Get("severity"),
+				GroupKey:  key,
+				CreatedAt: now,
+				UpdatedAt: now,
+				OrgID:     orgID,
+			}
+			id, err := m.ruleDB.CreateIncident(ctx, incident)
+			if err != nil {
+				continue
+			}
+			incident.Id = id
+			state = &incidentState{incident: incident, alerts: map[string]*IncidentAlert{}, notify: true}
+			states[id] = state
+			ordered = append(ordered, state)
+			events = append(events, IncidentEvent{IncidentId: id, Type: IncidentEventOpened, Fingerprint: fingerprint,
+				Message: fmt.Sprintf("opened by %s", alert.Labels.Get(labels.AlertNameLabel)), Timestamp: now})
+		} else {
+			events = append(events, IncidentEvent{IncidentId: state.incident.Id, Type: IncidentEventAlertAdded, Fingerprint: fingerprint,
+				Message: fmt.Sprintf("%s joined the incident", alert.Labels.Get(labels.AlertNameLabel)), Timestamp: now})
+		}
+
+		added := newIncidentAlert(state.incident.Id, alert)
+		if err := m.ruleDB.SetIncidentAlert(ctx, added); err != nil {
+			continue
+		}
+		state.alerts[fingerprint] = &added
+		byFingerprint[fingerprint] = append(byFingerprint[fingerprint], state)
+		state.incident.Severity = higherSeverity(state.incident.Severity, alert.Labels.Get("severity"))
+		state.changed = true
+	}
+
+	for _, state := range ordered {
+		if !state.changed {
+			continue
+		}
+		state.incident.UpdatedAt = now
+		if resolvedAlerts(state.alerts) {
+			resolvedAt := now.UTC()
+			state.incident.Status, state.incident.ResolvedAt = IncidentStatusResolved, &resolvedAt
+			state.notify = true
+			events = append(events, IncidentEvent{IncidentId: state.incident.Id, Type: IncidentEventStatusChanged,
+				Message: "resolved, all the alerts resolved", Timestamp: now})
+		}
+		if err := m.ruleDB.UpdateIncident(ctx, state.incident); err != nil {
+			continue
+		}
+		if state.notify {
+			m.notifyIncident(ctx, &state.incident, now)
+		}
+	}
+	if err := m.ruleDB.AddIncidentEvents(ctx, events); err != nil {
+		zap.L().Error("failed to record the timeline of the incidents", zap.Error(err))
+	}
+}
+
+// resolvedAlerts reports whether all the alerts of the incident resolved
+func resolvedAlerts(alerts map[string]*IncidentAlert) bool {
+	if len(alerts) == 0 {
+		return false
+	}
+	for _, alert := range alerts {
+		if alert.State != "resolved" {
+			return false
+		}
+	}
+	return true
+}
+
+// ruleOrgID returns the org of the rule, empty when the rule is shared or can't be fetched
+func (m *Manager) ruleOrgID(ctx context.Context, ruleId string) string {
+	if ruleId == "" {
+		return ""
+	}
+	stored, err := m.ruleDB.GetStoredRule(ctx, ruleId)
+	if err != nil {
+		zap.L().Error("failed to fetch the rule of the alert", zap.String("ruleId", ruleId), zap.Error(err))
+		return ""
+	}
+	return stored.orgID()
+}
+
+// incidentNotification is the alert notifying the incident to the incident channels
+func incidentNotification(incident *Incident, channels []string, now time.Time) *Alert {
+	lbls := map[string]string{
+		labels.AlertNameLabel: fmt.Sprintf("Incident #%d: %s", incident.Id, incident.Title),
+		IncidentIdLabel:       strconv.FormatInt(incident.Id, 10),
+	}
+	if incident.Severity != "" {
+		lbls["severity"] = incident.Severity
+	}
+	annotations := map[string]string{
+		labels.AlertSummaryLabel: fmt.Sprintf("Incident #%d %s: %s", incident.Id, incident.Status, incident.Title),
+	}
+	if incident.Assignee != "" {
+		annotations[labels.AlertDescriptionLabel] = "Assigned to " + incident.Assignee
+	}
+
+	alert := &Alert{
+		State:       model.StateFiring,
+		Labels:      labels.FromMap(lbls),
+		Annotations: labels.FromMap(annotations),
+		Receivers:   channels,
+		ActiveAt:    incident.CreatedAt,
+		FiredAt:     incident.CreatedAt,
+		LastSentAt:  now,
+		ValidUntil:  now.Add(incidentNotificationValidity),
+	}
+	if incident.ResolvedAt != nil {
+		alert.State, alert.ResolvedAt = model.StateInactive, *incident.ResolvedAt
+	}
+	return alert
+}
+
+// notifyIncident sends the incident to the incident channels, it is not correlated again
+func (m *Manager) notifyIncident(ctx context.Context, incident *Incident, now time.Time) {
+	if len(m.opts.IncidentChannels) == 0 {
+		return
+	}
+	m.send(ctx, []*Alert{incidentNotification(incident, m.opts.IncidentChannels, now)})
+}
+
+// CreateIncident groups the active alerts with the fingerprints into a new incident
+func (m *Manager) CreateIncident(ctx context.Context, postable PostableIncident) (*Incident, error) {
+	if strings.TrimSpace(postable.Title) == "" {
+		return nil, ErrMissingIncidentTitle
+	}
+	alerts, err := m.activeAlertsByFingerprint(postable.Fingerprints)
+	if err != nil {
+		return nil, err
+	}
+
+	m.incidentMtx.Lock()
+	defer m.incidentMtx.Unlock()
+
+	now := time.Now()
+	incident := Incident{
+		Title:     postable.Title,
+		Status:    IncidentStatusOpen,
+		Severity:  postable.Severity,
+		Assignee:  postable.Assignee,
+		CreatedAt: now,
+		CreatedBy: auditActor(ctx),
+		UpdatedAt: now,
+		OrgID:     contextOrgID(ctx),
+	}
+	id, err := m.ruleDB.CreateIncident(ctx, incident)
+	if err != nil {
+		return nil, err
+	}
+	incident.Id = id
+
+	events := []IncidentEvent{{IncidentId: id, Type: IncidentEventOpened, Message: "opened by hand", Actor: incident.CreatedBy, Timestamp: now}}
+	for _, alert := range alerts {
+		if err := m.ruleDB.SetIncidentAlert(ctx, newIncidentAlert(id, alert)); err != nil {
+			return nil, err
+		}
+		events = append(events, IncidentEvent{IncidentId: id, Type: IncidentEventAlertAdded, Fingerprint: fmt.Sprintf("%016x", alert.Labels.Hash()),
+			Message: fmt.Sprintf("%s added", alert.Labels.Get(labels.AlertNameLabel)), Actor: incident.CreatedBy, Timestamp: now})
+	}
+	if err := m.ruleDB.AddIncidentEvents(ctx, events); err != nil {
+		return nil, err
+	}
+	m.notifyIncident(ctx, &incident, now)
+	return m.ruleDB.GetIncident(ctx, strconv.FormatInt(id, 10))
+}
+
+// UpdateIncident changes the incident, the changes are recorded in its timeline
+func (m *Manager) UpdateIncident(ctx context.Context, id string, update IncidentUpdate) (*Incident, error) {
+	if err := update.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.incidentMtx.Lock()
+	defer m.incidentMtx.Unlock()
+
+	incident, err := m.ruleDB.GetIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	actor := auditActor(ctx)
+	var events []IncidentEvent
+	event := func(eventType IncidentEventType, message string) {
+		events = append(events, IncidentEvent{IncidentId: incident.Id, Type: eventType, Message: message, Actor: actor, Timestamp: now})
+	}
+	notify := false
+	if update.Title != nil && *update.Title != incident.Title {
+		event(IncidentEventEdited, fmt.Sprintf("title changed from %q to %q", incident.Title, *update.Title))
+		incident.Title = *update.Title
+	}
+	if update.Severity != nil && *update.Severity != incident.Severity {
+		event(IncidentEventEdited, fmt.Sprintf("severity changed from %q to %q", incident.Severity, *update.Severity))
+		incident.Severity = *update.Severity
+	}
+	if update.Assignee != nil && *update.Assignee != incident.Assignee {
+		event(IncidentEventAssigned, fmt.Sprintf("assigned to %q", *update.Assignee))
+		incident.Assignee = *update.Assignee
+		notify = true
+	}
+	if update.Status != nil && *update.Status != incident.Status {
+		event(IncidentEventStatusChanged, fmt.Sprintf("%s, was %s", *update.Status, incident.Status))
+		incident.Status = *update.Status
+		incident.ResolvedAt = nil
+		if incident.Status == IncidentStatusResolved {
+			resolvedAt := now.UTC()
+			incident.ResolvedAt = &resolvedAt
+		}
+		notify = true
+	}
+	if len(events) == 0 {
+		return incident, nil
+	}
+
+	incident.UpdatedAt = now
+	if err := m.ruleDB.UpdateIncident(ctx, *incident); err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.AddIncidentEvents(ctx, events); err != nil {
+		return nil, err
+	}
+	if notify {
+		m.notifyIncident(ctx, incident, now)
+	}
+	return m.ruleDB.GetIncident(ctx, id)
+}
+
+// AddIncidentAlerts adds the active alerts with the fingerprints to the incident
+func (m *Manager) AddIncidentAlerts(ctx context.Context, id string, fingerprints []string) (*Incident, error) {
+	alerts, err := m.activeAlertsByFingerprint(fingerprints)
+	if err != nil {
+		return nil, err
+	}
+
+	m.incidentMtx.Lock()
+	defer m.incidentMtx.Unlock()
+
+	incident, err := m.ruleDB.GetIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	actor := auditActor(ctx)
+	var events []IncidentEvent
+	for _, alert := range alerts {
+		if err := m.ruleDB.SetIncidentAlert(ctx, newIncidentAlert(incident.Id, alert)); err != nil {
+			return nil, err
+		}
+		events = append(events, IncidentEvent{IncidentId: incident.Id, Type: IncidentEventAlertAdded, Fingerprint: fmt.Sprintf("%016x", alert.Labels.Hash()),
+			Message: fmt.Sprintf("%s added", alert.Labels.Get(labels.AlertNameLabel)), Actor: actor, Timestamp: now})
+		incident.Severity = higherSeverity(incident.Severity, alert.Labels.Get("severity"))
+	}
+	incident.UpdatedAt = now
+	if err := m.ruleDB.UpdateIncident(ctx, *incident); err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.AddIncidentEvents(ctx, events); err != nil {
+		return nil, err
+	}
+	return m.ruleDB.GetIncident(ctx, id)
+}
+
+// RemoveIncidentAlert removes the alert from the incident
+func (m *Manager) RemoveIncidentAlert(ctx context.Context, id string, fingerprint string) (*Incident, error) {
+	m.incidentMtx.Lock()
+	defer m.incidentMtx.Unlock()
+
+	incident, err := m.ruleDB.GetIncident(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.DeleteIncidentAlert(ctx, incident.Id, fingerprint); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	incident.UpdatedAt = now
+	if err := m.ruleDB.UpdateIncident(ctx, *incident); err != nil {
+		return nil, err
+	}
+	event := IncidentEvent{IncidentId: incident.Id, Type: IncidentEventAlertRemoved, Fingerprint: fingerprint, Message: "alert removed", Actor: auditActor(ctx), Timestamp: now}
+	if err := m.ruleDB.AddIncidentEvents(ctx, []IncidentEvent{event}); err != nil {
+		return nil, err
+	}
+	return m.ruleDB.GetIncident(ctx, id)
+}
+
+// DeleteIncident deletes the incident visible to the user
+func (m *Manager) DeleteIncident(ctx context.Context, id string) error {
+	m.incidentMtx.Lock()
+	defer m.incidentMtx.Unlock()
+
+	incident, err := m.ruleDB.GetIncident(ctx, id)
+	if err != nil {
+		return err
+	}
+	return m.ruleDB.DeleteIncident(ctx, incident.Id)
+}
+
+// activeAlertsByFingerprint returns the active alerts of the rules with the fingerprints
+func (m *Manager) activeAlertsByFingerprint(fingerprints []string) ([]*Alert, error) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	active := map[string]*Alert{}
+	for _, rule := range m.rules {
+		for _, alert := range rule.ActiveAlerts() {
+			active[fmt.Sprintf("%016x", alert.Labels.Hash())] = alert
+		}
+	}
+	alerts := make([]*Alert, 0, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		alert, ok := active[fingerprint]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrAlertNotActive, fingerprint)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, nil
+}
@@ -0,0 +1,112 @@
+package rules
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestCorrelateIncidents(t *testing.T) {
+	m := newTestManager(t)
+	m.opts.IncidentWindow = 30 * time.Minute
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	alert := func(name, service, severity string) *Alert {
+		return &Alert{
+			Labels:  labels.FromMap(map[string]string{labels.AlertNameLabel: name, labels.AlertRuleIdLabel: name, "service.name": service, "severity": severity}),
+			FiredAt: now,
+		}
+	}
+	errors := alert("Errors", "cart", "warning")
+	latency := alert("Latency", "cart", "critical")
+	other := alert("Errors", "frontend", "warning")
+
+	m.correlateIncidents(ctx, []*Alert{errors}, now)
+	m.correlateIncidents(ctx, []*Alert{latency, other}, now.Add(10*time.Minute))
+
+	incidents, err := m.ruleDB.GetIncidents(ctx, &IncidentFilter{Open: true})
+	assert.NoError(t, err)
+	if !assert.Len(t, incidents, 2) {
+		return
+	}
+	assert.Equal(t, "Errors on frontend", incidents[0].Title)
+	assert.Equal(t, "Errors on cart", incidents[1].Title)
+	assert.Equal(t, "critical", incidents[1].Severity)
+
+	cart, err := m.ruleDB.GetIncident(ctx, strconv.FormatInt(incidents[1].Id, 10))
+	assert.NoError(t, err)
+	assert.Len(t, cart.Alerts, 2)
+
+	// the incident resolves once all its alerts resolved
+	resolved := *errors
+	resolved.ResolvedAt = now.Add(20 * time.Minute)
+	m.correlateIncidents(ctx, []*Alert{&resolved}, now.Add(20*time.Minute))
+	cart, err = m.ruleDB.GetIncident(ctx, strconv.FormatInt(cart.Id, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, IncidentStatusOpen, cart.Status)
+
+	resolved = *latency
+	resolved.ResolvedAt = now.Add(25 * time.Minute)
+	m.correlateIncidents(ctx, []*Alert{&resolved}, now.Add(25*time.Minute))
+	cart, err = m.ruleDB.GetIncident(ctx, strconv.FormatInt(cart.Id, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, IncidentStatusResolved, cart.Status)
+	var types []IncidentEventType
+	for _, event := range cart.Timeline {
+		types = append(types, event.Type)
+	}
+	assert.Equal(t, []IncidentEventType{IncidentEventOpened, IncidentEventAlertAdded, IncidentEventAlertResolved, IncidentEventAlertResolved, IncidentEventStatusChanged}, types)
+
+	// the alert firing after the window opens a new incident
+	m.correlateIncidents(ctx, []*Alert{alert("Latency", "frontend", "warning")}, now.Add(2*time.Hour))
+	incidents, err = m.ruleDB.GetIncidents(ctx, &IncidentFilter{Open: true})
+	assert.NoError(t, err)
+	assert.Len(t, incidents, 2)
+	incidents, err = m.ruleDB.GetIncidents(ctx, nil)
+	assert.NoError(t, err)
+	assert.Len(t, incidents, 3)
+}
+
+func TestUpdateIncident(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	_, err := m.CreateIncident(ctx, PostableIncident{Title: " "})
+	assert.ErrorIs(t, err, ErrMissingIncidentTitle)
+	_, err = m.CreateIncident(ctx, PostableIncident{Title: "Checkout is down", Fingerprints: []string{"missing"}})
+	assert.ErrorIs(t, err, ErrAlertNotActive)
+
+	incident, err := m.CreateIncident(ctx, PostableIncident{Title: "Checkout is down", Severity: "critical"})
+	assert.NoError(t, err)
+	id := strconv.FormatInt(incident.Id, 10)
+
+	assignee, status := "jane@example.com", IncidentStatusAcknowledged
+	incident, err = m.UpdateIncident(ctx, id, IncidentUpdate{Assignee: &assignee, Status: &status})
+	assert.NoError(t, err)
+	assert.Equal(t, assignee, incident.Assignee)
+	assert.Equal(t, IncidentStatusAcknowledged, incident.Status)
+	assert.Len(t, incident.Timeline, 3)
+
+	invalid := IncidentStatus("closed")
+	_, err = m.UpdateIncident(ctx, id, IncidentUpdate{Status: &invalid})
+	assert.ErrorIs(t, err, ErrInvalidIncidentStatus)
+
+	status = IncidentStatusResolved
+	incident, err = m.UpdateIncident(ctx, id, IncidentUpdate{Status: &status})
+	assert.NoError(t, err)
+	assert.NotNil(t, incident.ResolvedAt)
+
+	notification := incidentNotification(incident, []string{"oncall"}, time.Now())
+	assert.Equal(t, "Incident #"+id+": Checkout is down", notification.Labels.Get(labels.AlertNameLabel))
+	assert.Equal(t, []string{"oncall"}, notification.Receivers)
+	assert.False(t, notification.ResolvedAt.IsZero())
+
+	assert.NoError(t, m.DeleteIncident(ctx, id))
+	_, err = m.ruleDB.GetIncident(ctx, id)
+	assert.Error(t, err)
+}
@@ -0,0 +1,356 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	text_template "text/template"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+// jiraSummaryLength is the max length of the summary of the issue
+const jiraSummaryLength = 255
+
+var ErrMissingJiraProject = errors.New("jira channel must have the site url, the user, the api token and the project")
+
+// JiraConfig opens a Jira issue when the alert fires and comments the resolution on
+// the issue when the alert resolves. The alert firing again while its issue is open
+// doesn't open another issue.
+type JiraConfig struct {
+	// URL is the url of the jira site, e.g https://example.atlassian.net
+	URL string `json:"url"`
+	// User and APIToken authenticate the requests to jira
+	User     string `json:"user"`
+	APIToken string `json:"api_token"`
+	// Project is the key of the project the issues are opened in
+	Project string `json:"project"`
+	// IssueType is the type of the issues, Bug by default
+	IssueType string `json:"issue_type,omitempty"`
+
+	// Summary and Description are the templates of the issue, the title and body of the
+	// template of the channel or the summary of the alert by default. The templates are
+	// executed with the same data as the channel templates.
+	Summary     string `json:"summary,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Priority is the template of the name of the priority of the issue
+	Priority string `json:"priority,omitempty"`
+	// Labels are the templates of the labels of the issue
+	Labels []string `json:"labels,omitempty"`
+	// Fields are the templates of the other fields of the issue by field id, e.g customfield_10010
+	Fields map[string]string `json:"fields,omitempty"`
+
+	// SendResolved comments the resolution on the issue, true by default
+	SendResolved *bool `json:"send_resolved,omitempty"`
+	// ResolveTransition is the id of the transition applied to the issue when the alert resolves
+	ResolveTransition string `json:"resolve_transition,omitempty"`
+}
+
+// JiraIssue is the issue opened for the alert in the channel
+type JiraIssue struct {
+	Id          int64      `json:"id" db:"id"`
+	Channel     string     `json:"channel" db:"channel"`
+	Fingerprint string     `json:"fingerprint" db:"fingerprint"`
+	IssueKey    string     `json:"issueKey" db:"issue_key"`
+	IssueURL    string     `json:"issueUrl" db:"issue_url"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	ResolvedAt  *time.Time `json:"resolvedAt,omitempty" db:"resolved_at"`
+}
+
+func (r *ruleDB) CreateJiraIssue(ctx context.Context, issue JiraIssue) error {
+	issue.CreatedAt = issue.CreatedAt.UTC()
+	_, err := r.NamedExec(`INSERT INTO jira_issues (channel, fingerprint, issue_key, issue_url, created_at)
+		VALUES (:channel, :fingerprint, :issue_key, :issue_url, :created_at)`, issue)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to jira_issues", zap.Error(err))
+	}
+	return err
+}
+
+func (r *ruleDB) GetOpenJiraIssue(ctx context.Context, channel, fingerprint string) (*JiraIssue, error) {
+	issue := &JiraIssue{}
+	err := r.Get(issue, `SELECT id, channel, fingerprint, issue_key, issue_url, created_at, resolved_at FROM jira_issues
+		WHERE channel=$1 AND fingerprint=$2 AND resolved_at IS NULL ORDER BY created_at DESC LIMIT 1`, channel, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	return issue, nil
+}
+
+func (r *ruleDB) ResolveJiraIssue(ctx context.Context, id int64, ts time.Time) error {
+	_, err := r.Exec("UPDATE jira_issues SET resolved_at=$1 WHERE id=$2", ts.UTC(), id)
+	if err != nil {
+		zap.L().Error("Error in Executing UPDATE to jira_issues", zap.Error(err))
+	}
+	return err
+}
+
+// jiraTemplate executes the template of the issue with the notification data
+func jiraTemplate(name, text string, data *notificationData) (string, error) {
+	tmpl, err := text_template.New(name).Funcs(channelTemplateFuncs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: jira %s: %v", ErrInvalidChannelTemplate, name, err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("%w: jira %s: %v", ErrInvalidChannelTemplate, name, err)
+	}
+	return b.String(), nil
+}
+
+// issueFields returns the fields of the issue opened for the alert
+func (config *JiraConfig) issueFields(channel *deliveredChannel, alert *Alert) (map[string]interface{}, error) {
+	summary := labelsMap(alert.Annotations)[labels.AlertSummaryLabel]
+	if summary == "" {
+		summary = alert.Labels.Get(labels.AlertNameLabel)
+	}
+	description := labelsMap(alert.Annotations)[labels.AlertDescriptionLabel]
+	rendered, err := channel.renderAlertTemplate(alert, ChannelTemplateText)
+	if err != nil {
+		return nil, err
+	}
+	if rendered != nil {
+		summary, description = rendered.Title, rendered.Body
+	}
+
+	data := newNotificationData(channel.name, []*Alert{alert}).localize(channel.receiver)
+	if config.Summary != "" {
+		if summary, err = jiraTemplate("summary", config.Summary, data); err != nil {
+			return nil, err
+		}
+	}
+	if config.Description != "" {
+		if description, err = jiraTemplate("description", config.Description, data); err != nil {
+			return nil, err
+		}
+	}
+	summary = strings.TrimSpace(strings.ReplaceAll(summary, "\n", " "))
+	if len(summary) > jiraSummaryLength {
+		summary = summary[:jiraSummaryLength]
+	}
+	if alert.GeneratorURL != "" {
+		description += fmt.Sprintf("\n\n[View the alert in SigNoz|%s]", alert.GeneratorURL)
+	}
+
+	issueType := config.IssueType
+	if issueType == "" {
+		issueType = "Bug"
+	}
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": config.Project},
+		"issuetype":   map[string]string{"name": issueType},
+		"summary":     summary,
+		"description": strings.TrimSpace(description),
+	}
+	if config.Priority != "" {
+		priority, err := jiraTemplate("priority", config.Priority, data)
+		if err != nil {
+			return nil, err
+		}
+		if priority != "" {
+			fields["priority"] = map[string]string{"name": priority}
+		}
+	}
+	if len(config.Labels) > 0 {
+		issueLabels := make([]string, 0, len(config.Labels))
+		for _, l := range config.Labels {
+			value, err := jiraTemplate("labels", l, data)
+			if err != nil {
+				return nil, err
+			}
+			// the jira labels can't contain spaces
+			if value = strings.ReplaceAll(strings.TrimSpace(value), " ", "_"); value != "" {
+				issueLabels = append(issueLabels, value)
+			}
+		}
+		fields["labels"] = issueLabels
+	}
+	for id, text := range config.Fields {
+		value, err := jiraTemplate(id, text, data)
+		if err != nil {
+			return nil, err
+		}
+		fields[id] = value
+	}
+	return fields, nil
+}
+
+// resolutionComment is the comment added to the issue when the alert resolves, the
+// time is in RFC 3339 unless the channel has its own timezone or time format
+func resolutionComment(channel *deliveredChannel, alert *Alert) string {
+	resolvedAt := alert.ResolvedAt.UTC().Format(time.RFC3339)
+	if receiver := channel.receiver; receiver != nil && (receiver.Timezone != "" || receiver.TimeFormat != "") {
+		resolvedAt = newNotificationData(channel.name, nil).localize(receiver).FormatTime(alert.ResolvedAt)
+	}
+	comment := fmt.Sprintf("The alert %s resolved at %s.", alert.Labels.Get(labels.AlertNameLabel), resolvedAt)
+	if alert.GeneratorURL != "" {
+		comment += fmt.Sprintf("\n\n[View the alert in SigNoz|%s]", alert.GeneratorURL)
+	}
+	return comment
+}
+
+type jiraSender struct{}
+
+func (jiraSender) configured(receiver *am.Receiver) bool {
+	return receiver.JiraConfigs != nil
+}
+
+func (jiraSender) configs(receiver *am.Receiver) ([]JiraConfig, error) {
+	var configs []JiraConfig
+	if err := decodeConfigs(receiver.JiraConfigs, &configs); err != nil {
+		return nil, fmt.Errorf("invalid jira configs: %w", err)
+	}
+	return configs, nil
+}
+
+func (s jiraSender) validate(receiver *am.Receiver) error {
+	configs, err := s.configs(receiver)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if config.URL == "" || config.User == "" || config.APIToken == "" || config.Project == "" {
+			return ErrMissingJiraProject
+		}
+		u, err := url.Parse(config.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid jira site url %q", config.URL)
+		}
+		templates := map[string]string{"summary": config.Summary, "description": config.Description, "priority": config.Priority}
+		for i, l := range config.Labels {
+			templates[fmt.Sprintf("labels[%d]", i)] = l
+		}
+		for id, text := range config.Fields {
+			templates[id] = text
+		}
+		for name, text := range templates {
+			if _, err := text_template.New(name).Funcs(channelTemplateFuncs).Parse(text); err != nil {
+				return fmt.Errorf("%w: jira %s: %v", ErrInvalidChannelTemplate, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (s jiraSender) send(ctx context.Context, d *channelDelivery, channel *deliveredChannel, alerts []*Alert) error {
+	configs, err := s.configs(channel.receiver)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range configs {
+		config := &configs[i]
+		for _, alert := range alerts {
+			var err error
+			if alert.ResolvedAt.IsZero() {
+				err = d.openJiraIssue(ctx, channel, config, alert)
+			} else if config.SendResolved == nil || *config.SendResolved {
+				err = d.resolveJiraIssue(ctx, channel, config, alert)
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// request returns the request to the jira api at the path
+func (config *JiraConfig) request(channel *deliveredChannel, path string, body interface{}, alert *Alert) (*deliveryRequest, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &deliveryRequest{
+		Channel:     channel.name,
+		ChannelType: "jira",
+		URL:         strings.TrimSuffix(config.URL, "/") + path,
+		Body:        data,
+		Headers: map[string]string{
+			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(config.User+":"+config.APIToken)),
+		},
+		Fingerprints: []string{fmt.Sprintf("%016x", alert.Labels.Hash())},
+		Alerts:       notifiedAlerts([]*Alert{alert}),
+		Retries:      defaultDeliveryRetries,
+	}, nil
+}
+
+// openJiraIssue opens the issue of the firing alert unless it already has an open issue
+func (d *channelDelivery) openJiraIssue(ctx context.Context, channel *deliveredChannel, config *JiraConfig, alert *Alert) error {
+	fingerprint := fmt.Sprintf("%016x", alert.Labels.Hash())
+	// the test notification opens an issue every time and it isn't tracked
+	if d.test == nil {
+		if _, err := d.ruleDB.GetOpenJiraIssue(ctx, channel.name, fingerprint); err == nil {
+			return nil
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	fields, err := config.issueFields(channel, alert)
+	if err != nil {
+		return err
+	}
+	request, err := config.request(channel, "/rest/api/2/issue", map[string]interface{}{"fields": fields}, alert)
+	if err != nil {
+		return err
+	}
+	if err := d.send(ctx, request); err != nil || d.test != nil {
+		return err
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := json.Unmarshal(request.response, &created); err != nil || created.Key == "" {
+		return fmt.Errorf("jira channel %s: the issue key is missing in the response", channel.name)
+	}
+	return d.ruleDB.CreateJiraIssue(ctx, JiraIssue{
+		Channel:     channel.name,
+		Fingerprint: fingerprint,
+		IssueKey:    created.Key,
+		IssueURL:    strings.TrimSuffix(config.URL, "/") + "/browse/" + created.Key,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// resolveJiraIssue comments the resolution on the open issue of the alert and applies
+// the resolve transition, the issue stays open when the comment fails
+func (d *channelDelivery) resolveJiraIssue(ctx context.Context, channel *deliveredChannel, config *JiraConfig, alert *Alert) error {
+	issue, err := d.ruleDB.GetOpenJiraIssue(ctx, channel.name, fmt.Sprintf("%016x", alert.Labels.Hash()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	path := "/rest/api/2/issue/" + url.PathEscape(issue.IssueKey)
+	request, err := config.request(channel, path+"/comment", map[string]string{"body": resolutionComment(channel, alert)}, alert)
+	if err != nil {
+		return err
+	}
+	if err := d.send(ctx, request); err != nil {
+		return err
+	}
+	if config.ResolveTransition != "" {
+		request, err := config.request(channel, path+"/transitions", map[string]interface{}{"transition": map[string]string{"id": config.ResolveTransition}}, alert)
+		if err != nil {
+			return err
+		}
+		if err := d.send(ctx, request); err != nil {
+			zap.L().Error("failed to transition the jira issue", zap.String("issue", issue.IssueKey), zap.Error(err))
+		}
+	}
+	return d.ruleDB.ResolveJiraIssue(ctx, issue.Id, alert.ResolvedAt)
+}
@@ -0,0 +1,106 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestJiraIssueFields(t *testing.T) {
+	alert := &Alert{
+		Labels:       labels.FromMap(map[string]string{"alertname": "High latency", "severity": "critical", "service": "cart"}),
+		Annotations:  labels.FromMap(map[string]string{"summary": "The p99 latency of cart is 2s"}),
+		GeneratorURL: "https://signoz.example.com/alerts/edit?ruleId=1",
+	}
+	channel := &deliveredChannel{name: "jira"}
+	config := &JiraConfig{
+		Project:  "OPS",
+		Priority: `{{ if eq .CommonLabels.severity "critical" }}Highest{{ end }}`,
+		Labels:   []string{"signoz", "{{ .CommonLabels.service }} service"},
+		Fields:   map[string]string{"customfield_10010": "{{ .CommonLabels.service }}"},
+	}
+
+	fields, err := config.issueFields(channel, alert)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"key": "OPS"}, fields["project"])
+	assert.Equal(t, map[string]string{"name": "Bug"}, fields["issuetype"])
+	assert.Equal(t, "The p99 latency of cart is 2s", fields["summary"])
+	assert.Equal(t, "[View the alert in SigNoz|https://signoz.example.com/alerts/edit?ruleId=1]", fields["description"])
+	assert.Equal(t, map[string]string{"name": "Highest"}, fields["priority"])
+	assert.Equal(t, []string{"signoz", "cart_service"}, fields["labels"])
+	assert.Equal(t, "cart", fields["customfield_10010"])
+
+	config.Summary = "{{ .CommonLabels.alertname }} on {{ .CommonLabels.service }}"
+	fields, err = config.issueFields(channel, alert)
+	assert.NoError(t, err)
+	assert.Equal(t, "High latency on cart", fields["summary"])
+}
+
+func TestJiraValidate(t *testing.T) {
+	config := map[string]interface{}{"url": "https://example.atlassian.net", "user": "bot@example.com", "api_token": "token", "project": "OPS"}
+	assert.Nil(t, validateDeliveredConfigs(&am.Receiver{Name: "jira", JiraConfigs: []interface{}{config}}))
+	assert.ErrorIs(t, validateDeliveredConfigs(&am.Receiver{Name: "jira", JiraConfigs: []interface{}{map[string]interface{}{"url": "https://example.atlassian.net"}}}).Err, ErrMissingJiraProject)
+	config["summary"] = "{{ .CommonLabels.alertname"
+	assert.ErrorIs(t, validateDeliveredConfigs(&am.Receiver{Name: "jira", JiraConfigs: []interface{}{config}}).Err, ErrInvalidChannelTemplate)
+}
+
+func TestJiraIssueLifecycle(t *testing.T) {
+	var (
+		mtx      sync.Mutex
+		requests []string
+		bodies   []map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, _ := r.BasicAuth()
+		assert.Equal(t, "bot@example.com", user)
+		assert.Equal(t, "token", token)
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mtx.Lock()
+		requests = append(requests, r.URL.Path)
+		bodies = append(bodies, body)
+		mtx.Unlock()
+		if r.URL.Path == "/rest/api/2/issue" {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"10001","key":"OPS-1"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	db := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil).(*ruleDB)
+	delivery := newChannelDelivery(db)
+	ctx := context.Background()
+	channel := &deliveredChannel{name: "jira", receiver: &am.Receiver{Name: "jira", JiraConfigs: []interface{}{map[string]interface{}{
+		"url": server.URL, "user": "bot@example.com", "api_token": "token", "project": "OPS", "resolve_transition": "31",
+	}}}}
+	firing := &Alert{Labels: labels.FromMap(map[string]string{"alertname": "Errors", "service": "cart"})}
+
+	// the alert notified again while its issue is open doesn't open another issue
+	assert.NoError(t, jiraSender{}.send(ctx, delivery, channel, []*Alert{firing}))
+	assert.NoError(t, jiraSender{}.send(ctx, delivery, channel, []*Alert{firing}))
+	issue, err := db.GetOpenJiraIssue(ctx, "jira", pagerdutyDedupKey(firing))
+	assert.NoError(t, err)
+	assert.Equal(t, "OPS-1", issue.IssueKey)
+	assert.Equal(t, server.URL+"/browse/OPS-1", issue.IssueURL)
+
+	resolved := *firing
+	resolved.ResolvedAt = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, jiraSender{}.send(ctx, delivery, channel, []*Alert{&resolved}))
+	_, err = db.GetOpenJiraIssue(ctx, "jira", pagerdutyDedupKey(firing))
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"/rest/api/2/issue", "/rest/api/2/issue/OPS-1/comment", "/rest/api/2/issue/OPS-1/transitions"}, requests)
+	assert.Equal(t, "The alert Errors resolved at 2024-05-01T10:00:00Z.", bodies[1]["body"])
+	assert.Equal(t, map[string]interface{}{"id": "31"}, bodies[2]["transition"])
+}
@@ -0,0 +1,89 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	promModel "github.com/prometheus/common/model"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const (
+	// DefaultLabelAlertCountsWindow is the time range the alerts are counted over when
+	// the window is not given
+	DefaultLabelAlertCountsWindow = 7 * 24 * time.Hour
+	// maxLabelAlertCountsWindow bounds the history scanned for the alert counts
+	maxLabelAlertCountsWindow = 90 * 24 * time.Hour
+)
+
+// LabelAlertCount is how often the alerts with the value of the label fired and their
+// share of all the firings
+type LabelAlertCount struct {
+	model.LabelFiringCount
+	Share float64 `json:"share"`
+}
+
+// LabelAlertCountsReport is the firings of the rules the user can view over the window
+// broken down by the value of the label, the most firing values first
+type LabelAlertCountsReport struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Label string    `json:"label"`
+	// Total is the number of the firings of all the values, including those past the limit
+	Total  uint64            `json:"total"`
+	Values []LabelAlertCount `json:"values"`
+}
+
+// ValidateAlertLabel checks the label is a valid label name to break the alerts down by
+func ValidateAlertLabel(label string) error {
+	if !promModel.LabelName(label).IsValid() {
+		return fmt.Errorf("invalid label %q", label)
+	}
+	return nil
+}
+
+// AlertCountsByLabel counts the firings of the rules the user can view over the window
+// by the value of the label, such as the service or the cluster of the alerts
+func (m *Manager) AlertCountsByLabel(ctx context.Context, label string, window time.Duration, limit int) (*LabelAlertCountsReport, error) {
+	if err := ValidateAlertLabel(label); err != nil {
+		return nil, err
+	}
+	if window <= 0 {
+		window = DefaultLabelAlertCountsWindow
+	}
+	end := time.Now()
+	start := end.Add(-min(window, maxLabelAlertCountsWindow))
+	report := &LabelAlertCountsReport{Start: start, End: end, Label: label, Values: []LabelAlertCount{}}
+
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(storedRules) == 0 {
+		return report, nil
+	}
+	ruleIds := make([]string, 0, len(storedRules))
+	for _, storedRule := range storedRules {
+		ruleIds = append(ruleIds, strconv.Itoa(storedRule.Id))
+	}
+
+	counts, err := m.reader.GetFiringCountsByLabel(ctx, start.UnixMilli(), end.UnixMilli(), label, ruleIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		report.Total += count.Firings
+	}
+	for _, count := range counts {
+		if limit > 0 && len(report.Values) == limit {
+			break
+		}
+		report.Values = append(report.Values, LabelAlertCount{
+			LabelFiringCount: count,
+			Share:            float64(count.Firings) / float64(report.Total),
+		})
+	}
+	return report, nil
+}
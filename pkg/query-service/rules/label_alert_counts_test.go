@@ -0,0 +1,57 @@
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// labelCountsReader returns the firing counts of the label queried
+type labelCountsReader struct {
+	interfaces.Reader
+	counts  []model.LabelFiringCount
+	label   string
+	ruleIDs []string
+}
+
+func (r *labelCountsReader) GetFiringCountsByLabel(ctx context.Context, start, end int64, label string, ruleIDs []string) ([]model.LabelFiringCount, error) {
+	r.label, r.ruleIDs = label, ruleIDs
+	return r.counts, nil
+}
+
+func TestAlertCountsByLabel(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	reader := &labelCountsReader{counts: []model.LabelFiringCount{
+		{Value: "checkout", Firings: 6, Rules: 2, Series: 3},
+		{Value: "cart", Firings: 3, Rules: 1, Series: 1},
+		{Value: "", Firings: 1, Rules: 1, Series: 1},
+	}}
+	m.reader = reader
+
+	_, err := m.AlertCountsByLabel(ctx, "service-name", 0, 0)
+	assert.Error(t, err)
+
+	report, err := m.AlertCountsByLabel(ctx, "service", 0, 2)
+	require.NoError(t, err)
+	assert.Empty(t, report.Values)
+
+	for _, alert := range []string{"Error rate", "Latency"} {
+		_, err := m.CreateRule(ctx, `{"alert":"`+alert+`","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+		require.NoError(t, err)
+	}
+	report, err = m.AlertCountsByLabel(ctx, "service", 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "service", reader.label)
+	assert.ElementsMatch(t, []string{"1", "2"}, reader.ruleIDs)
+	assert.Equal(t, uint64(10), report.Total)
+	if assert.Len(t, report.Values, 2) {
+		assert.Equal(t, "checkout", report.Values[0].Value)
+		assert.InDelta(t, 0.6, report.Values[0].Share, 1e-9)
+		assert.Equal(t, "cart", report.Values[1].Value)
+	}
+}
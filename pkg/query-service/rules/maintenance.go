@@ -3,6 +3,7 @@ package rules
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"slices"
 	"strings"
 	"time"
@@ -17,22 +18,50 @@ var (
 	ErrMissingTimezone   = errors.New("missing timezone")
 	ErrMissingRepeatType = errors.New("missing repeat type")
 	ErrMissingDuration   = errors.New("missing duration")
+	ErrMissingWindow     = errors.New("missing start or end time")
+	ErrMaxDuration       = errors.New("maintenance window cannot be longer than 90 days")
 )
 
+// maxMaintenanceDuration is the longest window of the maintenance
+const maxMaintenanceDuration = 90 * 24 * time.Hour
+
 type PlannedMaintenance struct {
 	Id          int64     `json:"id" db:"id"`
 	Name        string    `json:"name" db:"name"`
 	Description string    `json:"description" db:"description"`
 	Schedule    *Schedule `json:"schedule" db:"schedule"`
 	AlertIds    *AlertIds `json:"alertIds" db:"alert_ids"`
-	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-	CreatedBy   string    `json:"createdBy" db:"created_by"`
-	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
-	UpdatedBy   string    `json:"updatedBy" db:"updated_by"`
-	Status      string    `json:"status"`
-	Kind        string    `json:"kind"`
+	// Matchers mute the alerts by their labels, the alert ids limit the rules of the alerts
+	Matchers MaintenanceMatchers `json:"matchers,omitempty" db:"matchers"`
+	// Severities limit the muted alerts to the alerts with the severities e.g. to keep the criticals paging
+	Severities MaintenanceList `json:"severities,omitempty" db:"severities"`
+	// Channels limit the muted notifications to the channels, the alerts are still sent to the other channels
+	Channels MaintenanceList `json:"channels,omitempty" db:"channels"`
+	// Mode is whether the rules are paused or only their notifications are muted, the
+	// partial maintenance always mutes
+	Mode MaintenanceMode `json:"mode,omitempty" db:"mode"`
+	// ExpiresAt is set for the quick maintenance, it is purged after the expiry
+	ExpiresAt *time.Time `json:"expiresAt,omitempty" db:"expires_at"`
+	CreatedAt time.Time  `json:"createdAt" db:"created_at"`
+	CreatedBy string     `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time  `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string     `json:"updatedBy" db:"updated_by"`
+	OrgID     string     `json:"-" db:"org_id"`
+	Status    string     `json:"status"`
+	Kind      string     `json:"kind"`
 }
 
+// MaintenanceMode is what the maintenance does to the rules it covers
+type MaintenanceMode string
+
+const (
+	// MaintenanceModePause skips the evaluation of the rules, the default
+	MaintenanceModePause MaintenanceMode = "pause"
+	// MaintenanceModeMute evaluates the rules and records the state history
+	// but drops the notifications
+	MaintenanceModeMute MaintenanceMode = "mute"
+)
+
 type AlertIds []string
 
 func (a *AlertIds) Scan(src interface{}) error {
@@ -90,6 +119,28 @@ type Recurrence struct {
 	Duration   Duration   `json:"duration"`
 	RepeatType RepeatType `json:"repeatType"`
 	RepeatOn   []RepeatOn `json:"repeatOn"`
+	// RRule is the RFC 5545 recurrence rule e.g FREQ=WEEKLY;BYDAY=SU, it
+	// replaces the repeat type and the start time is the first occurrence
+	RRule string `json:"rrule,omitempty"`
+	// ExDates are the starts of the occurrences of the rule that are skipped
+	ExDates []time.Time `json:"exDates,omitempty"`
+}
+
+// rruleActive reports whether an occurrence of the recurrence rule is in progress at the time
+func (r *Recurrence) rruleActive(now time.Time, loc *time.Location) (bool, error) {
+	rule, err := parseRRule(r.RRule, loc)
+	if err != nil {
+		return false, err
+	}
+
+	// the occurrences in progress started within the duration before the time
+	duration := time.Duration(r.Duration)
+	for _, occurrence := range rule.between(r.StartTime.In(loc), now.Add(-duration+time.Nanosecond), now.Add(time.Nanosecond)) {
+		if !slices.ContainsFunc(r.ExDates, occurrence.Equal) {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 func (r *Recurrence) Scan(src interface{}) error {
@@ -131,6 +182,8 @@ func (s Schedule) MarshalJSON() ([]byte, error) {
 			Duration:   s.Recurrence.Duration,
 			RepeatType: s.Recurrence.RepeatType,
 			RepeatOn:   s.Recurrence.RepeatOn,
+			RRule:      s.Recurrence.RRule,
+			ExDates:    s.Recurrence.ExDates,
 		}
 	}
 
@@ -205,6 +258,8 @@ func (s *Schedule) UnmarshalJSON(data []byte) error {
 			Duration:   aux.Recurrence.Duration,
 			RepeatType: aux.Recurrence.RepeatType,
 			RepeatOn:   aux.Recurrence.RepeatOn,
+			RRule:      aux.Recurrence.RRule,
+			ExDates:    aux.Recurrence.ExDates,
 		}
 	}
 	return nil
@@ -281,6 +336,14 @@ func (m *PlannedMaintenance) shouldSkip(ruleID string, now time.Time) bool {
 				return false
 			}
 
+			if m.Schedule.Recurrence.RRule != "" {
+				active, err := m.Schedule.Recurrence.rruleActive(currentTime, loc)
+				if err != nil {
+					zap.L().Error("Error evaluating rrule", zap.String("maintenance", m.Name), zap.String("rrule", m.Schedule.Recurrence.RRule), zap.Error(err))
+				}
+				return active
+			}
+
 			switch m.Schedule.Recurrence.RepeatType {
 			case RepeatTypeDaily:
 				// take the hours and minutes from the start time and add them to the current time
@@ -357,23 +420,62 @@ func (m *PlannedMaintenance) Validate() error {
 		return errors.New("invalid timezone")
 	}
 
+	if m.Schedule.Recurrence == nil && (m.Schedule.StartTime.IsZero() || m.Schedule.EndTime.IsZero()) {
+		return ErrMissingWindow
+	}
+
 	if !m.Schedule.StartTime.IsZero() && !m.Schedule.EndTime.IsZero() {
 		if m.Schedule.StartTime.After(m.Schedule.EndTime) {
 			return errors.New("start time cannot be after end time")
 		}
+		if !m.Schedule.EndTime.After(m.Schedule.StartTime) {
+			return errors.New("end time must be after start time")
+		}
+		if m.Schedule.EndTime.Sub(m.Schedule.StartTime) > maxMaintenanceDuration {
+			return ErrMaxDuration
+		}
 	}
 
 	if m.Schedule.Recurrence != nil {
-		if m.Schedule.Recurrence.RepeatType == "" {
+		if m.Schedule.Recurrence.RRule != "" {
+			if _, err := parseRRule(m.Schedule.Recurrence.RRule, time.UTC); err != nil {
+				return err
+			}
+		} else if m.Schedule.Recurrence.RepeatType == "" {
 			return ErrMissingRepeatType
 		}
-		if m.Schedule.Recurrence.Duration == 0 {
+		if m.Schedule.Recurrence.Duration <= 0 {
 			return ErrMissingDuration
 		}
+		if time.Duration(m.Schedule.Recurrence.Duration) > maxMaintenanceDuration {
+			return ErrMaxDuration
+		}
 		if m.Schedule.Recurrence.EndTime != nil && m.Schedule.Recurrence.EndTime.Before(m.Schedule.Recurrence.StartTime) {
 			return errors.New("end time cannot be before start time")
 		}
 	}
+	switch m.Mode {
+	case "", MaintenanceModeMute:
+	case MaintenanceModePause:
+		if m.isPartial() {
+			return errors.New("maintenance with matchers, severities or channels can only mute")
+		}
+	default:
+		return fmt.Errorf("invalid mode %s, must be pause or mute", m.Mode)
+	}
+	if m.ExpiresAt != nil {
+		if m.Schedule.Recurrence != nil || m.Schedule.EndTime.IsZero() {
+			return errors.New("only the fixed maintenance can expire")
+		}
+		if m.ExpiresAt.Before(m.Schedule.EndTime) {
+			return errors.New("expiry cannot be before end time")
+		}
+	}
+	for _, matcher := range m.Matchers {
+		if _, err := parseMaintenanceMatcher(matcher); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -389,30 +491,42 @@ func (m PlannedMaintenance) MarshalJSON() ([]byte, error) {
 	}
 	var kind string
 
-	if !m.Schedule.StartTime.IsZero() && !m.Schedule.EndTime.IsZero() && m.Schedule.EndTime.After(m.Schedule.StartTime) {
+	if m.ExpiresAt != nil {
+		kind = "quick"
+	} else if !m.Schedule.StartTime.IsZero() && !m.Schedule.EndTime.IsZero() && m.Schedule.EndTime.After(m.Schedule.StartTime) {
 		kind = "fixed"
 	} else {
 		kind = "recurring"
 	}
 
 	return json.Marshal(struct {
-		Id          int64     `json:"id" db:"id"`
-		Name        string    `json:"name" db:"name"`
-		Description string    `json:"description" db:"description"`
-		Schedule    *Schedule `json:"schedule" db:"schedule"`
-		AlertIds    *AlertIds `json:"alertIds" db:"alert_ids"`
-		CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-		CreatedBy   string    `json:"createdBy" db:"created_by"`
-		UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
-		UpdatedBy   string    `json:"updatedBy" db:"updated_by"`
-		Status      string    `json:"status"`
-		Kind        string    `json:"kind"`
+		Id          int64               `json:"id" db:"id"`
+		Name        string              `json:"name" db:"name"`
+		Description string              `json:"description" db:"description"`
+		Schedule    *Schedule           `json:"schedule" db:"schedule"`
+		AlertIds    *AlertIds           `json:"alertIds" db:"alert_ids"`
+		Matchers    MaintenanceMatchers `json:"matchers,omitempty" db:"matchers"`
+		Severities  MaintenanceList     `json:"severities,omitempty" db:"severities"`
+		Channels    MaintenanceList     `json:"channels,omitempty" db:"channels"`
+		Mode        MaintenanceMode     `json:"mode,omitempty" db:"mode"`
+		ExpiresAt   *time.Time          `json:"expiresAt,omitempty" db:"expires_at"`
+		CreatedAt   time.Time           `json:"createdAt" db:"created_at"`
+		CreatedBy   string              `json:"createdBy" db:"created_by"`
+		UpdatedAt   time.Time           `json:"updatedAt" db:"updated_at"`
+		UpdatedBy   string              `json:"updatedBy" db:"updated_by"`
+		Status      string              `json:"status"`
+		Kind        string              `json:"kind"`
 	}{
 		Id:          m.Id,
 		Name:        m.Name,
 		Description: m.Description,
 		Schedule:    m.Schedule,
 		AlertIds:    m.AlertIds,
+		Matchers:    m.Matchers,
+		Severities:  m.Severities,
+		Channels:    m.Channels,
+		Mode:        m.Mode,
+		ExpiresAt:   m.ExpiresAt,
 		CreatedAt:   m.CreatedAt,
 		CreatedBy:   m.CreatedBy,
 		UpdatedAt:   m.UpdatedAt,
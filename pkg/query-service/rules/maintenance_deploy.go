@@ -0,0 +1,95 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultDeployMaintenanceDuration is how long the deploy mutes the alerts when it is not finished
+const defaultDeployMaintenanceDuration = 15 * time.Minute
+
+var (
+	ErrMissingDeployMatchers = errors.New("deploy must have a service or matchers")
+	ErrNotQuickMaintenance   = errors.New("maintenance is not a deploy or quick maintenance")
+	ErrInvalidDeployDuration = errors.New("duration must be positive and at most 7 days")
+)
+
+// DeployMaintenance is the deploy started by the CI, it mutes the alerts
+// matching the matchers until it is finished or the duration has passed
+type DeployMaintenance struct {
+	// Service adds the matcher of the deployed service, it matches the service.name
+	// label of the traces and logs alerts or the service_name label of the metrics alerts
+	Service  string              `json:"service"`
+	Version  string              `json:"version"`
+	Matchers MaintenanceMatchers `json:"matchers"`
+	// Duration is the closure of the maintenance if the deploy is not finished, 15m by default
+	Duration Duration `json:"duration"`
+}
+
+func (d *DeployMaintenance) quickMaintenance() (QuickMaintenance, error) {
+	matchers := MaintenanceMatchers{}
+	if d.Service != "" {
+		matchers = append(matchers, "service.name|service_name="+d.Service)
+	}
+	matchers = append(matchers, d.Matchers...)
+	if len(matchers) == 0 {
+		return QuickMaintenance{}, ErrMissingDeployMatchers
+	}
+
+	ttl := d.Duration
+	if ttl == 0 {
+		ttl = Duration(defaultDeployMaintenanceDuration)
+	}
+
+	name := "Deploy of " + strings.Join(matchers, ", ")
+	if d.Service != "" {
+		name = "Deploy of " + d.Service
+	}
+	if d.Version != "" {
+		name = fmt.Sprintf("%s %s", name, d.Version)
+	}
+
+	quick := QuickMaintenance{
+		Name:        name,
+		Description: "Opened by the deploy webhook",
+		TTL:         ttl,
+		Matchers:    matchers,
+	}
+	if err := quick.Validate(); err != nil {
+		if errors.Is(err, ErrInvalidQuickMaintenanceTTL) {
+			return QuickMaintenance{}, ErrInvalidDeployDuration
+		}
+		return QuickMaintenance{}, err
+	}
+	return quick, nil
+}
+
+func (d *DeployMaintenance) Validate() error {
+	_, err := d.quickMaintenance()
+	return err
+}
+
+// StartDeployMaintenance opens the maintenance muting the alerts of the deploy
+func (m *Manager) StartDeployMaintenance(ctx context.Context, deploy DeployMaintenance) (*PlannedMaintenance, error) {
+	quick, err := deploy.quickMaintenance()
+	if err != nil {
+		return nil, err
+	}
+	return m.CreateQuickMaintenance(ctx, quick)
+}
+
+// FinishDeployMaintenance closes the maintenance of the deploy before its duration has passed
+func (m *Manager) FinishDeployMaintenance(ctx context.Context, id string) error {
+	maintenance, err := m.ruleDB.GetPlannedMaintenanceByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if maintenance.ExpiresAt == nil {
+		return ErrNotQuickMaintenance
+	}
+	_, err = m.ruleDB.DeletePlannedMaintenance(ctx, id)
+	return err
+}
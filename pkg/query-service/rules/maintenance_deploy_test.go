@@ -0,0 +1,44 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestDeployMaintenance(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	_, err := m.StartDeployMaintenance(ctx, DeployMaintenance{Version: "v1.2"})
+	assert.ErrorIs(t, err, ErrMissingDeployMatchers)
+	_, err = m.StartDeployMaintenance(ctx, DeployMaintenance{Service: "checkout", Duration: Duration(-time.Minute)})
+	assert.ErrorIs(t, err, ErrInvalidDeployDuration)
+
+	maintenance, err := m.StartDeployMaintenance(ctx, DeployMaintenance{Service: "checkout", Version: "v1.2", Matchers: MaintenanceMatchers{"env=staging"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Deploy of checkout v1.2", maintenance.Name)
+	assert.Equal(t, MaintenanceMatchers{"service.name|service_name=checkout", "env=staging"}, maintenance.Matchers)
+	assert.WithinDuration(t, time.Now().Add(defaultDeployMaintenanceDuration), *maintenance.ExpiresAt, time.Minute)
+	assert.True(t, maintenance.mutes(labels.Labels{{Name: "service.name", Value: "checkout"}, {Name: "env", Value: "staging"}}))
+	assert.False(t, maintenance.mutes(labels.Labels{{Name: "service.name", Value: "cart"}, {Name: "env", Value: "staging"}}))
+	// the metrics alerts have the service_name label
+	assert.True(t, maintenance.mutes(labels.Labels{{Name: "service_name", Value: "checkout"}, {Name: "env", Value: "staging"}}))
+	assert.False(t, maintenance.mutes(labels.Labels{{Name: "service_name", Value: "cart"}, {Name: "env", Value: "staging"}}))
+
+	id := strconv.FormatInt(maintenance.Id, 10)
+	assert.NoError(t, m.FinishDeployMaintenance(ctx, id))
+	_, err = m.ruleDB.GetPlannedMaintenanceByID(ctx, id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, m.FinishDeployMaintenance(ctx, id), sql.ErrNoRows)
+
+	// the planned maintenance is not closed by the deploy
+	plannedID, err := m.ruleDB.CreatePlannedMaintenance(ctx, PlannedMaintenance{Name: "planned", Schedule: &Schedule{Timezone: "UTC", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, m.FinishDeployMaintenance(ctx, strconv.FormatInt(plannedID, 10)), ErrNotQuickMaintenance)
+}
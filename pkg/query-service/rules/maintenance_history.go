@@ -0,0 +1,337 @@
+package rules
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// MaintenanceSuppression is the alert suppressed by the maintenance from the start to
+// the end, the fingerprint is empty when the rule was skipped without active alerts
+type MaintenanceSuppression struct {
+	Id              int64            `json:"id" db:"id"`
+	MaintenanceId   int64            `json:"maintenanceId" db:"maintenance_id"`
+	MaintenanceName string           `json:"maintenanceName" db:"maintenance_name"`
+	RuleId          string           `json:"ruleId" db:"rule_id"`
+	Fingerprint     string           `json:"fingerprint" db:"fingerprint"`
+	Labels          SuppressedLabels `json:"labels" db:"labels"`
+	StartedAt       time.Time        `json:"startedAt" db:"started_at"`
+	EndedAt         time.Time        `json:"endedAt" db:"ended_at"`
+	Duration        Duration         `json:"duration" db:"-"`
+	OrgID           string           `json:"-" db:"org_id"`
+}
+
+// SuppressedLabels are the labels of the suppressed alert
+type SuppressedLabels map[string]string
+
+func (l *SuppressedLabels) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, l)
+	case string:
+		return json.Unmarshal([]byte(data), l)
+	}
+	return nil
+}
+
+func (l SuppressedLabels) Value() (driver.Value, error) {
+	if l == nil {
+		l = SuppressedLabels{}
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// MaintenanceSuppressionFilter selects the suppressions, the empty fields match all the suppressions
+type MaintenanceSuppressionFilter struct {
+	MaintenanceId string
+	RuleId        string
+	// Start and End select the suppressions overlapping the range
+	Start time.Time
+	End   time.Time
+	// Limit is the max number of suppressions returned, latest first
+	Limit int
+}
+
+const defaultMaintenanceSuppressionLimit = 100
+
+// MaintenanceHistory is who changed the maintenance and the alerts it suppressed
+type MaintenanceHistory struct {
+	Changes      []AuditLog               `json:"changes"`
+	Suppressions []MaintenanceSuppression `json:"suppressions"`
+}
+
+// RecordMaintenanceSuppressions extends the suppressions of the alerts to the time, the
+// suppression ended before the gap is not extended and a new one is started instead
+func (r *ruleDB) RecordMaintenanceSuppressions(ctx context.Context, suppressions []MaintenanceSuppression, ts time.Time, gap time.Duration) error {
+	if len(suppressions) == 0 {
+		return nil
+	}
+
+	tx, err := r.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ts = ts.UTC()
+	for _, s := range suppressions {
+		result, err := tx.Exec(`UPDATE maintenance_suppressions SET ended_at=$1 WHERE maintenance_id=$2 AND rule_id=$3 AND fingerprint=$4 AND ended_at>=$5;`,
+			ts, s.MaintenanceId, s.RuleId, s.Fingerprint, ts.Add(-gap))
+		if err != nil {
+			zap.L().Error("Error in Executing UPDATE to maintenance_suppressions", zap.Error(err))
+			return err
+		}
+		if count, err := result.RowsAffected(); err != nil || count > 0 {
+			continue
+		}
+
+		s.StartedAt, s.EndedAt = ts, ts
+		if _, err := tx.NamedExec(`INSERT INTO maintenance_suppressions (maintenance_id, maintenance_name, rule_id, fingerprint, labels, started_at, ended_at, org_id)
+			VALUES (:maintenance_id, :maintenance_name, :rule_id, :fingerprint, :labels, :started_at, :ended_at, :org_id)`, s); err != nil {
+			zap.L().Error("Error in Executing INSERT to maintenance_suppressions", zap.Error(err))
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *ruleDB) GetMaintenanceSuppressions(ctx context.Context, filter *MaintenanceSuppressionFilter) ([]MaintenanceSuppression, error) {
+	if filter == nil {
+		filter = &MaintenanceSuppressionFilter{}
+	}
+
+	q := newSelectQuery("SELECT id, maintenance_id, maintenance_name, rule_id, fingerprint, labels, started_at, ended_at, COALESCE(org_id, '') AS org_id FROM maintenance_suppressions")
+	if filter.MaintenanceId != "" {
+		q.where("maintenance_id=?", filter.MaintenanceId)
+	}
+	if filter.RuleId != "" {
+		q.where("rule_id=?", filter.RuleId)
+	}
+	if !filter.Start.IsZero() {
+		q.where("ended_at>=?", filter.Start.UTC())
+	}
+	if !filter.End.IsZero() {
+		q.where("started_at<=?", filter.End.UTC())
+	}
+	whereOrg(ctx, q, "maintenance_suppressions")
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultMaintenanceSuppressionLimit
+	}
+	query, args := q.order("started_at DESC, id DESC").page(limit, 0).build()
+
+	suppressions := []MaintenanceSuppression{}
+	if err := r.Select(&suppressions, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	for i := range suppressions {
+		suppressions[i].Duration = Duration(suppressions[i].EndedAt.Sub(suppressions[i].StartedAt))
+	}
+
+	return suppressions, nil
+}
+
+// GetMaintenanceHistory returns the changes made to the maintenance and the alerts
+// it suppressed, the history is kept after the maintenance is deleted
+func (m *Manager) GetMaintenanceHistory(ctx context.Context, id string, filter *MaintenanceSuppressionFilter) (*MaintenanceHistory, error) {
+	if filter == nil {
+		filter = &MaintenanceSuppressionFilter{}
+	}
+	filter.MaintenanceId = id
+
+	changes, err := m.ruleDB.GetAuditLogs(ctx, &AuditLogFilter{ResourceType: AuditResourceMaintenance, ResourceId: id})
+	if err != nil {
+		return nil, err
+	}
+	suppressions, err := m.ruleDB.GetMaintenanceSuppressions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &MaintenanceHistory{Changes: changes, Suppressions: suppressions}, nil
+}
+
+// suppressionFlushInterval is how often the end of the ongoing suppression is written
+const suppressionFlushInterval = 5 * time.Minute
+
+type suppressionKey struct {
+	maintenanceId int64
+	fingerprint   string
+}
+
+type ongoingSuppression struct {
+	suppression MaintenanceSuppression
+	// lastSeen is the last evaluation the alert was suppressed at
+	lastSeen  time.Time
+	flushedAt time.Time
+}
+
+// suppressionTracker keeps the ongoing suppressions of the rules, they are written
+// when they start and end, and their end every suppressionFlushInterval in between
+type suppressionTracker struct {
+	mtx   sync.Mutex
+	rules map[string]map[suppressionKey]*ongoingSuppression
+}
+
+func newSuppressionTracker() *suppressionTracker {
+	return &suppressionTracker{rules: map[string]map[suppressionKey]*ongoingSuppression{}}
+}
+
+// update applies the suppressions of the evaluation of the rule at ts, it returns the
+// suppressions started or extended at ts and the ended ones by the time they ended
+func (t *suppressionTracker) update(ruleId string, ts time.Time, suppressed map[suppressionKey]MaintenanceSuppression) ([]MaintenanceSuppression, map[time.Time][]MaintenanceSuppression) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	ongoing, ok := t.rules[ruleId]
+	if !ok {
+		ongoing = map[suppressionKey]*ongoingSuppression{}
+		t.rules[ruleId] = ongoing
+	}
+
+	updated := []MaintenanceSuppression{}
+	for key, suppression := range suppressed {
+		o, ok := ongoing[key]
+		if !ok {
+			ongoing[key] = &ongoingSuppression{suppression: suppression, lastSeen: ts, flushedAt: ts}
+			updated = append(updated, suppression)
+			continue
+		}
+		o.lastSeen = ts
+		if ts.Sub(o.flushedAt) >= suppressionFlushInterval {
+			o.flushedAt = ts
+			updated = append(updated, o.suppression)
+		}
+	}
+
+	ended := map[time.Time][]MaintenanceSuppression{}
+	for key, o := range ongoing {
+		if _, ok := suppressed[key]; !ok {
+			ended[o.lastSeen] = append(ended[o.lastSeen], o.suppression)
+			delete(ongoing, key)
+		}
+	}
+	if len(ongoing) == 0 {
+		delete(t.rules, ruleId)
+	}
+	return updated, ended
+}
+
+// forget drops the ongoing suppressions of the rule no longer evaluated
+func (t *suppressionTracker) forget(ruleId string) {
+	if t == nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	delete(t.rules, ruleId)
+}
+
+// suppressionRecorder collects the alerts of the rule suppressed by the maintenance in an
+// evaluation, the suppressions are recorded by their state rather than the notifications
+// so that they last as long as the alerts are suppressed, whatever the notify policy
+type suppressionRecorder struct {
+	ruleDB  RuleDB
+	tracker *suppressionTracker
+	rule    Rule
+	ts      time.Time
+	// gap is the longest break between the evaluations of the same suppression
+	gap time.Duration
+
+	suppressed map[suppressionKey]MaintenanceSuppression
+	channels   []string
+}
+
+func newSuppressionRecorder(ruleDB RuleDB, tracker *suppressionTracker, rule Rule, ts time.Time, frequency time.Duration) *suppressionRecorder {
+	return &suppressionRecorder{
+		ruleDB:     ruleDB,
+		tracker:    tracker,
+		rule:       rule,
+		ts:         ts,
+		gap:        2 * frequency,
+		suppressed: map[suppressionKey]MaintenanceSuppression{},
+	}
+}
+
+// record collects the suppression of the alerts, the rule skipped without
+// active alerts is recorded without a fingerprint
+func (s *suppressionRecorder) record(maintenance *PlannedMaintenance, alerts []*Alert) {
+	if s == nil {
+		return
+	}
+
+	newSuppression := func() MaintenanceSuppression {
+		return MaintenanceSuppression{
+			MaintenanceId:   maintenance.Id,
+			MaintenanceName: maintenance.Name,
+			RuleId:          s.rule.ID(),
+			Labels:          SuppressedLabels{},
+			OrgID:           s.rule.OrgID(),
+		}
+	}
+	for _, alert := range alerts {
+		suppression := newSuppression()
+		if alert.Labels != nil {
+			suppression.Fingerprint = fmt.Sprintf("%d", alert.Labels.Hash())
+			suppression.Labels = alert.Labels.Map()
+		}
+		s.suppressed[suppressionKey{maintenance.Id, suppression.Fingerprint}] = suppression
+	}
+	if len(alerts) == 0 {
+		s.suppressed[suppressionKey{maintenance.Id, ""}] = newSuppression()
+	}
+}
+
+// recordMuted collects the firing alerts of the rule muted by the maintenance
+func (s *suppressionRecorder) recordMuted(maintenance []PlannedMaintenance, alerts []*Alert) {
+	if s == nil || len(maintenance) == 0 {
+		return
+	}
+	muted := make([][]*Alert, len(maintenance))
+	for _, alert := range alerts {
+		if alert.State != model.StateFiring {
+			continue
+		}
+		_, muting := muteAlert(alert, maintenance, s.channelNames)
+		for _, i := range muting {
+			muted[i] = append(muted[i], alert)
+		}
+	}
+	for i := range maintenance {
+		if len(muted[i]) > 0 {
+			s.record(&maintenance[i], muted[i])
+		}
+	}
+}
+
+// finish writes the suppressions started, extended and ended by the evaluation, the
+// evaluations of the ongoing suppressions don't write them until they are flushed
+func (s *suppressionRecorder) finish(ctx context.Context) {
+	if s == nil || s.ruleDB == nil || s.tracker == nil {
+		return
+	}
+
+	// the end of the ongoing suppression written is up to a flush interval behind
+	gap := s.gap + suppressionFlushInterval
+	updated, ended := s.tracker.update(s.rule.ID(), s.ts, s.suppressed)
+	if err := s.ruleDB.RecordMaintenanceSuppressions(ctx, updated, s.ts, gap); err != nil {
+		zap.L().Error("failed to record the maintenance suppressions", zap.String("rule", s.rule.ID()), zap.Error(err))
+	}
+	for lastSeen, suppressions := range ended {
+		if err := s.ruleDB.RecordMaintenanceSuppressions(ctx, suppressions, lastSeen, gap); err != nil {
+			zap.L().Error("failed to record the end of the maintenance suppressions", zap.String("rule", s.rule.ID()), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package rules
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestMaintenanceHistory(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	maintenance := PlannedMaintenance{
+		Name:     "staging",
+		Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)},
+		Matchers: MaintenanceMatchers{"env=staging"},
+	}
+	id, err := m.ruleDB.CreatePlannedMaintenance(ctx, maintenance)
+	assert.NoError(t, err)
+	maintenance.Id = id
+
+	rule := &ThresholdRule{BaseRule: &BaseRule{id: "1"}}
+	staging := &Alert{State: model.StateFiring, Labels: labels.Labels{{Name: "env", Value: "staging"}}}
+	prod := &Alert{State: model.StateFiring, Labels: labels.Labels{{Name: "env", Value: "prod"}}}
+	tracker := newSuppressionTracker()
+	evaluate := func(rule Rule, ts time.Time, alerts ...*Alert) {
+		recorder := newSuppressionRecorder(m.ruleDB, tracker, rule, ts, time.Minute)
+		recorder.recordMuted([]PlannedMaintenance{maintenance}, alerts)
+		recorder.finish(ctx)
+	}
+
+	// the suppression is extended by the evaluations while the alert is muted, though
+	// it isn't notified again, and ends at the last evaluation it was muted at
+	for i := 0; i < 3; i++ {
+		evaluate(rule, now.Add(time.Duration(i)*time.Minute), staging, prod)
+	}
+	evaluate(rule, now.Add(3*time.Minute), prod)
+	// the alert muted again starts a new suppression
+	evaluate(rule, now.Add(10*time.Minute), staging)
+
+	history, err := m.GetMaintenanceHistory(ctx, strconv.FormatInt(id, 10), nil)
+	assert.NoError(t, err)
+	assert.Len(t, history.Changes, 1)
+	assert.Equal(t, AuditActionCreate, history.Changes[0].Action)
+	assert.Len(t, history.Suppressions, 2)
+
+	latest, first := history.Suppressions[0], history.Suppressions[1]
+	assert.Equal(t, "staging", first.MaintenanceName)
+	assert.Equal(t, "1", first.RuleId)
+	assert.Equal(t, strconv.FormatUint(staging.Labels.Hash(), 10), first.Fingerprint)
+	assert.Equal(t, SuppressedLabels{"env": "staging"}, first.Labels)
+	assert.Equal(t, now, first.StartedAt.UTC())
+	assert.Equal(t, Duration(2*time.Minute), first.Duration)
+	assert.Equal(t, now.Add(10*time.Minute), latest.StartedAt.UTC())
+	assert.Equal(t, Duration(0), latest.Duration)
+
+	// the skipped rule without active alerts is recorded without a fingerprint
+	skipped := newSuppressionRecorder(m.ruleDB, tracker, &ThresholdRule{BaseRule: &BaseRule{id: "2"}}, now, time.Minute)
+	skipped.record(&maintenance, nil)
+	skipped.finish(ctx)
+	suppressions, err := m.ruleDB.GetMaintenanceSuppressions(ctx, &MaintenanceSuppressionFilter{RuleId: "2"})
+	assert.NoError(t, err)
+	assert.Len(t, suppressions, 1)
+	assert.Empty(t, suppressions[0].Fingerprint)
+
+	suppressions, err = m.ruleDB.GetMaintenanceSuppressions(ctx, &MaintenanceSuppressionFilter{RuleId: "1", Start: now.Add(5 * time.Minute)})
+	assert.NoError(t, err)
+	assert.Len(t, suppressions, 1)
+	assert.Equal(t, now.Add(10*time.Minute), suppressions[0].StartedAt.UTC())
+}
+
+func TestSuppressionTracker(t *testing.T) {
+	tracker := newSuppressionTracker()
+	now := time.Now()
+	key := suppressionKey{maintenanceId: 1, fingerprint: "1"}
+	suppressed := map[suppressionKey]MaintenanceSuppression{key: {MaintenanceId: 1, RuleId: "1", Fingerprint: "1"}}
+
+	// the ongoing suppression is only written when it starts, every flush interval and when it ends
+	updated, ended := tracker.update("1", now, suppressed)
+	assert.Len(t, updated, 1)
+	assert.Empty(t, ended)
+	updated, _ = tracker.update("1", now.Add(time.Minute), suppressed)
+	assert.Empty(t, updated)
+	updated, _ = tracker.update("1", now.Add(suppressionFlushInterval), suppressed)
+	assert.Len(t, updated, 1)
+	updated, ended = tracker.update("1", now.Add(suppressionFlushInterval+time.Minute), nil)
+	assert.Empty(t, updated)
+	assert.Len(t, ended[now.Add(suppressionFlushInterval)], 1)
+	assert.Empty(t, tracker.rules)
+}
@@ -0,0 +1,208 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// maintenanceCalendarPast and maintenanceCalendarAhead bound the windows in the calendar
+	maintenanceCalendarPast  = 7 * 24 * time.Hour
+	maintenanceCalendarAhead = 90 * 24 * time.Hour
+
+	icalTimeFormat = "20060102T150405Z"
+	// icalLineLength is the max octets of a line before it is folded
+	icalLineLength = 75
+)
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+var ErrInvalidCalendarToken = errors.New("invalid maintenance calendar token")
+
+// MaintenanceCalendarToken is the secret of the url the calendar clients subscribe to,
+// they can't send the credentials of the user. The token gives access to the calendar
+// of the org until it is rotated or revoked.
+type MaintenanceCalendarToken struct {
+	OrgID     string    `json:"-" db:"org_id"`
+	Token     string    `json:"token" db:"token"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy string    `json:"createdBy" db:"created_by"`
+}
+
+func (r *ruleDB) SetMaintenanceCalendarToken(ctx context.Context, token MaintenanceCalendarToken) error {
+	token.CreatedAt = token.CreatedAt.UTC()
+	_, err := r.NamedExec(`INSERT OR REPLACE INTO maintenance_calendar_tokens (org_id, token, created_at, created_by)
+		VALUES (:org_id, :token, :created_at, :created_by)`, token)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to maintenance_calendar_tokens", zap.Error(err))
+	}
+	return err
+}
+
+func (r *ruleDB) GetMaintenanceCalendarToken(ctx context.Context) (*MaintenanceCalendarToken, error) {
+	token := &MaintenanceCalendarToken{}
+	err := r.Get(token, "SELECT org_id, token, created_at, created_by FROM maintenance_calendar_tokens WHERE org_id=$1", contextOrgID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (r *ruleDB) GetMaintenanceCalendarTokenOrg(ctx context.Context, token string) (string, error) {
+	var orgID string
+	if err := r.Get(&orgID, "SELECT org_id FROM maintenance_calendar_tokens WHERE token=$1", token); err != nil {
+		return "", err
+	}
+	return orgID, nil
+}
+
+func (r *ruleDB) DeleteMaintenanceCalendarToken(ctx context.Context) error {
+	_, err := r.Exec("DELETE FROM maintenance_calendar_tokens WHERE org_id=$1", contextOrgID(ctx))
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE from maintenance_calendar_tokens", zap.Error(err))
+	}
+	return err
+}
+
+// RotateMaintenanceCalendarToken creates the calendar token of the org of the user,
+// the url of the previous token stops working
+func (m *Manager) RotateMaintenanceCalendarToken(ctx context.Context) (*MaintenanceCalendarToken, error) {
+	secret, err := utils.RandomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	token := MaintenanceCalendarToken{
+		OrgID:     contextOrgID(ctx),
+		Token:     secret,
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: auditActor(ctx),
+	}
+	if err := m.ruleDB.SetMaintenanceCalendarToken(ctx, token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeMaintenanceCalendarToken stops serving the calendar of the org of the user by its token
+func (m *Manager) RevokeMaintenanceCalendarToken(ctx context.Context) error {
+	return m.ruleDB.DeleteMaintenanceCalendarToken(ctx)
+}
+
+// MaintenanceCalendar returns the windows of the maintenance of the org of the token from
+// a week ago to three months ahead as an iCalendar (RFC 5545) feed
+func (m *Manager) MaintenanceCalendar(ctx context.Context, token string) (string, error) {
+	if token == "" {
+		return "", ErrInvalidCalendarToken
+	}
+	orgID, err := m.ruleDB.GetMaintenanceCalendarTokenOrg(ctx, token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrInvalidCalendarToken
+	}
+	if err != nil {
+		return "", err
+	}
+	// the request has no user, the maintenance of all the orgs is fetched
+	all, err := m.ruleDB.GetAllPlannedMaintenance(ctx)
+	if err != nil {
+		return "", err
+	}
+	maintenances := make([]PlannedMaintenance, 0, len(all))
+	for _, maintenance := range all {
+		if maintenance.matchesOrg(orgID) {
+			maintenances = append(maintenances, maintenance)
+		}
+	}
+	return maintenanceCalendar(maintenances, time.Now()), nil
+}
+
+func maintenanceCalendar(maintenances []PlannedMaintenance, now time.Time) string {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//SigNoz//Planned Maintenance//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:SigNoz planned maintenance",
+	}
+
+	for i := range maintenances {
+		m := &maintenances[i]
+		for _, window := range m.windows(now.Add(-maintenanceCalendarPast), now.Add(maintenanceCalendarAhead)) {
+			lines = append(lines,
+				"BEGIN:VEVENT",
+				fmt.Sprintf("UID:maintenance-%d-%d@signoz", m.Id, window.start.Unix()),
+				"DTSTAMP:"+m.UpdatedAt.UTC().Format(icalTimeFormat),
+				"DTSTART:"+window.start.UTC().Format(icalTimeFormat),
+				"DTEND:"+window.end.UTC().Format(icalTimeFormat),
+				"SUMMARY:"+icalEscaper.Replace("Maintenance: "+m.Name),
+				"DESCRIPTION:"+icalEscaper.Replace(m.calendarDescription()),
+				"TRANSP:TRANSPARENT",
+				"END:VEVENT",
+			)
+		}
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldICalLine(line))
+		b.WriteString("\r\n")
+	}
+	return b.String()
+}
+
+// calendarDescription describes what the maintenance mutes
+func (m *PlannedMaintenance) calendarDescription() string {
+	var parts []string
+	if m.Description != "" {
+		parts = append(parts, m.Description)
+	}
+	if m.pauses() {
+		parts = append(parts, "The rules are not evaluated.")
+	} else {
+		parts = append(parts, "The notifications are muted.")
+	}
+	if m.AlertIds != nil && len(*m.AlertIds) > 0 {
+		parts = append(parts, "Rules: "+strings.Join(*m.AlertIds, ", "))
+	} else {
+		parts = append(parts, "Rules: all")
+	}
+	if len(m.Matchers) > 0 {
+		parts = append(parts, "Matchers: "+strings.Join(m.Matchers, ", "))
+	}
+	if len(m.Severities) > 0 {
+		parts = append(parts, "Severities: "+strings.Join(m.Severities, ", "))
+	}
+	if len(m.Channels) > 0 {
+		parts = append(parts, "Channels: "+strings.Join(m.Channels, ", "))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// foldICalLine splits the line longer than 75 octets into continuation
+// lines starting with a space, without splitting the utf-8 characters
+func foldICalLine(line string) string {
+	if len(line) <= icalLineLength {
+		return line
+	}
+	var b strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > icalLineLength {
+			b.WriteString("\r\n ")
+			// the leading space of the continuation counts towards its length
+			length = 1
+		}
+		b.WriteRune(r)
+		length += size
+	}
+	return b.String()
+}
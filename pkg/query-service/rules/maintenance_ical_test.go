@@ -0,0 +1,105 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestMaintenanceCalendar(t *testing.T) {
+	now := time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+	maintenances := []PlannedMaintenance{
+		{
+			Id:          1,
+			Name:        "Database migration",
+			Description: "Moving to the new cluster; expect gaps, retries",
+			Schedule:    &Schedule{Timezone: "UTC", StartTime: now.Add(24 * time.Hour), EndTime: now.Add(26 * time.Hour)},
+			AlertIds:    &AlertIds{"1", "2"},
+			UpdatedAt:   now,
+		},
+		{
+			Id:        2,
+			Name:      "Weekly deploy",
+			Schedule:  &Schedule{Timezone: "UTC", Recurrence: &Recurrence{StartTime: now.Add(-30 * 24 * time.Hour), Duration: Duration(time.Hour), RRule: "FREQ=WEEKLY;COUNT=6"}},
+			Matchers:  MaintenanceMatchers{"env=staging"},
+			UpdatedAt: now,
+		},
+		{
+			Id:        3,
+			Name:      "Expired",
+			Schedule:  &Schedule{Timezone: "UTC", StartTime: now.Add(-30 * 24 * time.Hour), EndTime: now.Add(-29 * 24 * time.Hour)},
+			UpdatedAt: now,
+		},
+	}
+
+	calendar := maintenanceCalendar(maintenances, now)
+	assert.True(t, strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(calendar, "END:VCALENDAR\r\n"))
+	// the weekly deploy started four weeks ago has its last two of the six occurrences in the range
+	assert.Equal(t, 3, strings.Count(calendar, "BEGIN:VEVENT"))
+	assert.NotContains(t, calendar, "Expired")
+
+	assert.Contains(t, calendar, "UID:maintenance-1-1715083200@signoz\r\n")
+	assert.Contains(t, calendar, "DTSTART:20240507T120000Z\r\nDTEND:20240507T140000Z\r\n")
+	assert.Contains(t, calendar, "SUMMARY:Maintenance: Weekly deploy\r\n")
+
+	unfolded := strings.ReplaceAll(calendar, "\r\n ", "")
+	assert.Contains(t, unfolded, `DESCRIPTION:Moving to the new cluster\; expect gaps\, retries\nThe rules are not evaluated.\nRules: 1\, 2`)
+	assert.Contains(t, unfolded, `DESCRIPTION:The notifications are muted.\nRules: all\nMatchers: env=staging`)
+	for _, line := range strings.Split(calendar, "\r\n") {
+		assert.LessOrEqual(t, len(line), icalLineLength, line)
+	}
+}
+
+func TestMaintenanceCalendarToken(t *testing.T) {
+	m := newTestManager(t)
+	userCtx := func(email, orgId string) context.Context {
+		user := &model.UserPayload{User: model.User{Email: email, OrgId: orgId}}
+		return context.WithValue(context.Background(), constants.ContextUserKey, user)
+	}
+	acme, globex := userCtx("admin@acme.io", "acme"), userCtx("admin@globex.io", "globex")
+
+	schedule := func() *Schedule {
+		return &Schedule{Timezone: "UTC", StartTime: time.Now().Add(time.Hour), EndTime: time.Now().Add(2 * time.Hour)}
+	}
+	_, err := m.ruleDB.CreatePlannedMaintenance(acme, PlannedMaintenance{Name: "Acme upgrade", Schedule: schedule()})
+	require.NoError(t, err)
+	_, err = m.ruleDB.CreatePlannedMaintenance(globex, PlannedMaintenance{Name: "Globex upgrade", Schedule: schedule()})
+	require.NoError(t, err)
+
+	_, err = m.ruleDB.GetMaintenanceCalendarToken(acme)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = m.MaintenanceCalendar(context.Background(), "")
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+
+	// the calendar of the token only has the maintenance of its org
+	token, err := m.RotateMaintenanceCalendarToken(acme)
+	require.NoError(t, err)
+	assert.Len(t, token.Token, 64)
+	assert.Equal(t, "admin@acme.io", token.CreatedBy)
+	calendar, err := m.MaintenanceCalendar(context.Background(), token.Token)
+	require.NoError(t, err)
+	assert.Contains(t, calendar, "Acme upgrade")
+	assert.NotContains(t, calendar, "Globex upgrade")
+
+	// the rotated token replaces the previous one
+	rotated, err := m.RotateMaintenanceCalendarToken(acme)
+	require.NoError(t, err)
+	_, err = m.MaintenanceCalendar(context.Background(), token.Token)
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+	stored, err := m.ruleDB.GetMaintenanceCalendarToken(acme)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.Token, stored.Token)
+
+	// the revoked token no longer serves the calendar
+	require.NoError(t, m.RevokeMaintenanceCalendarToken(acme))
+	_, err = m.MaintenanceCalendar(context.Background(), rotated.Token)
+	assert.ErrorIs(t, err, ErrInvalidCalendarToken)
+}
@@ -0,0 +1,193 @@
+package rules
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+// MaintenanceMatchers select the alerts muted by the maintenance by their labels
+// e.g service=checkout, env!=prod, pod=~checkout-.* or region!~us-.*, the alert
+// is muted when all the matchers match. The matcher of several label names separated
+// by | matches when any of the labels matches, e.g service.name|service_name=checkout
+// matches the service of the traces and of the metrics, and the negative matcher when
+// none of the labels has the value. The maintenance with matchers mutes the
+// notifications of the matching alerts instead of skipping the rules, so that
+// it applies to the alerts of the rules created after the maintenance.
+type MaintenanceMatchers []string
+
+func (m *MaintenanceMatchers) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, m)
+	case string:
+		return json.Unmarshal([]byte(data), m)
+	}
+	return nil
+}
+
+func (m MaintenanceMatchers) Value() (driver.Value, error) {
+	if m == nil {
+		m = MaintenanceMatchers{}
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+type maintenanceMatchOp string
+
+const (
+	maintenanceMatchEqual    maintenanceMatchOp = "="
+	maintenanceMatchNotEqual maintenanceMatchOp = "!="
+	maintenanceMatchRegex    maintenanceMatchOp = "=~"
+	maintenanceMatchNotRegex maintenanceMatchOp = "!~"
+)
+
+type maintenanceMatcher struct {
+	names []string
+	op    maintenanceMatchOp
+	value string
+	re    *regexp.Regexp
+}
+
+// parseMaintenanceMatcher parses the matcher, the regex matches the whole label value
+func parseMaintenanceMatcher(matcher string) (*maintenanceMatcher, error) {
+	i := strings.IndexAny(matcher, "=!")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid matcher %s, must be name=value, name!=value, name=~regex or name!~regex", matcher)
+	}
+
+	m := &maintenanceMatcher{}
+	for _, name := range strings.Split(matcher[:i], "|") {
+		if name = strings.TrimSpace(name); name == "" {
+			return nil, fmt.Errorf("invalid matcher %s, must be name=value, name!=value, name=~regex or name!~regex", matcher)
+		}
+		m.names = append(m.names, name)
+	}
+	rest := matcher[i:]
+	for _, op := range []maintenanceMatchOp{maintenanceMatchRegex, maintenanceMatchNotRegex, maintenanceMatchNotEqual, maintenanceMatchEqual} {
+		if strings.HasPrefix(rest, string(op)) {
+			m.op = op
+			m.value = strings.TrimSpace(strings.TrimPrefix(rest, string(op)))
+			break
+		}
+	}
+	if m.op == "" {
+		return nil, fmt.Errorf("invalid matcher %s, must be name=value, name!=value, name=~regex or name!~regex", matcher)
+	}
+
+	if m.op == maintenanceMatchRegex || m.op == maintenanceMatchNotRegex {
+		re, err := regexp.Compile("^(?:" + m.value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in matcher %s: %w", matcher, err)
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// matches reports whether the value of any of the labels of the matcher matches, the
+// negative matcher matches when the values of all the labels match
+func (m *maintenanceMatcher) matches(lbls labels.BaseLabels) bool {
+	negative := m.op == maintenanceMatchNotEqual || m.op == maintenanceMatchNotRegex
+	for _, name := range m.names {
+		if m.matchesValue(lbls.Get(name)) != negative {
+			return !negative
+		}
+	}
+	return negative
+}
+
+func (m *maintenanceMatcher) matchesValue(value string) bool {
+	switch m.op {
+	case maintenanceMatchEqual:
+		return value == m.value
+	case maintenanceMatchNotEqual:
+		return value != m.value
+	case maintenanceMatchRegex:
+		return m.re.MatchString(value)
+	case maintenanceMatchNotRegex:
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+// mutes reports whether the alert with the labels matches all the matchers of the maintenance
+func (m *PlannedMaintenance) mutes(lbls labels.BaseLabels) bool {
+	if len(m.Matchers) == 0 || lbls == nil {
+		return false
+	}
+	for _, matcher := range m.Matchers {
+		parsed, err := parseMaintenanceMatcher(matcher)
+		if err != nil {
+			zap.L().Error("invalid maintenance matcher", zap.String("maintenance", m.Name), zap.Error(err))
+			return false
+		}
+		if !parsed.matches(lbls) {
+			return false
+		}
+	}
+	return true
+}
+
+// pauses reports whether the maintenance skips the evaluation of the rules instead of muting them
+func (m *PlannedMaintenance) pauses() bool {
+	return !m.isPartial() && m.Mode != MaintenanceModeMute
+}
+
+// mutesAlert reports whether the notifications of the alert are muted by the muting
+// maintenance, the maintenance without matchers or severities mutes all the alerts
+func (m *PlannedMaintenance) mutesAlert(lbls labels.BaseLabels) bool {
+	return (len(m.Matchers) == 0 || m.mutes(lbls)) && m.mutesSeverity(lbls)
+}
+
+// maintenanceForRule returns the active maintenance skipping the rule, if any,
+// and the active maintenance muting the alerts of the rule by their labels
+func maintenanceForRule(maintenance []PlannedMaintenance, rule Rule, ts time.Time) (*PlannedMaintenance, []PlannedMaintenance) {
+	var muting []PlannedMaintenance
+	for i := range maintenance {
+		m := &maintenance[i]
+		zap.L().Info("checking if rule should be skipped", zap.String("rule", rule.ID()), zap.Any("maintenance", m))
+		if !m.matchesOrg(rule.OrgID()) || !m.shouldSkip(rule.ID(), ts) {
+			continue
+		}
+		if m.pauses() {
+			return m, nil
+		}
+		muting = append(muting, *m)
+	}
+	return nil, muting
+}
+
+// muteMatchingAlerts drops the notifications muted by the maintenance before they
+// are sent, the recorder of the evaluation knows the channels of the alerts
+func muteMatchingAlerts(notify NotifyFunc, maintenance []PlannedMaintenance, recorder *suppressionRecorder) NotifyFunc {
+	if len(maintenance) == 0 {
+		return notify
+	}
+	return func(ctx context.Context, expr string, alerts ...*Alert) {
+		notified := make([]*Alert, 0, len(alerts))
+		for _, alert := range alerts {
+			sent, muting := muteAlert(alert, maintenance, recorder.channelNames)
+			for _, i := range muting {
+				zap.L().Info("alert muted by maintenance", zap.String("maintenance", maintenance[i].Name), zap.String("labels", alert.Labels.String()))
+			}
+			if sent != nil {
+				notified = append(notified, sent)
+			}
+		}
+		if len(notified) > 0 {
+			notify(ctx, expr, notified...)
+		}
+	}
+}
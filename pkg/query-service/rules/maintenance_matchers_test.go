@@ -0,0 +1,132 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestMaintenanceMutes(t *testing.T) {
+	lbls := labels.Labels{{Name: "service", Value: "checkout"}, {Name: "env", Value: "staging"}, {Name: "pod", Value: "checkout-7d9f"}}
+
+	cases := []struct {
+		matchers MaintenanceMatchers
+		expected bool
+	}{
+		{MaintenanceMatchers{"service=checkout", "env=staging"}, true},
+		{MaintenanceMatchers{"service=checkout", "env=prod"}, false},
+		{MaintenanceMatchers{"env!=prod"}, true},
+		{MaintenanceMatchers{"pod=~checkout-.*"}, true},
+		{MaintenanceMatchers{"pod=~checkout"}, false},
+		{MaintenanceMatchers{"region!~us-.*"}, true},
+		{MaintenanceMatchers{"region="}, true},
+		{MaintenanceMatchers{"service.name|service=checkout"}, true},
+		{MaintenanceMatchers{"service.name|service=cart"}, false},
+		{MaintenanceMatchers{"service.name|service!=cart"}, true},
+		{MaintenanceMatchers{"service.name|service!=checkout"}, false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		m := PlannedMaintenance{Matchers: c.matchers}
+		assert.Equal(t, c.expected, m.mutes(lbls), c.matchers)
+	}
+
+	for _, matcher := range []string{"service", "=checkout", "pod=~(", "service~checkout", "service|=checkout"} {
+		_, err := parseMaintenanceMatcher(matcher)
+		assert.Error(t, err, matcher)
+	}
+}
+
+func TestMuteMatchingAlerts(t *testing.T) {
+	var notified []*Alert
+	notify := func(ctx context.Context, expr string, alerts ...*Alert) {
+		notified = append(notified, alerts...)
+	}
+
+	maintenance := []PlannedMaintenance{{Name: "staging", Matchers: MaintenanceMatchers{"env=staging"}}}
+	staging := &Alert{Labels: labels.Labels{{Name: "env", Value: "staging"}}}
+	prod := &Alert{Labels: labels.Labels{{Name: "env", Value: "prod"}}}
+
+	muteMatchingAlerts(notify, maintenance, nil)(context.Background(), "", staging, prod)
+	assert.Equal(t, []*Alert{prod}, notified)
+
+	notified = nil
+	muteMatchingAlerts(notify, maintenance, nil)(context.Background(), "", staging)
+	assert.Empty(t, notified)
+}
+
+func TestMaintenanceForRule(t *testing.T) {
+	rule := &ThresholdRule{BaseRule: &BaseRule{id: "1"}}
+	now := time.Now()
+	active := &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}
+
+	skipping, muting := maintenanceForRule([]PlannedMaintenance{{Name: "labels", Schedule: active, Matchers: MaintenanceMatchers{"env=staging"}}}, rule, now)
+	assert.Nil(t, skipping)
+	assert.Len(t, muting, 1)
+
+	skipping, _ = maintenanceForRule([]PlannedMaintenance{{Name: "labels", Schedule: active, Matchers: MaintenanceMatchers{"env=staging"}}, {Name: "rule", Schedule: active, AlertIds: &AlertIds{"1"}}}, rule, now)
+	assert.Equal(t, "rule", skipping.Name)
+
+	skipping, muting = maintenanceForRule([]PlannedMaintenance{{Name: "other rule", Schedule: active, AlertIds: &AlertIds{"2"}, Matchers: MaintenanceMatchers{"env=staging"}}}, rule, now)
+	assert.Nil(t, skipping)
+	assert.Empty(t, muting)
+}
+
+func TestRuleDBMaintenanceMatchers(t *testing.T) {
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+	ctx := context.Background()
+
+	id, err := ruleDB.CreatePlannedMaintenance(ctx, PlannedMaintenance{
+		Name:     "staging",
+		Schedule: &Schedule{Timezone: "UTC", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)},
+		Matchers: MaintenanceMatchers{"service=checkout", "env=staging"},
+	})
+	assert.NoError(t, err)
+
+	maintenance, err := ruleDB.GetAllPlannedMaintenance(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, maintenance, 1)
+	assert.Equal(t, id, maintenance[0].Id)
+	assert.Equal(t, MaintenanceMatchers{"service=checkout", "env=staging"}, maintenance[0].Matchers)
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	rule := &ThresholdRule{BaseRule: &BaseRule{id: "1"}}
+	now := time.Now()
+	active := &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}
+
+	// the muting maintenance keeps evaluating the rule and drops all its notifications
+	mute := PlannedMaintenance{Name: "mute", Schedule: active, Mode: MaintenanceModeMute}
+	skipping, muting := maintenanceForRule([]PlannedMaintenance{mute}, rule, now)
+	assert.Nil(t, skipping)
+	assert.Len(t, muting, 1)
+
+	var notified []*Alert
+	notify := func(ctx context.Context, expr string, alerts ...*Alert) {
+		notified = append(notified, alerts...)
+	}
+	muteMatchingAlerts(notify, muting, nil)(context.Background(), "", &Alert{Labels: labels.Labels{{Name: "env", Value: "prod"}}})
+	assert.Empty(t, notified)
+
+	pause := PlannedMaintenance{Name: "pause", Schedule: active, Mode: MaintenanceModePause}
+	skipping, _ = maintenanceForRule([]PlannedMaintenance{mute, pause}, rule, now)
+	assert.Equal(t, "pause", skipping.Name)
+
+	assert.NoError(t, mute.Validate())
+	assert.NoError(t, pause.Validate())
+	pause.Matchers = MaintenanceMatchers{"env=staging"}
+	assert.EqualError(t, pause.Validate(), "maintenance with matchers, severities or channels can only mute")
+	invalid := PlannedMaintenance{Name: "invalid", Schedule: active, Mode: "snooze"}
+	assert.Error(t, invalid.Validate())
+
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+	_, err := ruleDB.CreatePlannedMaintenance(context.Background(), mute)
+	assert.NoError(t, err)
+	maintenance, err := ruleDB.GetAllPlannedMaintenance(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, MaintenanceModeMute, maintenance[0].Mode)
+}
@@ -0,0 +1,206 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maintenanceOverlapHorizon is how far ahead the windows of the recurring maintenance are compared
+const maintenanceOverlapHorizon = 30 * 24 * time.Hour
+
+// MaintenanceOverlap is the existing maintenance muting the same rules or
+// alerts at the same time, the window is the first overlap
+type MaintenanceOverlap struct {
+	Id      int64     `json:"id"`
+	Name    string    `json:"name"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Message string    `json:"message"`
+}
+
+// PlannedMaintenanceWarnings is the response of the maintenance write, the
+// overlaps don't stop the write
+type PlannedMaintenanceWarnings struct {
+	Id       int64                `json:"id,omitempty"`
+	Warnings []MaintenanceOverlap `json:"warnings"`
+}
+
+type maintenanceWindow struct {
+	start time.Time
+	end   time.Time
+}
+
+// windows returns the windows of the maintenance overlapping the range [from, to)
+func (m *PlannedMaintenance) windows(from, to time.Time) []maintenanceWindow {
+	if m.Schedule == nil {
+		return nil
+	}
+	loc, err := time.LoadLocation(m.Schedule.Timezone)
+	if err != nil {
+		return nil
+	}
+
+	var windows []maintenanceWindow
+	add := func(start, end time.Time) {
+		if start.Before(to) && end.After(from) {
+			windows = append(windows, maintenanceWindow{start: start, end: end})
+		}
+	}
+
+	if !m.Schedule.StartTime.IsZero() && !m.Schedule.EndTime.IsZero() {
+		add(m.Schedule.StartTime, m.Schedule.EndTime)
+	}
+
+	recurrence := m.Schedule.Recurrence
+	if recurrence == nil {
+		return windows
+	}
+	duration := time.Duration(recurrence.Duration)
+	start := recurrence.StartTime.In(loc)
+	if recurrence.EndTime != nil && recurrence.EndTime.Before(to) {
+		to = *recurrence.EndTime
+	}
+
+	if recurrence.RRule != "" {
+		rule, err := parseRRule(recurrence.RRule, loc)
+		if err != nil {
+			return windows
+		}
+		for _, occurrence := range rule.between(start, from.Add(-duration), to) {
+			if !slices.ContainsFunc(recurrence.ExDates, occurrence.Equal) {
+				add(occurrence, occurrence.Add(duration))
+			}
+		}
+		return windows
+	}
+
+	// the windows of the repeat types start at the time of the day of the start
+	day := from.Add(-duration).In(loc)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		occurrence := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		if occurrence.Before(start) {
+			continue
+		}
+		switch recurrence.RepeatType {
+		case RepeatTypeWeekly:
+			if len(recurrence.RepeatOn) > 0 && !slices.Contains(recurrence.RepeatOn, RepeatOn(strings.ToLower(day.Weekday().String()))) {
+				continue
+			}
+		case RepeatTypeMonthly:
+			if day.Day() != start.Day() {
+				continue
+			}
+		}
+		add(occurrence, occurrence.Add(duration))
+	}
+	return windows
+}
+
+// scopeOverlaps reports whether the maintenance may mute an alert muted by the other maintenance
+func (m *PlannedMaintenance) scopeOverlaps(other *PlannedMaintenance) bool {
+	if m.AlertIds != nil && len(*m.AlertIds) > 0 && other.AlertIds != nil && len(*other.AlertIds) > 0 {
+		shared := false
+		for _, id := range *m.AlertIds {
+			if slices.Contains(*other.AlertIds, id) {
+				shared = true
+				break
+			}
+		}
+		if !shared {
+			return false
+		}
+	}
+	return !matchersDisjoint(m.Matchers, other.Matchers)
+}
+
+// matchersDisjoint reports whether no labels match both the matchers, the regex
+// matchers and the matchers of several labels are assumed to match the same labels
+func matchersDisjoint(a, b MaintenanceMatchers) bool {
+	for _, x := range a {
+		mx, err := parseMaintenanceMatcher(x)
+		if err != nil {
+			continue
+		}
+		for _, y := range b {
+			my, err := parseMaintenanceMatcher(y)
+			if err != nil || len(mx.names) != 1 || !slices.Equal(mx.names, my.names) {
+				continue
+			}
+			switch {
+			case mx.op == maintenanceMatchEqual && my.op == maintenanceMatchEqual && mx.value != my.value:
+				return true
+			case mx.op == maintenanceMatchEqual && my.op == maintenanceMatchNotEqual && mx.value == my.value:
+				return true
+			case mx.op == maintenanceMatchNotEqual && my.op == maintenanceMatchEqual && mx.value == my.value:
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PlannedMaintenanceOverlaps returns the existing maintenance muting the same rules or alerts
+// during a window of the maintenance, the maintenance with the exclude id is the one edited
+func (m *Manager) PlannedMaintenanceOverlaps(ctx context.Context, maintenance *PlannedMaintenance, excludeID string) ([]MaintenanceOverlap, error) {
+	existing, err := m.ruleDB.GetAllPlannedMaintenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	from := time.Now()
+	to := from.Add(maintenanceOverlapHorizon)
+	if maintenance.Schedule != nil && maintenance.Schedule.EndTime.After(to) {
+		to = maintenance.Schedule.EndTime
+	}
+	windows := maintenance.windows(from, to)
+
+	overlaps := []MaintenanceOverlap{}
+	for i := range existing {
+		other := &existing[i]
+		if strconv.FormatInt(other.Id, 10) == excludeID || !maintenance.scopeOverlaps(other) {
+			continue
+		}
+		if overlap, ok := firstOverlap(windows, other.windows(from, to)); ok {
+			zap.L().Debug("maintenance overlaps", zap.String("maintenance", maintenance.Name), zap.String("other", other.Name))
+			overlaps = append(overlaps, MaintenanceOverlap{
+				Id:      other.Id,
+				Name:    other.Name,
+				Start:   overlap.start,
+				End:     overlap.end,
+				Message: fmt.Sprintf("overlaps with the maintenance %s from %s to %s", other.Name, overlap.start.Format(time.RFC3339), overlap.end.Format(time.RFC3339)),
+			})
+		}
+	}
+	return overlaps, nil
+}
+
+// firstOverlap returns the earliest intersection of the windows
+func firstOverlap(a, b []maintenanceWindow) (maintenanceWindow, bool) {
+	var first maintenanceWindow
+	found := false
+	for _, x := range a {
+		for _, y := range b {
+			start, end := x.start, x.end
+			if y.start.After(start) {
+				start = y.start
+			}
+			if y.end.Before(end) {
+				end = y.end
+			}
+			if !start.Before(end) {
+				continue
+			}
+			if !found || start.Before(first.start) {
+				first, found = maintenanceWindow{start: start, end: end}, true
+			}
+		}
+	}
+	return first, found
+}
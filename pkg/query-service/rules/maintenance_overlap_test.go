@@ -0,0 +1,116 @@
+package rules
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceWindows(t *testing.T) {
+	from := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC) // monday
+	to := from.Add(7 * 24 * time.Hour)
+	start := time.Date(2024, 4, 1, 22, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		schedule *Schedule
+		expected int
+	}{
+		{"fixed", &Schedule{Timezone: "UTC", StartTime: from.Add(time.Hour), EndTime: from.Add(2 * time.Hour)}, 1},
+		{"fixed outside the range", &Schedule{Timezone: "UTC", StartTime: to, EndTime: to.Add(time.Hour)}, 0},
+		{"daily", &Schedule{Timezone: "UTC", Recurrence: &Recurrence{StartTime: start, Duration: Duration(4 * time.Hour), RepeatType: RepeatTypeDaily}}, 8},
+		{"weekly", &Schedule{Timezone: "UTC", Recurrence: &Recurrence{StartTime: start, Duration: Duration(time.Hour), RepeatType: RepeatTypeWeekly, RepeatOn: []RepeatOn{RepeatOnSaturday, RepeatOnSunday}}}, 2},
+		{"monthly", &Schedule{Timezone: "UTC", Recurrence: &Recurrence{StartTime: time.Date(2024, 4, 8, 1, 0, 0, 0, time.UTC), Duration: Duration(time.Hour), RepeatType: RepeatTypeMonthly}}, 1},
+		{"rrule", &Schedule{Timezone: "UTC", Recurrence: &Recurrence{StartTime: start, Duration: Duration(time.Hour), RRule: "FREQ=WEEKLY;BYDAY=MO,WE,FR"}}, 3},
+	}
+	for _, c := range cases {
+		m := PlannedMaintenance{Schedule: c.schedule}
+		assert.Len(t, m.windows(from, to), c.expected, c.name)
+	}
+}
+
+func TestMaintenanceScopeOverlaps(t *testing.T) {
+	cases := []struct {
+		name     string
+		a, b     PlannedMaintenance
+		expected bool
+	}{
+		{"all rules", PlannedMaintenance{}, PlannedMaintenance{AlertIds: &AlertIds{"1"}}, true},
+		{"shared rule", PlannedMaintenance{AlertIds: &AlertIds{"1", "2"}}, PlannedMaintenance{AlertIds: &AlertIds{"2"}}, true},
+		{"different rules", PlannedMaintenance{AlertIds: &AlertIds{"1"}}, PlannedMaintenance{AlertIds: &AlertIds{"2"}}, false},
+		{"different label values", PlannedMaintenance{Matchers: MaintenanceMatchers{"env=staging"}}, PlannedMaintenance{Matchers: MaintenanceMatchers{"env=prod"}}, false},
+		{"excluded label value", PlannedMaintenance{Matchers: MaintenanceMatchers{"env!=prod"}}, PlannedMaintenance{Matchers: MaintenanceMatchers{"env=prod"}}, false},
+		{"different labels", PlannedMaintenance{Matchers: MaintenanceMatchers{"env=staging"}}, PlannedMaintenance{Matchers: MaintenanceMatchers{"service=checkout"}}, true},
+		{"without matchers", PlannedMaintenance{Matchers: MaintenanceMatchers{"env=staging"}}, PlannedMaintenance{}, true},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.a.scopeOverlaps(&c.b), c.name)
+	}
+}
+
+func TestPlannedMaintenanceOverlaps(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Minute)
+	nightly, err := m.ruleDB.CreatePlannedMaintenance(ctx, PlannedMaintenance{
+		Name:     "nightly",
+		Schedule: &Schedule{Timezone: "UTC", Recurrence: &Recurrence{StartTime: now.Add(-24 * time.Hour), Duration: Duration(time.Hour), RepeatType: RepeatTypeDaily}},
+		AlertIds: &AlertIds{"1"},
+	})
+	assert.NoError(t, err)
+	_, err = m.ruleDB.CreatePlannedMaintenance(ctx, PlannedMaintenance{
+		Name:     "staging",
+		Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(48 * time.Hour), EndTime: now.Add(50 * time.Hour)},
+		Matchers: MaintenanceMatchers{"env=staging"},
+	})
+	assert.NoError(t, err)
+
+	release := &PlannedMaintenance{
+		Name:     "release",
+		Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(47*time.Hour + 30*time.Minute), EndTime: now.Add(49 * time.Hour)},
+		AlertIds: &AlertIds{"1"},
+		Matchers: MaintenanceMatchers{"env=prod"},
+	}
+	overlaps, err := m.PlannedMaintenanceOverlaps(ctx, release, "")
+	assert.NoError(t, err)
+	assert.Len(t, overlaps, 1)
+	assert.Equal(t, "nightly", overlaps[0].Name)
+	assert.Equal(t, now.Add(48*time.Hour), overlaps[0].Start.UTC())
+	assert.Equal(t, now.Add(49*time.Hour), overlaps[0].End.UTC())
+
+	// the edited maintenance doesn't overlap with itself
+	overlaps, err = m.PlannedMaintenanceOverlaps(ctx, release, strconv.FormatInt(nightly, 10))
+	assert.NoError(t, err)
+	assert.Empty(t, overlaps)
+
+	release.Matchers = nil
+	overlaps, err = m.PlannedMaintenanceOverlaps(ctx, release, strconv.FormatInt(nightly, 10))
+	assert.NoError(t, err)
+	assert.Len(t, overlaps, 1)
+	assert.Equal(t, "staging", overlaps[0].Name)
+}
+
+func TestPlannedMaintenanceValidateWindow(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name     string
+		schedule *Schedule
+		expected error
+	}{
+		{"missing end time", &Schedule{Timezone: "UTC", StartTime: now}, ErrMissingWindow},
+		{"too long", &Schedule{Timezone: "UTC", StartTime: now, EndTime: now.Add(91 * 24 * time.Hour)}, ErrMaxDuration},
+		{"too long recurrence", &Schedule{Timezone: "UTC", Recurrence: &Recurrence{StartTime: now, Duration: Duration(91 * 24 * time.Hour), RepeatType: RepeatTypeDaily}}, ErrMaxDuration},
+		{"negative duration", &Schedule{Timezone: "UTC", Recurrence: &Recurrence{StartTime: now, Duration: Duration(-time.Hour), RepeatType: RepeatTypeDaily}}, ErrMissingDuration},
+	}
+	for _, c := range cases {
+		m := PlannedMaintenance{Name: c.name, Schedule: c.schedule}
+		assert.ErrorIs(t, m.Validate(), c.expected, c.name)
+	}
+
+	m := PlannedMaintenance{Name: "empty", Schedule: &Schedule{Timezone: "UTC", StartTime: now, EndTime: now}}
+	assert.EqualError(t, m.Validate(), "end time must be after start time")
+}
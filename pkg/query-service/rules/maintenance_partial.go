@@ -0,0 +1,128 @@
+package rules
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"slices"
+
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+// MaintenanceList is the list of the severities or channels of the partial maintenance
+type MaintenanceList []string
+
+func (l *MaintenanceList) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, l)
+	case string:
+		return json.Unmarshal([]byte(data), l)
+	}
+	return nil
+}
+
+func (l MaintenanceList) Value() (driver.Value, error) {
+	if l == nil {
+		l = MaintenanceList{}
+	}
+	data, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// isPartial reports whether the maintenance mutes only some of the alerts or channels of the rules
+func (m *PlannedMaintenance) isPartial() bool {
+	return len(m.Matchers) > 0 || len(m.Severities) > 0 || len(m.Channels) > 0
+}
+
+// mutesSeverity reports whether the maintenance mutes the alerts with the severity of the labels
+func (m *PlannedMaintenance) mutesSeverity(lbls labels.BaseLabels) bool {
+	if len(m.Severities) == 0 {
+		return true
+	}
+	if lbls == nil {
+		return false
+	}
+	return slices.Contains(m.Severities, lbls.Get("severity"))
+}
+
+// withoutMutedChannels returns the receivers left after the channels of the maintenance are muted
+func (m *PlannedMaintenance) withoutMutedChannels(receivers []string) []string {
+	remaining := make([]string, 0, len(receivers))
+	for _, receiver := range receivers {
+		if !slices.Contains(m.Channels, receiver) {
+			remaining = append(remaining, receiver)
+		}
+	}
+	return remaining
+}
+
+// channelNames returns the names of all the channels, the alerts without
+// preferred channels are sent to all of them
+func (s *suppressionRecorder) channelNames() []string {
+	if s == nil || s.ruleDB == nil {
+		return nil
+	}
+	if s.channels == nil {
+		s.channels = channelNames(s.ruleDB)
+	}
+	return s.channels
+}
+
+// channelNames returns the names of all the stored channels
+func channelNames(ruleDB RuleDB) []string {
+	channels, apiErr := ruleDB.GetChannels()
+	if apiErr != nil {
+		zap.L().Error("failed to get the channels", zap.Error(apiErr.Err))
+		return nil
+	}
+	names := make([]string, 0, len(*channels))
+	for _, channel := range *channels {
+		names = append(names, channel.Name)
+	}
+	return names
+}
+
+// muteAlert applies the muting maintenance to the alert, it returns the alert with the
+// receivers left or nil when all its notifications are muted, and the maintenance muting
+// it. The alert without receivers is sent to all the channels.
+func muteAlert(alert *Alert, maintenance []PlannedMaintenance, allChannels func() []string) (*Alert, []int) {
+	var muting []int
+	receivers := alert.Receivers
+	for i := range maintenance {
+		m := &maintenance[i]
+		if !m.mutesAlert(alert.Labels) {
+			continue
+		}
+		if len(m.Channels) == 0 {
+			return nil, append(muting, i)
+		}
+
+		if len(receivers) == 0 {
+			receivers = allChannels()
+			if len(receivers) == 0 {
+				// the channels are not known, the alert is sent to all of them
+				continue
+			}
+		}
+		remaining := m.withoutMutedChannels(receivers)
+		if len(remaining) == len(receivers) {
+			continue
+		}
+		muting = append(muting, i)
+		if len(remaining) == 0 {
+			return nil, muting
+		}
+		receivers = remaining
+	}
+
+	if len(muting) == 0 {
+		return alert, nil
+	}
+	partial := *alert
+	partial.Receivers = receivers
+	return &partial, muting
+}
@@ -0,0 +1,75 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestMuteAlertPartially(t *testing.T) {
+	critical := labels.Labels{{Name: "severity", Value: "critical"}}
+	warning := labels.Labels{{Name: "severity", Value: "warning"}}
+	allChannels := func() []string { return []string{"slack", "pagerduty", "email"} }
+	noChannels := func() []string { return nil }
+
+	warnings := PlannedMaintenance{Name: "warnings", Severities: MaintenanceList{"warning"}}
+	slack := PlannedMaintenance{Name: "slack", Channels: MaintenanceList{"slack"}}
+	slackWarnings := PlannedMaintenance{Name: "slack warnings", Severities: MaintenanceList{"warning"}, Channels: MaintenanceList{"slack", "email"}}
+
+	cases := []struct {
+		name      string
+		alert     *Alert
+		muting    []PlannedMaintenance
+		channels  func() []string
+		receivers []string
+		muted     bool
+		by        []int
+	}{
+		{"the criticals keep paging", &Alert{Labels: critical, Receivers: []string{"pagerduty"}}, []PlannedMaintenance{warnings}, allChannels, []string{"pagerduty"}, false, nil},
+		{"the warnings are muted", &Alert{Labels: warning, Receivers: []string{"pagerduty"}}, []PlannedMaintenance{warnings}, allChannels, nil, true, []int{0}},
+		{"slack is muted", &Alert{Labels: critical, Receivers: []string{"slack", "pagerduty"}}, []PlannedMaintenance{slack}, allChannels, []string{"pagerduty"}, false, []int{0}},
+		{"only slack is muted", &Alert{Labels: critical, Receivers: []string{"slack"}}, []PlannedMaintenance{slack}, allChannels, nil, true, []int{0}},
+		{"the alert without receivers is sent to the other channels", &Alert{Labels: critical}, []PlannedMaintenance{slack}, allChannels, []string{"pagerduty", "email"}, false, []int{0}},
+		{"the unknown channels are not muted", &Alert{Labels: critical}, []PlannedMaintenance{slack}, noChannels, nil, false, nil},
+		{"the other receivers are kept", &Alert{Labels: critical, Receivers: []string{"pagerduty"}}, []PlannedMaintenance{slack}, allChannels, []string{"pagerduty"}, false, nil},
+		{"the maintenance are combined", &Alert{Labels: warning}, []PlannedMaintenance{slack, slackWarnings}, allChannels, []string{"pagerduty"}, false, []int{0, 1}},
+	}
+	for _, c := range cases {
+		receivers := c.alert.Receivers
+		sent, by := muteAlert(c.alert, c.muting, c.channels)
+		assert.Equal(t, c.muted, sent == nil, c.name)
+		if sent != nil {
+			assert.Equal(t, c.receivers, sent.Receivers, c.name)
+		}
+		assert.Equal(t, c.by, by, c.name)
+		assert.Equal(t, receivers, c.alert.Receivers, c.name)
+	}
+}
+
+func TestPartialMaintenance(t *testing.T) {
+	now := time.Now()
+	active := &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}
+	maintenance := PlannedMaintenance{Name: "warnings", Schedule: active, Severities: MaintenanceList{"warning"}, Channels: MaintenanceList{"slack"}}
+
+	// the partial maintenance mutes the rule instead of pausing it
+	skipping, muting := maintenanceForRule([]PlannedMaintenance{maintenance}, &ThresholdRule{BaseRule: &BaseRule{id: "1"}}, now)
+	assert.Nil(t, skipping)
+	assert.Len(t, muting, 1)
+
+	assert.NoError(t, maintenance.Validate())
+	maintenance.Mode = MaintenanceModePause
+	assert.Error(t, maintenance.Validate())
+	maintenance.Mode = ""
+
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+	_, err := ruleDB.CreatePlannedMaintenance(context.Background(), maintenance)
+	assert.NoError(t, err)
+	stored, err := ruleDB.GetAllPlannedMaintenance(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, MaintenanceList{"warning"}, stored[0].Severities)
+	assert.Equal(t, MaintenanceList{"slack"}, stored[0].Channels)
+}
@@ -0,0 +1,95 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// MaintenancePreviewRule is a rule muted by the maintenance along with its
+// active alerts that would be muted
+type MaintenancePreviewRule struct {
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	Disabled bool   `json:"disabled"`
+	// AllAlerts is set when all the notifications of the rule are muted, otherwise
+	// only the alerts, severities or channels of the partial maintenance are muted
+	AllAlerts bool `json:"allAlerts"`
+	// Paused is set when the maintenance skips the evaluation of the rule
+	Paused      bool        `json:"paused"`
+	MutedAlerts []RuleAlert `json:"mutedAlerts"`
+}
+
+// MaintenancePreview is the scope of the maintenance, the active alerts are
+// the alerts at the time of the preview
+type MaintenancePreview struct {
+	// Active is set when a window of the maintenance is open at the time of the preview
+	Active      bool                     `json:"active"`
+	Rules       []MaintenancePreviewRule `json:"rules"`
+	MutedAlerts int                      `json:"mutedAlerts"`
+}
+
+// PreviewPlannedMaintenance returns the rules and the active alerts that would
+// be muted during the windows of the maintenance. The maintenance with
+// matchers only lists the rules with matching active alerts, the alerts
+// created later may still be muted.
+func (m *Manager) PreviewPlannedMaintenance(ctx context.Context, maintenance *PlannedMaintenance) (*MaintenancePreview, error) {
+	storedRules, err := m.ruleDB.FilterStoredRules(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &MaintenancePreview{
+		Active: maintenance.IsActive(time.Now()),
+		Rules:  []MaintenancePreviewRule{},
+	}
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+	for _, s := range storedRules {
+		id := fmt.Sprintf("%d", s.Id)
+		if !maintenance.covers(id) {
+			continue
+		}
+
+		rule := PostableRule{}
+		if err := json.Unmarshal([]byte(s.Data), &rule); err != nil {
+			zap.L().Error("failed to unmarshal rule from db", zap.Int("id", s.Id), zap.Error(err))
+			continue
+		}
+
+		previewRule := MaintenancePreviewRule{
+			Id:          id,
+			Name:        rule.AlertName,
+			Disabled:    true,
+			AllAlerts:   !maintenance.isPartial(),
+			Paused:      maintenance.pauses(),
+			MutedAlerts: []RuleAlert{},
+		}
+
+		var alerts []*Alert
+		if r, ok := m.rules[id]; ok {
+			previewRule.Disabled = false
+			for _, a := range r.ActiveAlerts() {
+				if maintenance.mutesAlert(a.Labels) {
+					alerts = append(alerts, a)
+				}
+			}
+		}
+		if !previewRule.AllAlerts && len(alerts) == 0 {
+			continue
+		}
+
+		sortAlerts(alerts)
+		for _, a := range alerts {
+			previewRule.MutedAlerts = append(previewRule.MutedAlerts, newRuleAlert(a))
+		}
+		preview.MutedAlerts += len(alerts)
+		preview.Rules = append(preview.Rules, previewRule)
+	}
+
+	return preview, nil
+}
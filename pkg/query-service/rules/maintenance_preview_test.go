@@ -0,0 +1,64 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestPreviewPlannedMaintenance(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	var ids []string
+	for _, rule := range []string{
+		`{"alert":"Error rate","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`,
+		`{"alert":"Latency","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"latency"}}},"op":"1","matchType":"1","target":1}}`,
+		`{"alert":"Disabled","disabled":true,"condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"up"}}},"op":"1","matchType":"1","target":1}}`,
+	} {
+		rule, err := m.CreateRule(ctx, rule)
+		assert.NoError(t, err)
+		ids = append(ids, rule.Id)
+	}
+
+	now := time.Now()
+	m.rules[ids[0]] = &ThresholdRule{BaseRule: &BaseRule{Active: map[uint64]*Alert{
+		1: {State: model.StateFiring, ActiveAt: now, Labels: labels.Labels{{Name: "service", Value: "checkout"}, {Name: "env", Value: "staging"}}},
+		2: {State: model.StateFiring, ActiveAt: now, Labels: labels.Labels{{Name: "service", Value: "checkout"}, {Name: "env", Value: "prod"}}},
+	}}}
+	m.rules[ids[1]] = &ThresholdRule{BaseRule: &BaseRule{Active: map[uint64]*Alert{
+		1: {State: model.StatePending, ActiveAt: now, Labels: labels.Labels{{Name: "service", Value: "cart"}, {Name: "env", Value: "prod"}}},
+	}}}
+
+	schedule := &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}
+
+	// the maintenance of the rules mutes all their alerts
+	preview, err := m.PreviewPlannedMaintenance(ctx, &PlannedMaintenance{Name: "rules", Schedule: schedule, AlertIds: &AlertIds{ids[1], ids[2]}})
+	assert.NoError(t, err)
+	assert.True(t, preview.Active)
+	assert.Equal(t, 1, preview.MutedAlerts)
+	assert.Len(t, preview.Rules, 2)
+	assert.Equal(t, "Latency", preview.Rules[0].Name)
+	assert.True(t, preview.Rules[0].AllAlerts)
+	assert.True(t, preview.Rules[0].Paused)
+	assert.Len(t, preview.Rules[0].MutedAlerts, 1)
+	assert.Equal(t, "Disabled", preview.Rules[1].Name)
+	assert.True(t, preview.Rules[1].Disabled)
+	assert.Empty(t, preview.Rules[1].MutedAlerts)
+
+	// the maintenance with matchers only lists the rules with matching alerts
+	upcoming := &Schedule{Timezone: "UTC", StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)}
+	preview, err = m.PreviewPlannedMaintenance(ctx, &PlannedMaintenance{Name: "staging", Schedule: upcoming, Matchers: MaintenanceMatchers{"env=staging"}})
+	assert.NoError(t, err)
+	assert.False(t, preview.Active)
+	assert.Equal(t, 1, preview.MutedAlerts)
+	assert.Len(t, preview.Rules, 1)
+	assert.Equal(t, ids[0], preview.Rules[0].Id)
+	assert.False(t, preview.Rules[0].AllAlerts)
+	assert.False(t, preview.Rules[0].Paused)
+	assert.Equal(t, map[string]string{"service": "checkout", "env": "staging"}, preview.Rules[0].MutedAlerts[0].Labels)
+}
@@ -0,0 +1,98 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// maxQuickMaintenanceTTL bounds the quick maintenance to the incident time muting
+	maxQuickMaintenanceTTL = 7 * 24 * time.Hour
+	// expiredMaintenanceCleanupInterval is how often the expired quick maintenance is purged
+	expiredMaintenanceCleanupInterval = time.Minute
+)
+
+var ErrInvalidQuickMaintenanceTTL = errors.New("ttl must be positive and at most 7 days")
+
+// QuickMaintenance is the one shot maintenance starting now, it is
+// purged from the planned maintenance once the ttl has passed
+type QuickMaintenance struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	TTL         Duration            `json:"ttl"`
+	AlertIds    []string            `json:"alertIds"`
+	Matchers    MaintenanceMatchers `json:"matchers"`
+	Mode        MaintenanceMode     `json:"mode"`
+}
+
+func (q *QuickMaintenance) Validate() error {
+	ttl := time.Duration(q.TTL)
+	if ttl <= 0 || ttl > maxQuickMaintenanceTTL {
+		return ErrInvalidQuickMaintenanceTTL
+	}
+	maintenance := PlannedMaintenance{Name: "quick", Schedule: &Schedule{Timezone: "UTC", StartTime: time.Now(), EndTime: time.Now().Add(ttl)}, Matchers: q.Matchers, Mode: q.Mode}
+	return maintenance.Validate()
+}
+
+// CreateQuickMaintenance stores the maintenance muting the rules from now until the ttl has passed
+func (m *Manager) CreateQuickMaintenance(ctx context.Context, quick QuickMaintenance) (*PlannedMaintenance, error) {
+	if err := quick.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	end := now.Add(time.Duration(quick.TTL))
+	maintenance := PlannedMaintenance{
+		Name:        quick.Name,
+		Description: quick.Description,
+		Schedule:    &Schedule{Timezone: "UTC", StartTime: now, EndTime: end},
+		Matchers:    quick.Matchers,
+		Mode:        quick.Mode,
+		ExpiresAt:   &end,
+	}
+	if maintenance.Name == "" {
+		maintenance.Name = "Quick maintenance " + now.Format(time.RFC3339)
+	}
+	if len(quick.AlertIds) > 0 {
+		alertIds := AlertIds(quick.AlertIds)
+		maintenance.AlertIds = &alertIds
+	}
+
+	m.purgeExpiredMaintenance(ctx)
+
+	id, err := m.ruleDB.CreatePlannedMaintenance(ctx, maintenance)
+	if err != nil {
+		return nil, err
+	}
+	return m.ruleDB.GetPlannedMaintenanceByID(ctx, strconv.FormatInt(id, 10))
+}
+
+// purgeExpiredMaintenance deletes the quick maintenance past its expiry
+func (m *Manager) purgeExpiredMaintenance(ctx context.Context) {
+	count, err := m.ruleDB.PurgeExpiredPlannedMaintenance(ctx, time.Now())
+	if err != nil {
+		zap.L().Error("failed to purge the expired maintenance", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		zap.L().Info("purged the expired maintenance", zap.Int64("count", count))
+	}
+}
+
+// purgeExpiredMaintenanceLoop purges the expired quick maintenance until the manager is stopped
+func (m *Manager) purgeExpiredMaintenanceLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(expiredMaintenanceCleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			m.purgeExpiredMaintenance(context.Background())
+		}
+	}
+}
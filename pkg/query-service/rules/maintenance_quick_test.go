@@ -0,0 +1,81 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateQuickMaintenance(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	_, err := m.CreateQuickMaintenance(ctx, QuickMaintenance{TTL: Duration(0)})
+	assert.ErrorIs(t, err, ErrInvalidQuickMaintenanceTTL)
+	_, err = m.CreateQuickMaintenance(ctx, QuickMaintenance{TTL: Duration(8 * 24 * time.Hour)})
+	assert.ErrorIs(t, err, ErrInvalidQuickMaintenanceTTL)
+	_, err = m.CreateQuickMaintenance(ctx, QuickMaintenance{TTL: Duration(time.Hour), Matchers: MaintenanceMatchers{"service"}})
+	assert.Error(t, err)
+
+	maintenance, err := m.CreateQuickMaintenance(ctx, QuickMaintenance{TTL: Duration(30 * time.Minute), AlertIds: []string{"1"}})
+	assert.NoError(t, err)
+	assert.Contains(t, maintenance.Name, "Quick maintenance")
+	assert.NotNil(t, maintenance.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), *maintenance.ExpiresAt, time.Minute)
+	assert.True(t, maintenance.shouldSkip("1", time.Now().Add(time.Minute)))
+	assert.False(t, maintenance.shouldSkip("2", time.Now().Add(time.Minute)))
+
+	data, err := json.Marshal(maintenance)
+	assert.NoError(t, err)
+	fields := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, "quick", fields["kind"])
+	assert.Equal(t, "active", fields["status"])
+
+	// the edit without the expiry keeps the maintenance quick and extends its expiry
+	id := strconv.FormatInt(maintenance.Id, 10)
+	end := maintenance.Schedule.EndTime.Add(time.Hour)
+	_, err = m.ruleDB.EditPlannedMaintenance(ctx, PlannedMaintenance{Name: "incident", Schedule: &Schedule{Timezone: "UTC", StartTime: maintenance.Schedule.StartTime, EndTime: end}}, id)
+	assert.NoError(t, err)
+	edited, err := m.ruleDB.GetPlannedMaintenanceByID(ctx, id)
+	assert.NoError(t, err)
+	assert.NotNil(t, edited.ExpiresAt)
+	assert.WithinDuration(t, end, *edited.ExpiresAt, time.Second)
+}
+
+func TestPurgeExpiredPlannedMaintenance(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	expiresAt := now.Add(-time.Minute)
+	_, err := m.ruleDB.CreatePlannedMaintenance(ctx, PlannedMaintenance{Name: "expired", Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: expiresAt}, ExpiresAt: &expiresAt})
+	assert.NoError(t, err)
+	_, err = m.ruleDB.CreatePlannedMaintenance(ctx, PlannedMaintenance{Name: "planned", Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: expiresAt}})
+	assert.NoError(t, err)
+
+	// the expired maintenance is hidden before it is purged
+	maintenances, err := m.ruleDB.GetAllPlannedMaintenance(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, maintenances, 1)
+	assert.Equal(t, "planned", maintenances[0].Name)
+
+	count, err := m.ruleDB.PurgeExpiredPlannedMaintenance(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	_, err = m.CreateQuickMaintenance(ctx, QuickMaintenance{Name: "incident", TTL: Duration(time.Hour)})
+	assert.NoError(t, err)
+
+	count, err = m.ruleDB.PurgeExpiredPlannedMaintenance(ctx, time.Now().Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	maintenances, err = m.ruleDB.GetAllPlannedMaintenance(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, maintenances, 1)
+	assert.Equal(t, "planned", maintenances[0].Name)
+}
@@ -219,12 +219,74 @@ func TestShouldSkipMaintenance(t *testing.T) {
 			ts:       time.Date(2024, 05, 04, 12, 10, 0, 0, time.UTC),
 			expected: true,
 		},
+		{
+			name: "rrule maintenance, every sunday from 02:00 to 04:00",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone: "UTC",
+					Recurrence: &Recurrence{
+						StartTime: time.Date(2024, 04, 07, 2, 0, 0, 0, time.UTC),
+						Duration:  Duration(time.Hour * 2),
+						RRule:     "FREQ=WEEKLY;BYDAY=SU",
+					},
+				},
+			},
+			ts:       time.Date(2024, 05, 12, 3, 30, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name: "rrule maintenance, every sunday from 02:00 to 04:00 on monday",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone: "UTC",
+					Recurrence: &Recurrence{
+						StartTime: time.Date(2024, 04, 07, 2, 0, 0, 0, time.UTC),
+						Duration:  Duration(time.Hour * 2),
+						RRule:     "FREQ=WEEKLY;BYDAY=SU",
+					},
+				},
+			},
+			ts:       time.Date(2024, 05, 13, 3, 30, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name: "rrule maintenance, skipped occurrence",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone: "UTC",
+					Recurrence: &Recurrence{
+						StartTime: time.Date(2024, 04, 07, 2, 0, 0, 0, time.UTC),
+						Duration:  Duration(time.Hour * 2),
+						RRule:     "FREQ=WEEKLY;BYDAY=SU",
+						ExDates:   []time.Time{time.Date(2024, 05, 12, 2, 0, 0, 0, time.UTC)},
+					},
+				},
+			},
+			ts:       time.Date(2024, 05, 12, 3, 30, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name: "rrule maintenance, occurrence crossing midnight in the timezone",
+			maintenance: &PlannedMaintenance{
+				Schedule: &Schedule{
+					Timezone: "Asia/Kolkata",
+					Recurrence: &Recurrence{
+						StartTime: time.Date(2024, 04, 01, 23, 0, 0, 0, time.FixedZone("IST", 19800)),
+						Duration:  Duration(time.Hour * 3),
+						RRule:     "FREQ=MONTHLY;BYDAY=1MO",
+					},
+				},
+			},
+			// the first monday of may is the 6th, 01:00 on the 7th in IST
+			ts:       time.Date(2024, 05, 06, 19, 30, 0, 0, time.UTC),
+			expected: true,
+		},
 	}
 
 	for _, c := range cases {
 		result := c.maintenance.shouldSkip(c.name, c.ts)
 		if result != c.expected {
-			t.Errorf("expected %v, got %v", c.expected, result)
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, result)
 		}
 	}
 }
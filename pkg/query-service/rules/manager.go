@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"go.uber.org/zap"
@@ -20,6 +23,7 @@ import (
 	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
 	pqle "go.signoz.io/signoz/pkg/query-service/pqlEngine"
 	"go.signoz.io/signoz/pkg/query-service/telemetry"
 )
@@ -90,6 +94,109 @@ type ManagerOptions struct {
 	Cache        cache.Cache
 
 	EvalDelay time.Duration
+	// EvalTimeout cancels the evaluations running for longer, the rules
+	// are evaluated for up to their frequency when not set
+	EvalTimeout time.Duration
+
+	// RuleVariables are the org level variables that can be referenced
+	// in the filter values of the rules
+	RuleVariables map[string]string
+
+	// TrashRetention is how long the deleted rules can be restored
+	TrashRetention time.Duration
+
+	// ProvisioningDir holds the rule files provisioned at startup and on SIGHUP
+	ProvisioningDir string
+
+	// RuleEventWebhooks are sent the rule changes, RuleEvents limits the
+	// changes sent to the webhooks, all the changes are sent when empty
+	RuleEventWebhooks []string
+	RuleEvents        []string
+
+	// IncidentWindow is how long after its last change the open incident takes the
+	// new firing alerts of its group, IncidentChannels are notified of the incidents
+	IncidentWindow   time.Duration
+	IncidentChannels []string
+
+	// ShardRules shares the rules among the replicas of the query service using the
+	// rules db, every rule is evaluated by one replica
+	ShardRules bool
+	// LeaderElection evaluates all the rules on the replica holding the leader lease
+	// in the rules db, the other replicas stand by to take over
+	LeaderElection bool
+
+	// EvalConcurrency is the number of rules evaluated at the same time, the
+	// evaluations past the limit wait for a worker
+	EvalConcurrency int
+	// evalPool is shared by the tasks to evaluate their rules
+	evalPool *evalPool
+	// QueryParallelism is the number of the queries of one evaluation run at the
+	// same time by the rules running several queries e.g. the anomaly rules
+	QueryParallelism int
+	// MaxResultSeries and MaxResultPoints abort the evaluations of the rules querying
+	// larger results instead of holding them in memory, zero doesn't limit the results
+	MaxResultSeries int
+	MaxResultPoints int
+
+	// DisableQueryResultCache runs the queries of every rule instead of sharing
+	// the results of the identical queries
+	DisableQueryResultCache bool
+	// resultCache shares the query results among the rules
+	resultCache *queryResultCache
+
+	// TemporalityCacheTTL is how long the temporality of the metrics is shared
+	// by the rules before it's fetched again
+	TemporalityCacheTTL time.Duration
+	// temporalityCache shares the temporality of the metrics among the rules
+	temporalityCache *temporalityCache
+	// costs keeps the costs of the last evaluations of the rules
+	costs *ruleCostTracker
+
+	// BreakerThreshold is the number of consecutive datastore failures pausing the
+	// evaluations for BreakerCooldown, a negative threshold disables the breaker.
+	// MetaAlertChannels are notified while the evaluations are paused
+	BreakerThreshold  int
+	BreakerCooldown   time.Duration
+	MetaAlertChannels []string
+	// breaker is shared by the tasks to pause their evaluations
+	breaker *circuitBreaker
+
+	// MaxEvalBackoff caps the interval between the evaluations of the rules failing
+	// every evaluation, a negative cap evaluates them at their frequency
+	MaxEvalBackoff time.Duration
+	// backoffs spaces the evaluations of the failing rules
+	backoffs *ruleBackoffs
+	// tracer captures the evaluations of the rules in the debug mode
+	tracer *evalTracer
+	// shadows evaluates the edited definitions of the rules alongside the live ones
+	shadows *shadowRules
+	// suppressions keeps the ongoing maintenance suppressions of the rules
+	suppressions *suppressionTracker
+
+	// ShutdownTimeout bounds how long the rule manager waits for the in-flight
+	// evaluations and the queued notifications when stopped
+	ShutdownTimeout time.Duration
+	// AlertSnapshotInterval is how often the active alerts of the rules are saved to
+	// the rules db to be restored at startup, a negative interval disables the snapshots
+	AlertSnapshotInterval time.Duration
+
+	// StateExportWebhooks and StateExportKafkaBrokers are the sinks the state changes
+	// of the rules and the notifications are exported to, StateExportKafkaTopic is the
+	// topic the events are produced to
+	StateExportWebhooks     []string
+	StateExportKafkaBrokers []string
+	StateExportKafkaTopic   string
+
+	// HistoryQueueCapacity bounds the state history entries written in the background,
+	// a negative capacity writes the state history during the evaluations
+	HistoryQueueCapacity int
+
+	// EvalLagThreshold is how late the evaluation of the rule starts before the rule
+	// is lagging, EvalLagAlert notifies the MetaAlertChannels while rules are lagging
+	EvalLagThreshold time.Duration
+	EvalLagAlert     bool
+	// evalLag keeps how late the evaluations of the rules start
+	evalLag *evalLagTracker
 
 	PrepareTaskFunc func(opts PrepareTaskOptions) (Task, error)
 
@@ -107,6 +214,10 @@ type Manager struct {
 	block chan struct{}
 	// Notifier sends messages through alert manager
 	notifier *am.Notifier
+	// delivery sends the messages of the channels delivered by the query service
+	delivery *channelDelivery
+	// alertManager sends the test notifications of the channels routed by the alertmanager
+	alertManager am.Manager
 
 	// datastore to store alert definitions
 	ruleDB RuleDB
@@ -121,6 +232,30 @@ type Manager struct {
 
 	UseLogsNewSchema  bool
 	UseTraceNewSchema bool
+
+	// reloadSignal receives the signals to provision the rules again
+	reloadSignal chan os.Signal
+	// maintenanceDone stops the purge of the expired maintenance
+	maintenanceDone chan struct{}
+	// deliveryDone stops the retries of the delivery queue and the purge of the delivery logs
+	deliveryDone chan struct{}
+	// incidentMtx serializes the changes of the incidents
+	incidentMtx sync.Mutex
+	// shards is the membership of the replica, nil when the rules are neither
+	// sharded nor evaluated by the leader
+	shards *ruleShards
+	// shardDone stops the heartbeat of the replica
+	shardDone chan struct{}
+	// snapshotDone stops the snapshots of the active alerts
+	snapshotDone chan struct{}
+	// rollupDone stops the rollups of the state history
+	rollupDone chan struct{}
+	// exporter publishes the state changes and the notifications to the external
+	// sinks, nil when no sink is configured
+	exporter *stateExporter
+	// history writes the state history of the evaluations in the background, nil when
+	// it's written during the evaluations
+	history *historyWriter
 }
 
 func defaultOptions(o *ManagerOptions) *ManagerOptions {
@@ -133,6 +268,24 @@ func defaultOptions(o *ManagerOptions) *ManagerOptions {
 	if o.ResendDelay == time.Duration(0) {
 		o.ResendDelay = 1 * time.Minute
 	}
+	if o.EvalConcurrency == 0 {
+		o.EvalConcurrency = DefaultEvalConcurrency
+	}
+	if o.ShutdownTimeout == time.Duration(0) {
+		o.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if o.AlertSnapshotInterval == time.Duration(0) {
+		o.AlertSnapshotInterval = DefaultAlertSnapshotInterval
+	}
+	if o.HistoryQueueCapacity == 0 {
+		o.HistoryQueueCapacity = DefaultHistoryQueueCapacity
+	}
+	if o.IncidentWindow == time.Duration(0) {
+		o.IncidentWindow = DefaultIncidentWindow
+	}
+	if o.TrashRetention == time.Duration(0) {
+		o.TrashRetention = 30 * 24 * time.Hour
+	}
 	if o.Logger == nil {
 		o.Logger = zap.L()
 	}
@@ -161,6 +314,9 @@ func defaultPrepareTaskFunc(opts PrepareTaskOptions) (Task, error) {
 			opts.UseLogsNewSchema,
 			opts.UseTraceNewSchema,
 			WithEvalDelay(opts.ManagerOpts.EvalDelay),
+			WithEvalTimeout(opts.ManagerOpts.EvalTimeout),
+			WithResultLimits(opts.ManagerOpts.MaxResultSeries, opts.ManagerOpts.MaxResultPoints),
+			WithVariables(opts.ManagerOpts.RuleVariables),
 		)
 
 		if err != nil {
@@ -172,6 +328,54 @@ func defaultPrepareTaskFunc(opts PrepareTaskOptions) (Task, error) {
 		// create ch rule task for evalution
 		task = newTask(TaskTypeCh, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
 
+	} else if opts.Rule.RuleType == RuleTypeSLO {
+		// create a slo rule
+		sr, err := NewSLORule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			WithEvalDelay(opts.ManagerOpts.EvalDelay),
+			WithEvalTimeout(opts.ManagerOpts.EvalTimeout),
+			WithResultLimits(opts.ManagerOpts.MaxResultSeries, opts.ManagerOpts.MaxResultPoints),
+			WithVariables(opts.ManagerOpts.RuleVariables),
+		)
+
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, sr)
+
+		// create ch rule task for evalution
+		task = newTask(TaskTypeCh, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
+
+	} else if opts.Rule.RuleType == RuleTypeHeartbeat {
+		// create a heartbeat rule
+		hr, err := NewHeartbeatRule(
+			ruleId,
+			opts.Rule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			WithEvalDelay(opts.ManagerOpts.EvalDelay),
+			WithEvalTimeout(opts.ManagerOpts.EvalTimeout),
+			WithResultLimits(opts.ManagerOpts.MaxResultSeries, opts.ManagerOpts.MaxResultPoints),
+			WithVariables(opts.ManagerOpts.RuleVariables),
+		)
+
+		if err != nil {
+			return task, err
+		}
+
+		rules = append(rules, hr)
+
+		// create ch rule task for evalution
+		task = newTask(TaskTypeCh, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
+
 	} else if opts.Rule.RuleType == RuleTypeProm {
 
 		// create promql rule
@@ -181,6 +385,8 @@ func defaultPrepareTaskFunc(opts PrepareTaskOptions) (Task, error) {
 			opts.Logger,
 			opts.Reader,
 			opts.ManagerOpts.PqlEngine,
+			WithEvalTimeout(opts.ManagerOpts.EvalTimeout),
+			WithResultLimits(opts.ManagerOpts.MaxResultSeries, opts.ManagerOpts.MaxResultPoints),
 		)
 
 		if err != nil {
@@ -193,7 +399,7 @@ func defaultPrepareTaskFunc(opts PrepareTaskOptions) (Task, error) {
 		task = newTask(TaskTypeProm, opts.TaskName, taskNamesuffix, time.Duration(opts.Rule.Frequency), rules, opts.ManagerOpts, opts.NotifyFunc, opts.RuleDB)
 
 	} else {
-		return nil, fmt.Errorf("unsupported rule type %s. Supported types: %s, %s", opts.Rule.RuleType, RuleTypeProm, RuleTypeThreshold)
+		return nil, fmt.Errorf("unsupported rule type %s. Supported types: %s, %s, %s, %s", opts.Rule.RuleType, RuleTypeProm, RuleTypeThreshold, RuleTypeSLO, RuleTypeHeartbeat)
 	}
 
 	return task, nil
@@ -204,6 +410,30 @@ func defaultPrepareTaskFunc(opts PrepareTaskOptions) (Task, error) {
 func NewManager(o *ManagerOptions) (*Manager, error) {
 
 	o = defaultOptions(o)
+
+	amManager, err := am.New()
+	if err != nil {
+		return nil, err
+	}
+
+	o.evalPool = newEvalPool(o.EvalConcurrency)
+	if !o.DisableQueryResultCache {
+		o.resultCache = newQueryResultCache()
+	}
+	o.temporalityCache = newTemporalityCache(o.TemporalityCacheTTL)
+	o.costs = newRuleCostTracker()
+	o.tracer = newEvalTracer()
+	o.shadows = newShadowRules()
+	o.suppressions = newSuppressionTracker()
+	o.evalLag = newEvalLagTracker(o.EvalLagThreshold, nil)
+	if o.MaxEvalBackoff >= 0 {
+		o.backoffs = newRuleBackoffs(o.MaxEvalBackoff)
+	}
+
+	db := newRuleDB(o.DBConn, amManager, newRuleEvents(o.RuleEventWebhooks, o.RuleEvents))
+	delivery := newChannelDelivery(db)
+	o.NotifierOpts.OnSend = delivery.recordAlertmanagerSend
+
 	// here we just initiate notifier, it will be started
 	// in run()
 	notifier, err := am.NewNotifier(&o.NotifierOpts, nil)
@@ -213,19 +443,14 @@ func NewManager(o *ManagerOptions) (*Manager, error) {
 		return nil, err
 	}
 
-	amManager, err := am.New()
-	if err != nil {
-		return nil, err
-	}
-
-	db := NewRuleDB(o.DBConn, amManager)
-
 	telemetry.GetInstance().SetAlertsInfoCallback(db.GetAlertsInfo)
 
 	m := &Manager{
 		tasks:               map[string]Task{},
 		rules:               map[string]Rule{},
 		notifier:            notifier,
+		delivery:            delivery,
+		alertManager:        amManager,
 		ruleDB:              db,
 		opts:                o,
 		block:               make(chan struct{}),
@@ -236,13 +461,67 @@ func NewManager(o *ManagerOptions) (*Manager, error) {
 		prepareTaskFunc:     o.PrepareTaskFunc,
 		prepareTestRuleFunc: o.PrepareTestRuleFunc,
 	}
+	if o.ShardRules && o.LeaderElection {
+		return nil, errors.New("the rules can't be both sharded and evaluated by the leader")
+	}
+	if o.ShardRules && !o.DisableRules {
+		m.shards = newRuleShards(newShardID())
+	}
+	if o.LeaderElection && !o.DisableRules {
+		m.shards = newRuleLeader(newShardID())
+	}
+	if o.BreakerThreshold >= 0 {
+		o.breaker = newCircuitBreaker(o.BreakerThreshold, o.BreakerCooldown, m.notifyBreaker)
+	}
+	if o.EvalLagAlert {
+		o.evalLag.onChange = m.notifyEvalLag
+	}
+	if m.exporter = newStateExporter(o.StateExportWebhooks, o.StateExportKafkaBrokers, o.StateExportKafkaTopic); m.exporter != nil {
+		m.reader = &stateExportReader{Reader: o.Reader, exporter: m.exporter}
+		delivery.exporter = m.exporter
+	}
+	if o.HistoryQueueCapacity > 0 {
+		// the written entries are exported by the reader wrapped
+		m.history = newHistoryWriter(m.reader, o.HistoryQueueCapacity)
+		m.reader = &asyncHistoryReader{Reader: m.reader, writer: m.history}
+	}
 	return m, nil
 }
 
 func (m *Manager) Start() {
+	if m.shards != nil {
+		// the replica joins before loading the rules to only load its own rules
+		if err := m.heartbeatShard(context.Background()); err != nil {
+			zap.L().Error("failed to join the rule shard", zap.Error(err))
+		}
+		m.shardDone = make(chan struct{})
+		go m.shardLoop(m.shardDone)
+	}
 	if err := m.initiate(); err != nil {
 		zap.L().Error("failed to initialize alerting rules manager", zap.Error(err))
 	}
+	if m.opts.ProvisioningDir != "" {
+		if err := m.ProvisionRules(context.Background()); err != nil {
+			zap.L().Error("failed to provision the rules", zap.Error(err))
+		}
+		m.reloadSignal = make(chan os.Signal, 1)
+		signal.Notify(m.reloadSignal, syscall.SIGHUP)
+		go m.reloadProvisionedRules()
+	}
+	m.maintenanceDone = make(chan struct{})
+	go m.purgeExpiredMaintenanceLoop(m.maintenanceDone)
+	m.deliveryDone = make(chan struct{})
+	go m.delivery.retryQueueLoop(m.deliveryDone)
+	go m.delivery.purgeDeliveryLogsLoop(m.deliveryDone)
+	if m.opts.AlertSnapshotInterval > 0 {
+		m.snapshotDone = make(chan struct{})
+		go m.snapshotAlertsLoop(m.snapshotDone)
+	}
+	m.rollupDone = make(chan struct{})
+	go m.rollupStateHistoryLoop(m.rollupDone)
+	go m.routeChannelsWithoutResolved(context.Background())
+	m.exporter.start()
+	m.history.start()
 	m.run()
 }
 
@@ -270,27 +549,11 @@ func (m *Manager) initiate() error {
 
 	for _, rec := range storedRules {
 		taskName := fmt.Sprintf("%d-groupname", rec.Id)
-		parsedRule, err := ParsePostableRule([]byte(rec.Data))
-
+		parsedRule, err := parseStoredRule(rec)
 		if err != nil {
-			if errors.Is(err, ErrFailedToParseJSON) {
-				zap.L().Info("failed to load rule in json format, trying yaml now:", zap.String("name", taskName))
-
-				// see if rule is stored in yaml format
-				parsedRule, err = parsePostableRule([]byte(rec.Data), RuleDataKindYaml)
-
-				if err != nil {
-					zap.L().Error("failed to parse and initialize yaml rule", zap.String("name", taskName), zap.Error(err))
-					// just one rule is being parsed so expect just one error
-					loadErrors = append(loadErrors, err)
-					continue
-				}
-			} else {
-				zap.L().Error("failed to parse and initialize rule", zap.String("name", taskName), zap.Error(err))
-				// just one rule is being parsed so expect just one error
-				loadErrors = append(loadErrors, err)
-				continue
-			}
+			// just one rule is being parsed so expect just one error
+			loadErrors = append(loadErrors, err)
+			continue
 		}
 		if !parsedRule.Disabled {
 			err := m.addTask(parsedRule, taskName)
@@ -307,6 +570,32 @@ func (m *Manager) initiate() error {
 	return nil
 }
 
+// parseStoredRule parses the definition of the stored rule, the rules stored in
+// yaml are parsed when the definition is not json
+func parseStoredRule(rec StoredRule) (*PostableRule, error) {
+	taskName := fmt.Sprintf("%d-groupname", rec.Id)
+	parsedRule, err := ParsePostableRule([]byte(rec.Data))
+	if err != nil {
+		if !errors.Is(err, ErrFailedToParseJSON) {
+			zap.L().Error("failed to parse and initialize rule", zap.String("name", taskName), zap.Error(err))
+			return nil, err
+		}
+		zap.L().Info("failed to load rule in json format, trying yaml now:", zap.String("name", taskName))
+
+		// see if rule is stored in yaml format
+		parsedRule, err = parsePostableRule([]byte(rec.Data), RuleDataKindYaml)
+		if err != nil {
+			zap.L().Error("failed to parse and initialize yaml rule", zap.String("name", taskName), zap.Error(err))
+			return nil, err
+		}
+	}
+	if err := parsedRule.validateChange(); err != nil {
+		zap.L().Warn("stored rule fails the checks of the rule edits", zap.String("name", taskName), zap.Error(err))
+	}
+	parsedRule.OrgID = rec.orgID()
+	return parsedRule, nil
+}
+
 // Run starts processing of the rule manager.
 func (m *Manager) run() {
 	// initiate notifier
@@ -319,14 +608,48 @@ func (m *Manager) run() {
 // Stop the rule manager's rule evaluation cycles.
 func (m *Manager) Stop() {
 	m.mtx.Lock()
-	defer m.mtx.Unlock()
 
 	zap.L().Info("Stopping rule manager...")
 
+	if m.reloadSignal != nil {
+		signal.Stop(m.reloadSignal)
+		close(m.reloadSignal)
+		m.reloadSignal = nil
+	}
+
+	if m.maintenanceDone != nil {
+		close(m.maintenanceDone)
+		m.maintenanceDone = nil
+	}
+
+	if m.deliveryDone != nil {
+		close(m.deliveryDone)
+		m.deliveryDone = nil
+	}
+
+	if m.shardDone != nil {
+		close(m.shardDone)
+		m.shardDone = nil
+	}
+
+	if m.snapshotDone != nil {
+		close(m.snapshotDone)
+		m.snapshotDone = nil
+	}
+
+	if m.rollupDone != nil {
+		close(m.rollupDone)
+		m.rollupDone = nil
+	}
+
+	tasks := make([]Task, 0, len(m.tasks))
 	for _, t := range m.tasks {
-		t.Stop()
+		tasks = append(tasks, t)
 	}
+	// the evaluations finishing during the shutdown may take the lock to notify
+	m.mtx.Unlock()
 
+	m.shutdown(tasks)
 	zap.L().Info("Rule manager stopped")
 }
 
@@ -339,6 +662,9 @@ func (m *Manager) EditRule(ctx context.Context, ruleStr string, id string) error
 	if err != nil {
 		return err
 	}
+	if err := parsedRule.validateChange(); err != nil {
+		return err
+	}
 
 	taskName, _, err := m.ruleDB.EditRuleTx(ctx, ruleStr, id)
 	if err != nil {
@@ -346,6 +672,13 @@ func (m *Manager) EditRule(ctx context.Context, ruleStr string, id string) error
 	}
 
 	if !m.opts.DisableRules {
+		// the org is not part of the rule definition, keep the org of the stored rule
+		storedRule, err := m.ruleDB.GetStoredRule(ctx, id)
+		if err != nil {
+			return err
+		}
+		parsedRule.OrgID = storedRule.orgID()
+
 		err = m.syncRuleStateWithTask(taskName, parsedRule)
 		if err != nil {
 			return err
@@ -381,8 +714,29 @@ func (m *Manager) editTask(rule *PostableRule, taskName string) error {
 		return errors.New("error preparing rule with given parameters, previous rule set restored")
 	}
 
+	if !m.shards.owns(RuleIdFromTaskName(taskName)) {
+		// the rule moved to another replica
+		if oldTask, ok := m.tasks[taskName]; ok {
+			oldTask.Stop()
+			delete(m.tasks, taskName)
+			delete(m.rules, RuleIdFromTaskName(taskName))
+			forgetRuleMetrics(RuleIdFromTaskName(taskName))
+			m.opts.costs.forget(RuleIdFromTaskName(taskName))
+			m.opts.backoffs.forget(RuleIdFromTaskName(taskName))
+			m.opts.tracer.forget(RuleIdFromTaskName(taskName))
+			m.opts.evalLag.forget(RuleIdFromTaskName(taskName))
+			m.opts.shadows.forget(RuleIdFromTaskName(taskName))
+			m.opts.suppressions.forget(RuleIdFromTaskName(taskName))
+		}
+		return nil
+	}
+
 	for _, r := range newTask.Rules() {
 		m.rules[r.ID()] = r
+		// the changed rule is evaluated at its frequency again
+		m.opts.backoffs.forget(r.ID())
+		// the shadow definition was compared with the replaced one
+		m.opts.shadows.forget(r.ID())
 	}
 
 	// If there is an old task with the same identifier, stop it and wait for
@@ -418,6 +772,11 @@ func (m *Manager) DeleteRule(ctx context.Context, id string) error {
 		return fmt.Errorf("delete rule received an rule id in invalid format, must be a number")
 	}
 
+	// check the permission before the task is stopped
+	if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionDelete); err != nil {
+		return err
+	}
+
 	taskName := prepareTaskName(int64(idInt))
 	if !m.opts.DisableRules {
 		m.deleteTask(taskName)
@@ -427,10 +786,84 @@ func (m *Manager) DeleteRule(ctx context.Context, id string) error {
 		zap.L().Error("failed to delete the rule from rule db", zap.String("id", id), zap.Error(err))
 		return err
 	}
+	if err := m.ruleDB.DeleteAlertSnapshots(ctx, id); err != nil {
+		zap.L().Warn("failed to delete the snapshot of the active alerts", zap.String("id", id), zap.Error(err))
+	}
 
 	return nil
 }
 
+// ListDeletedRules returns the rules in the trash that can still be restored
+func (m *Manager) ListDeletedRules(ctx context.Context) (*GettableRules, error) {
+	m.purgeDeletedRules(ctx)
+
+	storedRules, err := m.ruleDB.GetDeletedRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]*GettableRule, 0, len(storedRules))
+	for _, s := range storedRules {
+		ruleResponse := &GettableRule{}
+		if err := json.Unmarshal([]byte(s.Data), ruleResponse); err != nil {
+			zap.L().Error("failed to unmarshal rule from db", zap.Int("id", s.Id), zap.Error(err))
+			continue
+		}
+		ruleResponse.Id = fmt.Sprintf("%d", s.Id)
+		ruleResponse.State = model.StateDisabled
+		ruleResponse.CreatedAt = s.CreatedAt
+		ruleResponse.CreatedBy = s.CreatedBy
+		ruleResponse.UpdatedAt = s.UpdatedAt
+		ruleResponse.UpdatedBy = s.UpdatedBy
+		ruleResponse.Provisioned = s.Provisioned
+		ruleResponse.DeletedAt = s.DeletedAt
+		resp = append(resp, ruleResponse)
+	}
+
+	return &GettableRules{Rules: resp}, nil
+}
+
+// RestoreRule moves the rule out of the trash and resumes its evaluation
+func (m *Manager) RestoreRule(ctx context.Context, id string) (*GettableRule, error) {
+	m.purgeDeletedRules(ctx)
+
+	taskName, _, err := m.ruleDB.RestoreRuleTx(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := m.ruleDB.GetStoredRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedRule, err := ParsePostableRule([]byte(s.Data))
+	if err != nil {
+		return nil, err
+	}
+	parsedRule.OrgID = s.orgID()
+
+	if !m.opts.DisableRules {
+		if err := m.syncRuleStateWithTask(taskName, parsedRule); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.GetRule(ctx, id)
+}
+
+// purgeDeletedRules permanently removes the rules in the trash for longer than the retention
+func (m *Manager) purgeDeletedRules(ctx context.Context) {
+	count, err := m.ruleDB.PurgeDeletedRules(ctx, time.Now().Add(-m.opts.TrashRetention))
+	if err != nil {
+		zap.L().Error("failed to purge the deleted rules", zap.Error(err))
+		return
+	}
+	if count > 0 {
+		zap.L().Info("purged the deleted rules", zap.Int64("count", count))
+	}
+}
+
 func (m *Manager) deleteTask(taskName string) {
 	m.mtx.Lock()
 	defer m.mtx.Unlock()
@@ -441,6 +874,13 @@ func (m *Manager) deleteTask(taskName string) {
 		oldg.Stop()
 		delete(m.tasks, taskName)
 		delete(m.rules, RuleIdFromTaskName(taskName))
+		forgetRuleMetrics(RuleIdFromTaskName(taskName))
+		m.opts.costs.forget(RuleIdFromTaskName(taskName))
+		m.opts.backoffs.forget(RuleIdFromTaskName(taskName))
+		m.opts.tracer.forget(RuleIdFromTaskName(taskName))
+		m.opts.evalLag.forget(RuleIdFromTaskName(taskName))
+		m.opts.shadows.forget(RuleIdFromTaskName(taskName))
+		m.opts.suppressions.forget(RuleIdFromTaskName(taskName))
 		zap.L().Debug("rule task deleted", zap.String("name", taskName))
 	} else {
 		zap.L().Info("rule not found for deletion", zap.String("name", taskName))
@@ -455,12 +895,16 @@ func (m *Manager) CreateRule(ctx context.Context, ruleStr string) (*GettableRule
 	if err != nil {
 		return nil, err
 	}
+	if err := parsedRule.validateChange(); err != nil {
+		return nil, err
+	}
 
 	lastInsertId, tx, err := m.ruleDB.CreateRuleTx(ctx, ruleStr)
 	taskName := prepareTaskName(lastInsertId)
 	if err != nil {
 		return nil, err
 	}
+	parsedRule.OrgID = contextOrgID(ctx)
 	if !m.opts.DisableRules {
 		if err := m.addTask(parsedRule, taskName); err != nil {
 			tx.Rollback()
@@ -504,6 +948,12 @@ func (m *Manager) addTask(rule *PostableRule, taskName string) error {
 		return errors.New("error loading rules, previous rule set restored")
 	}
 
+	// the rule is validated by every replica and evaluated by the replica owning it
+	if !m.shards.owns(RuleIdFromTaskName(taskName)) {
+		zap.L().Debug("the rule is evaluated by another replica", zap.String("name", taskName))
+		return nil
+	}
+
 	for _, r := range newTask.Rules() {
 		m.rules[r.ID()] = r
 	}
@@ -526,6 +976,11 @@ func (m *Manager) addTask(rule *PostableRule, taskName string) error {
 	return nil
 }
 
+// EvalPoolStats returns the queueing metrics of the rule evaluations
+func (m *Manager) EvalPoolStats() EvalPoolStats {
+	return m.opts.evalPool.stats()
+}
+
 // RuleTasks returns the list of manager's rule tasks.
 func (m *Manager) RuleTasks() []Task {
 	m.mtx.RLock()
@@ -599,33 +1054,74 @@ type NotifyFunc func(ctx context.Context, expr string, alerts ...*Alert)
 // prepareNotifyFunc implements the NotifyFunc for a Notifier.
 func (m *Manager) prepareNotifyFunc() NotifyFunc {
 	return func(ctx context.Context, expr string, alerts ...*Alert) {
-		var res []*am.Alert
+		alerts = m.sendKeepAlives(alerts)
+		if len(alerts) > 0 {
+			m.attachCharts(ctx, alerts)
+			m.send(ctx, alerts)
+			m.correlateIncidents(ctx, alerts, time.Now())
+		}
+	}
+}
 
-		for _, alert := range alerts {
-			generatorURL := alert.GeneratorURL
-			if generatorURL == "" {
-				generatorURL = m.opts.RepoURL
-			}
+// sendKeepAlives re-sends the keep-alive alerts to the alertmanager only and returns
+// the alerts to notify
+func (m *Manager) sendKeepAlives(alerts []*Alert) []*Alert {
+	notified := make([]*Alert, 0, len(alerts))
+	keepAlives := []*Alert{}
+	for _, alert := range alerts {
+		if alert.keepAlive {
+			keepAlives = append(keepAlives, alert)
+		} else {
+			notified = append(notified, alert)
+		}
+	}
+	if len(keepAlives) > 0 {
+		m.notifier.Send(m.toNotifierAlerts(keepAlives)...)
+	}
+	return notified
+}
 
-			a := &am.Alert{
-				StartsAt:     alert.FiredAt,
-				Labels:       alert.Labels,
-				Annotations:  alert.Annotations,
-				GeneratorURL: generatorURL,
-				Receivers:    alert.Receivers,
-			}
-			if !alert.ResolvedAt.IsZero() {
-				a.EndsAt = alert.ResolvedAt
-			} else {
-				a.EndsAt = alert.ValidUntil
-			}
-			res = append(res, a)
+// send sends the alerts to the alertmanager and the channels delivered by the query service
+func (m *Manager) send(ctx context.Context, alerts []*Alert) {
+	m.notifier.Send(m.toNotifierAlerts(alerts)...)
+	m.delivery.deliver(ctx, alerts)
+}
+
+// toNotifierAlerts converts the alerts of the rule to the alerts sent to the alertmanager
+func (m *Manager) toNotifierAlerts(alerts []*Alert) []*am.Alert {
+	var res []*am.Alert
+
+	var channels []string
+	allChannels := func() []string {
+		if channels == nil {
+			channels = channelNames(m.ruleDB)
 		}
+		return channels
+	}
 
-		if len(alerts) > 0 {
-			m.notifier.Send(res...)
+	for _, alert := range alerts {
+		generatorURL := alert.GeneratorURL
+		if generatorURL == "" {
+			generatorURL = m.opts.RepoURL
 		}
+
+		a := &am.Alert{
+			StartsAt:     alert.FiredAt,
+			Labels:       alert.Labels,
+			Annotations:  alert.Annotations,
+			GeneratorURL: generatorURL,
+			Receivers:    routedReceivers(alert, allChannels),
+			KeepAlive:    alert.keepAlive,
+		}
+		if !alert.ResolvedAt.IsZero() {
+			a.EndsAt = alert.ResolvedAt
+		} else {
+			a.EndsAt = alert.ValidUntil
+		}
+		res = append(res, a)
 	}
+
+	return res
 }
 
 func (m *Manager) ListActiveRules() ([]Rule, error) {
@@ -638,10 +1134,10 @@ func (m *Manager) ListActiveRules() ([]Rule, error) {
 	return ruleList, nil
 }
 
-func (m *Manager) ListRuleStates(ctx context.Context) (*GettableRules, error) {
+func (m *Manager) ListRuleStates(ctx context.Context, filter *StoredRuleFilter) (*GettableRules, error) {
 
 	// fetch rules from DB
-	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	storedRules, err := m.ruleDB.FilterStoredRules(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -670,6 +1166,7 @@ func (m *Manager) ListRuleStates(ctx context.Context) (*GettableRules, error) {
 		ruleResponse.CreatedBy = s.CreatedBy
 		ruleResponse.UpdatedAt = s.UpdatedAt
 		ruleResponse.UpdatedBy = s.UpdatedBy
+		ruleResponse.Provisioned = s.Provisioned
 		resp = append(resp, ruleResponse)
 	}
 
@@ -687,20 +1184,71 @@ func (m *Manager) GetRule(ctx context.Context, id string) (*GettableRule, error)
 	}
 	r.Id = fmt.Sprintf("%d", s.Id)
 	// fetch state of rule from memory
-	if rm, ok := m.rules[r.Id]; !ok {
+	m.mtx.RLock()
+	rm, ok := m.rules[r.Id]
+	m.mtx.RUnlock()
+	if !ok {
 		r.State = model.StateDisabled
+		r.OverallState = model.StateDisabled
 		r.Disabled = true
 	} else {
 		r.State = rm.State()
+		r.OverallState, r.SeriesStates, r.Series = ruleSeriesStates(rm)
 	}
 	r.CreatedAt = s.CreatedAt
 	r.CreatedBy = s.CreatedBy
 	r.UpdatedAt = s.UpdatedAt
 	r.UpdatedBy = s.UpdatedBy
+	r.Provisioned = s.Provisioned
 
 	return r, nil
 }
 
+// getSLO returns the slo condition of the given SLO rule
+func (m *Manager) getSLO(ctx context.Context, id string) (*SLOCondition, error) {
+	rule, err := m.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule.RuleType != RuleTypeSLO || rule.RuleCondition == nil || rule.RuleCondition.SLO == nil {
+		return nil, fmt.Errorf("rule %s is not an slo rule", id)
+	}
+	return rule.RuleCondition.SLO, nil
+}
+
+// GetErrorBudget returns the error budget of every series of the SLO rule
+// over its compliance window
+func (m *Manager) GetErrorBudget(ctx context.Context, id string) ([]model.SLOErrorBudget, error) {
+	slo, err := m.getSLO(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	budgets, err := m.reader.GetSLOErrorRatio(ctx, id, now.Add(-slo.window()).UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	for idx := range budgets {
+		slo.ErrorBudget(&budgets[idx])
+	}
+	return budgets, nil
+}
+
+// GetErrorBudgetHistory returns the burn rate of the error budget of the SLO rule over time
+func (m *Manager) GetErrorBudgetHistory(ctx context.Context, id string, params *model.QueryRuleStateHistory) (*v3.Series, error) {
+	slo, err := m.getSLO(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	series, err := m.reader.GetSLOErrorRatioByInterval(ctx, id, params)
+	if err != nil {
+		return nil, err
+	}
+	return slo.BurnRate(series), nil
+}
+
 // syncRuleStateWithTask ensures that the state of a stored rule matches
 // the task state. For example - if a stored rule is disabled, then
 // there is no task running against it.
@@ -743,6 +1291,11 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 
 	taskName := prepareTaskName(ruleId)
 
+	// check the permission before the task is synced with the patched rule
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleId, RulePermissionEdit); err != nil {
+		return nil, err
+	}
+
 	// retrieve rule from DB
 	storedJSON, err := m.ruleDB.GetStoredRule(ctx, ruleId)
 	if err != nil {
@@ -756,12 +1309,16 @@ func (m *Manager) PatchRule(ctx context.Context, ruleStr string, ruleId string)
 		zap.L().Error("failed to unmarshal stored rule with given id", zap.String("id", ruleId), zap.Error(err))
 		return nil, err
 	}
+	storedRule.OrgID = storedJSON.orgID()
 
 	// patchedRule is combo of stored rule and patch received in the request
 	patchedRule, err := parseIntoRule(storedRule, []byte(ruleStr), "json")
 	if err != nil {
 		return nil, err
 	}
+	if err := patchedRule.validateChange(); err != nil {
+		return nil, err
+	}
 
 	// deploy or un-deploy task according to patched (new) rule state
 	if err := m.syncRuleStateWithTask(taskName, patchedRule); err != nil {
@@ -829,3 +1386,128 @@ func (m *Manager) TestNotification(ctx context.Context, ruleStr string) (int, *m
 
 	return alertCount, apiErr
 }
+
+// PreviewNotification evaluates the rule once like TestNotification but returns
+// the rendered alerts that would be sent instead of sending them
+func (m *Manager) PreviewNotification(ctx context.Context, ruleStr string) ([]*am.Alert, *model.ApiError) {
+
+	parsedRule, err := ParsePostableRule([]byte(ruleStr))
+
+	if err != nil {
+		return nil, model.BadRequest(err)
+	}
+
+	// the test rule sends the alerts synchronously, collect them in place of the notifier
+	rendered := []*am.Alert{}
+	collect := func(ctx context.Context, expr string, alerts ...*Alert) {
+		rendered = append(rendered, m.toNotifierAlerts(alerts)...)
+	}
+
+	_, apiErr := m.prepareTestRuleFunc(PrepareTestRuleOptions{
+		Rule:              parsedRule,
+		RuleDB:            m.ruleDB,
+		Logger:            m.logger,
+		Reader:            m.reader,
+		Cache:             m.cache,
+		FF:                m.featureFlags,
+		ManagerOpts:       m.opts,
+		NotifyFunc:        collect,
+		UseLogsNewSchema:  m.opts.UseLogsNewSchema,
+		UseTraceNewSchema: m.opts.UseTraceNewSchema,
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return rendered, nil
+}
+
+// GetRuleVersions returns the edit history of the rule, latest first
+func (m *Manager) GetRuleVersions(ctx context.Context, id string) ([]RuleVersion, error) {
+	return m.ruleDB.GetRuleVersions(ctx, id)
+}
+
+// DiffRuleVersions returns the changes made to the rule between the two versions
+func (m *Manager) DiffRuleVersions(ctx context.Context, id string, from, to int) (*RuleVersionDiff, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionView); err != nil {
+		return nil, err
+	}
+	fromVersion, err := m.ruleDB.GetRuleVersion(ctx, id, from)
+	if err != nil {
+		return nil, fmt.Errorf("version %d not found for rule %s", from, id)
+	}
+	toVersion, err := m.ruleDB.GetRuleVersion(ctx, id, to)
+	if err != nil {
+		return nil, fmt.Errorf("version %d not found for rule %s", to, id)
+	}
+	return diffRuleVersions(fromVersion, toVersion)
+}
+
+// RollbackRule restores the definition of the given version of the rule,
+// the rollback is saved as a new version
+func (m *Manager) RollbackRule(ctx context.Context, id string, version int) (*GettableRule, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionEdit); err != nil {
+		return nil, err
+	}
+	ruleVersion, err := m.ruleDB.GetRuleVersion(ctx, id, version)
+	if err != nil {
+		return nil, fmt.Errorf("version %d not found for rule %s", version, id)
+	}
+
+	if err := m.EditRule(ctx, ruleVersion.Data, id); err != nil {
+		return nil, err
+	}
+
+	return m.GetRule(ctx, id)
+}
+
+// ListRuleFolders returns the folders of the rules
+func (m *Manager) ListRuleFolders(ctx context.Context) ([]RuleFolder, error) {
+	return m.ruleDB.GetRuleFolders(ctx)
+}
+
+// MoveRules moves the rules to the folder, the empty folder
+// moves the rules out of their folders
+func (m *Manager) MoveRules(ctx context.Context, ids []string, folder string) ([]*GettableRule, error) {
+	patch, err := json.Marshal(map[string]string{"folder": folder})
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]*GettableRule, 0, len(ids))
+	for _, id := range ids {
+		rule, err := m.PatchRule(ctx, string(patch), id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move rule %s: %w", id, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// CloneRule creates a disabled copy of the rule with the "-copy" suffix
+// in its name, the copy gets its own external id
+func (m *Manager) CloneRule(ctx context.Context, id string) (*GettableRule, error) {
+	storedRule, err := m.ruleDB.GetStoredRule(ctx, id)
+	if err != nil {
+		zap.L().Error("failed to get stored rule with given id", zap.String("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	rule, err := ParsePostableRule([]byte(storedRule.Data))
+	if err != nil {
+		zap.L().Error("failed to parse stored rule with given id", zap.String("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	rule.AlertName = rule.AlertName + "-copy"
+	rule.Disabled = true
+	rule.ExternalID = ""
+
+	ruleBytes, err := json.Marshal(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.CreateRule(ctx, string(ruleBytes))
+}
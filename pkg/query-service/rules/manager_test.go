@@ -0,0 +1,219 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestCloneRule(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	ruleStr := `{"alert":"Error rate","externalId":"terraform/error-rate","labels":{"severity":"critical"},"preferredChannels":["slack"],"condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`
+	rule, err := m.CreateRule(ctx, ruleStr)
+	assert.NoError(t, err)
+
+	clone, err := m.CloneRule(ctx, rule.Id)
+	assert.NoError(t, err)
+	assert.NotEqual(t, rule.Id, clone.Id)
+	assert.Equal(t, "Error rate-copy", clone.AlertName)
+	assert.True(t, clone.Disabled)
+	assert.Equal(t, rule.Labels, clone.Labels)
+	assert.Equal(t, []string{"slack"}, clone.PreferredChannels)
+	assert.Equal(t, rule.RuleCondition.CompositeQuery.PromQueries, clone.RuleCondition.CompositeQuery.PromQueries)
+	assert.Equal(t, *rule.RuleCondition.Target, *clone.RuleCondition.Target)
+	// the copy can't share the external id of the rule
+	assert.Empty(t, clone.ExternalID)
+
+	_, err = m.CloneRule(ctx, "100")
+	assert.Error(t, err)
+}
+
+func TestRuleUnitCheck(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	mismatched := `{"alert":"latency","condition":{"compositeQuery":{"queryType":"promql","unit":"ms","promQueries":{"A":{"query":"latency"}}},"op":"1","matchType":"1","target":90,"targetUnit":"percent"}}`
+	var unitErr *UnitMismatchError
+	_, err := m.CreateRule(ctx, mismatched)
+	assert.ErrorAs(t, err, &unitErr)
+
+	rule, err := m.CreateRule(ctx, `{"alert":"latency","condition":{"compositeQuery":{"queryType":"promql","unit":"ms","promQueries":{"A":{"query":"latency"}}},"op":"1","matchType":"1","target":90,"targetUnit":"s"}}`)
+	assert.NoError(t, err)
+	assert.ErrorAs(t, m.EditRule(ctx, mismatched, rule.Id), &unitErr)
+	_, err = m.PatchRule(ctx, `{"condition":{"compositeQuery":{"queryType":"promql","unit":"ms","promQueries":{"A":{"query":"latency"}}},"op":"1","matchType":"1","target":90,"targetUnit":"percent"}}`, rule.Id)
+	assert.ErrorAs(t, err, &unitErr)
+
+	// the rules stored before the check are still loaded
+	stored, err := parseStoredRule(StoredRule{Id: 1, Data: mismatched})
+	assert.NoError(t, err)
+	assert.Equal(t, "percent", stored.RuleCondition.TargetUnit)
+}
+
+func TestPreviewNotification(t *testing.T) {
+	m := newTestManager(t)
+	m.opts.RepoURL = "http://localhost:3301"
+	m.prepareTestRuleFunc = func(opts PrepareTestRuleOptions) (int, *model.ApiError) {
+		firedAt := time.Now()
+		opts.NotifyFunc(context.Background(), "", &Alert{
+			Labels:      labels.Labels{{Name: labels.AlertNameLabel, Value: opts.Rule.AlertName}},
+			Annotations: labels.Labels{{Name: "related_logs", Value: "http://localhost:3301/logs"}},
+			Receivers:   opts.Rule.PreferredChannels,
+			FiredAt:     firedAt,
+			ValidUntil:  firedAt.Add(4 * time.Minute),
+		})
+		return 1, nil
+	}
+
+	ruleStr := `{"alert":"Error rate","preferredChannels":["slack"],"condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`
+	alerts, apiErr := m.PreviewNotification(context.Background(), ruleStr)
+	assert.Nil(t, apiErr)
+	assert.Len(t, alerts, 1)
+	assert.Equal(t, "Error rate", alerts[0].Name())
+	assert.Equal(t, "http://localhost:3301/logs", alerts[0].Annotations.Get("related_logs"))
+	assert.Equal(t, "http://localhost:3301", alerts[0].GeneratorURL)
+	assert.Equal(t, []string{"slack"}, alerts[0].Receivers)
+	assert.Equal(t, alerts[0].StartsAt.Add(4*time.Minute), alerts[0].EndsAt)
+
+	_, apiErr = m.PreviewNotification(context.Background(), `{"alert":`)
+	assert.NotNil(t, apiErr)
+}
+
+func TestListRuleAlerts(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	ruleStr := `{"alert":"Error rate","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`
+	rule, err := m.CreateRule(ctx, ruleStr)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	m.rules[rule.Id] = &ThresholdRule{BaseRule: &BaseRule{Active: map[uint64]*Alert{
+		1: {State: model.StateFiring, Value: 10, ActiveAt: now.Add(-time.Hour), FiredAt: now, Labels: labels.Labels{{Name: "service", Value: "checkout"}}},
+		2: {State: model.StatePending, Value: 5, ActiveAt: now, Labels: labels.Labels{{Name: "service", Value: "cart"}}},
+		3: {State: model.StateFiring, Value: 20, ActiveAt: now.Add(-2 * time.Hour), FiredAt: now, Labels: labels.Labels{{Name: "service", Value: "checkout"}, {Name: "region", Value: "eu"}}},
+		4: {State: model.StateInactive, ActiveAt: now.Add(-3 * time.Hour), ResolvedAt: now, Labels: labels.Labels{{Name: "service", Value: "checkout"}}},
+	}}}
+
+	alerts, err := m.ListRuleAlerts(ctx, rule.Id, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, alerts.Total)
+	assert.Equal(t, []float64{5, 10, 20}, []float64{alerts.Alerts[0].Value, alerts.Alerts[1].Value, alerts.Alerts[2].Value})
+	assert.Nil(t, alerts.Alerts[0].FiredAt)
+	assert.NotNil(t, alerts.Alerts[1].FiredAt)
+
+	alerts, err = m.ListRuleAlerts(ctx, rule.Id, &RuleAlertsFilter{State: "firing", Labels: map[string]string{"service": "checkout"}, Limit: 1, Offset: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, alerts.Total)
+	assert.Len(t, alerts.Alerts, 1)
+	assert.Equal(t, map[string]string{"service": "checkout", "region": "eu"}, alerts.Alerts[0].Labels)
+
+	_, err = m.ListRuleAlerts(ctx, "100", nil)
+	assert.Error(t, err)
+}
+
+func TestGetRuleSeriesStates(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	rule, err := m.CreateRule(ctx, `{"alert":"Error rate","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+	assert.NoError(t, err)
+
+	// not loaded
+	got, err := m.GetRule(ctx, rule.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StateDisabled, got.OverallState)
+	assert.Empty(t, got.Series)
+
+	now := time.Now()
+	m.rules[rule.Id] = &ThresholdRule{BaseRule: &BaseRule{Active: map[uint64]*Alert{
+		1: {State: model.StatePending, ActiveAt: now, Labels: labels.Labels{{Name: "service", Value: "cart"}}},
+		2: {State: model.StateNoData, ActiveAt: now.Add(-time.Hour), FiredAt: now, Labels: labels.Labels{{Name: "service", Value: "checkout"}}},
+		3: {State: model.StateInactive, ActiveAt: now.Add(-3 * time.Hour), ResolvedAt: now, Labels: labels.Labels{{Name: "service", Value: "checkout"}}},
+	}}}
+
+	// the overall state is firing while a series has no data
+	got, err = m.GetRule(ctx, rule.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StateNoData, got.State)
+	assert.Equal(t, model.StateFiring, got.OverallState)
+	assert.Equal(t, map[string]int{"pending": 1, "nodata": 1}, got.SeriesStates)
+	if assert.Len(t, got.Series, 2) {
+		assert.Equal(t, model.StatePending, got.Series[0].State)
+		assert.Equal(t, map[string]string{"service": "checkout"}, got.Series[1].Labels)
+	}
+
+	m.rules[rule.Id] = &ThresholdRule{BaseRule: &BaseRule{Active: map[uint64]*Alert{
+		1: {State: model.StatePending, ActiveAt: now, Labels: labels.Labels{{Name: "service", Value: "cart"}}},
+	}}}
+	got, err = m.GetRule(ctx, rule.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatePending, got.State)
+	assert.Equal(t, model.StateInactive, got.OverallState)
+}
+
+func TestGetAlertSettings(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	for _, rule := range []string{
+		`{"alert":"Error rate","labels":{"severity":"critical"},"folder":"payments","preferredChannels":["slack","pagerduty"],"condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`,
+		`{"alert":"Latency","preferredChannels":["slack"],"condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"latency"}}},"op":"1","matchType":"1","target":1}}`,
+	} {
+		_, err := m.CreateRule(ctx, rule)
+		assert.NoError(t, err)
+	}
+
+	db := m.ruleDB.(*ruleDB)
+	_, err := db.Exec(`INSERT INTO notification_channels (created_at, updated_at, name, type, data) VALUES($1,$1,'slack','slack','{}'),($1,$1,'email','email','{}');`, time.Now())
+	assert.NoError(t, err)
+
+	_, err = m.ruleDB.CreatePlannedMaintenance(ctx, PlannedMaintenance{
+		Name:     "upgrade",
+		Schedule: &Schedule{Timezone: "UTC", StartTime: time.Now().Add(-time.Hour), EndTime: time.Now().Add(time.Hour)},
+		AlertIds: &AlertIds{"2"},
+	})
+	assert.NoError(t, err)
+	_, err = m.ruleDB.CreatePlannedMaintenance(ctx, PlannedMaintenance{
+		Name:     "migration",
+		Schedule: &Schedule{Timezone: "UTC", StartTime: time.Now().Add(time.Hour), EndTime: time.Now().Add(2 * time.Hour)},
+	})
+	assert.NoError(t, err)
+
+	settings, err := m.GetAlertSettings(ctx, nil)
+	assert.NoError(t, err)
+
+	assert.Len(t, settings.Rules, 2)
+	errorRate, latency := settings.Rules[0], settings.Rules[1]
+	assert.Equal(t, "Error rate", errorRate.Name)
+	assert.Equal(t, "critical", errorRate.Severity)
+	assert.Equal(t, []string{"slack", "pagerduty"}, errorRate.Channels)
+	assert.Equal(t, []int64{2}, errorRate.MaintenanceIds)
+	assert.False(t, errorRate.InMaintenance)
+	assert.Equal(t, []int64{1, 2}, latency.MaintenanceIds)
+	assert.True(t, latency.InMaintenance)
+
+	assert.Len(t, settings.Channels, 2)
+	for _, channel := range settings.Channels {
+		if channel.Name == "slack" {
+			assert.Equal(t, []string{"1", "2"}, channel.RuleIds)
+		} else {
+			assert.Empty(t, channel.RuleIds)
+		}
+	}
+
+	assert.Len(t, settings.Maintenance, 2)
+	assert.Equal(t, []string{"2"}, settings.Maintenance[0].RuleIds)
+	assert.Equal(t, []string{"1", "2"}, settings.Maintenance[1].RuleIds)
+
+	data, err := json.Marshal(settings.Maintenance[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"ruleIds":["2"]`)
+	assert.Contains(t, string(data), `"name":"upgrade"`)
+}
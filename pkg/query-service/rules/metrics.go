@@ -0,0 +1,196 @@
+package rules
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const metricsNamespace = "signoz_rules"
+
+// evalDurationBuckets spread from the quick queries to the evaluations close to the timeout
+var evalDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// responseBuckets spread from the alerts handled within a minute to the ones left for days
+var responseBuckets = []float64{60, 300, 900, 1800, 3600, 7200, 14400, 43200, 86400, 259200}
+
+var (
+	ruleEvalDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "eval_duration_seconds",
+		Help:      "The duration of the rule evaluations.",
+		Buckets:   evalDurationBuckets,
+	}, []string{"rule_id"})
+
+	ruleEvals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "evals_total",
+		Help:      "The number of rule evaluations.",
+	}, []string{"rule_id"})
+
+	ruleEvalFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "eval_failures_total",
+		Help:      "The number of failed rule evaluations.",
+	}, []string{"rule_id"})
+
+	ruleQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "query_duration_seconds",
+		Help:      "The duration of the queries of the rules.",
+		Buckets:   evalDurationBuckets,
+	}, []string{"rule_id"})
+
+	ruleAlerts = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "alerts",
+		Help:      "The number of active alerts of the rules by state.",
+	}, []string{"rule_id", "state"})
+
+	notificationsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "notifications_sent_total",
+		Help:      "The number of notification deliveries by channel type.",
+	}, []string{"channel_type"})
+
+	notificationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "notification_failures_total",
+		Help:      "The number of failed notification deliveries by channel type.",
+	}, []string{"channel_type"})
+
+	ruleEvalsMissed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "evals_missed_total",
+		Help:      "The number of rule evaluations skipped as the previous evaluation ran past them.",
+	}, []string{"rule_id"})
+
+	evalLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "eval_lag_seconds",
+		Help:      "How late the last evaluation of the rule started after its scheduled time.",
+	}, []string{"rule_id"})
+
+	evalQueueLength = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "eval_queue_length",
+		Help:      "The number of rule evaluations waiting for a worker.",
+	})
+
+	evalRunning = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "eval_running",
+		Help:      "The number of rule evaluations in progress.",
+	})
+
+	alertTimeToAck = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "alert_time_to_acknowledge_seconds",
+		Help:      "The time from when the alerts of the rules fired to their acknowledgement.",
+		Buckets:   responseBuckets,
+	}, []string{"rule_id"})
+
+	alertTimeToResolve = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "alert_time_to_resolve_seconds",
+		Help:      "The time from when the alerts of the rules fired to their resolution.",
+		Buckets:   responseBuckets,
+	}, []string{"rule_id"})
+
+	stateExported = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "state_export_events_total",
+		Help:      "The number of the alert state events exported by sink.",
+	}, []string{"sink"})
+
+	stateExportFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "state_export_failures_total",
+		Help:      "The number of the alert state events that failed to be exported by sink.",
+	}, []string{"sink"})
+
+	stateExportDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "state_export_dropped_total",
+		Help:      "The number of the alert state events dropped as the export queue was full.",
+	})
+
+	historyWriteFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "state_history_write_failures_total",
+		Help:      "The number of the failed writes of the rule state history batches.",
+	})
+
+	historyDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "state_history_dropped_total",
+		Help:      "The number of the rule state history entries dropped by reason.",
+	}, []string{"reason"})
+)
+
+// observeEval records the duration, the failure and the active alerts of the evaluation
+func observeEval(rule Rule, duration time.Duration, err error) {
+	id := rule.ID()
+	ruleEvals.WithLabelValues(id).Inc()
+	ruleEvalDuration.WithLabelValues(id).Observe(duration.Seconds())
+	if err != nil {
+		ruleEvalFailures.WithLabelValues(id).Inc()
+		return
+	}
+
+	pending, firing := 0, 0
+	for _, alert := range rule.ActiveAlerts() {
+		switch alert.State {
+		case model.StatePending:
+			pending++
+		case model.StateFiring:
+			firing++
+		}
+	}
+	ruleAlerts.WithLabelValues(id, model.StatePending.String()).Set(float64(pending))
+	ruleAlerts.WithLabelValues(id, model.StateFiring.String()).Set(float64(firing))
+}
+
+// observeResolved records how long the alerts of the rule resolved by the evaluation at ts fired
+func observeResolved(rule Rule, ts time.Time) {
+	for _, alert := range rule.ActiveAlerts() {
+		if alert.State != model.StateInactive || !alert.ResolvedAt.Equal(ts) || alert.FiredAt.IsZero() {
+			continue
+		}
+		alertTimeToResolve.WithLabelValues(rule.ID()).Observe(ts.Sub(alert.FiredAt).Seconds())
+	}
+}
+
+// observeAck records how long the firing alert of the rule took to be acknowledged
+func observeAck(ruleId string, seconds float64) {
+	alertTimeToAck.WithLabelValues(ruleId).Observe(seconds)
+}
+
+// observeQuery records the duration of the query of the rule
+func observeQuery(ruleId string, duration time.Duration) {
+	ruleQueryDuration.WithLabelValues(ruleId).Observe(duration.Seconds())
+}
+
+// observeNotification records the delivery of the notification to the channel type
+func observeNotification(channelType string, err error) {
+	notificationsSent.WithLabelValues(channelType).Inc()
+	if err != nil {
+		notificationFailures.WithLabelValues(channelType).Inc()
+	}
+}
+
+// forgetRuleMetrics drops the metrics of the rule no longer evaluated
+func forgetRuleMetrics(ruleId string) {
+	labels := prometheus.Labels{"rule_id": ruleId}
+	ruleEvalDuration.DeletePartialMatch(labels)
+	ruleEvals.DeletePartialMatch(labels)
+	ruleEvalFailures.DeletePartialMatch(labels)
+	ruleQueryDuration.DeletePartialMatch(labels)
+	ruleAlerts.DeletePartialMatch(labels)
+	evalLag.DeletePartialMatch(labels)
+	ruleEvalsMissed.DeletePartialMatch(labels)
+	alertTimeToAck.DeletePartialMatch(labels)
+	alertTimeToResolve.DeletePartialMatch(labels)
+}
@@ -0,0 +1,36 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestRuleMetrics(t *testing.T) {
+	rule := &ThresholdRule{BaseRule: &BaseRule{id: "metrics-1", Active: map[uint64]*Alert{
+		1: {State: model.StateFiring},
+		2: {State: model.StatePending},
+		3: {State: model.StateFiring},
+	}}}
+
+	observeEval(rule, time.Second, nil)
+	observeEval(rule, time.Second, errors.New("timeout"))
+	assert.Equal(t, 2.0, testutil.ToFloat64(ruleEvals.WithLabelValues("metrics-1")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(ruleEvalFailures.WithLabelValues("metrics-1")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(ruleAlerts.WithLabelValues("metrics-1", "firing")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(ruleAlerts.WithLabelValues("metrics-1", "pending")))
+
+	before := testutil.ToFloat64(notificationFailures.WithLabelValues("webhook"))
+	observeNotification("webhook", errors.New("502"))
+	observeNotification("webhook", nil)
+	assert.Equal(t, before+1, testutil.ToFloat64(notificationFailures.WithLabelValues("webhook")))
+
+	// the deleted rules don't keep their metrics
+	forgetRuleMetrics("metrics-1")
+	assert.Equal(t, 0, testutil.CollectAndCount(ruleAlerts, "signoz_rules_alerts"))
+	assert.Equal(t, 0, testutil.CollectAndCount(ruleEvals, "signoz_rules_evals_total"))
+}
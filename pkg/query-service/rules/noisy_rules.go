@@ -0,0 +1,129 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultNoisyRulesWindow is the time range the noisy rules are computed over
+	// when the window is not given
+	DefaultNoisyRulesWindow = 7 * 24 * time.Hour
+	// maxNoisyRulesWindow bounds the history scanned for the noisy rules
+	maxNoisyRulesWindow = 90 * 24 * time.Hour
+)
+
+// NoisyRuleSort is the measure the noisy rules are sorted by
+type NoisyRuleSort string
+
+const (
+	NoisyRuleSortFirings        NoisyRuleSort = "firings"
+	NoisyRuleSortFiringDuration NoisyRuleSort = "firing_duration"
+	NoisyRuleSortNotifications  NoisyRuleSort = "notifications"
+)
+
+// Validate checks the sort is known, the empty sort sorts by firings
+func (s NoisyRuleSort) Validate() error {
+	switch s {
+	case "", NoisyRuleSortFirings, NoisyRuleSortFiringDuration, NoisyRuleSortNotifications:
+		return nil
+	}
+	return fmt.Errorf("invalid sort %s, supported sorts: %s, %s, %s", s, NoisyRuleSortFirings, NoisyRuleSortFiringDuration, NoisyRuleSortNotifications)
+}
+
+// NoisyRule is how often the rule fired, for how long and how many notifications it
+// sent over the window
+type NoisyRule struct {
+	RuleID   string `json:"ruleId"`
+	RuleName string `json:"ruleName"`
+	// Firings is the number of the times the series of the rule started firing
+	Firings uint64 `json:"firings"`
+	// FiringsPerDay is the firing frequency over the window
+	FiringsPerDay float64 `json:"firingsPerDay"`
+	// Series is the number of the distinct series that fired
+	Series uint64 `json:"series"`
+	// AvgFiringDuration is how long the series fired before resolving in seconds
+	AvgFiringDuration float64 `json:"avgFiringDuration"`
+	// Notifications is the number of the notifications sent to the channels
+	Notifications int `json:"notifications"`
+}
+
+// NoisyRulesReport is the noisiest rules the user can view over the window
+type NoisyRulesReport struct {
+	Start time.Time   `json:"start"`
+	End   time.Time   `json:"end"`
+	Rules []NoisyRule `json:"rules"`
+}
+
+// NoisyRules returns the rules the user can view firing the most over the window
+// sorted by the given measure
+func (m *Manager) NoisyRules(ctx context.Context, window time.Duration, sortBy NoisyRuleSort, limit int) (*NoisyRulesReport, error) {
+	if err := sortBy.Validate(); err != nil {
+		return nil, err
+	}
+	if window <= 0 {
+		window = DefaultNoisyRulesWindow
+	}
+	end := time.Now()
+	start := end.Add(-min(window, maxNoisyRulesWindow))
+	report := &NoisyRulesReport{Start: start, End: end, Rules: []NoisyRule{}}
+
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(storedRules) == 0 {
+		return report, nil
+	}
+	ruleIds := make([]string, 0, len(storedRules))
+	for _, storedRule := range storedRules {
+		ruleIds = append(ruleIds, strconv.Itoa(storedRule.Id))
+	}
+
+	stats, err := m.reader.GetRuleFiringStats(ctx, start.UnixMilli(), end.UnixMilli(), ruleIds)
+	if err != nil {
+		return nil, err
+	}
+	notifications, err := m.ruleDB.CountNotificationsByRule(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	days := end.Sub(start).Hours() / 24
+	for _, s := range stats {
+		report.Rules = append(report.Rules, NoisyRule{
+			RuleID:            s.RuleID,
+			RuleName:          s.RuleName,
+			Firings:           s.Firings,
+			FiringsPerDay:     float64(s.Firings) / days,
+			Series:            s.Series,
+			AvgFiringDuration: s.AvgFiringDuration,
+			Notifications:     notifications[s.RuleID],
+		})
+	}
+
+	sort.SliceStable(report.Rules, func(i, j int) bool {
+		a, b := report.Rules[i], report.Rules[j]
+		switch sortBy {
+		case NoisyRuleSortFiringDuration:
+			if a.AvgFiringDuration != b.AvgFiringDuration {
+				return a.AvgFiringDuration > b.AvgFiringDuration
+			}
+		case NoisyRuleSortNotifications:
+			if a.Notifications != b.Notifications {
+				return a.Notifications > b.Notifications
+			}
+		}
+		if a.Firings != b.Firings {
+			return a.Firings > b.Firings
+		}
+		return a.RuleID < b.RuleID
+	})
+	if limit > 0 && len(report.Rules) > limit {
+		report.Rules = report.Rules[:limit]
+	}
+	return report, nil
+}
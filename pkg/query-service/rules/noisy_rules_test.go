@@ -0,0 +1,71 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// firingStatsReader returns the firing stats of the rules queried
+type firingStatsReader struct {
+	interfaces.Reader
+	stats   []model.RuleFiringStats
+	ruleIDs []string
+}
+
+func (r *firingStatsReader) GetRuleFiringStats(ctx context.Context, start, end int64, ruleIDs []string) ([]model.RuleFiringStats, error) {
+	r.ruleIDs = ruleIDs
+	return r.stats, nil
+}
+
+func TestNoisyRules(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	reader := &firingStatsReader{stats: []model.RuleFiringStats{
+		{RuleID: "1", RuleName: "Error rate", Firings: 14, Series: 2, AvgFiringDuration: 60},
+		{RuleID: "2", RuleName: "Latency", Firings: 7, Series: 7, AvgFiringDuration: 3600},
+	}}
+	m.reader = reader
+
+	for _, alert := range []string{"Error rate", "Latency"} {
+		_, err := m.CreateRule(ctx, `{"alert":"`+alert+`","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+		assert.NoError(t, err)
+	}
+	now := time.Now()
+	assert.NoError(t, m.ruleDB.RecordNotifications(ctx, []NotificationLogEntry{
+		{RuleId: "2", State: "firing", Channel: "slack", Status: DeliveryStatusSuccess, Timestamp: now.Add(-time.Hour)},
+		{RuleId: "2", State: "resolved", Channel: "slack", Status: DeliveryStatusSuccess, Timestamp: now.Add(-time.Minute)},
+		{RuleId: "1", State: "firing", Channel: "slack", Status: DeliveryStatusSuccess, Timestamp: now.Add(-time.Minute)},
+		// before the window
+		{RuleId: "1", State: "firing", Channel: "slack", Status: DeliveryStatusSuccess, Timestamp: now.Add(-30 * 24 * time.Hour)},
+	}))
+
+	report, err := m.NoisyRules(ctx, 7*24*time.Hour, "", 10)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1", "2"}, reader.ruleIDs)
+	if assert.Len(t, report.Rules, 2) {
+		assert.Equal(t, "1", report.Rules[0].RuleID)
+		assert.InDelta(t, 2.0, report.Rules[0].FiringsPerDay, 0.01)
+		assert.Equal(t, 1, report.Rules[0].Notifications)
+		assert.Equal(t, 2, report.Rules[1].Notifications)
+	}
+
+	report, err = m.NoisyRules(ctx, 7*24*time.Hour, NoisyRuleSortFiringDuration, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Rules, 1) {
+		assert.Equal(t, "2", report.Rules[0].RuleID)
+	}
+
+	report, err = m.NoisyRules(ctx, 7*24*time.Hour, NoisyRuleSortNotifications, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Rules, 2) {
+		assert.Equal(t, "2", report.Rules[0].RuleID)
+	}
+
+	_, err = m.NoisyRules(ctx, time.Hour, "volume", 10)
+	assert.Error(t, err)
+}
@@ -0,0 +1,282 @@
+package rules
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultNotificationLogLimit = 100
+	// deliveryLogRetention is how long the notifications and the delivery attempts are logged
+	deliveryLogRetention = 30 * 24 * time.Hour
+	// deliveryLogPurgeInterval is how often the logs older than the retention are purged
+	deliveryLogPurgeInterval = time.Hour
+)
+
+// NotificationLogEntry is the notification of an alert sent to a channel, the payload
+// is the body sent to the provider of the channel, or to the alertmanager when the
+// alertmanager delivers the notification
+type NotificationLogEntry struct {
+	Id          int64            `json:"id" db:"id"`
+	RuleId      string           `json:"ruleId" db:"rule_id"`
+	AlertName   string           `json:"alertName" db:"alert_name"`
+	Fingerprint string           `json:"fingerprint" db:"fingerprint"`
+	Labels      SuppressedLabels `json:"labels" db:"labels"`
+	// State is firing or resolved
+	State       string         `json:"state" db:"state"`
+	Channel     string         `json:"channel" db:"channel"`
+	ChannelType string         `json:"channelType" db:"channel_type"`
+	Status      DeliveryStatus `json:"status" db:"status"`
+	Error       string         `json:"error" db:"error"`
+	Payload     string         `json:"payload" db:"payload"`
+	Timestamp   time.Time      `json:"timestamp" db:"timestamp"`
+	// PayloadId is the payload stored once for all the notifications of the request
+	PayloadId string `json:"-" db:"payload_id"`
+}
+
+// NotificationLogFilter selects the notifications, the empty fields match all the notifications
+type NotificationLogFilter struct {
+	RuleId      string
+	Channel     string
+	Fingerprint string
+	Start       time.Time
+	End         time.Time
+	// Limit is the max number of notifications returned, latest first
+	Limit int
+}
+
+// notifiedAlert is the alert of the delivery request, it is logged with the outcome of the request
+type notifiedAlert struct {
+	RuleId      string            `json:"ruleId"`
+	AlertName   string            `json:"alertName"`
+	Fingerprint string            `json:"fingerprint"`
+	Labels      map[string]string `json:"labels"`
+	State       string            `json:"state"`
+}
+
+func notifiedAlerts(alerts []*Alert) []notifiedAlert {
+	notified := make([]notifiedAlert, 0, len(alerts))
+	for _, alert := range alerts {
+		state := "firing"
+		if !alert.ResolvedAt.IsZero() {
+			state = "resolved"
+		}
+		notified = append(notified, notifiedAlert{
+			RuleId:      alert.Labels.Get(labels.AlertRuleIdLabel),
+			AlertName:   alert.Labels.Get(labels.AlertNameLabel),
+			Fingerprint: fmt.Sprintf("%016x", alert.Labels.Hash()),
+			Labels:      labelsMap(alert.Labels),
+			State:       state,
+		})
+	}
+	return notified
+}
+
+func (r *ruleDB) RecordNotifications(ctx context.Context, entries []NotificationLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := r.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		entry.Timestamp = entry.Timestamp.UTC()
+		if entry.Payload != "" {
+			sum := sha256.Sum256([]byte(entry.Payload))
+			entry.PayloadId = hex.EncodeToString(sum[:])
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO notification_payloads (id, payload) VALUES ($1, $2)`, entry.PayloadId, entry.Payload); err != nil {
+				zap.L().Error("Error in Executing INSERT to notification_payloads", zap.Error(err))
+				return err
+			}
+			entry.Payload = ""
+		}
+		_, err := tx.NamedExec(`INSERT INTO notification_log (rule_id, alert_name, fingerprint, labels, state, channel, channel_type, status, error, payload, payload_id, timestamp)
+			VALUES (:rule_id, :alert_name, :fingerprint, :labels, :state, :channel, :channel_type, :status, :error, :payload, :payload_id, :timestamp)`, entry)
+		if err != nil {
+			zap.L().Error("Error in Executing INSERT to notification_log", zap.Error(err))
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *ruleDB) GetNotificationLog(ctx context.Context, filter *NotificationLogFilter) ([]NotificationLogEntry, error) {
+	if filter == nil {
+		filter = &NotificationLogFilter{}
+	}
+
+	q := newSelectQuery("SELECT id, rule_id, alert_name, fingerprint, labels, state, channel, channel_type, status, error, " +
+		"COALESCE((SELECT notification_payloads.payload FROM notification_payloads WHERE notification_payloads.id=notification_log.payload_id), payload) AS payload, " +
+		"timestamp FROM notification_log")
+	if filter.RuleId != "" {
+		q.where("rule_id=?", filter.RuleId)
+	}
+	if filter.Channel != "" {
+		q.where("channel=?", filter.Channel)
+	}
+	if filter.Fingerprint != "" {
+		q.where("fingerprint=?", filter.Fingerprint)
+	}
+	if !filter.Start.IsZero() {
+		q.where("timestamp>=?", filter.Start.UTC())
+	}
+	if !filter.End.IsZero() {
+		q.where("timestamp<=?", filter.End.UTC())
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultNotificationLogLimit
+	}
+	query, args := q.order("timestamp DESC, id DESC").page(limit, 0).build()
+
+	entries := []NotificationLogEntry{}
+	if err := r.Select(&entries, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *ruleDB) PurgeNotifications(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.Exec("DELETE FROM notification_log WHERE timestamp < $1", before.UTC())
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE from notification_log", zap.Error(err))
+		return 0, err
+	}
+	if _, err := r.Exec("DELETE FROM notification_payloads WHERE id NOT IN (SELECT payload_id FROM notification_log WHERE payload_id IS NOT NULL)"); err != nil {
+		zap.L().Error("Error in Executing DELETE from notification_payloads", zap.Error(err))
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// purgeDeliveryLogs deletes the logs of the deliveries older than the retention
+func (d *channelDelivery) purgeDeliveryLogs(ctx context.Context, now time.Time) {
+	count, err := d.ruleDB.PurgeNotifications(ctx, now.Add(-deliveryLogRetention))
+	if err != nil {
+		zap.L().Error("failed to purge the notification log", zap.Error(err))
+	} else if count > 0 {
+		zap.L().Info("purged the notification log", zap.Int64("count", count))
+	}
+
+	count, err = d.ruleDB.PurgeDeliveryAttempts(ctx, now.Add(-deliveryLogRetention))
+	if err != nil {
+		zap.L().Error("failed to purge the delivery attempts", zap.Error(err))
+	} else if count > 0 {
+		zap.L().Info("purged the delivery attempts", zap.Int64("count", count))
+	}
+}
+
+func (d *channelDelivery) purgeDeliveryLogsLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(deliveryLogPurgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			d.purgeDeliveryLogs(context.Background(), time.Now())
+		}
+	}
+}
+
+func (r *ruleDB) CountNotificationsByRule(ctx context.Context, start, end time.Time) (map[string]int, error) {
+	rows := []struct {
+		RuleId string `db:"rule_id"`
+		Count  int    `db:"count"`
+	}{}
+	err := r.Select(&rows, `SELECT rule_id, COUNT(*) AS count FROM notification_log
+		WHERE timestamp>=$1 AND timestamp<$2 GROUP BY rule_id`, start.UTC(), end.UTC())
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.RuleId] = row.Count
+	}
+	return counts, nil
+}
+
+// logNotification logs the alerts of the request with the outcome of the delivery
+func (d *channelDelivery) logNotification(ctx context.Context, r *deliveryRequest, err error) {
+	entries := make([]NotificationLogEntry, 0, len(r.Alerts))
+	for _, alert := range r.Alerts {
+		entry := NotificationLogEntry{
+			RuleId:      alert.RuleId,
+			AlertName:   alert.AlertName,
+			Fingerprint: alert.Fingerprint,
+			Labels:      alert.Labels,
+			State:       alert.State,
+			Channel:     r.Channel,
+			ChannelType: r.ChannelType,
+			Status:      DeliveryStatusSuccess,
+			Payload:     string(r.Body),
+			Timestamp:   time.Now(),
+		}
+		if err != nil {
+			entry.Status, entry.Error = DeliveryStatusFailed, err.Error()
+		}
+		entries = append(entries, entry)
+	}
+	if logErr := d.ruleDB.RecordNotifications(ctx, entries); logErr != nil {
+		zap.L().Error("failed to log the notifications", zap.String("channel", r.Channel), zap.Error(logErr))
+	}
+	d.exporter.exportNotifications(entries)
+}
+
+// alertmanagerNotifications returns the notifications of the alerts sent to the alertmanager,
+// one for every preferred channel of the alert or one for the default route without them,
+// the keep-alives are not notifications
+func alertmanagerNotifications(alerts []*am.Alert, now time.Time, err error) []NotificationLogEntry {
+	var entries []NotificationLogEntry
+	for _, alert := range alerts {
+		if alert.KeepAlive {
+			continue
+		}
+		payload, marshalErr := json.Marshal(alert)
+		if marshalErr != nil {
+			continue
+		}
+		state := "firing"
+		if !alert.EndsAt.IsZero() && !alert.EndsAt.After(now) {
+			state = "resolved"
+		}
+		channels := alert.Receivers
+		if len(channels) == 0 {
+			channels = []string{"alertmanager"}
+		}
+		for _, channel := range channels {
+			entry := NotificationLogEntry{
+				RuleId:      alert.Labels.Get(labels.AlertRuleIdLabel),
+				AlertName:   alert.Name(),
+				Fingerprint: fmt.Sprintf("%016x", alert.Hash()),
+				Labels:      labelsMap(alert.Labels),
+				State:       state,
+				Channel:     channel,
+				ChannelType: "alertmanager",
+				Status:      DeliveryStatusSuccess,
+				Payload:     string(payload),
+				Timestamp:   now,
+			}
+			if err != nil {
+				entry.Status, entry.Error = DeliveryStatusFailed, err.Error()
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -0,0 +1,98 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestNotificationLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rejected" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	db := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil).(*ruleDB)
+	delivery := newChannelDelivery(db)
+	ctx := context.Background()
+
+	firing := &Alert{Labels: labels.FromMap(map[string]string{labels.AlertNameLabel: "Errors", labels.AlertRuleIdLabel: "1", "service": "cart"})}
+	resolved := &Alert{Labels: labels.FromMap(map[string]string{labels.AlertNameLabel: "Latency", labels.AlertRuleIdLabel: "2"}), ResolvedAt: time.Now()}
+
+	assert.NoError(t, delivery.send(ctx, &deliveryRequest{Channel: "oncall", ChannelType: "webhook", URL: server.URL, Body: []byte(`{"alerts":2}`), Alerts: notifiedAlerts([]*Alert{firing, resolved})}))
+	assert.Error(t, delivery.send(ctx, &deliveryRequest{Channel: "team", ChannelType: "webhook", URL: server.URL + "/rejected", Body: []byte(`{"alerts":1}`), Alerts: notifiedAlerts([]*Alert{firing})}))
+
+	entries, err := db.GetNotificationLog(ctx, &NotificationLogFilter{RuleId: "1"})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "team", entries[0].Channel)
+		assert.Equal(t, DeliveryStatusFailed, entries[0].Status)
+		assert.Equal(t, "oncall", entries[1].Channel)
+		assert.Equal(t, DeliveryStatusSuccess, entries[1].Status)
+		assert.Equal(t, "Errors", entries[1].AlertName)
+		assert.Equal(t, "firing", entries[1].State)
+		assert.Equal(t, "cart", entries[1].Labels["service"])
+		assert.Equal(t, `{"alerts":2}`, entries[1].Payload)
+	}
+
+	entries, err = db.GetNotificationLog(ctx, &NotificationLogFilter{Channel: "oncall", RuleId: "2"})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "resolved", entries[0].State)
+	}
+
+	entries, err = db.GetNotificationLog(ctx, &NotificationLogFilter{Start: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// the body of the request is stored once for all its notifications
+	var payloads int
+	assert.NoError(t, db.Get(&payloads, "SELECT count(*) FROM notification_payloads"))
+	assert.Equal(t, 2, payloads)
+
+	attempts, err := db.GetDeliveryAttempts(ctx, nil)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, attempts)
+
+	// the notifications older than the retention are purged with their payloads
+	// and the delivery attempts
+	delivery.purgeDeliveryLogs(ctx, time.Now().Add(deliveryLogRetention+time.Minute))
+	attempts, err = db.GetDeliveryAttempts(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, attempts)
+	entries, err = db.GetNotificationLog(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.NoError(t, db.Get(&payloads, "SELECT count(*) FROM notification_payloads"))
+	assert.Zero(t, payloads)
+}
+
+func TestAlertmanagerNotifications(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	alerts := []*am.Alert{
+		{Labels: labels.FromMap(map[string]string{labels.AlertNameLabel: "Errors", labels.AlertRuleIdLabel: "1"}), EndsAt: now.Add(time.Hour), Receivers: []string{"slack", "email"}},
+		{Labels: labels.FromMap(map[string]string{labels.AlertNameLabel: "Latency", labels.AlertRuleIdLabel: "2"}), EndsAt: now.Add(-time.Minute)},
+		// the keep-alives are not notifications
+		{Labels: labels.FromMap(map[string]string{labels.AlertNameLabel: "Saturation", labels.AlertRuleIdLabel: "3"}), EndsAt: now.Add(time.Hour), KeepAlive: true},
+	}
+
+	entries := alertmanagerNotifications(alerts, now, errors.New("bad response status 503"))
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, []string{"slack", "email", "alertmanager"}, []string{entries[0].Channel, entries[1].Channel, entries[2].Channel})
+		assert.Equal(t, "firing", entries[0].State)
+		assert.Equal(t, "resolved", entries[2].State)
+		assert.Equal(t, "2", entries[2].RuleId)
+		assert.Equal(t, DeliveryStatusFailed, entries[2].Status)
+		assert.Contains(t, entries[0].Payload, `"receivers":["slack","email"]`)
+	}
+}
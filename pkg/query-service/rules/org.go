@@ -0,0 +1,78 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.uber.org/zap"
+)
+
+// The rules and maintenance are owned by the org of the user creating them,
+// the users only see the rows of their org. The rows created before the orgs
+// were tracked are given the org of their creator when migrated, the rows left
+// without an org, e.g created by the query service itself, are shared by all
+// the orgs and only the admins change them.
+
+// contextOrgID is the org of the user making the request, empty for the
+// requests made by the query service itself
+func contextOrgID(ctx context.Context) string {
+	if user := common.GetUserFromContext(ctx); user != nil {
+		return user.OrgId
+	}
+	return ""
+}
+
+// whereOrg limits the query on the table to the rows visible to the org of the user
+func whereOrg(ctx context.Context, q *selectQuery, table string) {
+	if orgID := contextOrgID(ctx); orgID != "" {
+		q.where(fmt.Sprintf("(COALESCE(%[1]s.org_id, '')='' OR %[1]s.org_id=?)", table), orgID)
+	}
+}
+
+// checkRuleOrg returns a not found error if the rule belongs to an org other than the org of the user
+func (r *ruleDB) checkRuleOrg(ctx context.Context, id int) error {
+	orgID := contextOrgID(ctx)
+	if orgID == "" {
+		return nil
+	}
+
+	var count int
+	query := "SELECT count(*) FROM rules WHERE id=$1 AND COALESCE(org_id, '')!='' AND org_id!=$2"
+	if err := r.Get(&count, query, id, orgID); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("rule %d not found: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// checkSharedRule returns a permission error if the rule is shared by all the orgs
+// and the user of an org is not an admin
+func (r *ruleDB) checkSharedRule(ctx context.Context, id int, permission RulePermissionType) error {
+	user := common.GetUserFromContext(ctx)
+	if user == nil || user.OrgId == "" || auth.IsAdmin(user) {
+		return nil
+	}
+
+	var count int
+	query := "SELECT count(*) FROM rules WHERE id=$1 AND COALESCE(org_id, '')=''"
+	if err := r.Get(&count, query, id); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	if count > 0 {
+		return &RulePermissionError{RuleId: strconv.Itoa(id), Permission: permission}
+	}
+	return nil
+}
+
+// matchesOrg reports whether the maintenance applies to the rules of the org
+func (m *PlannedMaintenance) matchesOrg(orgID string) bool {
+	return m.OrgID == "" || m.OrgID == orgID
+}
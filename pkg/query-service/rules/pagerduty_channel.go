@@ -0,0 +1,219 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+const (
+	pagerdutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	// pagerdutySummaryLength is the max length of the summary of the event
+	pagerdutySummaryLength = 1024
+)
+
+var ErrMissingRoutingKey = errors.New("pagerduty channel must have a routing key")
+
+// pagerdutySeverities maps the severity label of the alert to the severity of the event
+var pagerdutySeverities = map[string]string{
+	"critical": "critical",
+	"page":     "critical",
+	"error":    "error",
+	"high":     "error",
+	"warning":  "warning",
+	"warn":     "warning",
+	"medium":   "warning",
+	"info":     "info",
+	"low":      "info",
+}
+
+// PagerdutyV2Config sends the alerts to the Events API v2 of PagerDuty, every
+// alert is an event deduplicated by the hash of its labels
+type PagerdutyV2Config struct {
+	RoutingKey string `json:"routing_key"`
+	// URL is the events endpoint, the PagerDuty endpoint by default
+	URL string `json:"url,omitempty"`
+	// Source is the source of the events, SigNoz by default
+	Source string `json:"source,omitempty"`
+	// Severity is the severity of the alerts without a known severity label, error by default
+	Severity string `json:"severity,omitempty"`
+	// SendResolved sends the resolve events, true by default
+	SendResolved *bool `json:"send_resolved,omitempty"`
+}
+
+type pagerdutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerdutyPayload `json:"payload,omitempty"`
+	Client      string            `json:"client,omitempty"`
+	ClientURL   string            `json:"client_url,omitempty"`
+	Links       []pagerdutyLink   `json:"links,omitempty"`
+}
+
+type pagerdutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type pagerdutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// pagerdutyDedupKey is the key PagerDuty deduplicates the events of the alert by
+func pagerdutyDedupKey(alert *Alert) string {
+	return fmt.Sprintf("%016x", alert.Labels.Hash())
+}
+
+// pagerdutySeverity maps the severity label of the alert to the severity of the event
+func pagerdutySeverity(alert *Alert, fallback string) string {
+	if severity, ok := pagerdutySeverities[strings.ToLower(alert.Labels.Get("severity"))]; ok {
+		return severity
+	}
+	if fallback != "" {
+		return fallback
+	}
+	return "error"
+}
+
+type pagerdutyV2Sender struct{}
+
+func (pagerdutyV2Sender) configured(receiver *am.Receiver) bool {
+	return receiver.PagerdutyV2Configs != nil
+}
+
+func (pagerdutyV2Sender) configs(receiver *am.Receiver) ([]PagerdutyV2Config, error) {
+	var configs []PagerdutyV2Config
+	if err := decodeConfigs(receiver.PagerdutyV2Configs, &configs); err != nil {
+		return nil, fmt.Errorf("invalid pagerduty configs: %w", err)
+	}
+	return configs, nil
+}
+
+func (s pagerdutyV2Sender) validate(receiver *am.Receiver) error {
+	configs, err := s.configs(receiver)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if config.RoutingKey == "" {
+			return ErrMissingRoutingKey
+		}
+		if config.Severity != "" && pagerdutySeverities[config.Severity] != config.Severity {
+			return fmt.Errorf("invalid pagerduty severity %s, must be critical, error, warning or info", config.Severity)
+		}
+	}
+	return nil
+}
+
+// event returns the event of the alert, nil when the resolved alert is not sent
+func (config *PagerdutyV2Config) event(channel *deliveredChannel, alert *Alert) (*pagerdutyEvent, error) {
+	event := &pagerdutyEvent{
+		RoutingKey:  config.RoutingKey,
+		EventAction: "trigger",
+		DedupKey:    pagerdutyDedupKey(alert),
+	}
+	if !alert.ResolvedAt.IsZero() {
+		if config.SendResolved != nil && !*config.SendResolved {
+			return nil, nil
+		}
+		// the resolve event only needs the dedup key
+		event.EventAction = "resolve"
+		return event, nil
+	}
+
+	annotations := labelsMap(alert.Annotations)
+	summary := annotations[labels.AlertSummaryLabel]
+	if summary == "" {
+		summary = alert.Labels.Get(labels.AlertNameLabel)
+	}
+	details := map[string]interface{}{
+		"labels":      labelsMap(alert.Labels),
+		"annotations": annotations,
+	}
+	rendered, err := channel.renderAlertTemplate(alert, ChannelTemplateText)
+	if err != nil {
+		return nil, err
+	}
+	if rendered != nil {
+		summary = rendered.Title
+		if rendered.Body != "" {
+			details["message"] = rendered.Body
+		}
+	}
+	if len(summary) > pagerdutySummaryLength {
+		summary = summary[:pagerdutySummaryLength]
+	}
+
+	source := config.Source
+	if source == "" {
+		source = "SigNoz"
+	}
+	event.Payload = &pagerdutyPayload{
+		Summary:       summary,
+		Source:        source,
+		Severity:      pagerdutySeverity(alert, config.Severity),
+		Timestamp:     alert.FiredAt.UTC().Format(time.RFC3339),
+		CustomDetails: details,
+	}
+	event.Client = "SigNoz"
+	if alert.GeneratorURL != "" {
+		event.ClientURL = alert.GeneratorURL
+		event.Links = []pagerdutyLink{{Href: alert.GeneratorURL, Text: "View the alert in SigNoz"}}
+	}
+	return event, nil
+}
+
+func (s pagerdutyV2Sender) send(ctx context.Context, d *channelDelivery, channel *deliveredChannel, alerts []*Alert) error {
+	configs, err := s.configs(channel.receiver)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range configs {
+		config := &configs[i]
+		url := config.URL
+		if url == "" {
+			url = pagerdutyEventsURL
+		}
+		for _, alert := range alerts {
+			event, err := config.event(channel, alert)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if event == nil {
+				continue
+			}
+			body, err := json.Marshal(event)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			err = d.send(ctx, &deliveryRequest{
+				Channel:      channel.name,
+				ChannelType:  "pagerduty_v2",
+				URL:          url,
+				Body:         body,
+				Fingerprints: []string{event.DedupKey},
+				Alerts:       notifiedAlerts([]*Alert{alert}),
+				Retries:      defaultDeliveryRetries,
+			})
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,100 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestPagerdutyEvent(t *testing.T) {
+	firing := &Alert{
+		Labels:       labels.FromMap(map[string]string{"alertname": "High latency", "severity": "Warning", "service": "cart"}),
+		Annotations:  labels.FromMap(map[string]string{"summary": "The p99 latency of cart is 2s"}),
+		FiredAt:      time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		GeneratorURL: "https://signoz.example.com/alerts/edit?ruleId=1",
+	}
+	channel := &deliveredChannel{name: "pagerduty"}
+	config := &PagerdutyV2Config{RoutingKey: "key"}
+
+	event, err := config.event(channel, firing)
+	assert.NoError(t, err)
+	assert.Equal(t, "trigger", event.EventAction)
+	assert.Equal(t, pagerdutyDedupKey(firing), event.DedupKey)
+	assert.Equal(t, "The p99 latency of cart is 2s", event.Payload.Summary)
+	assert.Equal(t, "warning", event.Payload.Severity)
+	assert.Equal(t, "SigNoz", event.Payload.Source)
+	assert.Equal(t, "2024-05-01T10:00:00Z", event.Payload.Timestamp)
+	assert.Equal(t, firing.GeneratorURL, event.Links[0].Href)
+
+	// the template of the channel renders the summary
+	channel.template = &ChannelTemplate{Title: "{{ .CommonLabels.service }} is slow", Body: "{{ .CommonAnnotations.summary }}"}
+	event, err = config.event(channel, firing)
+	assert.NoError(t, err)
+	assert.Equal(t, "cart is slow", event.Payload.Summary)
+	assert.Equal(t, "The p99 latency of cart is 2s", event.Payload.CustomDetails["message"])
+
+	resolved := *firing
+	resolved.ResolvedAt = firing.FiredAt.Add(time.Hour)
+	event, err = config.event(channel, &resolved)
+	assert.NoError(t, err)
+	assert.Equal(t, &pagerdutyEvent{RoutingKey: "key", EventAction: "resolve", DedupKey: pagerdutyDedupKey(firing)}, event)
+
+	sendResolved := false
+	config.SendResolved = &sendResolved
+	event, err = config.event(channel, &resolved)
+	assert.NoError(t, err)
+	assert.Nil(t, event)
+
+	assert.Equal(t, "error", pagerdutySeverity(&Alert{Labels: labels.FromMap(map[string]string{"severity": "unknown"})}, ""))
+	assert.Equal(t, "info", pagerdutySeverity(&Alert{Labels: labels.FromMap(map[string]string{})}, "info"))
+}
+
+func TestPagerdutyValidate(t *testing.T) {
+	assert.Nil(t, validateDeliveredConfigs(&am.Receiver{Name: "pd", PagerdutyV2Configs: []interface{}{map[string]interface{}{"routing_key": "key"}}}))
+	assert.ErrorIs(t, validateDeliveredConfigs(&am.Receiver{Name: "pd", PagerdutyV2Configs: []interface{}{map[string]interface{}{}}}).Err, ErrMissingRoutingKey)
+	assert.NotNil(t, validateDeliveredConfigs(&am.Receiver{Name: "pd", PagerdutyV2Configs: []interface{}{map[string]interface{}{"routing_key": "key", "severity": "fatal"}}}))
+	assert.Nil(t, validateDeliveredConfigs(&am.Receiver{Name: "slack", SlackConfigs: []interface{}{}}))
+}
+
+func TestChannelDelivery(t *testing.T) {
+	events := make(chan pagerdutyEvent, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerdutyEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events <- event
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	db := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil).(*ruleDB)
+	receiver, _ := json.Marshal(am.Receiver{Name: "pagerduty", PagerdutyV2Configs: []interface{}{map[string]interface{}{"routing_key": "key", "url": server.URL}}})
+	_, err := db.Exec(`INSERT INTO notification_channels (created_at, updated_at, name, type, data) VALUES($1,$1,'pagerduty','pagerduty_v2',$2),($1,$1,'slack','slack','{"name":"slack"}');`, time.Now(), string(receiver))
+	assert.NoError(t, err)
+
+	delivery := newChannelDelivery(db)
+	critical := &Alert{Labels: labels.FromMap(map[string]string{"alertname": "Errors", "severity": "critical"})}
+	slackOnly := &Alert{Labels: labels.FromMap(map[string]string{"alertname": "Latency"}), Receivers: []string{"slack"}}
+	delivery.deliver(context.Background(), []*Alert{critical, slackOnly})
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "key", event.RoutingKey)
+		assert.Equal(t, pagerdutyDedupKey(critical), event.DedupKey)
+		assert.Equal(t, "critical", event.Payload.Severity)
+	case <-time.After(time.Second):
+		t.Fatal("the alert was not delivered")
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("the alert of the other channel was delivered: %v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,378 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	promModel "github.com/prometheus/common/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// PrometheusExportResult reports the export of a rule, the rules with an
+// error are not translatable to PromQL and are left out of the groups
+type PrometheusExportResult struct {
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Group    string   `json:"group,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// PrometheusExport is the Prometheus rule file of the exported rules
+type PrometheusExport struct {
+	Groups  string                   `json:"groups"`
+	Results []PrometheusExportResult `json:"results"`
+}
+
+const defaultPrometheusExportGroup = "signoz"
+
+var promqlCompareOpSymbols = map[CompareOp]string{
+	ValueIsAbove:   ">",
+	ValueIsBelow:   "<",
+	ValueIsEq:      "==",
+	ValueIsNotEq:   "!=",
+	ValueAboveOrEq: ">=",
+	ValueBelowOrEq: "<=",
+}
+
+var promqlTimeAggregations = map[v3.TimeAggregation]string{
+	v3.TimeAggregationRate:     "rate",
+	v3.TimeAggregationIncrease: "increase",
+	v3.TimeAggregationSum:      "sum_over_time",
+	v3.TimeAggregationAvg:      "avg_over_time",
+	v3.TimeAggregationMin:      "min_over_time",
+	v3.TimeAggregationMax:      "max_over_time",
+	v3.TimeAggregationCount:    "count_over_time",
+	v3.TimeAggregationAnyLast:  "last_over_time",
+}
+
+var promqlPercentiles = map[v3.SpaceAggregation]float64{
+	v3.SpaceAggregationPercentile50: 0.5,
+	v3.SpaceAggregationPercentile75: 0.75,
+	v3.SpaceAggregationPercentile90: 0.9,
+	v3.SpaceAggregationPercentile95: 0.95,
+	v3.SpaceAggregationPercentile99: 0.99,
+}
+
+var (
+	invalidPromLabelChars  = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+	invalidPromMetricChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+)
+
+// ExportPrometheusRules renders the rules matching the filter as Prometheus
+// rule groups, one group per folder and frequency
+func (m *Manager) ExportPrometheusRules(ctx context.Context, filter *StoredRuleFilter) (*PrometheusExport, error) {
+	storedRules, err := m.ruleDB.FilterStoredRules(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string]*prometheusRuleGroup{}
+	results := make([]PrometheusExportResult, 0, len(storedRules))
+	for _, s := range storedRules {
+		// the defaults of the eval window and frequency are not stored
+		rule, err := ParsePostableRule([]byte(s.Data))
+		if err != nil {
+			zap.L().Error("failed to parse rule from db", zap.Int("id", s.Id), zap.Error(err))
+			continue
+		}
+
+		result := PrometheusExportResult{Id: fmt.Sprintf("%d", s.Id), Name: rule.AlertName}
+		promRule, warnings, err := convertToPrometheusRule(rule)
+		result.Warnings = warnings
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		result.Group = prometheusExportGroup(rule)
+		group, ok := groups[result.Group]
+		if !ok {
+			group = &prometheusRuleGroup{Name: result.Group, Interval: promModel.Duration(rule.Frequency)}
+			groups[result.Group] = group
+		}
+		group.Rules = append(group.Rules, promRule)
+		results = append(results, result)
+	}
+
+	export := prometheusRuleGroups{Groups: make([]prometheusRuleGroup, 0, len(groups))}
+	for _, group := range groups {
+		export.Groups = append(export.Groups, *group)
+	}
+	sort.Slice(export.Groups, func(i, j int) bool { return export.Groups[i].Name < export.Groups[j].Name })
+
+	content, err := yaml.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+	return &PrometheusExport{Groups: string(content), Results: results}, nil
+}
+
+// prometheusExportGroup is the folder of the rule, the rules of the folder
+// that are not evaluated every minute are grouped by their frequency
+func prometheusExportGroup(rule *PostableRule) string {
+	group := rule.Folder
+	if group == "" {
+		group = defaultPrometheusExportGroup
+	}
+	if frequency := time.Duration(rule.Frequency); frequency != time.Minute {
+		group = fmt.Sprintf("%s-%s", group, promModel.Duration(frequency))
+	}
+	return group
+}
+
+// convertToPrometheusRule translates the rule to a Prometheus alerting rule, the
+// warnings list the settings that are dropped or approximated in the translation
+func convertToPrometheusRule(rule *PostableRule) (prometheusRule, []string, error) {
+	var warnings []string
+	if rule.Disabled {
+		return prometheusRule{}, nil, fmt.Errorf("the rule is disabled")
+	}
+	switch rule.RuleType {
+	case RuleTypeAnomaly, RuleTypeSLO, RuleTypeHeartbeat:
+		return prometheusRule{}, nil, fmt.Errorf("%s rules are not translatable", rule.RuleType)
+	}
+
+	rc := rule.RuleCondition
+	if rc == nil || rc.CompositeQuery == nil || rc.Target == nil {
+		return prometheusRule{}, nil, fmt.Errorf("the rule has no condition")
+	}
+
+	query, queryWarnings, err := promQLFromCompositeQuery(rc)
+	warnings = append(warnings, queryWarnings...)
+	if err != nil {
+		return prometheusRule{}, warnings, err
+	}
+
+	op, ok := promqlCompareOpSymbols[rc.CompareOp]
+	if !ok {
+		return prometheusRule{}, warnings, fmt.Errorf("the compare op %s is not translatable", rc.CompareOp)
+	}
+
+	window := promModel.Duration(rule.EvalWindow)
+	promRule := prometheusRule{
+		Alert:       rule.AlertName,
+		Labels:      rule.Labels,
+		Annotations: rule.Annotations,
+	}
+
+	// the match types other than the latest value are evaluated over the
+	// eval window, as a for duration or as a subquery
+	switch rc.MatchType {
+	case Last:
+		promRule.Expr = fmt.Sprintf("%s %s %v", query, op, *rc.Target)
+	case AllTheTimes:
+		promRule.Expr = fmt.Sprintf("%s %s %v", query, op, *rc.Target)
+		promRule.For = window
+	case AtleastOnce:
+		overTime := "max_over_time"
+		switch rc.CompareOp {
+		case ValueIsBelow, ValueBelowOrEq:
+			overTime = "min_over_time"
+		case ValueIsEq, ValueIsNotEq:
+			return prometheusRule{}, warnings, fmt.Errorf("at least once with the compare op %s is not translatable", op)
+		}
+		promRule.Expr = fmt.Sprintf("%s((%s)[%s:]) %s %v", overTime, query, window, op, *rc.Target)
+	case OnAverage:
+		promRule.Expr = fmt.Sprintf("avg_over_time((%s)[%s:]) %s %v", query, window, op, *rc.Target)
+	case InTotal:
+		promRule.Expr = fmt.Sprintf("sum_over_time((%s)[%s:]) %s %v", query, window, op, *rc.Target)
+	default:
+		return prometheusRule{}, warnings, fmt.Errorf("the match type %s is not translatable", rc.MatchType)
+	}
+
+	if rc.TargetUnit != "" {
+		warnings = append(warnings, fmt.Sprintf("the target is in %s and is not converted to the unit of the query", rc.TargetUnit))
+	}
+	if rc.AlertOnAbsent {
+		warnings = append(warnings, "alerting on absent data is dropped, add an absent() rule")
+	}
+	if rc.RequireMinPoints {
+		warnings = append(warnings, "the required number of points is dropped")
+	}
+	if rc.BreachingSeriesThreshold > 0 {
+		warnings = append(warnings, "the breaching series threshold is dropped")
+	}
+	if rule.EvalDelay != 0 {
+		warnings = append(warnings, "the eval delay is dropped, set query_offset on the group")
+	}
+	if rule.ActiveSchedule != nil {
+		warnings = append(warnings, "the active schedule is dropped")
+	}
+
+	return promRule, warnings, nil
+}
+
+// promQLFromCompositeQuery returns the PromQL of the selected query of the condition
+func promQLFromCompositeQuery(rc *RuleCondition) (string, []string, error) {
+	switch rc.QueryType() {
+	case v3.QueryTypePromQL:
+		// the selected query name only covers the builder and clickhouse queries
+		name := rc.SelectedQuery
+		if name == "" && len(rc.CompositeQuery.PromQueries) == 1 {
+			for queryName := range rc.CompositeQuery.PromQueries {
+				name = queryName
+			}
+		}
+		query, ok := rc.CompositeQuery.PromQueries[name]
+		if !ok {
+			return "", nil, fmt.Errorf("the selected query %s is not found", name)
+		}
+		return query.Query, nil, nil
+	case v3.QueryTypeBuilder:
+		name := rc.GetSelectedQueryName()
+		query, ok := rc.CompositeQuery.BuilderQueries[name]
+		if !ok {
+			return "", nil, fmt.Errorf("the selected query %s is not found", name)
+		}
+		return promQLFromBuilderQuery(query)
+	}
+	return "", nil, fmt.Errorf("%s queries are not translatable", rc.QueryType())
+}
+
+// promQLFromBuilderQuery translates the metrics builder query, the time aggregation
+// over the step interval is applied first and then the space aggregation
+func promQLFromBuilderQuery(q *v3.BuilderQuery) (string, []string, error) {
+	var warnings []string
+	if q.Expression != "" && q.Expression != q.QueryName {
+		return "", nil, fmt.Errorf("formulas are not translatable")
+	}
+	if q.DataSource != v3.DataSourceMetrics {
+		return "", nil, fmt.Errorf("%s queries are not translatable", q.DataSource)
+	}
+	if len(q.Functions) > 0 {
+		return "", nil, fmt.Errorf("query functions are not translatable")
+	}
+	if len(q.Having) > 0 {
+		return "", nil, fmt.Errorf("having is not translatable")
+	}
+	if q.Limit > 0 {
+		warnings = append(warnings, "the series limit is dropped")
+	}
+
+	metric := invalidPromMetricChars.ReplaceAllString(q.AggregateAttribute.Key, "_")
+	if metric != q.AggregateAttribute.Key {
+		warnings = append(warnings, fmt.Sprintf("the metric %s is exported as %s", q.AggregateAttribute.Key, metric))
+	}
+	percentile, isPercentile := promqlPercentiles[q.SpaceAggregation]
+	if isPercentile && !strings.HasSuffix(metric, "_bucket") {
+		metric += "_bucket"
+	}
+
+	selector, selectorWarnings, err := promQLSelector(metric, q.Filters)
+	warnings = append(warnings, selectorWarnings...)
+	if err != nil {
+		return "", warnings, err
+	}
+
+	step := time.Duration(q.StepInterval) * time.Second
+	if step < time.Minute {
+		step = time.Minute
+	}
+	timeAggregation, ok := promqlTimeAggregations[q.TimeAggregation]
+	if !ok {
+		return "", warnings, fmt.Errorf("the time aggregation %s is not translatable", q.TimeAggregation)
+	}
+	query := fmt.Sprintf("%s(%s[%s])", timeAggregation, selector, promModel.Duration(step))
+
+	groupBy := make([]string, 0, len(q.GroupBy)+1)
+	for _, key := range q.GroupBy {
+		label, labelWarning := promLabelName(key.Key)
+		if labelWarning != "" {
+			warnings = append(warnings, labelWarning)
+		}
+		groupBy = append(groupBy, label)
+	}
+
+	if isPercentile {
+		groupBy = append(groupBy, "le")
+		return fmt.Sprintf("histogram_quantile(%v, sum by (%s) (%s))", percentile, strings.Join(groupBy, ", "), query), warnings, nil
+	}
+	switch q.SpaceAggregation {
+	case v3.SpaceAggregationSum, v3.SpaceAggregationAvg, v3.SpaceAggregationMin, v3.SpaceAggregationMax, v3.SpaceAggregationCount:
+		if len(groupBy) == 0 {
+			return fmt.Sprintf("%s(%s)", q.SpaceAggregation, query), warnings, nil
+		}
+		return fmt.Sprintf("%s by (%s) (%s)", q.SpaceAggregation, strings.Join(groupBy, ", "), query), warnings, nil
+	}
+	return "", warnings, fmt.Errorf("the space aggregation %s is not translatable", q.SpaceAggregation)
+}
+
+// promQLSelector returns the series selector of the metric with the filters as label matchers
+func promQLSelector(metric string, filters *v3.FilterSet) (string, []string, error) {
+	var warnings []string
+	if metric == "" {
+		return "", nil, fmt.Errorf("the metric is required")
+	}
+	if filters == nil || len(filters.Items) == 0 {
+		return metric, nil, nil
+	}
+	if filters.Operator != "" && !strings.EqualFold(filters.Operator, "AND") {
+		return "", nil, fmt.Errorf("the filter operator %s is not translatable", filters.Operator)
+	}
+
+	matchers := make([]string, 0, len(filters.Items))
+	for _, item := range filters.Items {
+		label, labelWarning := promLabelName(item.Key.Key)
+		if labelWarning != "" {
+			warnings = append(warnings, labelWarning)
+		}
+
+		var matcher string
+		switch item.Operator {
+		case v3.FilterOperatorEqual:
+			matcher = fmt.Sprintf("%s=%q", label, fmt.Sprint(item.Value))
+		case v3.FilterOperatorNotEqual:
+			matcher = fmt.Sprintf("%s!=%q", label, fmt.Sprint(item.Value))
+		case v3.FilterOperatorIn, v3.FilterOperatorNotIn:
+			values := []string{}
+			switch value := item.Value.(type) {
+			case []interface{}:
+				for _, v := range value {
+					values = append(values, regexp.QuoteMeta(fmt.Sprint(v)))
+				}
+			default:
+				values = append(values, regexp.QuoteMeta(fmt.Sprint(value)))
+			}
+			op := "=~"
+			if item.Operator == v3.FilterOperatorNotIn {
+				op = "!~"
+			}
+			matcher = fmt.Sprintf("%s%s%q", label, op, strings.Join(values, "|"))
+		case v3.FilterOperatorRegex:
+			matcher = fmt.Sprintf("%s=~%q", label, fmt.Sprint(item.Value))
+		case v3.FilterOperatorNotRegex:
+			matcher = fmt.Sprintf("%s!~%q", label, fmt.Sprint(item.Value))
+		case v3.FilterOperatorContains:
+			matcher = fmt.Sprintf("%s=~%q", label, ".*"+regexp.QuoteMeta(fmt.Sprint(item.Value))+".*")
+		case v3.FilterOperatorNotContains:
+			matcher = fmt.Sprintf("%s!~%q", label, ".*"+regexp.QuoteMeta(fmt.Sprint(item.Value))+".*")
+		case v3.FilterOperatorExists:
+			matcher = fmt.Sprintf(`%s!=""`, label)
+		case v3.FilterOperatorNotExists:
+			matcher = fmt.Sprintf(`%s=""`, label)
+		default:
+			return "", warnings, fmt.Errorf("the filter operator %s on %s is not translatable", item.Operator, item.Key.Key)
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	return fmt.Sprintf("%s{%s}", metric, strings.Join(matchers, ", ")), warnings, nil
+}
+
+// promLabelName replaces the characters that are not valid in the Prometheus
+// label names e.g service.name is service_name, the change is reported
+func promLabelName(key string) (string, string) {
+	label := invalidPromLabelChars.ReplaceAllString(key, "_")
+	if label != key {
+		return label, fmt.Sprintf("the attribute %s is exported as the label %s", key, label)
+	}
+	return label, ""
+}
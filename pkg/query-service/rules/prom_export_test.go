@@ -0,0 +1,67 @@
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestExportPrometheusRules(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	rules := []string{
+		`{"alert":"High error rate","folder":"payments","evalWindow":"10m","labels":{"severity":"critical"},"condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"sum by (service) (rate(errors[5m]))"}}},"op":"1","matchType":"2","target":0.5}}`,
+		`{"alert":"Slow checkout","folder":"payments","evalWindow":"5m","condition":{"compositeQuery":{"queryType":"builder","builderQueries":{"A":{"queryName":"A","expression":"A","dataSource":"metrics","aggregateAttribute":{"key":"http.server.duration"},"timeAggregation":"rate","spaceAggregation":"p99","stepInterval":60,"groupBy":[{"key":"service.name"}],"filters":{"op":"AND","items":[{"key":{"key":"service.name"},"op":"in","value":["checkout","cart"]},{"key":{"key":"env"},"op":"=","value":"prod"}]}}}},"op":"1","matchType":"3","target":500,"targetUnit":"ms"}}`,
+		`{"alert":"Few requests","frequency":"5m","condition":{"compositeQuery":{"queryType":"builder","builderQueries":{"A":{"queryName":"A","expression":"A","dataSource":"metrics","aggregateAttribute":{"key":"requests"},"timeAggregation":"increase","spaceAggregation":"sum","stepInterval":300}}},"op":"2","matchType":"1","target":10}}`,
+		`{"alert":"Error logs","condition":{"compositeQuery":{"queryType":"builder","builderQueries":{"A":{"queryName":"A","expression":"A","dataSource":"logs","aggregateOperator":"count"}}},"op":"1","matchType":"1","target":10}}`,
+		`{"alert":"Error ratio","condition":{"compositeQuery":{"queryType":"builder","builderQueries":{"A":{"queryName":"A","expression":"A","dataSource":"metrics","aggregateAttribute":{"key":"errors"},"timeAggregation":"rate","spaceAggregation":"sum"},"B":{"queryName":"B","expression":"B","dataSource":"metrics","aggregateAttribute":{"key":"requests"},"timeAggregation":"rate","spaceAggregation":"sum"},"F1":{"queryName":"F1","expression":"A/B"}},"selectedQueryName":"F1"},"op":"1","matchType":"1","target":0.1}}`,
+	}
+	for _, rule := range rules {
+		_, err := m.CreateRule(ctx, rule)
+		assert.NoError(t, err)
+	}
+
+	export, err := m.ExportPrometheusRules(ctx, nil)
+	assert.NoError(t, err)
+	assert.Len(t, export.Results, 5)
+
+	results := map[string]PrometheusExportResult{}
+	for _, result := range export.Results {
+		results[result.Name] = result
+	}
+	assert.Equal(t, "payments", results["High error rate"].Group)
+	assert.Empty(t, results["High error rate"].Warnings)
+	assert.Equal(t, "payments", results["Slow checkout"].Group)
+	assert.Contains(t, results["Slow checkout"].Warnings, "the metric http.server.duration is exported as http_server_duration")
+	assert.Contains(t, results["Slow checkout"].Warnings, "the attribute service.name is exported as the label service_name")
+	assert.Contains(t, results["Slow checkout"].Warnings, "the target is in ms and is not converted to the unit of the query")
+	assert.Equal(t, "signoz-5m", results["Few requests"].Group)
+	assert.Equal(t, "logs queries are not translatable", results["Error logs"].Error)
+	assert.Equal(t, "formulas are not translatable", results["Error ratio"].Error)
+
+	groups := prometheusRuleGroups{}
+	assert.NoError(t, yaml.UnmarshalStrict([]byte(export.Groups), &groups))
+	assert.Len(t, groups.Groups, 2)
+
+	payments := groups.Groups[0]
+	assert.Equal(t, "payments", payments.Name)
+	assert.Len(t, payments.Rules, 2)
+	assert.Equal(t, "sum by (service) (rate(errors[5m])) > 0.5", payments.Rules[0].Expr)
+	assert.Equal(t, "10m", payments.Rules[0].For.String())
+	assert.Equal(t, map[string]string{"severity": "critical"}, payments.Rules[0].Labels)
+	assert.Equal(t, `avg_over_time((histogram_quantile(0.99, sum by (service_name, le) (rate(http_server_duration_bucket{service_name=~"checkout|cart", env="prod"}[1m]))))[5m:]) > 500`, payments.Rules[1].Expr)
+
+	requests := groups.Groups[1]
+	assert.Equal(t, "signoz-5m", requests.Name)
+	assert.Equal(t, "5m", requests.Interval.String())
+	assert.Equal(t, `min_over_time((sum(increase(requests[5m])))[5m:]) < 10`, requests.Rules[0].Expr)
+
+	for _, rule := range append(payments.Rules, requests.Rules...) {
+		_, err := parser.ParseExpr(rule.Expr)
+		assert.NoError(t, err, rule.Expr)
+	}
+}
@@ -0,0 +1,239 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	promModel "github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// prometheusRuleGroups is the rule file format of Prometheus
+type prometheusRuleGroups struct {
+	Groups []prometheusRuleGroup `yaml:"groups"`
+}
+
+type prometheusRuleGroup struct {
+	Name        string             `yaml:"name"`
+	Interval    promModel.Duration `yaml:"interval,omitempty"`
+	QueryOffset promModel.Duration `yaml:"query_offset,omitempty"`
+	Limit       int                `yaml:"limit,omitempty"`
+	Rules       []prometheusRule   `yaml:"rules"`
+}
+
+type prometheusRule struct {
+	Record        string             `yaml:"record,omitempty"`
+	Alert         string             `yaml:"alert,omitempty"`
+	Expr          string             `yaml:"expr"`
+	For           promModel.Duration `yaml:"for,omitempty"`
+	KeepFiringFor promModel.Duration `yaml:"keep_firing_for,omitempty"`
+	Labels        map[string]string  `yaml:"labels,omitempty"`
+	Annotations   map[string]string  `yaml:"annotations,omitempty"`
+}
+
+var promqlCompareOps = map[parser.ItemType]CompareOp{
+	parser.GTR:  ValueIsAbove,
+	parser.LSS:  ValueIsBelow,
+	parser.EQLC: ValueIsEq,
+	parser.NEQ:  ValueIsNotEq,
+	parser.GTE:  ValueAboveOrEq,
+	parser.LTE:  ValueBelowOrEq,
+}
+
+// the operators are flipped when the threshold is on the left e.g 5 < x is x > 5
+var flippedCompareOps = map[CompareOp]CompareOp{
+	ValueIsAbove:   ValueIsBelow,
+	ValueIsBelow:   ValueIsAbove,
+	ValueIsEq:      ValueIsEq,
+	ValueIsNotEq:   ValueIsNotEq,
+	ValueAboveOrEq: ValueBelowOrEq,
+	ValueBelowOrEq: ValueAboveOrEq,
+}
+
+// the Prometheus template functions that are not available in the alert templates
+var unsupportedTemplateFuncs = regexp.MustCompile(`{{[^}]*\b(query|humanize1024|humanizePercentage|title|toUpper|toLower|match|graphLink|tableLink|stripPort|stripDomain|parseDuration|toTime)\b[^}]*}}`)
+
+// the variables of the other alerting systems that are not available in the alert templates
+var unsupportedTemplateVars = regexp.MustCompile(`{{[^}]*(\$externalLabels|\$values)`)
+
+// checkImportedTemplates returns an error listing the constructs of the imported
+// templates that are not available in the alert templates, the templates are also
+// validated with the rule but the error is clearer
+func checkImportedTemplates(templates ...map[string]string) error {
+	var unsupported []string
+	for _, tmpls := range templates {
+		for name, text := range tmpls {
+			if m := unsupportedTemplateFuncs.FindStringSubmatch(text); m != nil {
+				unsupported = append(unsupported, fmt.Sprintf("%s uses the template function %s", name, m[1]))
+			}
+			if m := unsupportedTemplateVars.FindStringSubmatch(text); m != nil {
+				unsupported = append(unsupported, fmt.Sprintf("%s uses %s", name, m[1]))
+			}
+		}
+	}
+	if len(unsupported) == 0 {
+		return nil
+	}
+	sort.Strings(unsupported)
+	return fmt.Errorf("unsupported templates: %s", strings.Join(unsupported, ", "))
+}
+
+// ConvertPrometheusRules converts the alerting rules of the Prometheus rule
+// groups into rules, every rule of the groups is reported
+func ConvertPrometheusRules(content []byte) ([]RuleConversion, error) {
+	groups := prometheusRuleGroups{}
+	if err := yaml.UnmarshalStrict(content, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse the prometheus rule groups: %w", err)
+	}
+
+	conversions := []RuleConversion{}
+	for _, group := range groups.Groups {
+		for _, rule := range group.Rules {
+			conversions = append(conversions, convertPrometheusRule(group, rule))
+		}
+	}
+	return conversions, nil
+}
+
+func convertPrometheusRule(group prometheusRuleGroup, rule prometheusRule) RuleConversion {
+	conversion := RuleConversion{Group: group.Name, Name: rule.Alert}
+
+	if rule.Record != "" {
+		conversion.Name = rule.Record
+		conversion.Error = "recording rules are not supported"
+		return conversion
+	}
+	if rule.Alert == "" {
+		conversion.Error = "alert name is required"
+		return conversion
+	}
+
+	query, compareOp, target, err := splitPromQLThreshold(rule.Expr)
+	if err != nil {
+		conversion.Error = fmt.Sprintf("invalid expression: %s", err)
+		return conversion
+	}
+	if compareOp == CompareOpNone {
+		// the rule fires for every series returned by the expression
+		conversion.Warnings = append(conversion.Warnings, "the expression has no threshold, the rule fires for every series returned by the expression")
+		query = fmt.Sprintf("(%s) * 0 + 1", query)
+		compareOp = ValueIsAbove
+		target = 0
+	}
+
+	frequency := time.Duration(group.Interval)
+	if frequency == 0 {
+		frequency = time.Minute
+	}
+
+	// for is the duration the condition must hold in every evaluation,
+	// without it the rule fires on the latest evaluation
+	evalWindow := time.Duration(rule.For)
+	matchType := AllTheTimes
+	if evalWindow == 0 {
+		evalWindow = frequency
+		matchType = Last
+	}
+
+	if rule.KeepFiringFor != 0 {
+		conversion.Warnings = append(conversion.Warnings, "keep_firing_for is not supported and is dropped")
+	}
+	if group.Limit != 0 {
+		conversion.Warnings = append(conversion.Warnings, "the group limit is not supported and is dropped")
+	}
+
+	if err := checkImportedTemplates(rule.Labels, rule.Annotations); err != nil {
+		conversion.Error = err.Error()
+		return conversion
+	}
+
+	conversion.Rule = &PostableRule{
+		AlertName:  rule.Alert,
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeProm,
+		EvalWindow: Duration(evalWindow),
+		Frequency:  Duration(frequency),
+		EvalDelay:  Duration(group.QueryOffset),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypePromQL,
+				PanelType: v3.PanelTypeGraph,
+				PromQueries: map[string]*v3.PromQuery{
+					"A": {Query: query},
+				},
+			},
+			CompareOp: compareOp,
+			Target:    &target,
+			MatchType: matchType,
+		},
+		Labels:      rule.Labels,
+		Annotations: rule.Annotations,
+		Folder:      group.Name,
+		ExternalID:  fmt.Sprintf("prometheus/%s/%s", group.Name, rule.Alert),
+	}
+
+	if err := conversion.Rule.Validate(); err != nil {
+		conversion.Error = err.Error()
+		conversion.Rule = nil
+	}
+
+	return conversion
+}
+
+// splitPromQLThreshold splits the comparison of the expression with a number
+// into the query and the threshold e.g rate(x[5m]) > 0.5, the compare op is
+// none if the expression isn't a comparison with a number
+func splitPromQLThreshold(expr string) (string, CompareOp, float64, error) {
+	parsed, err := parser.ParseExpr(expr)
+	if err != nil {
+		return "", CompareOpNone, 0, err
+	}
+
+	binary, ok := unwrapParens(parsed).(*parser.BinaryExpr)
+	if !ok || binary.ReturnBool {
+		return expr, CompareOpNone, 0, nil
+	}
+	compareOp, ok := promqlCompareOps[binary.Op]
+	if !ok {
+		return expr, CompareOpNone, 0, nil
+	}
+
+	if number, ok := unwrapParens(binary.RHS).(*parser.NumberLiteral); ok {
+		return unwrapParens(binary.LHS).String(), compareOp, number.Val, nil
+	}
+	if number, ok := unwrapParens(binary.LHS).(*parser.NumberLiteral); ok {
+		return unwrapParens(binary.RHS).String(), flippedCompareOps[compareOp], number.Val, nil
+	}
+	return expr, CompareOpNone, 0, nil
+}
+
+func unwrapParens(expr parser.Expr) parser.Expr {
+	for {
+		paren, ok := expr.(*parser.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.Expr
+	}
+}
+
+// ImportPrometheusRules converts the Prometheus rule groups and creates or updates
+// the converted rules by their external id, the rules are only converted on dry run
+func (m *Manager) ImportPrometheusRules(ctx context.Context, content []byte, dryRun bool) ([]RuleConversion, error) {
+	conversions, err := ConvertPrometheusRules(content)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return conversions, nil
+	}
+
+	m.importConversions(ctx, conversions)
+	return conversions, nil
+}
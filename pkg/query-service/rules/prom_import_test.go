@@ -0,0 +1,152 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pql "github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testPrometheusRules = `
+groups:
+  - name: checkout
+    interval: 30s
+    query_offset: 1m
+    rules:
+      - alert: HighErrorRate
+        expr: sum by (service) (rate(http_requests_total{code=~"5.."}[5m])) > 0.5
+        for: 10m
+        labels:
+          severity: critical
+        annotations:
+          summary: "{{ $labels.service }} error rate is {{ humanize $value }}"
+      - alert: LowThroughput
+        expr: 10 > (sum(rate(http_requests_total[5m])))
+        keep_firing_for: 5m
+      - alert: ErrorBudget
+        expr: error_ratio > 0.01
+        annotations:
+          description: "{{ $value | humanizePercentage }} of {{ $externalLabels.cluster }}"
+      - alert: TargetDown
+        expr: up == 0 and on(job) absent(maintenance)
+      - record: job:http_requests:rate5m
+        expr: sum by (job) (rate(http_requests_total[5m]))
+      - alert: Broken
+        expr: rate(http_requests_total[5m] >
+`
+
+func TestConvertPrometheusRules(t *testing.T) {
+	conversions, err := ConvertPrometheusRules([]byte(testPrometheusRules))
+	assert.NoError(t, err)
+	assert.Len(t, conversions, 6)
+
+	highErrorRate := conversions[0]
+	assert.Empty(t, highErrorRate.Error)
+	assert.Empty(t, highErrorRate.Warnings)
+	rule := highErrorRate.Rule
+	assert.Equal(t, RuleType(RuleTypeProm), rule.RuleType)
+	assert.Equal(t, `sum by (service) (rate(http_requests_total{code=~"5.."}[5m]))`, rule.RuleCondition.CompositeQuery.PromQueries["A"].Query)
+	assert.Equal(t, ValueIsAbove, rule.RuleCondition.CompareOp)
+	assert.Equal(t, 0.5, *rule.RuleCondition.Target)
+	assert.Equal(t, AllTheTimes, rule.RuleCondition.MatchType)
+	assert.Equal(t, Duration(10*time.Minute), rule.EvalWindow)
+	assert.Equal(t, Duration(30*time.Second), rule.Frequency)
+	assert.Equal(t, Duration(time.Minute), rule.EvalDelay)
+	assert.Equal(t, "critical", rule.Labels["severity"])
+	assert.Equal(t, "checkout", rule.Folder)
+	assert.Equal(t, "prometheus/checkout/HighErrorRate", rule.ExternalID)
+
+	// the threshold on the left flips the operator
+	lowThroughput := conversions[1]
+	assert.Empty(t, lowThroughput.Error)
+	assert.Equal(t, "sum(rate(http_requests_total[5m]))", lowThroughput.Rule.RuleCondition.CompositeQuery.PromQueries["A"].Query)
+	assert.Equal(t, ValueIsBelow, lowThroughput.Rule.RuleCondition.CompareOp)
+	assert.Equal(t, 10.0, *lowThroughput.Rule.RuleCondition.Target)
+	assert.Equal(t, Last, lowThroughput.Rule.RuleCondition.MatchType)
+	assert.Equal(t, Duration(30*time.Second), lowThroughput.Rule.EvalWindow)
+	assert.Equal(t, []string{"keep_firing_for is not supported and is dropped"}, lowThroughput.Warnings)
+
+	assert.Equal(t, "unsupported templates: description uses $externalLabels, description uses the template function humanizePercentage", conversions[2].Error)
+	assert.Nil(t, conversions[2].Rule)
+
+	// the expression without a threshold fires for every returned series
+	targetDown := conversions[3]
+	assert.Empty(t, targetDown.Error)
+	assert.Equal(t, "(up == 0 and on(job) absent(maintenance)) * 0 + 1", targetDown.Rule.RuleCondition.CompositeQuery.PromQueries["A"].Query)
+	assert.Equal(t, ValueIsAbove, targetDown.Rule.RuleCondition.CompareOp)
+	assert.Equal(t, 0.0, *targetDown.Rule.RuleCondition.Target)
+	assert.Len(t, targetDown.Warnings, 1)
+
+	assert.Equal(t, "job:http_requests:rate5m", conversions[4].Name)
+	assert.Equal(t, "recording rules are not supported", conversions[4].Error)
+	assert.Nil(t, conversions[4].Rule)
+
+	assert.Contains(t, conversions[5].Error, "invalid expression")
+	assert.Nil(t, conversions[5].Rule)
+
+	_, err = ConvertPrometheusRules([]byte("groups:\n  - name: x\n    unknown: true\n"))
+	assert.Error(t, err)
+}
+
+func TestImportPrometheusRules(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	conversions, err := m.ImportPrometheusRules(ctx, []byte(testPrometheusRules), true)
+	assert.NoError(t, err)
+	assert.Nil(t, conversions[0].Import)
+	rules, err := m.ruleDB.GetStoredRules(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 0)
+
+	conversions, err = m.ImportPrometheusRules(ctx, []byte(testPrometheusRules), false)
+	assert.NoError(t, err)
+	assert.Equal(t, RuleImportCreated, conversions[0].Import.Status)
+	assert.Nil(t, conversions[2].Import)
+	assert.Nil(t, conversions[4].Import)
+
+	// importing again updates the rules
+	conversions, err = m.ImportPrometheusRules(ctx, []byte(testPrometheusRules), false)
+	assert.NoError(t, err)
+	assert.Equal(t, RuleImportUpdated, conversions[0].Import.Status)
+	rules, err = m.ruleDB.GetStoredRules(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 3)
+}
+
+func TestImportedInclusiveRulesFire(t *testing.T) {
+	conversions, err := ConvertPrometheusRules([]byte(`
+groups:
+  - name: checkout
+    rules:
+      - alert: ErrorRatio
+        expr: error_ratio >= 0.5
+        for: 5m
+      - alert: Throughput
+        expr: 10 >= sum(rate(http_requests_total[5m]))
+`))
+	require.NoError(t, err)
+	require.Len(t, conversions, 2)
+
+	errorRatio := conversions[0].Rule
+	require.NotNil(t, errorRatio)
+	assert.Equal(t, ValueAboveOrEq, errorRatio.RuleCondition.CompareOp)
+	rule, err := NewPromRule("1", errorRatio, zap.NewNop(), nil, nil)
+	require.NoError(t, err)
+	_, shouldAlert := rule.ShouldAlert(toCommonSeries(pql.Series{Floats: []pql.FPoint{{T: 1, F: 0.5}, {T: 2, F: 0.7}}}))
+	assert.True(t, shouldAlert)
+	_, shouldAlert = rule.ShouldAlert(toCommonSeries(pql.Series{Floats: []pql.FPoint{{T: 1, F: 0.4}, {T: 2, F: 0.7}}}))
+	assert.False(t, shouldAlert)
+
+	throughput := conversions[1].Rule
+	require.NotNil(t, throughput)
+	assert.Equal(t, ValueBelowOrEq, throughput.RuleCondition.CompareOp)
+	rule, err = NewPromRule("2", throughput, zap.NewNop(), nil, nil)
+	require.NoError(t, err)
+	_, shouldAlert = rule.ShouldAlert(toCommonSeries(pql.Series{Floats: []pql.FPoint{{T: 1, F: 20}, {T: 2, F: 10}}}))
+	assert.True(t, shouldAlert)
+}
@@ -9,6 +9,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/prometheus/prometheus/promql"
+	"go.signoz.io/signoz/pkg/query-service/constants"
 	"go.signoz.io/signoz/pkg/query-service/formatter"
 	"go.signoz.io/signoz/pkg/query-service/interfaces"
 	"go.signoz.io/signoz/pkg/query-service/model"
@@ -97,8 +98,8 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 
 	prevState := r.State()
 
-	start := ts.Add(-r.evalWindow)
-	end := ts
+	start := ts.Add(-r.evalWindow - r.evalDelay)
+	end := ts.Add(-r.evalDelay)
 	interval := 60 * time.Second // TODO(srikanthccv): this should be configurable
 
 	valueFormatter := formatter.FromUnit(r.Unit())
@@ -108,35 +109,43 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 		return nil, err
 	}
 	zap.L().Info("evaluating promql query", zap.String("name", r.Name()), zap.String("query", q))
+	EvalTraceFromContext(ctx).AddQuery(q)
+	queryStart := time.Now()
 	res, err := r.pqlEngine.RunAlertQuery(ctx, q, start, end, interval)
+	observeQuery(r.ID(), time.Since(queryStart))
 	if err != nil {
 		r.SetHealth(HealthBad)
 		r.SetLastError(err)
+		return nil, fmt.Errorf("%w: %w", errQueryFailed, err)
+	}
+	points := 0
+	for _, s := range res {
+		points += len(s.Floats) + len(s.Histograms)
+	}
+	if err := r.checkResultLimits(len(res), points); err != nil {
 		return nil, err
 	}
+	if trace := EvalTraceFromContext(ctx); trace != nil {
+		series := make([]*v3.Series, 0, len(res))
+		for _, s := range res {
+			commonSeries := toCommonSeries(s)
+			series = append(series, &commonSeries)
+		}
+		trace.AddSeries(r.ruleCondition.GetSelectedQueryName(), series)
+	}
 
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
 	resultFPs := map[uint64]struct{}{}
 
-	var alerts = make(map[uint64]*Alert, len(res))
+	samples := r.alertSamples(ctx, res)
 
-	for _, series := range res {
-		l := make(map[string]string, len(series.Metric))
-		for _, lbl := range series.Metric {
-			l[lbl.Name] = lbl.Value
-		}
+	var alerts = make(map[uint64]*Alert, len(samples))
 
-		if len(series.Floats) == 0 {
-			continue
-		}
-
-		alertSmpl, shouldAlert := r.ShouldAlert(toCommonSeries(series))
-		if !shouldAlert {
-			continue
-		}
-		zap.L().Debug("alerting for series", zap.String("name", r.Name()), zap.Any("series", series))
+	for _, alertSmpl := range samples {
+		l := alertSmpl.Metric.Map()
+		zap.L().Debug("alerting for series", zap.String("name", r.Name()), zap.Any("sample", alertSmpl))
 
 		threshold := valueFormatter.Format(r.targetVal(), r.Unit())
 
@@ -179,6 +188,10 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 			annotations = append(annotations, qslabels.Label{Name: name, Value: expand(value)})
 		}
 
+		if alertSmpl.IsMissing {
+			lb.Set(qslabels.AlertNameLabel, "[No data] "+r.Name())
+		}
+
 		lbs := lb.Labels()
 		h := lbs.Hash()
 		resultFPs[h] = struct{}{}
@@ -201,9 +214,16 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 			Value:             alertSmpl.V,
 			GeneratorURL:      r.GeneratorURL(),
 			Receivers:         r.preferredChannels,
+			Missing:           alertSmpl.IsMissing,
 		}
 	}
 
+	if !r.HasEnoughBreachingSeries(len(alerts)) {
+		zap.L().Info("not enough breaching series to alert", zap.String("ruleid", r.ID()), zap.Int("count", len(alerts)))
+		alerts = map[uint64]*Alert{}
+		resultFPs = map[uint64]struct{}{}
+	}
+
 	zap.L().Debug("found alerts for rule", zap.Int("count", len(alerts)), zap.String("name", r.Name()))
 	// alerts[h] is ready, add or update active list now
 	for h, a := range alerts {
@@ -231,7 +251,7 @@ func (r *PromRule) Eval(ctx context.Context, ts time.Time) (interface{}, error)
 		if _, ok := resultFPs[fp]; !ok {
 			// If the alert was previously firing, keep it around for a given
 			// retention time so it is reported as resolved to the AlertManager.
-			if a.State == model.StatePending || (!a.ResolvedAt.IsZero() && ts.Sub(a.ResolvedAt) > ResolvedRetention) {
+			if a.State == model.StatePending || a.ResolvedExpired(ts) {
 				delete(r.Active, fp)
 			}
 			if a.State != model.StateInactive {
@@ -306,6 +326,48 @@ func (r *PromRule) String() string {
 	return string(byt)
 }
 
+// alertSamples returns the samples of the series that should alert, or a
+// sample for the missing data when the query returned no data for AbsentFor
+func (r *PromRule) alertSamples(ctx context.Context, res promql.Matrix) Vector {
+	trace := EvalTraceFromContext(ctx)
+	for _, series := range res {
+		if len(series.Floats) > 0 {
+			r.lastTimestampWithDatapoints = time.Now()
+			break
+		}
+	}
+
+	var resultVector Vector
+
+	// if the data is missing for `For` duration then we should send alert
+	if r.ruleCondition.AlertOnAbsent && r.lastTimestampWithDatapoints.Add(time.Duration(r.Condition().AbsentFor)*time.Minute).Before(time.Now()) {
+		zap.L().Info("no data found for rule condition", zap.String("ruleid", r.ID()))
+		trace.AddNote(fmt.Sprintf("no data for %d minutes, alerting on the absent data", r.Condition().AbsentFor))
+		lbls := qslabels.NewBuilder(qslabels.Labels{})
+		if !r.lastTimestampWithDatapoints.IsZero() {
+			lbls.Set("lastSeen", r.lastTimestampWithDatapoints.Format(constants.AlertTimeFormat))
+		}
+		resultVector = append(resultVector, Sample{
+			Metric:    lbls.Labels(),
+			IsMissing: true,
+		})
+		return resultVector
+	}
+
+	for _, series := range res {
+		if len(series.Floats) == 0 {
+			continue
+		}
+		commonSeries := toCommonSeries(series)
+		alertSmpl, shouldAlert := r.ShouldAlert(commonSeries)
+		trace.AddDecision(&commonSeries, alertSmpl, shouldAlert, r.targetVal())
+		if shouldAlert {
+			resultVector = append(resultVector, alertSmpl)
+		}
+	}
+	return resultVector
+}
+
 func toCommonSeries(series promql.Series) v3.Series {
 	commonSeries := v3.Series{
 		Labels:      make(map[string]string),
@@ -1,9 +1,11 @@
 package rules
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
 	pql "github.com/prometheus/prometheus/promql"
 	"github.com/stretchr/testify/assert"
 	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
@@ -665,3 +667,58 @@ func TestPromRuleShouldAlert(t *testing.T) {
 		assert.Equal(t, c.expectAlert, shoulAlert, "Test case %d", idx)
 	}
 }
+
+func TestPromRuleAlertOnAbsent(t *testing.T) {
+	target := 10.0
+	postableRule := PostableRule{
+		AlertName:  "Test Rule",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeProm,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypePromQL,
+				PromQueries: map[string]*v3.PromQuery{
+					"A": {
+						Query: "dummy_query", // This is not used in the test
+					},
+				},
+			},
+			CompareOp:     ValueIsAbove,
+			MatchType:     AtleastOnce,
+			Target:        &target,
+			AlertOnAbsent: true,
+			AbsentFor:     5,
+		},
+	}
+	assert.NoError(t, postableRule.Validate())
+
+	rule, err := NewPromRule("69", &postableRule, zap.NewNop(), nil, nil)
+	assert.NoError(t, err)
+
+	// the rule fires with the missing data when nothing was ever seen
+	res := rule.alertSamples(context.Background(), pql.Matrix{})
+	assert.Len(t, res, 1)
+	assert.True(t, res[0].IsMissing)
+	assert.Empty(t, res[0].Metric.Get("lastSeen"))
+
+	series := pql.Series{
+		Metric: labels.FromStrings("service_name", "frontend"),
+		Floats: []pql.FPoint{{F: 20.0}},
+	}
+	res = rule.alertSamples(context.Background(), pql.Matrix{series})
+	assert.Len(t, res, 1)
+	assert.False(t, res[0].IsMissing)
+	assert.Equal(t, "frontend", res[0].Metric.Get("service_name"))
+
+	// the data was seen recently
+	assert.Len(t, rule.alertSamples(context.Background(), pql.Matrix{}), 0)
+
+	// the data is missing for longer than AbsentFor
+	rule.lastTimestampWithDatapoints = time.Now().Add(-10 * time.Minute)
+	res = rule.alertSamples(context.Background(), pql.Matrix{})
+	assert.Len(t, res, 1)
+	assert.True(t, res[0].IsMissing)
+	assert.NotEmpty(t, res[0].Metric.Get("lastSeen"))
+}
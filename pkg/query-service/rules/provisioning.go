@@ -0,0 +1,208 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// The rules in the provisioning directory are created or updated at startup
+// and on SIGHUP. Each file holds one rule in json or yaml, the rule is
+// identified by its external id, which defaults to the name of the file.
+// The provisioned rules are read-only in the API, they are changed by
+// editing the files and are deleted when their file is removed. The rules
+// created through the API are never taken over by a file with their external id.
+
+// RuleProvisionedError is returned when a provisioned rule is changed through the API
+type RuleProvisionedError struct {
+	RuleId string
+}
+
+func (e *RuleProvisionedError) Error() string {
+	return fmt.Sprintf("rule %s is provisioned from a file and can only be changed in the provisioning directory", e.RuleId)
+}
+
+type provisioningContextKey struct{}
+
+// withProvisioning marks the changes made by the context as done by the provisioning
+func withProvisioning(ctx context.Context) context.Context {
+	return context.WithValue(ctx, provisioningContextKey{}, true)
+}
+
+func isProvisioning(ctx context.Context) bool {
+	provisioning, _ := ctx.Value(provisioningContextKey{}).(bool)
+	return provisioning
+}
+
+// checkRuleProvisioned returns a RuleProvisionedError if the rule is provisioned
+// and the change is not made by the provisioning
+func (r *ruleDB) checkRuleProvisioned(ctx context.Context, id int) error {
+	if isProvisioning(ctx) {
+		return nil
+	}
+
+	var provisioned []bool
+	if err := r.Select(&provisioned, "SELECT provisioned FROM rules WHERE id=$1", id); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return err
+	}
+	if len(provisioned) > 0 && provisioned[0] {
+		return &RuleProvisionedError{RuleId: fmt.Sprintf("%d", id)}
+	}
+	return nil
+}
+
+// provisionedRule is a rule definition read from the provisioning directory
+type provisionedRule struct {
+	file       string
+	externalID string
+	data       string
+}
+
+// loadProvisionedRules reads the rule files in the directory, the files
+// that fail to parse are reported and skipped
+func loadProvisionedRules(dir string) ([]provisionedRule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var loadErrors []error
+	rules := []provisionedRule{}
+	seen := map[string]string{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		file := filepath.Join(dir, entry.Name())
+		rule, err := loadProvisionedRule(file)
+		if err != nil {
+			loadErrors = append(loadErrors, fmt.Errorf("failed to load rule file %s: %w", file, err))
+			continue
+		}
+		if other, ok := seen[rule.externalID]; ok {
+			loadErrors = append(loadErrors, fmt.Errorf("external id %s of rule file %s is already used by %s", rule.externalID, file, other))
+			continue
+		}
+		seen[rule.externalID] = file
+		rules = append(rules, rule)
+	}
+
+	sort.Slice(rules, func(i, j int) bool { return rules[i].file < rules[j].file })
+	return rules, errors.Join(loadErrors...)
+}
+
+func loadProvisionedRule(file string) (provisionedRule, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return provisionedRule{}, err
+	}
+
+	// json is valid yaml, read both as yaml and store the rule as json
+	var raw interface{}
+	if err := yamlv3.Unmarshal(content, &raw); err != nil {
+		return provisionedRule{}, err
+	}
+	jsonContent, err := json.Marshal(raw)
+	if err != nil {
+		return provisionedRule{}, err
+	}
+
+	rule, err := ParsePostableRule(jsonContent)
+	if err != nil {
+		return provisionedRule{}, err
+	}
+	if rule.ExternalID == "" {
+		rule.ExternalID = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return provisionedRule{}, err
+	}
+	return provisionedRule{file: file, externalID: rule.ExternalID, data: string(data)}, nil
+}
+
+// ProvisionRules creates or updates the rules in the provisioning directory
+// and deletes the provisioned rules whose file is removed
+func (m *Manager) ProvisionRules(ctx context.Context) error {
+	if m.opts.ProvisioningDir == "" {
+		return nil
+	}
+	ctx = withProvisioning(ctx)
+
+	rules, loadErr := loadProvisionedRules(m.opts.ProvisioningDir)
+	if rules == nil {
+		// the directory could not be read, keep the provisioned rules as they are
+		return loadErr
+	}
+	provisionErrors := []error{loadErr}
+
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	provisioned := map[string]bool{}
+	for _, rule := range rules {
+		provisioned[rule.externalID] = true
+
+		storedRule, err := m.ruleDB.GetStoredRuleByExternalID(ctx, rule.externalID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			if _, err := m.CreateRule(ctx, rule.data); err != nil {
+				provisionErrors = append(provisionErrors, fmt.Errorf("failed to create rule from file %s: %w", rule.file, err))
+				continue
+			}
+			zap.L().Info("created the provisioned rule", zap.String("file", rule.file))
+		case err != nil:
+			provisionErrors = append(provisionErrors, err)
+		case !storedRule.Provisioned:
+			provisionErrors = append(provisionErrors, fmt.Errorf("external id %s of rule file %s is used by rule %d that is not provisioned", rule.externalID, rule.file, storedRule.Id))
+		case storedRule.Data != rule.data:
+			if err := m.EditRule(ctx, rule.data, fmt.Sprintf("%d", storedRule.Id)); err != nil {
+				provisionErrors = append(provisionErrors, fmt.Errorf("failed to update rule from file %s: %w", rule.file, err))
+				continue
+			}
+			zap.L().Info("updated the provisioned rule", zap.String("file", rule.file), zap.Int("id", storedRule.Id))
+		}
+	}
+
+	// the rule of a file that fails to load is kept until the file is fixed
+	if loadErr != nil {
+		return errors.Join(provisionErrors...)
+	}
+	for _, storedRule := range storedRules {
+		if !storedRule.Provisioned || storedRule.ExternalID == nil || provisioned[*storedRule.ExternalID] {
+			continue
+		}
+		if err := m.DeleteRule(ctx, fmt.Sprintf("%d", storedRule.Id)); err != nil {
+			provisionErrors = append(provisionErrors, fmt.Errorf("failed to delete provisioned rule %d: %w", storedRule.Id, err))
+			continue
+		}
+		zap.L().Info("deleted the provisioned rule without a file", zap.Int("id", storedRule.Id))
+	}
+
+	return errors.Join(provisionErrors...)
+}
+
+// reloadProvisionedRules provisions the rules again on every reload signal
+func (m *Manager) reloadProvisionedRules() {
+	for range m.reloadSignal {
+		zap.L().Info("reloading the provisioned rules", zap.String("dir", m.opts.ProvisioningDir))
+		if err := m.ProvisionRules(context.Background()); err != nil {
+			zap.L().Error("failed to provision the rules", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package rules
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+const provisionedYamlRule = `
+alert: High error rate
+labels:
+  severity: critical
+condition:
+  compositeQuery:
+    queryType: promql
+    promQueries:
+      A:
+        query: errors
+  op: "1"
+  matchType: "1"
+  target: 1
+`
+
+const provisionedJsonRule = `{"alert":"Slow checkout","externalId":"checkout-latency","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"latency"}}},"op":"1","matchType":"1","target":500}}`
+
+func TestProvisionRules(t *testing.T) {
+	m := newTestManager(t)
+	m.opts.ProvisioningDir = t.TempDir()
+	ctx := context.Background()
+
+	writeRule := func(name, content string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(m.opts.ProvisioningDir, name), []byte(content), 0o644))
+	}
+	writeRule("error-rate.yaml", provisionedYamlRule)
+	writeRule("checkout.json", provisionedJsonRule)
+	writeRule("README.md", "the rules of the payments team")
+
+	assert.NoError(t, m.ProvisionRules(ctx))
+	rules, err := m.ruleDB.GetStoredRules(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+
+	rule, err := m.GetRuleByExternalID(ctx, "error-rate")
+	assert.NoError(t, err)
+	assert.True(t, rule.Provisioned)
+	assert.Equal(t, "High error rate", rule.AlertName)
+	assert.Equal(t, "critical", rule.Labels["severity"])
+
+	// the provisioned rules are read-only in the api
+	user := &model.UserPayload{User: model.User{Email: "admin@signoz.io"}}
+	userCtx := context.WithValue(ctx, constants.ContextUserKey, user)
+	err = m.EditRule(userCtx, provisionedJsonRule, rule.Id)
+	assert.Equal(t, &RuleProvisionedError{RuleId: rule.Id}, err)
+	err = m.DeleteRule(userCtx, rule.Id)
+	assert.Equal(t, &RuleProvisionedError{RuleId: rule.Id}, err)
+	_, err = m.GetRule(userCtx, rule.Id)
+	assert.NoError(t, err)
+
+	// the changed files update the rules and the removed files delete them
+	writeRule("error-rate.yaml", provisionedYamlRule+"evalWindow: 10m\n")
+	assert.NoError(t, os.Remove(filepath.Join(m.opts.ProvisioningDir, "checkout.json")))
+	assert.NoError(t, m.ProvisionRules(ctx))
+
+	updated, err := m.GetRuleByExternalID(ctx, "error-rate")
+	assert.NoError(t, err)
+	assert.Equal(t, rule.Id, updated.Id)
+	assert.Equal(t, Duration(10*time.Minute), updated.EvalWindow)
+	_, err = m.GetRuleByExternalID(ctx, "checkout-latency")
+	assert.Error(t, err)
+
+	// the rules created through the api are not taken over by the files
+	created, err := m.CreateRule(ctx, `{"alert":"Manual","externalId":"manual","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"manual"}}},"op":"1","matchType":"1","target":1}}`)
+	assert.NoError(t, err)
+	writeRule("manual.yaml", provisionedYamlRule)
+	writeRule("rule-"+created.Id+".yaml", provisionedYamlRule)
+	assert.Error(t, m.ProvisionRules(ctx))
+	manual, err := m.GetRule(ctx, created.Id)
+	assert.NoError(t, err)
+	assert.False(t, manual.Provisioned)
+	assert.Equal(t, "Manual", manual.AlertName)
+	byFileName, err := m.GetRuleByExternalID(ctx, "rule-"+created.Id)
+	assert.NoError(t, err)
+	assert.NotEqual(t, created.Id, byFileName.Id)
+	assert.True(t, byFileName.Provisioned)
+	assert.NoError(t, os.Remove(filepath.Join(m.opts.ProvisioningDir, "manual.yaml")))
+
+	// the rule of a file that fails to load is kept
+	writeRule("error-rate.yaml", "alert: [")
+	assert.Error(t, m.ProvisionRules(ctx))
+	_, err = m.GetRuleByExternalID(ctx, "error-rate")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,83 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// selectQuery builds the select queries of the rule db, the values in the
+// conditions are always bound as parameters. The conditions use ? as the
+// placeholder, they are numbered when the query is built.
+type selectQuery struct {
+	query      string
+	conditions []string
+	args       []interface{}
+	groupBy    string
+	orderBy    string
+	limit      int
+	offset     int
+}
+
+// newSelectQuery starts the query from the select and from clauses
+func newSelectQuery(query string) *selectQuery {
+	return &selectQuery{query: query}
+}
+
+// where adds the condition, the values are bound to the placeholders in order
+func (q *selectQuery) where(condition string, args ...interface{}) *selectQuery {
+	if strings.Count(condition, "?") != len(args) {
+		panic(fmt.Sprintf("condition %q expects %d values, got %d", condition, strings.Count(condition, "?"), len(args)))
+	}
+	q.conditions = append(q.conditions, condition)
+	q.args = append(q.args, args...)
+	return q
+}
+
+func (q *selectQuery) group(groupBy string) *selectQuery {
+	q.groupBy = groupBy
+	return q
+}
+
+func (q *selectQuery) order(orderBy string) *selectQuery {
+	q.orderBy = orderBy
+	return q
+}
+
+// page limits the query to the page of the results, the zero limit returns all the results
+func (q *selectQuery) page(limit, offset int) *selectQuery {
+	q.limit = limit
+	q.offset = offset
+	return q
+}
+
+// build returns the query with the numbered placeholders and the values to bind
+func (q *selectQuery) build() (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString(q.query)
+	if len(q.conditions) > 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(q.conditions, " AND "))
+	}
+	if q.groupBy != "" {
+		sb.WriteString(" GROUP BY ")
+		sb.WriteString(q.groupBy)
+	}
+	if q.orderBy != "" {
+		sb.WriteString(" ORDER BY ")
+		sb.WriteString(q.orderBy)
+	}
+
+	args := append([]interface{}{}, q.args...)
+	if q.limit > 0 {
+		sb.WriteString(" LIMIT ?")
+		args = append(args, q.limit)
+		if q.offset > 0 {
+			sb.WriteString(" OFFSET ?")
+			args = append(args, q.offset)
+		}
+	}
+
+	return sqlx.Rebind(sqlx.DOLLAR, sb.String()), args
+}
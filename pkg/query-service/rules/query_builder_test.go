@@ -0,0 +1,27 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectQuery(t *testing.T) {
+	query, args := newSelectQuery("SELECT id FROM rules").build()
+	assert.Equal(t, "SELECT id FROM rules", query)
+	assert.Empty(t, args)
+
+	query, args = newSelectQuery("SELECT folder, count(*) AS count FROM rules").
+		where("deleted_at IS NULL").
+		where("(external_id=? OR 'rule-' || id=?)", "checkout", "checkout").
+		where("severity=?", "critical").
+		group("folder").
+		order("folder").
+		page(10, 20).
+		build()
+	assert.Equal(t, "SELECT folder, count(*) AS count FROM rules WHERE deleted_at IS NULL AND (external_id=$1 OR 'rule-' || id=$2) AND severity=$3 GROUP BY folder ORDER BY folder LIMIT $4 OFFSET $5", query)
+	assert.Equal(t, []interface{}{"checkout", "checkout", "critical", 10, 20}, args)
+
+	// the values are always bound, a condition with missing values is a bug
+	assert.Panics(t, func() { newSelectQuery("SELECT id FROM rules").where("id=?") })
+}
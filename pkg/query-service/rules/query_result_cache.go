@@ -0,0 +1,157 @@
+package rules
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.uber.org/zap"
+)
+
+// queryResultCacheTTL is how long the query results are shared, the start and end
+// of the queries are rounded to the minute so the results are reused within a tick
+const queryResultCacheTTL = time.Minute
+
+type queryResultCacheKey struct{}
+
+// queryResultCache shares the results of the identical queries of the rules, the
+// rules querying at the same time wait for the query already running
+type queryResultCache struct {
+	mtx       sync.Mutex
+	entries   map[string]*queryResultEntry
+	lastSweep time.Time
+}
+
+type queryResultEntry struct {
+	done      chan struct{}
+	result    *v3.Result
+	err       error
+	expiresAt time.Time
+}
+
+func newQueryResultCache() *queryResultCache {
+	return &queryResultCache{entries: map[string]*queryResultEntry{}}
+}
+
+// withQueryResultCache returns the context sharing the query results of the rules evaluated with it
+func withQueryResultCache(ctx context.Context, cache *queryResultCache) context.Context {
+	if cache == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, queryResultCacheKey{}, cache)
+}
+
+// queryResultCacheFromContext returns the cache of the query results, nil when
+// the results are not shared e.g. for the test notifications
+func queryResultCacheFromContext(ctx context.Context) *queryResultCache {
+	cache, _ := ctx.Value(queryResultCacheKey{}).(*queryResultCache)
+	return cache
+}
+
+// queryResultKey hashes the query, its time range and the selected query of the rule
+func queryResultKey(version, selectedQuery string, params *v3.QueryRangeParamsV3) (string, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(version))
+	h.Write([]byte{0})
+	h.Write([]byte(selectedQuery))
+	h.Write([]byte{0})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// get returns the result of the query, the query is run when its result is not
+// cached. The failed queries are not cached. The results are shared without a cache
+func (c *queryResultCache) get(ctx context.Context, key string, query func() (*v3.Result, error)) (*v3.Result, error) {
+	if c == nil || key == "" {
+		return query()
+	}
+
+	now := time.Now()
+	c.mtx.Lock()
+	c.sweep(now)
+	entry, ok := c.entries[key]
+	if ok && (entry.expiresAt.IsZero() || now.Before(entry.expiresAt)) {
+		c.mtx.Unlock()
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		zap.L().Debug("reusing the query result of another rule", zap.String("key", key))
+		return cloneResult(entry.result), nil
+	}
+	entry = &queryResultEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.mtx.Unlock()
+
+	entry.result, entry.err = query()
+	c.mtx.Lock()
+	if entry.err != nil {
+		delete(c.entries, key)
+	} else {
+		entry.expiresAt = time.Now().Add(queryResultCacheTTL)
+	}
+	c.mtx.Unlock()
+	close(entry.done)
+
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return cloneResult(entry.result), nil
+}
+
+// sweep drops the expired results, it's called with the lock held
+func (c *queryResultCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < queryResultCacheTTL {
+		return
+	}
+	c.lastSweep = now
+	for key, entry := range c.entries {
+		if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cloneResult copies the series of the result so the rules sharing it can't change it
+func cloneResult(result *v3.Result) *v3.Result {
+	if result == nil {
+		return nil
+	}
+	clone := *result
+	clone.Series = make([]*v3.Series, 0, len(result.Series))
+	for _, series := range result.Series {
+		if series == nil {
+			continue
+		}
+		s := &v3.Series{
+			Labels:      make(map[string]string, len(series.Labels)),
+			LabelsArray: make([]map[string]string, 0, len(series.LabelsArray)),
+			Points:      make([]v3.Point, len(series.Points)),
+		}
+		for k, v := range series.Labels {
+			s.Labels[k] = v
+		}
+		for _, lbls := range series.LabelsArray {
+			m := make(map[string]string, len(lbls))
+			for k, v := range lbls {
+				m[k] = v
+			}
+			s.LabelsArray = append(s.LabelsArray, m)
+		}
+		copy(s.Points, series.Points)
+		clone.Series = append(clone.Series, s)
+	}
+	return &clone
+}
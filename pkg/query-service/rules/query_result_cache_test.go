@@ -0,0 +1,74 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestQueryResultCache(t *testing.T) {
+	cache := newQueryResultCache()
+	ctx := context.Background()
+	params := &v3.QueryRangeParamsV3{Start: 1717205040000, End: 1717205340000}
+
+	key, err := queryResultKey("v4", "A", params)
+	assert.NoError(t, err)
+	other, err := queryResultKey("v4", "A", &v3.QueryRangeParamsV3{Start: 1717205100000, End: 1717205400000})
+	assert.NoError(t, err)
+	// the next minute runs the query again
+	assert.NotEqual(t, key, other)
+
+	var queries atomic.Int32
+	release := make(chan struct{})
+	query := func() (*v3.Result, error) {
+		queries.Add(1)
+		<-release
+		return &v3.Result{QueryName: "A", Series: []*v3.Series{{Labels: map[string]string{"service": "cart"}, Points: []v3.Point{{Timestamp: 1, Value: 10}}}}}, nil
+	}
+
+	// the rules running the same query at the same time wait for the query running
+	var wg sync.WaitGroup
+	results := make([]*v3.Result, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = cache.get(ctx, key, query)
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), queries.Load())
+	for _, res := range results {
+		assert.Equal(t, "cart", res.Series[0].Labels["service"])
+	}
+
+	// the rules can't change the shared result
+	results[0].Series[0].Points[0].Value = 20
+	res, err := cache.get(ctx, key, query)
+	assert.NoError(t, err)
+	assert.Equal(t, 10.0, res.Series[0].Points[0].Value)
+	assert.Equal(t, int32(1), queries.Load())
+
+	// the failed queries are run again
+	failing := errors.New("timeout")
+	_, err = cache.get(ctx, other, func() (*v3.Result, error) { return nil, failing })
+	assert.ErrorIs(t, err, failing)
+	res, err = cache.get(ctx, other, query)
+	assert.NoError(t, err)
+	assert.NotNil(t, res)
+	assert.Equal(t, int32(2), queries.Load())
+
+	// the queries are run every time without a cache
+	var unshared *queryResultCache
+	_, err = unshared.get(ctx, key, query)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), queries.Load())
+	assert.Nil(t, queryResultCacheFromContext(withQueryResultCache(ctx, nil)))
+	assert.Equal(t, cache, queryResultCacheFromContext(withQueryResultCache(ctx, cache)))
+}
@@ -0,0 +1,77 @@
+package rules
+
+import (
+	"text/template"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// queryTemplate is the composite query of the rule prepared once when the rule is
+// created, the evaluations derive their params from it so the rule condition read
+// by the api is never changed by the evaluations
+type queryTemplate struct {
+	// compositeQuery has the filter variables expanded, the time shift set and the
+	// graph panel type, every evaluation gets its own copy
+	compositeQuery *v3.CompositeQuery
+	// clickhouseQueries are the parsed enabled clickhouse queries by name
+	clickhouseQueries map[string]*template.Template
+	// err is returned to the evaluations when the query can't be prepared
+	err error
+}
+
+func newQueryTemplate(r *BaseRule) *queryTemplate {
+	tmpl := &queryTemplate{}
+	if r.ruleCondition == nil || r.ruleCondition.CompositeQuery == nil {
+		return tmpl
+	}
+	cq := r.ruleCondition.CompositeQuery
+
+	if cq.QueryType == v3.QueryTypeClickHouseSQL {
+		tmpl.clickhouseQueries = make(map[string]*template.Template, len(cq.ClickHouseQueries))
+		for name, chQuery := range cq.ClickHouseQueries {
+			if chQuery.Disabled {
+				continue
+			}
+			parsed, err := template.New("clickhouse-query").Parse(chQuery.Query)
+			if err != nil {
+				tmpl.err = err
+				return tmpl
+			}
+			tmpl.clickhouseQueries[name] = parsed
+		}
+	}
+
+	resolved, err := r.ResolveFilterVariables(cq)
+	if err != nil {
+		tmpl.err = err
+		return tmpl
+	}
+	if resolved == cq {
+		resolved = cq.Clone()
+	}
+	for _, q := range resolved.BuilderQueries {
+		q.SetShiftByFromFunc()
+	}
+	resolved.PanelType = v3.PanelTypeGraph
+	tmpl.compositeQuery = resolved
+	return tmpl
+}
+
+// PreparedCompositeQuery returns the copy of the prepared composite query of the
+// rule the evaluation can change e.g. to enrich it or set the step interval
+func (r *BaseRule) PreparedCompositeQuery() (*v3.CompositeQuery, error) {
+	query := r.queryTemplate()
+	if query.err != nil {
+		return nil, query.err
+	}
+	return query.compositeQuery.Clone(), nil
+}
+
+// queryTemplate returns the prepared query, it's prepared on the fly for the
+// rules not created with NewBaseRule
+func (r *BaseRule) queryTemplate() *queryTemplate {
+	if r.query == nil {
+		return newQueryTemplate(r)
+	}
+	return r.query
+}
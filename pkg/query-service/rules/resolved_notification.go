@@ -0,0 +1,212 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/pkg/query-service/formatter"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.signoz.io/signoz/pkg/query-service/utils/times"
+	"go.signoz.io/signoz/pkg/query-service/utils/timestamp"
+	"go.uber.org/zap"
+)
+
+// ResolvedNotifications controls the notifications of the resolved alerts of the rule.
+// The alerts are routed in the alertmanager to the copies of the channels with the
+// resolved notifications disabled, which don't send the resolved alerts, so they aren't
+// notified when the alerts expire. The resolved alert that resolved before the min firing
+// duration still expires in the alertmanager, the channels routed by it notify it then.
+type ResolvedNotifications struct {
+	// Disabled drops the resolved notifications
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	// MinFiringDuration only notifies the alerts that resolved at least the duration after they fired
+	MinFiringDuration Duration `yaml:"minFiringDuration,omitempty" json:"minFiringDuration,omitempty"`
+
+	// Summary and Description replace the annotations of the resolved alerts, they are
+	// expanded like the annotations of the rule
+	Summary     string `yaml:"summary,omitempty" json:"summary,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Channels override the settings for the preferred channels of the rule by name
+	Channels map[string]ResolvedChannelNotifications `yaml:"channels,omitempty" json:"channels,omitempty"`
+}
+
+// ResolvedChannelNotifications controls the resolved notifications sent to a channel
+type ResolvedChannelNotifications struct {
+	Disabled          bool     `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	MinFiringDuration Duration `yaml:"minFiringDuration,omitempty" json:"minFiringDuration,omitempty"`
+}
+
+func (rn *ResolvedNotifications) Validate() error {
+	if rn == nil {
+		return nil
+	}
+	if rn.MinFiringDuration < 0 {
+		return errors.Errorf("resolved notifications min firing duration must not be negative")
+	}
+	for name, channel := range rn.Channels {
+		if channel.MinFiringDuration < 0 {
+			return errors.Errorf("resolved notifications min firing duration of channel %s must not be negative", name)
+		}
+	}
+	return nil
+}
+
+// settings returns whether the resolved notifications of the channel are disabled and their
+// min firing duration, the empty channel is the route of the alert without preferred channels
+func (rn *ResolvedNotifications) settings(channel string) (bool, Duration) {
+	if override, ok := rn.Channels[channel]; ok && channel != "" {
+		return override.Disabled, override.MinFiringDuration
+	}
+	return rn.Disabled, rn.MinFiringDuration
+}
+
+// notifies reports whether the resolved alert is notified to the channel
+func (rn *ResolvedNotifications) notifies(alert *Alert, channel string) bool {
+	disabled, minFiring := rn.settings(channel)
+	return !disabled && alert.ResolvedAt.Sub(alert.FiredAt) >= time.Duration(minFiring)
+}
+
+// disables reports whether the resolved notifications of the channel are disabled
+func (rn *ResolvedNotifications) disables(channel string) bool {
+	if rn == nil {
+		return false
+	}
+	disabled, _ := rn.settings(channel)
+	return disabled
+}
+
+// routedReceivers returns the receivers of the alert in the alertmanager, the channels with
+// the resolved notifications disabled are replaced by their copies not sending the resolved
+// alerts. The alert without preferred channels is routed to all the channels by name then.
+func routedReceivers(alert *Alert, allChannels func() []string) []string {
+	rn := alert.resolved
+	if rn == nil {
+		return alert.Receivers
+	}
+	disabled := rn.Disabled
+	for _, channel := range rn.Channels {
+		disabled = disabled || channel.Disabled
+	}
+	if !disabled {
+		return alert.Receivers
+	}
+	receivers := alert.Receivers
+	if len(receivers) == 0 {
+		receivers = allChannels()
+	}
+	routed := make([]string, 0, len(receivers))
+	for _, receiver := range receivers {
+		if rn.disables(receiver) {
+			receiver = am.WithoutResolved(receiver)
+		}
+		routed = append(routed, receiver)
+	}
+	return routed
+}
+
+// routeChannelsWithoutResolved updates the routes of the channels in the alertmanager, the
+// channels routed before their copies not sending the resolved alerts existed get them
+func (m *Manager) routeChannelsWithoutResolved(ctx context.Context) {
+	if m.alertManager == nil {
+		return
+	}
+	channels, apiErr := m.ruleDB.GetChannels()
+	if apiErr != nil {
+		zap.L().Error("failed to get the channels routed by the alertmanager", zap.Error(apiErr.Err))
+		return
+	}
+	for i := range *channels {
+		channel := &(*channels)[i]
+		template, err := m.ruleDB.GetChannelTemplate(ctx, int64(channel.Id))
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			zap.L().Error("failed to get the template of the channel", zap.String("channel", channel.Name), zap.Error(err))
+			continue
+		}
+		receiver, err := channelReceiver(channel, template)
+		if err != nil {
+			zap.L().Error("failed to parse the channel", zap.String("channel", channel.Name), zap.Error(err))
+			continue
+		}
+		if apiErr := m.alertManager.EditRoute(receiver); apiErr != nil {
+			zap.L().Error("failed to update the route of the channel", zap.String("channel", channel.Name), zap.Error(apiErr.Err))
+		}
+	}
+}
+
+// resolvedNotifications applies the resolved notifications of the rule to the alerts sent,
+// the resolved alert is only sent to the channels notified and dropped when there are none
+func (r *BaseRule) resolvedNotifications(ctx context.Context, alerts []*Alert) []*Alert {
+	if r.notificationSettings == nil || r.notificationSettings.Resolved == nil {
+		return alerts
+	}
+	rn := r.notificationSettings.Resolved
+
+	sent := make([]*Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		alert.resolved = rn
+		if alert.ResolvedAt.IsZero() {
+			sent = append(sent, alert)
+			continue
+		}
+
+		if len(alert.Receivers) == 0 {
+			if !rn.notifies(alert, "") {
+				continue
+			}
+		} else {
+			receivers := make([]string, 0, len(alert.Receivers))
+			for _, receiver := range alert.Receivers {
+				if rn.notifies(alert, receiver) {
+					receivers = append(receivers, receiver)
+				}
+			}
+			if len(receivers) == 0 {
+				continue
+			}
+			alert.Receivers = receivers
+		}
+
+		if rn.Summary != "" || rn.Description != "" {
+			alert.Annotations = r.resolvedAnnotations(ctx, alert, rn)
+		}
+		sent = append(sent, alert)
+	}
+	return sent
+}
+
+// resolvedAnnotations returns the annotations of the alert with the resolved summary and description
+func (r *BaseRule) resolvedAnnotations(ctx context.Context, alert *Alert, rn *ResolvedNotifications) labels.BaseLabels {
+	valueFormatter := formatter.FromUnit(r.Unit())
+	tmplData := AlertTemplateData(labelsMap(alert.Labels), valueFormatter.Format(alert.Value, r.Unit()), valueFormatter.Format(r.targetVal(), r.Unit()))
+	defs := "{{$labels := .Labels}}{{$value := .Value}}{{$threshold := .Threshold}}"
+	expand := func(text string) string {
+		tmpl := NewTemplateExpander(
+			ctx,
+			defs+text,
+			"__alert_"+r.Name(),
+			tmplData,
+			times.Time(timestamp.FromTime(alert.ResolvedAt)),
+			nil,
+		)
+		result, err := tmpl.Expand()
+		if err != nil {
+			result = fmt.Sprintf("<error expanding template: %s>", err)
+		}
+		return result
+	}
+
+	// the map is a copy, the annotations of the active alert are left as they are
+	annotations := labelsMap(alert.Annotations)
+	if rn.Summary != "" {
+		annotations[labels.AlertSummaryLabel] = expand(rn.Summary)
+	}
+	if rn.Description != "" {
+		annotations[labels.AlertDescriptionLabel] = expand(rn.Description)
+	}
+	return labels.FromMap(annotations)
+}
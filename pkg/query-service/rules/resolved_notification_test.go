@@ -0,0 +1,90 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestResolvedNotifications(t *testing.T) {
+	now := time.Now()
+	resolvedAlert := func(firing time.Duration, receivers ...string) *Alert {
+		return &Alert{
+			State:       model.StateInactive,
+			Labels:      labels.FromMap(map[string]string{"service": "cart"}),
+			Annotations: labels.FromMap(map[string]string{labels.AlertSummaryLabel: "cart is failing"}),
+			FiredAt:     now.Add(-firing),
+			ResolvedAt:  now,
+			Receivers:   receivers,
+		}
+	}
+	send := func(settings *ResolvedNotifications, alert *Alert) []*Alert {
+		rule := &BaseRule{
+			name:                 "Errors",
+			notificationSettings: &NotificationSettings{Resolved: settings},
+			Active:               map[uint64]*Alert{1: alert},
+		}
+		var sent []*Alert
+		rule.SendAlerts(context.Background(), now, time.Minute, time.Minute, func(ctx context.Context, expr string, alerts ...*Alert) {
+			sent = append(sent, alerts...)
+		})
+		return sent
+	}
+
+	assert.Len(t, send(&ResolvedNotifications{}, resolvedAlert(time.Minute)), 1)
+	assert.Empty(t, send(&ResolvedNotifications{Disabled: true}, resolvedAlert(time.Hour)))
+
+	// only the alerts firing long enough are notified as resolved
+	minFiring := &ResolvedNotifications{MinFiringDuration: Duration(10 * time.Minute)}
+	assert.Empty(t, send(minFiring, resolvedAlert(time.Minute)))
+	assert.Len(t, send(minFiring, resolvedAlert(time.Hour)), 1)
+
+	// the channels override the settings of the rule
+	perChannel := &ResolvedNotifications{
+		Disabled: true,
+		Channels: map[string]ResolvedChannelNotifications{
+			"slack":     {MinFiringDuration: Duration(10 * time.Minute)},
+			"pagerduty": {},
+		},
+	}
+	sent := send(perChannel, resolvedAlert(time.Minute, "slack", "pagerduty", "email"))
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, []string{"pagerduty"}, sent[0].Receivers)
+	}
+	assert.Empty(t, send(perChannel, resolvedAlert(time.Minute, "slack", "email")))
+
+	// the resolved alert is notified with its own summary
+	alert := resolvedAlert(time.Hour)
+	sent = send(&ResolvedNotifications{Summary: "$service recovered"}, alert)
+	if assert.Len(t, sent, 1) {
+		assert.Equal(t, "cart recovered", sent[0].Annotations.Get(labels.AlertSummaryLabel))
+		assert.Equal(t, "cart is failing", alert.Annotations.Get(labels.AlertSummaryLabel))
+	}
+
+	assert.Error(t, (&NotificationSettings{Resolved: &ResolvedNotifications{MinFiringDuration: Duration(-time.Minute)}}).Validate())
+}
+
+func TestResolvedNotificationsRouting(t *testing.T) {
+	allChannels := func() []string { return []string{"slack", "pagerduty"} }
+	firing := func(rn *ResolvedNotifications, receivers ...string) *Alert {
+		alert := &Alert{State: model.StateFiring, Receivers: receivers}
+		rule := &BaseRule{notificationSettings: &NotificationSettings{Resolved: rn}}
+		return rule.resolvedNotifications(context.Background(), []*Alert{alert})[0]
+	}
+
+	// the alertmanager doesn't send the resolved alerts to the channels with them disabled
+	assert.Equal(t, []string{"slack/without-resolved", "pagerduty/without-resolved"},
+		routedReceivers(firing(&ResolvedNotifications{Disabled: true}, "slack", "pagerduty"), allChannels))
+	perChannel := &ResolvedNotifications{Channels: map[string]ResolvedChannelNotifications{"slack": {Disabled: true}}}
+	assert.Equal(t, []string{"slack/without-resolved", "pagerduty"}, routedReceivers(firing(perChannel, "slack", "pagerduty"), allChannels))
+	assert.Equal(t, []string{"slack/without-resolved", "pagerduty"}, routedReceivers(firing(perChannel), allChannels))
+
+	// the alerts of the channels sending the resolved alerts keep their routes
+	assert.Equal(t, []string{"slack"}, routedReceivers(firing(&ResolvedNotifications{MinFiringDuration: Duration(time.Hour)}, "slack"), allChannels))
+	assert.Empty(t, routedReceivers(firing(&ResolvedNotifications{}), allChannels))
+	assert.Equal(t, []string{"slack"}, routedReceivers(&Alert{Receivers: []string{"slack"}}, allChannels))
+}
@@ -0,0 +1,50 @@
+package rules
+
+import (
+	"fmt"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// ResultTooLargeError is returned when the query result of the rule holds more series
+// or points than the rule allows, the evaluation is aborted instead of processing it
+type ResultTooLargeError struct {
+	Series    int `json:"series"`
+	Points    int `json:"points"`
+	MaxSeries int `json:"maxSeries"`
+	MaxPoints int `json:"maxPoints"`
+}
+
+func (e *ResultTooLargeError) Error() string {
+	return fmt.Sprintf("result too large, add group-by limits: the query returned %d series and %d points, the rule allows %d series and %d points",
+		e.Series, e.Points, e.MaxSeries, e.MaxPoints)
+}
+
+// checkResultLimits returns a ResultTooLargeError when the result exceeds the limits of the rule
+func (r *BaseRule) checkResultLimits(series, points int) error {
+	if (r.maxResultSeries > 0 && series > r.maxResultSeries) || (r.maxResultPoints > 0 && points > r.maxResultPoints) {
+		return &ResultTooLargeError{
+			Series:    series,
+			Points:    points,
+			MaxSeries: r.maxResultSeries,
+			MaxPoints: r.maxResultPoints,
+		}
+	}
+	return nil
+}
+
+// CheckResultSize returns a ResultTooLargeError when the query results exceed the
+// series or the points the rule allows
+func (r *BaseRule) CheckResultSize(results ...*v3.Result) error {
+	series, points := 0, 0
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		series += len(result.Series)
+		for _, s := range result.Series {
+			points += len(s.Points)
+		}
+	}
+	return r.checkResultLimits(series, points)
+}
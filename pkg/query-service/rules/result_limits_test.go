@@ -0,0 +1,45 @@
+package rules
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+func TestCheckResultSize(t *testing.T) {
+	threshold := 1.0
+	newRule := func(p *PostableRule, opts ...RuleOption) *BaseRule {
+		p.AlertName = "result limits"
+		p.RuleCondition = &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypeBuilder},
+			CompareOp:      ValueIsAbove,
+			MatchType:      AtleastOnce,
+			Target:         &threshold,
+		}
+		rule, err := NewBaseRule("1", p, nil, opts...)
+		assert.NoError(t, err)
+		return rule
+	}
+	result := &v3.Result{QueryName: "A", Series: []*v3.Series{
+		{Labels: map[string]string{"service": "frontend"}, Points: []v3.Point{{Value: 1}, {Value: 2}}},
+		{Labels: map[string]string{"service": "backend"}, Points: []v3.Point{{Value: 3}, {Value: 4}}},
+	}}
+
+	// no limits
+	assert.NoError(t, newRule(&PostableRule{}).CheckResultSize(result))
+	assert.NoError(t, newRule(&PostableRule{}, WithResultLimits(2, 4)).CheckResultSize(result, nil))
+
+	err := newRule(&PostableRule{}, WithResultLimits(1, 0)).CheckResultSize(result)
+	var tooLarge *ResultTooLargeError
+	if assert.True(t, errors.As(err, &tooLarge)) {
+		assert.Equal(t, 2, tooLarge.Series)
+		assert.Equal(t, 4, tooLarge.Points)
+		assert.Contains(t, err.Error(), "add group-by limits")
+	}
+
+	// the limits of the rule override the ones of the rule manager
+	assert.NoError(t, newRule(&PostableRule{MaxResultSeries: 5}, WithResultLimits(1, 0)).CheckResultSize(result))
+	assert.Error(t, newRule(&PostableRule{MaxResultPoints: 3}, WithResultLimits(10, 100)).CheckResultSize(result))
+}
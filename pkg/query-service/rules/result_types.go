@@ -18,6 +18,9 @@ type Sample struct {
 	Metric labels.Labels
 
 	IsMissing bool
+
+	// Series is the points of the series the sample was taken from
+	Series []Point
 }
 
 func (s Sample) String() string {
@@ -0,0 +1,382 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The recurrence rules of the maintenance follow RFC 5545 e.g
+// FREQ=WEEKLY;BYDAY=SU for every Sunday. The supported parts are FREQ
+// (DAILY, WEEKLY, MONTHLY or YEARLY), INTERVAL, COUNT, UNTIL, BYDAY,
+// BYMONTHDAY, BYMONTH and WKST. The occurrences start at the time of day of
+// the recurrence start time, which is the DTSTART of the rule.
+
+type rruleFreq string
+
+const (
+	rruleDaily   rruleFreq = "DAILY"
+	rruleWeekly  rruleFreq = "WEEKLY"
+	rruleMonthly rruleFreq = "MONTHLY"
+	rruleYearly  rruleFreq = "YEARLY"
+)
+
+// maxRRulePeriods bounds the periods expanded for a rule, it covers
+// a daily rule for more than a century
+const maxRRulePeriods = 50000
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// rruleWeekday is a BYDAY value, the ordinal selects the nth weekday of the
+// month e.g 1SU is the first Sunday and -1FR the last Friday, zero selects all
+type rruleWeekday struct {
+	ordinal int
+	weekday time.Weekday
+}
+
+type rrule struct {
+	freq       rruleFreq
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []rruleWeekday
+	byMonthDay []int
+	byMonth    []time.Month
+	weekStart  time.Weekday
+}
+
+// parseRRule parses the recurrence rule, the until time without a zone is in the location
+func parseRRule(value string, loc *time.Location) (*rrule, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	r := &rrule{interval: 1, weekStart: time.Monday}
+
+	for _, part := range strings.Split(value, ";") {
+		if part == "" {
+			continue
+		}
+		key, val, found := strings.Cut(part, "=")
+		if !found || val == "" {
+			return nil, fmt.Errorf("invalid rrule part %s", part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = rruleFreq(strings.ToUpper(val))
+			switch r.freq {
+			case rruleDaily, rruleWeekly, rruleMonthly, rruleYearly:
+			default:
+				return nil, fmt.Errorf("unsupported rrule frequency %s", val)
+			}
+		case "INTERVAL":
+			if r.interval, err = strconv.Atoi(val); err != nil || r.interval < 1 {
+				return nil, fmt.Errorf("invalid rrule interval %s", val)
+			}
+		case "COUNT":
+			if r.count, err = strconv.Atoi(val); err != nil || r.count < 1 {
+				return nil, fmt.Errorf("invalid rrule count %s", val)
+			}
+		case "UNTIL":
+			if r.until, err = parseRRuleTime(val, loc); err != nil {
+				return nil, fmt.Errorf("invalid rrule until %s", val)
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, err := parseRRuleWeekday(day)
+				if err != nil {
+					return nil, err
+				}
+				r.byDay = append(r.byDay, weekday)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return nil, fmt.Errorf("invalid rrule month day %s", day)
+				}
+				r.byMonthDay = append(r.byMonthDay, monthDay)
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(val, ",") {
+				m, err := strconv.Atoi(month)
+				if err != nil || m < 1 || m > 12 {
+					return nil, fmt.Errorf("invalid rrule month %s", month)
+				}
+				r.byMonth = append(r.byMonth, time.Month(m))
+			}
+		case "WKST":
+			weekStart, ok := rruleWeekdays[strings.ToUpper(val)]
+			if !ok {
+				return nil, fmt.Errorf("invalid rrule week start %s", val)
+			}
+			r.weekStart = weekStart
+		default:
+			return nil, fmt.Errorf("unsupported rrule part %s", key)
+		}
+	}
+
+	if r.freq == "" {
+		return nil, fmt.Errorf("rrule frequency is required")
+	}
+	if r.count > 0 && !r.until.IsZero() {
+		return nil, fmt.Errorf("rrule can't have both count and until")
+	}
+	for _, day := range r.byDay {
+		if day.ordinal != 0 && r.freq != rruleMonthly && r.freq != rruleYearly {
+			return nil, fmt.Errorf("rrule weekday ordinals are only supported by the monthly and yearly rules")
+		}
+	}
+	return r, nil
+}
+
+func parseRRuleTime(value string, loc *time.Location) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+	if len(value) == len("20060102") {
+		// the until date includes the whole day
+		day, err := time.ParseInLocation("20060102", value, loc)
+		return day.Add(24*time.Hour - time.Second), err
+	}
+	return time.ParseInLocation("20060102T150405", value, loc)
+}
+
+func parseRRuleWeekday(value string) (rruleWeekday, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return rruleWeekday{}, fmt.Errorf("invalid rrule weekday %s", value)
+	}
+	weekday, ok := rruleWeekdays[value[len(value)-2:]]
+	if !ok {
+		return rruleWeekday{}, fmt.Errorf("invalid rrule weekday %s", value)
+	}
+	day := rruleWeekday{weekday: weekday}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		ordinal, err := strconv.Atoi(prefix)
+		if err != nil || ordinal == 0 || ordinal < -5 || ordinal > 5 {
+			return rruleWeekday{}, fmt.Errorf("invalid rrule weekday %s", value)
+		}
+		day.ordinal = ordinal
+	}
+	return day, nil
+}
+
+// between returns the starts of the occurrences from the start in the range
+// [from, to), the start is the first occurrence if it matches the rule
+func (r *rrule) between(start, from, to time.Time) []time.Time {
+	loc := start.Location()
+	var occurrences []time.Time
+
+	period := 0
+	// without a count the occurrences before the range don't matter,
+	// skip the periods ending before it
+	if r.count == 0 && from.After(start) {
+		period = r.periodsBetween(start, from.In(loc))
+		period -= period%r.interval + r.interval
+		if period < 0 {
+			period = 0
+		}
+	}
+
+	seen := 0
+	for ; period < maxRRulePeriods; period += r.interval {
+		candidates := r.candidates(start, period)
+		if len(candidates) == 0 && r.periodStart(start, period).After(to) {
+			break
+		}
+		for _, candidate := range candidates {
+			if candidate.Before(start) {
+				continue
+			}
+			if !r.until.IsZero() && candidate.After(r.until) {
+				return occurrences
+			}
+			seen++
+			if r.count > 0 && seen > r.count {
+				return occurrences
+			}
+			if !candidate.Before(to) {
+				return occurrences
+			}
+			if !candidate.Before(from) {
+				occurrences = append(occurrences, candidate)
+			}
+		}
+	}
+	return occurrences
+}
+
+// periodsBetween is the number of whole periods of the frequency from the start to the time
+func (r *rrule) periodsBetween(start, t time.Time) int {
+	switch r.freq {
+	case rruleDaily:
+		return daysBetween(start, t)
+	case rruleWeekly:
+		return daysBetween(r.weekOf(start), r.weekOf(t)) / 7
+	case rruleMonthly:
+		return (t.Year()-start.Year())*12 + int(t.Month()-start.Month())
+	case rruleYearly:
+		return t.Year() - start.Year()
+	}
+	return 0
+}
+
+func daysBetween(from, to time.Time) int {
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+// weekOf returns the first day of the week of the time
+func (r *rrule) weekOf(t time.Time) time.Time {
+	offset := (int(t.Weekday()) - int(r.weekStart) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+// periodStart is the first day of the nth period from the start
+func (r *rrule) periodStart(start time.Time, period int) time.Time {
+	switch r.freq {
+	case rruleWeekly:
+		week := r.weekOf(start)
+		return time.Date(week.Year(), week.Month(), week.Day()+7*period, 0, 0, 0, 0, start.Location())
+	case rruleMonthly:
+		return time.Date(start.Year(), start.Month()+time.Month(period), 1, 0, 0, 0, 0, start.Location())
+	case rruleYearly:
+		return time.Date(start.Year()+period, time.January, 1, 0, 0, 0, 0, start.Location())
+	}
+	return time.Date(start.Year(), start.Month(), start.Day()+period, 0, 0, 0, 0, start.Location())
+}
+
+// candidates returns the sorted occurrences of the nth period from the start
+func (r *rrule) candidates(start time.Time, period int) []time.Time {
+	periodStart := r.periodStart(start, period)
+	var days []time.Time
+
+	switch r.freq {
+	case rruleDaily:
+		days = []time.Time{periodStart}
+	case rruleWeekly:
+		weekdays := r.byDay
+		if len(weekdays) == 0 {
+			weekdays = []rruleWeekday{{weekday: start.Weekday()}}
+		}
+		for i := 0; i < 7; i++ {
+			day := periodStart.AddDate(0, 0, i)
+			for _, weekday := range weekdays {
+				if day.Weekday() == weekday.weekday {
+					days = append(days, day)
+					break
+				}
+			}
+		}
+	case rruleMonthly:
+		days = r.monthDays(start, periodStart.Year(), periodStart.Month())
+	case rruleYearly:
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+		for _, month := range months {
+			days = append(days, r.monthDays(start, periodStart.Year(), month)...)
+		}
+	}
+
+	candidates := make([]time.Time, 0, len(days))
+	for _, day := range days {
+		if !r.matches(day) {
+			continue
+		}
+		candidates = append(candidates, time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location()))
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	return candidates
+}
+
+// monthDays returns the days of the month selected by the month days and weekdays,
+// the day of the start when the rule selects neither
+func (r *rrule) monthDays(start time.Time, year int, month time.Month) []time.Time {
+	loc := start.Location()
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+
+	if len(r.byMonthDay) == 0 && len(r.byDay) == 0 {
+		if start.Day() > lastDay {
+			return nil
+		}
+		return []time.Time{time.Date(year, month, start.Day(), 0, 0, 0, 0, loc)}
+	}
+
+	var days []time.Time
+	for day := 1; day <= lastDay; day++ {
+		date := time.Date(year, month, day, 0, 0, 0, 0, loc)
+		if len(r.byMonthDay) > 0 && !containsMonthDay(r.byMonthDay, day, lastDay) {
+			continue
+		}
+		if len(r.byDay) > 0 && !containsWeekday(r.byDay, date, lastDay) {
+			continue
+		}
+		days = append(days, date)
+	}
+	return days
+}
+
+// matches applies the parts of the rule that limit the days of the period
+func (r *rrule) matches(day time.Time) bool {
+	if len(r.byMonth) > 0 {
+		found := false
+		for _, month := range r.byMonth {
+			if day.Month() == month {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.freq == rruleDaily {
+		lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+		if len(r.byMonthDay) > 0 && !containsMonthDay(r.byMonthDay, day.Day(), lastDay) {
+			return false
+		}
+		if len(r.byDay) > 0 && !containsWeekday(r.byDay, day, lastDay) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsMonthDay(monthDays []int, day, lastDay int) bool {
+	for _, monthDay := range monthDays {
+		if monthDay == day || (monthDay < 0 && lastDay+monthDay+1 == day) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWeekday(weekdays []rruleWeekday, date time.Time, lastDay int) bool {
+	for _, weekday := range weekdays {
+		if date.Weekday() != weekday.weekday {
+			continue
+		}
+		switch {
+		case weekday.ordinal == 0:
+			return true
+		case weekday.ordinal > 0 && (date.Day()-1)/7+1 == weekday.ordinal:
+			return true
+		case weekday.ordinal < 0 && (lastDay-date.Day())/7+1 == -weekday.ordinal:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRRuleBetween(t *testing.T) {
+	start := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC) // a monday
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 2, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		name     string
+		rrule    string
+		from, to time.Time
+		expected []time.Time
+	}{
+		{
+			name:     "daily with interval",
+			rrule:    "FREQ=DAILY;INTERVAL=2",
+			from:     day(1, 1),
+			to:       day(1, 8),
+			expected: []time.Time{day(1, 1), day(1, 3), day(1, 5), day(1, 7)},
+		},
+		{
+			name:     "daily skips the periods before the range",
+			rrule:    "FREQ=DAILY;INTERVAL=3",
+			from:     day(3, 1),
+			to:       day(3, 8),
+			expected: []time.Time{day(3, 1), day(3, 4), day(3, 7)},
+		},
+		{
+			name:     "weekly on the weekend",
+			rrule:    "RRULE:FREQ=WEEKLY;BYDAY=SA,SU",
+			from:     day(1, 1),
+			to:       day(1, 15),
+			expected: []time.Time{day(1, 6), day(1, 7), day(1, 13), day(1, 14)},
+		},
+		{
+			name:     "every other week with count",
+			rrule:    "FREQ=WEEKLY;INTERVAL=2;COUNT=3",
+			from:     day(1, 1),
+			to:       day(6, 1),
+			expected: []time.Time{day(1, 1), day(1, 15), day(1, 29)},
+		},
+		{
+			name:     "last friday of the month",
+			rrule:    "FREQ=MONTHLY;BYDAY=-1FR",
+			from:     day(1, 1),
+			to:       day(4, 1),
+			expected: []time.Time{day(1, 26), day(2, 23), day(3, 29)},
+		},
+		{
+			name:     "last day of the month until march",
+			rrule:    "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20240331",
+			from:     day(1, 1),
+			to:       day(6, 1),
+			expected: []time.Time{day(1, 31), day(2, 29), day(3, 31)},
+		},
+		{
+			name:     "yearly in the months",
+			rrule:    "FREQ=YEARLY;BYMONTH=3,9;BYMONTHDAY=15",
+			from:     day(1, 1),
+			to:       day(12, 31),
+			expected: []time.Time{day(3, 15), day(9, 15)},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rule, err := parseRRule(c.rrule, time.UTC)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, rule.between(start, c.from, c.to))
+		})
+	}
+}
+
+func TestParseRRuleErrors(t *testing.T) {
+	for _, rrule := range []string{
+		"",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;BYSETPOS=1",
+	} {
+		_, err := parseRRule(rrule, time.UTC)
+		assert.Error(t, err, rrule)
+	}
+}
+
+func TestScheduleRRuleJSON(t *testing.T) {
+	data := `{"timezone":"Europe/Berlin","recurrence":{"startTime":"2024-04-07T02:00:00Z","duration":"2h","rrule":"FREQ=WEEKLY;BYDAY=SU","exDates":["2024-05-12T02:00:00+02:00"]}}`
+	schedule := Schedule{}
+	assert.NoError(t, schedule.UnmarshalJSON([]byte(data)))
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=SU", schedule.Recurrence.RRule)
+	assert.Len(t, schedule.Recurrence.ExDates, 1)
+
+	marshalled, err := schedule.MarshalJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, string(marshalled), `"rrule":"FREQ=WEEKLY;BYDAY=SU"`)
+	assert.Contains(t, string(marshalled), `"exDates":["2024-05-12T02:00:00+02:00"]`)
+}
@@ -13,12 +13,17 @@ import (
 type Rule interface {
 	ID() string
 	Name() string
+	// OrgID is the org owning the rule, empty for the rules shared by all the orgs
+	OrgID() string
 	Type() RuleType
 
 	Labels() labels.BaseLabels
 	Annotations() labels.BaseLabels
 	Condition() *RuleCondition
 	EvalDelay() time.Duration
+	EvalTimeout() time.Duration
+	Priority() RulePriority
+	ActiveSchedule() *ActiveSchedule
 	EvalWindow() time.Duration
 	HoldDuration() time.Duration
 	State() model.AlertState
@@ -37,7 +42,14 @@ type Rule interface {
 	SetEvaluationTimestamp(time.Time)
 	GetEvaluationTimestamp() time.Time
 
+	// RestoreState loads the alert state saved before a restart, the snapshot of
+	// the active alerts restores the pending alerts
+	RestoreState(ctx context.Context, snapshot []AlertSnapshot) error
+
 	RecordRuleStateHistory(ctx context.Context, prevState, currentState model.AlertState, itemsToAdd []model.RuleStateHistory) error
 
 	SendAlerts(ctx context.Context, ts time.Time, resendDelay time.Duration, interval time.Duration, notifyFunc NotifyFunc)
+
+	// ResolveActiveAlerts resolves the alerts of the rule that is no longer evaluated
+	ResolveActiveAlerts(ctx context.Context, ts time.Time)
 }
@@ -0,0 +1,152 @@
+package rules
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// RuleAlert is an active alert of the rule
+type RuleAlert struct {
+	State       model.AlertState  `json:"state"`
+	Value       float64           `json:"value"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	ActiveAt    time.Time         `json:"activeAt"`
+	// FiredAt is empty for the pending alerts
+	FiredAt *time.Time `json:"firedAt,omitempty"`
+}
+
+// RuleAlertsFilter selects the page of the active alerts of the rule
+type RuleAlertsFilter struct {
+	// State selects the alerts in the state e.g. firing, the empty state selects all the alerts
+	State string
+	// Labels selects the alerts with all the label values
+	Labels map[string]string
+	Limit  int
+	Offset int
+}
+
+// RuleAlerts is a page of the active alerts of the rule, latest first
+type RuleAlerts struct {
+	Total  int         `json:"total"`
+	Alerts []RuleAlert `json:"alerts"`
+}
+
+const defaultRuleAlertsLimit = 100
+
+func (f *RuleAlertsFilter) matches(a *Alert) bool {
+	if f.State != "" && a.State.String() != f.State {
+		return false
+	}
+	for name, value := range f.Labels {
+		if a.Labels.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ListRuleAlerts returns the page of the active alerts of the rule
+// matching the filter, the disabled rules have no active alerts
+func (m *Manager) ListRuleAlerts(ctx context.Context, id string, filter *RuleAlertsFilter) (*RuleAlerts, error) {
+	if filter == nil {
+		filter = &RuleAlertsFilter{}
+	}
+
+	// the stored rule checks the rule is visible to the user
+	if _, err := m.ruleDB.GetStoredRule(ctx, id); err != nil {
+		return nil, err
+	}
+
+	m.mtx.RLock()
+	rule, ok := m.rules[id]
+	m.mtx.RUnlock()
+
+	alerts := []*Alert{}
+	if ok {
+		for _, a := range rule.ActiveAlerts() {
+			if filter.matches(a) {
+				alerts = append(alerts, a)
+			}
+		}
+	}
+	sortAlerts(alerts)
+
+	resp := &RuleAlerts{Total: len(alerts), Alerts: []RuleAlert{}}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultRuleAlertsLimit
+	}
+	if filter.Offset >= len(alerts) {
+		return resp, nil
+	}
+	alerts = alerts[filter.Offset:]
+	if len(alerts) > limit {
+		alerts = alerts[:limit]
+	}
+
+	for _, a := range alerts {
+		resp.Alerts = append(resp.Alerts, newRuleAlert(a))
+	}
+
+	return resp, nil
+}
+
+// sortAlerts sorts the alerts latest first
+func sortAlerts(alerts []*Alert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].ActiveAt.Equal(alerts[j].ActiveAt) {
+			return alerts[i].ActiveAt.After(alerts[j].ActiveAt)
+		}
+		return alerts[i].Labels.String() < alerts[j].Labels.String()
+	})
+}
+
+func newRuleAlert(a *Alert) RuleAlert {
+	ruleAlert := RuleAlert{
+		State:       a.State,
+		Value:       a.Value,
+		Labels:      map[string]string{},
+		Annotations: map[string]string{},
+		ActiveAt:    a.ActiveAt,
+	}
+	if a.Labels != nil {
+		ruleAlert.Labels = a.Labels.Map()
+	}
+	if a.Annotations != nil {
+		ruleAlert.Annotations = a.Annotations.Map()
+	}
+	if !a.FiredAt.IsZero() {
+		firedAt := a.FiredAt
+		ruleAlert.FiredAt = &firedAt
+	}
+	return ruleAlert
+}
+
+// ruleSeriesStates returns the overall state of the rule as recorded in the state
+// history, the number of its active series by state and the latest of them
+func ruleSeriesStates(rule Rule) (model.AlertState, map[string]int, []RuleAlert) {
+	overall := model.StateInactive
+	counts := map[string]int{}
+	alerts := rule.ActiveAlerts()
+	for _, a := range alerts {
+		counts[a.State.String()]++
+		if a.State == model.StateFiring || a.State == model.StateNoData {
+			overall = model.StateFiring
+		}
+	}
+
+	sortAlerts(alerts)
+	if len(alerts) > defaultRuleAlertsLimit {
+		alerts = alerts[:defaultRuleAlertsLimit]
+	}
+	series := make([]RuleAlert, 0, len(alerts))
+	for _, a := range alerts {
+		series = append(series, newRuleAlert(a))
+	}
+	return overall, counts, series
+}
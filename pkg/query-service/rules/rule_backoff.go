@@ -0,0 +1,144 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEvalBackoff is the longest interval between the evaluations of the failing rule
+const DefaultMaxEvalBackoff = 30 * time.Minute
+
+// ErrRuleNotLoaded is returned for the rules not evaluated by this replica
+var ErrRuleNotLoaded = errors.New("rule is not evaluated by this replica")
+
+// RuleBackoff is the backoff of the rule failing its evaluations
+type RuleBackoff struct {
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// Interval is the time between the evaluations of the failing rule
+	Interval       time.Duration `json:"interval"`
+	NextEvaluation time.Time     `json:"nextEvaluation"`
+}
+
+// RuleHealthStatus is the health of the rule returned by the api
+type RuleHealthStatus struct {
+	RuleID               string     `json:"ruleId"`
+	Health               RuleHealth `json:"health"`
+	LastError            string     `json:"lastError,omitempty"`
+	LastEvaluation       time.Time  `json:"lastEvaluation"`
+	EvaluationDurationMs float64    `json:"evaluationDurationMs"`
+	// EvalLagMs is how late the last evaluation started after its scheduled time,
+	// Lagging is set when the lag is past the threshold of the rule manager
+	EvalLagMs float64      `json:"evalLagMs"`
+	Lagging   bool         `json:"lagging"`
+	Backoff   *RuleBackoff `json:"backoff,omitempty"`
+}
+
+// ruleBackoffs spaces the evaluations of the rules failing every evaluation so
+// they don't query at their frequency forever. The interval doubles with every
+// failure up to the cap, the first success evaluates the rule at its frequency again
+type ruleBackoffs struct {
+	max   time.Duration
+	mtx   sync.Mutex
+	rules map[string]*RuleBackoff
+}
+
+func newRuleBackoffs(max time.Duration) *ruleBackoffs {
+	if max <= 0 {
+		max = DefaultMaxEvalBackoff
+	}
+	return &ruleBackoffs{max: max, rules: map[string]*RuleBackoff{}}
+}
+
+// skip reports whether the evaluation of the rule at ts is skipped by its backoff
+func (b *ruleBackoffs) skip(ruleId string, ts time.Time) bool {
+	if b == nil {
+		return false
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	backoff, ok := b.rules[ruleId]
+	return ok && ts.Before(backoff.NextEvaluation)
+}
+
+// record updates the backoff of the rule with the result of its evaluation at ts,
+// the datastore failures are left to the circuit breaker and don't change the backoff
+func (b *ruleBackoffs) record(ruleId string, err error, ts time.Time, frequency time.Duration) {
+	if b == nil || isDatastoreFailure(err) {
+		return
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if err == nil {
+		delete(b.rules, ruleId)
+		return
+	}
+
+	backoff, ok := b.rules[ruleId]
+	if !ok {
+		backoff = &RuleBackoff{}
+		b.rules[ruleId] = backoff
+	}
+	backoff.ConsecutiveFailures++
+	// the rule failing once is evaluated at its frequency, the interval
+	// doubles from the second failure on
+	interval := frequency
+	for i := 1; i < backoff.ConsecutiveFailures && interval < b.max; i++ {
+		interval *= 2
+	}
+	backoff.Interval = max(min(interval, b.max), frequency)
+	backoff.NextEvaluation = ts.Add(backoff.Interval)
+}
+
+// get returns the backoff of the rule, nil when the rule isn't failing
+func (b *ruleBackoffs) get(ruleId string) *RuleBackoff {
+	if b == nil {
+		return nil
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	backoff, ok := b.rules[ruleId]
+	if !ok {
+		return nil
+	}
+	copied := *backoff
+	return &copied
+}
+
+// forget drops the backoff of the rule changed or no longer evaluated
+func (b *ruleBackoffs) forget(ruleId string) {
+	if b == nil {
+		return
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	delete(b.rules, ruleId)
+}
+
+// RuleHealth returns the health of the rule evaluated by this replica and its backoff
+func (m *Manager) RuleHealth(ctx context.Context, id string) (*RuleHealthStatus, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionView); err != nil {
+		return nil, err
+	}
+	m.mtx.RLock()
+	rule, ok := m.rules[id]
+	m.mtx.RUnlock()
+	if !ok {
+		return nil, ErrRuleNotLoaded
+	}
+
+	status := &RuleHealthStatus{
+		RuleID:               id,
+		Health:               rule.Health(),
+		LastEvaluation:       rule.GetEvaluationTimestamp(),
+		EvaluationDurationMs: float64(rule.GetEvaluationDuration()) / float64(time.Millisecond),
+		Backoff:              m.opts.backoffs.get(id),
+	}
+	if err := rule.LastError(); err != nil {
+		status.LastError = err.Error()
+	}
+	lag, lagging := m.opts.evalLag.get(id)
+	status.EvalLagMs, status.Lagging = float64(lag)/float64(time.Millisecond), lagging
+	return status, nil
+}
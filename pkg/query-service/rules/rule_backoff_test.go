@@ -0,0 +1,55 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleBackoffs(t *testing.T) {
+	backoffs := newRuleBackoffs(10 * time.Minute)
+	frequency := time.Minute
+	ts := time.Now().Truncate(time.Minute)
+	failure := errors.New("invalid query")
+
+	assert.False(t, backoffs.skip("1", ts))
+	assert.Nil(t, backoffs.get("1"))
+
+	// the interval doubles from the second failure up to the cap
+	expected := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for _, interval := range expected {
+		backoffs.record("1", failure, ts, frequency)
+		backoff := backoffs.get("1")
+		assert.Equal(t, interval, backoff.Interval)
+		assert.True(t, backoffs.skip("1", ts.Add(interval-frequency/2)))
+		ts = ts.Add(interval)
+		assert.False(t, backoffs.skip("1", ts))
+	}
+	assert.Equal(t, len(expected), backoffs.get("1").ConsecutiveFailures)
+
+	// the first success evaluates the rule at its frequency again
+	backoffs.record("1", nil, ts, frequency)
+	assert.Nil(t, backoffs.get("1"))
+	assert.False(t, backoffs.skip("1", ts.Add(time.Second)))
+
+	// the datastore failures are left to the circuit breaker
+	backoffs.record("1", errQueryFailed, ts, frequency)
+	backoffs.record("1", context.DeadlineExceeded, ts, frequency)
+	assert.Nil(t, backoffs.get("1"))
+
+	// the cap is never shorter than the frequency of the rule
+	backoffs.record("2", failure, ts, time.Hour)
+	assert.Equal(t, time.Hour, backoffs.get("2").Interval)
+	backoffs.forget("2")
+	assert.Nil(t, backoffs.get("2"))
+}
+
+func TestRuleBackoffsNil(t *testing.T) {
+	var backoffs *ruleBackoffs
+	backoffs.record("1", errors.New("invalid query"), time.Now(), time.Minute)
+	assert.False(t, backoffs.skip("1", time.Now()))
+	assert.Nil(t, backoffs.get("1"))
+}
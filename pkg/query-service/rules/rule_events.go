@@ -0,0 +1,119 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RuleEventType is the change to the rule sent to the rule event webhooks
+type RuleEventType string
+
+const (
+	RuleEventCreated  RuleEventType = "created"
+	RuleEventUpdated  RuleEventType = "updated"
+	RuleEventDeleted  RuleEventType = "deleted"
+	RuleEventEnabled  RuleEventType = "enabled"
+	RuleEventDisabled RuleEventType = "disabled"
+)
+
+const ruleEventWebhookTimeout = 10 * time.Second
+
+// RuleEvent is the payload posted to the rule event webhooks, the rule is
+// the definition after the change, or before it for the deleted rules
+type RuleEvent struct {
+	Event     RuleEventType   `json:"event"`
+	RuleId    string          `json:"ruleId"`
+	Rule      json.RawMessage `json:"rule"`
+	Actor     string          `json:"actor"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// ruleEvents posts the rule changes to the webhooks, the events are sent in
+// the background once the change is stored and the failures are only logged
+type ruleEvents struct {
+	urls   []string
+	events []RuleEventType
+	client *http.Client
+}
+
+// newRuleEvents returns nil when no webhook is configured, the empty
+// events send all the events
+func newRuleEvents(urls []string, events []string) *ruleEvents {
+	if len(urls) == 0 {
+		return nil
+	}
+	e := &ruleEvents{
+		urls:   urls,
+		client: &http.Client{Timeout: ruleEventWebhookTimeout},
+	}
+	for _, event := range events {
+		e.events = append(e.events, RuleEventType(event))
+	}
+	return e
+}
+
+// editEvent is the event of the edit from the before to the after definition,
+// the edits that toggle the rule are sent as enabled or disabled
+func editEvent(before, after string) RuleEventType {
+	wasDisabled, isDisabled := newRuleMetadata(before).Disabled, newRuleMetadata(after).Disabled
+	switch {
+	case before != "" && !wasDisabled && isDisabled:
+		return RuleEventDisabled
+	case before != "" && wasDisabled && !isDisabled:
+		return RuleEventEnabled
+	}
+	return RuleEventUpdated
+}
+
+func (e *ruleEvents) send(ctx context.Context, event RuleEventType, ruleId string, rule string) {
+	if e == nil || (len(e.events) > 0 && !slices.Contains(e.events, event)) {
+		return
+	}
+
+	payload := RuleEvent{
+		Event:     event,
+		RuleId:    ruleId,
+		Rule:      json.RawMessage("null"),
+		Actor:     auditActor(ctx),
+		Timestamp: time.Now().UTC(),
+	}
+	if json.Valid([]byte(rule)) {
+		payload.Rule = json.RawMessage(rule)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		zap.L().Error("failed to marshal rule event payload", zap.String("ruleid", ruleId), zap.Error(err))
+		return
+	}
+
+	for _, url := range e.urls {
+		go e.post(url, ruleId, body)
+	}
+}
+
+func (e *ruleEvents) post(url string, ruleId string, body []byte) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		zap.L().Error("failed to create rule event webhook request", zap.String("ruleid", ruleId), zap.Error(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		zap.L().Error("failed to call rule event webhook", zap.String("ruleid", ruleId), zap.Error(err))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		zap.L().Error("rule event webhook returned an error", zap.String("ruleid", ruleId), zap.Int("status", resp.StatusCode))
+	}
+}
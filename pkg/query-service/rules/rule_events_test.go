@@ -0,0 +1,82 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+func TestRuleDBEvents(t *testing.T) {
+	received := make(chan RuleEvent, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event RuleEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	defer server.Close()
+
+	ruleDB := newRuleDB(utils.NewQueryServiceDBForTests(t), nil, newRuleEvents([]string{server.URL}, []string{"created", "disabled", "enabled", "deleted"}))
+	ctx := context.WithValue(context.Background(), constants.ContextUserKey, &model.UserPayload{User: model.User{Email: "user@signoz.io"}})
+
+	next := func() RuleEvent {
+		select {
+		case event := <-received:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("rule event not received")
+		}
+		return RuleEvent{}
+	}
+
+	rule := `{"alert":"Error rate","disabled":false}`
+	id, tx, err := ruleDB.CreateRuleTx(ctx, rule)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+	event := next()
+	assert.Equal(t, RuleEventCreated, event.Event)
+	assert.Equal(t, "1", event.RuleId)
+	assert.JSONEq(t, rule, string(event.Rule))
+	assert.Equal(t, "user@signoz.io", event.Actor)
+
+	// the updates are not subscribed to
+	_, _, err = ruleDB.EditRuleTx(ctx, `{"alert":"Error rate v2","disabled":false}`, "1")
+	assert.NoError(t, err)
+	_, _, err = ruleDB.EditRuleTx(ctx, `{"alert":"Error rate v2","disabled":true}`, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, RuleEventDisabled, next().Event)
+	_, _, err = ruleDB.EditRuleTx(ctx, `{"alert":"Error rate v2","disabled":false}`, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, RuleEventEnabled, next().Event)
+
+	_, _, err = ruleDB.DeleteRuleTx(ctx, "1")
+	assert.NoError(t, err)
+	event = next()
+	assert.Equal(t, RuleEventDeleted, event.Event)
+	assert.JSONEq(t, `{"alert":"Error rate v2","disabled":false}`, string(event.Rule))
+
+	_, tx, err = ruleDB.CreateRuleTx(ctx, rule)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Rollback())
+	select {
+	case event := <-received:
+		t.Fatalf("unexpected rule event %s", event.Event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.Equal(t, int64(1), id)
+}
+
+func TestEditEvent(t *testing.T) {
+	assert.Equal(t, RuleEventUpdated, editEvent(`{"alert":"a"}`, `{"alert":"b"}`))
+	assert.Equal(t, RuleEventDisabled, editEvent(`{"alert":"a"}`, `{"alert":"a","disabled":true}`))
+	assert.Equal(t, RuleEventEnabled, editEvent(`{"alert":"a","disabled":true}`, `{"alert":"a"}`))
+	assert.Equal(t, RuleEventUpdated, editEvent("", `{"alert":"a","disabled":true}`))
+}
@@ -0,0 +1,152 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ruleLeaderLease is the name of the lease of the rule manager leader
+const ruleLeaderLease = "rule_manager"
+
+// RuleLeader is the replica holding the lease to evaluate the rules and send the notifications
+type RuleLeader struct {
+	Holder     string    `json:"holder" db:"holder"`
+	AcquiredAt time.Time `json:"acquiredAt" db:"acquired_at"`
+	RenewedAt  time.Time `json:"renewedAt" db:"renewed_at"`
+}
+
+// RuleLeaderStatus is the leader election as seen by the replica answering
+type RuleLeaderStatus struct {
+	Enabled bool `json:"enabled"`
+	// Replica is the id of the replica answering
+	Replica  string        `json:"replica,omitempty"`
+	IsLeader bool          `json:"isLeader"`
+	Leader   *RuleLeader   `json:"leader,omitempty"`
+	Members  []ShardMember `json:"members,omitempty"`
+}
+
+func (r *ruleDB) AcquireRuleLeader(ctx context.Context, id string, ts time.Time, ttl time.Duration) (*RuleLeader, error) {
+	ts = ts.UTC()
+	// the lease is renewed by its holder and taken by another replica once expired
+	_, err := r.Exec(`INSERT INTO rule_manager_leader (name, holder, acquired_at, renewed_at) VALUES ($1, $2, $3, $3)
+		ON CONFLICT(name) DO UPDATE SET
+			acquired_at=CASE WHEN holder=excluded.holder THEN acquired_at ELSE excluded.acquired_at END,
+			holder=excluded.holder,
+			renewed_at=excluded.renewed_at
+		WHERE holder=excluded.holder OR renewed_at < $4`, ruleLeaderLease, id, ts, ts.Add(-ttl))
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to rule_manager_leader", zap.Error(err))
+		return nil, err
+	}
+	return r.GetRuleLeader(ctx)
+}
+
+func (r *ruleDB) GetRuleLeader(ctx context.Context) (*RuleLeader, error) {
+	leader := &RuleLeader{}
+	err := r.Get(leader, "SELECT holder, acquired_at, renewed_at FROM rule_manager_leader WHERE name=$1", ruleLeaderLease)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return leader, nil
+}
+
+func (r *ruleDB) ReleaseRuleLeader(ctx context.Context, id string) error {
+	_, err := r.Exec("DELETE FROM rule_manager_leader WHERE name=$1 AND holder=$2", ruleLeaderLease, id)
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE from rule_manager_leader", zap.Error(err))
+	}
+	return err
+}
+
+// newRuleLeader returns the membership of the replica taking part in the leader election
+func newRuleLeader(id string) *ruleShards {
+	shards := newRuleShards(id)
+	shards.election = true
+	return shards
+}
+
+// setLeader records the replica holding the lease and reports whether it changed
+func (s *ruleShards) setLeader(leader string, renewedAt time.Time) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if leader == s.id {
+		s.renewedAt = renewedAt
+	}
+	if s.leader == leader {
+		return false
+	}
+	s.leader = leader
+	return true
+}
+
+// leaseExpired reports whether the replica leads without having renewed its lease
+// for the ttl, another replica may have taken over since
+func (s *ruleShards) leaseExpired(now time.Time) bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return s.leader == s.id && now.Sub(s.renewedAt) > shardMemberTTL
+}
+
+// electLeader takes or renews the leader lease, the rules are started or
+// stopped by the reconcile following the change of leader
+func (m *Manager) electLeader(ctx context.Context, now time.Time) error {
+	leader, err := m.ruleDB.AcquireRuleLeader(ctx, m.shards.id, now, shardMemberTTL)
+	if err != nil {
+		return err
+	}
+	holder := ""
+	if leader != nil {
+		holder = leader.Holder
+	}
+	if m.shards.setLeader(holder, now) {
+		zap.L().Info("the rule manager leader changed", zap.String("id", m.shards.id), zap.String("leader", holder))
+	}
+	return nil
+}
+
+// stepDown stops evaluating the rules when the replica could not renew its lease
+func (m *Manager) stepDown() {
+	m.shards.setLeader("", time.Time{})
+	zap.L().Warn("the rule manager leader lease expired, stopping the rules", zap.String("id", m.shards.id))
+	for _, task := range m.RuleTasks() {
+		m.deleteTask(task.Name())
+	}
+	m.shards.mtx.Lock()
+	m.shards.loaded = map[string]time.Time{}
+	m.shards.mtx.Unlock()
+}
+
+// RuleLeaderStatus returns the current leader and the replicas standing by
+func (m *Manager) RuleLeaderStatus(ctx context.Context) (*RuleLeaderStatus, error) {
+	if m.shards == nil || !m.shards.election {
+		return &RuleLeaderStatus{}, nil
+	}
+	leader, err := m.ruleDB.GetRuleLeader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members, err := m.ShardMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := &RuleLeaderStatus{
+		Enabled: true,
+		Replica: m.shards.id,
+		Leader:  leader,
+		Members: members,
+	}
+	// the lease of a crashed leader is still stored until taken over
+	if leader != nil && time.Since(leader.RenewedAt) > shardMemberTTL {
+		status.Leader = nil
+	}
+	status.IsLeader = status.Leader != nil && status.Leader.Holder == m.shards.id
+	return status, nil
+}
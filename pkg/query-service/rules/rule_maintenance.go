@@ -0,0 +1,69 @@
+package rules
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ruleMaintenanceHorizon is how soon the upcoming maintenance of the rule is listed
+const ruleMaintenanceHorizon = 7 * 24 * time.Hour
+
+// RuleMaintenance is the maintenance currently or soon affecting the rule
+type RuleMaintenance struct {
+	Maintenance PlannedMaintenance `json:"maintenance"`
+	// Active is set when the maintenance is affecting the rule now
+	Active bool `json:"active"`
+	// Paused is set when the maintenance skips the evaluation of the rule,
+	// otherwise the notifications of the alerts it matches are muted
+	Paused bool `json:"paused"`
+	// Start and End are the current or next window of the maintenance
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// GetRuleMaintenance returns the maintenance affecting the rule now or within
+// a week, the active maintenance first and the rest by their next window
+func (m *Manager) GetRuleMaintenance(ctx context.Context, id string) ([]RuleMaintenance, error) {
+	s, err := m.ruleDB.GetStoredRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	maintenances, err := m.ruleDB.GetAllPlannedMaintenance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	affecting := []RuleMaintenance{}
+	for i := range maintenances {
+		maintenance := &maintenances[i]
+		if !maintenance.matchesOrg(s.orgID()) || !maintenance.covers(id) || maintenance.Schedule == nil {
+			continue
+		}
+
+		ruleMaintenance := RuleMaintenance{
+			Maintenance: *maintenance,
+			Active:      maintenance.shouldSkip(id, now),
+			Paused:      maintenance.pauses(),
+		}
+		if windows := maintenance.windows(now, now.Add(ruleMaintenanceHorizon)); len(windows) > 0 {
+			ruleMaintenance.Start, ruleMaintenance.End = &windows[0].start, &windows[0].end
+		}
+		if !ruleMaintenance.Active && ruleMaintenance.Start == nil {
+			continue
+		}
+		affecting = append(affecting, ruleMaintenance)
+	}
+
+	sort.SliceStable(affecting, func(i, j int) bool {
+		if affecting[i].Active != affecting[j].Active {
+			return affecting[i].Active
+		}
+		if affecting[i].Start == nil || affecting[j].Start == nil {
+			return affecting[j].Start == nil && affecting[i].Start != nil
+		}
+		return affecting[i].Start.Before(*affecting[j].Start)
+	})
+	return affecting, nil
+}
@@ -0,0 +1,45 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRuleMaintenance(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	rule, err := m.CreateRule(ctx, `{"alert":"Error rate","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	for _, maintenance := range []PlannedMaintenance{
+		{Name: "tomorrow", Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(24 * time.Hour), EndTime: now.Add(25 * time.Hour)}},
+		{Name: "now", Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}, AlertIds: &AlertIds{rule.Id}, Mode: MaintenanceModeMute},
+		{Name: "next month", Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(30 * 24 * time.Hour), EndTime: now.Add(31 * 24 * time.Hour)}},
+		{Name: "other rule", Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}, AlertIds: &AlertIds{"100"}},
+		{Name: "in an hour", Schedule: &Schedule{Timezone: "UTC", StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)}},
+	} {
+		_, err := m.ruleDB.CreatePlannedMaintenance(ctx, maintenance)
+		assert.NoError(t, err)
+	}
+
+	affecting, err := m.GetRuleMaintenance(ctx, rule.Id)
+	assert.NoError(t, err)
+	assert.Len(t, affecting, 3)
+	assert.Equal(t, "now", affecting[0].Maintenance.Name)
+	assert.True(t, affecting[0].Active)
+	assert.False(t, affecting[0].Paused)
+	assert.Equal(t, "in an hour", affecting[1].Maintenance.Name)
+	assert.False(t, affecting[1].Active)
+	assert.True(t, affecting[1].Paused)
+	assert.WithinDuration(t, now.Add(time.Hour), *affecting[1].Start, time.Second)
+	assert.Equal(t, "tomorrow", affecting[2].Maintenance.Name)
+
+	_, err = m.GetRuleMaintenance(ctx, "100")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,197 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// RulePermissionType is the operation allowed on the rule
+type RulePermissionType string
+
+const (
+	RulePermissionView   RulePermissionType = "view"
+	RulePermissionEdit   RulePermissionType = "edit"
+	RulePermissionDelete RulePermissionType = "delete"
+)
+
+// RulePrincipalType is the kind of the principal the permission is granted to
+type RulePrincipalType string
+
+const (
+	// RulePrincipalUser grants the permission to the user with the email
+	RulePrincipalUser RulePrincipalType = "user"
+	// RulePrincipalGroup grants the permission to the users of the group with the id
+	RulePrincipalGroup RulePrincipalType = "group"
+)
+
+// RulePermission grants an operation on the rule to a user or group. The rules
+// without permissions are open to all the users as per their role, once a
+// permission is added only the admins and the principals granted a permission
+// can access the rule. The edit and delete permissions include the view.
+type RulePermission struct {
+	PrincipalType RulePrincipalType  `json:"principalType" db:"principal_type"`
+	Principal     string             `json:"principal" db:"principal"`
+	Permission    RulePermissionType `json:"permission" db:"permission"`
+}
+
+func (p *RulePermission) Validate() error {
+	switch p.PrincipalType {
+	case RulePrincipalUser, RulePrincipalGroup:
+	default:
+		return fmt.Errorf("invalid principal type %s, must be user or group", p.PrincipalType)
+	}
+	if p.Principal == "" {
+		return fmt.Errorf("principal is required")
+	}
+	switch p.Permission {
+	case RulePermissionView, RulePermissionEdit, RulePermissionDelete:
+	default:
+		return fmt.Errorf("invalid permission %s, must be view, edit or delete", p.Permission)
+	}
+	return nil
+}
+
+// RulePermissionError is returned when the user is not allowed the operation on the rule
+type RulePermissionError struct {
+	RuleId     string
+	Permission RulePermissionType
+}
+
+func (e *RulePermissionError) Error() string {
+	return fmt.Sprintf("%s permission on rule %s is required", e.Permission, e.RuleId)
+}
+
+// isRulePermissionGranted checks the permissions of the rule for the user, the
+// requests without a user are made by the query service itself and are allowed
+func isRulePermissionGranted(permissions []RulePermission, user *model.UserPayload, permission RulePermissionType) bool {
+	if len(permissions) == 0 || user == nil || auth.IsAdmin(user) {
+		return true
+	}
+	for _, p := range permissions {
+		matches := (p.PrincipalType == RulePrincipalUser && p.Principal == user.Email) ||
+			(p.PrincipalType == RulePrincipalGroup && p.Principal == user.GroupId)
+		if matches && (p.Permission == permission || permission == RulePermissionView) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ruleDB) GetRulePermissions(ctx context.Context, id string) ([]RulePermission, error) {
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id parameter")
+	}
+
+	permissions := []RulePermission{}
+
+	query := "SELECT principal_type, principal, permission FROM rule_permissions WHERE rule_id=$1 ORDER BY principal_type, principal, permission"
+	if err := r.Select(&permissions, query, intId); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *ruleDB) SetRulePermissions(ctx context.Context, id string, permissions []RulePermission) error {
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		return fmt.Errorf("invalid id parameter")
+	}
+	for i := range permissions {
+		if err := permissions[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	before, err := r.GetRulePermissions(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM rule_permissions WHERE rule_id=$1;`, intId); err != nil {
+		zap.L().Error("Error in Executing DELETE to rule_permissions", zap.Error(err))
+		tx.Rollback()
+		return err
+	}
+	for _, p := range permissions {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO rule_permissions (rule_id, principal_type, principal, permission) VALUES($1,$2,$3,$4);`, intId, p.PrincipalType, p.Principal, p.Permission); err != nil {
+			zap.L().Error("Error in Executing INSERT to rule_permissions", zap.Error(err))
+			tx.Rollback()
+			return err
+		}
+	}
+
+	beforeData, _ := json.Marshal(map[string]interface{}{"permissions": before})
+	afterData, _ := json.Marshal(map[string]interface{}{"permissions": permissions})
+	if err := addAuditLog(ctx, tx, AuditResourceRule, id, AuditActionEdit, string(beforeData), string(afterData)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ruleDB) CheckRulePermission(ctx context.Context, id string, permission RulePermissionType) error {
+	intId, err := strconv.Atoi(id)
+	if err != nil {
+		return fmt.Errorf("invalid id parameter")
+	}
+	if err := r.checkRuleOrg(ctx, intId); err != nil {
+		return err
+	}
+	if permission != RulePermissionView {
+		if err := r.checkRuleProvisioned(ctx, intId); err != nil {
+			return err
+		}
+		if err := r.checkSharedRule(ctx, intId, permission); err != nil {
+			return err
+		}
+	}
+
+	user := common.GetUserFromContext(ctx)
+	if user == nil || auth.IsAdmin(user) {
+		return nil
+	}
+
+	permissions, err := r.GetRulePermissions(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !isRulePermissionGranted(permissions, user, permission) {
+		return &RulePermissionError{RuleId: id, Permission: permission}
+	}
+	return nil
+}
+
+// whereViewable limits the rules query to the rules the user can view
+func whereViewable(ctx context.Context, q *selectQuery) {
+	if condition, args := viewableRule(ctx, "rules.id"); condition != "" {
+		q.where(condition, args...)
+	}
+}
+
+// viewableRule is the condition matching the ids of the rules the user can view,
+// empty when the user can view all the rules
+func viewableRule(ctx context.Context, idColumn string) (string, []interface{}) {
+	user := common.GetUserFromContext(ctx)
+	if user == nil || auth.IsAdmin(user) {
+		return "", nil
+	}
+
+	return fmt.Sprintf(`(NOT EXISTS (SELECT 1 FROM rule_permissions p WHERE p.rule_id=%[1]s)
+		OR EXISTS (SELECT 1 FROM rule_permissions p WHERE p.rule_id=%[1]s AND ((p.principal_type='user' AND p.principal=?) OR (p.principal_type='group' AND p.principal=?))))`, idColumn), []interface{}{user.Email, user.GroupId}
+}
@@ -0,0 +1,49 @@
+package rules
+
+import (
+	"fmt"
+)
+
+// RulePriority orders the evaluations of the rules when the engine is overloaded,
+// the rules without a priority are normal ones
+type RulePriority string
+
+const (
+	RulePriorityCritical RulePriority = "critical"
+	RulePriorityHigh     RulePriority = "high"
+	RulePriorityNormal   RulePriority = "normal"
+	RulePriorityLow      RulePriority = "low"
+)
+
+// rulePriorities are the priorities from the first evaluated to the last
+var rulePriorities = []RulePriority{RulePriorityCritical, RulePriorityHigh, RulePriorityNormal, RulePriorityLow}
+
+// Validate checks the priority is known
+func (p RulePriority) Validate() error {
+	switch p {
+	case "", RulePriorityCritical, RulePriorityHigh, RulePriorityNormal, RulePriorityLow:
+		return nil
+	}
+	return fmt.Errorf("invalid priority %s, supported priorities: %s, %s, %s, %s", p, RulePriorityCritical, RulePriorityHigh, RulePriorityNormal, RulePriorityLow)
+}
+
+// rank is the position of the priority in the evaluation order, zero is evaluated first
+func (p RulePriority) rank() int {
+	switch p {
+	case RulePriorityCritical:
+		return 0
+	case RulePriorityHigh:
+		return 1
+	case RulePriorityLow:
+		return 3
+	}
+	return 2
+}
+
+// String returns the priority, normal when not set
+func (p RulePriority) String() string {
+	if p == "" {
+		return string(RulePriorityNormal)
+	}
+	return string(p)
+}
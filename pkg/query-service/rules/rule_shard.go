@@ -0,0 +1,326 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// shardHeartbeatInterval is how often the replicas renew their membership and
+	// pick up the rules changed on the other replicas
+	shardHeartbeatInterval = 10 * time.Second
+	// shardMemberTTL is how long the replica without a heartbeat keeps its rules
+	shardMemberTTL = 3 * shardHeartbeatInterval
+	// shardVirtualNodes is the number of points of every replica on the hash ring,
+	// more points spread the rules more evenly
+	shardVirtualNodes = 128
+)
+
+// ShardMember is the query service replica evaluating its share of the rules
+type ShardMember struct {
+	Id          string    `json:"id" db:"id"`
+	StartedAt   time.Time `json:"startedAt" db:"started_at"`
+	HeartbeatAt time.Time `json:"heartbeatAt" db:"heartbeat_at"`
+	// Rules is the number of rules evaluated by the replica, only known for the replica answering
+	Rules *int `json:"rules,omitempty" db:"-"`
+}
+
+func (r *ruleDB) HeartbeatShardMember(ctx context.Context, id string, ts time.Time) error {
+	ts = ts.UTC()
+	_, err := r.Exec(`INSERT INTO rule_shard_members (id, started_at, heartbeat_at) VALUES ($1, $2, $2)
+		ON CONFLICT(id) DO UPDATE SET heartbeat_at=excluded.heartbeat_at`, id, ts)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to rule_shard_members", zap.Error(err))
+	}
+	return err
+}
+
+func (r *ruleDB) GetShardMembers(ctx context.Context, since time.Time) ([]ShardMember, error) {
+	members := []ShardMember{}
+	err := r.Select(&members, "SELECT id, started_at, heartbeat_at FROM rule_shard_members WHERE heartbeat_at >= $1 ORDER BY id", since.UTC())
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *ruleDB) DeleteShardMember(ctx context.Context, id string) error {
+	_, err := r.Exec("DELETE FROM rule_shard_members WHERE id=$1", id)
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE from rule_shard_members", zap.Error(err))
+	}
+	return err
+}
+
+// hashRing assigns the rules to the replicas by consistent hashing, a replica
+// joining or leaving only moves the rules of its own points
+type hashRing struct {
+	hashes  []uint32
+	members map[uint32]string
+}
+
+func ringHash(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32()
+}
+
+func newHashRing(members []string) *hashRing {
+	ring := &hashRing{members: map[uint32]string{}}
+	for _, member := range members {
+		for i := 0; i < shardVirtualNodes; i++ {
+			hash := ringHash(fmt.Sprintf("%s#%d", member, i))
+			if existing, ok := ring.members[hash]; ok {
+				// the collisions keep the smallest member so every replica sees the same ring
+				ring.members[hash] = min(existing, member)
+				continue
+			}
+			ring.hashes = append(ring.hashes, hash)
+			ring.members[hash] = member
+		}
+	}
+	slices.Sort(ring.hashes)
+	return ring
+}
+
+// owner returns the replica of the rule, the first point clockwise of the hash of the rule
+func (ring *hashRing) owner(ruleId string) string {
+	if len(ring.hashes) == 0 {
+		return ""
+	}
+	hash := ringHash(ruleId)
+	i := sort.Search(len(ring.hashes), func(i int) bool { return ring.hashes[i] >= hash })
+	if i == len(ring.hashes) {
+		i = 0
+	}
+	return ring.members[ring.hashes[i]]
+}
+
+// ruleShards is the membership of the replica sharing the rules through the rules db
+type ruleShards struct {
+	id string
+	// election evaluates all the rules on the leader instead of sharding them
+	election bool
+
+	mtx     sync.RWMutex
+	members []string
+	ring    *hashRing
+	// leader is the replica holding the leader lease, empty until known, and
+	// renewedAt is when this replica last renewed its lease
+	leader    string
+	renewedAt time.Time
+	// loaded is the update time of the rule each task was loaded from, the tasks
+	// are reloaded when the rule is changed on another replica
+	loaded map[string]time.Time
+}
+
+// newShardID returns the id of the replica, unique across the restarts
+func newShardID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "query-service"
+	}
+	return host + "-" + uuid.New().String()[:8]
+}
+
+func newRuleShards(id string) *ruleShards {
+	return &ruleShards{id: id, loaded: map[string]time.Time{}}
+}
+
+// owns reports whether the rule is evaluated by this replica, all the rules are
+// evaluated when the rules are not sharded
+func (s *ruleShards) owns(ruleId string) bool {
+	if s == nil {
+		return true
+	}
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if s.election {
+		return s.leader == s.id
+	}
+	// the replica evaluates all the rules until it knows the other replicas
+	if s.ring == nil {
+		return true
+	}
+	return s.ring.owner(ruleId) == s.id
+}
+
+// setMembers updates the ring with the live replicas and reports whether they changed
+func (s *ruleShards) setMembers(members []string) bool {
+	if !slices.Contains(members, s.id) {
+		members = append(members, s.id)
+	}
+	slices.Sort(members)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.ring != nil && slices.Equal(s.members, members) {
+		return false
+	}
+	s.members, s.ring = members, newHashRing(members)
+	return true
+}
+
+// heartbeatShard renews the membership of the replica and rebuilds the ring
+// when the replicas changed
+func (m *Manager) heartbeatShard(ctx context.Context) error {
+	now := time.Now()
+	if err := m.ruleDB.HeartbeatShardMember(ctx, m.shards.id, now); err != nil {
+		return err
+	}
+	if m.shards.election {
+		return m.electLeader(ctx, now)
+	}
+	members, err := m.ruleDB.GetShardMembers(ctx, now.Add(-shardMemberTTL))
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.Id)
+	}
+	if m.shards.setMembers(ids) {
+		zap.L().Info("the rule shard members changed", zap.String("id", m.shards.id), zap.Strings("members", m.shards.members))
+	}
+	return nil
+}
+
+// reconcileShard runs the tasks of the rules owned by the replica and stops the
+// others, the rules changed on the other replicas are reloaded
+func (m *Manager) reconcileShard(ctx context.Context) error {
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	stored := map[string]bool{}
+	for _, rec := range storedRules {
+		taskName := prepareTaskName(int64(rec.Id))
+		stored[taskName] = true
+		var updatedAt time.Time
+		if rec.UpdatedAt != nil {
+			updatedAt = *rec.UpdatedAt
+		}
+
+		m.mtx.RLock()
+		_, running := m.tasks[taskName]
+		m.mtx.RUnlock()
+		m.shards.mtx.Lock()
+		loaded, known := m.shards.loaded[taskName]
+		// the tasks added by the api of this replica run the latest rule already
+		if running && !known {
+			m.shards.loaded[taskName], loaded, known = updatedAt, updatedAt, true
+		}
+		m.shards.mtx.Unlock()
+
+		owned := m.shards.owns(fmt.Sprintf("%d", rec.Id))
+		if rec.Disabled || !owned {
+			if running {
+				m.deleteTask(taskName)
+			}
+			continue
+		}
+		if running && loaded.Equal(updatedAt) {
+			continue
+		}
+
+		rule, err := parseStoredRule(rec)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if rule.Disabled {
+			continue
+		}
+		if running {
+			err = m.editTask(rule, taskName)
+		} else {
+			err = m.addTask(rule, taskName)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.shards.mtx.Lock()
+		m.shards.loaded[taskName] = updatedAt
+		m.shards.mtx.Unlock()
+	}
+
+	// the rules deleted on the other replicas
+	for _, task := range m.RuleTasks() {
+		if !stored[task.Name()] {
+			m.deleteTask(task.Name())
+		}
+	}
+	m.shards.mtx.Lock()
+	for taskName := range m.shards.loaded {
+		if !stored[taskName] {
+			delete(m.shards.loaded, taskName)
+		}
+	}
+	m.shards.mtx.Unlock()
+	return errors.Join(errs...)
+}
+
+// shardLoop keeps the membership of the replica and its rules up to date until the manager is stopped
+func (m *Manager) shardLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(shardHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			// the other replicas take over the rules right away
+			if err := m.ruleDB.DeleteShardMember(context.Background(), m.shards.id); err != nil {
+				zap.L().Error("failed to leave the rule shard", zap.Error(err))
+			}
+			if m.shards.election {
+				if err := m.ruleDB.ReleaseRuleLeader(context.Background(), m.shards.id); err != nil {
+					zap.L().Error("failed to release the rule manager leader lease", zap.Error(err))
+				}
+			}
+			return
+		case <-ticker.C:
+			ctx := context.Background()
+			if err := m.heartbeatShard(ctx); err != nil {
+				zap.L().Error("failed to renew the rule shard membership", zap.Error(err))
+				if m.shards.leaseExpired(time.Now()) {
+					m.stepDown()
+				}
+				continue
+			}
+			if err := m.reconcileShard(ctx); err != nil {
+				zap.L().Error("failed to reconcile the rules of the shard", zap.Error(err))
+			}
+		}
+	}
+}
+
+// ShardMembers returns the replicas sharing the rules, nil when the rules are not sharded
+func (m *Manager) ShardMembers(ctx context.Context) ([]ShardMember, error) {
+	if m.shards == nil {
+		return nil, nil
+	}
+	members, err := m.ruleDB.GetShardMembers(ctx, time.Now().Add(-shardMemberTTL))
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if members[i].Id == m.shards.id {
+			count := len(m.RuleTasks())
+			members[i].Rules = &count
+		}
+	}
+	return members, nil
+}
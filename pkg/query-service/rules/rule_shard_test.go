@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashRingOwner(t *testing.T) {
+	ring := newHashRing([]string{"a", "b", "c"})
+	owners := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		owners[ring.owner(fmt.Sprintf("%d", i))]++
+	}
+	// every replica evaluates a share of the rules
+	assert.Len(t, owners, 3)
+	for member, count := range owners {
+		assert.Greater(t, count, 500, member)
+	}
+
+	// a replica leaving only moves its own rules
+	smaller := newHashRing([]string{"a", "c"})
+	for i := 0; i < 3000; i++ {
+		id := fmt.Sprintf("%d", i)
+		if owner := ring.owner(id); owner != "b" {
+			assert.Equal(t, owner, smaller.owner(id))
+		}
+	}
+
+	assert.Equal(t, "", newHashRing(nil).owner("1"))
+}
+
+func TestRuleShardsOwns(t *testing.T) {
+	var unsharded *ruleShards
+	assert.True(t, unsharded.owns("1"))
+
+	shards := newRuleShards("a")
+	// all the rules until the other replicas are known
+	assert.True(t, shards.owns("1"))
+
+	assert.True(t, shards.setMembers([]string{"b"}))
+	assert.Equal(t, []string{"a", "b"}, shards.members)
+	assert.False(t, shards.setMembers([]string{"a", "b"}))
+
+	owned := 0
+	for i := 0; i < 100; i++ {
+		if shards.owns(fmt.Sprintf("%d", i)) {
+			owned++
+		}
+	}
+	assert.Greater(t, owned, 0)
+	assert.Less(t, owned, 100)
+}
+
+func TestShardMembers(t *testing.T) {
+	m := newTestManager(t)
+	m.shards = newRuleShards("a")
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.NoError(t, m.ruleDB.HeartbeatShardMember(ctx, "b", now.Add(-time.Hour)))
+	assert.NoError(t, m.ruleDB.HeartbeatShardMember(ctx, "c", now))
+	assert.NoError(t, m.heartbeatShard(ctx))
+	// the replica without a recent heartbeat lost its rules
+	assert.Equal(t, []string{"a", "c"}, m.shards.members)
+
+	members, err := m.ShardMembers(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, members, 2) {
+		assert.Equal(t, "a", members[0].Id)
+		assert.NotNil(t, members[0].Rules)
+		assert.Nil(t, members[1].Rules)
+	}
+
+	assert.NoError(t, m.ruleDB.DeleteShardMember(ctx, "c"))
+	assert.NoError(t, m.heartbeatShard(ctx))
+	assert.Equal(t, []string{"a"}, m.shards.members)
+}
+
+func TestRuleLeaderElection(t *testing.T) {
+	m := newTestManager(t)
+	m.shards = newRuleLeader("a")
+	ctx := context.Background()
+	now := time.Now()
+
+	// the standby evaluates nothing until elected
+	assert.False(t, m.shards.owns("1"))
+
+	assert.NoError(t, m.heartbeatShard(ctx))
+	assert.True(t, m.shards.owns("1"))
+	status, err := m.RuleLeaderStatus(ctx)
+	assert.NoError(t, err)
+	assert.True(t, status.IsLeader)
+	assert.Equal(t, "a", status.Leader.Holder)
+
+	// the lease is kept by its holder
+	leader, err := m.ruleDB.AcquireRuleLeader(ctx, "b", now, shardMemberTTL)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", leader.Holder)
+
+	// and taken over once expired
+	leader, err = m.ruleDB.AcquireRuleLeader(ctx, "b", now.Add(2*shardMemberTTL), shardMemberTTL)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", leader.Holder)
+	assert.NoError(t, m.electLeader(ctx, now.Add(2*shardMemberTTL)))
+	assert.False(t, m.shards.owns("1"))
+
+	// the release only gives up the lease of its holder
+	assert.NoError(t, m.ruleDB.ReleaseRuleLeader(ctx, "a"))
+	leader, err = m.ruleDB.GetRuleLeader(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", leader.Holder)
+	assert.NoError(t, m.ruleDB.ReleaseRuleLeader(ctx, "b"))
+	leader, err = m.ruleDB.GetRuleLeader(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, leader)
+}
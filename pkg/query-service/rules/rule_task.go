@@ -9,6 +9,7 @@ import (
 
 	opentracing "github.com/opentracing/opentracing-go"
 	"go.signoz.io/signoz/pkg/query-service/common"
+	"go.signoz.io/signoz/pkg/query-service/model"
 	"go.signoz.io/signoz/pkg/query-service/utils/labels"
 	"go.uber.org/zap"
 )
@@ -91,6 +92,15 @@ func NewQueryOriginContext(ctx context.Context, data map[string]interface{}) con
 func (g *RuleTask) Run(ctx context.Context) {
 	defer close(g.terminated)
 
+	// restore the alert state saved before the last restart so that
+	// firing alerts are not reset to pending
+	for _, rule := range g.rules {
+		snapshot := loadAlertSnapshots(ctx, g.ruleDB, rule.ID(), time.Now())
+		if err := rule.RestoreState(ctx, snapshot); err != nil {
+			zap.L().Warn("failed to restore rule state from history", zap.String("ruleid", rule.ID()), zap.Error(err))
+		}
+	}
+
 	// Wait an initial amount to have consistently slotted intervals.
 	evalTimestamp := g.EvalTimestamp(time.Now().UnixNano()).Add(g.frequency)
 	zap.L().Debug("group run to begin at", zap.Time("evalTimestamp", evalTimestamp))
@@ -120,11 +130,11 @@ func (g *RuleTask) Run(ctx context.Context) {
 		g.setLastEvaluation(start)
 	}
 
-	// The assumption here is that since the ticker was started after having
-	// waited for `evalTimestamp` to pass, the ticks will trigger soon
-	// after each `evalTimestamp + N * g.frequency` occurrence.
-	tick := time.NewTicker(g.frequency)
-	defer tick.Stop()
+	// The timer is set from the wall clock for every `evalTimestamp + N * g.frequency`
+	// slot rather than ticking every g.frequency, so the long evaluations don't push
+	// the later ones and the slots passed while evaluating are skipped.
+	timer := time.NewTimer(time.Until(evalTimestamp.Add(g.frequency)))
+	defer timer.Stop()
 
 	iter()
 
@@ -137,10 +147,12 @@ func (g *RuleTask) Run(ctx context.Context) {
 			select {
 			case <-g.done:
 				return
-			case <-tick.C:
-				missed := (time.Since(evalTimestamp) / g.frequency) - 1
-				evalTimestamp = evalTimestamp.Add((missed + 1) * g.frequency)
+			case <-timer.C:
+				var missed int64
+				evalTimestamp, missed = nextEvalTimestamp(evalTimestamp, time.Now(), g.frequency)
+				recordMissedEvals(g.rules, missed)
 				iter()
+				timer.Reset(time.Until(evalTimestamp.Add(g.frequency)))
 			}
 		}
 	}
@@ -305,22 +317,45 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 		zap.L().Error("Error in processing sql query", zap.Error(err))
 	}
 
-	for i, rule := range g.rules {
+	silences, err := g.ruleDB.GetSilences(ctx, &SilenceFilter{Status: SilenceStatusActive, At: ts})
+	if err != nil {
+		zap.L().Error("failed to fetch the active silences", zap.Error(err))
+	}
+
+	acks, err := g.ruleDB.GetAlertAcks(ctx, ts.Add(-AlertAckRetention))
+	if err != nil {
+		zap.L().Error("failed to fetch the alert acknowledgements", zap.Error(err))
+	}
+
+	// the rules of all the tasks share the results of their identical queries
+	ctx = withQueryResultCache(ctx, g.opts.resultCache)
+	ctx = withTemporalityCache(ctx, g.opts.temporalityCache)
+
+	for _, rule := range g.rules {
 		if rule == nil {
 			continue
 		}
 
-		shouldSkip := false
-		for _, m := range maintenance {
-			zap.L().Info("checking if rule should be skipped", zap.String("rule", rule.ID()), zap.Any("maintenance", m))
-			if m.shouldSkip(rule.ID(), ts) {
-				shouldSkip = true
-				break
-			}
+		skipping, muting := maintenanceForRule(maintenance, rule, ts)
+		recorder := newSuppressionRecorder(g.ruleDB, g.opts.suppressions, rule, ts, g.frequency)
+		if skipping != nil {
+			zap.L().Info("rule should be skipped", zap.String("rule", rule.ID()))
+			recorder.record(skipping, rule.ActiveAlerts())
+			recorder.finish(ctx)
+			continue
 		}
 
-		if shouldSkip {
-			zap.L().Info("rule should be skipped", zap.String("rule", rule.ID()))
+		if !rule.ActiveSchedule().shouldEvaluate(ts) {
+			zap.L().Info("rule is outside the active schedule", zap.String("rule", rule.ID()))
+			// the alerts firing when the schedule closes are resolved instead of staying frozen
+			rule.ResolveActiveAlerts(ctx, ts)
+			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, ackMatchingAlerts(silenceMatchingAlerts(muteMatchingAlerts(g.notify, muting, recorder), silencesForRule(silences, rule)), acks))
+			recorder.finish(ctx)
+			continue
+		}
+
+		if g.opts.backoffs.skip(rule.ID(), ts) {
+			zap.L().Debug("rule is backing off after failed evaluations", zap.String("rule", rule.ID()))
 			continue
 		}
 
@@ -330,7 +365,8 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 		default:
 		}
 
-		func(i int, rule Rule) {
+		// the evaluations of all the tasks share the workers of the manager
+		g.opts.evalPool.run(g.done, rule.Priority(), func() {
 			sp, ctx := opentracing.StartSpanFromContext(ctx, "rule")
 
 			sp.SetTag("name", rule.Name())
@@ -349,7 +385,25 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 			}
 			ctx = context.WithValue(ctx, common.LogCommentKey, kvs)
 
-			_, err := rule.Eval(ctx, ts)
+			// the evaluation waited for a worker when the engine is overloaded
+			g.opts.evalLag.record(rule.ID(), ts, time.Now())
+
+			// the evaluations are paused while the datastore is failing
+			if err := g.opts.breaker.allow(time.Now(), rule.Priority()); err != nil {
+				rule.SetHealth(HealthBad)
+				rule.SetLastError(err)
+				return
+			}
+
+			stats := &model.QueryStats{}
+			trace := g.opts.tracer.start(rule, ts)
+			evalStart := time.Now()
+			err := evalWithTimeout(withEvalTrace(model.NewQueryStatsContext(ctx, stats), trace), rule, ts, g.frequency)
+			trace.finish(rule, time.Since(evalStart), err, stats)
+			g.opts.breaker.record(err, time.Now())
+			g.opts.backoffs.record(rule.ID(), err, ts, g.frequency)
+			observeEval(rule, time.Since(evalStart), err)
+			g.opts.costs.record(rule, evalStart, time.Since(evalStart), stats)
 			if err != nil {
 				rule.SetHealth(HealthBad)
 				rule.SetLastError(err)
@@ -364,8 +418,23 @@ func (g *RuleTask) Eval(ctx context.Context, ts time.Time) {
 				return
 			}
 
-			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, g.notify)
+			observeResolved(rule, ts)
+
+			// the suppressions follow the firing alerts muted by the maintenance, whether they are notified or not
+			recorder.recordMuted(muting, rule.ActiveAlerts())
+			recorder.finish(ctx)
+
+			// the shadow definition is evaluated once the alerts of the live one are sent
+			defer g.opts.shadows.eval(ctx, rule, ts, g.frequency)
+
+			// the resolves are kept until the schedule opens again
+			if !rule.ActiveSchedule().shouldNotify(ts) {
+				zap.L().Debug("rule is outside the active schedule, skipping notifications", zap.String("rule", rule.ID()))
+				return
+			}
+
+			rule.SendAlerts(ctx, ts, g.opts.ResendDelay, g.frequency, ackMatchingAlerts(silenceMatchingAlerts(muteMatchingAlerts(g.notify, muting, recorder), silencesForRule(silences, rule)), acks))
 
-		}(i, rule)
+		})
 	}
 }
@@ -0,0 +1,368 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// RuleTemplateVariableType is the kind of value accepted by a template variable
+type RuleTemplateVariableType string
+
+const (
+	RuleTemplateVariableString   RuleTemplateVariableType = "string"
+	RuleTemplateVariableNumber   RuleTemplateVariableType = "number"
+	RuleTemplateVariableDuration RuleTemplateVariableType = "duration"
+)
+
+// RuleTemplateVariable is a parameter of the rule template
+type RuleTemplateVariable struct {
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	Type        RuleTemplateVariableType `json:"type"`
+	// Default is used when the variable is not given, the variables
+	// without a default are required
+	Default string `json:"default,omitempty"`
+}
+
+// RuleTemplate is a parameterized rule definition. The rule is a json
+// text/template using [[ ]] as delimiters so that the {{ }} templates
+// of the labels and annotations are kept as they are. The string and
+// duration variables are placed inside the json strings and the number
+// variables outside.
+type RuleTemplate struct {
+	Id          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Category    string                 `json:"category"`
+	Variables   []RuleTemplateVariable `json:"variables"`
+	Rule        string                 `json:"rule"`
+}
+
+// ruleTemplates is the catalog of the rule templates
+var ruleTemplates = []RuleTemplate{
+	{
+		Id:          "high-error-rate",
+		Name:        "High error rate",
+		Description: "Alerts when the percentage of the calls to the service that end in an error is above the threshold",
+		Category:    "apm",
+		Variables: []RuleTemplateVariable{
+			{Name: "service", Description: "Name of the service", Type: RuleTemplateVariableString},
+			{Name: "threshold", Description: "Error rate in percent", Type: RuleTemplateVariableNumber, Default: "5"},
+			{Name: "evalWindow", Description: "Window the error rate is computed over", Type: RuleTemplateVariableDuration, Default: "5m"},
+		},
+		Rule: `{
+	"alert": "High error rate in [[ .service ]]",
+	"alertType": "METRIC_BASED_ALERT",
+	"ruleType": "threshold_rule",
+	"evalWindow": "[[ .evalWindow ]]",
+	"frequency": "1m",
+	"condition": {
+		"compositeQuery": {
+			"queryType": "builder",
+			"panelType": "graph",
+			"builderQueries": {
+				"A": {
+					"queryName": "A",
+					"dataSource": "metrics",
+					"aggregateOperator": "sum_rate",
+					"aggregateAttribute": {"key": "signoz_calls_total", "dataType": "float64", "type": "", "isColumn": true},
+					"timeAggregation": "rate",
+					"spaceAggregation": "sum",
+					"filters": {"op": "AND", "items": [
+						{"key": {"key": "service_name", "dataType": "string", "type": "tag", "isColumn": false}, "op": "=", "value": "[[ .service ]]"},
+						{"key": {"key": "status_code", "dataType": "string", "type": "tag", "isColumn": false}, "op": "=", "value": "STATUS_CODE_ERROR"}
+					]},
+					"expression": "A",
+					"disabled": true
+				},
+				"B": {
+					"queryName": "B",
+					"dataSource": "metrics",
+					"aggregateOperator": "sum_rate",
+					"aggregateAttribute": {"key": "signoz_calls_total", "dataType": "float64", "type": "", "isColumn": true},
+					"timeAggregation": "rate",
+					"spaceAggregation": "sum",
+					"filters": {"op": "AND", "items": [
+						{"key": {"key": "service_name", "dataType": "string", "type": "tag", "isColumn": false}, "op": "=", "value": "[[ .service ]]"}
+					]},
+					"expression": "B",
+					"disabled": true
+				},
+				"F1": {
+					"queryName": "F1",
+					"expression": "A*100/B"
+				}
+			}
+		},
+		"selectedQueryName": "F1",
+		"op": "1",
+		"matchType": "3",
+		"target": [[ .threshold ]],
+		"targetUnit": "percent"
+	},
+	"labels": {"severity": "critical", "service": "[[ .service ]]"},
+	"annotations": {
+		"description": "The error rate of [[ .service ]] is {{$value}}% which is above [[ .threshold ]]%",
+		"summary": "High error rate in [[ .service ]]"
+	},
+	"tags": ["template:high-error-rate"]
+}`,
+	},
+	{
+		Id:          "high-p99-latency",
+		Name:        "High p99 latency",
+		Description: "Alerts when the p99 latency of the service is above the threshold",
+		Category:    "apm",
+		Variables: []RuleTemplateVariable{
+			{Name: "service", Description: "Name of the service", Type: RuleTemplateVariableString},
+			{Name: "threshold", Description: "Latency in milliseconds", Type: RuleTemplateVariableNumber, Default: "500"},
+			{Name: "evalWindow", Description: "Window the latency is computed over", Type: RuleTemplateVariableDuration, Default: "5m"},
+		},
+		Rule: `{
+	"alert": "High p99 latency in [[ .service ]]",
+	"alertType": "METRIC_BASED_ALERT",
+	"ruleType": "threshold_rule",
+	"evalWindow": "[[ .evalWindow ]]",
+	"frequency": "1m",
+	"condition": {
+		"compositeQuery": {
+			"queryType": "builder",
+			"panelType": "graph",
+			"builderQueries": {
+				"A": {
+					"queryName": "A",
+					"dataSource": "metrics",
+					"aggregateOperator": "p99",
+					"aggregateAttribute": {"key": "signoz_latency", "dataType": "float64", "type": "", "isColumn": true},
+					"timeAggregation": "",
+					"spaceAggregation": "p99",
+					"filters": {"op": "AND", "items": [
+						{"key": {"key": "service_name", "dataType": "string", "type": "tag", "isColumn": false}, "op": "=", "value": "[[ .service ]]"}
+					]},
+					"expression": "A"
+				}
+			}
+		},
+		"op": "1",
+		"matchType": "3",
+		"target": [[ .threshold ]],
+		"targetUnit": "ms"
+	},
+	"labels": {"severity": "warning", "service": "[[ .service ]]"},
+	"annotations": {
+		"description": "The p99 latency of [[ .service ]] is {{$value}} which is above [[ .threshold ]]ms",
+		"summary": "High p99 latency in [[ .service ]]"
+	},
+	"tags": ["template:high-p99-latency"]
+}`,
+	},
+	{
+		Id:          "error-logs",
+		Name:        "Error logs",
+		Description: "Alerts when the service logs more errors than the threshold",
+		Category:    "logs",
+		Variables: []RuleTemplateVariable{
+			{Name: "service", Description: "Name of the service", Type: RuleTemplateVariableString},
+			{Name: "threshold", Description: "Number of the error logs", Type: RuleTemplateVariableNumber, Default: "10"},
+			{Name: "evalWindow", Description: "Window the error logs are counted over", Type: RuleTemplateVariableDuration, Default: "5m"},
+		},
+		Rule: `{
+	"alert": "Error logs in [[ .service ]]",
+	"alertType": "LOGS_BASED_ALERT",
+	"ruleType": "threshold_rule",
+	"evalWindow": "[[ .evalWindow ]]",
+	"frequency": "1m",
+	"condition": {
+		"compositeQuery": {
+			"queryType": "builder",
+			"panelType": "graph",
+			"builderQueries": {
+				"A": {
+					"queryName": "A",
+					"dataSource": "logs",
+					"aggregateOperator": "count",
+					"aggregateAttribute": {"key": "", "dataType": "", "type": "", "isColumn": false},
+					"filters": {"op": "AND", "items": [
+						{"key": {"key": "service.name", "dataType": "string", "type": "resource", "isColumn": true}, "op": "=", "value": "[[ .service ]]"},
+						{"key": {"key": "severity_text", "dataType": "string", "type": "", "isColumn": true}, "op": "=", "value": "ERROR"}
+					]},
+					"expression": "A"
+				}
+			}
+		},
+		"op": "1",
+		"matchType": "4",
+		"target": [[ .threshold ]]
+	},
+	"labels": {"severity": "warning", "service": "[[ .service ]]"},
+	"annotations": {
+		"description": "[[ .service ]] logged {{$value}} errors which is above [[ .threshold ]]",
+		"summary": "Error logs in [[ .service ]]"
+	},
+	"tags": ["template:error-logs"]
+}`,
+	},
+	{
+		Id:          "host-high-cpu",
+		Name:        "High host CPU usage",
+		Description: "Alerts when the CPU usage of the host is above the threshold",
+		Category:    "infra",
+		Variables: []RuleTemplateVariable{
+			{Name: "host", Description: "Name of the host", Type: RuleTemplateVariableString},
+			{Name: "threshold", Description: "CPU usage in percent", Type: RuleTemplateVariableNumber, Default: "80"},
+			{Name: "evalWindow", Description: "Window the CPU usage is computed over", Type: RuleTemplateVariableDuration, Default: "15m"},
+		},
+		Rule: `{
+	"alert": "High CPU usage on [[ .host ]]",
+	"alertType": "METRIC_BASED_ALERT",
+	"ruleType": "threshold_rule",
+	"evalWindow": "[[ .evalWindow ]]",
+	"frequency": "1m",
+	"condition": {
+		"compositeQuery": {
+			"queryType": "builder",
+			"panelType": "graph",
+			"builderQueries": {
+				"A": {
+					"queryName": "A",
+					"dataSource": "metrics",
+					"aggregateOperator": "sum_rate",
+					"aggregateAttribute": {"key": "system_cpu_time", "dataType": "float64", "type": "Sum", "isColumn": true},
+					"timeAggregation": "rate",
+					"spaceAggregation": "sum",
+					"filters": {"op": "AND", "items": [
+						{"key": {"key": "host_name", "dataType": "string", "type": "tag", "isColumn": false}, "op": "=", "value": "[[ .host ]]"},
+						{"key": {"key": "state", "dataType": "string", "type": "tag", "isColumn": false}, "op": "!=", "value": "idle"}
+					]},
+					"expression": "A",
+					"disabled": true
+				},
+				"B": {
+					"queryName": "B",
+					"dataSource": "metrics",
+					"aggregateOperator": "sum_rate",
+					"aggregateAttribute": {"key": "system_cpu_time", "dataType": "float64", "type": "Sum", "isColumn": true},
+					"timeAggregation": "rate",
+					"spaceAggregation": "sum",
+					"filters": {"op": "AND", "items": [
+						{"key": {"key": "host_name", "dataType": "string", "type": "tag", "isColumn": false}, "op": "=", "value": "[[ .host ]]"}
+					]},
+					"expression": "B",
+					"disabled": true
+				},
+				"F1": {
+					"queryName": "F1",
+					"expression": "A*100/B"
+				}
+			}
+		},
+		"selectedQueryName": "F1",
+		"op": "1",
+		"matchType": "2",
+		"target": [[ .threshold ]],
+		"targetUnit": "percent"
+	},
+	"labels": {"severity": "warning", "host": "[[ .host ]]"},
+	"annotations": {
+		"description": "The CPU usage of [[ .host ]] is {{$value}}% which is above [[ .threshold ]]%",
+		"summary": "High CPU usage on [[ .host ]]"
+	},
+	"tags": ["template:host-high-cpu"]
+}`,
+	},
+}
+
+// GetRuleTemplates lists the rule templates in the catalog
+func GetRuleTemplates() []RuleTemplate {
+	return ruleTemplates
+}
+
+// GetRuleTemplate fetches the rule template with the given id from the catalog
+func GetRuleTemplate(id string) (*RuleTemplate, error) {
+	for i := range ruleTemplates {
+		if ruleTemplates[i].Id == id {
+			return &ruleTemplates[i], nil
+		}
+	}
+	return nil, fmt.Errorf("rule template %s not found", id)
+}
+
+// Render fills the template with the variables and returns the rule definition in json
+func (t *RuleTemplate) Render(values map[string]interface{}) (string, error) {
+	known := make(map[string]struct{}, len(t.Variables))
+	data := make(map[string]string, len(t.Variables))
+	for _, variable := range t.Variables {
+		known[variable.Name] = struct{}{}
+
+		value := variable.Default
+		if v, ok := values[variable.Name]; ok && v != nil {
+			value = fmt.Sprint(v)
+		}
+		if value == "" {
+			return "", fmt.Errorf("variable %s is required", variable.Name)
+		}
+
+		rendered, err := renderTemplateVariable(variable, value)
+		if err != nil {
+			return "", err
+		}
+		data[variable.Name] = rendered
+	}
+	for name := range values {
+		if _, ok := known[name]; !ok {
+			return "", fmt.Errorf("unknown variable %s for rule template %s", name, t.Id)
+		}
+	}
+
+	tmpl, err := template.New(t.Id).Delims("[[", "]]").Option("missingkey=error").Parse(t.Rule)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse rule template %s: %w", t.Id, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render rule template %s: %w", t.Id, err)
+	}
+	return buf.String(), nil
+}
+
+// renderTemplateVariable validates the value of the variable and formats it
+// for the json template, the strings are escaped so that they can't break
+// out of the json string they are placed in
+func renderTemplateVariable(variable RuleTemplateVariable, value string) (string, error) {
+	switch variable.Type {
+	case RuleTemplateVariableNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", fmt.Errorf("variable %s must be a number", variable.Name)
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	case RuleTemplateVariableDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return "", fmt.Errorf("variable %s must be a duration e.g 5m", variable.Name)
+		}
+	}
+	quoted, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(string(quoted), `"`), nil
+}
+
+// InstantiateRuleTemplate creates a rule from the template filled with the variables
+func (m *Manager) InstantiateRuleTemplate(ctx context.Context, id string, values map[string]interface{}) (*GettableRule, error) {
+	tmpl, err := GetRuleTemplate(id)
+	if err != nil {
+		return nil, err
+	}
+	ruleStr, err := tmpl.Render(values)
+	if err != nil {
+		return nil, err
+	}
+	return m.CreateRule(ctx, ruleStr)
+}
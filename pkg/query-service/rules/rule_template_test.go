@@ -0,0 +1,66 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleTemplatesRender(t *testing.T) {
+	for _, tmpl := range GetRuleTemplates() {
+		values := map[string]interface{}{}
+		for _, variable := range tmpl.Variables {
+			if variable.Default == "" {
+				values[variable.Name] = `my "quoted" value`
+			}
+		}
+
+		ruleStr, err := tmpl.Render(values)
+		if !assert.NoError(t, err, tmpl.Id) {
+			continue
+		}
+		rule, err := ParsePostableRule([]byte(ruleStr))
+		if !assert.NoError(t, err, tmpl.Id) {
+			continue
+		}
+		assert.Contains(t, rule.AlertName, `my "quoted" value`, tmpl.Id)
+		assert.Contains(t, rule.Annotations["description"], "{{$value}}", tmpl.Id)
+	}
+}
+
+func TestRuleTemplateVariables(t *testing.T) {
+	tmpl, err := GetRuleTemplate("high-error-rate")
+	assert.NoError(t, err)
+
+	ruleStr, err := tmpl.Render(map[string]interface{}{"service": "checkout", "threshold": 2.5, "evalWindow": "10m"})
+	assert.NoError(t, err)
+	rule, err := ParsePostableRule([]byte(ruleStr))
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, *rule.RuleCondition.Target)
+	assert.Equal(t, Duration(10*time.Minute), rule.EvalWindow)
+	assert.Equal(t, "checkout", rule.Labels["service"])
+
+	_, err = tmpl.Render(map[string]interface{}{})
+	assert.EqualError(t, err, "variable service is required")
+	_, err = tmpl.Render(map[string]interface{}{"service": "checkout", "threshold": "5, \"disabled\": true"})
+	assert.EqualError(t, err, "variable threshold must be a number")
+	_, err = tmpl.Render(map[string]interface{}{"service": "checkout", "evalWindow": "5 minutes"})
+	assert.EqualError(t, err, "variable evalWindow must be a duration e.g 5m")
+	_, err = tmpl.Render(map[string]interface{}{"service": "checkout", "team": "payments"})
+	assert.EqualError(t, err, "unknown variable team for rule template high-error-rate")
+
+	_, err = GetRuleTemplate("unknown")
+	assert.Error(t, err)
+}
+
+func TestInstantiateRuleTemplate(t *testing.T) {
+	m := newTestManager(t)
+
+	rule, err := m.InstantiateRuleTemplate(context.Background(), "high-p99-latency", map[string]interface{}{"service": "frontend"})
+	assert.NoError(t, err)
+	assert.Equal(t, "High p99 latency in frontend", rule.AlertName)
+	assert.Equal(t, []string{"template:high-p99-latency"}, rule.Tags)
+	assert.Equal(t, float64(500), *rule.RuleCondition.Target)
+}
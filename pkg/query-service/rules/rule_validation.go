@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/prometheus/model/timestamp"
+	"go.signoz.io/signoz/pkg/query-service/utils/times"
+	"go.uber.org/multierr"
+)
+
+// RuleValidationIssue is a problem found in the rule definition
+type RuleValidationIssue struct {
+	Message string `json:"message"`
+	// Details holds the structured details of the issue, if any
+	Details interface{} `json:"details,omitempty"`
+}
+
+// RuleValidationResult lists all the problems found in the rule definition,
+// the rule can be saved when there are no errors
+type RuleValidationResult struct {
+	Valid    bool                  `json:"valid"`
+	Errors   []RuleValidationIssue `json:"errors"`
+	Warnings []RuleValidationIssue `json:"warnings"`
+}
+
+func (r *RuleValidationResult) addError(err error) {
+	issue := RuleValidationIssue{Message: err.Error()}
+	var unitErr *UnitMismatchError
+	if errors.As(err, &unitErr) {
+		issue.Details = unitErr
+	}
+	r.Errors = append(r.Errors, issue)
+}
+
+func (r *RuleValidationResult) addWarning(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, RuleValidationIssue{Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateRule runs all the checks done when saving the rule and reports
+// every problem found instead of stopping at the first one
+func ValidateRule(content []byte) *RuleValidationResult {
+	result := &RuleValidationResult{
+		Errors:   []RuleValidationIssue{},
+		Warnings: []RuleValidationIssue{},
+	}
+
+	rule, err := loadIntoRule(PostableRule{}, content, RuleDataKindJson)
+	if err != nil {
+		result.addError(err)
+		return result
+	}
+
+	for _, err := range multierr.Errors(rule.Validate()) {
+		result.addError(err)
+	}
+	if err := rule.validateChange(); err != nil {
+		result.addError(err)
+	}
+
+	if rule.RuleCondition != nil && rule.RuleCondition.CompositeQuery != nil {
+		if rule.RuleCondition.TargetUnit != "" && rule.RuleCondition.CompositeQuery.Unit == "" {
+			result.addWarning("target unit %s is set but the query has no unit, the threshold is compared with the raw values", rule.RuleCondition.TargetUnit)
+		}
+	}
+
+	if rule.Frequency > rule.EvalWindow {
+		result.addWarning("frequency %s is longer than the eval window %s, the data between the evaluations is not checked", time.Duration(rule.Frequency), time.Duration(rule.EvalWindow))
+	}
+
+	// the templates that parse can still fail on the alert data e.g. calling
+	// a function with the wrong arguments, expand them with the sample data
+	// to catch these before the rule fires
+	if len(testTemplateParsing(rule)) == 0 {
+		for _, issue := range testTemplateExpansion(rule) {
+			result.addWarning("%s", issue)
+		}
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// testTemplateExpansion expands the labels and annotations of the rule
+// with sample data and returns the failures
+func testTemplateExpansion(rl *PostableRule) []string {
+	threshold := "0"
+	if rl.RuleCondition != nil && rl.RuleCondition.Target != nil {
+		threshold = fmt.Sprintf("%v", *rl.RuleCondition.Target)
+	}
+	tmplData := AlertTemplateData(map[string]string{}, "0", threshold)
+	defs := "{{$labels := .Labels}}{{$value := .Value}}{{$threshold := .Threshold}}"
+
+	issues := []string{}
+	check := func(kind, name, text string) {
+		tmpl := NewTemplateExpander(
+			context.TODO(),
+			defs+text,
+			"__alert_"+rl.AlertName,
+			tmplData,
+			times.Time(timestamp.FromTime(time.Now())),
+			nil,
+		)
+		if _, err := tmpl.Expand(); err != nil {
+			issues = append(issues, fmt.Sprintf("%s %s fails to expand with the sample data: %s", kind, name, err.Error()))
+		}
+	}
+	for name, val := range rl.Labels {
+		check("label", name, val)
+	}
+	for name, val := range rl.Annotations {
+		check("annotation", name, val)
+	}
+
+	sort.Strings(issues)
+	return issues
+}
@@ -0,0 +1,84 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRule(t *testing.T) {
+	cases := []struct {
+		name     string
+		rule     string
+		errors   []string
+		warnings []string
+	}{
+		{
+			name: "valid rule",
+			rule: `{"alert":"cpu","evalWindow":"5m","frequency":"1m","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"cpu"}}},"op":"1","matchType":"1","target":90},"annotations":{"summary":"cpu is {{$value}}"}}`,
+		},
+		{
+			name:   "invalid json",
+			rule:   `{"alert":`,
+			errors: []string{ErrFailedToParseJSON.Error()},
+		},
+		{
+			name:   "missing condition",
+			rule:   `{"alert":"cpu"}`,
+			errors: []string{"rule condition is required"},
+		},
+		{
+			name: "all errors at once",
+			rule: `{"alert":"cpu","ruleType":"threshold_rule","evalDelay":"-1m","condition":{"compositeQuery":{"queryType":"builder","builderQueries":{"A":{"queryName":"A","expression":"A","disabled":true}}}},"labels":{"1bad":"x"}}`,
+			errors: []string{
+				"all queries are disabled in rule condition",
+				"rule condition missing the threshold",
+				"rule condition missing the compare op",
+				"rule condition missing the match option",
+				"eval delay cannot be negative",
+				"invalid label name: 1bad",
+			},
+		},
+		{
+			name:   "unit mismatch",
+			rule:   `{"alert":"latency","condition":{"compositeQuery":{"queryType":"promql","unit":"ms","promQueries":{"A":{"query":"latency"}}},"op":"1","matchType":"1","target":90,"targetUnit":"percent"}}`,
+			errors: []string{"threshold unit percent can't be converted to the y-axis unit ms, compatible units are: ns, us, µs, ms, s, m, h, d"},
+		},
+		{
+			name: "warnings",
+			rule: `{"alert":"cpu","evalWindow":"1m","frequency":"5m","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"cpu"}}},"op":"1","matchType":"1","target":90,"targetUnit":"percent"},"annotations":{"summary":"{{ humanize \"high\" }}"}}`,
+			warnings: []string{
+				"target unit percent is set but the query has no unit, the threshold is compared with the raw values",
+				"frequency 5m0s is longer than the eval window 1m0s, the data between the evaluations is not checked",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result := ValidateRule([]byte(c.rule))
+			assert.Equal(t, len(c.errors) == 0, result.Valid)
+			if c.name == "unit mismatch" {
+				assert.IsType(t, &UnitMismatchError{}, result.Errors[0].Details)
+			}
+
+			messages := []string{}
+			for _, issue := range result.Errors {
+				messages = append(messages, issue.Message)
+			}
+			assert.ElementsMatch(t, c.errors, messages)
+
+			messages = []string{}
+			for _, issue := range result.Warnings {
+				messages = append(messages, issue.Message)
+			}
+			if c.name == "warnings" {
+				// the failed expansion is reported last
+				assert.Len(t, messages, len(c.warnings)+1)
+				assert.Contains(t, messages[len(messages)-1], "annotation summary fails to expand with the sample data")
+				messages = messages[:len(messages)-1]
+			}
+			assert.ElementsMatch(t, c.warnings, messages)
+		})
+	}
+}
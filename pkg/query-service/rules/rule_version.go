@@ -0,0 +1,83 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// RuleVersionChange is a field of the rule definition that differs between two versions
+type RuleVersionChange struct {
+	// Path is the dotted path of the field e.g condition.target
+	Path string      `json:"path"`
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// RuleVersionDiff lists the changes between two versions of a rule
+type RuleVersionDiff struct {
+	From    *RuleVersion        `json:"from"`
+	To      *RuleVersion        `json:"to"`
+	Changes []RuleVersionChange `json:"changes"`
+}
+
+// diffRuleVersions compares the definitions of the rule versions field by field
+func diffRuleVersions(from, to *RuleVersion) (*RuleVersionDiff, error) {
+	var fromData, toData interface{}
+	if err := json.Unmarshal([]byte(from.Data), &fromData); err != nil {
+		return nil, fmt.Errorf("failed to parse version %d: %w", from.Version, err)
+	}
+	if err := json.Unmarshal([]byte(to.Data), &toData); err != nil {
+		return nil, fmt.Errorf("failed to parse version %d: %w", to.Version, err)
+	}
+
+	return &RuleVersionDiff{From: from, To: to, Changes: diffRuleData(fromData, toData)}, nil
+}
+
+// diffRuleData compares the parsed json documents field by field
+func diffRuleData(fromData, toData interface{}) []RuleVersionChange {
+	fromFields := map[string]interface{}{}
+	flattenRuleData("", fromData, fromFields)
+	toFields := map[string]interface{}{}
+	flattenRuleData("", toData, toFields)
+
+	paths := make(map[string]struct{}, len(fromFields)+len(toFields))
+	for path := range fromFields {
+		paths[path] = struct{}{}
+	}
+	for path := range toFields {
+		paths[path] = struct{}{}
+	}
+
+	changes := []RuleVersionChange{}
+	for path := range paths {
+		fromValue, toValue := fromFields[path], toFields[path]
+		if reflect.DeepEqual(fromValue, toValue) {
+			continue
+		}
+		changes = append(changes, RuleVersionChange{Path: path, From: fromValue, To: toValue})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
+
+// flattenRuleData collects the leaf values of the json document by their dotted path,
+// arrays are compared as a whole
+func flattenRuleData(prefix string, data interface{}, fields map[string]interface{}) {
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		fields[prefix] = data
+		return
+	}
+	for key, value := range obj {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		flattenRuleData(path, value, fields)
+	}
+}
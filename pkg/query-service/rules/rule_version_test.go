@@ -0,0 +1,76 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+)
+
+func TestDiffRuleVersions(t *testing.T) {
+	from := &RuleVersion{
+		Version: 1,
+		Data:    `{"alert":"High latency","condition":{"target":500,"op":"1"},"labels":{"severity":"warning"}}`,
+	}
+	to := &RuleVersion{
+		Version: 2,
+		Data:    `{"alert":"High latency","condition":{"target":800,"op":"1"},"labels":{"severity":"critical","team":"payments"}}`,
+	}
+
+	diff, err := diffRuleVersions(from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, []RuleVersionChange{
+		{Path: "condition.target", From: 500.0, To: 800.0},
+		{Path: "labels.severity", From: "warning", To: "critical"},
+		{Path: "labels.team", To: "payments"},
+	}, diff.Changes)
+
+	_, err = diffRuleVersions(from, &RuleVersion{Version: 3, Data: "{"})
+	assert.Error(t, err)
+}
+
+func TestRuleDBVersions(t *testing.T) {
+	ruleDB := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil)
+	ctx := context.Background()
+
+	id, tx, err := ruleDB.CreateRuleTx(ctx, `{"alert":"v1"}`)
+	assert.NoError(t, err)
+	assert.NoError(t, tx.Commit())
+
+	ruleID := "1"
+	assert.Equal(t, int64(1), id)
+
+	_, _, err = ruleDB.EditRuleTx(ctx, `{"alert":"v2"}`, ruleID)
+	assert.NoError(t, err)
+	_, _, err = ruleDB.EditRuleTx(ctx, `{"alert":"v3"}`, ruleID)
+	assert.NoError(t, err)
+
+	versions, err := ruleDB.GetRuleVersions(ctx, ruleID)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 3)
+	assert.Equal(t, 3, versions[0].Version)
+	assert.Equal(t, `{"alert":"v3"}`, versions[0].Data)
+
+	version, err := ruleDB.GetRuleVersion(ctx, ruleID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"alert":"v1"}`, version.Data)
+
+	_, err = ruleDB.GetRuleVersion(ctx, ruleID, 4)
+	assert.Error(t, err)
+
+	// the versions are kept in the trash and removed with the rule
+	_, _, err = ruleDB.DeleteRuleTx(ctx, ruleID)
+	assert.NoError(t, err)
+	versions, err = ruleDB.GetRuleVersions(ctx, ruleID)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 3)
+
+	_, err = ruleDB.PurgeDeletedRules(ctx, time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	versions, err = ruleDB.GetRuleVersions(ctx, ruleID)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 0)
+}
@@ -0,0 +1,348 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultShadowDuration is how long the shadow definition of the rule is
+	// evaluated when the duration is not given
+	DefaultShadowDuration = 24 * time.Hour
+	// maxShadowDuration bounds the shadow evaluation forgotten on a rule
+	maxShadowDuration = 7 * 24 * time.Hour
+	// shadowEvalsPerRule is the number of the last comparisons kept for the rule
+	shadowEvalsPerRule = 100
+	// shadowMaxSeries bounds the series firing for only one of the definitions kept
+	// for the comparison
+	shadowMaxSeries = 20
+)
+
+// ErrNoShadow is returned for the rules without a shadow definition
+var ErrNoShadow = errors.New("rule has no shadow definition")
+
+// shadowRule is implemented by the rules embedding the BaseRule, the shadow rule
+// keeps its alerts in memory without recording the state history of the live rule
+type shadowRule interface {
+	markShadow()
+}
+
+func (r *BaseRule) markShadow() {
+	r.shadow = true
+}
+
+// ShadowEvaluation compares the alerts of the live and the shadow definitions of
+// the rule after the evaluation at EvaluatedAt
+type ShadowEvaluation struct {
+	EvaluatedAt time.Time `json:"evaluatedAt"`
+	Error       string    `json:"error,omitempty"`
+	// Live and Shadow are the alerts of the definitions by state
+	Live   map[string]int `json:"live"`
+	Shadow map[string]int `json:"shadow"`
+	// OnlyLive and OnlyShadow are the labels of the series firing for only one of the definitions
+	OnlyLive   []map[string]string `json:"onlyLive,omitempty"`
+	OnlyShadow []map[string]string `json:"onlyShadow,omitempty"`
+}
+
+// ShadowStatus is what the shadow definition of the rule would have done compared
+// to the live one since the shadow evaluation started
+type ShadowStatus struct {
+	RuleID    string        `json:"ruleId"`
+	Active    bool          `json:"active"`
+	StartedAt time.Time     `json:"startedAt"`
+	Until     time.Time     `json:"until"`
+	Rule      *PostableRule `json:"rule"`
+
+	Evaluations int `json:"evaluations"`
+	Errors      int `json:"errors"`
+	// LiveFired and ShadowFired are the number of the alerts that started firing
+	LiveFired   int `json:"liveFired"`
+	ShadowFired int `json:"shadowFired"`
+	// Disagreements is the number of the evaluations the firing series differed
+	Disagreements int `json:"disagreements"`
+	// Recent are the last comparisons, the newest first
+	Recent []ShadowEvaluation `json:"recent"`
+}
+
+type shadowRun struct {
+	rule    Rule
+	ruleStr string
+	status  ShadowStatus
+	// liveFiring and shadowFiring are the series firing after the previous evaluation
+	liveFiring   map[uint64]struct{}
+	shadowFiring map[uint64]struct{}
+}
+
+// shadowRules evaluates the edited definitions of the rules alongside the live ones
+// without sending their alerts, so the effect of the edit is known before applying it
+type shadowRules struct {
+	mtx  sync.Mutex
+	runs map[string]*shadowRun
+}
+
+func newShadowRules() *shadowRules {
+	return &shadowRules{runs: map[string]*shadowRun{}}
+}
+
+// start evaluates the rule as the shadow of the live rule with the same id until the given time
+func (s *shadowRules) start(rule Rule, ruleStr string, postable *PostableRule, now, until time.Time) {
+	if shadow, ok := rule.(shadowRule); ok {
+		shadow.markShadow()
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.runs[rule.ID()] = &shadowRun{
+		rule:    rule,
+		ruleStr: ruleStr,
+		status: ShadowStatus{
+			RuleID:    rule.ID(),
+			StartedAt: now,
+			Until:     until,
+			Rule:      postable,
+		},
+	}
+}
+
+// eval evaluates the shadow definition of the live rule at ts and compares their alerts,
+// it's called once the live rule is evaluated
+func (s *shadowRules) eval(ctx context.Context, live Rule, ts time.Time, frequency time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	run, ok := s.runs[live.ID()]
+	s.mtx.Unlock()
+	if !ok || !ts.Before(run.status.Until) {
+		return
+	}
+
+	err := evalWithTimeout(ctx, run.rule, ts, frequency)
+	if err != nil {
+		zap.L().Debug("shadow evaluation of the rule failed", zap.String("ruleid", live.ID()), zap.Error(err))
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.runs[live.ID()] != run {
+		// the shadow evaluation was stopped meanwhile
+		return
+	}
+	run.record(live.ActiveAlerts(), run.rule.ActiveAlerts(), ts, err)
+}
+
+// record compares the alerts of the definitions after the evaluation at ts
+func (run *shadowRun) record(live, shadow []*Alert, ts time.Time, err error) {
+	evaluation := ShadowEvaluation{EvaluatedAt: ts}
+	run.status.Evaluations++
+	if err != nil {
+		run.status.Errors++
+		evaluation.Error = err.Error()
+	}
+
+	var liveFiring, shadowFiring map[uint64]*Alert
+	evaluation.Live, liveFiring = countShadowAlerts(live)
+	evaluation.Shadow, shadowFiring = countShadowAlerts(shadow)
+
+	for h := range liveFiring {
+		if _, ok := run.liveFiring[h]; !ok {
+			run.status.LiveFired++
+		}
+	}
+	for h := range shadowFiring {
+		if _, ok := run.shadowFiring[h]; !ok {
+			run.status.ShadowFired++
+		}
+	}
+	evaluation.OnlyLive = firingOnlyIn(liveFiring, shadowFiring)
+	evaluation.OnlyShadow = firingOnlyIn(shadowFiring, liveFiring)
+	if len(evaluation.OnlyLive) > 0 || len(evaluation.OnlyShadow) > 0 {
+		run.status.Disagreements++
+	}
+
+	run.liveFiring = make(map[uint64]struct{}, len(liveFiring))
+	for h := range liveFiring {
+		run.liveFiring[h] = struct{}{}
+	}
+	run.shadowFiring = make(map[uint64]struct{}, len(shadowFiring))
+	for h := range shadowFiring {
+		run.shadowFiring[h] = struct{}{}
+	}
+
+	run.status.Recent = append(run.status.Recent, evaluation)
+	if len(run.status.Recent) > shadowEvalsPerRule {
+		run.status.Recent = run.status.Recent[len(run.status.Recent)-shadowEvalsPerRule:]
+	}
+}
+
+// countShadowAlerts counts the alerts by state and returns the firing ones by the
+// hash of the labels of their series, the labels of the rule may differ between definitions
+func countShadowAlerts(alerts []*Alert) (map[string]int, map[uint64]*Alert) {
+	counts := map[string]int{}
+	firing := map[uint64]*Alert{}
+	for _, alert := range alerts {
+		counts[alert.State.String()]++
+		if alert.State == model.StateFiring {
+			firing[alert.QueryResultLables.Hash()] = alert
+		}
+	}
+	return counts, firing
+}
+
+// firingOnlyIn returns the labels of the series firing in a but not in b
+func firingOnlyIn(a, b map[uint64]*Alert) []map[string]string {
+	var alerts []*Alert
+	for h, alert := range a {
+		if _, ok := b[h]; !ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].QueryResultLables.String() < alerts[j].QueryResultLables.String()
+	})
+	if len(alerts) > shadowMaxSeries {
+		alerts = alerts[:shadowMaxSeries]
+	}
+	var only []map[string]string
+	for _, alert := range alerts {
+		only = append(only, alert.QueryResultLables.Map())
+	}
+	return only
+}
+
+// status returns the comparison of the shadow definition of the rule, nil when the
+// rule has none
+func (s *shadowRules) status(ruleId string, now time.Time) *ShadowStatus {
+	if s == nil {
+		return nil
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	run, ok := s.runs[ruleId]
+	if !ok {
+		return nil
+	}
+	status := run.status
+	status.Active = now.Before(status.Until)
+	status.Recent = make([]ShadowEvaluation, 0, len(run.status.Recent))
+	for i := len(run.status.Recent) - 1; i >= 0; i-- {
+		status.Recent = append(status.Recent, run.status.Recent[i])
+	}
+	return &status
+}
+
+// definition returns the shadow definition of the rule as it was given
+func (s *shadowRules) definition(ruleId string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	run, ok := s.runs[ruleId]
+	if !ok {
+		return "", false
+	}
+	return run.ruleStr, true
+}
+
+// forget drops the shadow definition of the rule and its comparison
+func (s *shadowRules) forget(ruleId string) {
+	if s == nil {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.runs, ruleId)
+}
+
+// StartShadow evaluates the edited definition of the rule alongside the live one
+// for the given duration without sending its alerts, the shadow definition is
+// evaluated at the frequency of the live rule
+func (m *Manager) StartShadow(ctx context.Context, id string, ruleStr string, duration time.Duration) (*ShadowStatus, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionEdit); err != nil {
+		return nil, err
+	}
+	m.mtx.RLock()
+	_, ok := m.rules[id]
+	m.mtx.RUnlock()
+	if !ok {
+		return nil, ErrRuleNotLoaded
+	}
+
+	parsedRule, err := ParsePostableRule([]byte(ruleStr))
+	if err != nil {
+		return nil, err
+	}
+	storedRule, err := m.ruleDB.GetStoredRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	parsedRule.OrgID = storedRule.orgID()
+
+	task, err := m.prepareTaskFunc(PrepareTaskOptions{
+		Rule:        parsedRule,
+		TaskName:    prepareTaskName(id),
+		RuleDB:      m.ruleDB,
+		Logger:      m.logger,
+		Reader:      m.reader,
+		Cache:       m.cache,
+		FF:          m.featureFlags,
+		ManagerOpts: m.opts,
+		NotifyFunc:  m.prepareNotifyFunc(),
+
+		UseLogsNewSchema:  m.opts.UseLogsNewSchema,
+		UseTraceNewSchema: m.opts.UseTraceNewSchema,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the task isn't run, the live task evaluates its rule
+	taskRules := task.Rules()
+	if len(taskRules) != 1 {
+		return nil, fmt.Errorf("expected one rule for the shadow definition, got %d", len(taskRules))
+	}
+
+	if duration <= 0 {
+		duration = DefaultShadowDuration
+	}
+	now := time.Now()
+	m.opts.shadows.start(taskRules[0], ruleStr, parsedRule, now, now.Add(min(duration, maxShadowDuration)))
+	return m.opts.shadows.status(id, now), nil
+}
+
+// StopShadow stops evaluating the shadow definition of the rule and drops its comparison
+func (m *Manager) StopShadow(ctx context.Context, id string) error {
+	if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionEdit); err != nil {
+		return err
+	}
+	m.opts.shadows.forget(id)
+	return nil
+}
+
+// ShadowStatus returns the comparison of the shadow definition of the rule with the live one
+func (m *Manager) ShadowStatus(ctx context.Context, id string) (*ShadowStatus, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionView); err != nil {
+		return nil, err
+	}
+	status := m.opts.shadows.status(id, time.Now())
+	if status == nil {
+		return nil, ErrNoShadow
+	}
+	return status, nil
+}
+
+// PromoteShadow applies the shadow definition of the rule as its live definition
+func (m *Manager) PromoteShadow(ctx context.Context, id string) error {
+	ruleStr, ok := m.opts.shadows.definition(id)
+	if !ok {
+		return ErrNoShadow
+	}
+	// the shadow evaluation is dropped once the rule is edited
+	return m.EditRule(ctx, ruleStr, id)
+}
@@ -0,0 +1,107 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func shadowAlert(service string, state model.AlertState) *Alert {
+	return &Alert{
+		QueryResultLables: labels.FromMap(map[string]string{"service": service}),
+		State:             state,
+	}
+}
+
+func TestShadowRunRecord(t *testing.T) {
+	now := time.Now()
+	s := newShadowRules()
+	threshold := 1.0
+	rule, err := NewBaseRule("1", &PostableRule{
+		AlertName: "shadow test",
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypeBuilder},
+			CompareOp:      ValueIsAbove,
+			MatchType:      AtleastOnce,
+			Target:         &threshold,
+		},
+	}, nil)
+	assert.NoError(t, err)
+	s.start(&ThresholdRule{BaseRule: rule}, "{}", nil, now, now.Add(time.Hour))
+	assert.True(t, rule.shadow)
+
+	run := s.runs["1"]
+	run.record(
+		[]*Alert{shadowAlert("frontend", model.StateFiring)},
+		[]*Alert{shadowAlert("frontend", model.StateFiring), shadowAlert("backend", model.StateFiring)},
+		now, nil,
+	)
+	run.record(
+		[]*Alert{shadowAlert("frontend", model.StateFiring)},
+		[]*Alert{shadowAlert("frontend", model.StateFiring), shadowAlert("backend", model.StatePending)},
+		now.Add(time.Minute), nil,
+	)
+
+	status := s.status("1", now)
+	if assert.NotNil(t, status) {
+		assert.True(t, status.Active)
+		assert.Equal(t, 2, status.Evaluations)
+		// the alerts firing in both evaluations are counted once
+		assert.Equal(t, 1, status.LiveFired)
+		assert.Equal(t, 2, status.ShadowFired)
+		assert.Equal(t, 1, status.Disagreements)
+		if assert.Len(t, status.Recent, 2) {
+			// the newest comparison first
+			assert.Empty(t, status.Recent[0].OnlyShadow)
+			assert.Equal(t, map[string]int{"firing": 1, "pending": 1}, status.Recent[0].Shadow)
+			assert.Equal(t, []map[string]string{{"service": "backend"}}, status.Recent[1].OnlyShadow)
+			assert.Empty(t, status.Recent[1].OnlyLive)
+		}
+	}
+	assert.False(t, s.status("1", now.Add(2*time.Hour)).Active)
+
+	s.forget("1")
+	assert.Nil(t, s.status("1", now))
+	_, ok := s.definition("1")
+	assert.False(t, ok)
+}
+
+func TestShadowRuleKeepsLiveState(t *testing.T) {
+	threshold := 1.0
+	reader := &stateHistoryReader{
+		lastSavedState: []model.RuleStateHistory{
+			{
+				RuleID:      "1",
+				State:       model.StateFiring,
+				UnixMilli:   time.Now().Add(-10 * time.Minute).UnixMilli(),
+				Labels:      model.LabelsString(`{"service":"frontend"}`),
+				Fingerprint: 1,
+			},
+		},
+	}
+	rule, err := NewBaseRule("1", &PostableRule{
+		AlertName: "shadow test",
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{QueryType: v3.QueryTypeBuilder},
+			CompareOp:      ValueIsAbove,
+			MatchType:      AtleastOnce,
+			Target:         &threshold,
+		},
+	}, reader)
+	assert.NoError(t, err)
+	rule.markShadow()
+
+	// the shadow rule doesn't carry over the alerts of the live rule
+	assert.NoError(t, rule.RestoreState(context.Background(), nil))
+	assert.Empty(t, rule.Active)
+
+	// nor records the state history in place of the live rule, the reader would panic
+	assert.NoError(t, rule.RecordRuleStateHistory(context.Background(), model.StateInactive, model.StateFiring, []model.RuleStateHistory{
+		{RuleID: "1", State: model.StateFiring, Fingerprint: 1},
+	}))
+}
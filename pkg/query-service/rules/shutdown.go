@@ -0,0 +1,52 @@
+package rules
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultShutdownTimeout bounds the graceful shutdown of the rule manager
+const DefaultShutdownTimeout = 30 * time.Second
+
+// shutdown lets the in-flight evaluations finish and writes their queued state
+// transitions, saves the snapshot of the active alerts, then sends the notifications
+// still queued for the alertmanager and exports the queued alert state events.
+// The shutdown gives up on what's left once the timeout is over
+func (m *Manager) shutdown(tasks []Task) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.opts.ShutdownTimeout)
+	defer cancel()
+
+	stopTasks(ctx, tasks)
+	m.history.stop(ctx)
+	if m.opts.AlertSnapshotInterval > 0 {
+		m.snapshotAlerts(ctx)
+	}
+	m.notifier.Drain(ctx)
+	m.exporter.stop(ctx)
+}
+
+// stopTasks stops the tasks at once and waits for their in-flight evaluations
+func stopTasks(ctx context.Context, tasks []Task) {
+	var wg sync.WaitGroup
+	for _, t := range tasks {
+		wg.Add(1)
+		go func(t Task) {
+			defer wg.Done()
+			t.Stop()
+		}(t)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		zap.L().Warn("in-flight rule evaluations did not finish before the shutdown timeout")
+	}
+}
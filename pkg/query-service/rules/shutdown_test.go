@@ -0,0 +1,53 @@
+package rules
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestManagerStopDrainsNotifications(t *testing.T) {
+	var mtx sync.Mutex
+	var received []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alerts []map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&alerts))
+		mtx.Lock()
+		received = append(received, alerts...)
+		mtx.Unlock()
+	}))
+	defer srv.Close()
+
+	notifier, err := am.NewNotifier(&am.NotifierOptions{
+		AlertManagerURLs: []string{srv.URL},
+		QueueCapacity:    100,
+		Timeout:          time.Second,
+	}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	m := &Manager{
+		tasks:    map[string]Task{},
+		rules:    map[string]Rule{},
+		notifier: notifier,
+		opts:     &ManagerOptions{ShutdownTimeout: time.Second},
+	}
+
+	// the notifier isn't running, the alerts stay queued until the shutdown
+	for i := 0; i < 70; i++ {
+		notifier.Send(&am.Alert{Labels: labels.FromMap(map[string]string{"alertname": "test"})})
+	}
+	m.Stop()
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	assert.Len(t, received, 70)
+}
@@ -0,0 +1,319 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+// SilenceStatus is the state of the silence at the time it is fetched
+type SilenceStatus string
+
+const (
+	SilenceStatusPending SilenceStatus = "pending"
+	SilenceStatusActive  SilenceStatus = "active"
+	SilenceStatusExpired SilenceStatus = "expired"
+)
+
+var (
+	ErrMissingSilenceMatchers = errors.New("silence must have matchers")
+	ErrSilenceMatchesAll      = errors.New("at least one matcher of the silence must not match the empty label")
+	ErrInvalidSilenceWindow   = errors.New("silence must end after it starts")
+)
+
+// Silence mutes the notifications of the alerts matching all the matchers from the
+// start to the end, like the silences of the alertmanager. Unlike the maintenance
+// it has no schedule, it is created for the ongoing alert and expires on its own.
+type Silence struct {
+	Id        int64               `json:"id" db:"id"`
+	Matchers  MaintenanceMatchers `json:"matchers" db:"matchers"`
+	StartsAt  time.Time           `json:"startsAt" db:"starts_at"`
+	EndsAt    time.Time           `json:"endsAt" db:"ends_at"`
+	Comment   string              `json:"comment" db:"comment"`
+	CreatedAt time.Time           `json:"createdAt" db:"created_at"`
+	CreatedBy string              `json:"createdBy" db:"created_by"`
+	UpdatedAt time.Time           `json:"updatedAt" db:"updated_at"`
+	UpdatedBy string              `json:"updatedBy" db:"updated_by"`
+	Status    SilenceStatus       `json:"status" db:"-"`
+	OrgID     string              `json:"-" db:"org_id"`
+}
+
+// SilenceFilter selects the silences, the empty fields match all the silences
+type SilenceFilter struct {
+	Status SilenceStatus
+	// At is the time the status is evaluated at, now by default
+	At time.Time
+}
+
+const silenceColumns = "id, matchers, starts_at, ends_at, comment, created_at, created_by, updated_at, updated_by, COALESCE(org_id, '') AS org_id"
+
+func (s *Silence) Validate() error {
+	if len(s.Matchers) == 0 {
+		return ErrMissingSilenceMatchers
+	}
+	matchesAll := true
+	for _, matcher := range s.Matchers {
+		parsed, err := parseMaintenanceMatcher(matcher)
+		if err != nil {
+			return err
+		}
+		if !parsed.matches(labels.Labels{}) {
+			matchesAll = false
+		}
+	}
+	if matchesAll {
+		return ErrSilenceMatchesAll
+	}
+	if s.EndsAt.IsZero() || !s.EndsAt.After(s.StartsAt) {
+		return ErrInvalidSilenceWindow
+	}
+	return nil
+}
+
+func (s *Silence) statusAt(ts time.Time) SilenceStatus {
+	switch {
+	case !s.EndsAt.After(ts):
+		return SilenceStatusExpired
+	case s.StartsAt.After(ts):
+		return SilenceStatusPending
+	}
+	return SilenceStatusActive
+}
+
+// silences reports whether the alert with the labels matches all the matchers
+func (s *Silence) silences(lbls labels.BaseLabels) bool {
+	if lbls == nil {
+		return false
+	}
+	for _, matcher := range s.Matchers {
+		parsed, err := parseMaintenanceMatcher(matcher)
+		if err != nil {
+			zap.L().Error("invalid silence matcher", zap.Int64("silence", s.Id), zap.Error(err))
+			return false
+		}
+		if !parsed.matches(lbls) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *ruleDB) CreateSilence(ctx context.Context, silence Silence) (int64, error) {
+	// the silences created by the integrations, e.g slack, name their own creator
+	email, _ := auth.GetEmailFromJwt(ctx)
+	if email == "" {
+		email = silence.CreatedBy
+	}
+	now := time.Now().UTC()
+	silence.CreatedBy, silence.CreatedAt = email, now
+	silence.UpdatedBy, silence.UpdatedAt = email, now
+	silence.OrgID = contextOrgID(ctx)
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = now
+	}
+	silence.StartsAt, silence.EndsAt = silence.StartsAt.UTC(), silence.EndsAt.UTC()
+
+	result, err := r.NamedExec(`INSERT INTO silences (matchers, starts_at, ends_at, comment, created_at, created_by, updated_at, updated_by, org_id)
+		VALUES (:matchers, :starts_at, :ends_at, :comment, :created_at, :created_by, :updated_at, :updated_by, :org_id)`, silence)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to silences", zap.Error(err))
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	silence.Id = id
+
+	after, err := json.Marshal(silence)
+	if err != nil {
+		return id, err
+	}
+	return id, addAuditLog(ctx, r, AuditResourceSilence, strconv.FormatInt(id, 10), AuditActionCreate, "", string(after))
+}
+
+func (r *ruleDB) GetSilenceByID(ctx context.Context, id string) (*Silence, error) {
+	silence := &Silence{}
+
+	q := newSelectQuery("SELECT "+silenceColumns+" FROM silences").where("id=?", id)
+	whereOrg(ctx, q, "silences")
+
+	query, args := q.build()
+	if err := r.Get(silence, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	silence.Status = silence.statusAt(time.Now())
+	return silence, nil
+}
+
+func (r *ruleDB) GetSilences(ctx context.Context, filter *SilenceFilter) ([]Silence, error) {
+	if filter == nil {
+		filter = &SilenceFilter{}
+	}
+	at := filter.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	at = at.UTC()
+
+	q := newSelectQuery("SELECT " + silenceColumns + " FROM silences")
+	switch filter.Status {
+	case SilenceStatusPending:
+		q.where("starts_at>?", at)
+	case SilenceStatusActive:
+		q.where("starts_at<=?", at).where("ends_at>?", at)
+	case SilenceStatusExpired:
+		q.where("ends_at<=?", at)
+	}
+	whereOrg(ctx, q, "silences")
+	query, args := q.order("ends_at DESC, id DESC").build()
+
+	silences := []Silence{}
+	if err := r.Select(&silences, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	for i := range silences {
+		silences[i].Status = silences[i].statusAt(at)
+	}
+	return silences, nil
+}
+
+func (r *ruleDB) EditSilence(ctx context.Context, silence Silence, id string) error {
+	before, err := r.GetSilenceByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	email, _ := auth.GetEmailFromJwt(ctx)
+	silence.Id = before.Id
+	silence.CreatedAt, silence.CreatedBy = before.CreatedAt, before.CreatedBy
+	silence.UpdatedAt, silence.UpdatedBy = time.Now().UTC(), email
+	silence.OrgID = before.OrgID
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = before.StartsAt
+	}
+	silence.StartsAt, silence.EndsAt = silence.StartsAt.UTC(), silence.EndsAt.UTC()
+
+	if _, err := r.NamedExec(`UPDATE silences SET matchers=:matchers, starts_at=:starts_at, ends_at=:ends_at, comment=:comment, updated_at=:updated_at, updated_by=:updated_by WHERE id=:id`, silence); err != nil {
+		zap.L().Error("Error in Executing UPDATE to silences", zap.Error(err))
+		return err
+	}
+	return r.auditSilence(ctx, before, &silence, AuditActionEdit)
+}
+
+// ExpireSilence ends the silence at the time, the expired silence is kept for the history
+func (r *ruleDB) ExpireSilence(ctx context.Context, id string, ts time.Time) error {
+	before, err := r.GetSilenceByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if before.statusAt(ts) == SilenceStatusExpired {
+		return nil
+	}
+
+	email, _ := auth.GetEmailFromJwt(ctx)
+	expired := *before
+	expired.EndsAt = ts.UTC()
+	if expired.StartsAt.After(expired.EndsAt) {
+		expired.StartsAt = expired.EndsAt
+	}
+	expired.UpdatedAt, expired.UpdatedBy = time.Now().UTC(), email
+
+	if _, err := r.NamedExec(`UPDATE silences SET starts_at=:starts_at, ends_at=:ends_at, updated_at=:updated_at, updated_by=:updated_by WHERE id=:id`, expired); err != nil {
+		zap.L().Error("Error in Executing UPDATE to silences", zap.Error(err))
+		return err
+	}
+	return r.auditSilence(ctx, before, &expired, AuditActionDelete)
+}
+
+func (r *ruleDB) auditSilence(ctx context.Context, before, after *Silence, action AuditAction) error {
+	beforeData, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterData, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+	return addAuditLog(ctx, r, AuditResourceSilence, strconv.FormatInt(before.Id, 10), action, string(beforeData), string(afterData))
+}
+
+// CreateSilence validates and stores the silence
+func (m *Manager) CreateSilence(ctx context.Context, silence Silence) (*Silence, error) {
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = time.Now()
+	}
+	if err := silence.Validate(); err != nil {
+		return nil, err
+	}
+	id, err := m.ruleDB.CreateSilence(ctx, silence)
+	if err != nil {
+		return nil, err
+	}
+	return m.ruleDB.GetSilenceByID(ctx, strconv.FormatInt(id, 10))
+}
+
+// EditSilence validates and updates the silence, the silence keeps its start when none is given
+func (m *Manager) EditSilence(ctx context.Context, silence Silence, id string) (*Silence, error) {
+	existing, err := m.ruleDB.GetSilenceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = existing.StartsAt
+	}
+	if err := silence.Validate(); err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.EditSilence(ctx, silence, id); err != nil {
+		return nil, err
+	}
+	return m.ruleDB.GetSilenceByID(ctx, id)
+}
+
+// silencesForRule returns the silences applying to the alerts of the rule
+func silencesForRule(silences []Silence, rule Rule) []Silence {
+	var applying []Silence
+	for _, s := range silences {
+		if s.OrgID == "" || s.OrgID == rule.OrgID() {
+			applying = append(applying, s)
+		}
+	}
+	return applying
+}
+
+// silenceMatchingAlerts drops the notifications of the alerts matched by the
+// silences, the silences are applied before the maintenance
+func silenceMatchingAlerts(notify NotifyFunc, silences []Silence) NotifyFunc {
+	if len(silences) == 0 {
+		return notify
+	}
+	return func(ctx context.Context, expr string, alerts ...*Alert) {
+		notified := make([]*Alert, 0, len(alerts))
+		for _, alert := range alerts {
+			silenced := false
+			for i := range silences {
+				if silences[i].silences(alert.Labels) {
+					zap.L().Info("alert silenced", zap.Int64("silence", silences[i].Id), zap.String("labels", alert.Labels.String()))
+					silenced = true
+					break
+				}
+			}
+			if !silenced {
+				notified = append(notified, alert)
+			}
+		}
+		if len(notified) > 0 {
+			notify(ctx, expr, notified...)
+		}
+	}
+}
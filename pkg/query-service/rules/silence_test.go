@@ -0,0 +1,91 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestSilenceValidate(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name    string
+		silence Silence
+		err     error
+	}{
+		{"valid", Silence{Matchers: MaintenanceMatchers{"service=cart"}, StartsAt: now, EndsAt: now.Add(time.Hour)}, nil},
+		{"no matchers", Silence{StartsAt: now, EndsAt: now.Add(time.Hour)}, ErrMissingSilenceMatchers},
+		{"matches all", Silence{Matchers: MaintenanceMatchers{"service=~.*", "env!=prod"}, StartsAt: now, EndsAt: now.Add(time.Hour)}, ErrSilenceMatchesAll},
+		{"no end", Silence{Matchers: MaintenanceMatchers{"service=cart"}, StartsAt: now}, ErrInvalidSilenceWindow},
+		{"ends before start", Silence{Matchers: MaintenanceMatchers{"service=cart"}, StartsAt: now, EndsAt: now.Add(-time.Hour)}, ErrInvalidSilenceWindow},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.err, c.silence.Validate(), c.name)
+	}
+
+	invalid := Silence{Matchers: MaintenanceMatchers{"service"}, StartsAt: now, EndsAt: now.Add(time.Hour)}
+	assert.Error(t, invalid.Validate())
+}
+
+func TestSilenceMatchingAlerts(t *testing.T) {
+	var notified []*Alert
+	notify := func(ctx context.Context, expr string, alerts ...*Alert) {
+		notified = append(notified, alerts...)
+	}
+
+	cart := &Alert{Labels: labels.FromMap(map[string]string{"service": "cart", "env": "prod"})}
+	checkout := &Alert{Labels: labels.FromMap(map[string]string{"service": "checkout", "env": "prod"})}
+	staging := &Alert{Labels: labels.FromMap(map[string]string{"service": "checkout", "env": "staging"})}
+
+	silences := []Silence{
+		{Id: 1, Matchers: MaintenanceMatchers{"service=cart"}},
+		{Id: 2, Matchers: MaintenanceMatchers{"service=~check.*", "env!=prod"}},
+	}
+	silenceMatchingAlerts(notify, silences)(context.Background(), "", cart, checkout, staging)
+	assert.Equal(t, []*Alert{checkout}, notified)
+
+	// the silences of the other orgs don't apply to the rule
+	rule := &ThresholdRule{BaseRule: &BaseRule{id: "1", orgID: "org-1"}}
+	applying := silencesForRule([]Silence{{Id: 1}, {Id: 2, OrgID: "org-1"}, {Id: 3, OrgID: "org-2"}}, rule)
+	assert.Len(t, applying, 2)
+}
+
+func TestSilences(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	active, err := m.CreateSilence(ctx, Silence{Matchers: MaintenanceMatchers{"service=cart"}, EndsAt: now.Add(time.Hour), Comment: "rollout"})
+	assert.NoError(t, err)
+	assert.Equal(t, SilenceStatusActive, active.Status)
+	pending, err := m.CreateSilence(ctx, Silence{Matchers: MaintenanceMatchers{"service=checkout"}, StartsAt: now.Add(time.Hour), EndsAt: now.Add(2 * time.Hour)})
+	assert.NoError(t, err)
+	assert.Equal(t, SilenceStatusPending, pending.Status)
+
+	silences, err := m.ruleDB.GetSilences(ctx, &SilenceFilter{Status: SilenceStatusActive})
+	assert.NoError(t, err)
+	assert.Len(t, silences, 1)
+	assert.Equal(t, "rollout", silences[0].Comment)
+
+	// the edit keeps the start
+	edited, err := m.EditSilence(ctx, Silence{Matchers: MaintenanceMatchers{"service=checkout", "env=prod"}, EndsAt: now.Add(3 * time.Hour)}, "2")
+	assert.NoError(t, err)
+	assert.True(t, edited.StartsAt.Equal(pending.StartsAt))
+	assert.Equal(t, MaintenanceMatchers{"service=checkout", "env=prod"}, edited.Matchers)
+
+	assert.NoError(t, m.ruleDB.ExpireSilence(ctx, "1", time.Now()))
+	assert.NoError(t, m.ruleDB.ExpireSilence(ctx, "2", time.Now()))
+	silences, err = m.ruleDB.GetSilences(ctx, &SilenceFilter{Status: SilenceStatusExpired})
+	assert.NoError(t, err)
+	assert.Len(t, silences, 2)
+	silences, err = m.ruleDB.GetSilences(ctx, &SilenceFilter{Status: SilenceStatusActive})
+	assert.NoError(t, err)
+	assert.Empty(t, silences)
+
+	changes, err := m.ruleDB.GetAuditLogs(ctx, &AuditLogFilter{ResourceType: AuditResourceSilence, ResourceId: "2"})
+	assert.NoError(t, err)
+	assert.Len(t, changes, 3)
+}
@@ -0,0 +1,148 @@
+package rules
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+)
+
+// slackRequestTolerance is how old the signed slack request may be, older requests are replayed
+const slackRequestTolerance = 5 * time.Minute
+
+var (
+	ErrInvalidSlackSignature = errors.New("invalid slack request signature")
+	ErrUnknownSlackAction    = errors.New("unknown slack action")
+)
+
+// VerifySlackRequest checks the signature of the request made by slack with the
+// signing secret of the slack app, see https://api.slack.com/authentication/verifying-requests-from-slack
+func VerifySlackRequest(secret, timestamp, signature string, body []byte, now time.Time) error {
+	if secret == "" || signature == "" {
+		return ErrInvalidSlackSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSlackSignature
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > slackRequestTolerance || age < -slackRequestTolerance {
+		return ErrInvalidSlackSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSlackSignature
+	}
+	return nil
+}
+
+// SlackInteraction is the payload slack sends when a button of the message is clicked
+type SlackInteraction struct {
+	Type       string `json:"type"`
+	CallbackID string `json:"callback_id"`
+	User       struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"user"`
+	Actions []SlackInteractionAction `json:"actions"`
+}
+
+type SlackInteractionAction struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// SlackActionResponse is the message slack posts in the thread of the alert
+type SlackActionResponse struct {
+	ResponseType    string `json:"response_type"`
+	ReplaceOriginal bool   `json:"replace_original"`
+	Text            string `json:"text"`
+}
+
+// slackActionMatchers returns the matchers of the alerts of the message, the value
+// of the buttons is the url encoded common labels of the alerts
+func slackActionMatchers(value string) (MaintenanceMatchers, error) {
+	values, err := url.ParseQuery(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid slack action value: %w", err)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matchers := make(MaintenanceMatchers, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, name+"="+values.Get(name))
+	}
+	if len(matchers) == 0 {
+		return nil, ErrMissingSilenceMatchers
+	}
+	return matchers, nil
+}
+
+// HandleSlackAction acknowledges or snoozes the alerts of the slack message whose button was clicked
+func (m *Manager) HandleSlackAction(ctx context.Context, interaction *SlackInteraction) (*SlackActionResponse, error) {
+	if interaction.CallbackID != am.SlackActionCallbackID || len(interaction.Actions) == 0 {
+		return nil, ErrUnknownSlackAction
+	}
+	action := interaction.Actions[0]
+	matchers, err := slackActionMatchers(action.Value)
+	if err != nil {
+		return nil, err
+	}
+	user := interaction.User.Name
+	if user == "" {
+		user = interaction.User.Id
+	}
+	actor := "slack:" + user
+	alerts := strings.Join(matchers, ", ")
+
+	name, param, _ := strings.Cut(action.Name, ":")
+	switch name {
+	case am.SlackActionAck:
+		_, err := m.createAlertAck(ctx, AlertAck{Matchers: matchers, AckedBy: actor, Comment: "Acknowledged from Slack"})
+		if err != nil {
+			return nil, err
+		}
+		return &SlackActionResponse{
+			ResponseType: "in_channel",
+			Text:         fmt.Sprintf("<@%s> acknowledged the alerts %s", interaction.User.Id, alerts),
+		}, nil
+
+	case am.SlackActionSnooze:
+		duration, err := time.ParseDuration(param)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("%w: invalid snooze duration %q", ErrUnknownSlackAction, param)
+		}
+		now := time.Now()
+		_, err = m.CreateSilence(ctx, Silence{
+			Matchers:  matchers,
+			StartsAt:  now,
+			EndsAt:    now.Add(duration),
+			Comment:   "Snoozed from Slack",
+			CreatedBy: actor,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &SlackActionResponse{
+			ResponseType: "in_channel",
+			Text:         fmt.Sprintf("<@%s> snoozed the alerts %s for %s", interaction.User.Id, alerts, param),
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownSlackAction, action.Name)
+}
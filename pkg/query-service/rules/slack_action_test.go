@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestVerifySlackRequest(t *testing.T) {
+	now := time.Unix(1714557600, 0)
+	body := []byte("payload=%7B%7D")
+	sign := func(ts time.Time) (string, string) {
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte("v0:" + timestamp + ":" + string(body)))
+		return timestamp, "v0=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	timestamp, signature := sign(now)
+	assert.NoError(t, VerifySlackRequest("secret", timestamp, signature, body, now))
+	assert.ErrorIs(t, VerifySlackRequest("other", timestamp, signature, body, now), ErrInvalidSlackSignature)
+	assert.ErrorIs(t, VerifySlackRequest("", timestamp, signature, body, now), ErrInvalidSlackSignature)
+	assert.ErrorIs(t, VerifySlackRequest("secret", timestamp, signature, []byte("payload=tampered"), now), ErrInvalidSlackSignature)
+
+	// the replayed request is rejected
+	timestamp, signature = sign(now.Add(-10 * time.Minute))
+	assert.ErrorIs(t, VerifySlackRequest("secret", timestamp, signature, body, now), ErrInvalidSlackSignature)
+}
+
+func TestHandleSlackAction(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+
+	interaction := &SlackInteraction{CallbackID: "signoz_alert"}
+	interaction.User.Id, interaction.User.Name = "U123", "jane"
+	value := "alertname=High+error+rate&service.name=front%26end&"
+
+	matchers, err := slackActionMatchers(value)
+	assert.NoError(t, err)
+	assert.Equal(t, MaintenanceMatchers{"alertname=High error rate", "service.name=front&end"}, matchers)
+
+	interaction.Actions = []SlackInteractionAction{{Name: "ack", Value: value}}
+	response, err := m.HandleSlackAction(ctx, interaction)
+	assert.NoError(t, err)
+	assert.Equal(t, "in_channel", response.ResponseType)
+	acks, err := m.ruleDB.GetAlertAcks(ctx, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	if assert.Len(t, acks, 1) {
+		assert.Equal(t, "slack:jane", acks[0].AckedBy)
+		assert.Equal(t, matchers, acks[0].Matchers)
+	}
+
+	interaction.Actions = []SlackInteractionAction{{Name: "snooze:4h", Value: value}}
+	_, err = m.HandleSlackAction(ctx, interaction)
+	assert.NoError(t, err)
+	silences, err := m.ruleDB.GetSilences(ctx, &SilenceFilter{Status: SilenceStatusActive})
+	assert.NoError(t, err)
+	if assert.Len(t, silences, 1) {
+		assert.Equal(t, "slack:jane", silences[0].CreatedBy)
+		assert.Equal(t, 4*time.Hour, silences[0].EndsAt.Sub(silences[0].StartsAt).Round(time.Second))
+	}
+
+	interaction.Actions = []SlackInteractionAction{{Name: "snooze:forever", Value: value}}
+	_, err = m.HandleSlackAction(ctx, interaction)
+	assert.ErrorIs(t, err, ErrUnknownSlackAction)
+
+	interaction.CallbackID = "other"
+	_, err = m.HandleSlackAction(ctx, interaction)
+	assert.ErrorIs(t, err, ErrUnknownSlackAction)
+}
+
+func TestAckMatchingAlerts(t *testing.T) {
+	ackedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	acks := []AlertAck{{Id: 1, Matchers: MaintenanceMatchers{"service=cart"}, AckedAt: ackedAt}}
+
+	acked := &Alert{Labels: labels.FromMap(map[string]string{"service": "cart"}), FiredAt: ackedAt.Add(-time.Hour)}
+	refired := &Alert{Labels: labels.FromMap(map[string]string{"service": "cart", "pod": "b"}), FiredAt: ackedAt.Add(time.Hour)}
+	resolved := &Alert{Labels: labels.FromMap(map[string]string{"service": "cart", "pod": "c"}), FiredAt: ackedAt.Add(-time.Hour), ResolvedAt: ackedAt.Add(time.Hour)}
+	other := &Alert{Labels: labels.FromMap(map[string]string{"service": "frontend"}), FiredAt: ackedAt.Add(-time.Hour)}
+
+	var notified []*Alert
+	notify := ackMatchingAlerts(func(ctx context.Context, expr string, alerts ...*Alert) {
+		notified = append(notified, alerts...)
+	}, acks)
+	notify(context.Background(), "", acked, refired, resolved, other)
+	assert.Equal(t, []*Alert{refired, resolved, other}, notified)
+}
@@ -0,0 +1,341 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+const (
+	// SLOBurnLabel tells whether the alert is the fast or the slow burn alert
+	SLOBurnLabel = "slo_burn"
+
+	SLOBurnFast = "fast"
+	SLOBurnSlow = "slow"
+)
+
+const (
+	// SLOBudgetExhausted is the slo_burn label of the alert fired
+	// when the error budget of the compliance window is used up
+	SLOBudgetExhausted = "exhausted"
+
+	// ErrorBudgetRemainingAnnotation is added to the SLO alerts
+	ErrorBudgetRemainingAnnotation = "error_budget_remaining"
+
+	defaultSLOWindow = 30 * 24 * time.Hour
+)
+
+// SLOCondition captures the objective of an SLO rule
+type SLOCondition struct {
+	// Target is the objective in percent e.g 99.9
+	Target float64 `yaml:"target" json:"target"`
+	// Window is the compliance window of the error budget, 30 days by default
+	Window Duration `yaml:"window,omitempty" json:"window,omitempty"`
+	// AlertOnBudgetExhaustion fires an alert once the error budget is used up
+	AlertOnBudgetExhaustion bool `yaml:"alertOnBudgetExhaustion,omitempty" json:"alertOnBudgetExhaustion,omitempty"`
+}
+
+// errorBudget is the allowed error ratio e.g 0.001 for 99.9%
+func (s *SLOCondition) errorBudget() float64 {
+	return 1 - s.Target/100
+}
+
+func (s *SLOCondition) window() time.Duration {
+	if s.Window <= 0 {
+		return defaultSLOWindow
+	}
+	return time.Duration(s.Window)
+}
+
+// ErrorBudget fills the consumed and the remaining fractions of the error
+// budget from the mean error ratio observed over the compliance window
+func (s *SLOCondition) ErrorBudget(budget *model.SLOErrorBudget) {
+	budget.Consumed = budget.ErrorRatio / s.errorBudget()
+	budget.Remaining = 1 - budget.Consumed
+}
+
+// BurnRate converts the error ratio series into the burn rate of the error budget
+func (s *SLOCondition) BurnRate(series *v3.Series) *v3.Series {
+	if series == nil {
+		return nil
+	}
+	for idx := range series.Points {
+		series.Points[idx].Value = series.Points[idx].Value / s.errorBudget()
+	}
+	return series
+}
+
+// burnRateWindow is a pair of windows that must both burn the error
+// budget faster than the factor for the alert to fire, the short
+// window makes the alert reset quickly once the issue is fixed
+type burnRateWindow struct {
+	name   string
+	long   time.Duration
+	short  time.Duration
+	factor float64
+}
+
+// the multi window multi burn rate alerts from the SRE workbook, the fast burn
+// consumes 2% of a 30 day budget in an hour and the slow burn 5% in 6 hours
+var burnRateWindows = []burnRateWindow{
+	{name: SLOBurnFast, long: time.Hour, short: 5 * time.Minute, factor: 14.4},
+	{name: SLOBurnSlow, long: 6 * time.Hour, short: 30 * time.Minute, factor: 6},
+}
+
+// SLORule alerts when the error budget of an SLO burns too fast. The selected
+// query is the SLI and must return the error ratio i.e bad events / total events.
+type SLORule struct {
+	*ThresholdRule
+
+	slo       *SLOCondition
+	frequency time.Duration
+}
+
+func NewSLORule(
+	id string,
+	p *PostableRule,
+	featureFlags interfaces.FeatureLookup,
+	reader interfaces.Reader,
+	useLogsNewSchema bool,
+	useTraceNewSchema bool,
+	opts ...RuleOption,
+) (*SLORule, error) {
+
+	zap.L().Info("creating new SLORule", zap.String("id", id))
+
+	if p.RuleCondition == nil || p.RuleCondition.SLO == nil {
+		return nil, fmt.Errorf("slo condition is required for the slo rule")
+	}
+
+	t, err := NewThresholdRule(id, p, featureFlags, reader, useLogsNewSchema, useTraceNewSchema, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the query should cover the longest burn rate window
+	for _, w := range burnRateWindows {
+		if t.evalWindow < w.long {
+			t.evalWindow = w.long
+		}
+	}
+
+	return &SLORule{
+		ThresholdRule: t,
+		slo:           p.RuleCondition.SLO,
+		frequency:     time.Duration(p.Frequency),
+	}, nil
+}
+
+func (r *SLORule) Type() RuleType {
+	return RuleTypeSLO
+}
+
+func (r *SLORule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vector, error) {
+
+	params, err := r.prepareQueryRange(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	queryResult, err := r.runQuery(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if queryResult == nil {
+		return nil, nil
+	}
+
+	end := time.UnixMilli(params.End)
+	r.recordErrorRatios(ctx, queryResult.Series, end)
+
+	return r.burningSeries(queryResult.Series, end), nil
+}
+
+// exhaustedSeries returns a sample for every series that used up its error budget
+func (r *SLORule) exhaustedSeries(budgets []model.SLOErrorBudget, ts time.Time) Vector {
+	var resultVector Vector
+	for _, budget := range budgets {
+		if budget.Remaining > 0 {
+			continue
+		}
+		lbls := make(map[string]string)
+		if err := json.Unmarshal([]byte(budget.Labels), &lbls); err != nil {
+			continue
+		}
+		resultVector = append(resultVector, Sample{
+			Point:  Point{T: ts.UnixMilli(), V: budget.Consumed},
+			Metric: labels.NewBuilder(labels.FromMap(lbls)).Set(SLOBurnLabel, SLOBudgetExhausted).Labels(),
+		})
+	}
+	return resultVector
+}
+
+// seriesLabels returns the labels of the series as they appear in the alerts
+func seriesLabels(series *v3.Series) labels.Labels {
+	return labels.NewBuilder(labels.FromMap(series.Labels)).Del(labels.MetricNameLabel).Del(labels.TemporalityLabel).Labels()
+}
+
+// recordErrorRatios saves the error ratio observed since the last evaluation,
+// the error budget is the mean of these ratios over the compliance window
+func (r *SLORule) recordErrorRatios(ctx context.Context, series []*v3.Series, end time.Time) {
+	if r.reader == nil || r.shadow {
+		return
+	}
+
+	frequency := r.frequency
+	if frequency <= 0 {
+		frequency = time.Minute
+	}
+
+	errorRatios := make([]model.SLOErrorRatio, 0, len(series))
+	for _, s := range series {
+		ratio, ok := errorRatio(removeGroupinSetPoints(*s), end.Add(-frequency))
+		if !ok {
+			continue
+		}
+		lbls := seriesLabels(s)
+		labelsJSON, err := json.Marshal(lbls)
+		if err != nil {
+			zap.L().Error("error marshaling labels", zap.Error(err), zap.Any("labels", lbls))
+			continue
+		}
+		errorRatios = append(errorRatios, model.SLOErrorRatio{
+			RuleID:      r.ID(),
+			RuleName:    r.Name(),
+			UnixMilli:   end.UnixMilli(),
+			Fingerprint: lbls.Hash(),
+			Labels:      model.LabelsString(labelsJSON),
+			Target:      r.slo.Target,
+			ErrorRatio:  ratio,
+		})
+	}
+
+	if len(errorRatios) == 0 {
+		return
+	}
+	if err := r.reader.AddSLOErrorRatios(ctx, errorRatios); err != nil {
+		zap.L().Error("error while saving slo error ratios", zap.String("ruleid", r.ID()), zap.Error(err))
+	}
+}
+
+// errorBudgets returns the error budget of every series over the compliance window
+func (r *SLORule) errorBudgets(ctx context.Context, ts time.Time) ([]model.SLOErrorBudget, error) {
+	if r.reader == nil {
+		return nil, nil
+	}
+	budgets, err := r.reader.GetSLOErrorRatio(ctx, r.ID(), ts.Add(-r.slo.window()).UnixMilli(), ts.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	for idx := range budgets {
+		r.slo.ErrorBudget(&budgets[idx])
+	}
+	return budgets, nil
+}
+
+// annotateErrorBudget adds the remaining error budget to the active alerts
+func (r *SLORule) annotateErrorBudget(budgets []model.SLOErrorBudget) {
+	if len(budgets) == 0 {
+		return
+	}
+	remaining := make(map[uint64]float64, len(budgets))
+	for _, budget := range budgets {
+		remaining[budget.Fingerprint] = budget.Remaining
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for _, a := range r.Active {
+		fp := labels.NewBuilder(labels.FromMap(a.QueryResultLables.Map())).Del(SLOBurnLabel).Labels().Hash()
+		value, ok := remaining[fp]
+		if !ok {
+			continue
+		}
+		a.Annotations = labels.NewBuilder(labels.FromMap(a.Annotations.Map())).Set(ErrorBudgetRemainingAnnotation, fmt.Sprintf("%.2f%%", value*100)).Labels()
+	}
+}
+
+// burningSeries returns a sample for every burn rate window
+// in which the series burns the error budget too fast
+func (r *SLORule) burningSeries(series []*v3.Series, end time.Time) Vector {
+	var resultVector Vector
+
+	budget := r.slo.errorBudget()
+	for _, s := range series {
+		points := removeGroupinSetPoints(*s)
+		for _, w := range burnRateWindows {
+			longRate, ok := errorRatio(points, end.Add(-w.long))
+			if !ok || longRate/budget < w.factor {
+				continue
+			}
+			shortRate, ok := errorRatio(points, end.Add(-w.short))
+			if !ok || shortRate/budget < w.factor {
+				continue
+			}
+
+			lbls := labels.NewBuilder(seriesLabels(s)).Set(SLOBurnLabel, w.name).Labels()
+			resultVector = append(resultVector, Sample{
+				Point:  Point{T: end.UnixMilli(), V: longRate / budget},
+				Metric: lbls,
+			})
+		}
+	}
+	return resultVector
+}
+
+// errorRatio approximates the error ratio since the given time
+// with the mean of the error ratio of the points after it
+func errorRatio(points []v3.Point, since time.Time) (float64, bool) {
+	var sum float64
+	var count int
+	for _, p := range points {
+		if p.Timestamp < since.UnixMilli() {
+			continue
+		}
+		sum += p.Value
+		count++
+	}
+	if count == 0 {
+		return 0, false
+	}
+	return sum / float64(count), !math.IsNaN(sum)
+}
+
+func (r *SLORule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
+
+	res, err := r.buildAndRunQuery(ctx, ts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	budgets, err := r.errorBudgets(ctx, ts)
+	if err != nil {
+		zap.L().Error("failed to get the error budget", zap.String("ruleid", r.ID()), zap.Error(err))
+	}
+
+	if r.slo.AlertOnBudgetExhaustion {
+		res = append(res, r.exhaustedSeries(budgets, ts)...)
+	}
+
+	// the value of the alert is the burn rate of the long window
+	// and the threshold is the objective
+	count, err := r.evalVector(ctx, ts, res, r.slo.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	r.annotateErrorBudget(budgets)
+
+	return count, nil
+}
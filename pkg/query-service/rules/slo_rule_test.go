@@ -0,0 +1,172 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/featureManager"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func newTestSLORule(t *testing.T, reader interfaces.Reader) *SLORule {
+	postableRule := PostableRule{
+		AlertName:  "Checkout availability",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeSLO,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:    "A",
+						StepInterval: 60,
+						AggregateAttribute: v3.AttributeKey{
+							Key: "signoz_calls_total",
+						},
+						AggregateOperator: v3.AggregateOperatorSumRate,
+						DataSource:        v3.DataSourceMetrics,
+						Expression:        "A",
+					},
+				},
+			},
+			SLO: &SLOCondition{Target: 99.9, AlertOnBudgetExhaustion: true},
+		},
+	}
+	assert.NoError(t, postableRule.Validate())
+
+	fm := featureManager.StartManager()
+	rule, err := NewSLORule("69", &postableRule, fm, reader, true, true)
+	assert.NoError(t, err)
+	return rule
+}
+
+func TestSLORuleBurningSeries(t *testing.T) {
+	rule := newTestSLORule(t, nil)
+	// the query covers the longest burn rate window
+	assert.Equal(t, 6*time.Hour, rule.EvalWindow())
+
+	end := time.Unix(1717205940, 0)
+	// ratioSeries returns a series with one point per minute over the last
+	// 6 hours, the points in the last recent duration have the recent ratio
+	ratioSeries := func(service string, ratio, recentRatio float64, recent time.Duration) *v3.Series {
+		series := &v3.Series{Labels: map[string]string{"service.name": service}}
+		for ts := end.Add(-6 * time.Hour); !ts.After(end); ts = ts.Add(time.Minute) {
+			value := ratio
+			if ts.After(end.Add(-recent)) {
+				value = recentRatio
+			}
+			series.Points = append(series.Points, v3.Point{Timestamp: ts.UnixMilli(), Value: value})
+		}
+		return series
+	}
+
+	cases := []struct {
+		name     string
+		series   *v3.Series
+		expected []string
+	}{
+		{
+			name:     "within budget",
+			series:   ratioSeries("checkout", 0.0005, 0.0005, 0),
+			expected: nil,
+		},
+		{
+			// 2% errors for the last hour is a burn rate of 20
+			name:     "fast burn",
+			series:   ratioSeries("checkout", 0, 0.02, time.Hour),
+			expected: []string{SLOBurnFast},
+		},
+		{
+			// 1% errors for 6 hours is a burn rate of 10
+			name:     "fast and slow burn",
+			series:   ratioSeries("checkout", 0.01, 0.02, time.Hour),
+			expected: []string{SLOBurnFast, SLOBurnSlow},
+		},
+		{
+			// the long windows burn but the issue is fixed since 40 minutes
+			name:     "recovered",
+			series:   ratioSeries("checkout", 0.02, 0, 40*time.Minute),
+			expected: nil,
+		},
+	}
+
+	for _, c := range cases {
+		res := rule.burningSeries([]*v3.Series{c.series}, end)
+		var burns []string
+		for _, smpl := range res {
+			assert.Equal(t, "checkout", smpl.Metric.Get("service.name"), c.name)
+			burns = append(burns, smpl.Metric.Get(SLOBurnLabel))
+		}
+		assert.Equal(t, c.expected, burns, c.name)
+	}
+}
+
+type sloReader struct {
+	interfaces.Reader
+	saved   []model.SLOErrorRatio
+	budgets []model.SLOErrorBudget
+}
+
+func (r *sloReader) AddSLOErrorRatios(ctx context.Context, errorRatios []model.SLOErrorRatio) error {
+	r.saved = append(r.saved, errorRatios...)
+	return nil
+}
+
+func (r *sloReader) GetSLOErrorRatio(ctx context.Context, ruleID string, start, end int64) ([]model.SLOErrorBudget, error) {
+	return append([]model.SLOErrorBudget(nil), r.budgets...), nil
+}
+
+func TestSLORuleErrorBudget(t *testing.T) {
+	checkout := labels.FromMap(map[string]string{"service.name": "checkout"})
+	payment := labels.FromMap(map[string]string{"service.name": "payment"})
+
+	reader := &sloReader{
+		budgets: []model.SLOErrorBudget{
+			{Fingerprint: checkout.Hash(), Labels: model.LabelsString(`{"service.name":"checkout"}`), ErrorRatio: 0.0005},
+			{Fingerprint: payment.Hash(), Labels: model.LabelsString(`{"service.name":"payment"}`), ErrorRatio: 0.002},
+		},
+	}
+	rule := newTestSLORule(t, reader)
+
+	end := time.Unix(1717205940, 0)
+	series := &v3.Series{
+		Labels: map[string]string{"service.name": "checkout"},
+		Points: []v3.Point{
+			{Timestamp: end.Add(-2 * time.Minute).UnixMilli(), Value: 0.5},
+			{Timestamp: end.UnixMilli(), Value: 0.01},
+		},
+	}
+
+	// only the error ratio since the last evaluation is saved
+	rule.recordErrorRatios(context.Background(), []*v3.Series{series}, end)
+	assert.Len(t, reader.saved, 1)
+	assert.Equal(t, 0.01, reader.saved[0].ErrorRatio)
+	assert.Equal(t, checkout.Hash(), reader.saved[0].Fingerprint)
+
+	budgets, err := rule.errorBudgets(context.Background(), end)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.5, budgets[0].Remaining, 1e-9)
+	assert.InDelta(t, -1, budgets[1].Remaining, 1e-9)
+
+	exhausted := rule.exhaustedSeries(budgets, end)
+	assert.Len(t, exhausted, 1)
+	assert.Equal(t, "payment", exhausted[0].Metric.Get("service.name"))
+	assert.Equal(t, SLOBudgetExhausted, exhausted[0].Metric.Get(SLOBurnLabel))
+
+	fastBurn := labels.NewBuilder(checkout).Set(SLOBurnLabel, SLOBurnFast).Labels()
+	rule.Active[fastBurn.Hash()] = &Alert{
+		Labels:            fastBurn,
+		QueryResultLables: fastBurn,
+		Annotations:       labels.Labels{},
+		State:             model.StateFiring,
+	}
+	rule.annotateErrorBudget(budgets)
+	assert.Equal(t, "50.00%", rule.Active[fastBurn.Hash()].Annotations.Map()[ErrorBudgetRemainingAnnotation])
+}
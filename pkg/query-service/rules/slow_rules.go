@@ -0,0 +1,177 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// ruleCostWindow is the number of the last evaluations of every rule the costs are computed over
+const ruleCostWindow = 30
+
+// RuleCostSort is the cost the slow rules are sorted by
+type RuleCostSort string
+
+const (
+	RuleCostSortDuration    RuleCostSort = "duration"
+	RuleCostSortMaxDuration RuleCostSort = "max_duration"
+	RuleCostSortReadBytes   RuleCostSort = "read_bytes"
+	RuleCostSortReadRows    RuleCostSort = "read_rows"
+)
+
+// Validate checks the sort is known, the empty sort sorts by duration
+func (s RuleCostSort) Validate() error {
+	switch s {
+	case "", RuleCostSortDuration, RuleCostSortMaxDuration, RuleCostSortReadBytes, RuleCostSortReadRows:
+		return nil
+	}
+	return fmt.Errorf("invalid sort %s, supported sorts: %s, %s, %s, %s", s, RuleCostSortDuration, RuleCostSortMaxDuration, RuleCostSortReadBytes, RuleCostSortReadRows)
+}
+
+// RuleCost is the cost of the last evaluations of the rule
+type RuleCost struct {
+	RuleID         string    `json:"ruleId"`
+	Name           string    `json:"name"`
+	Evaluations    int       `json:"evaluations"`
+	AvgDurationMs  float64   `json:"avgDurationMs"`
+	MaxDurationMs  float64   `json:"maxDurationMs"`
+	AvgReadRows    float64   `json:"avgReadRows"`
+	AvgReadBytes   float64   `json:"avgReadBytes"`
+	LastEvaluation time.Time `json:"lastEvaluation"`
+	// Queries are the clickhouse queries generated for the last evaluation running them
+	Queries []string `json:"queries,omitempty"`
+}
+
+type ruleCostSample struct {
+	duration  time.Duration
+	readRows  uint64
+	readBytes uint64
+}
+
+// ruleCosts is the rolling window of the costs of the rule
+type ruleCosts struct {
+	name    string
+	samples [ruleCostWindow]ruleCostSample
+	next    int
+	count   int
+	last    time.Time
+	queries []string
+}
+
+// ruleCostTracker keeps the costs of the last evaluations of the rules to find the
+// rules hurting the datastore
+type ruleCostTracker struct {
+	mtx   sync.Mutex
+	rules map[string]*ruleCosts
+}
+
+func newRuleCostTracker() *ruleCostTracker {
+	return &ruleCostTracker{rules: map[string]*ruleCosts{}}
+}
+
+// record adds the cost of the evaluation of the rule
+func (t *ruleCostTracker) record(rule Rule, ts time.Time, duration time.Duration, stats *model.QueryStats) {
+	if t == nil {
+		return
+	}
+	sample := ruleCostSample{duration: duration}
+	var queries []string
+	if stats != nil {
+		sample.readRows, sample.readBytes = stats.Read()
+		queries = stats.Queries()
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	costs, ok := t.rules[rule.ID()]
+	if !ok {
+		costs = &ruleCosts{}
+		t.rules[rule.ID()] = costs
+	}
+	costs.name = rule.Name()
+	costs.samples[costs.next] = sample
+	costs.next = (costs.next + 1) % ruleCostWindow
+	costs.count = min(costs.count+1, ruleCostWindow)
+	costs.last = ts
+	// the evaluations reusing the results of other rules don't run queries
+	if len(queries) > 0 {
+		costs.queries = queries
+	}
+}
+
+// forget drops the costs of the rule no longer evaluated
+func (t *ruleCostTracker) forget(ruleId string) {
+	if t == nil {
+		return
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	delete(t.rules, ruleId)
+}
+
+// report returns the most expensive rules by the given cost
+func (t *ruleCostTracker) report(sortBy RuleCostSort, limit int) []RuleCost {
+	report := []RuleCost{}
+	if t == nil {
+		return report
+	}
+
+	t.mtx.Lock()
+	for id, costs := range t.rules {
+		cost := RuleCost{
+			RuleID:         id,
+			Name:           costs.name,
+			Evaluations:    costs.count,
+			LastEvaluation: costs.last,
+			Queries:        costs.queries,
+		}
+		var total time.Duration
+		var rows, bytes uint64
+		for _, sample := range costs.samples[:costs.count] {
+			total += sample.duration
+			cost.MaxDurationMs = max(cost.MaxDurationMs, float64(sample.duration)/float64(time.Millisecond))
+			rows += sample.readRows
+			bytes += sample.readBytes
+		}
+		if costs.count > 0 {
+			cost.AvgDurationMs = float64(total) / float64(costs.count) / float64(time.Millisecond)
+			cost.AvgReadRows = float64(rows) / float64(costs.count)
+			cost.AvgReadBytes = float64(bytes) / float64(costs.count)
+		}
+		report = append(report, cost)
+	}
+	t.mtx.Unlock()
+
+	value := func(cost RuleCost) float64 {
+		switch sortBy {
+		case RuleCostSortMaxDuration:
+			return cost.MaxDurationMs
+		case RuleCostSortReadBytes:
+			return cost.AvgReadBytes
+		case RuleCostSortReadRows:
+			return cost.AvgReadRows
+		}
+		return cost.AvgDurationMs
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if value(report[i]) != value(report[j]) {
+			return value(report[i]) > value(report[j])
+		}
+		return report[i].RuleID < report[j].RuleID
+	})
+	if limit > 0 && len(report) > limit {
+		report = report[:limit]
+	}
+	return report
+}
+
+// SlowRules returns the rules with the most expensive evaluations on this replica
+func (m *Manager) SlowRules(sortBy RuleCostSort, limit int) ([]RuleCost, error) {
+	if err := sortBy.Validate(); err != nil {
+		return nil, err
+	}
+	return m.opts.costs.report(sortBy, limit), nil
+}
@@ -0,0 +1,57 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestSlowRules(t *testing.T) {
+	m := newTestManager(t)
+	m.opts.costs = newRuleCostTracker()
+	now := time.Now()
+
+	quick := &ThresholdRule{BaseRule: &BaseRule{id: "1", name: "Quick"}}
+	slow := &ThresholdRule{BaseRule: &BaseRule{id: "2", name: "Slow"}}
+
+	stats := &model.QueryStats{}
+	stats.AddQuery("SELECT count() FROM signoz_logs.distributed_logs_v2")
+	stats.AddProgress(1000, 1<<20)
+	m.opts.costs.record(quick, now, 100*time.Millisecond, stats)
+	m.opts.costs.record(slow, now, 2*time.Second, &model.QueryStats{})
+	// the evaluation reusing the result of another rule keeps the last queries
+	m.opts.costs.record(quick, now.Add(time.Minute), 300*time.Millisecond, &model.QueryStats{})
+
+	report, err := m.SlowRules("", 10)
+	assert.NoError(t, err)
+	if assert.Len(t, report, 2) {
+		assert.Equal(t, "Slow", report[0].Name)
+		assert.Equal(t, 2, report[1].Evaluations)
+		assert.Equal(t, 200.0, report[1].AvgDurationMs)
+		assert.Equal(t, 300.0, report[1].MaxDurationMs)
+		assert.Equal(t, []string{"SELECT count() FROM signoz_logs.distributed_logs_v2"}, report[1].Queries)
+	}
+
+	report, err = m.SlowRules(RuleCostSortReadBytes, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, report, 1) {
+		assert.Equal(t, "Quick", report[0].Name)
+		assert.Equal(t, float64(1<<19), report[0].AvgReadBytes)
+	}
+
+	_, err = m.SlowRules("cost", 10)
+	assert.Error(t, err)
+
+	// the window only keeps the last evaluations
+	for i := 0; i < ruleCostWindow; i++ {
+		m.opts.costs.record(slow, now, time.Second, nil)
+	}
+	report, _ = m.SlowRules(RuleCostSortMaxDuration, 1)
+	assert.Equal(t, 1000.0, report[0].MaxDurationMs)
+
+	m.opts.costs.forget("2")
+	report, _ = m.SlowRules("", 10)
+	assert.Len(t, report, 1)
+}
@@ -0,0 +1,447 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+var ErrSmtpTLSUnavailable = errors.New("the smtp server does not support STARTTLS")
+
+// defaultEmailSubject and defaultEmailBody render the emails of the channels without a template
+const (
+	defaultEmailSubject  = `{{ if eq .Status "firing" }}[FIRING:{{ len .Alerts.Firing }}]{{ else }}[RESOLVED]{{ end }} {{ with .CommonLabels.alertname }}{{ . }}{{ else }}{{ len .Alerts }} alerts{{ end }}`
+	defaultDigestSubject = `[DIGEST] {{ len .Alerts.Firing }} firing, {{ len .Alerts.Resolved }} resolved alerts`
+	defaultEmailBody     = `<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:16px;background:#f6f7f9;font-family:Helvetica,Arial,sans-serif;font-size:14px;color:#1d212d">
+{{ range .Alerts }}
+<div style="background:#ffffff;border-left:4px solid {{ if eq .Status "firing" }}#e5484d{{ else }}#30a46c{{ end }};border-radius:4px;padding:12px 16px;margin-bottom:16px">
+<h2 style="margin:0 0 8px;font-size:16px">{{ .Labels.alertname }} <span style="font-weight:normal;color:#6b7280">{{ .Status }}</span></h2>
+{{ with .Annotations.summary }}<p style="margin:0 0 8px">{{ . }}</p>{{ end }}
+{{ with .Annotations.description }}<p style="margin:0 0 8px;color:#4b5563">{{ . }}</p>{{ end }}
+<p style="margin:0 0 8px"><b>Value:</b> {{ printf "%g" .Value }}{{ with .Labels.threshold }} &nbsp; <b>Threshold:</b> {{ . }}{{ end }} &nbsp; <b>Since:</b> {{ $.FormatTime .StartsAt }}</p>
+{{ with .ChartURL }}<img src="{{ . }}" alt="Chart of the alert" width="480" height="160" style="display:block;margin:0 0 8px;border:1px solid #e5e7eb">{{ end }}
+<table style="border-collapse:collapse;margin:0 0 8px">
+{{ range .Labels.SortedPairs }}<tr><td style="padding:2px 12px 2px 0;color:#6b7280">{{ .Name }}</td><td style="padding:2px 0">{{ .Value }}</td></tr>
+{{ end }}</table>
+<p style="margin:0">{{ with .GeneratorURL }}<a href="{{ . }}">View the alert</a>{{ end }}{{ with .Annotations.related_logs }} &middot; <a href="{{ . }}">Related logs</a>{{ end }}{{ with .Annotations.related_traces }} &middot; <a href="{{ . }}">Related traces</a>{{ end }}</p>
+</div>
+{{ end }}
+</body>
+</html>
+`
+)
+
+// EmailTemplate is the template of the emails of the rule
+type EmailTemplate struct {
+	Subject string `yaml:"subject" json:"subject"`
+	// Body is the html template of the email
+	Body string `yaml:"body" json:"body"`
+}
+
+func (t *EmailTemplate) channelTemplate() *ChannelTemplate {
+	return &ChannelTemplate{Title: t.Subject, Body: t.Body, Format: ChannelTemplateHTML}
+}
+
+// SmtpConfig sends the alerts as html emails through the smtp server. The critical
+// alerts are always emailed right away, the other alerts are batched into one email
+// per digest interval when the interval is set.
+type SmtpConfig struct {
+	// To is the comma separated list of the recipients
+	To   string `json:"to"`
+	From string `json:"from"`
+	// Smarthost is the host:port of the smtp server, the port 465 uses implicit tls
+	Smarthost    string `json:"smarthost"`
+	AuthUsername string `json:"auth_username,omitempty"`
+	AuthPassword string `json:"auth_password,omitempty"`
+	// RequireTLS fails the delivery when the server doesn't support STARTTLS, true by default
+	RequireTLS *bool `json:"require_tls,omitempty"`
+	// SendResolved sends the resolved alerts, true by default
+	SendResolved *bool `json:"send_resolved,omitempty"`
+	// DigestInterval batches the non-critical alerts, 0 emails every alert right away
+	DigestInterval Duration `json:"digest_interval,omitempty"`
+}
+
+// emailDigest is the non-critical alerts of a config waiting for the next digest email
+type emailDigest struct {
+	channel *deliveredChannel
+	config  SmtpConfig
+	alerts  map[uint64]*Alert
+}
+
+// digested reports whether the alert waits for the digest instead of being emailed right away
+func (config *SmtpConfig) digested(alert *Alert) bool {
+	return config.DigestInterval > 0 && !strings.EqualFold(alert.Labels.Get("severity"), "critical")
+}
+
+// emailImage is the image embedded in the email, the html references it by its content id
+type emailImage struct {
+	contentId string
+	data      []byte
+}
+
+// message returns the html email with the subject and the body, the email is
+// multipart with the images inlined when it has images
+func (config *SmtpConfig) message(subject, body string, now time.Time, images ...emailImage) ([]byte, error) {
+	var b bytes.Buffer
+	headers := [][2]string{
+		{"From", config.From},
+		{"To", config.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"MIME-Version", "1.0"},
+	}
+	var parts *multipart.Writer
+	if len(images) > 0 {
+		parts = multipart.NewWriter(&b)
+		headers = append(headers, [2]string{"Content-Type", mime.FormatMediaType("multipart/related", map[string]string{"boundary": parts.Boundary(), "type": "text/html"})})
+	} else {
+		headers = append(headers, [2]string{"Content-Type", "text/html; charset=UTF-8"}, [2]string{"Content-Transfer-Encoding", "quoted-printable"})
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&b, "%s: %s\r\n", header[0], header[1])
+	}
+	b.WriteString("\r\n")
+
+	var w io.Writer = &b
+	if parts != nil {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"text/html; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		w = part
+	}
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	if parts == nil {
+		return b.Bytes(), nil
+	}
+
+	for _, image := range images {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"image/png"},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Id":                {"<" + image.contentId + ">"},
+			"Content-Disposition":       {`inline; filename="chart.png"`},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(image.data)
+		// the lines of the base64 body are at most 76 characters
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// inlineCharts returns the alerts with their charts referenced as the inline images
+// of the email, the emails don't rely on the chart urls being reachable
+func inlineCharts(alerts []*Alert) ([]*Alert, []emailImage) {
+	var images []emailImage
+	inlined := make([]*Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		if alert.chart == nil || !alert.ResolvedAt.IsZero() {
+			inlined = append(inlined, alert)
+			continue
+		}
+		contentId := fmt.Sprintf("chart-%016x@signoz", alert.Labels.Hash())
+		copied := *alert
+		copied.chart = &alertChart{url: "cid:" + contentId, image: alert.chart.image}
+		inlined = append(inlined, &copied)
+		images = append(images, emailImage{contentId: contentId, data: alert.chart.image})
+	}
+	return inlined, images
+}
+
+// smtpResult returns the status code of the smtp error, only the 4xx replies and
+// the network errors are transient
+func smtpResult(err error) (int, bool, error) {
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return reply.Code, reply.Code/100 == 4, err
+	}
+	return 0, true, err
+}
+
+// sendMail sends the message through the smtp server of the config
+func (config *SmtpConfig) sendMail(ctx context.Context, msg []byte) (int, bool, error) {
+	host, port, err := net.SplitHostPort(config.Smarthost)
+	if err != nil {
+		return 0, false, err
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return 0, false, err
+	}
+	to, err := mail.ParseAddressList(config.To)
+	if err != nil {
+		return 0, false, err
+	}
+
+	dialer := &net.Dialer{Timeout: deliveryTimeout}
+	var conn net.Conn
+	if port == "465" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", config.Smarthost)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", config.Smarthost)
+	}
+	if err != nil {
+		return 0, true, err
+	}
+	_ = conn.SetDeadline(time.Now().Add(deliveryTimeout))
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return smtpResult(err)
+	}
+	defer c.Close()
+
+	if port != "465" {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+				return smtpResult(err)
+			}
+		} else if config.RequireTLS == nil || *config.RequireTLS {
+			return 0, false, ErrSmtpTLSUnavailable
+		}
+	}
+	if config.AuthUsername != "" {
+		if err := c.Auth(smtp.PlainAuth("", config.AuthUsername, config.AuthPassword, host)); err != nil {
+			return smtpResult(err)
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return smtpResult(err)
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr.Address); err != nil {
+			return smtpResult(err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return smtpResult(err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return smtpResult(err)
+	}
+	if err := w.Close(); err != nil {
+		return smtpResult(err)
+	}
+	if err := c.Quit(); err != nil {
+		return smtpResult(err)
+	}
+	return 250, false, nil
+}
+
+type smtpSender struct{}
+
+func (smtpSender) configured(receiver *am.Receiver) bool {
+	return receiver.SmtpConfigs != nil
+}
+
+func (smtpSender) configs(receiver *am.Receiver) ([]SmtpConfig, error) {
+	var configs []SmtpConfig
+	if err := decodeConfigs(receiver.SmtpConfigs, &configs); err != nil {
+		return nil, fmt.Errorf("invalid smtp configs: %w", err)
+	}
+	return configs, nil
+}
+
+func (s smtpSender) validate(receiver *am.Receiver) error {
+	configs, err := s.configs(receiver)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if _, err := mail.ParseAddressList(config.To); err != nil {
+			return fmt.Errorf("invalid smtp recipients %q: %w", config.To, err)
+		}
+		if _, err := mail.ParseAddress(config.From); err != nil {
+			return fmt.Errorf("invalid smtp sender %q: %w", config.From, err)
+		}
+		if _, _, err := net.SplitHostPort(config.Smarthost); err != nil {
+			return fmt.Errorf("invalid smtp smarthost %q, must be host:port", config.Smarthost)
+		}
+		if config.DigestInterval < 0 {
+			return fmt.Errorf("smtp digest interval must not be negative")
+		}
+	}
+	return nil
+}
+
+func (s smtpSender) send(ctx context.Context, d *channelDelivery, channel *deliveredChannel, alerts []*Alert) error {
+	configs, err := s.configs(channel.receiver)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range configs {
+		config := &configs[i]
+		// the alerts of every rule are emailed with the template of the rule
+		var ruleIds []string
+		byRule := map[string][]*Alert{}
+		var digested []*Alert
+		for _, alert := range alerts {
+			if !alert.ResolvedAt.IsZero() && config.SendResolved != nil && !*config.SendResolved {
+				continue
+			}
+			if config.digested(alert) {
+				digested = append(digested, alert)
+				continue
+			}
+			ruleId := alert.Labels.Get(labels.AlertRuleIdLabel)
+			if _, ok := byRule[ruleId]; !ok {
+				ruleIds = append(ruleIds, ruleId)
+			}
+			byRule[ruleId] = append(byRule[ruleId], alert)
+		}
+
+		d.addToDigest(channel, *config, digested)
+		for _, ruleId := range ruleIds {
+			t := d.ruleEmailTemplate(ctx, ruleId)
+			if t == nil {
+				t = channel.template
+			}
+			if err := d.email(ctx, channel, config, t, defaultEmailSubject, byRule[ruleId]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ruleEmailTemplate returns the email template of the rule, nil when it has none
+func (d *channelDelivery) ruleEmailTemplate(ctx context.Context, ruleId string) *ChannelTemplate {
+	if ruleId == "" {
+		return nil
+	}
+	stored, err := d.ruleDB.GetStoredRule(ctx, ruleId)
+	if err != nil {
+		zap.L().Error("failed to fetch the rule of the alerts", zap.String("ruleId", ruleId), zap.Error(err))
+		return nil
+	}
+	rule, err := ParsePostableRule([]byte(stored.Data))
+	if err != nil || rule.NotificationSettings == nil || rule.NotificationSettings.EmailTemplate == nil {
+		return nil
+	}
+	return rule.NotificationSettings.EmailTemplate.channelTemplate()
+}
+
+// email renders the alerts with the template, the default template when it's nil, and
+// sends them in one email
+func (d *channelDelivery) email(ctx context.Context, channel *deliveredChannel, config *SmtpConfig, t *ChannelTemplate, defaultSubject string, alerts []*Alert) error {
+	if t == nil {
+		t = &ChannelTemplate{Title: defaultSubject, Body: defaultEmailBody}
+	} else {
+		copied := *t
+		t = &copied
+	}
+	t.Format = ChannelTemplateHTML
+	inlined, images := inlineCharts(alerts)
+	rendered, err := t.render(newNotificationData(channel.name, inlined).localize(channel.receiver))
+	if err != nil {
+		return fmt.Errorf("channel %s: %w", channel.name, err)
+	}
+	msg, err := config.message(rendered.Title, rendered.Body, time.Now(), images...)
+	if err != nil {
+		return err
+	}
+
+	fingerprints := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		fingerprints = append(fingerprints, fmt.Sprintf("%016x", alert.Labels.Hash()))
+	}
+	return d.send(ctx, &deliveryRequest{
+		Channel:      channel.name,
+		ChannelType:  "smtp",
+		Body:         msg,
+		Smtp:         config,
+		Fingerprints: fingerprints,
+		Alerts:       notifiedAlerts(alerts),
+		Retries:      defaultDeliveryRetries,
+	})
+}
+
+// addToDigest adds the alerts to the digest of the config, the first alert of the
+// digest schedules the email at the end of the interval
+func (d *channelDelivery) addToDigest(channel *deliveredChannel, config SmtpConfig, alerts []*Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+	key := channel.name + "/" + config.To
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	digest, ok := d.digests[key]
+	if !ok {
+		digest = &emailDigest{alerts: map[uint64]*Alert{}}
+		d.digests[key] = digest
+		time.AfterFunc(time.Duration(config.DigestInterval), func() { d.flushDigest(key) })
+	}
+	// the digest is sent with the latest config of the channel
+	digest.channel, digest.config = channel, config
+	for _, alert := range alerts {
+		copied := *alert
+		digest.alerts[alert.Labels.Hash()] = &copied
+	}
+}
+
+// flushDigest emails the alerts of the digest, the latest state of every alert
+func (d *channelDelivery) flushDigest(key string) {
+	d.mtx.Lock()
+	digest, ok := d.digests[key]
+	delete(d.digests, key)
+	d.mtx.Unlock()
+	if !ok {
+		return
+	}
+
+	alerts := make([]*Alert, 0, len(digest.alerts))
+	for _, alert := range digest.alerts {
+		alerts = append(alerts, alert)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].FiredAt.Before(alerts[j].FiredAt)
+	})
+	if err := d.email(context.Background(), digest.channel, &digest.config, digest.channel.template, defaultDigestSubject, alerts); err != nil {
+		zap.L().Error("failed to deliver the digest", zap.String("channel", digest.channel.name), zap.Int("alerts", len(alerts)), zap.Error(err))
+	}
+}
@@ -0,0 +1,158 @@
+package rules
+
+import (
+	"context"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// smtpServer accepts the emails without tls and sends them to the channel
+func smtpServer(t *testing.T, emails chan<- *mail.Message) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				c := textproto.NewConn(conn)
+				_ = c.PrintfLine("220 localhost ESMTP")
+				for {
+					line, err := c.ReadLine()
+					if err != nil {
+						return
+					}
+					switch strings.ToUpper(strings.SplitN(line, " ", 2)[0]) {
+					case "EHLO", "HELO":
+						_ = c.PrintfLine("250 localhost")
+					case "DATA":
+						_ = c.PrintfLine("354 go ahead")
+						msg, err := mail.ReadMessage(c.DotReader())
+						if err != nil {
+							return
+						}
+						emails <- msg
+						_ = c.PrintfLine("250 ok")
+					case "QUIT":
+						_ = c.PrintfLine("221 bye")
+						return
+					default:
+						_ = c.PrintfLine("250 ok")
+					}
+				}
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func emailBody(t *testing.T, msg *mail.Message) string {
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestSmtpValidate(t *testing.T) {
+	valid := map[string]interface{}{"to": "oncall@example.com, sre@example.com", "from": "alerts@example.com", "smarthost": "smtp.example.com:587", "digest_interval": "15m"}
+	assert.Nil(t, validateDeliveredConfigs(&am.Receiver{Name: "email", SmtpConfigs: []interface{}{valid}}))
+
+	invalid := []map[string]interface{}{
+		{"to": "", "from": "alerts@example.com", "smarthost": "smtp.example.com:587"},
+		{"to": "oncall@example.com", "from": "alerts", "smarthost": "smtp.example.com:587"},
+		{"to": "oncall@example.com", "from": "alerts@example.com", "smarthost": "smtp.example.com"},
+		{"to": "oncall@example.com", "from": "alerts@example.com", "smarthost": "smtp.example.com:587", "digest_interval": "-1m"},
+	}
+	for _, config := range invalid {
+		assert.NotNil(t, validateDeliveredConfigs(&am.Receiver{Name: "email", SmtpConfigs: []interface{}{config}}), config)
+	}
+
+	assert.NoError(t, (&NotificationSettings{EmailTemplate: &EmailTemplate{Subject: "{{ .CommonLabels.alertname }}", Body: "<p>{{ len .Alerts }}</p>"}}).Validate())
+	assert.ErrorIs(t, (&NotificationSettings{EmailTemplate: &EmailTemplate{Subject: "{{ .CommonLabels.alertname", Body: ""}}).Validate(), ErrInvalidChannelTemplate)
+}
+
+func TestSmtpDelivery(t *testing.T) {
+	emails := make(chan *mail.Message, 10)
+	addr := smtpServer(t, emails)
+
+	db := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil).(*ruleDB)
+	_, err := db.Exec(`INSERT INTO rules (created_at, updated_at, data) VALUES ($1, $1, $2)`, time.Now(),
+		`{"alert":"Latency","alertType":"METRIC_BASED_ALERT","ruleType":"threshold_rule","evalWindow":"5m","frequency":"1m",
+		"condition":{"compositeQuery":{"queryType":"builder","panelType":"graph","builderQueries":{"A":{"queryName":"A","stepInterval":60,"dataSource":"metrics","aggregateOperator":"noop","aggregateAttribute":{"key":"signoz_latency"},"expression":"A","disabled":false}}},"op":"1","target":1,"matchType":"1"},
+		"notificationSettings":{"emailTemplate":{"subject":"{{ .CommonLabels.alertname }} on {{ .CommonLabels.service }}","body":"<p>{{ .CommonLabels.service }} is slow</p>"}}}`)
+	assert.NoError(t, err)
+
+	delivery := newChannelDelivery(db)
+	requireTLS := false
+	channel := &deliveredChannel{name: "email", receiver: &am.Receiver{Name: "email", SmtpConfigs: []interface{}{map[string]interface{}{
+		"to": "oncall@example.com", "from": "SigNoz <alerts@example.com>", "smarthost": addr, "require_tls": requireTLS, "digest_interval": "100ms",
+	}}}}
+
+	critical := &Alert{
+		Labels: labels.FromMap(map[string]string{"alertname": "Errors", "severity": "critical", "service": "cart", "threshold": "5"}),
+		Annotations: labels.FromMap(map[string]string{
+			"summary":      "The error rate of cart is 7.5%",
+			"related_logs": "https://signoz.example.com/logs/logs-explorer?q=1",
+		}),
+		Value:        7.5,
+		FiredAt:      time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		GeneratorURL: "https://signoz.example.com/alerts/edit?ruleId=2",
+	}
+	templated := &Alert{Labels: labels.FromMap(map[string]string{"alertname": "Latency", "severity": "critical", "service": "cart", "ruleId": "1"})}
+	warnings := []*Alert{
+		{Labels: labels.FromMap(map[string]string{"alertname": "Disk", "severity": "warning", "host": "a"})},
+		{Labels: labels.FromMap(map[string]string{"alertname": "Disk", "severity": "warning", "host": "b"})},
+	}
+
+	start := time.Now()
+	assert.NoError(t, smtpSender{}.send(context.Background(), delivery, channel, append([]*Alert{critical, templated}, warnings...)))
+
+	// the critical alerts are emailed right away
+	msg := <-emails
+	assert.Equal(t, "[FIRING:1] Errors", msg.Header.Get("Subject"))
+	assert.Equal(t, "oncall@example.com", msg.Header.Get("To"))
+	assert.True(t, strings.HasPrefix(msg.Header.Get("Content-Type"), "text/html"))
+	body := emailBody(t, msg)
+	assert.Contains(t, body, "<b>Value:</b> 7.5")
+	assert.Contains(t, body, "<b>Threshold:</b> 5")
+	assert.Contains(t, body, `<td style="padding:2px 0">cart</td>`)
+	assert.Contains(t, body, `<a href="https://signoz.example.com/logs/logs-explorer?q=1">Related logs</a>`)
+
+	// the template of the rule renders its alerts
+	msg = <-emails
+	assert.Equal(t, "Latency on cart", msg.Header.Get("Subject"))
+	assert.Equal(t, "<p>cart is slow</p>", strings.TrimSpace(emailBody(t, msg)))
+
+	// the other alerts are batched into the digest
+	select {
+	case msg := <-emails:
+		assert.Equal(t, "[DIGEST] 2 firing, 0 resolved alerts", msg.Header.Get("Subject"))
+		assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		body := emailBody(t, msg)
+		assert.Contains(t, body, `<td style="padding:2px 0">a</td>`)
+		assert.Contains(t, body, `<td style="padding:2px 0">b</td>`)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the digest was not delivered")
+	}
+
+	// the digest is recorded once the smtp session ends
+	assert.Eventually(t, func() bool {
+		attempts, err := db.GetDeliveryAttempts(context.Background(), &DeliveryAttemptFilter{Channel: "email", Status: DeliveryStatusSuccess})
+		return err == nil && len(attempts) == 3
+	}, time.Second, 10*time.Millisecond)
+}
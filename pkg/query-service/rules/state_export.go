@@ -0,0 +1,286 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// stateExportQueueCapacity bounds the events waiting to be exported, the events
+	// past it are dropped
+	stateExportQueueCapacity = 10000
+	// stateExportBatchSize and stateExportFlushInterval are how many events are sent at
+	// once and how long the events wait for a batch to fill
+	stateExportBatchSize     = 500
+	stateExportFlushInterval = time.Second
+	stateExportTimeout       = 10 * time.Second
+)
+
+// StateExportEventType is the kind of the event exported to the sinks
+type StateExportEventType string
+
+const (
+	StateExportStateChange  StateExportEventType = "state_change"
+	StateExportNotification StateExportEventType = "notification"
+)
+
+// StateExportEvent is the payload of the event exported to the sinks, either the state
+// change of a series of the rule or the notification of an alert
+type StateExportEvent struct {
+	Type         StateExportEventType    `json:"type"`
+	RuleId       string                  `json:"ruleId"`
+	Timestamp    time.Time               `json:"timestamp"`
+	StateChange  *model.RuleStateHistory `json:"stateChange,omitempty"`
+	Notification *NotificationLogEntry   `json:"notification,omitempty"`
+}
+
+// stateExportSink publishes the batches of the events to an external system
+type stateExportSink interface {
+	name() string
+	publish(ctx context.Context, events []StateExportEvent) error
+	close() error
+}
+
+// webhookSink posts the batches of the events as json arrays
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *webhookSink) name() string {
+	return "webhook"
+}
+
+func (s *webhookSink) publish(ctx context.Context, events []StateExportEvent) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s returned status %d", s.url, resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *webhookSink) close() error {
+	return nil
+}
+
+// kafkaSink produces every event as a message keyed by the rule id so the events of
+// a rule keep their order
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func (s *kafkaSink) name() string {
+	return "kafka"
+}
+
+func (s *kafkaSink) publish(ctx context.Context, events []StateExportEvent) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		value, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, kafka.Message{Key: []byte(event.RuleId), Value: value, Time: event.Timestamp})
+	}
+	return s.writer.WriteMessages(ctx, messages...)
+}
+
+func (s *kafkaSink) close() error {
+	return s.writer.Close()
+}
+
+// stateExporter publishes the state changes of the rules and the notifications to the
+// sinks in the background, the evaluations never wait for the sinks and the events are
+// dropped when the queue is full
+type stateExporter struct {
+	sinks []stateExportSink
+	queue chan StateExportEvent
+	// done stops the export, stopped is closed once the queued events are published
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// newStateExporter returns nil when no sink is configured
+func newStateExporter(webhooks []string, kafkaBrokers []string, kafkaTopic string) *stateExporter {
+	var sinks []stateExportSink
+	for _, url := range webhooks {
+		sinks = append(sinks, &webhookSink{url: url, client: &http.Client{Timeout: stateExportTimeout}})
+	}
+	if len(kafkaBrokers) > 0 {
+		sinks = append(sinks, &kafkaSink{writer: &kafka.Writer{
+			Addr:         kafka.TCP(kafkaBrokers...),
+			Topic:        kafkaTopic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireOne,
+			// the events are batched by the exporter already
+			BatchSize:    stateExportBatchSize,
+			BatchTimeout: 10 * time.Millisecond,
+		}})
+	}
+	if len(sinks) == 0 {
+		return nil
+	}
+	return &stateExporter{
+		sinks:   sinks,
+		queue:   make(chan StateExportEvent, stateExportQueueCapacity),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (e *stateExporter) start() {
+	if e == nil {
+		return
+	}
+	go e.run()
+}
+
+// stop publishes the events left in the queue, it gives up on them once the context
+// is done
+func (e *stateExporter) stop(ctx context.Context) {
+	if e == nil {
+		return
+	}
+	close(e.done)
+	select {
+	case <-e.stopped:
+	case <-ctx.Done():
+		zap.L().Warn("queued alert state events were not exported before the shutdown timeout")
+	}
+}
+
+func (e *stateExporter) enqueue(event StateExportEvent) {
+	select {
+	case e.queue <- event:
+	default:
+		stateExportDropped.Inc()
+	}
+}
+
+func (e *stateExporter) exportStateChanges(entries []model.RuleStateHistory) {
+	if e == nil {
+		return
+	}
+	for i := range entries {
+		entry := entries[i]
+		e.enqueue(StateExportEvent{
+			Type:        StateExportStateChange,
+			RuleId:      entry.RuleID,
+			Timestamp:   time.UnixMilli(entry.UnixMilli).UTC(),
+			StateChange: &entry,
+		})
+	}
+}
+
+func (e *stateExporter) exportNotifications(entries []NotificationLogEntry) {
+	if e == nil {
+		return
+	}
+	for i := range entries {
+		entry := entries[i]
+		// the payload sent to the channel is left out of the export
+		entry.Payload = ""
+		e.enqueue(StateExportEvent{
+			Type:         StateExportNotification,
+			RuleId:       entry.RuleId,
+			Timestamp:    entry.Timestamp.UTC(),
+			Notification: &entry,
+		})
+	}
+}
+
+// run publishes the queued events in batches until the export is stopped, then
+// publishes the events left in the queue and closes the sinks
+func (e *stateExporter) run() {
+	defer close(e.stopped)
+	ticker := time.NewTicker(stateExportFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]StateExportEvent, 0, stateExportBatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.publish(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case <-e.done:
+			for {
+				select {
+				case event := <-e.queue:
+					batch = append(batch, event)
+					if len(batch) == stateExportBatchSize {
+						flush()
+					}
+				default:
+					flush()
+					for _, sink := range e.sinks {
+						if err := sink.close(); err != nil {
+							zap.L().Warn("failed to close the state export sink", zap.String("sink", sink.name()), zap.Error(err))
+						}
+					}
+					return
+				}
+			}
+		case event := <-e.queue:
+			batch = append(batch, event)
+			if len(batch) == stateExportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (e *stateExporter) publish(events []StateExportEvent) {
+	for _, sink := range e.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), stateExportTimeout)
+		err := sink.publish(ctx, events)
+		cancel()
+		if err != nil {
+			stateExportFailures.WithLabelValues(sink.name()).Add(float64(len(events)))
+			zap.L().Error("failed to export the alert state events", zap.String("sink", sink.name()), zap.Int("events", len(events)), zap.Error(err))
+			continue
+		}
+		stateExported.WithLabelValues(sink.name()).Add(float64(len(events)))
+	}
+}
+
+// stateExportReader exports the state changes of the rules recorded in the state history
+type stateExportReader struct {
+	interfaces.Reader
+	exporter *stateExporter
+}
+
+func (r *stateExportReader) AddRuleStateHistory(ctx context.Context, ruleStateHistory []model.RuleStateHistory) error {
+	if err := r.Reader.AddRuleStateHistory(ctx, ruleStateHistory); err != nil {
+		return err
+	}
+	r.exporter.exportStateChanges(ruleStateHistory)
+	return nil
+}
@@ -0,0 +1,89 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// historyWriterReader records the state history written
+type historyWriterReader struct {
+	interfaces.Reader
+	written []model.RuleStateHistory
+}
+
+func (r *historyWriterReader) AddRuleStateHistory(ctx context.Context, ruleStateHistory []model.RuleStateHistory) error {
+	r.written = append(r.written, ruleStateHistory...)
+	return nil
+}
+
+// exportedEvent is the exported event as read by the sink
+type exportedEvent struct {
+	Type        StateExportEventType `json:"type"`
+	RuleId      string               `json:"ruleId"`
+	StateChange *struct {
+		State  model.AlertState  `json:"state"`
+		Labels map[string]string `json:"labels"`
+	} `json:"stateChange"`
+	Notification *NotificationLogEntry `json:"notification"`
+}
+
+func TestStateExporter(t *testing.T) {
+	var mtx sync.Mutex
+	var received []exportedEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []exportedEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&events))
+		mtx.Lock()
+		received = append(received, events...)
+		mtx.Unlock()
+	}))
+	defer server.Close()
+
+	assert.Nil(t, newStateExporter(nil, nil, ""))
+	exporter := newStateExporter([]string{server.URL}, nil, "")
+	require.NotNil(t, exporter)
+
+	writer := &historyWriterReader{}
+	reader := &stateExportReader{Reader: writer, exporter: exporter}
+	require.NoError(t, reader.AddRuleStateHistory(context.Background(), []model.RuleStateHistory{
+		{RuleID: "1", Fingerprint: 7, Labels: `{"service":"cart"}`, State: model.StateFiring, StateChanged: true, UnixMilli: 1000},
+	}))
+	assert.Len(t, writer.written, 1)
+	exporter.exportNotifications([]NotificationLogEntry{
+		{RuleId: "1", Fingerprint: "0000000000000007", State: "firing", Channel: "slack", Payload: "{}", Timestamp: time.UnixMilli(2000)},
+	})
+
+	// the queued events are exported when the exporter stops
+	exporter.start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	exporter.stop(ctx)
+
+	mtx.Lock()
+	defer mtx.Unlock()
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, StateExportStateChange, received[0].Type)
+		assert.Equal(t, "1", received[0].RuleId)
+		assert.Equal(t, model.StateFiring, received[0].StateChange.State)
+		assert.Equal(t, map[string]string{"service": "cart"}, received[0].StateChange.Labels)
+		assert.Equal(t, StateExportNotification, received[1].Type)
+		assert.Equal(t, "slack", received[1].Notification.Channel)
+		assert.Empty(t, received[1].Notification.Payload)
+	}
+}
+
+func TestStateExporterDropsWhenFull(t *testing.T) {
+	exporter := &stateExporter{queue: make(chan StateExportEvent, 1)}
+	exporter.exportStateChanges([]model.RuleStateHistory{{RuleID: "1"}, {RuleID: "1"}})
+	assert.Len(t, exporter.queue, 1)
+}
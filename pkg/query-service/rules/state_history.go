@@ -0,0 +1,209 @@
+package rules
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+// QueryStateHistory returns a page of the state changes of the alerts of the rules the
+// user can view, the state changes of all the rules the user can view when no rule is given
+func (m *Manager) QueryStateHistory(ctx context.Context, params *model.QueryAlertStateHistory) (*model.AlertStateHistoryPage, error) {
+	ok, err := m.viewableHistoryRules(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &model.AlertStateHistoryPage{Items: []model.RuleStateHistory{}, Offset: params.Offset, Limit: params.Limit}, nil
+	}
+	return m.reader.QueryAlertStateHistory(ctx, params)
+}
+
+// RuleStateHistory returns the state changes of the rule the user can view with the
+// annotations of its firing periods
+func (m *Manager) RuleStateHistory(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (*model.RuleStateTimeline, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleID, RulePermissionView); err != nil {
+		return nil, err
+	}
+	timeline, err := m.reader.ReadRuleStateHistoryByRuleID(ctx, ruleID, params)
+	if err != nil {
+		return nil, err
+	}
+	timeline.Annotations, err = m.ruleDB.GetFiringAnnotations(ctx, ruleID, params.Start, params.End)
+	if err != nil {
+		return nil, err
+	}
+	return timeline, nil
+}
+
+// OverallStateTransitions returns the periods of the overall state of the rule the user
+// can view with the annotations of its firing periods
+func (m *Manager) OverallStateTransitions(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) ([]model.ReleStateItem, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleID, RulePermissionView); err != nil {
+		return nil, err
+	}
+	stateItems, err := m.reader.GetOverallStateTransitions(ctx, ruleID, params)
+	if err != nil {
+		return nil, err
+	}
+	annotations, err := m.ruleDB.GetFiringAnnotations(ctx, ruleID, params.Start, params.End)
+	if err != nil {
+		return nil, err
+	}
+	AnnotateStateItems(stateItems, annotations)
+	return stateItems, nil
+}
+
+// RuleStateHistoryTopContributors returns the series of the rule the user can view
+// firing the most
+func (m *Manager) RuleStateHistoryTopContributors(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) ([]model.RuleStateHistoryContributor, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleID, RulePermissionView); err != nil {
+		return nil, err
+	}
+	return m.reader.ReadRuleStateHistoryTopContributorsByRuleID(ctx, ruleID, params)
+}
+
+// RuleStats returns the triggers and the resolution time of the rule the user can view
+// in the time range and in the period before it
+func (m *Manager) RuleStats(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (*model.Stats, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleID, RulePermissionView); err != nil {
+		return nil, err
+	}
+
+	totalCurrentTriggers, err := m.reader.GetTotalTriggers(ctx, ruleID, params)
+	if err != nil {
+		return nil, err
+	}
+	currentTriggersSeries, err := m.reader.GetTriggersByInterval(ctx, ruleID, params)
+	if err != nil {
+		return nil, err
+	}
+	currentAvgResolutionTime, err := m.reader.GetAvgResolutionTime(ctx, ruleID, params)
+	if err != nil {
+		return nil, err
+	}
+	currentAvgResolutionTimeSeries, err := m.reader.GetAvgResolutionTimeByInterval(ctx, ruleID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	past := *params
+	if past.End-past.Start >= 86400000 {
+		days := int64(math.Ceil(float64(past.End-past.Start) / 86400000))
+		past.Start -= days * 86400000
+		past.End -= days * 86400000
+	} else {
+		past.Start -= 86400000
+		past.End -= 86400000
+	}
+
+	totalPastTriggers, err := m.reader.GetTotalTriggers(ctx, ruleID, &past)
+	if err != nil {
+		return nil, err
+	}
+	pastTriggersSeries, err := m.reader.GetTriggersByInterval(ctx, ruleID, &past)
+	if err != nil {
+		return nil, err
+	}
+	pastAvgResolutionTime, err := m.reader.GetAvgResolutionTime(ctx, ruleID, &past)
+	if err != nil {
+		return nil, err
+	}
+	pastAvgResolutionTimeSeries, err := m.reader.GetAvgResolutionTimeByInterval(ctx, ruleID, &past)
+	if err != nil {
+		return nil, err
+	}
+	if math.IsNaN(currentAvgResolutionTime) || math.IsInf(currentAvgResolutionTime, 0) {
+		currentAvgResolutionTime = 0
+	}
+	if math.IsNaN(pastAvgResolutionTime) || math.IsInf(pastAvgResolutionTime, 0) {
+		pastAvgResolutionTime = 0
+	}
+
+	return &model.Stats{
+		TotalCurrentTriggers:           totalCurrentTriggers,
+		TotalPastTriggers:              totalPastTriggers,
+		CurrentTriggersSeries:          currentTriggersSeries,
+		PastTriggersSeries:             pastTriggersSeries,
+		CurrentAvgResolutionTime:       strconv.FormatFloat(currentAvgResolutionTime, 'f', -1, 64),
+		PastAvgResolutionTime:          strconv.FormatFloat(pastAvgResolutionTime, 'f', -1, 64),
+		CurrentAvgResolutionTimeSeries: currentAvgResolutionTimeSeries,
+		PastAvgResolutionTimeSeries:    pastAvgResolutionTimeSeries,
+	}, nil
+}
+
+// viewableHistoryRules checks the user can view the rules of the query, the query of no
+// rule selects all the rules the user can view, false when there are none
+func (m *Manager) viewableHistoryRules(ctx context.Context, params *model.QueryAlertStateHistory) (bool, error) {
+	if len(params.RuleIDs) > 0 {
+		for _, id := range params.RuleIDs {
+			if err := m.ruleDB.CheckRulePermission(ctx, id, RulePermissionView); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	storedRules, err := m.ruleDB.GetStoredRules(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, storedRule := range storedRules {
+		params.RuleIDs = append(params.RuleIDs, strconv.Itoa(storedRule.Id))
+	}
+	return len(params.RuleIDs) > 0, nil
+}
+
+// GetAllRuleIds fetches the ids of the rules of all the orgs, the rules in the trash included
+func (r *ruleDB) GetAllRuleIds(ctx context.Context) ([]string, error) {
+	ids := []int{}
+	if err := r.Select(&ids, "SELECT id FROM rules"); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	ruleIds := make([]string, 0, len(ids))
+	for _, id := range ids {
+		ruleIds = append(ruleIds, strconv.Itoa(id))
+	}
+	return ruleIds, nil
+}
+
+// PurgeDeletedRulesHistory deletes the state history of the rules purged from the trash
+// with its rollups and annotations and returns their ids, the history of the rules in
+// the trash is kept to restore them
+func (m *Manager) PurgeDeletedRulesHistory(ctx context.Context) ([]string, error) {
+	historyRuleIds, err := m.reader.GetRuleStateHistoryRuleIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ruleIds, err := m.ruleDB.GetAllRuleIds(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]struct{}, len(ruleIds))
+	for _, id := range ruleIds {
+		existing[id] = struct{}{}
+	}
+
+	purged := []string{}
+	for _, id := range historyRuleIds {
+		if _, ok := existing[id]; !ok {
+			purged = append(purged, id)
+		}
+	}
+	if err := m.reader.DeleteRuleStateHistory(ctx, purged); err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.DeleteStateRollups(ctx, purged); err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.DeleteFiringAnnotationsOfRules(ctx, purged); err != nil {
+		return nil, err
+	}
+	if len(purged) > 0 {
+		zap.L().Info("purged the state history of the deleted rules", zap.Strings("rules", purged))
+	}
+	return purged, nil
+}
@@ -0,0 +1,131 @@
+package rules
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// HistoryExportFormat is the file format the state history is exported in
+type HistoryExportFormat string
+
+const (
+	HistoryExportCSV     HistoryExportFormat = "csv"
+	HistoryExportParquet HistoryExportFormat = "parquet"
+)
+
+// parquetRowGroupSize keeps the rows buffered by the parquet writer bounded, the
+// row groups are flushed to the response as they fill
+const parquetRowGroupSize = 8 * 1024 * 1024
+
+// Validate checks the format is known
+func (f HistoryExportFormat) Validate() error {
+	switch f {
+	case HistoryExportCSV, HistoryExportParquet:
+		return nil
+	}
+	return fmt.Errorf("invalid format %s, supported formats: %s, %s", f, HistoryExportCSV, HistoryExportParquet)
+}
+
+// ContentType is the media type of the exported file
+func (f HistoryExportFormat) ContentType() string {
+	if f == HistoryExportParquet {
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv"
+}
+
+var stateHistoryCSVHeader = []string{
+	"timestamp", "rule_id", "rule_name", "fingerprint", "labels",
+	"state", "state_changed", "overall_state", "overall_state_changed", "value",
+}
+
+// stateHistoryParquetRow is the parquet schema of a state change
+type stateHistoryParquetRow struct {
+	Timestamp           int64   `parquet:"name=timestamp, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	RuleID              string  `parquet:"name=rule_id, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	RuleName            string  `parquet:"name=rule_name, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	Fingerprint         string  `parquet:"name=fingerprint, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Labels              string  `parquet:"name=labels, type=BYTE_ARRAY, convertedtype=UTF8"`
+	State               string  `parquet:"name=state, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	StateChanged        bool    `parquet:"name=state_changed, type=BOOLEAN"`
+	OverallState        string  `parquet:"name=overall_state, type=BYTE_ARRAY, convertedtype=UTF8, encoding=PLAIN_DICTIONARY"`
+	OverallStateChanged bool    `parquet:"name=overall_state_changed, type=BOOLEAN"`
+	Value               float64 `parquet:"name=value, type=DOUBLE"`
+}
+
+// ExportStateHistory writes the state changes of the alerts of the rules the user can
+// view matching the query to w in the format, oldest first and without paging
+func (m *Manager) ExportStateHistory(ctx context.Context, params *model.QueryAlertStateHistory, format HistoryExportFormat, w io.Writer) error {
+	if err := format.Validate(); err != nil {
+		return err
+	}
+	ok, err := m.viewableHistoryRules(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case HistoryExportParquet:
+		pw, err := writer.NewParquetWriterFromWriter(w, new(stateHistoryParquetRow), 1)
+		if err != nil {
+			return err
+		}
+		pw.RowGroupSize = parquetRowGroupSize
+		pw.CompressionType = parquet.CompressionCodec_SNAPPY
+		if ok {
+			err = m.reader.ExportAlertStateHistory(ctx, params, func(item *model.RuleStateHistory) error {
+				return pw.Write(stateHistoryParquetRow{
+					Timestamp:           item.UnixMilli,
+					RuleID:              item.RuleID,
+					RuleName:            item.RuleName,
+					Fingerprint:         strconv.FormatUint(item.Fingerprint, 10),
+					Labels:              string(item.Labels),
+					State:               item.State.String(),
+					StateChanged:        item.StateChanged,
+					OverallState:        item.OverallState.String(),
+					OverallStateChanged: item.OverallStateChanged,
+					Value:               item.Value,
+				})
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return pw.WriteStop()
+
+	default:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(stateHistoryCSVHeader); err != nil {
+			return err
+		}
+		if ok {
+			err = m.reader.ExportAlertStateHistory(ctx, params, func(item *model.RuleStateHistory) error {
+				return cw.Write([]string{
+					time.UnixMilli(item.UnixMilli).UTC().Format(time.RFC3339Nano),
+					item.RuleID,
+					item.RuleName,
+					strconv.FormatUint(item.Fingerprint, 10),
+					string(item.Labels),
+					item.State.String(),
+					strconv.FormatBool(item.StateChanged),
+					item.OverallState.String(),
+					strconv.FormatBool(item.OverallStateChanged),
+					strconv.FormatFloat(item.Value, 'f', -1, 64),
+				})
+			})
+			if err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+}
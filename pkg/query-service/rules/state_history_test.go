@@ -0,0 +1,212 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/auth"
+	"go.signoz.io/signoz/pkg/query-service/constants"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// alertStateHistoryReader records the query of the alert state history
+type alertStateHistoryReader struct {
+	interfaces.Reader
+	params *model.QueryAlertStateHistory
+}
+
+func (r *alertStateHistoryReader) QueryAlertStateHistory(ctx context.Context, params *model.QueryAlertStateHistory) (*model.AlertStateHistoryPage, error) {
+	r.params = params
+	return &model.AlertStateHistoryPage{Items: []model.RuleStateHistory{}}, nil
+}
+
+func TestQueryStateHistory(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	reader := &alertStateHistoryReader{}
+	m.reader = reader
+
+	// no rules, nothing to query
+	page, err := m.QueryStateHistory(ctx, &model.QueryAlertStateHistory{Start: 1, End: 2, Limit: 10, Order: "desc"})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Items)
+	assert.Nil(t, reader.params)
+
+	for _, alert := range []string{"Error rate", "Latency"} {
+		_, err := m.CreateRule(ctx, `{"alert":"`+alert+`","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+		assert.NoError(t, err)
+	}
+
+	// the state changes of all the rules the user can view
+	_, err = m.QueryStateHistory(ctx, &model.QueryAlertStateHistory{Start: 1, End: 2, Limit: 10, Order: "desc"})
+	assert.NoError(t, err)
+	if assert.NotNil(t, reader.params) {
+		assert.ElementsMatch(t, []string{"1", "2"}, reader.params.RuleIDs)
+	}
+
+	_, err = m.QueryStateHistory(ctx, &model.QueryAlertStateHistory{Start: 1, End: 2, RuleIDs: []string{"2"}, Fingerprints: []uint64{7}, Limit: 10, Order: "desc"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, reader.params.RuleIDs)
+	assert.Equal(t, []uint64{7}, reader.params.Fingerprints)
+}
+
+func TestQueryAlertStateHistoryValidate(t *testing.T) {
+	valid := model.QueryAlertStateHistory{Start: 1, End: 2, Limit: 10, Order: "asc", States: []string{"firing", "nodata"}}
+	assert.NoError(t, valid.Validate())
+
+	invalid := valid
+	invalid.States = []string{"resolved"}
+	assert.Error(t, invalid.Validate())
+
+	invalid = valid
+	invalid.Limit = model.MaxStateHistoryLimit + 1
+	assert.Error(t, invalid.Validate())
+
+	invalid = valid
+	invalid.Start = 3
+	assert.Error(t, invalid.Validate())
+}
+
+// ruleStateHistoryPurgeReader records the rules their state history is deleted
+type ruleStateHistoryPurgeReader struct {
+	interfaces.Reader
+	ruleIDs []string
+	deleted []string
+}
+
+func (r *ruleStateHistoryPurgeReader) GetRuleStateHistoryRuleIDs(ctx context.Context) ([]string, error) {
+	return r.ruleIDs, nil
+}
+
+func (r *ruleStateHistoryPurgeReader) DeleteRuleStateHistory(ctx context.Context, ruleIDs []string) error {
+	r.deleted = ruleIDs
+	return nil
+}
+
+func TestPurgeDeletedRulesHistory(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	reader := &ruleStateHistoryPurgeReader{ruleIDs: []string{"1", "2", "3"}}
+	m.reader = reader
+
+	for _, alert := range []string{"Error rate", "Latency"} {
+		_, err := m.CreateRule(ctx, `{"alert":"`+alert+`","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+		assert.NoError(t, err)
+	}
+	// the rule in the trash can still be restored with its history
+	assert.NoError(t, m.DeleteRule(ctx, "2"))
+
+	purged, err := m.PurgeDeletedRulesHistory(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"3"}, purged)
+	assert.Equal(t, []string{"3"}, reader.deleted)
+}
+
+// exportStateHistoryReader streams the state changes to the export
+type exportStateHistoryReader struct {
+	interfaces.Reader
+	items []model.RuleStateHistory
+}
+
+func (r *exportStateHistoryReader) ExportAlertStateHistory(ctx context.Context, params *model.QueryAlertStateHistory, fn func(*model.RuleStateHistory) error) error {
+	for i := range r.items {
+		if err := fn(&r.items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportStateHistory(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	m.reader = &exportStateHistoryReader{items: []model.RuleStateHistory{
+		{RuleID: "1", RuleName: "Error rate", State: model.StateFiring, StateChanged: true, OverallState: model.StateFiring, OverallStateChanged: true, UnixMilli: 1700000000000, Labels: `{"service":"cart"}`, Fingerprint: 42, Value: 0.5},
+	}}
+	_, err := m.CreateRule(ctx, `{"alert":"Error rate","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	params := &model.QueryAlertStateHistory{Start: 1, End: 2, Order: "asc"}
+	assert.NoError(t, m.ExportStateHistory(ctx, params, HistoryExportCSV, &buf))
+	assert.Equal(t, "timestamp,rule_id,rule_name,fingerprint,labels,state,state_changed,overall_state,overall_state_changed,value\n"+
+		`2023-11-14T22:13:20Z,1,Error rate,42,"{""service"":""cart""}",firing,true,firing,true,0.5`+"\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, m.ExportStateHistory(ctx, params, HistoryExportParquet, &buf))
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("PAR1")))
+	assert.True(t, bytes.HasSuffix(buf.Bytes(), []byte("PAR1")))
+
+	assert.Error(t, m.ExportStateHistory(ctx, params, "xlsx", &buf))
+}
+
+// ruleHistoryReader returns an empty state history of the rules
+type ruleHistoryReader struct {
+	interfaces.Reader
+}
+
+func (r *ruleHistoryReader) ReadRuleStateHistoryByRuleID(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (*model.RuleStateTimeline, error) {
+	return &model.RuleStateTimeline{Items: []model.RuleStateHistory{}}, nil
+}
+
+func (r *ruleHistoryReader) GetOverallStateTransitions(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) ([]model.ReleStateItem, error) {
+	return []model.ReleStateItem{}, nil
+}
+
+func TestRuleStateHistoryAccess(t *testing.T) {
+	m := newTestManager(t)
+	m.reader = &ruleHistoryReader{}
+
+	adminGroupId := auth.AuthCacheObj.AdminGroupId
+	auth.AuthCacheObj.AdminGroupId = "admin-group"
+	defer func() { auth.AuthCacheObj.AdminGroupId = adminGroupId }()
+
+	userCtx := func(email, groupId, orgId string) context.Context {
+		user := &model.UserPayload{User: model.User{Email: email, GroupId: groupId, OrgId: orgId}}
+		return context.WithValue(context.Background(), constants.ContextUserKey, user)
+	}
+	admin := userCtx("admin@acme.io", "admin-group", "acme")
+	viewer := userCtx("viewer@acme.io", "viewer-group", "acme")
+	other := userCtx("admin@globex.io", "viewer-group", "globex")
+
+	_, err := m.CreateRule(admin, `{"alert":"Error rate","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+	require.NoError(t, err)
+	require.NoError(t, m.ruleDB.SetRulePermissions(admin, "1", []RulePermission{
+		{PrincipalType: RulePrincipalUser, Principal: "oncall@acme.io", Permission: RulePermissionView},
+	}))
+
+	params := &model.QueryRuleStateHistory{Start: 1, End: 2}
+	_, err = m.RuleStateHistory(admin, "1", params)
+	assert.NoError(t, err)
+	_, err = m.OverallStateTransitions(admin, "1", params)
+	assert.NoError(t, err)
+
+	// the users denied the view don't read the history
+	denied := &RulePermissionError{RuleId: "1", Permission: RulePermissionView}
+	_, err = m.RuleStateHistory(viewer, "1", params)
+	assert.Equal(t, denied, err)
+	_, err = m.OverallStateTransitions(viewer, "1", params)
+	assert.Equal(t, denied, err)
+	_, err = m.RuleStats(viewer, "1", params)
+	assert.Equal(t, denied, err)
+	_, err = m.RuleStateHistoryTopContributors(viewer, "1", params)
+	assert.Equal(t, denied, err)
+
+	// the history of the rules of the other orgs is not found
+	_, err = m.RuleStateHistory(other, "1", params)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = m.RuleStats(other, "1", params)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	// only the admins change the rules shared by all the orgs
+	_, err = m.CreateRule(context.Background(), `{"alert":"Shared","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+	require.NoError(t, err)
+	assert.NoError(t, m.ruleDB.CheckRulePermission(viewer, "2", RulePermissionView))
+	assert.Equal(t, &RulePermissionError{RuleId: "2", Permission: RulePermissionEdit}, m.ruleDB.CheckRulePermission(viewer, "2", RulePermissionEdit))
+	assert.NoError(t, m.ruleDB.CheckRulePermission(admin, "2", RulePermissionEdit))
+}
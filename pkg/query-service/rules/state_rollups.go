@@ -0,0 +1,409 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// stateRollupInterval is how often the state history is rolled up
+	stateRollupInterval = 10 * time.Minute
+	// stateRollupBackfill is how much of the state history is rolled up the first time
+	stateRollupBackfill = 7 * 24 * time.Hour
+	// stateRollupBatch bounds the state history read at once
+	stateRollupBatch = 24 * time.Hour
+
+	// hourlyRollupRetention and dailyRollupRetention are how long the rollups are kept
+	hourlyRollupRetention = 35 * 24 * time.Hour
+	dailyRollupRetention  = 400 * 24 * time.Hour
+)
+
+// RollupResolution is the size of the buckets the state history is rolled up into
+type RollupResolution string
+
+const (
+	RollupHourly RollupResolution = "1h"
+	RollupDaily  RollupResolution = "1d"
+)
+
+// Validate checks the resolution is known
+func (r RollupResolution) Validate() error {
+	switch r {
+	case RollupHourly, RollupDaily:
+		return nil
+	}
+	return fmt.Errorf("invalid resolution %s, supported resolutions: %s, %s", r, RollupHourly, RollupDaily)
+}
+
+// Duration is the size of the buckets of the resolution
+func (r RollupResolution) Duration() time.Duration {
+	if r == RollupDaily {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// StateRollup is the time the series of the rule spent in each state and the number of
+// their state changes over the bucket, the buckets the rule stayed inactive throughout
+// are not stored
+type StateRollup struct {
+	RuleId      string           `json:"ruleId" db:"rule_id"`
+	Resolution  RollupResolution `json:"resolution" db:"resolution"`
+	BucketStart time.Time        `json:"bucketStart" db:"bucket_start"`
+	// OverallFiringSeconds is how long any series of the rule was firing or had no data
+	OverallFiringSeconds float64 `json:"overallFiringSeconds" db:"overall_firing_seconds"`
+	// FiringSeconds, PendingSeconds and NoDataSeconds sum the time of every series in the state
+	FiringSeconds  float64 `json:"firingSeconds" db:"firing_seconds"`
+	PendingSeconds float64 `json:"pendingSeconds" db:"pending_seconds"`
+	NoDataSeconds  float64 `json:"noDataSeconds" db:"nodata_seconds"`
+	// Transitions is the number of the state changes of the series
+	Transitions int `json:"transitions" db:"transitions"`
+	// Firings is the number of the times a series started firing
+	Firings int `json:"firings" db:"firings"`
+}
+
+func (r *ruleDB) SaveStateRollups(ctx context.Context, resolution RollupResolution, rolledUpTo time.Time, rollups []StateRollup) error {
+	tx, err := r.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, rollup := range rollups {
+		_, err := tx.Exec(`INSERT OR REPLACE INTO rule_state_rollups (rule_id, resolution, bucket_start, overall_firing_seconds,
+			firing_seconds, pending_seconds, nodata_seconds, transitions, firings)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			rollup.RuleId, resolution, rollup.BucketStart.UTC(), rollup.OverallFiringSeconds,
+			rollup.FiringSeconds, rollup.PendingSeconds, rollup.NoDataSeconds, rollup.Transitions, rollup.Firings)
+		if err != nil {
+			zap.L().Error("Error in Executing INSERT to rule_state_rollups", zap.Error(err))
+			return err
+		}
+	}
+	_, err = tx.Exec(`INSERT OR REPLACE INTO rule_state_rollup_progress (resolution, rolled_up_to) VALUES ($1, $2)`,
+		resolution, rolledUpTo.UTC())
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to rule_state_rollup_progress", zap.Error(err))
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *ruleDB) GetStateRollupProgress(ctx context.Context, resolution RollupResolution) (time.Time, error) {
+	progress := []time.Time{}
+	err := r.Select(&progress, `SELECT rolled_up_to FROM rule_state_rollup_progress WHERE resolution=$1`, resolution)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return time.Time{}, err
+	}
+	if len(progress) == 0 {
+		return time.Time{}, nil
+	}
+	return progress[0].UTC(), nil
+}
+
+func (r *ruleDB) GetStateRollups(ctx context.Context, ruleId string, resolution RollupResolution, start, end time.Time) ([]StateRollup, error) {
+	rollups := []StateRollup{}
+	query := `SELECT rule_id, resolution, bucket_start, overall_firing_seconds, firing_seconds, pending_seconds, nodata_seconds,
+		transitions, firings FROM rule_state_rollups WHERE resolution=$1 AND bucket_start>=$2 AND bucket_start<$3`
+	args := []interface{}{resolution, start.UTC(), end.UTC()}
+	if ruleId != "" {
+		query += " AND rule_id=$4"
+		args = append(args, ruleId)
+	}
+	err := r.Select(&rollups, query+" ORDER BY rule_id, bucket_start", args...)
+	if err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	for i := range rollups {
+		rollups[i].BucketStart = rollups[i].BucketStart.UTC()
+	}
+	return rollups, nil
+}
+
+func (r *ruleDB) PurgeStateRollups(ctx context.Context, resolution RollupResolution, before time.Time) error {
+	_, err := r.Exec("DELETE FROM rule_state_rollups WHERE resolution=$1 AND bucket_start<$2", resolution, before.UTC())
+	if err != nil {
+		zap.L().Error("Error in Executing DELETE from rule_state_rollups", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (r *ruleDB) DeleteStateRollups(ctx context.Context, ruleIds []string) error {
+	for _, ruleId := range ruleIds {
+		if _, err := r.Exec("DELETE FROM rule_state_rollups WHERE rule_id=$1", ruleId); err != nil {
+			zap.L().Error("Error in Executing DELETE from rule_state_rollups", zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Manager) rollupStateHistoryLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(stateRollupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := m.rollupStateHistory(context.Background(), time.Now()); err != nil {
+				zap.L().Error("failed to roll up the rule state history", zap.Error(err))
+			}
+		}
+	}
+}
+
+// rollupStateHistory rolls up the state history of the rules into the hourly buckets up
+// to the last complete hour and the hourly rollups into the daily ones up to the last
+// complete day, and purges the rollups past their retention
+func (m *Manager) rollupStateHistory(ctx context.Context, now time.Time) error {
+	through := now.UTC().Truncate(time.Hour)
+	from, err := m.ruleDB.GetStateRollupProgress(ctx, RollupHourly)
+	if err != nil {
+		return err
+	}
+	if from.IsZero() {
+		from = through.Truncate(24 * time.Hour).Add(-stateRollupBackfill)
+	}
+	for from.Before(through) {
+		to := from.Add(stateRollupBatch)
+		if to.After(through) {
+			to = through
+		}
+		changes, err := m.reader.GetRuleStateChanges(ctx, nil, from.UnixMilli(), to.UnixMilli())
+		if err != nil {
+			return err
+		}
+		if err := m.ruleDB.SaveStateRollups(ctx, RollupHourly, to, computeStateRollups(changes, from, to)); err != nil {
+			return err
+		}
+		from = to
+	}
+
+	throughDay := through.Truncate(24 * time.Hour)
+	day, err := m.ruleDB.GetStateRollupProgress(ctx, RollupDaily)
+	if err != nil {
+		return err
+	}
+	if day.IsZero() {
+		day = throughDay.Add(-stateRollupBackfill)
+	}
+	for ; day.Before(throughDay); day = day.Add(24 * time.Hour) {
+		hourly, err := m.ruleDB.GetStateRollups(ctx, "", RollupHourly, day, day.Add(24*time.Hour))
+		if err != nil {
+			return err
+		}
+		if err := m.ruleDB.SaveStateRollups(ctx, RollupDaily, day.Add(24*time.Hour), sumStateRollups(hourly, day)); err != nil {
+			return err
+		}
+	}
+
+	if err := m.ruleDB.PurgeStateRollups(ctx, RollupHourly, now.Add(-hourlyRollupRetention)); err != nil {
+		return err
+	}
+	return m.ruleDB.PurgeStateRollups(ctx, RollupDaily, now.Add(-dailyRollupRetention))
+}
+
+// computeStateRollups folds the state changes of the rules into the hourly buckets of
+// the time range, the start of the range is on the hour
+func computeStateRollups(changes []model.RuleStateHistory, start, end time.Time) []StateRollup {
+	byRule := make(map[string][]model.RuleStateHistory)
+	for _, change := range changes {
+		byRule[change.RuleID] = append(byRule[change.RuleID], change)
+	}
+	startMillis, endMillis := start.UnixMilli(), end.UnixMilli()
+	bucketMillis := time.Hour.Milliseconds()
+
+	rollups := []StateRollup{}
+	for ruleId, ruleChanges := range byRule {
+		buckets := make(map[int64]*StateRollup)
+		bucket := func(ts int64) *StateRollup {
+			bucketStart := startMillis + (ts-startMillis)/bucketMillis*bucketMillis
+			b, ok := buckets[bucketStart]
+			if !ok {
+				b = &StateRollup{RuleId: ruleId, Resolution: RollupHourly, BucketStart: time.UnixMilli(bucketStart).UTC()}
+				buckets[bucketStart] = b
+			}
+			return b
+		}
+		// splitSegment calls fn with the part of the segment in every bucket it spans
+		splitSegment := func(segment model.StateSegment, fn func(b *StateRollup, seconds float64)) {
+			for ts := segment.Start; ts < segment.End; {
+				b := bucket(ts)
+				next := min(b.BucketStart.UnixMilli()+bucketMillis, segment.End)
+				fn(b, float64(next-ts)/1000)
+				ts = next
+			}
+		}
+
+		overall := []model.StateSegment{}
+		for _, timeline := range buildStateTimelines(ruleChanges, startMillis, endMillis) {
+			for _, segment := range timeline.Segments {
+				switch segment.State {
+				case model.StatePending:
+					splitSegment(segment, func(b *StateRollup, seconds float64) { b.PendingSeconds += seconds })
+				case model.StateFiring:
+					splitSegment(segment, func(b *StateRollup, seconds float64) { b.FiringSeconds += seconds })
+					overall = append(overall, segment)
+				case model.StateNoData:
+					splitSegment(segment, func(b *StateRollup, seconds float64) { b.NoDataSeconds += seconds })
+					overall = append(overall, segment)
+				}
+			}
+		}
+		for _, segment := range mergeSegments(overall) {
+			splitSegment(segment, func(b *StateRollup, seconds float64) { b.OverallFiringSeconds += seconds })
+		}
+
+		for _, change := range ruleChanges {
+			if change.UnixMilli < startMillis || change.UnixMilli >= endMillis {
+				continue
+			}
+			b := bucket(change.UnixMilli)
+			b.Transitions++
+			if change.State == model.StateFiring {
+				b.Firings++
+			}
+		}
+
+		for _, b := range buckets {
+			rollups = append(rollups, *b)
+		}
+	}
+
+	sortStateRollups(rollups)
+	return rollups
+}
+
+// mergeSegments merges the overlapping segments regardless of their states
+func mergeSegments(segments []model.StateSegment) []model.StateSegment {
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].Start < segments[j].Start
+	})
+	merged := []model.StateSegment{}
+	for _, segment := range segments {
+		if n := len(merged); n > 0 && segment.Start <= merged[n-1].End {
+			merged[n-1].End = max(merged[n-1].End, segment.End)
+			continue
+		}
+		merged = append(merged, segment)
+	}
+	return merged
+}
+
+// sumStateRollups sums the rollups of every rule into one daily rollup of the day
+func sumStateRollups(rollups []StateRollup, day time.Time) []StateRollup {
+	byRule := make(map[string]*StateRollup)
+	for _, rollup := range rollups {
+		sum, ok := byRule[rollup.RuleId]
+		if !ok {
+			sum = &StateRollup{RuleId: rollup.RuleId, Resolution: RollupDaily, BucketStart: day.UTC()}
+			byRule[rollup.RuleId] = sum
+		}
+		sum.OverallFiringSeconds += rollup.OverallFiringSeconds
+		sum.FiringSeconds += rollup.FiringSeconds
+		sum.PendingSeconds += rollup.PendingSeconds
+		sum.NoDataSeconds += rollup.NoDataSeconds
+		sum.Transitions += rollup.Transitions
+		sum.Firings += rollup.Firings
+	}
+
+	sums := make([]StateRollup, 0, len(byRule))
+	for _, sum := range byRule {
+		sums = append(sums, *sum)
+	}
+	sortStateRollups(sums)
+	return sums
+}
+
+func sortStateRollups(rollups []StateRollup) {
+	sort.Slice(rollups, func(i, j int) bool {
+		if rollups[i].RuleId != rollups[j].RuleId {
+			return rollups[i].RuleId < rollups[j].RuleId
+		}
+		return rollups[i].BucketStart.Before(rollups[j].BucketStart)
+	})
+}
+
+// QueryStateRollups selects the rollups of the state history of the rule in the time
+// range in milliseconds
+type QueryStateRollups struct {
+	Start      int64            `json:"start"`
+	End        int64            `json:"end"`
+	Resolution RollupResolution `json:"resolution"`
+}
+
+func (q *QueryStateRollups) Validate() error {
+	if q.Start == 0 || q.End == 0 {
+		return fmt.Errorf("start and end are required")
+	}
+	if q.Start >= q.End {
+		return fmt.Errorf("start must be before end")
+	}
+	return q.Resolution.Validate()
+}
+
+// RuleStateRollups is the rollups of the state history of the rule over the time range,
+// the history after RolledUpTo is not rolled up yet
+type RuleStateRollups struct {
+	RuleID     string           `json:"ruleId"`
+	Resolution RollupResolution `json:"resolution"`
+	Start      int64            `json:"start"`
+	End        int64            `json:"end"`
+	RolledUpTo time.Time        `json:"rolledUpTo"`
+	// Availability is the share of the rolled up time range no series of the rule was
+	// firing or had no data
+	Availability float64       `json:"availability"`
+	Buckets      []StateRollup `json:"buckets"`
+}
+
+// StateRollups returns the rollups of the state history of the rule over the time range
+// and the availability of the rule they add up to
+func (m *Manager) StateRollups(ctx context.Context, ruleID string, params *QueryStateRollups) (*RuleStateRollups, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleID, RulePermissionView); err != nil {
+		return nil, err
+	}
+	rolledUpTo, err := m.ruleDB.GetStateRollupProgress(ctx, params.Resolution)
+	if err != nil {
+		return nil, err
+	}
+	// the bucket the range starts in is included
+	start := time.UnixMilli(params.Start).UTC().Truncate(params.Resolution.Duration())
+	end := time.UnixMilli(params.End).UTC()
+	buckets, err := m.ruleDB.GetStateRollups(ctx, ruleID, params.Resolution, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RuleStateRollups{
+		RuleID:       ruleID,
+		Resolution:   params.Resolution,
+		Start:        params.Start,
+		End:          params.End,
+		RolledUpTo:   rolledUpTo,
+		Availability: 1,
+		Buckets:      buckets,
+	}
+	if rolledUpTo.Before(end) {
+		end = rolledUpTo
+	}
+	if covered := end.Sub(start).Seconds(); covered > 0 {
+		firing := 0.0
+		for _, bucket := range buckets {
+			firing += bucket.OverallFiringSeconds
+		}
+		result.Availability = max(0, 1-firing/covered)
+	}
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestComputeStateRollups(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) int64 { return start.Add(d).UnixMilli() }
+	changes := []model.RuleStateHistory{
+		// firing since before the range
+		{RuleID: "1", Fingerprint: 1, State: model.StateFiring, UnixMilli: at(-time.Hour)},
+		{RuleID: "1", Fingerprint: 1, State: model.StateInactive, UnixMilli: at(30 * time.Minute)},
+		{RuleID: "1", Fingerprint: 2, State: model.StatePending, UnixMilli: at(10 * time.Minute)},
+		{RuleID: "1", Fingerprint: 2, State: model.StateFiring, UnixMilli: at(20 * time.Minute)},
+		{RuleID: "1", Fingerprint: 2, State: model.StateNoData, UnixMilli: at(90 * time.Minute)},
+		{RuleID: "2", Fingerprint: 3, State: model.StatePending, UnixMilli: at(70 * time.Minute)},
+		{RuleID: "2", Fingerprint: 3, State: model.StateInactive, UnixMilli: at(75 * time.Minute)},
+	}
+
+	rollups := computeStateRollups(changes, start, start.Add(2*time.Hour))
+	assert.Equal(t, []StateRollup{
+		{RuleId: "1", Resolution: RollupHourly, BucketStart: start,
+			OverallFiringSeconds: 3600, FiringSeconds: 1800 + 2400, PendingSeconds: 600, Transitions: 3, Firings: 1},
+		{RuleId: "1", Resolution: RollupHourly, BucketStart: start.Add(time.Hour),
+			OverallFiringSeconds: 3600, FiringSeconds: 1800, NoDataSeconds: 1800, Transitions: 1},
+		{RuleId: "2", Resolution: RollupHourly, BucketStart: start.Add(time.Hour),
+			PendingSeconds: 300, Transitions: 2},
+	}, rollups)
+}
+
+// stateChangesReader returns the state changes in the time range
+type stateChangesReader struct {
+	interfaces.Reader
+	changes []model.RuleStateHistory
+}
+
+func (r *stateChangesReader) GetRuleStateChanges(ctx context.Context, ruleIDs []string, start, end int64) ([]model.RuleStateHistory, error) {
+	changes := []model.RuleStateHistory{}
+	for _, change := range r.changes {
+		if change.UnixMilli < end {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func TestRollupStateHistory(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t)
+	_, err := m.CreateRule(ctx, `{"alert":"Error rate","condition":{"compositeQuery":{"queryType":"promql","promQueries":{"A":{"query":"errors"}}},"op":"1","matchType":"1","target":1}}`)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 5, 10, 12, 30, 0, 0, time.UTC)
+	day := time.Date(2024, 5, 9, 0, 0, 0, 0, time.UTC)
+	m.reader = &stateChangesReader{changes: []model.RuleStateHistory{
+		{RuleID: "1", Fingerprint: 1, State: model.StateFiring, UnixMilli: day.Add(6 * time.Hour).UnixMilli()},
+		{RuleID: "1", Fingerprint: 1, State: model.StateInactive, UnixMilli: day.Add(8 * time.Hour).UnixMilli()},
+	}}
+	require.NoError(t, m.rollupStateHistory(ctx, now))
+
+	rolledUpTo, err := m.ruleDB.GetStateRollupProgress(ctx, RollupHourly)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC), rolledUpTo)
+
+	hourly, err := m.ruleDB.GetStateRollups(ctx, "1", RollupHourly, day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	if assert.Len(t, hourly, 3) {
+		assert.Equal(t, day.Add(6*time.Hour), hourly[0].BucketStart)
+		assert.Equal(t, 3600.0, hourly[0].OverallFiringSeconds)
+		assert.Equal(t, 1, hourly[0].Firings)
+		assert.Equal(t, 1, hourly[2].Transitions)
+	}
+
+	result, err := m.StateRollups(ctx, "1", &QueryStateRollups{
+		Start:      day.Add(-24 * time.Hour).UnixMilli(),
+		End:        now.UnixMilli(),
+		Resolution: RollupDaily,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, day.Add(24*time.Hour), result.RolledUpTo)
+	if assert.Len(t, result.Buckets, 1) {
+		assert.Equal(t, day, result.Buckets[0].BucketStart)
+		assert.Equal(t, 7200.0, result.Buckets[0].OverallFiringSeconds)
+		assert.Equal(t, 2, result.Buckets[0].Transitions)
+	}
+	assert.InDelta(t, 1-7200.0/(48*3600), result.Availability, 1e-9)
+
+	// rolling up again only rolls up the new history
+	require.NoError(t, m.rollupStateHistory(ctx, now.Add(time.Hour)))
+	rolledUpTo, err = m.ruleDB.GetStateRollupProgress(ctx, RollupHourly)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 10, 13, 0, 0, 0, time.UTC), rolledUpTo)
+}
@@ -0,0 +1,95 @@
+package rules
+
+import (
+	"context"
+	"sort"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// StateSegments returns the state timelines of the series of the rule over the time range
+// built from the state changes of the series, the series that fired the longest first
+func (m *Manager) StateSegments(ctx context.Context, ruleID string, params *model.QueryRuleStateHistory) (*model.RuleStateSegments, error) {
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleID, RulePermissionView); err != nil {
+		return nil, err
+	}
+	changes, err := m.reader.GetRuleStateChanges(ctx, []string{ruleID}, params.Start, params.End)
+	if err != nil {
+		return nil, err
+	}
+
+	series := buildStateTimelines(changes, params.Start, params.End)
+	result := &model.RuleStateSegments{Start: params.Start, End: params.End, Total: len(series), Series: series}
+	limit := int(min(params.Limit, model.MaxStateHistoryLimit))
+	if limit > 0 && len(result.Series) > limit {
+		result.Series = result.Series[:limit]
+	}
+	return result, nil
+}
+
+// buildStateTimelines folds the state changes of every series into the consecutive
+// segments clipped to the time range, the series inactive throughout are left out
+func buildStateTimelines(changes []model.RuleStateHistory, start, end int64) []model.SeriesStateTimeline {
+	byFingerprint := make(map[uint64][]model.RuleStateHistory)
+	for _, change := range changes {
+		byFingerprint[change.Fingerprint] = append(byFingerprint[change.Fingerprint], change)
+	}
+
+	timelines := []model.SeriesStateTimeline{}
+	firing := make(map[uint64]int64)
+	for fingerprint, seriesChanges := range byFingerprint {
+		sort.SliceStable(seriesChanges, func(i, j int) bool {
+			return seriesChanges[i].UnixMilli < seriesChanges[j].UnixMilli
+		})
+
+		timeline := model.SeriesStateTimeline{Fingerprint: fingerprint}
+		state, segmentStart := model.StateInactive, start
+		for _, change := range seriesChanges {
+			if change.Labels != "" {
+				timeline.Labels = change.Labels
+			}
+			ts := max(change.UnixMilli, start)
+			if ts >= end || change.State == state {
+				continue
+			}
+			timeline.Segments = appendSegment(timeline.Segments, model.StateSegment{State: state, Start: segmentStart, End: ts})
+			state, segmentStart = change.State, ts
+		}
+		timeline.Segments = appendSegment(timeline.Segments, model.StateSegment{State: state, Start: segmentStart, End: end})
+
+		active := false
+		for _, segment := range timeline.Segments {
+			if segment.State != model.StateInactive {
+				active = true
+			}
+			if segment.State == model.StateFiring {
+				firing[fingerprint] += segment.End - segment.Start
+			}
+		}
+		if active {
+			timelines = append(timelines, timeline)
+		}
+	}
+
+	sort.Slice(timelines, func(i, j int) bool {
+		a, b := timelines[i].Fingerprint, timelines[j].Fingerprint
+		if firing[a] != firing[b] {
+			return firing[a] > firing[b]
+		}
+		return a < b
+	})
+	return timelines
+}
+
+// appendSegment appends the segment merging it into the last one in the same state,
+// the empty segments are dropped
+func appendSegment(segments []model.StateSegment, segment model.StateSegment) []model.StateSegment {
+	if segment.End <= segment.Start {
+		return segments
+	}
+	if n := len(segments); n > 0 && segments[n-1].State == segment.State {
+		segments[n-1].End = segment.End
+		return segments
+	}
+	return append(segments, segment)
+}
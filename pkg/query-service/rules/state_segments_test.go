@@ -0,0 +1,38 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestBuildStateTimelines(t *testing.T) {
+	changes := []model.RuleStateHistory{
+		// firing since before the range
+		{Fingerprint: 1, State: model.StateFiring, UnixMilli: 50, Labels: `{"service":"cart"}`},
+		{Fingerprint: 1, State: model.StateInactive, UnixMilli: 300},
+		{Fingerprint: 1, State: model.StateNoData, UnixMilli: 800},
+		{Fingerprint: 2, State: model.StatePending, UnixMilli: 200, Labels: `{"service":"frontend"}`},
+		{Fingerprint: 2, State: model.StateFiring, UnixMilli: 400},
+		{Fingerprint: 2, State: model.StateFiring, UnixMilli: 500},
+		{Fingerprint: 2, State: model.StateInactive, UnixMilli: 900},
+		// after the range
+		{Fingerprint: 3, State: model.StateFiring, UnixMilli: 1000},
+	}
+
+	timelines := buildStateTimelines(changes, 100, 1000)
+	if assert.Len(t, timelines, 2) {
+		assert.Equal(t, model.SeriesStateTimeline{Fingerprint: 2, Labels: `{"service":"frontend"}`, Segments: []model.StateSegment{
+			{State: model.StateInactive, Start: 100, End: 200},
+			{State: model.StatePending, Start: 200, End: 400},
+			{State: model.StateFiring, Start: 400, End: 900},
+			{State: model.StateInactive, Start: 900, End: 1000},
+		}}, timelines[0])
+		assert.Equal(t, []model.StateSegment{
+			{State: model.StateFiring, Start: 100, End: 300},
+			{State: model.StateInactive, Start: 300, End: 800},
+			{State: model.StateNoData, Start: 800, End: 1000},
+		}, timelines[1].Segments)
+	}
+}
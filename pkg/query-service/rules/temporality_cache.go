@@ -0,0 +1,98 @@
+package rules
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// DefaultTemporalityCacheTTL is how long the temporality of the metrics is
+// reused before it's fetched again
+const DefaultTemporalityCacheTTL = 30 * time.Minute
+
+type temporalityCacheKey struct{}
+
+// temporalityCache shares the temporality of the metrics among the rules so
+// every metric is looked up once per ttl instead of once per rule
+type temporalityCache struct {
+	ttl time.Duration
+
+	mtx     sync.RWMutex
+	entries map[string]temporalityEntry
+}
+
+type temporalityEntry struct {
+	temporality map[v3.Temporality]bool
+	expiresAt   time.Time
+}
+
+func newTemporalityCache(ttl time.Duration) *temporalityCache {
+	if ttl <= 0 {
+		ttl = DefaultTemporalityCacheTTL
+	}
+	return &temporalityCache{ttl: ttl, entries: map[string]temporalityEntry{}}
+}
+
+// withTemporalityCache returns the context sharing the temporality among the rules evaluated with it
+func withTemporalityCache(ctx context.Context, cache *temporalityCache) context.Context {
+	if cache == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, temporalityCacheKey{}, cache)
+}
+
+// temporalityCacheFromContext returns the cache of the temporality, nil when
+// the rule keeps its own temporality
+func temporalityCacheFromContext(ctx context.Context) *temporalityCache {
+	cache, _ := ctx.Value(temporalityCacheKey{}).(*temporalityCache)
+	return cache
+}
+
+// metricNames returns the metrics of the builder queries without a temporality
+func metricNames(qp *v3.QueryRangeParamsV3) []string {
+	if qp.CompositeQuery == nil {
+		return nil
+	}
+	names := make([]string, 0, len(qp.CompositeQuery.BuilderQueries))
+	for _, query := range qp.CompositeQuery.BuilderQueries {
+		if query.DataSource == v3.DataSourceMetrics && query.Temporality == "" {
+			names = append(names, query.AggregateAttribute.Key)
+		}
+	}
+	return names
+}
+
+// get returns the temporality of the metrics looked up within the ttl, the
+// metrics without a known temporality have an empty entry
+func (c *temporalityCache) get(names []string, now time.Time) map[string]map[v3.Temporality]bool {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	found := make(map[string]map[v3.Temporality]bool, len(names))
+	for _, name := range names {
+		if entry, ok := c.entries[name]; ok && now.Before(entry.expiresAt) {
+			found[name] = entry.temporality
+		}
+	}
+	return found
+}
+
+// set stores the temporality fetched for the metrics, the metrics missing from
+// the fetched temporality are stored too so they are not fetched again until expired
+func (c *temporalityCache) set(names []string, fetched map[string]map[v3.Temporality]bool, now time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	for _, name := range names {
+		temporality := fetched[name]
+		if temporality == nil {
+			temporality = map[v3.Temporality]bool{}
+		}
+		c.entries[name] = temporalityEntry{temporality: temporality, expiresAt: now.Add(c.ttl)}
+	}
+}
@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/interfaces"
+	v3 "go.signoz.io/signoz/pkg/query-service/model/v3"
+)
+
+// temporalityReader counts the temporality lookups
+type temporalityReader struct {
+	interfaces.Reader
+	fetched [][]string
+}
+
+func (r *temporalityReader) FetchTemporality(ctx context.Context, metricNames []string) (map[string]map[v3.Temporality]bool, error) {
+	r.fetched = append(r.fetched, metricNames)
+	return map[string]map[v3.Temporality]bool{
+		"signoz_calls_total": {v3.Delta: true, v3.Cumulative: true},
+	}, nil
+}
+
+func temporalityParams() *v3.QueryRangeParamsV3 {
+	return &v3.QueryRangeParamsV3{
+		CompositeQuery: &v3.CompositeQuery{
+			BuilderQueries: map[string]*v3.BuilderQuery{
+				"A": {QueryName: "A", DataSource: v3.DataSourceMetrics, AggregateAttribute: v3.AttributeKey{Key: "signoz_calls_total"}},
+				"B": {QueryName: "B", DataSource: v3.DataSourceMetrics, AggregateAttribute: v3.AttributeKey{Key: "unknown_metric"}},
+			},
+		},
+	}
+}
+
+func TestPopulateTemporalityCache(t *testing.T) {
+	reader := &temporalityReader{}
+	cache := newTemporalityCache(time.Hour)
+	ctx := withTemporalityCache(context.Background(), cache)
+
+	first := &BaseRule{reader: reader, TemporalityMap: map[string]map[v3.Temporality]bool{}}
+	second := &BaseRule{reader: reader, TemporalityMap: map[string]map[v3.Temporality]bool{}}
+
+	params := temporalityParams()
+	assert.NoError(t, first.PopulateTemporality(ctx, params))
+	assert.Equal(t, v3.Delta, params.CompositeQuery.BuilderQueries["A"].Temporality)
+	assert.Equal(t, v3.Unspecified, params.CompositeQuery.BuilderQueries["B"].Temporality)
+	// the missing metrics are fetched at once
+	if assert.Len(t, reader.fetched, 1) {
+		assert.ElementsMatch(t, []string{"signoz_calls_total", "unknown_metric"}, reader.fetched[0])
+	}
+
+	// the other rules reuse the temporality, the unknown metrics included
+	params = temporalityParams()
+	assert.NoError(t, second.PopulateTemporality(ctx, params))
+	assert.Equal(t, v3.Delta, params.CompositeQuery.BuilderQueries["A"].Temporality)
+	assert.Equal(t, v3.Unspecified, params.CompositeQuery.BuilderQueries["B"].Temporality)
+	assert.Len(t, reader.fetched, 1)
+
+	// and fetch it again once expired
+	assert.Empty(t, cache.get([]string{"signoz_calls_total"}, time.Now().Add(2*time.Hour)))
+}
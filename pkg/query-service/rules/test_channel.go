@@ -0,0 +1,152 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// channelTestResponseLength bounds the response body of the provider returned by the test
+const channelTestResponseLength = 4096
+
+// ChannelTestResponse is the response of the provider to a request of the test notification
+type ChannelTestResponse struct {
+	ChannelType string `json:"channelType"`
+	// URL is the endpoint the request was sent to without its query
+	URL        string `json:"url,omitempty"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	Body       string `json:"body,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ChannelTestResult is the outcome of the test notification sent to the channel, the
+// channels routed by the alertmanager only report whether the alertmanager sent it
+type ChannelTestResult struct {
+	Channel   string                `json:"channel"`
+	Success   bool                  `json:"success"`
+	Responses []ChannelTestResponse `json:"responses"`
+}
+
+// channelTest collects the responses of the test notification
+type channelTest struct {
+	mtx       sync.Mutex
+	responses []ChannelTestResponse
+}
+
+// send sends the request of the test once and keeps the response of the provider
+func (t *channelTest) send(ctx context.Context, d *channelDelivery, r *deliveryRequest) error {
+	var (
+		statusCode int
+		err        error
+	)
+	response := ChannelTestResponse{ChannelType: r.ChannelType}
+	if r.Smtp != nil {
+		statusCode, _, err = r.Smtp.sendMail(ctx, r.Body)
+		response.URL = "smtp://" + r.Smtp.Smarthost
+	} else {
+		statusCode, err = d.postOnce(ctx, r)
+		if u, parseErr := url.Parse(r.URL); parseErr == nil {
+			u.RawQuery, u.User = "", nil
+			response.URL = u.String()
+		}
+	}
+	response.StatusCode = statusCode
+	body := string(r.response)
+	if len(body) > channelTestResponseLength {
+		body = body[:channelTestResponseLength]
+	}
+	response.Body = body
+	if err != nil {
+		response.Error = err.Error()
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.responses = append(t.responses, response)
+	return err
+}
+
+func (t *channelTest) count() int {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	return len(t.responses)
+}
+
+// testChannelAlert is the synthetic critical alert sent by the test notification
+func testChannelAlert(channel string, now time.Time) *Alert {
+	return &Alert{
+		State: model.StateFiring,
+		Labels: labels.FromMap(map[string]string{
+			labels.AlertNameLabel:   "Test notification" + TestAlertPostFix,
+			labels.AlertRuleIdLabel: "test",
+			"severity":              "critical",
+		}),
+		Annotations: labels.FromMap(map[string]string{
+			labels.AlertSummaryLabel:     fmt.Sprintf("Test notification of the channel %s", channel),
+			labels.AlertDescriptionLabel: "This is a test notification sent by SigNoz to check the channel is set up, no action is needed.",
+		}),
+		ActiveAt:  now,
+		FiredAt:   now,
+		Receivers: []string{channel},
+	}
+}
+
+// TestChannel sends a test alert through the rendering and delivery of the stored
+// channel and returns the responses of the providers. The configs delivered by the
+// query service are sent once without retries and not recorded in the delivery history.
+func (m *Manager) TestChannel(ctx context.Context, id string) (*ChannelTestResult, error) {
+	channel, apiErr := m.ruleDB.GetChannel(id)
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	template, err := m.ruleDB.GetChannelTemplate(ctx, int64(channel.Id))
+	if err != nil {
+		template = nil
+	}
+	receiver, err := channelReceiver(channel, template)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &channelDelivery{
+		ruleDB:  m.ruleDB,
+		client:  &http.Client{Timeout: deliveryTimeout},
+		digests: map[string]*emailDigest{},
+		test:    &channelTest{},
+	}
+	delivered := &deliveredChannel{name: channel.Name, receiver: receiver, template: template}
+	alert := testChannelAlert(channel.Name, time.Now())
+	for _, sender := range channelSenders {
+		if !sender.configured(receiver) {
+			continue
+		}
+		before := d.test.count()
+		err := sender.send(ctx, d, delivered, []*Alert{alert})
+		// the errors of the requests are in their responses already
+		if err != nil && d.test.count() == before {
+			d.test.responses = append(d.test.responses, ChannelTestResponse{ChannelType: channel.Type, Error: err.Error()})
+		}
+	}
+
+	if receiver.Routed() && m.alertManager != nil {
+		response := ChannelTestResponse{ChannelType: "alertmanager"}
+		if apiErr := m.alertManager.TestReceiver(receiver); apiErr != nil {
+			response.Error = apiErr.Err.Error()
+		}
+		d.test.responses = append(d.test.responses, response)
+	}
+
+	result := &ChannelTestResult{Channel: channel.Name, Success: len(d.test.responses) > 0, Responses: d.test.responses}
+	for _, response := range result.Responses {
+		if response.Error != "" {
+			result.Success = false
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+)
+
+func TestTestChannel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/invalid" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"invalid token"}`))
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"status":"success","dedup_key":"abc"}`))
+	}))
+	defer server.Close()
+
+	m := newTestManager(t)
+	db := m.ruleDB.(*ruleDB)
+	ctx := context.Background()
+	pagerduty, _ := json.Marshal(am.Receiver{Name: "pagerduty", PagerdutyV2Configs: []interface{}{map[string]interface{}{"routing_key": "key", "url": server.URL}}})
+	webhook, _ := json.Marshal(am.Receiver{Name: "webhook", WebhookConfigs: []interface{}{map[string]interface{}{"url": server.URL + "/invalid?token=secret", "secret": "s3cr3t"}}})
+	_, err := db.Exec(`INSERT INTO notification_channels (created_at, updated_at, name, type, data) VALUES($1,$1,'pagerduty','pagerduty_v2',$2),($1,$1,'webhook','webhook',$3);`, time.Now(), string(pagerduty), string(webhook))
+	assert.NoError(t, err)
+
+	result, err := m.TestChannel(ctx, "1")
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	if assert.Len(t, result.Responses, 1) {
+		assert.Equal(t, "pagerduty_v2", result.Responses[0].ChannelType)
+		assert.Equal(t, http.StatusAccepted, result.Responses[0].StatusCode)
+		assert.Contains(t, result.Responses[0].Body, `"status":"success"`)
+	}
+
+	// the failed test is returned and not retried from the queue
+	result, err = m.TestChannel(ctx, "2")
+	assert.NoError(t, err)
+	assert.False(t, result.Success)
+	if assert.Len(t, result.Responses, 1) {
+		assert.Equal(t, http.StatusBadRequest, result.Responses[0].StatusCode)
+		assert.Equal(t, server.URL+"/invalid", result.Responses[0].URL)
+		assert.Contains(t, result.Responses[0].Error, "invalid token")
+	}
+	queued, err := db.GetQueuedDeliveries(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, queued)
+	entries, err := db.GetNotificationLog(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	_, err = m.TestChannel(ctx, "3")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -59,6 +59,50 @@ func defaultTestNotification(opts PrepareTestRuleOptions) (int, *model.ApiError)
 			return 0, model.BadRequest(err)
 		}
 
+	} else if parsedRule.RuleType == RuleTypeSLO {
+
+		parsedRule.Labels[labels.RuleSourceLabel] = ""
+		parsedRule.Labels[labels.AlertRuleIdLabel] = ""
+
+		// create a slo rule
+		rule, err = NewSLORule(
+			alertname,
+			parsedRule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			WithSendAlways(),
+			WithSendUnmatched(),
+		)
+
+		if err != nil {
+			zap.L().Error("failed to prepare a new slo rule for test", zap.String("name", alertname), zap.Error(err))
+			return 0, model.BadRequest(err)
+		}
+
+	} else if parsedRule.RuleType == RuleTypeHeartbeat {
+
+		parsedRule.Labels[labels.RuleSourceLabel] = ""
+		parsedRule.Labels[labels.AlertRuleIdLabel] = ""
+
+		// create a heartbeat rule
+		rule, err = NewHeartbeatRule(
+			alertname,
+			parsedRule,
+			opts.FF,
+			opts.Reader,
+			opts.UseLogsNewSchema,
+			opts.UseTraceNewSchema,
+			WithSendAlways(),
+			WithSendUnmatched(),
+		)
+
+		if err != nil {
+			zap.L().Error("failed to prepare a new heartbeat rule for test", zap.String("name", alertname), zap.Error(err))
+			return 0, model.BadRequest(err)
+		}
+
 	} else if parsedRule.RuleType == RuleTypeProm {
 
 		// create promql rule
@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
-	"text/template"
 	"time"
 
 	"go.uber.org/zap"
@@ -53,6 +52,9 @@ type ThresholdRule struct {
 	spansKeys map[string]v3.AttributeKey
 
 	useTraceNewSchema bool
+
+	// delta keeps the points of the eval window in the delta evaluation mode
+	delta *deltaWindow
 }
 
 func NewThresholdRule(
@@ -99,6 +101,9 @@ func NewThresholdRule(
 	t.querier = querier.NewQuerier(querierOption)
 	t.querierV2 = querierV2.NewQuerier(querierOptsV2)
 	t.reader = reader
+	if p.RuleCondition.DeltaEval {
+		t.delta = newDeltaWindow()
+	}
 	return &t, nil
 }
 
@@ -112,78 +117,72 @@ func (r *ThresholdRule) prepareQueryRange(ts time.Time) (*v3.QueryRangeParamsV3,
 
 	startTs, endTs := r.Timestamps(ts)
 	start, end := startTs.UnixMilli(), endTs.UnixMilli()
+	step := int64(math.Max(float64(common.MinAllowedStepInterval(start, end)), 60))
+
+	query := r.queryTemplate()
+	if query.err != nil {
+		return nil, query.err
+	}
 
 	if r.ruleCondition.QueryType() == v3.QueryTypeClickHouseSQL {
 		params := &v3.QueryRangeParamsV3{
 			Start: start,
 			End:   end,
-			Step:  int64(math.Max(float64(common.MinAllowedStepInterval(start, end)), 60)),
+			Step:  step,
 			CompositeQuery: &v3.CompositeQuery{
 				QueryType:         r.ruleCondition.CompositeQuery.QueryType,
 				PanelType:         r.ruleCondition.CompositeQuery.PanelType,
 				BuilderQueries:    make(map[string]*v3.BuilderQuery),
-				ClickHouseQueries: make(map[string]*v3.ClickHouseQuery),
+				ClickHouseQueries: make(map[string]*v3.ClickHouseQuery, len(query.clickhouseQueries)),
 				PromQueries:       make(map[string]*v3.PromQuery),
 				Unit:              r.ruleCondition.CompositeQuery.Unit,
 			},
 			Variables: make(map[string]interface{}, 0),
 			NoCache:   true,
 		}
+		for name, value := range r.TemplateVariables() {
+			params.Variables[name] = value
+		}
 		querytemplate.AssignReservedVarsV3(params)
-		for name, chQuery := range r.ruleCondition.CompositeQuery.ClickHouseQueries {
-			if chQuery.Disabled {
-				continue
-			}
-			tmpl := template.New("clickhouse-query")
-			tmpl, err := tmpl.Parse(chQuery.Query)
-			if err != nil {
-				return nil, err
-			}
-			var query bytes.Buffer
-			err = tmpl.Execute(&query, params.Variables)
-			if err != nil {
+		for name, tmpl := range query.clickhouseQueries {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, params.Variables); err != nil {
 				return nil, err
 			}
 			params.CompositeQuery.ClickHouseQueries[name] = &v3.ClickHouseQuery{
-				Query:    query.String(),
-				Disabled: chQuery.Disabled,
-				Legend:   chQuery.Legend,
+				Query:  buf.String(),
+				Legend: r.ruleCondition.CompositeQuery.ClickHouseQueries[name].Legend,
 			}
 		}
 		return params, nil
 	}
 
-	if r.ruleCondition.CompositeQuery != nil && r.ruleCondition.CompositeQuery.BuilderQueries != nil {
-		for _, q := range r.ruleCondition.CompositeQuery.BuilderQueries {
-			// If the step interval is less than the minimum allowed step interval, set it to the minimum allowed step interval
-			if minStep := common.MinAllowedStepInterval(start, end); q.StepInterval < minStep {
-				q.StepInterval = minStep
-			}
-
-			q.SetShiftByFromFunc()
+	// the evaluation changes its own copy of the prepared query
+	compositeQuery := query.compositeQuery.Clone()
+	minStep := common.MinAllowedStepInterval(start, end)
+	for _, q := range compositeQuery.BuilderQueries {
+		// If the step interval is less than the minimum allowed step interval, set it to the minimum allowed step interval
+		if q.StepInterval < minStep {
+			q.StepInterval = minStep
+		}
 
-			if q.DataSource == v3.DataSourceMetrics && constants.UseMetricsPreAggregation() {
-				// if the time range is greater than 1 day, and less than 1 week set the step interval to be multiple of 5 minutes
-				// if the time range is greater than 1 week, set the step interval to be multiple of 30 mins
-				if end-start >= 24*time.Hour.Milliseconds() && end-start < 7*24*time.Hour.Milliseconds() {
-					q.StepInterval = int64(math.Round(float64(q.StepInterval)/300)) * 300
-				} else if end-start >= 7*24*time.Hour.Milliseconds() {
-					q.StepInterval = int64(math.Round(float64(q.StepInterval)/1800)) * 1800
-				}
+		if q.DataSource == v3.DataSourceMetrics && constants.UseMetricsPreAggregation() {
+			// if the time range is greater than 1 day, and less than 1 week set the step interval to be multiple of 5 minutes
+			// if the time range is greater than 1 week, set the step interval to be multiple of 30 mins
+			if end-start >= 24*time.Hour.Milliseconds() && end-start < 7*24*time.Hour.Milliseconds() {
+				q.StepInterval = int64(math.Round(float64(q.StepInterval)/300)) * 300
+			} else if end-start >= 7*24*time.Hour.Milliseconds() {
+				q.StepInterval = int64(math.Round(float64(q.StepInterval)/1800)) * 1800
 			}
 		}
 	}
 
-	if r.ruleCondition.CompositeQuery.PanelType != v3.PanelTypeGraph {
-		r.ruleCondition.CompositeQuery.PanelType = v3.PanelTypeGraph
-	}
-
 	// default mode
 	return &v3.QueryRangeParamsV3{
 		Start:          start,
 		End:            end,
-		Step:           int64(math.Max(float64(common.MinAllowedStepInterval(start, end)), 60)),
-		CompositeQuery: r.ruleCondition.CompositeQuery,
+		Step:           step,
+		CompositeQuery: compositeQuery,
 		Variables:      make(map[string]interface{}, 0),
 		NoCache:        true,
 	}, nil
@@ -192,12 +191,7 @@ func (r *ThresholdRule) prepareQueryRange(ts time.Time) (*v3.QueryRangeParamsV3,
 func (r *ThresholdRule) prepareLinksToLogs(ts time.Time, lbls labels.Labels) string {
 	selectedQuery := r.GetSelectedQuery()
 
-	qr, err := r.prepareQueryRange(ts)
-	if err != nil {
-		return ""
-	}
-	start := time.UnixMilli(qr.Start)
-	end := time.UnixMilli(qr.End)
+	start, end := r.Timestamps(ts)
 
 	// TODO(srikanthccv): handle formula queries
 	if selectedQuery < "A" || selectedQuery > "Z" {
@@ -226,12 +220,7 @@ func (r *ThresholdRule) prepareLinksToLogs(ts time.Time, lbls labels.Labels) str
 func (r *ThresholdRule) prepareLinksToTraces(ts time.Time, lbls labels.Labels) string {
 	selectedQuery := r.GetSelectedQuery()
 
-	qr, err := r.prepareQueryRange(ts)
-	if err != nil {
-		return ""
-	}
-	start := time.UnixMilli(qr.Start)
-	end := time.UnixMilli(qr.End)
+	start, end := r.Timestamps(ts)
 
 	// TODO(srikanthccv): handle formula queries
 	if selectedQuery < "A" || selectedQuery > "Z" {
@@ -267,7 +256,63 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 	if err != nil {
 		return nil, err
 	}
-	err = r.PopulateTemporality(ctx, params)
+
+	var queryResult *v3.Result
+	if r.delta != nil {
+		queryResult, err = r.runDeltaQuery(ctx, params)
+	} else {
+		queryResult, err = r.runQuery(ctx, params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if queryResult != nil && len(queryResult.Series) > 0 {
+		r.lastTimestampWithDatapoints = time.Now()
+	}
+
+	var resultVector Vector
+
+	trace := EvalTraceFromContext(ctx)
+
+	// if the data is missing for `For` duration then we should send alert
+	if r.ruleCondition.AlertOnAbsent && r.lastTimestampWithDatapoints.Add(time.Duration(r.Condition().AbsentFor)*time.Minute).Before(time.Now()) {
+		zap.L().Info("no data found for rule condition", zap.String("ruleid", r.ID()))
+		trace.AddNote(fmt.Sprintf("no data for %d minutes, alerting on the absent data", r.Condition().AbsentFor))
+		lbls := labels.NewBuilder(labels.Labels{})
+		if !r.lastTimestampWithDatapoints.IsZero() {
+			lbls.Set("lastSeen", r.lastTimestampWithDatapoints.Format(constants.AlertTimeFormat))
+		}
+		resultVector = append(resultVector, Sample{
+			Metric:    lbls.Labels(),
+			IsMissing: true,
+		})
+		return resultVector, nil
+	}
+
+	for _, series := range queryResult.Series {
+		smpl, shouldAlert := r.ShouldAlert(*series)
+		trace.AddDecision(series, smpl, shouldAlert, r.targetVal())
+		if shouldAlert {
+			smpl.Series = make([]Point, 0, len(series.Points))
+			for _, p := range series.Points {
+				smpl.Series = append(smpl.Series, Point{T: p.Timestamp, V: p.Value})
+			}
+			resultVector = append(resultVector, smpl)
+		}
+	}
+
+	if !r.HasEnoughBreachingSeries(len(resultVector)) {
+		zap.L().Info("not enough breaching series to alert", zap.String("ruleid", r.ID()), zap.Int("count", len(resultVector)))
+		trace.AddNote(fmt.Sprintf("%d breaching series are not enough to alert", len(resultVector)))
+		return nil, nil
+	}
+	return resultVector, nil
+}
+
+// runQuery runs the rule queries and returns the result of the selected query
+func (r *ThresholdRule) runQuery(ctx context.Context, params *v3.QueryRangeParamsV3) (*v3.Result, error) {
+	err := r.PopulateTemporality(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("internal error while setting temporality")
 	}
@@ -287,7 +332,13 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 		if hasLogsQuery {
 			// check if any enrichment is required for logs if yes then enrich them
 			if logsv3.EnrichmentRequired(params) {
-				logsFields, err := r.reader.GetLogFields(ctx)
+				logsFields, err := queryWithContext(ctx, func() (*model.GetFieldsResponse, error) {
+					fields, apiErr := r.reader.GetLogFields(ctx)
+					if apiErr != nil {
+						return nil, apiErr
+					}
+					return fields, nil
+				})
 				if err != nil {
 					return nil, err
 				}
@@ -298,7 +349,9 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 		}
 
 		if hasTracesQuery {
-			spanKeys, err := r.reader.GetSpanAttributeKeys(ctx)
+			spanKeys, err := queryWithContext(ctx, func() (map[string]v3.AttributeKey, error) {
+				return r.reader.GetSpanAttributeKeys(ctx)
+			})
 			if err != nil {
 				return nil, err
 			}
@@ -311,18 +364,57 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 		}
 	}
 
-	var results []*v3.Result
-	var queryErrors map[string]error
+	selectedQuery := r.GetSelectedQuery()
+	cache := queryResultCacheFromContext(ctx)
+	var key string
+	if cache != nil {
+		key, err = queryResultKey(r.version, selectedQuery, params)
+		if err != nil {
+			zap.L().Warn("failed to hash the rule query, the result is not shared", zap.String("rule", r.Name()), zap.Error(err))
+		}
+	}
 
-	if r.version == "v4" {
-		results, queryErrors, err = r.querierV2.QueryRange(ctx, params)
-	} else {
-		results, queryErrors, err = r.querier.QueryRange(ctx, params)
+	trace := EvalTraceFromContext(ctx)
+	trace.SetParams(params)
+
+	// the rules running the same query in the same minute share its result
+	result, err := cache.get(ctx, key, func() (*v3.Result, error) {
+		return r.queryRange(ctx, params, selectedQuery)
+	})
+	if err == nil && result != nil {
+		trace.AddSeries(selectedQuery, result.Series)
+	}
+	if err == nil {
+		// the result is shared with the rules running the same query, each rule
+		// checks it against its own limits
+		err = r.CheckResultSize(result)
 	}
+	return result, err
+}
+
+// queryRange queries the datastore and returns the post processed result of the selected query
+func (r *ThresholdRule) queryRange(ctx context.Context, params *v3.QueryRangeParamsV3, selectedQuery string) (*v3.Result, error) {
+	type rangeResult struct {
+		results     []*v3.Result
+		queryErrors map[string]error
+	}
+	queryStart := time.Now()
+	queried, err := queryWithContext(ctx, func() (rangeResult, error) {
+		var res rangeResult
+		var err error
+		if r.version == "v4" {
+			res.results, res.queryErrors, err = r.querierV2.QueryRange(ctx, params)
+		} else {
+			res.results, res.queryErrors, err = r.querier.QueryRange(ctx, params)
+		}
+		return res, err
+	})
+	results, queryErrors := queried.results, queried.queryErrors
+	observeQuery(r.ID(), time.Since(queryStart))
 
 	if err != nil {
 		zap.L().Error("failed to get alert query result", zap.String("rule", r.Name()), zap.Error(err), zap.Any("errors", queryErrors))
-		return nil, fmt.Errorf("internal error while querying")
+		return nil, errQueryFailed
 	}
 
 	if params.CompositeQuery.QueryType == v3.QueryTypeBuilder {
@@ -333,8 +425,6 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 		}
 	}
 
-	selectedQuery := r.GetSelectedQuery()
-
 	var queryResult *v3.Result
 	for _, res := range results {
 		if res.QueryName == selectedQuery {
@@ -343,45 +433,28 @@ func (r *ThresholdRule) buildAndRunQuery(ctx context.Context, ts time.Time) (Vec
 		}
 	}
 
-	if queryResult != nil && len(queryResult.Series) > 0 {
-		r.lastTimestampWithDatapoints = time.Now()
-	}
+	return queryResult, nil
+}
 
-	var resultVector Vector
+func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
 
-	// if the data is missing for `For` duration then we should send alert
-	if r.ruleCondition.AlertOnAbsent && r.lastTimestampWithDatapoints.Add(time.Duration(r.Condition().AbsentFor)*time.Minute).Before(time.Now()) {
-		zap.L().Info("no data found for rule condition", zap.String("ruleid", r.ID()))
-		lbls := labels.NewBuilder(labels.Labels{})
-		if !r.lastTimestampWithDatapoints.IsZero() {
-			lbls.Set("lastSeen", r.lastTimestampWithDatapoints.Format(constants.AlertTimeFormat))
-		}
-		resultVector = append(resultVector, Sample{
-			Metric:    lbls.Labels(),
-			IsMissing: true,
-		})
-		return resultVector, nil
-	}
+	res, err := r.buildAndRunQuery(ctx, ts)
 
-	for _, series := range queryResult.Series {
-		smpl, shouldAlert := r.ShouldAlert(*series)
-		if shouldAlert {
-			resultVector = append(resultVector, smpl)
-		}
+	if err != nil {
+		return nil, err
 	}
-	return resultVector, nil
+
+	return r.evalVector(ctx, ts, res, r.targetVal())
 }
 
-func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, error) {
+// evalVector updates the active alerts from the samples matching the rule condition
+func (r *ThresholdRule) evalVector(ctx context.Context, ts time.Time, res Vector, target float64) (interface{}, error) {
 
 	prevState := r.State()
 
 	valueFormatter := formatter.FromUnit(r.Unit())
-	res, err := r.buildAndRunQuery(ctx, ts)
 
-	if err != nil {
-		return nil, err
-	}
+	var err error
 
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
@@ -396,7 +469,7 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 		}
 
 		value := valueFormatter.Format(smpl.V, r.Unit())
-		threshold := valueFormatter.Format(r.targetVal(), r.Unit())
+		threshold := valueFormatter.Format(target, r.Unit())
 		zap.L().Debug("Alert template data for rule", zap.String("name", r.Name()), zap.String("formatter", valueFormatter.Name()), zap.String("value", value), zap.String("threshold", threshold))
 
 		tmplData := AlertTemplateData(l, value, threshold)
@@ -479,6 +552,7 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 			GeneratorURL:      r.GeneratorURL(),
 			Receivers:         r.preferredChannels,
 			Missing:           smpl.IsMissing,
+			Series:            newAlertSeries(smpl.Series, r.targetVal()),
 		}
 	}
 
@@ -493,6 +567,7 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 			alert.Value = a.Value
 			alert.Annotations = a.Annotations
 			alert.Receivers = r.preferredChannels
+			alert.Series = a.Series
 			continue
 		}
 
@@ -510,7 +585,7 @@ func (r *ThresholdRule) Eval(ctx context.Context, ts time.Time) (interface{}, er
 		if _, ok := resultFPs[fp]; !ok {
 			// If the alert was previously firing, keep it around for a given
 			// retention time so it is reported as resolved to the AlertManager.
-			if a.State == model.StatePending || (!a.ResolvedAt.IsZero() && ts.Sub(a.ResolvedAt) > ResolvedRetention) {
+			if a.State == model.StatePending || a.ResolvedExpired(ts) {
 				delete(r.Active, fp)
 			}
 			if a.State != model.StateInactive {
@@ -1066,6 +1066,161 @@ func TestThresholdRuleEvalDelay(t *testing.T) {
 	}
 }
 
+func TestThresholdRulePrepareQueryRangeKeepsCondition(t *testing.T) {
+	postableRule := PostableRule{
+		AlertName:  "Test Prepared Query",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				PanelType: v3.PanelTypeValue,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:         "A",
+						DataSource:        v3.DataSourceMetrics,
+						AggregateOperator: v3.AggregateOperatorSumRate,
+						AggregateAttribute: v3.AttributeKey{
+							Key: "signoz_calls_total",
+						},
+						Expression: "A",
+					},
+				},
+			},
+			CompareOp: "1", // Above
+			MatchType: "1", // Once
+			Target:    &[]float64{1.0}[0],
+		},
+	}
+
+	fm := featureManager.StartManager()
+	rule, err := NewThresholdRule("69", &postableRule, fm, nil, true, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	params, err := rule.prepareQueryRange(time.Unix(1717205987, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, v3.PanelTypeGraph, params.CompositeQuery.PanelType)
+	assert.Greater(t, params.CompositeQuery.BuilderQueries["A"].StepInterval, int64(0))
+
+	// the evaluations change their own copy of the query
+	params.CompositeQuery.BuilderQueries["A"].Temporality = v3.Cumulative
+	assert.Equal(t, v3.PanelTypeValue, postableRule.RuleCondition.CompositeQuery.PanelType)
+	assert.Zero(t, postableRule.RuleCondition.CompositeQuery.BuilderQueries["A"].StepInterval)
+	assert.Empty(t, postableRule.RuleCondition.CompositeQuery.BuilderQueries["A"].Temporality)
+
+	next, err := rule.prepareQueryRange(time.Unix(1717205987, 0))
+	assert.NoError(t, err)
+	assert.Empty(t, next.CompositeQuery.BuilderQueries["A"].Temporality)
+}
+
+func TestThresholdRulePerRuleEvalDelay(t *testing.T) {
+	postableRule := PostableRule{
+		AlertName:  "Test Per Rule Eval Delay",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		EvalDelay:  Duration(10 * time.Minute),
+		RuleCondition: &RuleCondition{
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeClickHouseSQL,
+				ClickHouseQueries: map[string]*v3.ClickHouseQuery{
+					"A": {
+						Query: "SELECT 1 >= {{.start_timestamp_ms}} AND 1 <= {{.end_timestamp_ms}}",
+					},
+				},
+			},
+		},
+	}
+
+	// 01:39:47
+	ts := time.Unix(1717205987, 0)
+
+	fm := featureManager.StartManager()
+	// the rule level delay overrides the manager level delay
+	rule, err := NewThresholdRule("69", &postableRule, fm, nil, true, true, WithEvalDelay(2*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, rule.EvalDelay())
+
+	params, err := rule.prepareQueryRange(ts)
+	assert.NoError(t, err)
+	// 01:24:00 - 01:29:00
+	assert.Equal(t, "SELECT 1 >= 1717205040000 AND 1 <= 1717205340000", params.CompositeQuery.ClickHouseQueries["A"].Query)
+}
+
+func TestThresholdRuleFilterVariables(t *testing.T) {
+	postableRule := PostableRule{
+		AlertName:  "Test Filter Variables",
+		AlertType:  AlertTypeMetric,
+		RuleType:   RuleTypeThreshold,
+		EvalWindow: Duration(5 * time.Minute),
+		Frequency:  Duration(1 * time.Minute),
+		Labels:     map[string]string{"env": "production"},
+		RuleCondition: &RuleCondition{
+			CompareOp: ValueIsAbove,
+			MatchType: AtleastOnce,
+			Target:    &[]float64{1}[0],
+			CompositeQuery: &v3.CompositeQuery{
+				QueryType: v3.QueryTypeBuilder,
+				BuilderQueries: map[string]*v3.BuilderQuery{
+					"A": {
+						QueryName:    "A",
+						StepInterval: 60,
+						AggregateAttribute: v3.AttributeKey{
+							Key: "signoz_calls_total",
+						},
+						AggregateOperator: v3.AggregateOperatorSumRate,
+						DataSource:        v3.DataSourceMetrics,
+						Expression:        "A",
+						Filters: &v3.FilterSet{
+							Operator: "AND",
+							Items: []v3.FilterItem{
+								{
+									Key:      v3.AttributeKey{Key: "deployment.environment"},
+									Operator: v3.FilterOperatorEqual,
+									Value:    "{{.env}}",
+								},
+								{
+									Key:      v3.AttributeKey{Key: "service.name"},
+									Operator: v3.FilterOperatorIn,
+									Value:    []interface{}{"{{.team}}-api", "frontend"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	fm := featureManager.StartManager()
+	// the rule labels take precedence over the org level variables
+	rule, err := NewThresholdRule("69", &postableRule, fm, nil, true, true, WithVariables(map[string]string{"env": "staging", "team": "payments"}))
+	assert.NoError(t, err)
+
+	params, err := rule.prepareQueryRange(time.Unix(1717205987, 0))
+	assert.NoError(t, err)
+
+	items := params.CompositeQuery.BuilderQueries["A"].Filters.Items
+	assert.Equal(t, "production", items[0].Value)
+	assert.Equal(t, []interface{}{"payments-api", "frontend"}, items[1].Value)
+
+	// the rule condition keeps the templates for the next evaluation
+	ruleItems := postableRule.RuleCondition.CompositeQuery.BuilderQueries["A"].Filters.Items
+	assert.Equal(t, "{{.env}}", ruleItems[0].Value)
+	assert.Equal(t, []interface{}{"{{.team}}-api", "frontend"}, ruleItems[1].Value)
+
+	// referencing an unknown variable fails the evaluation
+	rule, err = NewThresholdRule("69", &postableRule, fm, nil, true, true)
+	assert.NoError(t, err)
+	_, err = rule.prepareQueryRange(time.Unix(1717205987, 0))
+	assert.Error(t, err)
+}
+
 func TestThresholdRuleClickHouseTmpl(t *testing.T) {
 	postableRule := PostableRule{
 		AlertName:  "Tricky Condition Tests",
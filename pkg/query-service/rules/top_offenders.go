@@ -0,0 +1,133 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+// OffenderSort is the measure the offending series are sorted by
+type OffenderSort string
+
+const (
+	OffenderSortFirings        OffenderSort = "firings"
+	OffenderSortFiringDuration OffenderSort = "firing_duration"
+)
+
+// Validate checks the sort is known, the empty sort sorts by firings
+func (s OffenderSort) Validate() error {
+	switch s {
+	case "", OffenderSortFirings, OffenderSortFiringDuration:
+		return nil
+	}
+	return fmt.Errorf("invalid sort %s, supported sorts: %s, %s", s, OffenderSortFirings, OffenderSortFiringDuration)
+}
+
+// QueryTopOffenders selects the series of the rule firing the most in the time range
+// in milliseconds
+type QueryTopOffenders struct {
+	Start  int64        `json:"start"`
+	End    int64        `json:"end"`
+	SortBy OffenderSort `json:"sortBy"`
+	Limit  int          `json:"limit"`
+}
+
+func (q *QueryTopOffenders) Validate() error {
+	if q.Start == 0 || q.End == 0 {
+		return fmt.Errorf("start and end are required")
+	}
+	if q.Start >= q.End {
+		return fmt.Errorf("start must be before end")
+	}
+	if q.Limit < 0 || q.Limit > model.MaxStateHistoryLimit {
+		return fmt.Errorf("limit must be between 0 and %d", model.MaxStateHistoryLimit)
+	}
+	return q.SortBy.Validate()
+}
+
+// OffendingSeries is how often the series of the rule started firing in the time range
+// and for how long it fired
+type OffendingSeries struct {
+	Fingerprint uint64             `json:"fingerprint"`
+	Labels      model.LabelsString `json:"labels"`
+	Firings     int                `json:"firings"`
+	// FiringSeconds is the time the series fired in the time range, LongestFiringSeconds
+	// the longest it fired at once
+	FiringSeconds        float64 `json:"firingSeconds"`
+	LongestFiringSeconds float64 `json:"longestFiringSeconds"`
+}
+
+// TopOffenders is the series of the rule firing the most in the time range, Total
+// counts the series that fired before the limit
+type TopOffenders struct {
+	Start  int64             `json:"start"`
+	End    int64             `json:"end"`
+	Total  int               `json:"total"`
+	Series []OffendingSeries `json:"series"`
+}
+
+// TopOffendingSeries returns the label sets of the rule that fired the most often or the
+// longest in the time range, such as the worst endpoints of a latency alert
+func (m *Manager) TopOffendingSeries(ctx context.Context, ruleID string, params *QueryTopOffenders) (*TopOffenders, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if err := m.ruleDB.CheckRulePermission(ctx, ruleID, RulePermissionView); err != nil {
+		return nil, err
+	}
+	changes, err := m.reader.GetRuleStateChanges(ctx, []string{ruleID}, params.Start, params.End)
+	if err != nil {
+		return nil, err
+	}
+
+	series := rankOffenders(changes, params.Start, params.End, params.SortBy)
+	result := &TopOffenders{Start: params.Start, End: params.End, Total: len(series), Series: series}
+	if params.Limit > 0 && len(result.Series) > params.Limit {
+		result.Series = result.Series[:params.Limit]
+	}
+	return result, nil
+}
+
+// rankOffenders folds the state changes into the firings and the firing time of every
+// series in the time range, the series that never fired in it are left out
+func rankOffenders(changes []model.RuleStateHistory, start, end int64, sortBy OffenderSort) []OffendingSeries {
+	firings := make(map[uint64]int)
+	for _, change := range changes {
+		if change.State == model.StateFiring && change.UnixMilli >= start && change.UnixMilli < end {
+			firings[change.Fingerprint]++
+		}
+	}
+
+	offenders := []OffendingSeries{}
+	for _, timeline := range buildStateTimelines(changes, start, end) {
+		offender := OffendingSeries{Fingerprint: timeline.Fingerprint, Labels: timeline.Labels, Firings: firings[timeline.Fingerprint]}
+		for _, segment := range timeline.Segments {
+			if segment.State != model.StateFiring {
+				continue
+			}
+			seconds := float64(segment.End-segment.Start) / 1000
+			offender.FiringSeconds += seconds
+			offender.LongestFiringSeconds = max(offender.LongestFiringSeconds, seconds)
+		}
+		if offender.Firings > 0 || offender.FiringSeconds > 0 {
+			offenders = append(offenders, offender)
+		}
+	}
+
+	sort.SliceStable(offenders, func(i, j int) bool {
+		a, b := offenders[i], offenders[j]
+		if sortBy == OffenderSortFiringDuration && a.FiringSeconds != b.FiringSeconds {
+			return a.FiringSeconds > b.FiringSeconds
+		}
+		if a.Firings != b.Firings {
+			return a.Firings > b.Firings
+		}
+		if a.FiringSeconds != b.FiringSeconds {
+			return a.FiringSeconds > b.FiringSeconds
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+	return offenders
+}
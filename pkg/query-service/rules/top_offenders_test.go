@@ -0,0 +1,33 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.signoz.io/signoz/pkg/query-service/model"
+)
+
+func TestRankOffenders(t *testing.T) {
+	changes := []model.RuleStateHistory{
+		// firing since before the range
+		{Fingerprint: 1, State: model.StateFiring, UnixMilli: 0, Labels: `{"endpoint":"/checkout"}`},
+		{Fingerprint: 1, State: model.StateInactive, UnixMilli: 60000},
+		{Fingerprint: 2, State: model.StateFiring, UnixMilli: 20000, Labels: `{"endpoint":"/cart"}`},
+		{Fingerprint: 2, State: model.StateInactive, UnixMilli: 25000},
+		{Fingerprint: 2, State: model.StateFiring, UnixMilli: 30000},
+		{Fingerprint: 2, State: model.StateInactive, UnixMilli: 32000},
+		// never fired
+		{Fingerprint: 3, State: model.StatePending, UnixMilli: 40000, Labels: `{"endpoint":"/home"}`},
+	}
+
+	offenders := rankOffenders(changes, 10000, 100000, OffenderSortFirings)
+	if assert.Len(t, offenders, 2) {
+		assert.Equal(t, OffendingSeries{Fingerprint: 2, Labels: `{"endpoint":"/cart"}`, Firings: 2, FiringSeconds: 7, LongestFiringSeconds: 5}, offenders[0])
+		assert.Equal(t, OffendingSeries{Fingerprint: 1, Labels: `{"endpoint":"/checkout"}`, Firings: 0, FiringSeconds: 50, LongestFiringSeconds: 50}, offenders[1])
+	}
+
+	offenders = rankOffenders(changes, 10000, 100000, OffenderSortFiringDuration)
+	if assert.Len(t, offenders, 2) {
+		assert.Equal(t, uint64(1), offenders[0].Fingerprint)
+	}
+}
@@ -0,0 +1,419 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+	"go.uber.org/zap"
+)
+
+const (
+	twilioAPIURL = "https://api.twilio.com"
+	// twilioSmsLength is the max length of the body of the text message
+	twilioSmsLength = 1600
+	// TwilioSignatureHeader carries the signature of the status callbacks made by twilio
+	TwilioSignatureHeader = "X-Twilio-Signature"
+
+	defaultTwilioCallLimit = 100
+)
+
+// The types of the twilio configs
+const (
+	TwilioTypeSms   = "sms"
+	TwilioTypeVoice = "voice"
+)
+
+var (
+	ErrMissingTwilioCredentials = errors.New("twilio channel must have an account sid and an auth token")
+	ErrInvalidTwilioSignature   = errors.New("invalid twilio request signature")
+)
+
+// TwilioConfig texts or calls the recipients about the alerts through Twilio. Only the
+// critical alerts are notified by default, the alerts firing during the quiet hours are
+// not notified.
+type TwilioConfig struct {
+	AccountSid string `json:"account_sid"`
+	AuthToken  string `json:"auth_token"`
+	// Type is sms or voice
+	Type string `json:"type"`
+	// From is the twilio phone number, To are the phone numbers of the recipients
+	From string   `json:"from"`
+	To   []string `json:"to"`
+	// Severities are the severity labels of the alerts notified, critical by default
+	Severities []string `json:"severities,omitempty"`
+	// QuietHours are the windows during which the alerts are not notified
+	QuietHours *ActiveSchedule `json:"quiet_hours,omitempty"`
+	// SendResolved texts the resolved alerts, true by default, the resolved alerts are never called
+	SendResolved *bool `json:"send_resolved,omitempty"`
+	// StatusCallbackURL is the url of SigNoz reachable by twilio, the calls report
+	// whether they were answered to it when it's set
+	StatusCallbackURL string `json:"status_callback_url,omitempty"`
+	// URL is the api endpoint, the twilio endpoint by default
+	URL string `json:"url,omitempty"`
+}
+
+// TwilioCall is the voice call made for an alert, its status is reported by the status callback
+type TwilioCall struct {
+	Id          string `json:"id" db:"id"`
+	Channel     string `json:"channel" db:"channel"`
+	AccountSid  string `json:"-" db:"account_sid"`
+	Recipient   string `json:"recipient" db:"recipient"`
+	RuleId      string `json:"ruleId" db:"rule_id"`
+	Fingerprint string `json:"fingerprint" db:"fingerprint"`
+	CallbackURL string `json:"-" db:"callback_url"`
+	CallSid     string `json:"callSid" db:"call_sid"`
+	// Status is initiated until twilio reports the status of the call, e.g
+	// completed, busy, no-answer or failed
+	Status    string    `json:"status" db:"status"`
+	Answered  bool      `json:"answered" db:"answered"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// TwilioCallFilter selects the calls, the empty fields match all the calls
+type TwilioCallFilter struct {
+	Channel     string
+	Fingerprint string
+	// Limit is the max number of calls returned, latest first
+	Limit int
+}
+
+const twilioCallColumns = "id, channel, account_sid, recipient, rule_id, fingerprint, callback_url, call_sid, status, answered, created_at, updated_at"
+
+func (r *ruleDB) CreateTwilioCall(ctx context.Context, call TwilioCall) error {
+	call.CreatedAt = call.CreatedAt.UTC()
+	call.UpdatedAt = call.CreatedAt
+	_, err := r.NamedExec(`INSERT INTO twilio_calls (`+twilioCallColumns+`)
+		VALUES (:id, :channel, :account_sid, :recipient, :rule_id, :fingerprint, :callback_url, :call_sid, :status, :answered, :created_at, :updated_at)`, call)
+	if err != nil {
+		zap.L().Error("Error in Executing INSERT to twilio_calls", zap.Error(err))
+	}
+	return err
+}
+
+func (r *ruleDB) GetTwilioCall(ctx context.Context, id string) (*TwilioCall, error) {
+	call := &TwilioCall{}
+	if err := r.Get(call, "SELECT "+twilioCallColumns+" FROM twilio_calls WHERE id=$1", id); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+func (r *ruleDB) GetTwilioCalls(ctx context.Context, filter *TwilioCallFilter) ([]TwilioCall, error) {
+	if filter == nil {
+		filter = &TwilioCallFilter{}
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultTwilioCallLimit
+	}
+	q := newSelectQuery("SELECT " + twilioCallColumns + " FROM twilio_calls")
+	if filter.Channel != "" {
+		q.where("channel=?", filter.Channel)
+	}
+	if filter.Fingerprint != "" {
+		q.where("fingerprint=?", filter.Fingerprint)
+	}
+	query, args := q.order("created_at DESC").page(limit, 0).build()
+
+	calls := []TwilioCall{}
+	if err := r.Select(&calls, query, args...); err != nil {
+		zap.L().Error("Error in processing sql query", zap.Error(err))
+		return nil, err
+	}
+	return calls, nil
+}
+
+func (r *ruleDB) UpdateTwilioCallStatus(ctx context.Context, call TwilioCall) error {
+	call.UpdatedAt = time.Now().UTC()
+	_, err := r.NamedExec(`UPDATE twilio_calls SET call_sid=:call_sid, status=:status, answered=:answered,
+		updated_at=:updated_at WHERE id=:id`, call)
+	if err != nil {
+		zap.L().Error("Error in Executing UPDATE to twilio_calls", zap.Error(err))
+	}
+	return err
+}
+
+// TwilioSignature signs the request twilio made to the url with the params, see
+// https://www.twilio.com/docs/usage/security#validating-requests
+func TwilioSignature(authToken, url string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(url))
+	for _, key := range keys {
+		for _, value := range params[key] {
+			mac.Write([]byte(key + value))
+		}
+	}
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// twilioCallAnswered reports whether the call was answered by a person, the
+// answering machines are only detected when the detection is enabled in twilio
+func twilioCallAnswered(status, answeredBy string) bool {
+	return status == "completed" && !strings.HasPrefix(answeredBy, "machine")
+}
+
+// notifies reports whether the alert is texted or called at the time
+func (config *TwilioConfig) notifies(alert *Alert, now time.Time) bool {
+	if !alert.ResolvedAt.IsZero() {
+		if config.Type == TwilioTypeVoice || (config.SendResolved != nil && !*config.SendResolved) {
+			return false
+		}
+	}
+	severities := config.Severities
+	if len(severities) == 0 {
+		severities = []string{"critical"}
+	}
+	severity := strings.ToLower(alert.Labels.Get("severity"))
+	if !slices.ContainsFunc(severities, func(s string) bool { return strings.ToLower(s) == severity }) {
+		return false
+	}
+	return config.QuietHours == nil || !config.QuietHours.isActive(now)
+}
+
+// message returns the text of the alert, the title and body of the template of the
+// channel when it has one
+func (config *TwilioConfig) message(channel *deliveredChannel, alert *Alert) (string, error) {
+	title := labelsMap(alert.Annotations)[labels.AlertSummaryLabel]
+	if title == "" {
+		title = alert.Labels.Get(labels.AlertNameLabel)
+	}
+	var body string
+	rendered, err := channel.renderAlertTemplate(alert, ChannelTemplateText)
+	if err != nil {
+		return "", err
+	}
+	if rendered != nil {
+		title, body = rendered.Title, rendered.Body
+	}
+
+	status := "FIRING"
+	if !alert.ResolvedAt.IsZero() {
+		status = "RESOLVED"
+	}
+	message := fmt.Sprintf("[%s] %s", status, title)
+	if body != "" && config.Type == TwilioTypeSms {
+		message += "\n" + body
+	}
+	if len(message) > twilioSmsLength {
+		message = message[:twilioSmsLength]
+	}
+	return message, nil
+}
+
+// twiml returns the instructions of the call saying the message twice
+func twiml(message string) string {
+	var b bytes.Buffer
+	b.WriteString(`<Response><Say loop="2">`)
+	_ = xml.EscapeText(&b, []byte("SigNoz alert. "+message))
+	b.WriteString(`</Say></Response>`)
+	return b.String()
+}
+
+// twilioCallStatusURL is the url the status of the call is reported to
+func twilioCallStatusURL(base, id string) string {
+	return strings.TrimSuffix(base, "/") + "/api/v1/twilio/calls/" + id + "/status"
+}
+
+type twilioSender struct{}
+
+func (twilioSender) configured(receiver *am.Receiver) bool {
+	return receiver.TwilioConfigs != nil
+}
+
+func (twilioSender) configs(receiver *am.Receiver) ([]TwilioConfig, error) {
+	var configs []TwilioConfig
+	if err := decodeConfigs(receiver.TwilioConfigs, &configs); err != nil {
+		return nil, fmt.Errorf("invalid twilio configs: %w", err)
+	}
+	return configs, nil
+}
+
+func (s twilioSender) validate(receiver *am.Receiver) error {
+	configs, err := s.configs(receiver)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if config.AccountSid == "" || config.AuthToken == "" {
+			return ErrMissingTwilioCredentials
+		}
+		if config.Type != TwilioTypeSms && config.Type != TwilioTypeVoice {
+			return fmt.Errorf("invalid twilio type %q, must be sms or voice", config.Type)
+		}
+		if config.From == "" || len(config.To) == 0 {
+			return fmt.Errorf("twilio channel must have the from and to phone numbers")
+		}
+		if err := config.QuietHours.Validate(); err != nil {
+			return fmt.Errorf("invalid twilio quiet hours: %w", err)
+		}
+		if config.StatusCallbackURL != "" {
+			u, err := url.Parse(config.StatusCallbackURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid twilio status callback url %q", config.StatusCallbackURL)
+			}
+		}
+	}
+	return nil
+}
+
+func (s twilioSender) send(ctx context.Context, d *channelDelivery, channel *deliveredChannel, alerts []*Alert) error {
+	configs, err := s.configs(channel.receiver)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var errs []error
+	for i := range configs {
+		config := &configs[i]
+		api := config.URL
+		if api == "" {
+			api = twilioAPIURL
+		}
+		resource := "Messages.json"
+		if config.Type == TwilioTypeVoice {
+			resource = "Calls.json"
+		}
+		endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/%s", strings.TrimSuffix(api, "/"), url.PathEscape(config.AccountSid), resource)
+		headers := map[string]string{
+			"Content-Type":  "application/x-www-form-urlencoded",
+			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(config.AccountSid+":"+config.AuthToken)),
+		}
+
+		for _, alert := range alerts {
+			if !config.notifies(alert, now) {
+				continue
+			}
+			message, err := config.message(channel, alert)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			fingerprint := fmt.Sprintf("%016x", alert.Labels.Hash())
+			for _, to := range config.To {
+				form := url.Values{"From": {config.From}, "To": {to}}
+				if config.Type == TwilioTypeVoice {
+					form.Set("Twiml", twiml(message))
+					if callbackURL := d.createTwilioCall(ctx, channel, config, to, alert, now); callbackURL != "" {
+						form.Set("StatusCallback", callbackURL)
+					}
+				} else {
+					form.Set("Body", message)
+				}
+				err := d.send(ctx, &deliveryRequest{
+					Channel:      channel.name,
+					ChannelType:  "twilio_" + config.Type,
+					URL:          endpoint,
+					Body:         []byte(form.Encode()),
+					Headers:      headers,
+					Fingerprints: []string{fingerprint},
+					Alerts:       notifiedAlerts([]*Alert{alert}),
+					Retries:      defaultDeliveryRetries,
+				})
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// createTwilioCall stores the call made to the recipient and returns the url its
+// status is reported to, the call isn't tracked when the config has no callback url
+func (d *channelDelivery) createTwilioCall(ctx context.Context, channel *deliveredChannel, config *TwilioConfig, to string, alert *Alert, now time.Time) string {
+	// the test calls are not tracked
+	if config.StatusCallbackURL == "" || d.test != nil {
+		return ""
+	}
+	call := TwilioCall{
+		Id:          uuid.New().String(),
+		Channel:     channel.name,
+		AccountSid:  config.AccountSid,
+		Recipient:   to,
+		RuleId:      alert.Labels.Get(labels.AlertRuleIdLabel),
+		Fingerprint: fmt.Sprintf("%016x", alert.Labels.Hash()),
+		Status:      "initiated",
+		CreatedAt:   now,
+	}
+	call.CallbackURL = twilioCallStatusURL(config.StatusCallbackURL, call.Id)
+	if err := d.ruleDB.CreateTwilioCall(ctx, call); err != nil {
+		zap.L().Error("failed to store the twilio call", zap.String("channel", channel.name), zap.Error(err))
+		return ""
+	}
+	return call.CallbackURL
+}
+
+// twilioConfig returns the twilio config of the channel with the account sid
+func (m *Manager) twilioConfig(channelName, accountSid string) (*TwilioConfig, error) {
+	channels, apiErr := m.ruleDB.GetChannels()
+	if apiErr != nil {
+		return nil, apiErr.Err
+	}
+	for _, c := range *channels {
+		if c.Name != channelName {
+			continue
+		}
+		receiver := &am.Receiver{}
+		if err := json.Unmarshal([]byte(c.Data), receiver); err != nil {
+			return nil, err
+		}
+		configs, err := twilioSender{}.configs(receiver)
+		if err != nil {
+			return nil, err
+		}
+		for i := range configs {
+			if configs[i].AccountSid == accountSid {
+				return &configs[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: the channel %s has no twilio config", ErrInvalidTwilioSignature, channelName)
+}
+
+// HandleTwilioCallStatus records the status of the call reported by twilio, the request
+// is verified with the auth token of the config that made the call
+func (m *Manager) HandleTwilioCallStatus(ctx context.Context, id, signature string, params url.Values) (*TwilioCall, error) {
+	call, err := m.ruleDB.GetTwilioCall(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	config, err := m.twilioConfig(call.Channel, call.AccountSid)
+	if err != nil {
+		return nil, err
+	}
+	expected := TwilioSignature(config.AuthToken, call.CallbackURL, params)
+	if signature == "" || !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, ErrInvalidTwilioSignature
+	}
+
+	call.CallSid = params.Get("CallSid")
+	call.Status = params.Get("CallStatus")
+	call.Answered = twilioCallAnswered(call.Status, params.Get("AnsweredBy"))
+	if err := m.ruleDB.UpdateTwilioCallStatus(ctx, *call); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestTwilioNotifies(t *testing.T) {
+	now := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	critical := &Alert{Labels: labels.FromMap(map[string]string{"alertname": "Errors", "severity": "Critical"})}
+	warning := &Alert{Labels: labels.FromMap(map[string]string{"alertname": "Errors", "severity": "warning"})}
+	resolved := *critical
+	resolved.ResolvedAt = now
+
+	sms := &TwilioConfig{Type: TwilioTypeSms}
+	assert.True(t, sms.notifies(critical, now))
+	assert.False(t, sms.notifies(warning, now))
+	assert.True(t, sms.notifies(&resolved, now))
+
+	voice := &TwilioConfig{Type: TwilioTypeVoice, Severities: []string{"critical", "warning"}}
+	assert.True(t, voice.notifies(warning, now))
+	assert.False(t, voice.notifies(&resolved, now))
+
+	// the alerts are not notified during the quiet hours
+	voice.QuietHours = &ActiveSchedule{Timezone: "UTC", Windows: []ActiveWindow{{StartTime: "22:00", EndTime: "07:00"}}}
+	assert.False(t, voice.notifies(critical, now))
+	assert.True(t, voice.notifies(critical, now.Add(9*time.Hour)))
+
+	message, err := sms.message(&deliveredChannel{name: "oncall"}, &resolved)
+	assert.NoError(t, err)
+	assert.Equal(t, "[RESOLVED] Errors", message)
+	assert.Equal(t, `<Response><Say loop="2">SigNoz alert. [FIRING] cart &amp; checkout</Say></Response>`, twiml("[FIRING] cart & checkout"))
+}
+
+func TestTwilioValidate(t *testing.T) {
+	config := func(fields map[string]interface{}) *am.Receiver {
+		c := map[string]interface{}{"account_sid": "AC1", "auth_token": "token", "type": "sms", "from": "+15550100", "to": []string{"+15550101"}}
+		for name, value := range fields {
+			c[name] = value
+		}
+		return &am.Receiver{Name: "twilio", TwilioConfigs: []interface{}{c}}
+	}
+	assert.Nil(t, validateDeliveredConfigs(config(nil)))
+	assert.ErrorIs(t, validateDeliveredConfigs(config(map[string]interface{}{"auth_token": ""})).Err, ErrMissingTwilioCredentials)
+	assert.NotNil(t, validateDeliveredConfigs(config(map[string]interface{}{"type": "fax"})))
+	assert.NotNil(t, validateDeliveredConfigs(config(map[string]interface{}{"to": []string{}})))
+	assert.NotNil(t, validateDeliveredConfigs(config(map[string]interface{}{"quiet_hours": map[string]interface{}{"timezone": "UTC"}})))
+	assert.NotNil(t, validateDeliveredConfigs(config(map[string]interface{}{"status_callback_url": "signoz"})))
+}
+
+func TestTwilioCall(t *testing.T) {
+	requests := make(chan url.Values, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "AC1", user)
+		assert.Equal(t, "token", password)
+		assert.Equal(t, "/2010-04-01/Accounts/AC1/Calls.json", r.URL.Path)
+		assert.NoError(t, r.ParseForm())
+		requests <- r.PostForm
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	m := newTestManager(t)
+	db := m.ruleDB.(*ruleDB)
+	receiver, _ := json.Marshal(am.Receiver{Name: "oncall", TwilioConfigs: []interface{}{map[string]interface{}{
+		"account_sid": "AC1", "auth_token": "token", "type": "voice", "from": "+15550100", "to": []string{"+15550101"},
+		"url": server.URL, "status_callback_url": "https://signoz.example.com/",
+	}}})
+	_, err := db.Exec(`INSERT INTO notification_channels (created_at, updated_at, name, type, data) VALUES($1,$1,'oncall','twilio',$2);`, time.Now(), string(receiver))
+	assert.NoError(t, err)
+
+	critical := &Alert{Labels: labels.FromMap(map[string]string{"alertname": "Errors", "severity": "critical"})}
+	newChannelDelivery(db).deliver(context.Background(), []*Alert{critical})
+
+	var form url.Values
+	select {
+	case form = <-requests:
+		assert.Equal(t, "+15550101", form.Get("To"))
+		assert.Contains(t, form.Get("Twiml"), "[FIRING] Errors")
+	case <-time.After(time.Second):
+		t.Fatal("the alert was not called")
+	}
+
+	calls, err := db.GetTwilioCalls(context.Background(), &TwilioCallFilter{Channel: "oncall"})
+	assert.NoError(t, err)
+	if !assert.Len(t, calls, 1) {
+		return
+	}
+	assert.Equal(t, "initiated", calls[0].Status)
+	assert.Equal(t, "https://signoz.example.com/api/v1/twilio/calls/"+calls[0].Id+"/status", form.Get("StatusCallback"))
+
+	// the status callback signed by twilio records the answered call
+	params := url.Values{"CallSid": {"CA1"}, "CallStatus": {"completed"}, "AnsweredBy": {"human"}}
+	_, err = m.HandleTwilioCallStatus(context.Background(), calls[0].Id, "forged", params)
+	assert.ErrorIs(t, err, ErrInvalidTwilioSignature)
+	call, err := m.HandleTwilioCallStatus(context.Background(), calls[0].Id, TwilioSignature("token", form.Get("StatusCallback"), params), params)
+	assert.NoError(t, err)
+	assert.True(t, call.Answered)
+
+	call, err = db.GetTwilioCall(context.Background(), calls[0].Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "CA1", call.CallSid)
+	assert.Equal(t, "completed", call.Status)
+	assert.True(t, call.Answered)
+	assert.False(t, twilioCallAnswered("no-answer", ""))
+	assert.False(t, twilioCallAnswered("completed", "machine_start"))
+}
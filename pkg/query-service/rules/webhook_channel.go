@@ -0,0 +1,225 @@
+package rules
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+// webhookSignatureHeader carries the HMAC-SHA256 of the payload signed with the secret of the channel
+const webhookSignatureHeader = "X-Signature"
+
+var ErrInvalidWebhookURL = errors.New("webhook channel must have a http or https url")
+
+// WebhookConfig sends the alerts to the webhook in the payload of the alertmanager
+// webhooks. The webhook with a secret or headers is delivered by the query service,
+// the others are left to the alertmanager
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// SendResolved sends the resolved alerts, true by default
+	SendResolved *bool `json:"send_resolved,omitempty"`
+	// MaxAlerts is the max number of alerts in the payload, 0 sends all the alerts
+	MaxAlerts int `json:"max_alerts,omitempty"`
+	// Secret signs the payload, the signature is sent in the X-Signature header
+	Secret string `json:"secret,omitempty"`
+	// Headers are the custom headers set on the requests
+	Headers map[string]string `json:"headers,omitempty"`
+	// MaxRetries is how many times the request failing with 5xx is retried, 3 by default
+	MaxRetries *int `json:"max_retries,omitempty"`
+}
+
+type webhookPayload struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []webhookAlert    `json:"alerts"`
+}
+
+type webhookAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// webhookSignature signs the payload with the secret of the channel
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type webhookSender struct{}
+
+func (webhookSender) configured(receiver *am.Receiver) bool {
+	configs, ok := receiver.WebhookConfigs.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, config := range configs {
+		if am.DeliveredWebhook(config) {
+			return true
+		}
+	}
+	return false
+}
+
+// configs returns the webhook configs delivered by the query service
+func (webhookSender) configs(receiver *am.Receiver) ([]WebhookConfig, error) {
+	list, _ := receiver.WebhookConfigs.([]interface{})
+	delivered := []interface{}{}
+	for _, config := range list {
+		if am.DeliveredWebhook(config) {
+			delivered = append(delivered, config)
+		}
+	}
+	var configs []WebhookConfig
+	if err := decodeConfigs(delivered, &configs); err != nil {
+		return nil, fmt.Errorf("invalid webhook configs: %w", err)
+	}
+	return configs, nil
+}
+
+func (s webhookSender) validate(receiver *am.Receiver) error {
+	configs, err := s.configs(receiver)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		u, err := url.Parse(config.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return ErrInvalidWebhookURL
+		}
+		if config.MaxRetries != nil && (*config.MaxRetries < 0 || *config.MaxRetries > maxDeliveryRetries) {
+			return fmt.Errorf("webhook max_retries must be between 0 and %d", maxDeliveryRetries)
+		}
+		for name := range config.Headers {
+			if http.CanonicalHeaderKey(name) == webhookSignatureHeader {
+				return fmt.Errorf("webhook header %s is set by the signature", webhookSignatureHeader)
+			}
+		}
+	}
+	return nil
+}
+
+// payload returns the body sent to the webhook, the json template of the channel
+// replaces the alertmanager payload when the channel has one
+func (config *WebhookConfig) payload(channel *deliveredChannel, alerts []*Alert) ([]byte, error) {
+	data := newNotificationData(channel.name, alerts).localize(channel.receiver)
+	if channel.template != nil {
+		t := *channel.template
+		t.Format = ChannelTemplateJSON
+		rendered, err := t.render(data)
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", channel.name, err)
+		}
+		return []byte(rendered.Body), nil
+	}
+
+	payload := webhookPayload{
+		Version:           "4",
+		GroupKey:          "{}:" + labels.FromMap(data.GroupLabels).String(),
+		Status:            data.Status,
+		Receiver:          channel.name,
+		GroupLabels:       data.GroupLabels,
+		CommonLabels:      data.CommonLabels,
+		CommonAnnotations: data.CommonAnnotations,
+		ExternalURL:       data.ExternalURL,
+		Alerts:            make([]webhookAlert, 0, len(data.Alerts)),
+	}
+	for i, a := range data.Alerts {
+		if config.MaxAlerts > 0 && i >= config.MaxAlerts {
+			payload.TruncatedAlerts = len(data.Alerts) - config.MaxAlerts
+			break
+		}
+		payload.Alerts = append(payload.Alerts, webhookAlert{
+			Status:       a.Status,
+			Labels:       a.Labels,
+			Annotations:  a.Annotations,
+			StartsAt:     a.StartsAt,
+			EndsAt:       a.EndsAt,
+			GeneratorURL: a.GeneratorURL,
+			Fingerprint:  a.Fingerprint,
+		})
+	}
+	return json.Marshal(payload)
+}
+
+func (s webhookSender) send(ctx context.Context, d *channelDelivery, channel *deliveredChannel, alerts []*Alert) error {
+	configs, err := s.configs(channel.receiver)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range configs {
+		config := &configs[i]
+		sent := alerts
+		if config.SendResolved != nil && !*config.SendResolved {
+			sent = make([]*Alert, 0, len(alerts))
+			for _, alert := range alerts {
+				if alert.ResolvedAt.IsZero() {
+					sent = append(sent, alert)
+				}
+			}
+		}
+		if len(sent) == 0 {
+			continue
+		}
+
+		body, err := config.payload(channel, sent)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		headers := make(map[string]string, len(config.Headers)+1)
+		for name, value := range config.Headers {
+			headers[name] = value
+		}
+		if config.Secret != "" {
+			headers[webhookSignatureHeader] = webhookSignature(config.Secret, body)
+		}
+		retries := defaultDeliveryRetries
+		if config.MaxRetries != nil {
+			retries = *config.MaxRetries
+		}
+		fingerprints := make([]string, 0, len(sent))
+		for _, alert := range sent {
+			fingerprints = append(fingerprints, fmt.Sprintf("%016x", alert.Labels.Hash()))
+		}
+
+		err = d.send(ctx, &deliveryRequest{
+			Channel:      channel.name,
+			ChannelType:  "webhook",
+			URL:          config.URL,
+			Body:         body,
+			Headers:      headers,
+			Fingerprints: fingerprints,
+			Alerts:       notifiedAlerts(sent),
+			Retries:      retries,
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
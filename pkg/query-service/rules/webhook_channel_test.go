@@ -0,0 +1,119 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	am "go.signoz.io/signoz/pkg/query-service/integrations/alertManager"
+	"go.signoz.io/signoz/pkg/query-service/utils"
+	"go.signoz.io/signoz/pkg/query-service/utils/labels"
+)
+
+func TestWebhookConfigs(t *testing.T) {
+	receiver := &am.Receiver{Name: "webhook", WebhookConfigs: []interface{}{
+		map[string]interface{}{"url": "http://plain"},
+		map[string]interface{}{"url": "http://signed", "secret": "s3cret"},
+		map[string]interface{}{"url": "http://headers", "headers": map[string]interface{}{"Authorization": "Bearer token"}},
+	}}
+	assert.True(t, webhookSender{}.configured(receiver))
+
+	// the plain webhook is left to the alertmanager
+	configs, err := webhookSender{}.configs(receiver)
+	assert.NoError(t, err)
+	assert.Len(t, configs, 2)
+	assert.Equal(t, "s3cret", configs[0].Secret)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer token"}, configs[1].Headers)
+
+	assert.False(t, webhookSender{}.configured(&am.Receiver{Name: "webhook", WebhookConfigs: []interface{}{map[string]interface{}{"url": "http://plain"}}}))
+}
+
+func TestWebhookValidate(t *testing.T) {
+	valid := map[string]interface{}{"url": "https://hooks.example.com", "secret": "s3cret", "max_retries": 5}
+	assert.Nil(t, validateDeliveredConfigs(&am.Receiver{Name: "webhook", WebhookConfigs: []interface{}{valid}}))
+
+	invalid := []map[string]interface{}{
+		{"url": "ftp://hooks.example.com", "secret": "s3cret"},
+		{"url": "https://hooks.example.com", "secret": "s3cret", "max_retries": 11},
+		{"url": "https://hooks.example.com", "headers": map[string]interface{}{"x-signature": "forged"}},
+	}
+	for _, config := range invalid {
+		assert.NotNil(t, validateDeliveredConfigs(&am.Receiver{Name: "webhook", WebhookConfigs: []interface{}{config}}), config)
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	var requests atomic.Int32
+	bodies := make(chan []byte, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, webhookSignature("s3cret", body), r.Header.Get("X-Signature"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/flaky":
+			if requests.Add(1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+		case "/rejected":
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		bodies <- body
+	}))
+	defer server.Close()
+
+	db := NewRuleDB(utils.NewQueryServiceDBForTests(t), nil).(*ruleDB)
+	delivery := newChannelDelivery(db)
+	delivery.backoff = time.Millisecond
+
+	alert := &Alert{
+		Labels:  labels.FromMap(map[string]string{"alertname": "Errors", "service": "cart"}),
+		FiredAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+	channel := func(name, path string) *deliveredChannel {
+		return &deliveredChannel{name: name, receiver: &am.Receiver{Name: name, WebhookConfigs: []interface{}{map[string]interface{}{
+			"url":     server.URL + path,
+			"secret":  "s3cret",
+			"headers": map[string]interface{}{"Authorization": "Bearer token"},
+		}}}}
+	}
+
+	// the 5xx is retried
+	assert.NoError(t, webhookSender{}.send(context.Background(), delivery, channel("flaky", "/flaky"), []*Alert{alert}))
+	var payload webhookPayload
+	assert.NoError(t, json.Unmarshal(<-bodies, &payload))
+	assert.Equal(t, "4", payload.Version)
+	assert.Equal(t, "firing", payload.Status)
+	assert.Equal(t, `{}:{alertname="Errors"}`, payload.GroupKey)
+	assert.Equal(t, "cart", payload.Alerts[0].Labels["service"])
+	assert.Equal(t, pagerdutyDedupKey(alert), payload.Alerts[0].Fingerprint)
+
+	attempts, err := db.GetDeliveryAttempts(context.Background(), &DeliveryAttemptFilter{Channel: "flaky"})
+	assert.NoError(t, err)
+	if assert.Len(t, attempts, 2) {
+		assert.Equal(t, 2, attempts[0].Attempt)
+		assert.Equal(t, DeliveryStatusSuccess, attempts[0].Status)
+		assert.Equal(t, 1, attempts[1].Attempt)
+		assert.Equal(t, DeliveryStatusFailed, attempts[1].Status)
+		assert.Equal(t, http.StatusBadGateway, attempts[1].StatusCode)
+		assert.Equal(t, DeliveryFingerprints{pagerdutyDedupKey(alert)}, attempts[1].Fingerprints)
+	}
+
+	// the 4xx is not retried
+	assert.Error(t, webhookSender{}.send(context.Background(), delivery, channel("rejected", "/rejected"), []*Alert{alert}))
+	attempts, err = db.GetDeliveryAttempts(context.Background(), &DeliveryAttemptFilter{Channel: "rejected"})
+	assert.NoError(t, err)
+	assert.Len(t, attempts, 1)
+
+	failed, err := db.GetDeliveryAttempts(context.Background(), &DeliveryAttemptFilter{Status: DeliveryStatusFailed})
+	assert.NoError(t, err)
+	assert.Len(t, failed, 2)
+}